@@ -1,32 +1,125 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
-	"github.com/ivanoskov/financial_bot/internal/bot"
+	"os"
+	"os/signal"
+	"syscall"
+	botpkg "github.com/ivanoskov/financial_bot/internal/bot"
+	"github.com/ivanoskov/financial_bot/internal/bot/fake"
+	"github.com/ivanoskov/financial_bot/internal/chaos"
 	"github.com/ivanoskov/financial_bot/internal/config"
+	"github.com/ivanoskov/financial_bot/internal/currency"
+	"github.com/ivanoskov/financial_bot/internal/inflation"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/quotes"
+	"github.com/ivanoskov/financial_bot/internal/receipt"
 	"github.com/ivanoskov/financial_bot/internal/service"
 	"github.com/ivanoskov/financial_bot/internal/repository"
+	"github.com/ivanoskov/financial_bot/internal/sentry"
+	"github.com/ivanoskov/financial_bot/internal/webhook"
 )
 
 func main() {
+	console := flag.Bool("console", false, "писать боту из консоли вместо Telegram, токен не нужен")
+	filesDir := flag.String("files", "", "с -console: каталог для отправленных графиков и документов")
+	flag.Parse()
+	if *console && os.Getenv("TELEGRAM_TOKEN") == "" {
+		// Токен в консольном режиме не используется, но обязателен в конфигурации
+		os.Setenv("TELEGRAM_TOKEN", "0:console")
+	}
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	repo, err := repository.NewSupabaseRepository(cfg.SupabaseURL, cfg.SupabaseKey)
+	supabaseRepo, err := repository.NewSupabaseRepository(cfg.SupabaseURL, cfg.SupabaseKey)
 	if err != nil {
 		log.Fatal(err)
 	}
+	var repo repository.Repository = supabaseRepo
+	if cfg.EncryptionKey != nil {
+		if repo, err = repository.NewEncryptedRepository(supabaseRepo, cfg.EncryptionKey); err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	service := service.NewExpenseTracker(repo)
-	
-	bot, err := bot.NewBot(cfg.TelegramToken, service)
-	if err != nil {
+	// Процесс живет долго, поэтому отчеты кэшируются в памяти
+	reportCache := service.NewMemoryReportCache(service.DefaultReportCacheTTL)
+
+	service := service.NewExpenseTracker(chaos.Wrap(repo))
+	if cfg.ReceiptToken != "" {
+		service.SetReceiptProvider(receipt.NewProverkachekaClient(cfg.ReceiptToken))
+	}
+	if cfg.AttachmentsBucket != "" {
+		service.SetAttachmentStorage(repository.NewSupabaseStorage(cfg.SupabaseURL, cfg.SupabaseKey, cfg.AttachmentsBucket))
+	}
+	if cfg.InflationCPIURL != "" {
+		service.SetInflationProvider(inflation.NewCPIClient(cfg.InflationCPIURL))
+	} else if cfg.InflationRate != 0 {
+		service.SetInflationProvider(inflation.NewFixed(cfg.InflationRate))
+	}
+	service.SetBaseCurrency(cfg.BaseCurrency)
+	service.SetRateProvider(currency.NewCBRClient(cfg.RatesURL))
+	service.SetPriceProvider(model.HoldingKindStock, quotes.NewMOEXClient(cfg.MOEXURL))
+	service.SetPriceProvider(model.HoldingKindCrypto, quotes.NewCoinGeckoClient(cfg.CoinGeckoURL))
+	service.SetWebhookSender(webhook.NewSender())
+	service.SetReportCache(reportCache)
+
+	var bot *botpkg.Bot
+	if *console {
+		// Сообщения пишутся в stdin, ответы печатаются; по концу ввода бот завершается
+		transport := fake.NewTransport(os.Stdout)
+		transport.SaveFilesTo(*filesDir)
+		go transport.Run(os.Stdin)
+		bot = botpkg.NewBotWithTransport(transport, service)
+		cfg.WebhookURL = ""
+	} else if bot, err = botpkg.NewBot(cfg.TelegramToken, service); err != nil {
 		log.Fatal(err)
 	}
+	bot.SetDebug(cfg.LogLevel == config.LogDebug)
+	bot.SetAdmins(cfg.AdminIDs)
+	if cfg.SentryDSN != "" {
+		reporter, err := sentry.NewClient(cfg.SentryDSN)
+		if err != nil {
+			log.Fatal(err)
+		}
+		bot.SetErrorReporter(reporter)
+	}
+
+	// Меню команд не критично для работы бота
+	if err := bot.RegisterCommands(); err != nil {
+		log.Printf("Error registering commands: %v", err)
+	}
 
-	if err := bot.Start(); err != nil {
+	// SIGTERM присылает оркестратор при развертывании: дорабатываем полученные обновления
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		// Повторный сигнал завершает процесс сразу
+		stop()
+		log.Println("Shutting down, waiting for in-flight updates...")
+	}()
+
+	if cfg.WebhookURL != "" {
+		// Webhook-сервер: Telegram сам присылает обновления, при остановке webhook снимается
+		err = bot.StartWebhook(ctx, cfg.ListenAddr, cfg.TLSCertFile, cfg.TLSKeyFile, botpkg.WebhookOptions{
+			URL:         cfg.WebhookURL,
+			Secret:      cfg.WebhookSecret,
+			Certificate: cfg.TLSCertFile,
+		})
+	} else {
+		err = bot.Start(ctx)
+	}
+	if err != nil {
 		log.Fatal(err)
 	}
+
+	// Дожидаемся доставки событий на webhook'и, отправленных последними обновлениями
+	service.WaitWebhooks()
+	log.Println("Bot stopped")
 } 
\ No newline at end of file
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/base64"
+	"time"
+)
+
+// timerEventType - тип сообщения таймера Yandex Cloud Functions
+const timerEventType = "yandex.cloud.events.serverless.triggers.TimerMessage"
+
+// eventSource - кто вызвал функцию
+type eventSource int
+
+const (
+	sourceUnknown eventSource = iota // пустое событие, например тестовый вызов из консоли
+	sourceHTTP                       // API Gateway или HTTP-вызов функции
+	sourceTimer                      // таймер Yandex Cloud Functions
+	sourceTrigger                    // другой триггер: очередь сообщений, хранилище и т.п.
+)
+
+// triggerMessage - сообщение триггера Yandex Cloud Functions:
+// {"messages": [{"event_metadata": {...}, "details": {...}}]}
+type triggerMessage struct {
+	EventMetadata struct {
+		EventID   string    `json:"event_id"`
+		EventType string    `json:"event_type"`
+		CreatedAt time.Time `json:"created_at"`
+	} `json:"event_metadata"`
+	Details struct {
+		TriggerID string `json:"trigger_id"`
+		Payload   string `json:"payload"`
+	} `json:"details"`
+}
+
+// source определяет по конверту события, кто вызвал функцию
+func (r Request) source() eventSource {
+	switch {
+	case len(r.Messages) > 0 && r.Messages[0].EventMetadata.EventType == timerEventType:
+		return sourceTimer
+	case len(r.Messages) > 0:
+		return sourceTrigger
+	case r.HTTPMethod != "" || r.Body != "" || len(r.Headers) > 0:
+		return sourceHTTP
+	}
+	return sourceUnknown
+}
+
+// scheduledAt возвращает время срабатывания таймера в часовом поясе функции. Задания по
+// расписанию считают от него, а не от времени вызова: повторный вызов после сбоя
+// или задержка запуска не сдвигают час отправки отчетов и напоминаний
+func (r Request) scheduledAt() time.Time {
+	if r.source() == sourceTimer {
+		if created := r.Messages[0].EventMetadata.CreatedAt; !created.IsZero() {
+			return created.In(time.Local)
+		}
+	}
+	return time.Now()
+}
+
+// timerPayload возвращает строку payload из настроек таймера, пусто - если вызов не по таймеру
+func (r Request) timerPayload() string {
+	if r.source() != sourceTimer {
+		return ""
+	}
+	return r.Messages[0].Details.Payload
+}
+
+// body возвращает тело HTTP-запроса. API Gateway кодирует тело в base64, если так настроено
+func (r Request) body() ([]byte, error) {
+	if r.IsBase64Encoded {
+		return base64.StdEncoding.DecodeString(r.Body)
+	}
+	return []byte(r.Body), nil
+}
@@ -3,16 +3,35 @@ package main
 import (
 	"context"
 	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/ivanoskov/financial_bot/internal/bot"
+	"github.com/ivanoskov/financial_bot/internal/chaos"
 	"github.com/ivanoskov/financial_bot/internal/config"
 	"github.com/ivanoskov/financial_bot/internal/repository"
 	"github.com/ivanoskov/financial_bot/internal/service"
 )
 
-// Request структура входящего запроса от API Gateway
+// Request - событие вызова функции: запрос от API Gateway или сообщения триггера.
+// Источник определяет Request.source (events.go)
 type Request struct {
-	Body string `json:"body"`
+	HTTPMethod      string            `json:"httpMethod,omitempty"`
+	Body            string            `json:"body"`
+	Headers         map[string]string `json:"headers"`
+	IsBase64Encoded bool              `json:"isBase64Encoded,omitempty"`
+	Messages        []triggerMessage  `json:"messages,omitempty"`
+}
+
+// header возвращает заголовок запроса без учета регистра имени
+func (r Request) header(name string) string {
+	for key, value := range r.Headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
 }
 
 // Response структура ответа для API Gateway
@@ -24,89 +43,378 @@ type Response struct {
 
 // WebhookHandler обрабатывает входящие обновления от Telegram
 func WebhookHandler(ctx context.Context, request Request) (*Response, error) {
-	// Загрузка конфигурации
-	cfg, err := config.LoadConfig()
+	deps, err := getDependencies()
 	if err != nil {
 		return errorResponse(err)
 	}
 
-	// Инициализация репозитория
-	repo, err := repository.NewSupabaseRepository(cfg.SupabaseURL, cfg.SupabaseKey)
+	// Таймер, по ошибке направленный на webhook, не должен выглядеть как пустое обновление
+	if request.source() != sourceHTTP {
+		log.Printf("Rejected webhook call from a non-HTTP event")
+		return &Response{StatusCode: 400, Body: "not a Telegram update"}, nil
+	}
+
+	// Без секрета любой, кто знает адрес функции, мог бы писать от имени пользователей
+	if !bot.ValidSecretToken(request.header(bot.SecretTokenHeader), deps.cfg.WebhookSecret) {
+		log.Printf("Rejected webhook request with invalid secret token")
+		return &Response{StatusCode: 401, Body: "invalid secret token"}, nil
+	}
+
+	body, err := request.body()
 	if err != nil {
+		return &Response{StatusCode: 400, Body: "invalid body encoding"}, nil
+	}
+
+	// Обработка webhook-обновления
+	if err := deps.bot.HandleWebhook(ctx, body); err != nil {
 		return errorResponse(err)
 	}
 
-	// Инициализация сервиса
-	service := service.NewExpenseTracker(repo)
+	// Функция может быть заморожена после ответа, поэтому дожидаемся исходящих webhook'ов
+	deps.tracker.WaitWebhooks()
+
+	return okResponse(""), nil
+}
+
+// DailyReportHandler отправляет ежедневные отчеты. Вызывается ежечасно:
+// сводка уходит пользователям, выбравшим текущий час, кроме их тихих дней
+func DailyReportHandler(ctx context.Context, request Request) (*Response, error) {
+	return sendDigests(ctx, service.DailyReport, "Daily", request.scheduledAt())
+}
+
+// WeeklyReportHandler отправляет еженедельные отчеты (триггер в конце недели)
+func WeeklyReportHandler(ctx context.Context, request Request) (*Response, error) {
+	return sendDigests(ctx, service.WeeklyReport, "Weekly", request.scheduledAt())
+}
 
-	// Инициализация бота
-	bot, err := bot.NewBot(cfg.TelegramToken, service)
+// MonthlyReportHandler отправляет ежемесячные отчеты (триггер в последний день месяца)
+func MonthlyReportHandler(ctx context.Context, request Request) (*Response, error) {
+	return sendDigests(ctx, service.MonthlyReport, "Monthly", request.scheduledAt())
+}
+
+// sendDigests отправляет отчет за период всем пользователям, у которых он включен в настройках
+func sendDigests(ctx context.Context, reportType service.ReportType, name string, now time.Time) (*Response, error) {
+	sent, total, err := forEachUser(ctx, func(deps *dependencies, userID int64) (bool, error) {
+		settings, err := deps.tracker.GetUserSettings(ctx, userID)
+		if err != nil {
+			return false, fmt.Errorf("error getting settings: %w", err)
+		}
+		if !service.DigestDue(settings, reportType, now) {
+			return false, nil
+		}
+
+		if err := deps.bot.SendDigest(ctx, settings, reportType); err != nil {
+			return false, fmt.Errorf("error sending %s report: %w", name, err)
+		}
+		return true, nil
+	})
 	if err != nil {
 		return errorResponse(err)
 	}
+	return okResponse(fmt.Sprintf("%s reports sent to %d of %d users", name, sent, total)), nil
+}
 
-	// Обработка webhook-обновления
-	if err := bot.HandleWebhook([]byte(request.Body)); err != nil {
+// MonthlyClosingHandler присылает итоги прошедшего месяца тем, у кого они включены.
+// Вызывается 1-го числа каждого месяца: итоги подводятся за месяц, в который попадает вчерашний день
+func MonthlyClosingHandler(ctx context.Context, request Request) (*Response, error) {
+	month := request.scheduledAt().AddDate(0, 0, -1)
+	sent, total, err := forEachUser(ctx, func(deps *dependencies, userID int64) (bool, error) {
+		settings, err := deps.tracker.GetUserSettings(ctx, userID)
+		if err != nil {
+			return false, fmt.Errorf("error getting settings: %w", err)
+		}
+		if !settings.MonthlyClosing {
+			return false, nil
+		}
+
+		ok, err := deps.bot.SendMonthClosing(ctx, userID, month)
+		if err != nil {
+			return false, fmt.Errorf("error sending month closing: %w", err)
+		}
+		return ok, nil
+	})
+	if err != nil {
 		return errorResponse(err)
 	}
+	return okResponse(fmt.Sprintf("Month closings sent to %d of %d users", sent, total)), nil
+}
 
-	return &Response{
-		StatusCode: 200,
-		Body:       "",
-		Headers: map[string]string{
-			"Content-Type": "application/json",
-		},
-	}, nil
+// YearReviewHandler присылает итоги прошедшего года с графиками тем, у кого они включены.
+// Вызывается 1 января: итоги подводятся за год, в который попадает вчерашний день
+func YearReviewHandler(ctx context.Context, request Request) (*Response, error) {
+	year := request.scheduledAt().AddDate(0, 0, -1).Year()
+	sent, total, err := forEachUser(ctx, func(deps *dependencies, userID int64) (bool, error) {
+		settings, err := deps.tracker.GetUserSettings(ctx, userID)
+		if err != nil {
+			return false, fmt.Errorf("error getting settings: %w", err)
+		}
+		if !settings.YearReview {
+			return false, nil
+		}
+
+		ok, err := deps.bot.SendYearReview(ctx, userID, year)
+		if err != nil {
+			return false, fmt.Errorf("error sending year review: %w", err)
+		}
+		return ok, nil
+	})
+	if err != nil {
+		return errorResponse(err)
+	}
+	return okResponse(fmt.Sprintf("Year reviews for %d sent to %d of %d users", year, sent, total)), nil
 }
 
-// DailyReportHandler отправляет ежедневные отчеты всем пользователям
-func DailyReportHandler(ctx context.Context, request Request) (*Response, error) {
-	// Загрузка конфигурации
-	cfg, err := config.LoadConfig()
+// ReminderHandler напоминает записать расходы тем, кто ничего не записал за день.
+// Вызывается ежечасно, напоминание уходит в час, выбранный пользователем
+func ReminderHandler(ctx context.Context, request Request) (*Response, error) {
+	now := request.scheduledAt()
+	sent, total, err := forEachUser(ctx, func(deps *dependencies, userID int64) (bool, error) {
+		settings, err := deps.tracker.GetUserSettings(ctx, userID)
+		if err != nil {
+			return false, fmt.Errorf("error getting settings: %w", err)
+		}
+		if !service.ReminderDue(settings, now) {
+			return false, nil
+		}
+
+		logged, err := deps.tracker.HasLoggedToday(ctx, userID, now)
+		if err != nil {
+			return false, fmt.Errorf("error checking transactions: %w", err)
+		}
+		if logged {
+			return false, nil
+		}
+
+		if err := deps.bot.SendReminder(ctx, userID); err != nil {
+			return false, fmt.Errorf("error sending reminder: %w", err)
+		}
+		return true, nil
+	})
 	if err != nil {
 		return errorResponse(err)
 	}
+	return okResponse(fmt.Sprintf("Reminders sent to %d of %d users", sent, total)), nil
+}
 
-	// Инициализация репозитория
-	repo, err := repository.NewSupabaseRepository(cfg.SupabaseURL, cfg.SupabaseKey)
+// CustomReminderHandler отправляет напоминания, созданные пользователями в /remind.
+// Вызывается ежечасно или чаще: напоминание уходит в течение часа после своего времени
+func CustomReminderHandler(ctx context.Context, request Request) (*Response, error) {
+	deps, err := getDependencies()
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	now := request.scheduledAt()
+	reminders, err := deps.tracker.DueReminders(ctx, now)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	sent := 0
+	for i := range reminders {
+		reminder := &reminders[i]
+		if err := deps.bot.SendScheduledReminder(ctx, reminder); err != nil {
+			log.Printf("Error sending reminder %s to user %d: %v", reminder.ID, reminder.UserID, err)
+			continue
+		}
+		deps.tracker.MarkReminderSent(ctx, reminder, now)
+		sent++
+	}
+
+	return okResponse(fmt.Sprintf("Custom reminders sent: %d of %d", sent, len(reminders))), nil
+}
+
+// BroadcastHandler отправляет подтвержденные рассылки администраторов. Вызывается по расписанию
+// раз в несколько минут: за вызов уходит ограниченное число сообщений, остальные - в следующих вызовах
+func BroadcastHandler(ctx context.Context, request Request) (*Response, error) {
+	deps, err := getDependencies()
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	sent, err := deps.bot.DeliverBroadcasts(ctx)
 	if err != nil {
 		return errorResponse(err)
 	}
 
-	// Инициализация сервиса
-	expenseTracker := service.NewExpenseTracker(repo)
+	return okResponse(fmt.Sprintf("Broadcast messages sent: %d", sent)), nil
+}
+
+// BudgetAlertHandler предупреждает о бюджетах, перешедших порог, если предупреждение не ушло
+// сразу после записи траты, например из-за тихих часов. Вызывается по расписанию, например раз в час
+func BudgetAlertHandler(ctx context.Context, request Request) (*Response, error) {
+	deps, err := getDependencies()
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	sent, err := deps.bot.DeliverBudgetAlerts(ctx)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return okResponse(fmt.Sprintf("Budget alerts sent: %d", sent)), nil
+}
+
+// BaselineHandler еженедельно пересчитывает типичные траты всех пользователей
+func BaselineHandler(ctx context.Context, request Request) (*Response, error) {
+	updated, total, err := forEachUser(ctx, func(deps *dependencies, userID int64) (bool, error) {
+		if _, err := deps.tracker.RecalculateBaseline(ctx, userID); err != nil {
+			return false, fmt.Errorf("error recalculating baseline: %w", err)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return errorResponse(err)
+	}
+	return okResponse(fmt.Sprintf("Baselines recalculated for %d of %d users", updated, total)), nil
+}
+
+// NetWorthHandler сохраняет ежемесячные снимки капитала всех пользователей
+func NetWorthHandler(ctx context.Context, request Request) (*Response, error) {
+	saved, total, err := forEachUser(ctx, func(deps *dependencies, userID int64) (bool, error) {
+		if _, err := deps.tracker.TakeNetWorthSnapshot(ctx, userID); err != nil {
+			return false, fmt.Errorf("error saving net worth snapshot: %w", err)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return errorResponse(err)
+	}
+	return okResponse(fmt.Sprintf("Net worth snapshots saved for %d of %d users", saved, total)), nil
+}
+
+// StateCleanupHandler удаляет состояния диалогов, брошенные пользователями.
+// Вызывается по расписанию, например раз в час
+func StateCleanupHandler(ctx context.Context, request Request) (*Response, error) {
+	deps, err := getDependencies()
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if err := deps.tracker.PruneUserStates(ctx, request.scheduledAt()); err != nil {
+		return errorResponse(err)
+	}
+
+	return okResponse("Expired user states deleted"), nil
+}
 
-	// Инициализация бота
-	bot, err := bot.NewBot(cfg.TelegramToken, expenseTracker)
+// RecycleBinPurgeHandler безвозвратно удаляет записи, пролежавшие в корзине дольше
+// service.RecycleBinDays дней. Вызывается по расписанию, например раз в сутки
+func RecycleBinPurgeHandler(ctx context.Context, request Request) (*Response, error) {
+	deps, err := getDependencies()
 	if err != nil {
 		return errorResponse(err)
 	}
 
-	// Получаем список всех пользователей
-	users, err := repo.GetAllUsers(ctx)
+	if err := deps.tracker.PurgeRecycleBin(ctx, request.scheduledAt()); err != nil {
+		return errorResponse(err)
+	}
+
+	return okResponse("Recycle bin purged"), nil
+}
+
+// scheduledJobs - задания ScheduledHandler по payload таймера
+var scheduledJobs = map[string]func(context.Context, Request) (*Response, error){
+	"daily":            DailyReportHandler,
+	"weekly":           WeeklyReportHandler,
+	"monthly":          MonthlyReportHandler,
+	"closing":          MonthlyClosingHandler,
+	"year_review":      YearReviewHandler,
+	"reminders":        ReminderHandler,
+	"custom_reminders": CustomReminderHandler,
+	"broadcasts":       BroadcastHandler,
+	"budget_alerts":    BudgetAlertHandler,
+	"baselines":        BaselineHandler,
+	"networth":         NetWorthHandler,
+	"state_cleanup":    StateCleanupHandler,
+	"recycle_bin":      RecycleBinPurgeHandler,
+}
+
+// ScheduledHandler - одна точка входа для всех заданий по расписанию: задание выбирается
+// по payload таймера Yandex Cloud Functions, например "daily" или "reminders"
+func ScheduledHandler(ctx context.Context, request Request) (*Response, error) {
+	if request.source() != sourceTimer {
+		return &Response{StatusCode: 400, Body: "expected a timer trigger event"}, nil
+	}
+	job, ok := scheduledJobs[request.timerPayload()]
+	if !ok {
+		log.Printf("Unknown scheduled job %q", request.timerPayload())
+		return &Response{StatusCode: 400, Body: fmt.Sprintf("unknown scheduled job %q", request.timerPayload())}, nil
+	}
+	return job(ctx, request)
+}
+
+// SetupHandler регистрирует меню команд бота. Вызывается вручную после развертывания
+func SetupHandler(ctx context.Context, request Request) (*Response, error) {
+	deps, err := getDependencies()
 	if err != nil {
 		return errorResponse(err)
 	}
 
-	// Отправляем отчеты каждому пользователю
+	if err := deps.bot.RegisterCommands(); err != nil {
+		return errorResponse(err)
+	}
+
+	return okResponse("Commands registered"), nil
+}
+
+// newRepository создает репозиторий Supabase, при заданном ключе - с шифрованием описаний
+func newRepository(cfg *config.Config) (repository.Repository, error) {
+	repo, err := repository.NewSupabaseRepository(cfg.SupabaseURL, cfg.SupabaseKey)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.EncryptionKey == nil {
+		return chaos.Wrap(repo), nil
+	}
+
+	encrypted, err := repository.NewEncryptedRepository(repo, cfg.EncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	return chaos.Wrap(encrypted), nil
+}
+
+// forEachUser выполняет fn для каждого пользователя и возвращает, для скольких из них fn
+// вернула true, и сколько пользователей всего. Зависимости переиспользуются между вызовами
+// функции. Ошибка по одному пользователю логируется и не прерывает обход, а отмена ctx -
+// прерывает: остальные пользователи получат свое при следующем вызове
+func forEachUser(ctx context.Context, fn func(deps *dependencies, userID int64) (bool, error)) (done, total int, err error) {
+	deps, err := getDependencies()
+	if err != nil {
+		return 0, 0, err
+	}
+	users, err := deps.repo.GetAllUsers(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
 	for _, userID := range users {
-		// Получаем отчет за день
-		report, err := expenseTracker.GetReport(ctx, userID, service.DailyReport)
+		if err := ctx.Err(); err != nil {
+			return done, len(users), err
+		}
+		ok, err := fn(deps, userID)
 		if err != nil {
-			continue // Пропускаем пользователя в случае ошибки
+			log.Printf("Error processing user %d: %v", userID, err)
+			continue
+		}
+		if ok {
+			done++
 		}
-
-		// Отправляем отчет
-		bot.SendDailyReport(ctx, userID, report)
 	}
+	return done, len(users), nil
+}
 
+// okResponse - успешный ответ функции
+func okResponse(body string) *Response {
 	return &Response{
 		StatusCode: 200,
-		Body:       fmt.Sprintf("Daily reports sent to %d users", len(users)),
+		Body:       body,
 		Headers: map[string]string{
 			"Content-Type": "application/json",
 		},
-	}, nil
+	}
 }
 
 func errorResponse(err error) (*Response, error) {
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/bot"
+	"github.com/ivanoskov/financial_bot/internal/config"
+	"github.com/ivanoskov/financial_bot/internal/currency"
+	"github.com/ivanoskov/financial_bot/internal/inflation"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/quotes"
+	"github.com/ivanoskov/financial_bot/internal/receipt"
+	"github.com/ivanoskov/financial_bot/internal/repository"
+	"github.com/ivanoskov/financial_bot/internal/sentry"
+	"github.com/ivanoskov/financial_bot/internal/service"
+	"github.com/ivanoskov/financial_bot/internal/webhook"
+)
+
+// defaultStartupBudget - допустимое время инициализации при холодном старте
+const defaultStartupBudget = 300 * time.Millisecond
+
+// dependencies - зависимости обработчиков, переиспользуемые между вызовами теплого экземпляра функции
+type dependencies struct {
+	cfg     *config.Config
+	repo    repository.Repository
+	tracker *service.ExpenseTracker
+	bot     *bot.Bot
+}
+
+var (
+	depsMu sync.Mutex
+	deps   *dependencies
+)
+
+// getDependencies возвращает зависимости, инициализируя их при первом вызове.
+// При ошибке инициализация будет повторена следующим вызовом
+func getDependencies() (*dependencies, error) {
+	depsMu.Lock()
+	defer depsMu.Unlock()
+
+	if deps != nil {
+		return deps, nil
+	}
+
+	timer := newStartupTimer()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	timer.phase("config")
+
+	repo, err := newRepository(cfg)
+	if err != nil {
+		return nil, err
+	}
+	timer.phase("repository")
+
+	tracker := service.NewExpenseTracker(repo)
+	if cfg.ReceiptToken != "" {
+		tracker.SetReceiptProvider(receipt.NewProverkachekaClient(cfg.ReceiptToken))
+	}
+	if cfg.AttachmentsBucket != "" {
+		tracker.SetAttachmentStorage(repository.NewSupabaseStorage(cfg.SupabaseURL, cfg.SupabaseKey, cfg.AttachmentsBucket))
+	}
+	if cfg.InflationCPIURL != "" {
+		tracker.SetInflationProvider(inflation.NewCPIClient(cfg.InflationCPIURL))
+	} else if cfg.InflationRate != 0 {
+		tracker.SetInflationProvider(inflation.NewFixed(cfg.InflationRate))
+	}
+	tracker.SetBaseCurrency(cfg.BaseCurrency)
+	tracker.SetRateProvider(currency.NewCBRClient(cfg.RatesURL))
+	tracker.SetPriceProvider(model.HoldingKindStock, quotes.NewMOEXClient(cfg.MOEXURL))
+	tracker.SetPriceProvider(model.HoldingKindCrypto, quotes.NewCoinGeckoClient(cfg.CoinGeckoURL))
+	tracker.SetWebhookSender(webhook.NewSender())
+	// Память экземпляра не переживает холодный старт, поэтому отчеты кэшируются в таблице
+	tracker.SetReportCache(service.NewStoreReportCache(repo, service.DefaultReportCacheTTL))
+	timer.phase("service")
+
+	// Бот создается без запроса getMe, генератор графиков - при первом построении графиков
+	b := bot.NewWebhookBot(cfg.TelegramToken, tracker)
+	b.SetDebug(cfg.LogLevel == config.LogDebug)
+	b.SetAdmins(cfg.AdminIDs)
+	if cfg.SentryDSN != "" {
+		reporter, err := sentry.NewClient(cfg.SentryDSN)
+		if err != nil {
+			return nil, err
+		}
+		b.SetErrorReporter(reporter)
+	}
+	timer.phase("bot")
+
+	timer.report(startupBudget())
+
+	deps = &dependencies{cfg: cfg, repo: repo, tracker: tracker, bot: b}
+	return deps, nil
+}
+
+// startupBudget читает бюджет инициализации из STARTUP_BUDGET (например, "200ms")
+func startupBudget() time.Duration {
+	if budget, err := time.ParseDuration(os.Getenv("STARTUP_BUDGET")); err == nil && budget > 0 {
+		return budget
+	}
+	return defaultStartupBudget
+}
+
+// startupTimer замеряет длительность фаз инициализации
+type startupTimer struct {
+	start  time.Time
+	last   time.Time
+	phases []string
+}
+
+func newStartupTimer() *startupTimer {
+	now := time.Now()
+	return &startupTimer{start: now, last: now}
+}
+
+// phase фиксирует завершение фазы инициализации
+func (t *startupTimer) phase(name string) {
+	now := time.Now()
+	t.phases = append(t.phases, fmt.Sprintf("%s=%s", name, now.Sub(t.last).Round(time.Microsecond)))
+	t.last = now
+}
+
+// report логирует длительность фаз и предупреждает о превышении бюджета
+func (t *startupTimer) report(budget time.Duration) {
+	total := time.Since(t.start)
+	if total > budget {
+		log.Printf("Cold start exceeded budget %s: total=%s %s", budget, total, strings.Join(t.phases, " "))
+		return
+	}
+	log.Printf("Cold start: total=%s %s", total, strings.Join(t.phases, " "))
+}
@@ -0,0 +1,88 @@
+// Команда migrate обновляет схему базы Supabase миграциями из internal/migrations:
+//
+//	go run ./cmd/migrate bootstrap  # SQL для однократной установки, выполнить в SQL-редакторе Supabase
+//	go run ./cmd/migrate status     # примененные и ожидающие миграции
+//	go run ./cmd/migrate up         # применить ожидающие миграции по порядку
+//
+// Запускайте up перед развертыванием новой версии бота: код рассчитывает на новые колонки
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/ivanoskov/financial_bot/internal/config"
+	"github.com/ivanoskov/financial_bot/internal/migrations"
+	"github.com/ivanoskov/financial_bot/internal/repository"
+)
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: migrate bootstrap|status|up\n")
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
+		usage()
+		os.Exit(2)
+	}
+	if flag.Arg(0) == "bootstrap" {
+		// SQL печатается без подключения: функции apply_migration еще нет
+		fmt.Print(migrations.Bootstrap)
+		return
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	repo, err := repository.NewSupabaseRepository(cfg.SupabaseURL, cfg.SupabaseKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	switch flag.Arg(0) {
+	case "status":
+		all, err := migrations.All()
+		if err != nil {
+			log.Fatal(err)
+		}
+		pending, err := migrations.Pending(ctx, repo)
+		if err != nil {
+			log.Fatalf("%v\nIf migrations are not installed yet, run the SQL from `migrate bootstrap` in the Supabase SQL editor", err)
+		}
+		waiting := make(map[int]bool, len(pending))
+		for _, m := range pending {
+			waiting[m.Version] = true
+		}
+		for _, m := range all {
+			state := "applied"
+			if waiting[m.Version] {
+				state = "pending"
+			}
+			fmt.Printf("%04d_%s\t%s\n", m.Version, m.Name, state)
+		}
+	case "up":
+		done, err := migrations.Up(ctx, repo)
+		for _, m := range done {
+			fmt.Printf("Applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(done) == 0 {
+			fmt.Println("Schema is up to date")
+		}
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,104 @@
+// Команда setup управляет webhook бота в Telegram после развертывания функции:
+//
+//	go run ./cmd/setup set        # зарегистрировать TELEGRAM_WEBHOOK_URL с TELEGRAM_WEBHOOK_SECRET
+//	go run ./cmd/setup info       # адрес, очередь обновлений и последняя ошибка доставки
+//	go run ./cmd/setup delete     # снять webhook, например для перехода на long polling
+//	go run ./cmd/setup commands   # зарегистрировать меню команд
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/bot"
+	"github.com/ivanoskov/financial_bot/internal/config"
+)
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: setup [flags] set|info|delete|commands\n\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	url := flag.String("url", "", "адрес webhook, по умолчанию TELEGRAM_WEBHOOK_URL")
+	cert := flag.String("cert", "", "самоподписанный сертификат для Telegram")
+	dropPending := flag.Bool("drop-pending", false, "удалить накопленные обновления")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Боту для запросов настройки не нужны ни база, ни сервис
+	b := bot.NewWebhookBot(cfg.TelegramToken, nil)
+
+	switch flag.Arg(0) {
+	case "set":
+		if *url == "" {
+			*url = cfg.WebhookURL
+		}
+		if *url == "" {
+			log.Fatal("webhook url is not set: pass -url or TELEGRAM_WEBHOOK_URL")
+		}
+		if cfg.WebhookSecret == "" {
+			log.Println("Warning: TELEGRAM_WEBHOOK_SECRET is not set, anyone who knows the url can send updates")
+		}
+		err = b.SetWebhook(bot.WebhookOptions{
+			URL:         *url,
+			Secret:      cfg.WebhookSecret,
+			Certificate: *cert,
+			DropPending: *dropPending,
+		})
+		if err == nil {
+			fmt.Printf("Webhook set: %s\n", *url)
+		}
+	case "delete":
+		if err = b.DeleteWebhook(*dropPending); err == nil {
+			fmt.Println("Webhook deleted")
+		}
+	case "info":
+		err = printWebhookInfo(b)
+	case "commands":
+		if err = b.RegisterCommands(); err == nil {
+			fmt.Println("Commands registered")
+		}
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// printWebhookInfo выводит состояние webhook
+func printWebhookInfo(b *bot.Bot) error {
+	info, err := b.WebhookInfo()
+	if err != nil {
+		return err
+	}
+	if !info.IsSet() {
+		fmt.Println("Webhook is not set, the bot receives updates via long polling")
+		return nil
+	}
+
+	fmt.Printf("URL:                %s\n", info.URL)
+	fmt.Printf("Custom certificate: %t\n", info.HasCustomCertificate)
+	fmt.Printf("Pending updates:    %d\n", info.PendingUpdateCount)
+	if info.MaxConnections > 0 {
+		fmt.Printf("Max connections:    %d\n", info.MaxConnections)
+	}
+	if info.LastErrorDate > 0 {
+		fmt.Printf("Last error:         %s, %s\n",
+			time.Unix(int64(info.LastErrorDate), 0).Format(time.RFC3339), info.LastErrorMessage)
+	}
+	return nil
+}
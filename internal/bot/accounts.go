@@ -0,0 +1,167 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// handleBalance показывает остатки по счетам
+func (b *Bot) handleBalance(ctx context.Context, message *tgbotapi.Message) {
+	balances, err := b.service.GetAccountBalances(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить счета")
+		return
+	}
+
+	text := "💳 *Счета*\n\n"
+	if len(balances) == 0 {
+		text += "У вас пока нет счетов. Добавьте наличные, карту или вклад, чтобы видеть остатки\n"
+	} else {
+		text += formatAccountBalances(balances)
+	}
+
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+		callbackButton("➕ 💵 Наличные", cbNewAccount, model.AccountTypeCash),
+		callbackButton("➕ 💳 Карта", cbNewAccount, model.AccountTypeCard),
+		callbackButton("➕ 🏦 Вклад", cbNewAccount, model.AccountTypeDeposit),
+	))
+	for _, balance := range balances {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton("🗑 "+balance.Account.Name, cbDeleteAccount, balance.Account.ID),
+		))
+	}
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+		callbackButton("« Назад", cbMenu),
+	))
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	b.api.Send(msg)
+}
+
+// handleNewAccount запрашивает название нового счета
+func (b *Bot) handleNewAccount(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	accountType := args.String(0)
+
+	state := &model.UserState{
+		UserID:         callback.From.ID,
+		AwaitingAction: model.StateNewAccount,
+		Payload:        accountType,
+	}
+	if err := b.saveUserState(ctx, state); err != nil {
+		return fmt.Errorf("error saving user state: %w", err)
+	}
+
+	msg := tgbotapi.NewMessage(callback.Message.Chat.ID,
+		"Введите название счета и, если нужно, текущий остаток:\n"+
+			"`Карта Сбер 15000`")
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = cancelKeyboard
+	b.api.Send(msg)
+	return nil
+}
+
+// createAccountFromMessage создает счет из введенного текста
+func (b *Bot) createAccountFromMessage(ctx context.Context, message *tgbotapi.Message, state *model.UserState) error {
+	name := strings.TrimSpace(message.Text)
+	balance := 0.0
+
+	// Последнее слово - необязательный начальный остаток
+	if sep := strings.LastIndex(name, " "); sep > 0 {
+		if value, err := strconv.ParseFloat(strings.ReplaceAll(name[sep+1:], ",", "."), 64); err == nil {
+			balance = value
+			name = strings.TrimSpace(name[:sep])
+		}
+	}
+	if name == "" {
+		b.sendErrorMessage(message.Chat.ID, "Название счета не может быть пустым")
+		return nil
+	}
+
+	if _, err := b.service.CreateAccount(ctx, message.From.ID, name, state.Payload, balance); err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Ошибка при создании счета")
+		return nil
+	}
+
+	if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+		b.reportError(ctx, fmt.Errorf("error deleting user state: %w", err))
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Счет '%s' создан! ✅", name))
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+	return nil
+}
+
+// handleSelectAccount меняет счет для вводимой транзакции
+func (b *Bot) handleSelectAccount(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	accountID := args.String(0)
+
+	state, err := b.expectState(ctx, callback.From.ID, model.StateAmount)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		b.sendErrorMessage(callback.Message.Chat.ID, "Сначала выберите категорию")
+		return nil
+	}
+
+	accounts, err := b.service.GetAccounts(ctx, callback.From.ID)
+	if err != nil {
+		return fmt.Errorf("error getting accounts: %w", err)
+	}
+	var selected *model.Account
+	for i := range accounts {
+		if accounts[i].ID == accountID {
+			selected = &accounts[i]
+			break
+		}
+	}
+	if selected == nil {
+		b.sendErrorMessage(callback.Message.Chat.ID, "Счет не найден")
+		return nil
+	}
+
+	next := *state
+	next.SelectedAccount = selected.ID
+	if err := b.transitionUserState(ctx, state, &next); err != nil {
+		return fmt.Errorf("error saving user state: %w", err)
+	}
+
+	msg := tgbotapi.NewMessage(callback.Message.Chat.ID,
+		fmt.Sprintf("*Счет:* %s %s\n\nВведите сумму и описание", accountEmoji(selected.Type), selected.Name))
+	msg.ParseMode = "Markdown"
+	b.api.Send(msg)
+	return nil
+}
+
+// formatAccountBalances формирует список остатков и общий итог
+func formatAccountBalances(balances []model.AccountBalance) string {
+	text := ""
+	total := 0.0
+	for _, balance := range balances {
+		text += fmt.Sprintf("%s %s: %.2f₽\n", accountEmoji(balance.Account.Type), balance.Account.Name, balance.Balance)
+		total += balance.Balance
+	}
+	text += fmt.Sprintf("*Всего:* %.2f₽\n", total)
+	return text
+}
+
+// accountEmoji возвращает иконку для типа счета
+func accountEmoji(accountType string) string {
+	switch accountType {
+	case model.AccountTypeCash:
+		return "💵"
+	case model.AccountTypeDeposit:
+		return "🏦"
+	default:
+		return "💳"
+	}
+}
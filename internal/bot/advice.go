@@ -0,0 +1,265 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/locale"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// handleAdvice показывает рекомендации по экономии
+func (b *Bot) handleAdvice(ctx context.Context, message *tgbotapi.Message) {
+	advices, err := b.service.GetSavingsAdvice(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось сформировать рекомендации")
+		return
+	}
+
+	if len(advices) == 0 {
+		msg := tgbotapi.NewMessage(message.Chat.ID,
+			"*Рекомендации*\n\nПока недостаточно данных: нужна история расходов хотя бы за прошлый месяц")
+		msg.ParseMode = "Markdown"
+		msg.ReplyMarkup = b.getMainKeyboard()
+		b.api.Send(msg)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, "💡 *Рекомендации*\n\n"+formatAdvices(advices))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = b.getAdviceKeyboard(advices)
+	b.api.Send(msg)
+}
+
+// handleAdviceAccept применяет рекомендацию: устанавливает предложенный бюджет категории
+func (b *Bot) handleAdviceAccept(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	// Аргументы: ID категории и сумма бюджета
+	categoryID := args.String(0)
+	amount, err := args.Float(1)
+	if err != nil {
+		return fmt.Errorf("invalid advice amount: %w", err)
+	}
+
+	if err := b.service.SetBudget(ctx, callback.From.ID, categoryID, amount); err != nil {
+		b.sendServiceError(ctx, callback.Message.Chat.ID, err, "Не удалось установить бюджет")
+		return nil
+	}
+
+	msg := tgbotapi.NewMessage(callback.Message.Chat.ID,
+		fmt.Sprintf("Бюджет установлен: *%.0f₽* в месяц ✅", amount))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+	return nil
+}
+
+// handleGoal создает финансовую цель: /goal 100000 Отпуск
+func (b *Bot) handleGoal(ctx context.Context, message *tgbotapi.Message) {
+	args := strings.SplitN(strings.TrimSpace(message.CommandArguments()), " ", 2)
+	if len(args) < 2 {
+		b.showGoals(ctx, message)
+		return
+	}
+
+	target, err := strconv.ParseFloat(args[0], 64)
+	if err != nil || target <= 0 {
+		b.sendErrorMessage(message.Chat.ID, "Неверный формат суммы. Используйте: /goal 100000 Отпуск")
+		return
+	}
+
+	name := strings.TrimSpace(args[1])
+	if err := b.service.CreateGoal(ctx, message.From.ID, name, target); err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Ошибка при создании цели")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Цель '%s' на %.0f₽ создана! 🎯", name, target))
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+}
+
+// showGoals показывает список целей пользователя
+func (b *Bot) showGoals(ctx context.Context, message *tgbotapi.Message) {
+	goals, err := b.service.GetGoals(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить цели")
+		return
+	}
+
+	text := "*Ваши цели* 🎯\n\n"
+	if len(goals) == 0 {
+		text += "У вас пока нет целей\n"
+	}
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	for _, g := range goals {
+		text += formatGoal(g)
+		if g.Remaining() > 0 {
+			buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+				callbackButton("💰 Отложить на «"+g.Name+"»", cbGoal, g.ID),
+			))
+		}
+	}
+	text += "\nЧтобы добавить цель, отправьте: `/goal 100000 Отпуск`"
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(callbackButton("« В меню", cbMenu)))
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	b.api.Send(msg)
+}
+
+// formatGoal описывает цель: накоплено и сколько осталось
+func formatGoal(goal model.Goal) string {
+	if goal.Remaining() == 0 {
+		return fmt.Sprintf("• *%s*: %.0f₽ из %.0f₽ ✅\n", goal.Name, goal.SavedAmount, goal.TargetAmount)
+	}
+	return fmt.Sprintf("• *%s*: %.0f₽ из %.0f₽, осталось %.0f₽\n",
+		goal.Name, goal.SavedAmount, goal.TargetAmount, goal.Remaining())
+}
+
+// handleGoalCallback начинает ввод суммы, откладываемой на цель
+func (b *Bot) handleGoalCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	state := &model.UserState{
+		UserID:         callback.From.ID,
+		AwaitingAction: model.StateGoalAmount,
+		Payload:        args.String(0),
+	}
+	if err := b.saveUserState(ctx, state); err != nil {
+		return fmt.Errorf("error saving user state: %w", err)
+	}
+	msg := tgbotapi.NewMessage(callback.Message.Chat.ID, "Сколько отложить на цель? Отправьте сумму")
+	msg.ReplyMarkup = cancelKeyboard
+	b.api.Send(msg)
+	return nil
+}
+
+// saveToGoalFromMessage откладывает на цель сумму из сообщения
+func (b *Bot) saveToGoalFromMessage(ctx context.Context, message *tgbotapi.Message, state *model.UserState) error {
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(message.Text), ",", "."), 64)
+	if err != nil || amount <= 0 {
+		b.sendErrorMessage(message.Chat.ID, "Отправьте сумму числом, например 5000")
+		return nil
+	}
+
+	goal, err := b.service.AddGoalSavings(ctx, message.From.ID, state.Payload, amount)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось отложить сумму")
+		return fmt.Errorf("error saving to goal: %w", err)
+	}
+	if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+		b.reportError(ctx, fmt.Errorf("error deleting user state: %w", err))
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Отложено %.0f₽ 💰\n\n", amount)+formatGoal(*goal))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+	return nil
+}
+
+// handleBudgets показывает бюджеты категорий и их исполнение в текущем месяце.
+// У бюджетов с переносом доступная сумма включает остаток прошлых месяцев
+func (b *Bot) handleBudgets(ctx context.Context, message *tgbotapi.Message) {
+	statuses, err := b.service.GetBudgetStatuses(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить бюджеты")
+		return
+	}
+
+	if len(statuses) == 0 {
+		msg := tgbotapi.NewMessage(message.Chat.ID,
+			"*Бюджеты*\n\nУ вас пока нет бюджетов. Их можно установить из рекомендаций: /advice")
+		msg.ParseMode = "Markdown"
+		b.api.Send(msg)
+		return
+	}
+
+	text := fmt.Sprintf("*Бюджеты за %s*\n\n", locale.FormatMonth(locale.FromContext(ctx), statuses[0].Month))
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, status := range statuses {
+		emoji := "✅"
+		if status.Remaining() < 0 {
+			emoji = "🔴"
+		}
+		text += fmt.Sprintf("%s *%s*: %.0f₽ из %.0f₽\n",
+			emoji, status.CategoryName, status.Spent, status.Available())
+		if status.Budget.Rollover() {
+			text += fmt.Sprintf("   🔁 бюджет %.0f₽, перенос %+.0f₽\n", status.Budget.Amount, status.Carryover)
+		}
+
+		rollover := "🔁 Перенос: выкл"
+		if status.Budget.Rollover() {
+			rollover = "🔁 Перенос: вкл"
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			callbackButton("📉 "+status.CategoryName, cbBudget, "chart", status.Budget.CategoryID),
+			callbackButton(rollover, cbBudget, "rollover", status.Budget.CategoryID),
+		))
+	}
+	text += "\nС переносом неизрасходованный остаток переходит на следующий месяц, а перерасход уменьшает его бюджет"
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(callbackButton("« В меню", cbMenu)))
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.api.Send(msg)
+}
+
+// handleBudgetCallback показывает бюджеты, график расходования бюджета или переключает перенос остатка
+func (b *Bot) handleBudgetCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	categoryID := args.String(1)
+	switch args.String(0) {
+	case "list":
+		b.handleBudgets(ctx, callbackMessage(callback))
+	case "rollover":
+		budgets, err := b.service.GetBudgets(ctx, callback.From.ID)
+		if err != nil {
+			return fmt.Errorf("error getting budgets: %w", err)
+		}
+		enabled := false
+		for _, budget := range budgets {
+			if budget.CategoryID == categoryID {
+				enabled = !budget.Rollover()
+			}
+		}
+		if _, err := b.service.SetBudgetRollover(ctx, callback.From.ID, categoryID, enabled); err != nil {
+			b.sendServiceError(ctx, callback.Message.Chat.ID, err, "Не удалось изменить перенос остатка")
+			return nil
+		}
+		b.handleBudgets(ctx, callbackMessage(callback))
+	case "chart":
+		statuses, err := b.service.GetBudgetStatuses(ctx, callback.From.ID)
+		if err != nil {
+			return fmt.Errorf("error getting budgets: %w", err)
+		}
+		for _, status := range statuses {
+			if status.Budget.CategoryID != categoryID {
+				continue
+			}
+			delivery := b.chartDelivery(ctx, callback.From.ID)
+			chartData, err := delivery.charts.GenerateBudgetBurndownChart(status)
+			if err != nil {
+				return fmt.Errorf("error generating budget chart: %w", err)
+			}
+			b.api.Send(delivery.message(callback.Message.Chat.ID, "budget", chartData))
+			return nil
+		}
+		b.sendErrorMessage(callback.Message.Chat.ID, "Бюджет не найден")
+	default:
+		return fmt.Errorf("unknown budget action: %s", callback.Data)
+	}
+	return nil
+}
+
+// formatAdvices формирует текст списка рекомендаций
+func formatAdvices(advices []service.SavingsAdvice) string {
+	text := ""
+	for _, advice := range advices {
+		text += fmt.Sprintf("• %s\n", advice.Text)
+	}
+	return text
+}
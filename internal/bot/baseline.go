@@ -0,0 +1,31 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// withTodaySpending дописывает к подтверждению траты, сколько еще можно потратить сегодня,
+// или предупреждение, если трата сделала день необычно затратным. Если о трате уже
+// предупредили как о крупной, день отдельно не отмечается
+func (b *Bot) withTodaySpending(ctx context.Context, userID int64, amount float64, warned bool, text string) string {
+	if amount >= 0 {
+		return text
+	}
+	today, err := b.service.GetTodaySpending(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting today spending: %v", err)
+		return text
+	}
+	switch {
+	case today == nil:
+	case today.BecameAnomaly(amount):
+		if !warned {
+			text += fmt.Sprintf("\n\n⚠️ Необычно затратный день: потрачено %.0f₽, обычно ~%.0f₽", today.Spent, today.Typical)
+		}
+	case today.SafeToSpend() > 0:
+		text += fmt.Sprintf("\n\n💡 Сегодня можно потратить еще ~%.0f₽, чтобы день остался обычным", today.SafeToSpend())
+	}
+	return text
+}
@@ -5,26 +5,45 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"strconv"
+	"math"
+	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/ivanoskov/financial_bot/internal/charts"
+	"github.com/ivanoskov/financial_bot/internal/locale"
 	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/receipt"
 	"github.com/ivanoskov/financial_bot/internal/service"
 )
 
-// UserState хранит текущее состояние пользователя
-type UserState struct {
-	SelectedCategoryID string
-	TransactionType    string // "income" или "expense"
-	AwaitingAction     string // "new_category" или пусто
-}
-
 type Bot struct {
-	api      *tgbotapi.BotAPI
-	service  *service.ExpenseTracker
-	chartGen *charts.ChartGenerator
+	api     TelegramAPI
+	service *service.ExpenseTracker
+	errors  ErrorReporter
+	admins  []int64
+
+	// Имя бота для ссылок, в webhook-режиме запрашивается при первом обращении
+	username string
+
+	// Обработчики команд и кнопок
+	commands  map[string]command
+	callbacks map[callbackAction]callbackHandler
+
+	// Ограничение частоты запросов каждого пользователя и защита от двойных нажатий
+	throttle *userThrottle
+	repeats  *callbackRepeats
+
+	// Генератор графиков создается при первом запросе графиков
+	chartOnce sync.Once
+	chartGen  *charts.ChartGenerator
+
+	// Остановка long polling: отмена приема обновлений и сигнал о завершении Start
+	stopMu  sync.Mutex
+	stop    context.CancelFunc
+	stopped chan struct{}
 }
 
 func NewBot(token string, service *service.ExpenseTracker) (*Bot, error) {
@@ -33,118 +52,364 @@ func NewBot(token string, service *service.ExpenseTracker) (*Bot, error) {
 		return nil, err
 	}
 
-	return &Bot{
-		api:      bot,
-		service:  service,
-		chartGen: charts.NewChartGenerator(),
-	}, nil
+	b := NewBotWithAPI(newRateLimitedAPI(bot), service)
+	b.username = bot.Self.UserName
+	return b, nil
+}
+
+// NewWebhookBot создает бота без проверочного запроса getMe к Telegram.
+// Используется в serverless-режиме, где каждый лишний запрос увеличивает холодный старт
+func NewWebhookBot(token string, service *service.ExpenseTracker) *Bot {
+	api := &tgbotapi.BotAPI{
+		Token:  token,
+		Client: &http.Client{},
+		Buffer: 100,
+	}
+	api.SetAPIEndpoint(tgbotapi.APIEndpoint)
+
+	return NewBotWithAPI(newRateLimitedAPI(api), service)
+}
+
+// charts возвращает генератор графиков, создавая его при первом обращении
+func (b *Bot) charts() *charts.ChartGenerator {
+	b.chartOnce.Do(func() {
+		b.chartGen = charts.NewChartGenerator()
+	})
+	return b.chartGen
+}
+
+// updateTimeout ограничивает обработку одного обновления вместе со всеми запросами к базе
+const updateTimeout = 2 * time.Minute
+
+// cleanupInterval - как часто удалять брошенные состояния и очищать корзину в режиме long polling
+const cleanupInterval = time.Hour
+
+// SetDebug включает подробный лог запросов к Telegram
+func (b *Bot) SetDebug(debug bool) {
+	api := b.api
+	if stamped, ok := api.(*menuSessionAPI); ok {
+		api = stamped.TelegramAPI
+	}
+	if limited, ok := api.(*rateLimitedAPI); ok {
+		if api, ok := limited.Transport.(*tgbotapi.BotAPI); ok {
+			api.Debug = debug
+		}
+	}
+}
+
+// userContext дополняет контекст запроса языком пользователя
+func userContext(ctx context.Context, user *tgbotapi.User) context.Context {
+	if user != nil {
+		ctx = locale.WithLanguage(ctx, user.LanguageCode)
+	}
+	return ctx
 }
 
-// getUserState получает состояние пользователя из БД
+// getUserState получает состояние пользователя из БД. Истекшие состояния не возвращаются
 func (b *Bot) getUserState(ctx context.Context, userID int64) (*model.UserState, error) {
 	return b.service.GetUserState(ctx, userID)
 }
 
-// saveUserState сохраняет состояние пользователя в БД
+// expectState возвращает состояние пользователя, только если он на шаге action.
+// Кнопки старых сообщений не должны продолжать сценарий, который уже закончился
+func (b *Bot) expectState(ctx context.Context, userID int64, action model.StateAction) (*model.UserState, error) {
+	state, err := b.getUserState(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user state: %w", err)
+	}
+	if state == nil || state.AwaitingAction != action {
+		return nil, nil
+	}
+	return state, nil
+}
+
+// saveUserState начинает сценарий с начального состояния
 func (b *Bot) saveUserState(ctx context.Context, state *model.UserState) error {
 	return b.service.SaveUserState(ctx, state)
 }
 
+// transitionUserState переводит сценарий на следующий шаг
+func (b *Bot) transitionUserState(ctx context.Context, current, next *model.UserState) error {
+	return b.service.TransitionUserState(ctx, current, next)
+}
+
 // deleteUserState удаляет состояние пользователя из БД
 func (b *Bot) deleteUserState(ctx context.Context, userID int64) error {
 	return b.service.DeleteUserState(ctx, userID)
 }
 
-func (b *Bot) handleUpdate(update tgbotapi.Update) error {
-	if update.Message == nil && update.CallbackQuery == nil {
+func (b *Bot) handleUpdate(ctx context.Context, update tgbotapi.Update) error {
+	if update.Message == nil && update.CallbackQuery == nil &&
+		update.InlineQuery == nil && update.ChosenInlineResult == nil {
+		return nil
+	}
+
+	user, chatID := updateSender(update)
+	// Лишние запросы отклоняем до первого обращения к базе
+	if user != nil && b.throttled(update, user, chatID) {
+		return nil
+	}
+	if user != nil {
+		b.loadMenuSession(ctx, user.ID, chatID)
+	}
+
+	switch {
+	case update.InlineQuery != nil:
+		ctx = userContext(ctx, update.InlineQuery.From)
+	case update.ChosenInlineResult != nil:
+		ctx = userContext(ctx, update.ChosenInlineResult.From)
+	default:
+		ctx = userContext(ctx, user)
+	}
+
+	// После ответа однократно показываем новости о новых возможностях
+	if user != nil {
+		b.touchUser(ctx, user)
+		defer b.showAnnouncements(ctx, user, chatID)
+	}
+
+	// Финансовые данные после бездействия показываем только после ввода PIN
+	if b.requirePIN(ctx, update) {
 		return nil
 	}
 
 	if update.Message != nil && update.Message.IsCommand() {
-		return b.handleCommand(update.Message)
+		return b.handleCommand(ctx, update.Message)
 	}
 
 	if update.CallbackQuery != nil {
-		return b.handleCallback(update.CallbackQuery)
+		return b.handleCallback(ctx, update.CallbackQuery)
+	}
+
+	if update.InlineQuery != nil {
+		return b.handleInlineQuery(update.InlineQuery)
+	}
+
+	if update.ChosenInlineResult != nil {
+		return b.handleChosenInlineResult(ctx, update.ChosenInlineResult)
 	}
 
 	if update.Message != nil {
-		return b.handleMessage(update.Message)
+		return b.handleMessage(ctx, update.Message)
 	}
 
 	return nil
 }
 
-// Start запускает бота в режиме long polling
-func (b *Bot) Start() error {
+// Start запускает бота в режиме long polling и работает до отмены ctx или вызова Stop.
+// При остановке прием обновлений прекращается, а уже полученные обрабатываются до конца
+func (b *Bot) Start(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	defer close(b.registerStop(cancel))
+
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 
 	updates := b.api.GetUpdatesChan(u)
-
+	go func() {
+		<-ctx.Done()
+		// Канал закроется, когда завершится текущий запрос getUpdates
+		b.api.StopReceivingUpdates()
+	}()
+	b.startJobs(ctx)
+
+	// Начатую обработку не прерываем: сообщение, записанное наполовину, хуже задержки остановки
+	work := context.WithoutCancel(ctx)
+	lastUpdateID := 0
 	for update := range updates {
-		if err := b.handleUpdate(update); err != nil {
-			// Логируем ошибку, но продолжаем работу
-			fmt.Printf("Error handling update: %v\n", err)
+		if err := b.processUpdate(work, update); err != nil {
+			// Ошибка уже отправлена в трекер, продолжаем работу
+			log.Printf("Error handling update %d: %v", update.UpdateID, err)
 		}
+		lastUpdateID = update.UpdateID
 	}
 
+	b.confirmUpdates(lastUpdateID)
 	return nil
 }
 
-// HandleWebhook - точка входа для обработки входящих webhook-обновлений
-func (b *Bot) HandleWebhook(body []byte) error {
+// registerStop запоминает отмену запущенного приема обновлений для Stop. Возвращает канал,
+// который вызывающий закрывает по завершении
+func (b *Bot) registerStop(cancel context.CancelFunc) chan struct{} {
+	stopped := make(chan struct{})
+	b.stopMu.Lock()
+	b.stop, b.stopped = cancel, stopped
+	b.stopMu.Unlock()
+	return stopped
+}
+
+// startJobs запускает фоновые задачи долгоживущего процесса до отмены ctx
+func (b *Bot) startJobs(ctx context.Context) {
+	go b.cleanup(ctx)
+	go b.deliverBroadcasts(ctx)
+	go b.deliverBudgetAlerts(ctx)
+}
+
+// cleanup периодически удаляет брошенные состояния и старые записи из корзины до остановки
+// бота. В serverless-режиме то же делают функции StateCleanupHandler и RecycleBinPurgeHandler
+// по расписанию
+func (b *Bot) cleanup(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := b.service.PruneUserStates(ctx, now); err != nil {
+				b.reportError(ctx, fmt.Errorf("error pruning user states: %w", err))
+			}
+			if err := b.service.PurgeRecycleBin(ctx, now); err != nil {
+				b.reportError(ctx, fmt.Errorf("error purging recycle bin: %w", err))
+			}
+		}
+	}
+}
+
+// Stop останавливает long polling или webhook-сервер и ждет обработки полученных
+// обновлений, но не дольше ctx
+func (b *Bot) Stop(ctx context.Context) error {
+	b.stopMu.Lock()
+	stop, stopped := b.stop, b.stopped
+	b.stopMu.Unlock()
+	if stop == nil {
+		return nil
+	}
+
+	stop()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("error waiting for updates to drain: %w", ctx.Err())
+	}
+}
+
+// confirmUpdates подтверждает Telegram обработанные обновления. Иначе последняя полученная
+// пачка остается неподтвержденной и будет доставлена повторно после перезапуска
+func (b *Bot) confirmUpdates(lastUpdateID int) {
+	if lastUpdateID == 0 {
+		return
+	}
+	confirm := tgbotapi.NewUpdate(lastUpdateID + 1)
+	confirm.Limit = 1
+	if _, err := b.api.GetUpdates(confirm); err != nil {
+		log.Printf("Error confirming updates: %v", err)
+	}
+}
+
+// HandleWebhook - точка входа для обработки входящих webhook-обновлений.
+// Контекст вызова функции ограничивает время обработки
+func (b *Bot) HandleWebhook(ctx context.Context, body []byte) error {
 	var update tgbotapi.Update
 	if err := json.Unmarshal(body, &update); err != nil {
 		return err
 	}
 
-	return b.handleUpdate(update)
-}
-
-func (b *Bot) handleCommand(message *tgbotapi.Message) error {
-	cmd := message.Command()
+	// Telegram повторяет доставку, если не дождался ответа. Если проверить не удалось,
+	// обрабатываем: потерять сообщение хуже, чем изредка обработать его дважды
+	fresh, err := b.service.BeginUpdate(ctx, update.UpdateID)
+	if err != nil {
+		log.Printf("Error checking update %d: %v", update.UpdateID, err)
+	} else if !fresh {
+		log.Printf("Skipping redelivered update %d", update.UpdateID)
+		return nil
+	}
+	marked := err == nil
 
-	switch cmd {
-	case "start":
-		b.handleStart(message)
-	case "add":
-		b.handleAddTransaction(message)
-	case "report":
-		b.handleReport(message)
-	case "categories":
-		b.handleCategories(message)
+	ctx = service.WithUpdateWrites(ctx)
+	err = b.processUpdate(ctx, update)
+	if err == nil {
+		// Обработчики сообщают пользователю об ошибках сами, но истекший контекст значит,
+		// что обработка оборвалась на середине
+		err = ctx.Err()
+	}
+	if err == nil || !marked {
+		return err
+	}
+	if service.UpdateWrote(ctx) {
+		// Транзакция или другая запись уже сохранена: повторная доставка задвоила бы ее.
+		// Оставляем отметку и отвечаем успехом, чтобы Telegram не повторял обновление
+		log.Printf("Keeping update %d processed after partial handling: %v", update.UpdateID, err)
+		return nil
+	}
+	// Ничего не записано - снимаем отметку, чтобы повторная доставка обработала обновление
+	// заново. Контекст обновления к этому моменту может быть уже истекшим
+	abortCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), reportTimeout)
+	defer cancel()
+	if abortErr := b.service.AbortUpdate(abortCtx, update.UpdateID); abortErr != nil {
+		log.Printf("Error aborting update %d: %v", update.UpdateID, abortErr)
 	}
+	return err
+}
 
+func (b *Bot) handleCommand(ctx context.Context, message *tgbotapi.Message) error {
+	if cmd, ok := b.commands[message.Command()]; ok {
+		cmd.handle(ctx, message)
+	}
 	return nil
 }
 
-func (b *Bot) handleStart(message *tgbotapi.Message) {
-	// Создаем категории по умолчанию при первом запуске
-	err := b.service.CreateDefaultCategories(context.Background(), message.From.ID)
-	if err != nil {
-		b.sendErrorMessage(message.Chat.ID, fmt.Sprintf("Не удалось создать стандартные категории: %v", err))
+// welcomeText - приветствие по команде /start
+const welcomeText = "*Привет! Я помогу вести учет финансов* 💰\n\n" +
+	"Вот что я умею:\n" +
+	"• Записывать доходы и расходы\n" +
+	"• Показывать отчеты по категориям\n" +
+	"• Управлять категориями\n\n" +
+	"Все команды - /help, прервать любое действие - /cancel\n\n" +
+	"*Выберите нужное действие в меню ниже* 👇"
+
+func (b *Bot) handleStart(ctx context.Context, message *tgbotapi.Message) {
+	if err := b.service.RegisterUser(ctx, registryUser(message.From)); err != nil {
+		b.reportError(ctx, fmt.Errorf("error registering user: %w", err))
+	}
+	// Кнопки, отправленные до этого /start, устаревают
+	if _, err := b.service.StartMenuSession(ctx, message.From.ID); err != nil {
+		b.reportError(ctx, fmt.Errorf("error starting menu session: %w", err))
+	}
+
+	// Переход по ссылке-приглашению в общий бюджет
+	if code, ok := strings.CutPrefix(message.CommandArguments(), joinPrefix); ok {
+		b.handleJoin(ctx, message, code)
+		return
+	}
+	// Переход по ссылке-приглашению в общее событие
+	if code, ok := strings.CutPrefix(message.CommandArguments(), eventJoinPrefix); ok {
+		b.handleJoinEvent(ctx, message, code)
 		return
 	}
 
+	// Сначала отвечаем пользователю, а наборы категорий предлагаем после приветствия
 	keyboard := b.getMainKeyboard()
-	msg := tgbotapi.NewMessage(message.Chat.ID,
-		"*Привет! Я помогу вести учет финансов* 💰\n\n"+
-			"Вот что я умею:\n"+
-			"• Записывать доходы и расходы\n"+
-			"• Показывать отчеты по категориям\n"+
-			"• Управлять категориями\n\n"+
-			"*Выберите нужное действие в меню ниже* 👇")
+	msg := tgbotapi.NewMessage(message.Chat.ID, welcomeText)
 
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = keyboard
 	b.api.Send(msg)
+
+	// При первом запуске предлагаем выбрать стартовый набор категорий
+	categories, err := b.service.GetCategories(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить категории")
+		return
+	}
+	if len(categories) == 0 {
+		b.sendCategoryPacks(message.Chat.ID)
+	}
 }
 
-func (b *Bot) handleAddTransaction(message *tgbotapi.Message) {
-	categories, err := b.service.GetCategories(context.Background(), message.From.ID)
+func (b *Bot) handleAddTransaction(ctx context.Context, message *tgbotapi.Message) {
+	// Транзакция целиком в аргументах команды: /add 500 такси
+	if args := strings.TrimSpace(message.CommandArguments()); args != "" {
+		b.handleQuickAdd(ctx, message, args)
+		return
+	}
+
+	categories, err := b.service.GetInputCategories(ctx, message.From.ID)
 	if err != nil {
-		b.sendErrorMessage(message.Chat.ID, "Ошибка при получении категорий")
+		b.sendServiceError(ctx, message.Chat.ID, err, "Ошибка при получении категорий")
 		return
 	}
 
@@ -153,146 +418,278 @@ func (b *Bot) handleAddTransaction(message *tgbotapi.Message) {
 	b.api.Send(msg)
 }
 
-func (b *Bot) handleCallback(callback *tgbotapi.CallbackQuery) error {
-	var msg tgbotapi.MessageConfig
+func (b *Bot) handleCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) error {
+	// Клавиатура из прошлой сессии меню может относиться к уже неактуальному состоянию
+	if b.staleCallback(ctx, callback) {
+		return nil
+	}
+	action, args, err := decodeCallback(callback.Data)
+	handler, ok := b.callbacks[action]
+	if err != nil || !ok {
+		// Кнопка из сообщения, отправленного прошлой версией бота
+		b.api.Request(tgbotapi.NewCallback(callback.ID, "Кнопка устарела, откройте меню заново"))
+		return nil
+	}
+	// Двойное нажатие на «🗑» или категорию не должно выполнять действие дважды
+	if b.repeatedCallback(callback, action) {
+		return nil
+	}
+	if err := handler(ctx, callback, args); err != nil {
+		b.repeats.forget(callbackKey(callback))
+		b.api.Request(tgbotapi.NewCallback(callback.ID, callbackErrorText(err)))
+		return err
+	}
 
-	switch {
-	case callback.Data == "action_add_income":
-		b.handleAddIncome(&tgbotapi.Message{
-			From: callback.From,
-			Chat: callback.Message.Chat,
-		})
-	case callback.Data == "action_add_expense":
-		b.handleAddExpense(&tgbotapi.Message{
-			From: callback.From,
-			Chat: callback.Message.Chat,
-		})
-	case callback.Data == "action_report":
-		b.handleReport(&tgbotapi.Message{
-			From: callback.From,
-			Chat: callback.Message.Chat,
-		})
-	case callback.Data == "action_categories":
-		b.handleCategories(&tgbotapi.Message{
-			From: callback.From,
-			Chat: callback.Message.Chat,
-		})
-	case callback.Data == "action_transactions":
-		b.handleTransactions(&tgbotapi.Message{
-			From: callback.From,
-			Chat: callback.Message.Chat,
-		})
-	case callback.Data == "add_income_category":
-		b.handleAddIncomeCategory(&tgbotapi.Message{
-			From: callback.From,
-			Chat: callback.Message.Chat,
-		})
-	case callback.Data == "add_expense_category":
-		b.handleAddExpenseCategory(&tgbotapi.Message{
-			From: callback.From,
-			Chat: callback.Message.Chat,
-		})
-	case callback.Data == "action_back":
-		msg = tgbotapi.NewMessage(callback.Message.Chat.ID, "*Главное меню*\nВыберите нужное действие 👇")
-		msg.ParseMode = "Markdown"
-		msg.ReplyMarkup = b.getMainKeyboard()
-		b.api.Send(msg)
-	case strings.HasPrefix(callback.Data, "delete_transaction_"):
-		transactionID := strings.TrimPrefix(callback.Data, "delete_transaction_")
-		err := b.service.DeleteTransaction(context.Background(), transactionID, callback.From.ID)
-		if err != nil {
-			return fmt.Errorf("error deleting transaction: %w", err)
-		}
-		// Обновляем список транзакций
-		b.handleTransactions(&tgbotapi.Message{
-			From: callback.From,
-			Chat: callback.Message.Chat,
-		})
-	case strings.HasPrefix(callback.Data, "delete_category_"):
-		categoryID := strings.TrimPrefix(callback.Data, "delete_category_")
-		err := b.service.DeleteCategory(context.Background(), categoryID, callback.From.ID)
-		if err != nil {
-			return fmt.Errorf("error deleting category: %w", err)
-		}
-		// Обновляем список категорий
-		b.handleCategories(&tgbotapi.Message{
-			From: callback.From,
-			Chat: callback.Message.Chat,
-		})
-	case strings.HasPrefix(callback.Data, "category_"):
-		categoryID := strings.TrimPrefix(callback.Data, "category_")
+	// Отвечаем на callback, чтобы убрать loading indicator
+	callbackResponse := tgbotapi.NewCallback(callback.ID, "")
+	b.api.Request(callbackResponse)
 
-		// Получаем категорию для определения типа транзакции
-		categories, err := b.service.GetCategories(context.Background(), callback.From.ID)
-		if err != nil {
-			return fmt.Errorf("error getting categories: %w", err)
+	return nil
+}
+
+// callbackHandlers - обработчики кнопок по действиям. Новая кнопка добавляется
+// константой в callback.go и обработчиком здесь
+func (b *Bot) callbackHandlers() map[callbackAction]callbackHandler {
+	// onMessage переиспользует обработчик команды для кнопки без аргументов
+	onMessage := func(handle func(context.Context, *tgbotapi.Message)) callbackHandler {
+		return func(ctx context.Context, callback *tgbotapi.CallbackQuery, _ callbackArgs) error {
+			handle(ctx, callbackMessage(callback))
+			return nil
 		}
+	}
 
-		var transactionType string
-		var categoryName string
-		for _, cat := range categories {
-			if cat.ID == categoryID {
-				transactionType = cat.Type
-				categoryName = cat.Name
-				break
+	return map[callbackAction]callbackHandler{
+		cbMenu: func(ctx context.Context, callback *tgbotapi.CallbackQuery, _ callbackArgs) error {
+			b.sendMainMenu(ctx, callback.Message.Chat.ID, callback.From.ID)
+			return nil
+		},
+		cbCancel: onMessage(b.handleCancel),
+		cbCategories: func(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+			if parentID := args.String(0); parentID != "" {
+				b.handleSubcategories(ctx, callbackMessage(callback), parentID)
+			} else {
+				b.handleCategories(ctx, callbackMessage(callback))
 			}
-		}
+			return nil
+		},
+		cbBalance:      onMessage(b.handleBalance),
+		cbTransactions: onMessage(b.handleTransactions),
+		cbReports: func(ctx context.Context, callback *tgbotapi.CallbackQuery, _ callbackArgs) error {
+			b.handleReport(callbackMessage(callback))
+			return nil
+		},
+		cbAdd: func(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+			if args.String(0) == "income" {
+				b.handleAddIncome(ctx, callbackMessage(callback))
+			} else {
+				b.handleAddExpense(ctx, callbackMessage(callback))
+			}
+			return nil
+		},
+		cbNewCategory: func(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+			if parentID := args.String(1); parentID != "" {
+				b.handleAddSubcategory(ctx, callbackMessage(callback), parentID)
+			} else if args.String(0) == "income" {
+				b.handleAddIncomeCategory(ctx, callbackMessage(callback))
+			} else {
+				b.handleAddExpenseCategory(ctx, callbackMessage(callback))
+			}
+			return nil
+		},
+		cbTransaction: b.handleTransactionCallback,
+		cbRecycleBin:  b.handleRecycleBinCallback,
+		cbDeleteTransaction: func(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+			if err := b.service.DeleteTransaction(ctx, args.String(0), callback.From.ID); err != nil {
+				return fmt.Errorf("error deleting transaction: %w", err)
+			}
+			b.sendMovedToRecycleBin(callback.Message.Chat.ID, "Транзакция", "tx", args.String(0))
+			// Обновляем список транзакций
+			b.handleTransactions(ctx, callbackMessage(callback))
+			return nil
+		},
+		cbDeleteCategory: func(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+			if err := b.service.DeleteCategory(ctx, args.String(0), callback.From.ID); err != nil {
+				return fmt.Errorf("error deleting category: %w", err)
+			}
+			b.sendMovedToRecycleBin(callback.Message.Chat.ID, "Категория и ее транзакции", "cat", args.String(0))
+			// Удалить можно только категорию из архива - обновляем архив
+			b.handleCategoryArchive(ctx, callbackMessage(callback))
+			return nil
+		},
+		cbDeleteAccount: func(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+			if err := b.service.DeleteAccount(ctx, args.String(0), callback.From.ID); err != nil {
+				return fmt.Errorf("error deleting account: %w", err)
+			}
+			b.handleBalance(ctx, callbackMessage(callback))
+			return nil
+		},
+		cbReport: func(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+			reportType, err := args.Int(0)
+			if err != nil || reportType < int(service.DailyReport) || reportType > int(service.YearlyReport) {
+				return fmt.Errorf("invalid report type: %s", callback.Data)
+			}
+			b.sendReport(ctx, callback.Message.Chat.ID, callback.From.ID, service.ReportType(reportType))
+			return nil
+		},
+		cbCharts:          b.handleChartsCallback,
+		cbForecast:        b.handleForecastCallback,
+		cbCompare:         b.handleCompareCallback,
+		cbIncome:          b.handleIncomeCallback,
+		cbReminder:        b.handleReminderCallback,
+		cbBroadcast:       b.handleBroadcastCallback,
+		cbCategory:        b.handleCategoryCallback,
+		cbSubcategory:     b.handleSubcategoryCallback,
+		cbCategoryArchive: b.handleCategoryArchiveCallback,
+		cbCategoryOrder:   b.handleCategoryOrderCallback,
+		cbCategoryPack:    b.handleCategoryPackCallback,
+		cbCategoryStyle:   b.handleCategoryStyleCallback,
+		cbQuickAmount:     b.handleQuickAmount,
+		cbAccount:         b.handleSelectAccount,
+		cbNewAccount:      b.handleNewAccount,
+		cbRecategorize:    b.handleRecategorizeCallback,
+		cbRecategorizeTo:  b.handleRecategorizeToCallback,
+		cbReceipt:         b.handleReceiptCallback,
+		cbImport:          b.handleImportCallback,
+		cbReconcile:       b.handleReconcileCallback,
+		cbDuplicate:       b.handleDuplicateCallback,
+		cbAdvice:          b.handleAdviceAccept,
+		cbBudget:          b.handleBudgetCallback,
+		cbPlan:            b.handlePlanCallback,
+		cbWishlist:        b.handleWishlistCallback,
+		cbGoal:            b.handleGoalCallback,
+		cbExport:          b.handleExportCallback,
+		cbSettings:        b.handleSettingsCallback,
+		cbReportSections:  b.handleReportSectionsCallback,
+		cbNetWorth:        b.handleNetWorthCallback,
+		cbFamily:          b.handleFamilyCallback,
+		cbWebhook:         b.handleIntegrationsCallback,
+		cbRetry:           b.handleRetryCallback,
+		cbDeleteMe:        b.handleDeleteMeCallback,
+		cbPIN:             b.handlePINCallback,
+		cbLedger:          b.handleLedgerCallback,
+		cbEvent:           b.handleEventCallback,
+	}
+}
 
-		// Сохраняем состояние в БД
-		state := &model.UserState{
-			UserID:           callback.From.ID,
-			SelectedCategory: categoryID,
-			TransactionType:  transactionType,
-		}
-		if err := b.saveUserState(context.Background(), state); err != nil {
-			return fmt.Errorf("error saving user state: %w", err)
-		}
+// handleCategoryCallback начинает ввод транзакции в выбранную категорию
+func (b *Bot) handleCategoryCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	categoryID := args.String(0)
 
-		msg = tgbotapi.NewMessage(callback.Message.Chat.ID,
-			fmt.Sprintf("*Категория:* %s\n\n"+
-				"Введите сумму и описание в формате:\n"+
-				"`1000 Покупка продуктов`", categoryName))
-		msg.ParseMode = "Markdown"
-		b.api.Send(msg)
-	case callback.Data == "report_daily":
-		b.sendReport(callback.Message.Chat.ID, callback.From.ID, service.DailyReport)
-	case callback.Data == "report_weekly":
-		b.sendReport(callback.Message.Chat.ID, callback.From.ID, service.WeeklyReport)
-	case callback.Data == "report_monthly":
-		b.sendReport(callback.Message.Chat.ID, callback.From.ID, service.MonthlyReport)
-	case callback.Data == "report_yearly":
-		b.sendReport(callback.Message.Chat.ID, callback.From.ID, service.YearlyReport)
-	case callback.Data == "report_charts":
-		// Получаем отчет для графиков
-		report, err := b.service.GetReport(context.Background(), callback.From.ID, service.MonthlyReport)
-		if err != nil {
-			b.sendErrorMessage(callback.Message.Chat.ID, "Не удалось сформировать отчет для графиков")
-			return nil
-		}
-		msg := tgbotapi.NewMessage(callback.Message.Chat.ID, "📊 Графический анализ...")
-		b.api.Send(msg)
-		err = b.sendCharts(context.Background(), callback.Message.Chat.ID, report)
-		if err != nil {
-			b.sendErrorMessage(callback.Message.Chat.ID, fmt.Sprintf("Не удалось сгенерировать графики: %v", err))
-		}
+	// Получаем категорию для определения типа транзакции
+	categories, err := b.service.GetCategories(ctx, callback.From.ID)
+	if err != nil {
+		return fmt.Errorf("error getting categories: %w", err)
 	}
 
-	// Отвечаем на callback, чтобы убрать loading indicator
-	callbackResponse := tgbotapi.NewCallback(callback.ID, "")
-	b.api.Request(callbackResponse)
+	var transactionType string
+	var categoryName string
+	if cat := findCategory(categories, categoryID); cat != nil {
+		transactionType = cat.Type
+		categoryName = categoryTitle(categories, cat)
+	}
+
+	// По умолчанию выбираем первый счет, его можно сменить кнопками
+	accounts, err := b.service.GetAccounts(ctx, callback.From.ID)
+	if err != nil {
+		log.Printf("Error getting accounts: %v", err)
+	}
+	selectedAccount := ""
+	if len(accounts) > 0 {
+		selectedAccount = accounts[0].ID
+	}
+
+	// Сохраняем состояние в БД
+	state := &model.UserState{
+		UserID:           callback.From.ID,
+		SelectedCategory: categoryID,
+		SelectedAccount:  selectedAccount,
+		TransactionType:  transactionType,
+		AwaitingAction:   model.StateAmount,
+	}
+	if err := b.saveUserState(ctx, state); err != nil {
+		return fmt.Errorf("error saving user state: %w", err)
+	}
 
+	msg := tgbotapi.NewMessage(callback.Message.Chat.ID,
+		fmt.Sprintf("*Категория:* %s\n\n"+
+			"Введите сумму и описание в формате:\n"+
+			"`1000 Покупка продуктов`", categoryName))
+	msg.ParseMode = "Markdown"
+
+	// Предлагаем частые суммы, чтобы записать типовую покупку в два нажатия
+	amounts, err := b.service.GetFrequentAmounts(ctx, callback.From.ID, categoryID, 6)
+	if err != nil {
+		log.Printf("Error getting frequent amounts: %v", err)
+	} else if len(amounts) > 0 {
+		msg.Text += "\n\nИли выберите одну из частых сумм:"
+	}
+	if len(accounts) > 0 {
+		msg.Text += fmt.Sprintf("\n\n*Счет:* %s", accounts[0].Name)
+	}
+	msg.ReplyMarkup = b.getTransactionInputKeyboard(categoryID, amounts, accounts)
+	b.api.Send(msg)
+	return nil
+}
+
+// handleChartsCallback предлагает выбрать график и отправляет выбранные графики
+func (b *Bot) handleChartsCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	chatID := callback.Message.Chat.ID
+	kind := args.String(0)
+	switch kind {
+	case "":
+		b.sendChartPicker(chatID)
+		return nil
+	case chartKindBudget:
+		// Бюджеты строятся по своим данным, месячный отчет для них не нужен
+		return b.sendBudgetCharts(ctx, chatID, callback.From.ID)
+	}
+
+	report, err := b.service.GetReport(ctx, callback.From.ID, chartPeriod(kind))
+	if err != nil {
+		b.sendServiceError(ctx, chatID, err, "Не удалось сформировать отчет для графиков")
+		return nil
+	}
+	err = b.sendCharts(ctx, chatID, callback.From.ID, report, kind)
+	if err != nil {
+		b.sendServiceError(ctx, chatID, err, "Не удалось сгенерировать графики")
+	}
 	return nil
 }
 
-func (b *Bot) handleMessage(message *tgbotapi.Message) error {
+func (b *Bot) handleMessage(ctx context.Context, message *tgbotapi.Message) error {
+	// Присланный файл - выписка для сверки
+	if message.Document != nil {
+		return b.handleDocument(ctx, message)
+	}
+
+	// Фото чека к транзакции, иначе фотография или текст QR-кода чека для импорта
+	if len(message.Photo) > 0 {
+		state, err := b.getUserState(ctx, message.From.ID)
+		if err != nil {
+			return fmt.Errorf("error getting user state: %w", err)
+		}
+		if state != nil && state.AwaitingAction == model.StateTransactionPhoto {
+			return b.attachPhotoFromMessage(ctx, message, state)
+		}
+		return b.handleReceiptPhoto(ctx, message)
+	}
+	if receipt.IsQR(message.Text) {
+		return b.handleReceiptQR(ctx, message, message.Text)
+	}
+
 	// Проверяем состояние пользователя в БД
-	state, err := b.getUserState(context.Background(), message.From.ID)
+	state, err := b.getUserState(ctx, message.From.ID)
 	if err != nil {
 		return fmt.Errorf("error getting user state: %w", err)
 	}
 
 	fmt.Printf("Current user state: %+v\n", state)
 
+	// Платеж, разделенный по категориям, можно ввести в любой момент вне других сценариев
+	if (state == nil || state.AwaitingAction == model.StateAmount) && service.IsSplitInput(message.Text) {
+		return b.handleSplitInput(ctx, message, state)
+	}
+
 	if state == nil {
 		// Если нет активного состояния, показываем главное меню
 		msg := tgbotapi.NewMessage(message.Chat.ID, "Выберите действие:")
@@ -301,89 +698,230 @@ func (b *Bot) handleMessage(message *tgbotapi.Message) error {
 		return nil
 	}
 
-	// Если ожидаем создание новой категории
-	if state.AwaitingAction == "new_category" {
-		fmt.Printf("Creating new category: %s, type: %s\n", message.Text, state.TransactionType)
-		category := model.Category{
-			UserID: message.From.ID,
-			Name:   message.Text,
-			Type:   state.TransactionType,
-		}
+	switch state.AwaitingAction {
+	case model.StateAmount:
+		return b.addTransactionFromMessage(ctx, message, state)
+	case model.StateNewCategory:
+		return b.createCategoryFromMessage(ctx, message, state)
+	case model.StateRenameCategory:
+		return b.renameCategoryFromMessage(ctx, message, state)
+	case model.StateNewAccount:
+		return b.createAccountFromMessage(ctx, message, state)
+	case model.StateNewLedger:
+		return b.createLedgerFromMessage(ctx, message)
+	case model.StateNewEvent:
+		return b.startEventFromMessage(ctx, message)
+	case model.StateWebhookURL:
+		return b.createWebhookFromMessage(ctx, message)
+	case model.StateNewAsset:
+		return b.createAssetFromMessage(ctx, message, state)
+	case model.StateNewHolding:
+		return b.saveHoldingFromMessage(ctx, message, state)
+	case model.StatePINUnlock:
+		return b.unlockFromMessage(ctx, message, state)
+	case model.StatePINSet:
+		return b.setPINFromMessage(ctx, message, state)
+	case model.StatePINConfirm:
+		return b.confirmPINFromMessage(ctx, message, state)
+	case model.StatePINDisable:
+		return b.disablePINFromMessage(ctx, message)
+	case model.StateCompareFirst, model.StateCompareNext:
+		return b.comparePeriodFromMessage(ctx, message, state)
+	case model.StateTransactionNote:
+		return b.noteFromMessage(ctx, message, state)
+	case model.StateCounterparty:
+		return b.businessFromMessage(ctx, message, state)
+	case model.StateWishlistAmount:
+		return b.allocateFromMessage(ctx, message, state)
+	case model.StateGoalAmount:
+		return b.saveToGoalFromMessage(ctx, message, state)
+	case model.StateTransactionPhoto:
+		b.sendErrorMessage(message.Chat.ID, "Пришлите фото чека, нажмите «Отмена» или отправьте /cancel")
+	case model.StateReceipt:
+		// Ожидаем выбор способа импорта чека кнопками
+		b.sendErrorMessage(message.Chat.ID, "Выберите способ импорта чека кнопками выше, нажмите «Отмена» или отправьте /cancel")
+	case model.StateReconcile:
+		// Ожидаем нажатия кнопок сверки
+		b.sendErrorMessage(message.Chat.ID, "Добавьте операции из выписки кнопками выше или отправьте /cancel")
+	case model.StateRecategorize, model.StateImport, model.StatePlanCategory:
+		// Ожидаем выбор категории кнопками
+		b.sendErrorMessage(message.Chat.ID, "Выберите категорию кнопками выше или отправьте /cancel")
+	}
+	return nil
+}
 
-		if err := b.service.CreateCategory(context.Background(), &category); err != nil {
-			b.sendErrorMessage(message.Chat.ID, fmt.Sprintf("Ошибка при создании категории: %v", err))
-			return nil
-		}
+// createCategoryFromMessage создает категорию с названием из сообщения
+func (b *Bot) createCategoryFromMessage(ctx context.Context, message *tgbotapi.Message, state *model.UserState) error {
+	fmt.Printf("Creating new category: %s, type: %s\n", message.Text, state.TransactionType)
+	emoji, name := splitCategoryName(message.Text)
+	category := model.Category{
+		UserID:   message.From.ID,
+		Name:     name,
+		Type:     state.TransactionType,
+		ParentID: state.Payload,
+		Emoji:    emoji,
+	}
+
+	err := b.service.CreateCategory(ctx, &category)
+	if text, ok := categoryErrorText(err); ok {
+		b.sendErrorMessage(message.Chat.ID, text)
+		return nil
+	}
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось создать категорию")
+		return fmt.Errorf("error creating category: %w", err)
+	}
 
-		// Очищаем состояние
-		if err := b.deleteUserState(context.Background(), message.From.ID); err != nil {
-			return fmt.Errorf("error deleting user state: %w", err)
-		}
+	// Очищаем состояние
+	if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+		return fmt.Errorf("error deleting user state: %w", err)
+	}
 
-		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Категория '%s' успешно создана! ✅", category.Name))
-		b.api.Send(msg)
-		b.handleCategories(message)
-		return nil
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Категория '%s %s' успешно создана! ✅", category.Icon(), category.Name))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		callbackButton("🎨 Значок и цвет", cbCategoryStyle, category.ID),
+	))
+	b.api.Send(msg)
+	if category.ParentID != "" {
+		b.handleSubcategories(ctx, message, category.ParentID)
+	} else {
+		b.handleCategories(ctx, message)
 	}
+	return nil
+}
 
-	// Обработка ввода суммы и описания транзакции
+// addTransactionFromMessage записывает транзакцию с суммой и описанием из сообщения
+// в категорию, выбранную на предыдущем шаге
+func (b *Bot) addTransactionFromMessage(ctx context.Context, message *tgbotapi.Message, state *model.UserState) error {
 	parts := strings.SplitN(message.Text, " ", 2)
-	amount, err := strconv.ParseFloat(parts[0], 64)
+	amount, currency, err := service.ParseAmount(parts[0])
 	if err != nil {
-		b.sendErrorMessage(message.Chat.ID, "Неверный формат суммы. Используйте число, например: 1000.50")
+		b.sendErrorMessage(message.Chat.ID, "Неверный формат суммы. Используйте число, например: 1000.50, или сумму в валюте: 25$")
 		return nil
 	}
 
-	// Если это расход, делаем сумму отрицательной
-	if state.TransactionType == "expense" {
-		amount = -amount
-	}
-
 	// Получаем описание, если оно есть
 	description := ""
 	if len(parts) > 1 {
 		description = parts[1]
 	}
 
-	err = b.service.AddTransaction(context.Background(),
+	transaction, err := b.service.AddCurrencyTransaction(ctx,
 		message.From.ID,
 		state.SelectedCategory,
+		state.SelectedAccount,
 		amount,
+		currency,
 		description)
 
 	if err != nil {
-		b.sendErrorMessage(message.Chat.ID, fmt.Sprintf("Ошибка при сохранении транзакции: %v", err))
+		b.sendServiceError(ctx, message.Chat.ID, err, "Ошибка при сохранении транзакции")
 		return nil
 	}
 
 	// Очищаем состояние после сохранения транзакции
-	if err := b.deleteUserState(context.Background(), message.From.ID); err != nil {
+	if err := b.deleteUserState(ctx, message.From.ID); err != nil {
 		return fmt.Errorf("error deleting user state: %w", err)
 	}
 
 	// Отправляем сообщение об успехе и показываем главное меню
-	msg := tgbotapi.NewMessage(message.Chat.ID, "Транзакция сохранена! ✅")
+	text := "Транзакция сохранена! ✅"
+
+	// Предупреждаем о возможной опечатке по предрасчитанной статистике
+	warning, err := b.service.CheckAmount(ctx, message.From.ID, state.SelectedCategory, transaction.Amount)
+	if err != nil {
+		log.Printf("Error checking amount: %v", err)
+	} else if warning != "" {
+		text += "\n\n⚠️ " + warning
+	}
+	text = b.withLimitWarning(ctx, message.From.ID, state.SelectedCategory, transaction.Amount, text)
+	text = b.withTodaySpending(ctx, message.From.ID, transaction.Amount, warning != "", text)
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+
+	b.warnDuplicate(ctx, message.From.ID, message.Chat.ID, transaction.Amount, description)
+	return nil
+}
+
+// handleQuickAmount сохраняет транзакцию с суммой, выбранной из кнопок частых сумм
+func (b *Bot) handleQuickAmount(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	categoryID := args.String(0)
+	amount, err := args.Float(1)
+	if err != nil {
+		return fmt.Errorf("invalid quick amount: %w", err)
+	}
+
+	categories, err := b.service.GetCategories(ctx, callback.From.ID)
+	if err != nil {
+		return fmt.Errorf("error getting categories: %w", err)
+	}
+
+	var category *model.Category
+	for i := range categories {
+		if categories[i].ID == categoryID {
+			category = &categories[i]
+			break
+		}
+	}
+	if category == nil {
+		b.sendErrorMessage(callback.Message.Chat.ID, "Категория не найдена")
+		return nil
+	}
+
+	// Счет берем из состояния, сохраненного при выборе категории
+	accountID := ""
+	state, err := b.getUserState(ctx, callback.From.ID)
+	if err != nil {
+		return fmt.Errorf("error getting user state: %w", err)
+	}
+	if state != nil && state.SelectedCategory == categoryID {
+		accountID = state.SelectedAccount
+	}
+
+	transaction, err := b.service.AddTransaction(ctx, callback.From.ID, categoryID, accountID, amount, "")
+	if err != nil {
+		b.sendServiceError(ctx, callback.Message.Chat.ID, err, "Ошибка при сохранении транзакции")
+		return nil
+	}
+
+	// Очищаем состояние, оставшееся после выбора категории
+	if err := b.deleteUserState(ctx, callback.From.ID); err != nil {
+		return fmt.Errorf("error deleting user state: %w", err)
+	}
+
+	text := fmt.Sprintf("Транзакция сохранена! ✅\n%s: %.2f₽", category.Name, math.Abs(amount))
+	msg := tgbotapi.NewMessage(callback.Message.Chat.ID,
+		b.withLimitWarning(ctx, callback.From.ID, categoryID, transaction.Amount, text))
 	msg.ReplyMarkup = b.getMainKeyboard()
 	b.api.Send(msg)
 
+	b.warnDuplicate(ctx, callback.From.ID, callback.Message.Chat.ID, transaction.Amount, "")
+
 	return nil
 }
 
 func (b *Bot) handleReport(message *tgbotapi.Message) {
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📊 За день", "report_daily"),
-			tgbotapi.NewInlineKeyboardButtonData("📈 За неделю", "report_weekly"),
+			callbackButton("📊 За день", cbReport, int(service.DailyReport)),
+			callbackButton("📈 За неделю", cbReport, int(service.WeeklyReport)),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📋 За месяц", "report_monthly"),
-			tgbotapi.NewInlineKeyboardButtonData("📅 За год", "report_yearly"),
+			callbackButton("📋 За месяц", cbReport, int(service.MonthlyReport)),
+			callbackButton("📅 За год", cbReport, int(service.YearlyReport)),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📊 Графики", "report_charts"),
+			callbackButton("📊 Графики", cbCharts),
+			callbackButton("🔮 Прогноз", cbForecast),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("« Назад", "action_back"),
+			callbackButton("⚖️ Сравнить периоды", cbCompare),
+			callbackButton("💼 Доходы", cbIncome),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("« Назад", cbMenu),
 		),
 	)
 
@@ -393,49 +931,39 @@ func (b *Bot) handleReport(message *tgbotapi.Message) {
 			"• За неделю - анализ трендов за последние 7 дней\n"+
 			"• За месяц - полный анализ за текущий месяц\n"+
 			"• За год - годовая статистика и тренды\n"+
-			"• Графики - визуальный анализ ваших финансов")
+			"• Графики - визуальный анализ ваших финансов\n"+
+			"• Прогноз - остаток на 30 дней вперед с учетом регулярных платежей\n"+
+			"• Сравнить периоды - два любых периода рядом, например отпуск и обычный месяц\n"+
+			"• Доходы - насколько стабилен доход и сколько его приходит из одного источника")
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = keyboard
 	b.api.Send(msg)
 }
 
-func (b *Bot) handleCategories(message *tgbotapi.Message) {
-	categories, err := b.service.GetCategories(context.Background(), message.From.ID)
+func (b *Bot) handleCategories(ctx context.Context, message *tgbotapi.Message) {
+	categories, err := b.service.GetCategories(ctx, message.From.ID)
 	if err != nil {
-		b.sendErrorMessage(message.Chat.ID, "Не удалось загрузить категории")
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить категории")
 		return
 	}
 
-	// Группируем категории по типу
-	incomeCategories := make([]model.Category, 0)
-	expenseCategories := make([]model.Category, 0)
-	for _, cat := range categories {
-		if cat.Type == "income" {
-			incomeCategories = append(incomeCategories, cat)
-		} else {
-			expenseCategories = append(expenseCategories, cat)
-		}
-	}
+	// Группируем категории по типу, подкатегории - под родителем. Архив показывается отдельно
+	incomeCategories := categoryTree(model.ActiveCategories(categories), "income")
+	expenseCategories := categoryTree(model.ActiveCategories(categories), "expense")
 
 	text := "*Ваши категории*\n\n"
-	if len(incomeCategories) > 0 {
-		text += "💰 *Доходы:*\n"
-		for _, cat := range incomeCategories {
-			text += fmt.Sprintf("• %s\n", cat.Name)
-		}
+	if incomeCategories != "" {
+		text += "💰 *Доходы:*\n" + incomeCategories
 	}
 
-	if len(expenseCategories) > 0 {
-		if len(incomeCategories) > 0 {
+	if expenseCategories != "" {
+		if incomeCategories != "" {
 			text += "\n"
 		}
-		text += "💸 *Расходы:*\n"
-		for _, cat := range expenseCategories {
-			text += fmt.Sprintf("• %s\n", cat.Name)
-		}
+		text += "💸 *Расходы:*\n" + expenseCategories
 	}
 
-	text += "\nНажмите на категорию для добавления транзакции или 🗑 для удаления"
+	text += "\nНажмите на категорию для добавления транзакции, 📂 - подкатегории, 🎨 - значок, цвет и название, 🗄 - в архив"
 
 	msg := tgbotapi.NewMessage(message.Chat.ID, text)
 	msg.ParseMode = "Markdown"
@@ -444,17 +972,17 @@ func (b *Bot) handleCategories(message *tgbotapi.Message) {
 }
 
 // Добавляем новые методы для обработки доходов и расходов
-func (b *Bot) handleAddExpense(message *tgbotapi.Message) {
-	categories, err := b.service.GetCategories(context.Background(), message.From.ID)
+func (b *Bot) handleAddExpense(ctx context.Context, message *tgbotapi.Message) {
+	categories, err := b.service.GetInputCategories(ctx, message.From.ID)
 	if err != nil {
-		b.sendErrorMessage(message.Chat.ID, "Не удалось загрузить категории")
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить категории")
 		return
 	}
 
 	// Фильтруем только категории расходов
 	expenseCategories := make([]model.Category, 0)
 	for _, cat := range categories {
-		if cat.Type == "expense" {
+		if cat.Type == "expense" && !cat.Archived {
 			expenseCategories = append(expenseCategories, cat)
 		}
 	}
@@ -468,23 +996,24 @@ func (b *Bot) handleAddExpense(message *tgbotapi.Message) {
 		return
 	}
 
-	msg := tgbotapi.NewMessage(message.Chat.ID, "*Добавление расхода*\n\nВыберите категорию:")
+	msg := tgbotapi.NewMessage(message.Chat.ID, "*Добавление расхода*\n\nВыберите категорию:\n\n"+
+		"Чтобы разделить покупку на несколько категорий, отправьте:\n"+splitHint)
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = b.getSelectCategoryKeyboard(expenseCategories)
 	b.api.Send(msg)
 }
 
-func (b *Bot) handleAddIncome(message *tgbotapi.Message) {
-	categories, err := b.service.GetCategories(context.Background(), message.From.ID)
+func (b *Bot) handleAddIncome(ctx context.Context, message *tgbotapi.Message) {
+	categories, err := b.service.GetInputCategories(ctx, message.From.ID)
 	if err != nil {
-		b.sendErrorMessage(message.Chat.ID, "Не удалось загрузить категории")
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить категории")
 		return
 	}
 
 	// Фильтруем только категории доходов
 	incomeCategories := make([]model.Category, 0)
 	for _, cat := range categories {
-		if cat.Type == "income" {
+		if cat.Type == "income" && !cat.Archived {
 			incomeCategories = append(incomeCategories, cat)
 		}
 	}
@@ -505,43 +1034,45 @@ func (b *Bot) handleAddIncome(message *tgbotapi.Message) {
 }
 
 // Добавляем новые методы для управления категориями
-func (b *Bot) handleAddIncomeCategory(message *tgbotapi.Message) {
+func (b *Bot) handleAddIncomeCategory(ctx context.Context, message *tgbotapi.Message) {
 	state := &model.UserState{
 		UserID:          message.From.ID,
 		TransactionType: "income",
-		AwaitingAction:  "new_category",
+		AwaitingAction:  model.StateNewCategory,
 	}
-	if err := b.saveUserState(context.Background(), state); err != nil {
-		b.sendErrorMessage(message.Chat.ID, "Ошибка при сохранении состояния")
+	if err := b.saveUserState(ctx, state); err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Ошибка при сохранении состояния")
 		return
 	}
 
-	msg := tgbotapi.NewMessage(message.Chat.ID, "*Новая категория дохода*\n\nВведите название:")
+	msg := tgbotapi.NewMessage(message.Chat.ID, "*Новая категория дохода*\n\nВведите название. Можно начать со значка: `💼 Фриланс`")
 	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = cancelKeyboard
 	b.api.Send(msg)
 }
 
-func (b *Bot) handleAddExpenseCategory(message *tgbotapi.Message) {
+func (b *Bot) handleAddExpenseCategory(ctx context.Context, message *tgbotapi.Message) {
 	state := &model.UserState{
 		UserID:          message.From.ID,
 		TransactionType: "expense",
-		AwaitingAction:  "new_category",
+		AwaitingAction:  model.StateNewCategory,
 	}
-	if err := b.saveUserState(context.Background(), state); err != nil {
-		b.sendErrorMessage(message.Chat.ID, "Ошибка при сохранении состояния")
+	if err := b.saveUserState(ctx, state); err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Ошибка при сохранении состояния")
 		return
 	}
 
-	msg := tgbotapi.NewMessage(message.Chat.ID, "*Новая категория расхода*\n\nВведите название:")
+	msg := tgbotapi.NewMessage(message.Chat.ID, "*Новая категория расхода*\n\nВведите название. Можно начать со значка: `🍔 Кафе`")
 	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = cancelKeyboard
 	b.api.Send(msg)
 }
 
-func (b *Bot) handleTransactions(message *tgbotapi.Message) {
+func (b *Bot) handleTransactions(ctx context.Context, message *tgbotapi.Message) {
 	// Получаем последние 10 транзакций
-	transactions, err := b.service.GetRecentTransactions(context.Background(), message.From.ID, 10)
+	transactions, err := b.service.GetRecentTransactions(ctx, message.From.ID, 10)
 	if err != nil {
-		b.sendErrorMessage(message.Chat.ID, "Не удалось загрузить транзакции")
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить транзакции")
 		return
 	}
 
@@ -554,43 +1085,55 @@ func (b *Bot) handleTransactions(message *tgbotapi.Message) {
 	}
 
 	// Получаем категории для отображения их названий
-	categories, err := b.service.GetCategories(context.Background(), message.From.ID)
+	categories, err := b.service.GetCategories(ctx, message.From.ID)
 	if err != nil {
-		b.sendErrorMessage(message.Chat.ID, "Не удалось загрузить категории")
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить категории")
 		return
 	}
 
-	categoryNames := make(map[string]string)
+	categoriesByID := make(map[string]model.Category)
 	for _, cat := range categories {
-		categoryNames[cat.ID] = cat.Name
+		categoriesByID[cat.ID] = cat
 	}
 
-	text := "*Последние транзакции*\nНажмите на транзакцию для её удаления\n\n"
+	text := "*Последние транзакции*\nНажмите на транзакцию, чтобы открыть ее: заметка, фото чека, удаление\n\n"
 	var buttons [][]tgbotapi.InlineKeyboardButton
 
 	for _, t := range transactions {
-		categoryName := categoryNames[t.CategoryID]
-		emoji := "💸"
-		amountStr := fmt.Sprintf("%.2f₽", -t.Amount)
-		if t.Amount > 0 {
-			emoji = "💰"
-			amountStr = fmt.Sprintf("%.2f₽", t.Amount)
+		amountStr := fmt.Sprintf("%.2f₽", t.AbsAmount())
+		transactionType := model.TransactionExpense
+		if t.IsIncome() {
+			transactionType = model.TransactionIncome
+		}
+
+		category, ok := categoriesByID[t.CategoryID]
+		categoryName, emoji := category.Name, category.Icon()
+		switch {
+		case t.IsSplit:
+			categoryName, emoji = "Несколько категорий", "🔀"
+		case !ok:
+			emoji = model.TypeIcon(transactionType)
 		}
 
-		text += fmt.Sprintf("%s *%s*: %s _%s_\n",
-			emoji, categoryName, amountStr, t.Description)
+		mark := ""
+		if t.Note != "" || t.AttachmentPath != "" {
+			mark = " 📎"
+		}
+		text += fmt.Sprintf("%s *%s*: %s _%s_%s\n",
+			emoji, categoryName, amountStr, t.Description, mark)
 
 		buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
-			tgbotapi.NewInlineKeyboardButtonData(
+			callbackButton(
 				fmt.Sprintf("%s %s: %s", emoji, categoryName, amountStr),
-				"delete_transaction_"+t.ID,
+				cbTransaction, t.ID,
 			),
 		})
 	}
 
-	// Добавляем кнопку "Назад"
+	// Добавляем кнопки корзины и "Назад"
 	buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("« Назад", "action_back"),
+		callbackButton("🗑 Корзина", cbRecycleBin),
+		callbackButton("« Назад", cbMenu),
 	})
 
 	msg := tgbotapi.NewMessage(message.Chat.ID, text)
@@ -599,248 +1142,134 @@ func (b *Bot) handleTransactions(message *tgbotapi.Message) {
 	b.api.Send(msg)
 }
 
-func (b *Bot) sendReport(chatID int64, userID int64, reportType service.ReportType) {
-	report, err := b.service.GetReport(context.Background(), userID, reportType)
+func (b *Bot) sendReport(ctx context.Context, chatID int64, userID int64, reportType service.ReportType) {
+	report, err := b.service.GetReport(ctx, userID, reportType)
 	if err != nil {
-		b.sendErrorMessage(chatID, "Не удалось сформировать отчет")
+		b.sendServiceError(ctx, chatID, err, "Не удалось сформировать отчет")
 		return
 	}
 
-	// Формируем текст отчета
-	text := fmt.Sprintf("📊 *Отчет за %s*\n\n", report.Period)
+	settings := b.reportSettings(ctx, userID)
+	view := reportView{BaseReport: report, Compact: settings.CompactReports(), Sections: settings.Sections()}
 
-	// Основные показатели
-	text += "*Основные показатели:*\n"
-	text += fmt.Sprintf("💰 Доходы: *%.0f₽*", report.TotalIncome)
-	if report.Trends.PeriodComparison.IncomeChange != 0 {
-		if report.Trends.PeriodComparison.IncomeChange > 0 {
-			text += fmt.Sprintf(" (+%.1f%%⬆️)", report.Trends.PeriodComparison.IncomeChange)
-		} else {
-			text += fmt.Sprintf(" (%.1f%%⬇️)", report.Trends.PeriodComparison.IncomeChange)
-		}
+	// Вклад участников общего бюджета
+	if len(report.Members) > 1 {
+		view.Members = formatMemberStats(report.Members)
 	}
-	text += "\n"
 
-	text += fmt.Sprintf("💸 Расходы: *%.0f₽*", report.TotalExpenses)
-	if report.Trends.PeriodComparison.ExpenseChange != 0 {
-		if report.Trends.PeriodComparison.ExpenseChange > 0 {
-			text += fmt.Sprintf(" (+%.1f%%⬆️)", report.Trends.PeriodComparison.ExpenseChange)
-		} else {
-			text += fmt.Sprintf(" (%.1f%%⬇️)", report.Trends.PeriodComparison.ExpenseChange)
-		}
+	// Остатки по счетам
+	balances, err := b.service.GetAccountBalances(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting account balances: %v", err)
+	} else if len(balances) > 0 {
+		view.Accounts = formatAccountBalances(balances)
+	}
+
+	// Добавляем кнопки. К недельному отчету сразу предлагается график недели по дням
+	chartsButton := callbackButton("📊 Графики", cbCharts)
+	if reportType == service.WeeklyReport {
+		chartsButton = callbackButton("📊 График по дням", cbCharts, chartKindWeek)
 	}
-	text += "\n"
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			chartsButton,
+			callbackButton("« В меню", cbMenu),
+		),
+	)
 
-	text += fmt.Sprintf("💵 Баланс: *%.0f₽*", report.Balance)
-	if report.Trends.PeriodComparison.BalanceChange != 0 {
-		if report.Trends.PeriodComparison.BalanceChange > 0 {
-			text += fmt.Sprintf(" (+%.1f%%⬆️)", report.Trends.PeriodComparison.BalanceChange)
+	// В месячный отчет добавляем план и факт и рекомендации с кнопками принятия
+	if reportType == service.MonthlyReport {
+		plan, err := b.service.GetPlanVsFact(ctx, userID, report.StartDate)
+		if err != nil {
+			log.Printf("Error getting plan vs fact: %v", err)
 		} else {
-			text += fmt.Sprintf(" (%.1f%%⬇️)", report.Trends.PeriodComparison.BalanceChange)
+			view.Plan = formatPlanVsFact(plan)
 		}
-	}
-	text += "\n\n"
-
-	// Статистика транзакций
-	text += "*Статистика транзакций:*\n"
-	text += fmt.Sprintf("• Всего: *%.d* (💰 *%d*, 💸 *%d*)\n",
-		report.TransactionData.TotalCount,
-		report.TransactionData.IncomeCount,
-		report.TransactionData.ExpenseCount)
-	text += fmt.Sprintf("• Средний доход: *%.0f₽*\n", report.TransactionData.AvgIncome)
-	text += fmt.Sprintf("• Средний расход: *%.0f₽*\n", report.TransactionData.AvgExpense)
-	text += fmt.Sprintf("• В день (доходы): *%.0f₽*\n", report.TransactionData.DailyAvgIncome)
-	text += fmt.Sprintf("• В день (расходы): *%.0f₽*\n\n", report.TransactionData.DailyAvgExpense)
-
-	// Максимальные транзакции
-	text += "*Крупнейшие транзакции:*\n"
-	if report.TransactionData.MaxIncome.Amount > 0 {
-		text += fmt.Sprintf("💰 +*%.0f₽*: %s\n",
-			report.TransactionData.MaxIncome.Amount,
-			report.TransactionData.MaxIncome.Description)
-	}
-	if report.TransactionData.MaxExpense.Amount > 0 {
-		text += fmt.Sprintf("💸 -*%.0f₽*: %s\n\n",
-			report.TransactionData.MaxExpense.Amount,
-			report.TransactionData.MaxExpense.Description)
-	}
-
-	// Категории расходов
-	if len(report.CategoryData.Expenses) > 0 {
-		text += "*Топ категорий расходов:*\n"
-		for _, cat := range report.CategoryData.Expenses {
-			text += fmt.Sprintf("• *%s*: *%.0f₽* (%.1f%%)",
-				cat.Name, cat.Amount, cat.Share)
-			if cat.TrendPercent != 0 {
-				if cat.TrendPercent > 0 {
-					text += fmt.Sprintf(" (+%.1f%%⬆️)", cat.TrendPercent)
-				} else {
-					text += fmt.Sprintf(" (%.1f%%⬇️)", cat.TrendPercent)
-				}
-			}
-			text += "\n"
+		if report.TimeOfDay.Night.Count > 0 {
+			view.Night = service.NightHours()
 		}
-		text += "\n"
-	}
-
-	// Категории доходов
-	if len(report.CategoryData.Income) > 0 {
-		text += "*Топ категорий доходов:*\n"
-		for _, cat := range report.CategoryData.Income {
-			text += fmt.Sprintf("• *%s*: *%.0f₽* (%.1f%%)",
-				cat.Name, cat.Amount, cat.Share)
-			if cat.TrendPercent != 0 {
-				if cat.TrendPercent > 0 {
-					text += fmt.Sprintf(" (+%.1f%%⬆️)", cat.TrendPercent)
-				} else {
-					text += fmt.Sprintf(" (%.1f%%⬇️)", cat.TrendPercent)
-				}
-			}
-			text += "\n"
+		advices, err := b.service.GetSavingsAdvice(ctx, userID)
+		if err != nil {
+			log.Printf("Error getting savings advice: %v", err)
+		} else if len(advices) > 0 {
+			view.Advice = formatAdvices(advices)
+			adviceKeyboard := b.getAdviceKeyboard(advices)
+			// Кнопка "Назад" уже есть в основной клавиатуре отчета
+			adviceRows := adviceKeyboard.InlineKeyboard[:len(adviceKeyboard.InlineKeyboard)-1]
+			keyboard.InlineKeyboard = append(adviceRows, keyboard.InlineKeyboard...)
 		}
-		text += "\n"
 	}
 
-	// Значительные изменения
-	text += "*Значительные изменения:*\n"
-	if report.CategoryData.Changes.FastestGrowingExpense.Name != "" {
-		text += fmt.Sprintf("📈 *Быстрее всего растут расходы в категории '%s': %.1f%%*\n",
-			report.CategoryData.Changes.FastestGrowingExpense.Name,
-			report.CategoryData.Changes.FastestGrowingExpense.ChangePercent)
-	}
-	if report.CategoryData.Changes.LargestDropExpense.Name != "" {
-		text += fmt.Sprintf("📉 *Сильнее всего снизились расходы в '%s': %.1f%%*\n",
-			report.CategoryData.Changes.LargestDropExpense.Name,
-			report.CategoryData.Changes.LargestDropExpense.ChangePercent)
-	}
-	if report.CategoryData.Changes.FastestGrowingIncome.Name != "" {
-		text += fmt.Sprintf("📈 *Быстрее всего растут доходы в '%s': %.1f%%*\n",
-			report.CategoryData.Changes.FastestGrowingIncome.Name,
-			report.CategoryData.Changes.FastestGrowingIncome.ChangePercent)
-	}
-	if report.CategoryData.Changes.LargestDropIncome.Name != "" {
-		text += fmt.Sprintf("📉 *Сильнее всего снизились доходы в '%s': %.1f%%*\n",
-			report.CategoryData.Changes.LargestDropIncome.Name,
-			report.CategoryData.Changes.LargestDropIncome.ChangePercent)
+	text, err := renderReport(ctx, "report", view)
+	if err != nil {
+		b.reportError(ctx, err)
+		b.sendErrorMessage(chatID, "Не удалось сформировать отчет")
+		return
 	}
 
-	// Добавляем кнопки
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📊 Графики", "report_charts"),
-			tgbotapi.NewInlineKeyboardButtonData("« В меню", "action_back"),
-		),
-	)
-
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = keyboard
 	b.api.Send(msg)
 }
 
-func (b *Bot) sendCharts(ctx context.Context, chatID int64, report *service.BaseReport) error {
+// sendCharts отправляет графики отчета вида kind, chartKindAll - все графики месяца одним альбомом
+func (b *Bot) sendCharts(ctx context.Context, chatID, userID int64, report *service.BaseReport, kind string) error {
 	// Отправляем сообщение о начале генерации
 	msg := tgbotapi.NewMessage(chatID, "📊 Генерация графиков...")
 	b.api.Send(msg)
 
-	// Генерируем все графики
-	log.Printf("Generating financial dashboard...")
-	dashboardData, err := b.chartGen.GenerateFinancialDashboard(report)
-	if err != nil {
-		return fmt.Errorf("failed to generate financial dashboard: %w", err)
-	}
+	delivery := b.chartDelivery(ctx, userID)
 
-	log.Printf("Generating expense categories analysis...")
-	expenseCategoriesData, err := b.chartGen.GenerateCategoryPieChart(report, true)
-	if err != nil {
-		return fmt.Errorf("failed to generate expense categories chart: %w", err)
-	}
-
-	log.Printf("Generating income categories analysis...")
-	incomeCategoriesData, err := b.chartGen.GenerateCategoryPieChart(report, false)
-	if err != nil {
-		return fmt.Errorf("failed to generate income categories chart: %w", err)
-	}
-
-	log.Printf("Generating trends chart...")
-	trendsData, err := b.chartGen.GenerateTrendChart(report)
-	if err != nil {
-		return fmt.Errorf("failed to generate trends chart: %w", err)
-	}
-
-	log.Printf("Generating balance chart...")
-	balanceData, err := b.chartGen.GenerateBalanceChart(report)
-	if err != nil {
-		return fmt.Errorf("failed to generate balance chart: %w", err)
-	}
-
-	// Собираем все графики в одно сообщение
+	// Собираем графики в одно сообщение, у каждого - подпись с главным выводом
 	var media []interface{}
-
-	if len(dashboardData) > 0 {
-		media = append(media, tgbotapi.NewInputMediaPhoto(tgbotapi.FileBytes{
-			Name:  "1_dashboard.png",
-			Bytes: dashboardData,
-		}))
-	}
-
-	if len(expenseCategoriesData) > 0 {
-		media = append(media, tgbotapi.NewInputMediaPhoto(tgbotapi.FileBytes{
-			Name:  "2_expenses.png",
-			Bytes: expenseCategoriesData,
-		}))
-	}
-
-	if len(incomeCategoriesData) > 0 {
-		media = append(media, tgbotapi.NewInputMediaPhoto(tgbotapi.FileBytes{
-			Name:  "3_income.png",
-			Bytes: incomeCategoriesData,
-		}))
-	}
-
-	if len(trendsData) > 0 {
-		media = append(media, tgbotapi.NewInputMediaPhoto(tgbotapi.FileBytes{
-			Name:  "4_trends.png",
-			Bytes: trendsData,
-		}))
-	}
-
-	if len(balanceData) > 0 {
-		media = append(media, tgbotapi.NewInputMediaPhoto(tgbotapi.FileBytes{
-			Name:  "5_balance.png",
-			Bytes: balanceData,
-		}))
+	var single tgbotapi.Chattable
+	for _, chart := range reportCharts {
+		all := kind == chartKindAll && chart.period == service.MonthlyReport
+		if !all && chart.kind != kind {
+			continue
+		}
+		// Вид графика трендов выбирается в настройках
+		if chart.kind == chartKindTrend && delivery.absoluteTrends {
+			chart = b.absoluteTrendChart(ctx, userID, chart)
+		}
+		log.Printf("Generating %s chart...", chart.name)
+		data, err := chart.generate(delivery.charts, report)
+		if err != nil {
+			return fmt.Errorf("failed to generate %s chart: %w", chart.name, err)
+		}
+		if len(data) == 0 {
+			continue
+		}
+		caption := chart.caption(report)
+		media = append(media, delivery.media(chart.name, data, caption))
+		single = delivery.captioned(chatID, chart.name, data, caption)
 	}
 
-	if len(media) == 0 {
+	switch len(media) {
+	case 0:
 		msg := tgbotapi.NewMessage(chatID, "❌ Недостаточно данных для построения графиков")
 		b.api.Send(msg)
 		return nil
-	}
-
-	// Добавляем описание к первому изображению
-	if mediaPhoto, ok := media[0].(*tgbotapi.InputMediaPhoto); ok {
-		mediaPhoto.Caption = "📊 *Графический анализ*\n\n" +
-			"1. Динамика доходов и расходов\n" +
-			"2. Распределение расходов по категориям\n" +
-			"3. Распределение доходов по категориям\n" +
-			"4. Тренды изменений\n" +
-			"5. Сравнение периодов"
-		mediaPhoto.ParseMode = "Markdown"
-	}
-
-	// Отправляем все графики одним сообщением
-	mediaGroup := tgbotapi.NewMediaGroup(chatID, media)
-	_, err = b.api.SendMediaGroup(mediaGroup)
-	if err != nil {
-		return fmt.Errorf("failed to send charts: %w", err)
+	case 1:
+		// Альбом в Telegram - от двух файлов
+		if _, err := b.api.Send(single); err != nil {
+			return fmt.Errorf("failed to send chart: %w", err)
+		}
+	default:
+		if _, err := b.api.SendMediaGroup(tgbotapi.NewMediaGroup(chatID, media)); err != nil {
+			return fmt.Errorf("failed to send charts: %w", err)
+		}
 	}
 
 	// Добавляем кнопки навигации
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📊 К отчетам", "action_report"),
-			tgbotapi.NewInlineKeyboardButtonData("« В меню", "action_back"),
+			callbackButton("📈 Другой график", cbCharts),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("📊 К отчетам", cbReports),
+			callbackButton("« В меню", cbMenu),
 		),
 	)
 
@@ -858,53 +1287,51 @@ func (b *Bot) sendErrorMessage(chatID int64, text string) {
 
 // SendDailyReport отправляет ежедневный отчет пользователю
 func (b *Bot) SendDailyReport(ctx context.Context, userID int64, report *service.BaseReport) error {
-	// Формируем текст отчета
-	text := "*Ваша финансовая сводка за прошедший день:*\n\n"
-
-	// Основные показатели
-	text += "*Основные показатели:*\n"
-	text += fmt.Sprintf("💰 Доходы: %.2f₽", report.TotalIncome)
-	if report.Trends.PeriodComparison.IncomeChange != 0 {
-		if report.Trends.PeriodComparison.IncomeChange > 0 {
-			text += fmt.Sprintf(" (+%.1f%%⬆️)", report.Trends.PeriodComparison.IncomeChange)
-		} else {
-			text += fmt.Sprintf(" (%.1f%%⬇️)", report.Trends.PeriodComparison.IncomeChange)
-		}
-	}
-	text += "\n"
-
-	text += fmt.Sprintf("💸 Расходы: %.2f₽", report.TotalExpenses)
-	if report.Trends.PeriodComparison.ExpenseChange != 0 {
-		if report.Trends.PeriodComparison.ExpenseChange > 0 {
-			text += fmt.Sprintf(" (+%.1f%%⬆️)", report.Trends.PeriodComparison.ExpenseChange)
-		} else {
-			text += fmt.Sprintf(" (%.1f%%⬇️)", report.Trends.PeriodComparison.ExpenseChange)
+	// Сводка за день по умолчанию короткая: разделы в нее попадают, только если
+	// пользователь собрал отчет сам в /report_settings
+	settings := b.reportSettings(ctx, userID)
+	view := reportView{
+		BaseReport: report,
+		Compact:    settings.CompactDailyReports(),
+		Sections:   settings.ReportSections,
+	}
+	// Компактная сводка заканчивается остатком бюджетов на сегодня
+	if view.Compact {
+		budget, err := b.service.GetBudgetToday(ctx, userID)
+		if err != nil {
+			log.Printf("Error getting budget today: %v", err)
+		} else if budget != nil {
+			view.Budget = formatBudgetToday(budget)
 		}
 	}
-	text += "\n"
-
-	text += fmt.Sprintf("💵 Баланс: %.2f₽", report.Balance)
-	if report.Trends.PeriodComparison.BalanceChange != 0 {
-		if report.Trends.PeriodComparison.BalanceChange > 0 {
-			text += fmt.Sprintf(" (+%.1f%%⬆️)", report.Trends.PeriodComparison.BalanceChange)
-		} else {
-			text += fmt.Sprintf(" (%.1f%%⬇️)", report.Trends.PeriodComparison.BalanceChange)
-		}
+	text, err := renderReport(ctx, "daily", view)
+	if err != nil {
+		return err
 	}
-	text += "\n\n"
 
 	// Добавляем кнопки
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📊 Подробный отчет", "report_daily"),
-			tgbotapi.NewInlineKeyboardButtonData("📈 Графики", "report_charts"),
+			callbackButton("📊 Подробный отчет", cbReport, int(service.DailyReport)),
+			callbackButton("📈 Графики", cbCharts),
 		),
 	)
 
 	msg := tgbotapi.NewMessage(userID, text)
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = keyboard
-	_, err := b.api.Send(msg)
+	_, err = b.api.Send(msg)
 
 	return err
 }
+
+// reportSettings возвращает настройки вида отчетов. Если настройки не загрузились,
+// показываем подробный отчет с разделами по умолчанию
+func (b *Bot) reportSettings(ctx context.Context, userID int64) *model.UserSettings {
+	settings, err := b.service.GetUserSettings(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting settings: %v", err)
+		return model.DefaultUserSettings(userID)
+	}
+	return settings
+}
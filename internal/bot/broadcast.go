@@ -0,0 +1,189 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+const (
+	// broadcastBatch - сколько сообщений рассылки отправляется за один вызов DeliverBroadcasts:
+	// при 30 сообщениях в секунду это около 20 секунд, функция успевает до таймаута
+	broadcastBatch = 600
+	// broadcastDeliveryInterval - как часто рассылки проверяются в режиме long polling
+	broadcastDeliveryInterval = time.Minute
+)
+
+// broadcastMessage - сообщение рассылки. Предпросмотр администратору отправляется так же,
+// поэтому ошибка разметки видна до отправки пользователям
+func broadcastMessage(chatID int64, broadcast *model.Broadcast) tgbotapi.MessageConfig {
+	msg := tgbotapi.NewMessage(chatID, broadcast.Text)
+	msg.ParseMode = "Markdown"
+	return msg
+}
+
+// handleBroadcast создает черновик рассылки из текста команды и показывает предпросмотр
+// с выбором получателей. Доступна только администраторам
+func (b *Bot) handleBroadcast(ctx context.Context, message *tgbotapi.Message) {
+	if !b.isAdmin(message.From.ID) {
+		return
+	}
+
+	text := strings.TrimSpace(message.CommandArguments())
+	if text == "" {
+		b.api.Send(tgbotapi.NewMessage(message.Chat.ID,
+			"📣 Рассылка: /broadcast <текст>\n\n"+
+				"Текст может занимать несколько строк и использовать разметку Markdown. "+
+				"Перед отправкой придет предпросмотр и выбор получателей"))
+		return
+	}
+
+	broadcast, err := b.service.CreateBroadcast(ctx, message.From.ID, text)
+	if err != nil {
+		log.Printf("Error creating broadcast: %v", err)
+		b.sendErrorMessage(message.Chat.ID, "Не удалось сохранить рассылку")
+		return
+	}
+
+	if _, err := b.api.Send(broadcastMessage(message.Chat.ID, broadcast)); err != nil {
+		b.service.CancelBroadcast(ctx, broadcast.ID)
+		b.sendErrorMessage(message.Chat.ID, "Telegram не принял текст - проверьте разметку Markdown")
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, segment := range service.BroadcastSegments {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			callbackButton(segment.Title, cbBroadcast, "seg", broadcast.ID, segment.ID),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		callbackButton("❌ Отмена", cbBroadcast, "cancel", broadcast.ID),
+	))
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, "☝️ Предпросмотр рассылки. Кому отправить?")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.api.Send(msg)
+}
+
+// handleBroadcastCallback выбирает получателей, подтверждает или отменяет рассылку
+func (b *Bot) handleBroadcastCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	if !b.isAdmin(callback.From.ID) {
+		return nil
+	}
+	chatID := callback.Message.Chat.ID
+	id := args.String(1)
+
+	var err error
+	switch args.String(0) {
+	case "seg":
+		var broadcast *model.Broadcast
+		var recipients int
+		broadcast, recipients, err = b.service.SetBroadcastSegment(ctx, id, args.String(2))
+		if err != nil {
+			break
+		}
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("%s: получателей - %d. Отправить?",
+			service.BroadcastSegmentTitle(broadcast.Segment), recipients))
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				callbackButton("✅ Отправить", cbBroadcast, "send", broadcast.ID),
+				callbackButton("❌ Отмена", cbBroadcast, "cancel", broadcast.ID),
+			),
+		)
+		b.api.Send(msg)
+	case "send":
+		if _, err = b.service.QueueBroadcast(ctx, id); err == nil {
+			b.api.Send(tgbotapi.NewMessage(chatID,
+				"📣 Рассылка поставлена в очередь. Когда она уйдет всем получателям, придет итог"))
+		}
+	case "cancel":
+		if err = b.service.CancelBroadcast(ctx, id); err == nil {
+			b.api.Send(tgbotapi.NewMessage(chatID, "Рассылка отменена"))
+		}
+	}
+
+	switch {
+	case errors.Is(err, service.ErrBroadcastNotDraft):
+		b.sendErrorMessage(chatID, "Рассылка уже отправляется или отменена")
+	case errors.Is(err, service.ErrBroadcastNotFound):
+		b.sendErrorMessage(chatID, "Рассылка не найдена")
+	case err != nil:
+		b.sendErrorMessage(chatID, "Не удалось изменить рассылку")
+		return fmt.Errorf("error updating broadcast: %w", err)
+	}
+	return nil
+}
+
+// DeliverBroadcasts отправляет подтвержденные рассылки получателям, которым они еще не
+// отправлялись, не больше broadcastBatch сообщений за вызов. Каждая отправка записывается,
+// поэтому повторный вызов продолжает с места остановки и не присылает рассылку дважды.
+// Возвращает число отправленных сообщений
+func (b *Bot) DeliverBroadcasts(ctx context.Context) (int, error) {
+	broadcasts, err := b.service.QueuedBroadcasts(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range broadcasts {
+		broadcast := &broadcasts[i]
+		recipients, err := b.service.BroadcastRecipients(ctx, broadcast, time.Now())
+		if err != nil {
+			return sent, err
+		}
+		for _, userID := range recipients {
+			if sent == broadcastBatch {
+				return sent, nil
+			}
+			_, sendErr := sendContext(ctx, b.api, broadcastMessage(userID, broadcast))
+			if ctx.Err() != nil {
+				// Отправка прервана остановкой: получатель не виноват, продолжим в следующий раз
+				return sent, ctx.Err()
+			}
+			var apiErr *tgbotapi.Error
+			if errors.As(sendErr, &apiErr) && apiErr.Code == 429 {
+				// Лимит Telegram не ошибка получателя: продолжим при следующем вызове
+				return sent, nil
+			}
+			if err := b.service.RecordBroadcastDelivery(ctx, broadcast.ID, userID, sendErr); err != nil {
+				// Без записи об отправке следующий вызов отправил бы сообщение повторно
+				return sent, err
+			}
+			sent++
+		}
+
+		result, err := b.service.FinishBroadcast(ctx, broadcast)
+		if err != nil {
+			return sent, err
+		}
+		b.api.Send(tgbotapi.NewMessage(broadcast.CreatedBy, fmt.Sprintf(
+			"📣 Рассылка отправлена (%s): доставлено %d, не доставлено %d",
+			service.BroadcastSegmentTitle(broadcast.Segment), result.Sent, result.Failed)))
+	}
+	return sent, nil
+}
+
+// deliverBroadcasts периодически отправляет рассылки до остановки бота.
+// В serverless-режиме то же делает функция BroadcastHandler по расписанию
+func (b *Bot) deliverBroadcasts(ctx context.Context) {
+	ticker := time.NewTicker(broadcastDeliveryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := b.DeliverBroadcasts(ctx); err != nil && ctx.Err() == nil {
+				b.reportError(ctx, fmt.Errorf("error delivering broadcasts: %w", err))
+			}
+		}
+	}
+}
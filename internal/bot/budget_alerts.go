@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// budgetAlertInterval - как часто бюджеты проверяются в режиме long polling
+const budgetAlertInterval = time.Hour
+
+// budgetAlertPlugin предупреждает о бюджете сразу после записи траты, каким бы способом
+// она ни была записана: вручную, из чека, выписки или через API
+type budgetAlertPlugin struct {
+	bot *Bot
+}
+
+func (p *budgetAlertPlugin) Name() string {
+	return "budget_alerts"
+}
+
+func (p *budgetAlertPlugin) OnTransactionCreated(ctx context.Context, transaction *model.Transaction) error {
+	if transaction.IsIncome() {
+		return nil
+	}
+	// В общем бюджете предупреждение получает тот, кто записал трату
+	userID := transaction.AuthorID
+	if userID == 0 {
+		userID = transaction.UserID
+	}
+	alerts, err := p.bot.service.PendingBudgetAlerts(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("error checking budget alerts: %w", err)
+	}
+	p.bot.sendBudgetAlerts(ctx, alerts)
+	return nil
+}
+
+// budgetAlertMessage готовит предупреждение о бюджете с кнопками графика и отключения
+func budgetAlertMessage(alert service.BudgetAlert) tgbotapi.MessageConfig {
+	status := alert.Status
+	// Порог назван фактическим процентом: трата могла перескочить его с запасом
+	text := fmt.Sprintf("⚠️ Бюджет «%s» израсходован на %.0f%%: потрачено %.0f₽ из %.0f₽, осталось %.0f₽",
+		status.CategoryName, status.Spent/status.Available()*100, status.Spent, status.Available(), status.Remaining())
+	if alert.Threshold >= 100 {
+		text = fmt.Sprintf("🔴 Бюджет «%s» превышен: потрачено %.0f₽ из %.0f₽, перерасход %.0f₽",
+			status.CategoryName, status.Spent, status.Available(), -status.Remaining())
+	}
+
+	msg := tgbotapi.NewMessage(alert.UserID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("📉 График бюджета", cbBudget, "chart", status.Budget.CategoryID),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("🔕 Не предупреждать о бюджетах", cbSettings, "budgetalerts"),
+		),
+	)
+	return msg
+}
+
+// sendBudgetAlerts отправляет предупреждения о бюджетах и запоминает отправленные.
+// Возвращает число отправленных
+func (b *Bot) sendBudgetAlerts(ctx context.Context, alerts []service.BudgetAlert) int {
+	sent := 0
+	for _, alert := range alerts {
+		if _, err := b.api.Send(budgetAlertMessage(alert)); err != nil {
+			log.Printf("Error sending budget alert to user %d: %v", alert.UserID, err)
+			continue
+		}
+		b.service.MarkBudgetAlertSent(ctx, alert)
+		sent++
+	}
+	return sent
+}
+
+// DeliverBudgetAlerts проверяет бюджеты всех пользователей и отправляет предупреждения,
+// отложенные на тихие часы. Возвращает число отправленных предупреждений
+func (b *Bot) DeliverBudgetAlerts(ctx context.Context) (int, error) {
+	alerts, err := b.service.DueBudgetAlerts(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return b.sendBudgetAlerts(ctx, alerts), nil
+}
+
+// deliverBudgetAlerts периодически проверяет бюджеты до остановки бота.
+// В serverless-режиме то же делает функция BudgetAlertHandler по расписанию
+func (b *Bot) deliverBudgetAlerts(ctx context.Context) {
+	ticker := time.NewTicker(budgetAlertInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := b.DeliverBudgetAlerts(ctx); err != nil {
+				b.reportError(ctx, fmt.Errorf("error delivering budget alerts: %w", err))
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// maxQuarterCounterparties - сколько крупнейших контрагентов показывает квартальный отчет
+const maxQuarterCounterparties = 10
+
+// handleQuarter присылает квартальный отчет бизнес-книги: «/quarter» - за текущий квартал,
+// «/quarter 2» - за второй квартал этого года, «/quarter 4 2025» - за указанный год
+func (b *Bot) handleQuarter(ctx context.Context, message *tgbotapi.Message) {
+	now := time.Now()
+	year, quarter := now.Year(), service.QuarterOf(now)
+	if args := strings.Fields(message.CommandArguments()); len(args) > 0 {
+		parsed, err := strconv.Atoi(args[0])
+		if err != nil || parsed < 1 || parsed > 4 {
+			b.sendErrorMessage(message.Chat.ID, "Укажите квартал от 1 до 4 и, если нужно, год: /quarter 4 2025")
+			return
+		}
+		quarter = parsed
+		if len(args) > 1 {
+			if year, err = strconv.Atoi(args[1]); err != nil {
+				b.sendErrorMessage(message.Chat.ID, "Укажите квартал от 1 до 4 и, если нужно, год: /quarter 4 2025")
+				return
+			}
+		}
+	}
+
+	report, err := b.service.GetQuarterReport(ctx, message.From.ID, year, quarter)
+	if errors.Is(err, service.ErrNotBusinessLedger) {
+		b.sendErrorMessage(message.Chat.ID, "Квартальный отчет строится по книге учета в бизнес-режиме. "+
+			"Заведите книгу и включите режим в /ledgers")
+		return
+	}
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось построить отчет за квартал")
+		return
+	}
+	if report.Transactions == 0 {
+		b.api.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("За %d квартал %d года операций не записано", quarter, year)))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, formatQuarterReport(report))
+	msg.ParseMode = "Markdown"
+	b.api.Send(msg)
+}
+
+// formatQuarterReport описывает квартал: обороты, НДС, налог самозанятого и контрагентов
+func formatQuarterReport(report *service.QuarterReport) string {
+	text := fmt.Sprintf("🏢 *Отчет за %d квартал %d года*\n", report.Quarter, report.Year)
+	text += fmt.Sprintf("%s - %s\n\n", report.Start.Format("02.01.2006"), report.End.Format("02.01.2006"))
+	text += fmt.Sprintf("💰 Доходы: %.2f₽\n", report.Income)
+	text += fmt.Sprintf("💸 Расходы: %.2f₽\n", report.Expenses)
+	text += fmt.Sprintf("💵 Прибыль: %.2f₽\n", report.Income-report.Expenses)
+
+	if report.VATCollected > 0 || report.VATPaid > 0 {
+		text += "\n*НДС:*\n"
+		text += fmt.Sprintf("В доходах: %.2f₽\n", report.VATCollected)
+		text += fmt.Sprintf("В расходах: %.2f₽\n", report.VATPaid)
+		text += fmt.Sprintf("Разница: %.2f₽\n", report.VATDue())
+	}
+
+	text += "\n*Налог самозанятого (НПД):*\n"
+	text += fmt.Sprintf("От физлиц %.2f₽ × %.0f%%\n", report.IndividualIncome, service.NPDIndividualRate*100)
+	text += fmt.Sprintf("От организаций и ИП %.2f₽ × %.0f%%\n", report.CompanyIncome, service.NPDCompanyRate*100)
+	text += fmt.Sprintf("К уплате около %.2f₽ без учета налогового вычета\n", report.NPD())
+	if left := report.LimitLeft(); left > 0 {
+		text += fmt.Sprintf("Доход с начала года %.0f₽, до лимита %.0f₽ осталось %.0f₽\n",
+			report.YearIncome, float64(service.NPDYearLimit), left)
+	} else {
+		text += fmt.Sprintf("⚠️ Доход с начала года %.0f₽ превысил лимит %.0f₽\n", report.YearIncome, float64(service.NPDYearLimit))
+	}
+
+	if len(report.Counterparties) > 0 {
+		text += "\n*Контрагенты:*\n"
+		for i, c := range report.Counterparties {
+			if i == maxQuarterCounterparties {
+				text += fmt.Sprintf("и еще %d\n", len(report.Counterparties)-i)
+				break
+			}
+			line := "• " + tgbotapi.EscapeText(tgbotapi.ModeMarkdown, c.Name)
+			if c.Income > 0 {
+				line += fmt.Sprintf(": 💰 %.2f₽", c.Income)
+			}
+			if c.Expenses > 0 {
+				line += fmt.Sprintf(" 💸 %.2f₽", c.Expenses)
+			}
+			if c.Invoices > 0 {
+				line += fmt.Sprintf(", счетов: %d", c.Invoices)
+			}
+			text += line + "\n"
+		}
+	}
+	if report.Untagged > 0 {
+		text += fmt.Sprintf("\nБез контрагента: %d операций. Доходы без контрагента считаются полученными от физлиц\n", report.Untagged)
+	}
+	return strings.TrimSpace(text)
+}
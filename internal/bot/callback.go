@@ -0,0 +1,233 @@
+package bot
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// callbackVersion - версия формата данных кнопок. При несовместимом изменении формата
+// версия увеличивается, и кнопки старых сообщений распознаются как устаревшие
+const callbackVersion = "1"
+
+// maxCallbackData - ограничение Telegram на данные кнопки в байтах
+const maxCallbackData = 64
+
+// callbackAction - действие кнопки. Коды короткие, чтобы аргументам хватало 64 байт
+type callbackAction string
+
+// Действия кнопок. В комментарии - аргументы
+const (
+	cbMenu              callbackAction = "mn" // главное меню
+	cbCancel            callbackAction = "cn" // отмена текущего действия
+	cbAdd               callbackAction = "ad" // ввод транзакции: income | expense
+	cbCategories        callbackAction = "ct" // управление категориями [, ID родительской категории]
+	cbNewCategory       callbackAction = "nc" // новая категория: income | expense [, ID родительской категории]
+	cbDeleteCategory    callbackAction = "dc" // ID категории
+	cbCategoryArchive   callbackAction = "ar" // архив категорий [, add | restore <ID категории>]
+	cbCategoryOrder     callbackAction = "so" // порядок категорий: income | expense [, up | down <ID категории>]
+	cbCategoryPack      callbackAction = "cp" // стартовый набор категорий: ID набора
+	cbCategoryStyle     callbackAction = "cs" // ID категории [, emoji <значок> | color <номер в палитре> | rename]
+	cbCategory          callbackAction = "sc" // выбор категории для ввода: ID категории
+	cbSubcategory       callbackAction = "sb" // выбор подкатегории для ввода: ID родительской категории
+	cbQuickAmount       callbackAction = "qa" // ID категории, сумма
+	cbReports           callbackAction = "rp" // меню отчетов
+	cbReport            callbackAction = "rr" // service.ReportType
+	cbCharts            callbackAction = "ch" // графики за месяц
+	cbForecast          callbackAction = "fc" // прогноз остатка на 30 дней
+	cbCompare           callbackAction = "cm" // сравнение двух периодов
+	cbIncome            callbackAction = "in" // стабильность дохода
+	cbReminder          callbackAction = "rd" // del <ID напоминания>
+	cbBroadcast         callbackAction = "bc" // seg|send|cancel <ID рассылки> [сегмент]
+	cbTransactions      callbackAction = "tx" // история транзакций
+	cbDeleteTransaction callbackAction = "dt" // ID транзакции
+	cbTransaction       callbackAction = "td" // ID транзакции [, note | photo | show | unphoto | biz | event]
+	cbRecycleBin        callbackAction = "rb" // корзина [, tx <ID транзакции> | cat <ID категории>]
+	cbBalance           callbackAction = "bl" // счета
+	cbAccount           callbackAction = "sa" // выбор счета для ввода: ID счета
+	cbNewAccount        callbackAction = "an" // тип счета
+	cbDeleteAccount     callbackAction = "da" // ID счета
+	cbRecategorize      callbackAction = "rc" // тип категории, ID транзакции
+	cbRecategorizeTo    callbackAction = "rt" // ID новой категории
+	cbReceipt           callbackAction = "re" // total | split [, ID категории]
+	cbImport            callbackAction = "im" // cat <ID категории> | skip | all
+	cbReconcile         callbackAction = "ro" // add <номер операции в сверке> | del <ID транзакции>
+	cbDuplicate         callbackAction = "du" // del <ID транзакции> | ok
+	cbAdvice            callbackAction = "av" // ID категории, бюджет
+	cbBudget            callbackAction = "bu" // list | chart | rollover, ID категории
+	cbPlan              callbackAction = "pl" // cat <ID категории> | del <ID плана>
+	cbWishlist          callbackAction = "wl" // add | del, ID покупки
+	cbGoal              callbackAction = "gl" // ID цели, на которую откладывается сумма
+	cbExport            callbackAction = "ex" // csv | xlsx | pdf
+	cbSettings          callbackAction = "st" // настройка [, значение]
+	cbReportSections    callbackAction = "rs" // toggle | up <раздел> | reset
+	cbNetWorth          callbackAction = "nw" // add <вид> | del <ID актива> | hold <вид> | unhold <ID инвестиции>
+	cbFamily            callbackAction = "fm" // invite | leave | remove <ID участника>
+	cbWebhook           callbackAction = "wh" // add | test <ID> | del <ID>
+	cbRetry             callbackAction = "ry" // команда без "/"
+	cbDeleteMe          callbackAction = "dm" // 1 | 2 <unix-время первого подтверждения>
+	cbPIN               callbackAction = "pn" // menu | set | off | idle <минуты>
+	cbLedger            callbackAction = "lg" // книги учета [, switch [ID книги] | new | biz <ID> | del <ID> | rm <ID> | all <service.ReportType>]
+	cbEvent             callbackAction = "ev" // события [, new | report <ID> | close <ID> | del <ID> | rm <ID> | share <ID> | split <ID> | unpay <ID платежа>]
+)
+
+// callbackHandler обрабатывает нажатие кнопки с разобранными аргументами
+type callbackHandler func(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error
+
+// errStaleCallback - данные кнопки в неизвестном формате, например из сообщения старой версии бота
+var errStaleCallback = errors.New("unknown callback data format")
+
+// encodeCallback кодирует действие и аргументы кнопки: версия, код действия и аргументы
+// через ":", например "1sc:!<UUID>". UUID упаковываются в 22 символа base64 с префиксом "!",
+// остальные аргументы экранируются. ok = false - данные не уложились в ограничение Telegram
+func encodeCallback(action callbackAction, args ...any) (string, bool) {
+	var b strings.Builder
+	b.WriteString(callbackVersion)
+	b.WriteString(string(action))
+	for _, arg := range args {
+		b.WriteByte(':')
+		b.WriteString(encodeCallbackArg(arg))
+	}
+	return b.String(), b.Len() <= maxCallbackData
+}
+
+// callbackButton создает кнопку с закодированными данными. Если данные длиннее 64 байт,
+// например из-за длинного аргумента, кнопка получается пропущенной: с ней Telegram
+// отклонил бы все сообщение, поэтому при отправке ее убирает menuSessionAPI
+func callbackButton(text string, action callbackAction, args ...any) tgbotapi.InlineKeyboardButton {
+	data, ok := encodeCallback(action, args...)
+	if !ok {
+		log.Printf("Callback data %q exceeds %d bytes, button %q omitted", data, maxCallbackData, text)
+		data = ""
+	}
+	return tgbotapi.NewInlineKeyboardButtonData(text, data)
+}
+
+// omittedButton сообщает, что кнопка пропущена callbackButton. Пустых данных у настоящих
+// кнопок не бывает: Telegram требует от 1 до 64 байт
+func omittedButton(button tgbotapi.InlineKeyboardButton) bool {
+	return button.CallbackData != nil && *button.CallbackData == ""
+}
+
+func encodeCallbackArg(arg any) string {
+	switch v := arg.(type) {
+	case string:
+		if packed, ok := packUUID(v); ok {
+			return packed
+		}
+		return url.QueryEscape(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return url.QueryEscape(fmt.Sprint(v))
+	}
+}
+
+// decodeCallback разбирает данные кнопки, закодированные callbackData. Пометка сессии
+// меню отбрасывается: ее проверяет staleCallback
+func decodeCallback(data string) (callbackAction, callbackArgs, error) {
+	_, data, _ = splitMenuSession(data)
+	rest, ok := strings.CutPrefix(data, callbackVersion)
+	if !ok || rest == "" {
+		return "", nil, errStaleCallback
+	}
+	fields := strings.Split(rest, ":")
+	args := make(callbackArgs, 0, len(fields)-1)
+	for _, field := range fields[1:] {
+		if id, ok := unpackUUID(field); ok {
+			args = append(args, id)
+			continue
+		}
+		arg, err := url.QueryUnescape(field)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %v", errStaleCallback, err)
+		}
+		args = append(args, arg)
+	}
+	return callbackAction(fields[0]), args, nil
+}
+
+// packUUID упаковывает UUID в нижнем регистре: 36 символов превращаются в 23
+func packUUID(s string) (string, bool) {
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return "", false
+	}
+	raw, err := hex.DecodeString(strings.ReplaceAll(s, "-", ""))
+	if err != nil || formatUUID(raw) != s {
+		return "", false
+	}
+	return "!" + base64.RawURLEncoding.EncodeToString(raw), true
+}
+
+func unpackUUID(s string) (string, bool) {
+	encoded, ok := strings.CutPrefix(s, "!")
+	if !ok {
+		return "", false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(raw) != 16 {
+		return "", false
+	}
+	return formatUUID(raw), true
+}
+
+func formatUUID(raw []byte) string {
+	h := hex.EncodeToString(raw)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// callbackArgs - аргументы кнопки. Отсутствующий аргумент - пустая строка
+type callbackArgs []string
+
+// String возвращает i-й аргумент
+func (a callbackArgs) String(i int) string {
+	if i < len(a) {
+		return a[i]
+	}
+	return ""
+}
+
+// Int возвращает i-й аргумент как целое число
+func (a callbackArgs) Int(i int) (int, error) {
+	n, err := strconv.Atoi(a.String(i))
+	if err != nil {
+		return 0, fmt.Errorf("invalid callback argument %d: %w", i, err)
+	}
+	return n, nil
+}
+
+// Int64 возвращает i-й аргумент как int64, например ID пользователя Telegram
+func (a callbackArgs) Int64(i int) (int64, error) {
+	n, err := strconv.ParseInt(a.String(i), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid callback argument %d: %w", i, err)
+	}
+	return n, nil
+}
+
+// Float возвращает i-й аргумент как число, например сумму
+func (a callbackArgs) Float(i int) (float64, error) {
+	f, err := strconv.ParseFloat(a.String(i), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid callback argument %d: %w", i, err)
+	}
+	return f, nil
+}
+
+// callbackMessage собирает сообщение от имени нажавшего кнопку, чтобы переиспользовать
+// обработчики команд
+func callbackMessage(callback *tgbotapi.CallbackQuery) *tgbotapi.Message {
+	return &tgbotapi.Message{From: callback.From, Chat: callback.Message.Chat}
+}
@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// callbackRepeatWindow - повторное нажатие той же кнопки в течение этого времени
+// считается случайным двойным нажатием и не обрабатывается
+const callbackRepeatWindow = 3 * time.Second
+
+// repeatableActions - кнопки, которые нажимают несколько раз подряд намеренно
+var repeatableActions = map[callbackAction]bool{
+	cbCategoryOrder: true,
+}
+
+// callbackRepeats помнит недавно нажатые кнопки по чату, сообщению и данным кнопки.
+// У каждого нажатия свой ID callback'а, поэтому двойное нажатие по ID не отличить
+type callbackRepeats struct {
+	mu      sync.Mutex
+	pressed map[string]time.Time
+}
+
+func newCallbackRepeats() *callbackRepeats {
+	return &callbackRepeats{pressed: make(map[string]time.Time)}
+}
+
+// first запоминает нажатие и сообщает, что такой кнопки не нажимали в течение
+// callbackRepeatWindow. Нажатие отмечается до обработки: в webhook-режиме второе
+// нажатие может прийти, пока первое еще обрабатывается
+func (r *callbackRepeats) first(key string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.pressed) >= 1000 {
+		for k, at := range r.pressed {
+			if now.Sub(at) >= callbackRepeatWindow {
+				delete(r.pressed, k)
+			}
+		}
+	}
+	if at, ok := r.pressed[key]; ok && now.Sub(at) < callbackRepeatWindow {
+		return false
+	}
+	r.pressed[key] = now
+	return true
+}
+
+// forget разрешает сразу нажать кнопку снова, например после ошибки обработки
+func (r *callbackRepeats) forget(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pressed, key)
+}
+
+// callbackKey - кнопка, которую нажали: сообщение с клавиатурой и данные кнопки
+func callbackKey(callback *tgbotapi.CallbackQuery) string {
+	if callback.Message != nil {
+		return fmt.Sprintf("%d:%d:%s", callback.Message.Chat.ID, callback.Message.MessageID, callback.Data)
+	}
+	return fmt.Sprintf("%s:%s", callback.InlineMessageID, callback.Data)
+}
+
+// repeatedCallback отвечает на повторное нажатие кнопки, которая только что обработана
+func (b *Bot) repeatedCallback(callback *tgbotapi.CallbackQuery, action callbackAction) bool {
+	if repeatableActions[action] || b.repeats.first(callbackKey(callback), time.Now()) {
+		return false
+	}
+	b.api.Request(tgbotapi.NewCallback(callback.ID, "Уже обработано"))
+	return true
+}
@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestCallbackRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		action callbackAction
+		args   []any
+		want   callbackArgs
+	}{
+		{"без аргументов", cbMenu, nil, callbackArgs{}},
+		{"UUID", cbCategory, []any{"0f8fad5b-d9cb-469f-a165-70867728950e"}, callbackArgs{"0f8fad5b-d9cb-469f-a165-70867728950e"}},
+		{"числа", cbQuickAmount, []any{"food", 250.5, -3, int64(123456789012)}, callbackArgs{"food", "250.5", "-3", "123456789012"}},
+		{"разделители в тексте", cbRetry, []any{"a:b c/д"}, callbackArgs{"a:b c/д"}},
+		{"ID не в нижнем регистре не упаковывается", cbCategory, []any{"0F8FAD5B-D9CB-469F-A165-70867728950E"}, callbackArgs{"0F8FAD5B-D9CB-469F-A165-70867728950E"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, ok := encodeCallback(tt.action, tt.args...)
+			if !ok {
+				t.Fatalf("данные %q не уложились в %d байт", data, maxCallbackData)
+			}
+			action, args, err := decodeCallback(data)
+			if err != nil {
+				t.Fatalf("decodeCallback(%q): %v", data, err)
+			}
+			if action != tt.action || !reflect.DeepEqual(args, tt.want) {
+				t.Errorf("decodeCallback(%q) = %s %q, ожидалось %s %q", data, action, args, tt.action, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCallbackStale(t *testing.T) {
+	// Пометка сессии меню не мешает разбору
+	action, args, err := decodeCallback(withMenuSession(mustEncodeCallback(t, cbBudget, "list"), 7))
+	if err != nil || action != cbBudget || !reflect.DeepEqual(args, callbackArgs{"list"}) {
+		t.Errorf("кнопка с сессией: %s %q, %v", action, args, err)
+	}
+
+	for _, data := range []string{"", "1", "0mn", "2sc:x", "1sc:%zz"} {
+		if _, _, err := decodeCallback(data); !errors.Is(err, errStaleCallback) {
+			t.Errorf("decodeCallback(%q): %v, ожидалась устаревшая кнопка", data, err)
+		}
+	}
+}
+
+func TestPackUUID(t *testing.T) {
+	id := "0f8fad5b-d9cb-469f-a165-70867728950e"
+	packed, ok := packUUID(id)
+	if !ok || len(packed) != 23 {
+		t.Fatalf("packUUID(%q) = %q, %v; ожидалось 23 символа", id, packed, ok)
+	}
+	if unpacked, ok := unpackUUID(packed); !ok || unpacked != id {
+		t.Errorf("unpackUUID(%q) = %q, %v; ожидалось %q", packed, unpacked, ok, id)
+	}
+
+	for _, s := range []string{"", "food", "0f8fad5b-d9cb-469f-a165-70867728950", "0f8fad5bxd9cb-469f-a165-70867728950e", "zf8fad5b-d9cb-469f-a165-70867728950e"} {
+		if packed, ok := packUUID(s); ok {
+			t.Errorf("packUUID(%q) = %q, ожидалось без упаковки", s, packed)
+		}
+	}
+	for _, s := range []string{"food", "!", "!short", "!" + strings.Repeat("A", 23)} {
+		if unpacked, ok := unpackUUID(s); ok {
+			t.Errorf("unpackUUID(%q) = %q, ожидалась ошибка", s, unpacked)
+		}
+	}
+}
+
+// Кнопка с данными длиннее 64 байт не роняет бота, а пропадает из клавиатуры
+func TestCallbackButtonTooLong(t *testing.T) {
+	long := callbackButton("Длинная", cbRetry, strings.Repeat("x", maxCallbackData))
+	if !omittedButton(long) {
+		t.Fatalf("кнопка с длинными данными не пропущена: %+v", long)
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(long),
+		tgbotapi.NewInlineKeyboardRow(long, callbackButton("« В меню", cbMenu)),
+	)
+	for _, stamp := range []bool{false, true} {
+		got := stampKeyboard(keyboard, 3, stamp).InlineKeyboard
+		if len(got) != 1 || len(got[0]) != 1 || got[0][0].Text != "« В меню" {
+			t.Errorf("stamp = %v: клавиатура %+v, ожидалась одна кнопка меню", stamp, got)
+		}
+	}
+}
+
+func mustEncodeCallback(t *testing.T, action callbackAction, args ...any) string {
+	t.Helper()
+	data, ok := encodeCallback(action, args...)
+	if !ok {
+		t.Fatalf("данные %q не уложились в %d байт", data, maxCallbackData)
+	}
+	return data
+}
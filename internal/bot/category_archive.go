@@ -0,0 +1,83 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleCategoryArchiveCallback убирает категорию в архив, возвращает ее из архива
+// или показывает архив
+func (b *Bot) handleCategoryArchiveCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	chatID := callback.Message.Chat.ID
+	message := callbackMessage(callback)
+
+	switch args.String(0) {
+	case "add":
+		category, err := b.service.SetCategoryArchived(ctx, callback.From.ID, args.String(1), true)
+		if err != nil {
+			b.sendServiceError(ctx, chatID, err, "Не удалось убрать категорию в архив")
+			return fmt.Errorf("error archiving category: %w", err)
+		}
+		b.api.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf(
+			"🗄 Категория «%s» в архиве. Ее транзакции остаются в отчетах, вернуть категорию можно из архива", category.Name)))
+		if category.ParentID != "" {
+			b.handleSubcategories(ctx, message, category.ParentID)
+		} else {
+			b.handleCategories(ctx, message)
+		}
+	case "restore":
+		category, err := b.service.SetCategoryArchived(ctx, callback.From.ID, args.String(1), false)
+		if err != nil {
+			b.sendServiceError(ctx, chatID, err, "Не удалось вернуть категорию из архива")
+			return fmt.Errorf("error restoring category: %w", err)
+		}
+		b.api.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("♻️ Категория «%s» снова доступна", category.Name)))
+		b.handleCategoryArchive(ctx, message)
+	default:
+		b.handleCategoryArchive(ctx, message)
+	}
+	return nil
+}
+
+// handleCategoryArchive показывает категории из архива. Подкатегории архивной
+// категории отдельно не показываются: они возвращаются вместе с ней
+func (b *Bot) handleCategoryArchive(ctx context.Context, message *tgbotapi.Message) {
+	categories, err := b.service.GetCategories(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить категории")
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, cat := range categories {
+		if !cat.Archived {
+			continue
+		}
+		if parent := findCategory(categories, cat.ParentID); parent != nil && parent.Archived {
+			continue
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			callbackButton("♻️ "+categoryTitle(categories, &cat), cbCategoryArchive, "restore", cat.ID),
+			callbackButton("🗑", cbDeleteCategory, cat.ID),
+		))
+	}
+
+	text := "*🗄 Архив категорий*\n\n"
+	if len(rows) == 0 {
+		text += "В архиве пусто"
+	} else {
+		text += "Категории из архива не предлагаются при вводе, но их транзакции остаются в отчетах.\n\n" +
+			"♻️ - вернуть категорию, 🗑 - перенести ее вместе с транзакциями в корзину"
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		callbackButton("🗑 Корзина", cbRecycleBin),
+		callbackButton("« Назад", cbCategories),
+	))
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.api.Send(msg)
+}
@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// handleCategoryOrderCallback показывает порядок категорий и сдвигает их кнопками.
+// Сообщение редактируется на месте, чтобы категорию можно было двигать несколько раз подряд
+func (b *Bot) handleCategoryOrderCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	categoryType := args.String(0)
+	if categoryType != "income" {
+		categoryType = "expense"
+	}
+
+	edit := false
+	switch op := args.String(1); op {
+	case "up", "down":
+		if err := b.service.MoveCategory(ctx, callback.From.ID, args.String(2), op == "up"); err != nil {
+			return fmt.Errorf("error moving category: %w", err)
+		}
+		edit = true
+	}
+
+	categories, err := b.service.GetCategories(ctx, callback.From.ID)
+	if err != nil {
+		return fmt.Errorf("error getting categories: %w", err)
+	}
+	var ordered []model.Category
+	for _, cat := range model.TopLevelCategories(model.ActiveCategories(categories)) {
+		if cat.Type == categoryType {
+			ordered = append(ordered, cat)
+		}
+	}
+
+	title := "расходов"
+	if categoryType == "income" {
+		title = "доходов"
+	}
+	text := fmt.Sprintf("↕️ *Порядок категорий %s*\n\n"+
+		"В этом порядке категории предлагаются при вводе. "+
+		"В /settings можно вместо него показывать первыми самые частые категории", title)
+	keyboard := categoryOrderKeyboard(categoryType, ordered)
+
+	if edit {
+		msg := tgbotapi.NewEditMessageTextAndMarkup(callback.Message.Chat.ID, callback.Message.MessageID, text, keyboard)
+		msg.ParseMode = "Markdown"
+		b.api.Send(msg)
+		return nil
+	}
+	msg := tgbotapi.NewMessage(callback.Message.Chat.ID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = keyboard
+	b.api.Send(msg)
+	return nil
+}
+
+// categoryOrderKeyboard - категории с кнопками сдвига вверх и вниз
+func categoryOrderKeyboard(categoryType string, categories []model.Category) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, cat := range categories {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			callbackButton(cat.Icon()+" "+cat.Name, cbCategoryOrder, categoryType),
+			callbackButton("⬆️", cbCategoryOrder, categoryType, "up", cat.ID),
+			callbackButton("⬇️", cbCategoryOrder, categoryType, "down", cat.ID),
+		))
+	}
+
+	other, otherTitle := "income", "💰 Категории доходов"
+	if categoryType == "income" {
+		other, otherTitle = "expense", "💸 Категории расходов"
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(callbackButton(otherTitle, cbCategoryOrder, other)),
+		tgbotapi.NewInlineKeyboardRow(callbackButton("✅ Готово", cbCategories)),
+	)
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
@@ -0,0 +1,40 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// sendCategoryPacks предлагает новому пользователю выбрать стартовый набор категорий
+func (b *Bot) sendCategoryPacks(chatID int64) {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, pack := range service.CategoryPacks() {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			callbackButton(pack.Title, cbCategoryPack, pack.ID),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "*С чего начнем?*\n\n"+
+		"Выберите набор категорий под ваш образ жизни. Потом их можно переименовать, "+
+		"убрать в архив или добавить свои в разделе «Категории»")
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.api.Send(msg)
+}
+
+// handleCategoryPackCallback создает категории из выбранного набора
+func (b *Bot) handleCategoryPackCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	if err := b.service.CreateDefaultCategories(ctx, callback.From.ID, args.String(0)); err != nil {
+		b.sendServiceError(ctx, callback.Message.Chat.ID, err, "Не удалось создать категории")
+		return fmt.Errorf("error creating default categories: %w", err)
+	}
+
+	// Убираем кнопки выбора, чтобы набор нельзя было выбрать повторно
+	b.api.Send(tgbotapi.NewEditMessageReplyMarkup(callback.Message.Chat.ID, callback.Message.MessageID,
+		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}))
+	b.handleCategories(ctx, callbackMessage(callback))
+	return nil
+}
@@ -0,0 +1,174 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// categoryEmojis - значки, которые бот предлагает для категорий
+var categoryEmojis = []string{
+	"🛒", "🍔", "☕", "🚕", "🚗", "🏠",
+	"💡", "📱", "👕", "💊", "🎉", "🎁",
+	"✈️", "📚", "🐾", "💼", "💵", "📈",
+}
+
+// handleCategoryStyleCallback показывает и меняет значок и цвет категории, а также
+// начинает переименование
+func (b *Bot) handleCategoryStyleCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	categoryID := args.String(0)
+
+	var emoji, color string
+	switch args.String(1) {
+	case "rename":
+		return b.startCategoryRename(ctx, callbackMessage(callback), categoryID)
+	case "emoji":
+		emoji = args.String(2)
+	case "color":
+		i, err := args.Int(2)
+		if err != nil || i < 0 || i >= len(model.CategoryColors) {
+			return fmt.Errorf("invalid category color: %s", args.String(2))
+		}
+		color = model.CategoryColors[i].Hex
+	}
+
+	if emoji == "" && color == "" {
+		categories, err := b.service.GetCategories(ctx, callback.From.ID)
+		if err != nil {
+			return fmt.Errorf("error getting categories: %w", err)
+		}
+		category := findCategory(categories, categoryID)
+		if category == nil {
+			b.sendErrorMessage(callback.Message.Chat.ID, "Категория не найдена")
+			return nil
+		}
+		msg := tgbotapi.NewMessage(callback.Message.Chat.ID, categoryStyleText(category))
+		msg.ParseMode = "Markdown"
+		msg.ReplyMarkup = categoryStyleKeyboard(category)
+		b.api.Send(msg)
+		return nil
+	}
+
+	category, err := b.service.SetCategoryStyle(ctx, callback.From.ID, categoryID, emoji, color)
+	if err != nil {
+		b.sendServiceError(ctx, callback.Message.Chat.ID, err, "Не удалось изменить оформление категории")
+		return fmt.Errorf("error setting category style: %w", err)
+	}
+	edit := tgbotapi.NewEditMessageTextAndMarkup(callback.Message.Chat.ID, callback.Message.MessageID,
+		categoryStyleText(category), categoryStyleKeyboard(category))
+	edit.ParseMode = "Markdown"
+	b.api.Send(edit)
+	return nil
+}
+
+// categoryStyleText описывает текущее оформление категории
+func categoryStyleText(category *model.Category) string {
+	color := "по умолчанию"
+	for _, c := range model.CategoryColors {
+		if strings.EqualFold(c.Hex, category.Color) {
+			color = c.Mark
+		}
+	}
+	return fmt.Sprintf("🎨 *Оформление категории*\n\n%s *%s*\nЦвет на графиках: %s\n\nВыберите значок и цвет:",
+		category.Icon(), category.Name, color)
+}
+
+// categoryStyleKeyboard - значки по шесть в ряд и палитра цветов
+func categoryStyleKeyboard(category *model.Category) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, emoji := range categoryEmojis {
+		row = append(row, callbackButton(emoji, cbCategoryStyle, category.ID, "emoji", emoji))
+		if len(row) == 6 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+
+	var colors []tgbotapi.InlineKeyboardButton
+	for i, c := range model.CategoryColors {
+		mark := c.Mark
+		if strings.EqualFold(c.Hex, category.Color) {
+			mark = "✅"
+		}
+		colors = append(colors, callbackButton(mark, cbCategoryStyle, category.ID, "color", i))
+	}
+	rows = append(rows, colors, tgbotapi.NewInlineKeyboardRow(
+		callbackButton("✏️ Переименовать", cbCategoryStyle, category.ID, "rename"),
+		callbackButton("✅ Готово", cbCategories, category.ParentID),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// splitCategoryName отделяет значок в начале названия новой категории: "🍔 Кафе"
+func splitCategoryName(text string) (emoji, name string) {
+	text = strings.TrimSpace(text)
+	if first, rest, ok := strings.Cut(text, " "); ok && model.ValidCategoryEmoji(first) {
+		if rest = strings.TrimSpace(rest); rest != "" {
+			return first, rest
+		}
+	}
+	return "", text
+}
+
+// startCategoryRename запрашивает новое название категории
+func (b *Bot) startCategoryRename(ctx context.Context, message *tgbotapi.Message, categoryID string) error {
+	state := &model.UserState{
+		UserID:         message.From.ID,
+		AwaitingAction: model.StateRenameCategory,
+		Payload:        categoryID,
+	}
+	if err := b.saveUserState(ctx, state); err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Ошибка при сохранении состояния")
+		return fmt.Errorf("error saving user state: %w", err)
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, "✏️ Введите новое название категории:")
+	msg.ReplyMarkup = cancelKeyboard
+	b.api.Send(msg)
+	return nil
+}
+
+// renameCategoryFromMessage переименовывает категорию из состояния. При ошибке в названии
+// состояние сохраняется, чтобы можно было сразу ввести другое
+func (b *Bot) renameCategoryFromMessage(ctx context.Context, message *tgbotapi.Message, state *model.UserState) error {
+	category, err := b.service.RenameCategory(ctx, message.From.ID, state.Payload, message.Text)
+	if text, ok := categoryErrorText(err); ok {
+		b.sendErrorMessage(message.Chat.ID, text)
+		return nil
+	}
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось переименовать категорию")
+		return fmt.Errorf("error renaming category: %w", err)
+	}
+	if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+		return fmt.Errorf("error deleting user state: %w", err)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Категория переименована: %s %s ✅", category.Icon(), category.Name))
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+	return nil
+}
+
+// categoryErrorText объясняет пользователю, что не так с названием категории.
+// ok = false, если ошибка не связана с проверкой категории
+func categoryErrorText(err error) (text string, ok bool) {
+	switch {
+	case errors.Is(err, service.ErrCategoryNameEmpty):
+		return "Название категории не может быть пустым", true
+	case errors.Is(err, service.ErrCategoryNameTooLong):
+		return fmt.Sprintf("Название слишком длинное: не больше %d символов", service.MaxCategoryNameLength), true
+	case errors.Is(err, service.ErrCategoryExists):
+		return "Категория с таким названием уже есть. Введите другое название", true
+	case errors.Is(err, service.ErrCategoryType):
+		return "Неизвестный тип категории: выберите доходы или расходы", true
+	case errors.Is(err, service.ErrTooManyCategories):
+		return fmt.Sprintf("Достигнут предел в %d категорий. Удалите или объедините ненужные", service.MaxCategories), true
+	}
+	return "", false
+}
@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/charts"
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// chartDelivery - как графики отправляются пользователю: сжатыми фото или файлами
+// PNG в высоком разрешении и SVG
+type chartDelivery struct {
+	charts    *charts.ChartGenerator
+	documents bool
+	// absoluteTrends - тренды в рублях с линией бюджета вместо отклонения в процентах
+	absoluteTrends bool
+}
+
+// chartDelivery выбирает генератор и способ отправки графиков по настройкам пользователя.
+// Если настройки не загрузились, графики приходят обычными фото
+func (b *Bot) chartDelivery(ctx context.Context, userID int64) chartDelivery {
+	settings, err := b.service.GetUserSettings(ctx, userID)
+	if err != nil {
+		b.reportError(ctx, fmt.Errorf("error getting chart settings: %w", err))
+		return chartDelivery{charts: b.charts()}
+	}
+	delivery := chartDelivery{charts: b.charts(), absoluteTrends: settings.AbsoluteTrends()}
+	switch {
+	case !settings.ChartDocuments():
+	case settings.ChartFormat == model.ChartFormatSVG:
+		// Векторному графику разрешение не нужно
+		delivery.charts = delivery.charts.WithFormat(charts.FormatSVG)
+		delivery.documents = true
+	default:
+		delivery.charts = delivery.charts.WithScale(float64(settings.ChartScale))
+		delivery.documents = true
+	}
+	return delivery
+}
+
+// fileName добавляет к имени графика расширение его формата
+func (d chartDelivery) fileName(name string) string {
+	return name + "." + d.charts.Format()
+}
+
+// message готовит сообщение с одним графиком, name - имя файла без расширения
+func (d chartDelivery) message(chatID int64, name string, data []byte) tgbotapi.Chattable {
+	return d.captioned(chatID, name, data, "")
+}
+
+// captioned готовит сообщение с одним графиком и подписью в Markdown
+func (d chartDelivery) captioned(chatID int64, name string, data []byte, caption string) tgbotapi.Chattable {
+	file := tgbotapi.FileBytes{Name: d.fileName(name), Bytes: data}
+	if d.documents {
+		doc := tgbotapi.NewDocument(chatID, file)
+		doc.Caption = caption
+		doc.ParseMode = "Markdown"
+		return doc
+	}
+	photo := tgbotapi.NewPhoto(chatID, file)
+	photo.Caption = caption
+	photo.ParseMode = "Markdown"
+	return photo
+}
+
+// media готовит график для альбома. Telegram не смешивает в альбоме фото и файлы,
+// поэтому все графики альбома отправляются одинаково
+func (d chartDelivery) media(name string, data []byte, caption string) interface{} {
+	file := tgbotapi.FileBytes{Name: d.fileName(name), Bytes: data}
+	if d.documents {
+		media := tgbotapi.NewInputMediaDocument(file)
+		media.Caption = caption
+		media.ParseMode = "Markdown"
+		return media
+	}
+	media := tgbotapi.NewInputMediaPhoto(file)
+	media.Caption = caption
+	media.ParseMode = "Markdown"
+	return media
+}
@@ -0,0 +1,224 @@
+package bot
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ivanoskov/financial_bot/internal/charts"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// Подписи к графикам отчета: название и главный вывод из данных, чтобы изменения
+// были видны без разглядывания графика
+
+// dashboardInsight - вывод к графику доходов и расходов: сколько сохранено и самый затратный день
+func dashboardInsight(report *service.BaseReport) string {
+	text := "📊 *Динамика доходов и расходов*\n"
+	switch {
+	case report.TotalIncome > 0 && report.Balance >= 0:
+		text += fmt.Sprintf("Сохранено %.0f₽ - %.0f%% дохода", report.Balance, report.Balance/report.TotalIncome*100)
+	case report.Balance < 0:
+		text += fmt.Sprintf("Расходы превысили доходы на %.0f₽", -report.Balance)
+	default:
+		text += fmt.Sprintf("Расходы: %.0f₽", report.TotalExpenses)
+	}
+
+	var peak service.TrendPoint
+	for _, point := range report.Trends.ExpenseTrend {
+		if point.Amount > peak.Amount {
+			peak = point
+		}
+	}
+	if peak.Amount > 0 && len(report.Trends.ExpenseTrend) > 1 {
+		text += fmt.Sprintf("\nСамый затратный день - %s: %.0f₽", peak.Date.Format("02.01"), peak.Amount)
+	}
+	return text
+}
+
+// expensesInsight - вывод к распределению расходов: крупнейшая категория и главный драйвер
+// роста расходов по сравнению с прошлым периодом
+func expensesInsight(report *service.BaseReport) string {
+	text := "🛒 *Расходы по категориям*\n"
+	if len(report.CategoryData.Expenses) == 0 {
+		return text + "Расходов за период нет"
+	}
+	top := report.CategoryData.Expenses[0]
+	text += fmt.Sprintf("Больше всего потрачено на «%s»: %.0f₽, %.0f%% расходов", top.Name, math.Abs(top.Amount), top.Share)
+
+	comparison := report.Trends.PeriodComparison
+	if comparison.PrevPeriod.TotalExpenses == 0 {
+		return text
+	}
+	// Драйвер - категория, расходы в которой выросли сильнее всего в рублях:
+	// рост маленькой категории в разы меньше влияет на итог
+	var driver string
+	var driverGrowth float64
+	for name, amount := range comparison.CurrentPeriod.ExpensesByCategory {
+		if growth := amount - comparison.PrevPeriod.ExpensesByCategory[name]; growth > driverGrowth {
+			driver, driverGrowth = name, growth
+		}
+	}
+	totalGrowth := comparison.CurrentPeriod.TotalExpenses - comparison.PrevPeriod.TotalExpenses
+	switch {
+	case totalGrowth < 0:
+		return text + fmt.Sprintf("\nРасходы снизились на %.0f₽ к прошлому периоду 👍", -totalGrowth)
+	case totalGrowth == 0 || driver == "":
+		return text
+	}
+	if prev := comparison.PrevPeriod.ExpensesByCategory[driver]; prev > 0 {
+		text += fmt.Sprintf("\nРасходы на «%s» выросли на %.0f%%, это главный драйвер периода", driver, driverGrowth/prev*100)
+	} else {
+		text += fmt.Sprintf("\nНовые расходы на «%s» - %.0f₽, это главный драйвер периода", driver, driverGrowth)
+	}
+	return text
+}
+
+// incomeInsight - вывод к распределению доходов: главный источник и его доля
+func incomeInsight(report *service.BaseReport) string {
+	text := "💰 *Доходы по категориям*\n"
+	switch len(report.CategoryData.Income) {
+	case 0:
+		return text + "Доходов за период нет"
+	case 1:
+		return text + fmt.Sprintf("Весь доход - «%s»: %.0f₽", report.CategoryData.Income[0].Name, report.TotalIncome)
+	}
+	top := report.CategoryData.Income[0]
+	text += fmt.Sprintf("Главный источник - «%s»: %.0f%% дохода", top.Name, top.Share)
+	if top.Share >= 80 {
+		text += "\nДоход почти целиком зависит от одного источника"
+	}
+	return text
+}
+
+// trendsInsight - вывод к трендам: в скольких днях расходы заметно выше среднего
+func trendsInsight(report *service.BaseReport) string {
+	text := "📈 *Отклонение от среднего по дням*\n"
+	if len(report.Trends.ExpenseTrend) < charts.MinLinePoints {
+		return text + "Операций пока слишком мало, чтобы судить о трендах"
+	}
+	var above int
+	var peak service.TrendPoint
+	for _, point := range report.Trends.ExpenseTrend {
+		if point.Change >= 50 {
+			above++
+		}
+		if point.Change > peak.Change {
+			peak = point
+		}
+	}
+	if above == 0 {
+		return text + "Расходы распределены ровно: ни один день не превысил среднее в полтора раза"
+	}
+	return text + fmt.Sprintf("Дней с расходами в полтора раза выше среднего: %d из %d, пик - %s (%+.0f%%)",
+		above, len(report.Trends.ExpenseTrend), peak.Date.Format("02.01"), peak.Change)
+}
+
+// absoluteTrendsInsight - вывод к трендам в рублях: средние траты за последнюю неделю
+// и сравнение с бюджетом на день
+func absoluteTrendsInsight(report *service.BaseReport, dailyBudget float64) string {
+	text := "📈 *Расходы по дням*\n"
+	// Как и на графике, не наступившие дни без операций не считаются
+	days := charts.TrendDays(report)
+	if days < charts.MinLinePoints {
+		return text + "Операций пока слишком мало, чтобы судить о трендах"
+	}
+	var spent float64
+	week := report.Trends.ExpenseTrend[max(0, days-charts.TrendAverageDays):days]
+	for _, point := range week {
+		spent += math.Abs(point.Amount)
+	}
+	average := spent / float64(len(week))
+	text += fmt.Sprintf("В среднем за последние %d дней: %.0f₽ в день", len(week), average)
+	switch {
+	case dailyBudget <= 0:
+	case average > dailyBudget:
+		text += fmt.Sprintf("\nЭто на %.0f₽ выше бюджета в день (%.0f₽)", average-dailyBudget, dailyBudget)
+	default:
+		text += fmt.Sprintf("\nЭто в пределах бюджета в день (%.0f₽)", dailyBudget)
+	}
+	return text
+}
+
+// balanceInsight - вывод к сравнению периодов: как изменились доходы, расходы и баланс
+func balanceInsight(report *service.BaseReport) string {
+	text := "⚖️ *Сравнение с прошлым периодом*\n"
+	comparison := report.Trends.PeriodComparison
+	if comparison.PrevPeriod.TotalIncome == 0 && comparison.PrevPeriod.TotalExpenses == 0 {
+		return text + "За прошлый период данных нет"
+	}
+	// Изменение считается только к ненулевой сумме прошлого периода
+	if comparison.PrevPeriod.TotalExpenses > 0 {
+		text += fmt.Sprintf("Расходы %s", changeText(comparison.ExpenseChange))
+	}
+	if comparison.PrevPeriod.TotalIncome > 0 {
+		if comparison.PrevPeriod.TotalExpenses > 0 {
+			text += ", доходы "
+		} else {
+			text += "Доходы "
+		}
+		text += changeText(comparison.IncomeChange)
+	}
+	delta := comparison.CurrentPeriod.Balance - comparison.PrevPeriod.Balance
+	switch {
+	case delta > 0:
+		text += fmt.Sprintf("\nБаланс лучше прошлого периода на %.0f₽", delta)
+	case delta < 0:
+		text += fmt.Sprintf("\nБаланс хуже прошлого периода на %.0f₽", -delta)
+	}
+	return text
+}
+
+// changeText описывает изменение в процентах словами
+func changeText(percent float64) string {
+	switch {
+	case math.Abs(percent) < 1:
+		return "не изменились"
+	case percent > 0:
+		return fmt.Sprintf("выросли на %.0f%%", percent)
+	default:
+		return fmt.Sprintf("снизились на %.0f%%", -percent)
+	}
+}
+
+// weekInsight - вывод к графику недели: самый затратный день и дни без трат
+func weekInsight(report *service.BaseReport) string {
+	text := "📅 *Неделя по дням*\n"
+	var peak service.TrendPoint
+	spendDays := 0
+	for _, point := range report.Trends.ExpenseTrend {
+		if point.Amount == 0 {
+			continue
+		}
+		spendDays++
+		if math.Abs(point.Amount) > math.Abs(peak.Amount) {
+			peak = point
+		}
+	}
+	if spendDays == 0 {
+		return text + "Трат за неделю не было 🎉"
+	}
+	text += fmt.Sprintf("Больше всего потрачено %s: %.0f₽", peak.Date.Format("02.01"), math.Abs(peak.Amount))
+	days := int(report.EndDate.Sub(report.StartDate).Hours()/24) + 1
+	if free := days - spendDays; free > 0 {
+		text += fmt.Sprintf("\nДней без трат: %d", free)
+	}
+	return text
+}
+
+// hoursInsight - вывод к графику по часам: самый затратный час и доля ночных покупок
+func hoursInsight(report *service.BaseReport) string {
+	text := "🕐 *Расходы по часам*\n"
+	stats := report.TimeOfDay
+	peak := stats.PeakHour()
+	if peak < 0 {
+		return text + "Время покупок пока не записано"
+	}
+	text += fmt.Sprintf("Больше всего потрачено с %02d:00 до %02d:00: %.0f₽", peak, (peak+1)%24, stats.Hours[peak].Expenses)
+	if stats.Night.Count > 0 {
+		text += fmt.Sprintf("\nНочью (%s) - %.0f₽, %.0f%% расходов", service.NightHours(), stats.Night.Expenses, stats.NightShare())
+	}
+	if stats.Untimed > 0 {
+		text += fmt.Sprintf("\nОпераций без времени (из выписок и старых записей): %d", stats.Untimed)
+	}
+	return text
+}
@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/locale"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// SendMonthClosing присылает итоги месяца, в который попадает day, с кнопками бюджетов
+// на следующий месяц. Возвращает false, если за месяц не было операций и итогов нет
+func (b *Bot) SendMonthClosing(ctx context.Context, userID int64, day time.Time) (bool, error) {
+	closing, err := b.service.GetMonthClosing(ctx, userID, day)
+	if err != nil {
+		return false, fmt.Errorf("error getting month closing: %w", err)
+	}
+	if closing == nil {
+		return false, nil
+	}
+
+	msg := tgbotapi.NewMessage(userID, formatMonthClosing(ctx, closing))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = monthClosingKeyboard(closing)
+	if _, err := b.api.Send(msg); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// formatMonthClosing описывает итоги месяца: показатели, победы и рост трат по категориям, бюджеты
+func formatMonthClosing(ctx context.Context, closing *service.MonthClosing) string {
+	lang := locale.FromContext(ctx)
+	current, prev := closing.CurrentPeriod, closing.PrevPeriod
+
+	text := fmt.Sprintf("🗓 *Итоги месяца: %s*\n\n", locale.FormatMonth(lang, closing.Second.Start))
+	text += fmt.Sprintf("💰 Доходы: %.0f₽%s\n", current.TotalIncome, closingChange(current.TotalIncome, prev.TotalIncome))
+	text += fmt.Sprintf("💸 Расходы: %.0f₽%s\n", current.TotalExpenses, closingChange(current.TotalExpenses, prev.TotalExpenses))
+	switch {
+	case current.TotalIncome > 0 && current.Balance >= 0:
+		text += fmt.Sprintf("💵 Сохранено: %.0f₽ - %.0f%% дохода", current.Balance, current.SavingsRate())
+		if prev.TotalIncome > 0 {
+			text += fmt.Sprintf(" (месяцем ранее %.0f%%)", prev.SavingsRate())
+		}
+		text += "\n"
+	case current.Balance < 0:
+		text += fmt.Sprintf("💵 Расходы превысили доходы на %.0f₽\n", -current.Balance)
+	}
+
+	if len(closing.Wins) > 0 {
+		text += "\n🏆 *Победы:*\n"
+		for _, category := range closing.Wins {
+			text += fmt.Sprintf("• %s: %.0f₽ → %.0f₽ (-%.0f₽)\n",
+				category.Name, category.First, category.Second, category.First-category.Second)
+		}
+	}
+	if len(closing.Regressions) > 0 {
+		text += "\n📈 *Рост трат:*\n"
+		for _, category := range closing.Regressions {
+			text += fmt.Sprintf("• %s: %.0f₽ → %.0f₽ (+%.0f₽)\n",
+				category.Name, category.First, category.Second, category.Second-category.First)
+		}
+	}
+
+	if len(closing.Budgets) > 0 {
+		text += "\n🎯 *Бюджеты:*\n"
+		within := 0
+		for _, status := range closing.Budgets {
+			if status.Remaining() >= 0 {
+				within++
+				text += fmt.Sprintf("✅ %s: %.0f₽ из %.0f₽\n", status.CategoryName, status.Spent, status.Available())
+				continue
+			}
+			text += fmt.Sprintf("🔴 %s: %.0f₽ из %.0f₽, перерасход %.0f₽\n",
+				status.CategoryName, status.Spent, status.Available(), -status.Remaining())
+		}
+		text += fmt.Sprintf("Уложились в %d из %d\n", within, len(closing.Budgets))
+	}
+
+	if len(closing.Suggestions) > 0 {
+		next := closing.Second.Start.AddDate(0, 1, 0)
+		text += fmt.Sprintf("\nБюджеты на %s можно установить кнопками ниже", locale.FormatMonth(lang, next))
+	}
+	return strings.TrimSpace(text)
+}
+
+// closingChange описывает изменение к прошлому месяцу. Без данных за прошлый месяц - пусто
+func closingChange(current, prev float64) string {
+	if prev == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%+.0f%%)", (current-prev)/prev*100)
+}
+
+// monthClosingKeyboard предлагает установить бюджеты на следующий месяц
+func monthClosingKeyboard(closing *service.MonthClosing) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, suggestion := range closing.Suggestions {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(callbackButton(
+			fmt.Sprintf("🎯 %s: бюджет %.0f₽", suggestion.CategoryName, suggestion.Amount),
+			cbAdvice, suggestion.CategoryID, math.Round(suggestion.Amount),
+		)))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(callbackButton("📋 Все бюджеты", cbBudget, "list")))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
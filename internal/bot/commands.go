@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/locale"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// command - обработчик команды. Команды с financial показывают финансовые данные
+// и после бездействия требуют PIN
+type command struct {
+	handle    func(ctx context.Context, message *tgbotapi.Message)
+	financial bool
+}
+
+// commandHandlers - обработчики команд по имени без "/". Новая команда добавляется только здесь,
+// в меню Telegram и справку - по желанию
+func (b *Bot) commandHandlers() map[string]command {
+	report := func(reportType service.ReportType) command {
+		return command{
+			handle: func(ctx context.Context, message *tgbotapi.Message) {
+				b.sendReport(ctx, message.Chat.ID, message.From.ID, reportType)
+			},
+			financial: true,
+		}
+	}
+	withoutContext := func(handle func(*tgbotapi.Message)) func(context.Context, *tgbotapi.Message) {
+		return func(_ context.Context, message *tgbotapi.Message) { handle(message) }
+	}
+
+	return map[string]command{
+		"start":        {handle: b.handleStart},
+		"add":          {handle: b.handleAddTransaction},
+		"report":       {handle: withoutContext(b.handleReport), financial: true},
+		"today":        report(service.DailyReport),
+		"week":         report(service.WeeklyReport),
+		"month":        report(service.MonthlyReport),
+		"year":         report(service.YearlyReport),
+		"categories":   {handle: b.handleCategories},
+		"export":       {handle: withoutContext(b.handleExport), financial: true},
+		"duplicates":   {handle: b.handleDuplicates, financial: true},
+		"advice":       {handle: b.handleAdvice, financial: true},
+		"goal":         {handle: b.handleGoal, financial: true},
+		"wishlist":     {handle: b.handleWishlist, financial: true},
+		"budgets":      {handle: b.handleBudgets, financial: true},
+		"plan":         {handle: b.handlePlan, financial: true},
+		"limit":        {handle: b.handleLimit, financial: true},
+		"balance":      {handle: b.handleBalance, financial: true},
+		"family":       {handle: b.handleFamily},
+		"ledgers":      {handle: b.handleLedgers, financial: true},
+		"quarter":      {handle: b.handleQuarter, financial: true},
+		"event":        {handle: b.handleEvent, financial: true},
+		"paid":         {handle: b.handlePaid, financial: true},
+		"rate":         {handle: b.handleRate},
+		"whatsnew":     {handle: b.handleWhatsNew},
+		"networth":     {handle: b.handleNetWorth, financial: true},
+		"forecast":     {handle: b.handleForecast, financial: true},
+		"compare":      {handle: b.handleCompare, financial: true},
+		"income":       {handle: b.handleIncome, financial: true},
+		"year_review":  {handle: b.handleYearReview, financial: true},
+		"remind":       {handle: b.handleRemind},
+		"settings":     {handle: b.handleSettings},
+		"digests":      {handle: b.handleSettings},
+		"integrations": {handle: b.handleIntegrations},
+		"help":         {handle: withoutContext(b.handleHelp)},
+		"cancel":       {handle: b.handleCancel},
+		"stats":        {handle: b.handleStats},
+		"broadcast":    {handle: b.handleBroadcast},
+		"export_all":   {handle: b.handleExportAll, financial: true},
+		"trash":        {handle: b.handleRecycleBin, financial: true},
+		"delete_me":    {handle: b.handleDeleteMe, financial: true},
+		"pin":          {handle: b.handlePIN, financial: true},
+		// Конструктор отчета
+		"report_settings": {handle: b.handleReportSettings},
+	}
+}
+
+// menuCommands - команды, которые показываются в меню Telegram, с описаниями на каждом языке
+var menuCommands = map[string][]tgbotapi.BotCommand{
+	locale.Russian: {
+		{Command: "start", Description: "Главное меню"},
+		{Command: "add", Description: "Добавить доход или расход"},
+		{Command: "report", Description: "Отчеты и графики"},
+		{Command: "categories", Description: "Управление категориями"},
+		{Command: "export", Description: "Выгрузка в CSV, Excel и PDF"},
+		{Command: "settings", Description: "Настройки уведомлений"},
+		{Command: "help", Description: "Справка"},
+		{Command: "cancel", Description: "Отменить текущее действие"},
+	},
+	locale.English: {
+		{Command: "start", Description: "Main menu"},
+		{Command: "add", Description: "Add income or expense"},
+		{Command: "report", Description: "Reports and charts"},
+		{Command: "categories", Description: "Manage categories"},
+		{Command: "export", Description: "Export to CSV, Excel and PDF"},
+		{Command: "settings", Description: "Notification settings"},
+		{Command: "help", Description: "Help"},
+		{Command: "cancel", Description: "Cancel current action"},
+	},
+}
+
+// RegisterCommands регистрирует меню команд в Telegram.
+// Описания на языке по умолчанию видят пользователи, для языка которых нет перевода
+func (b *Bot) RegisterCommands() error {
+	scope := tgbotapi.NewBotCommandScopeDefault()
+	if _, err := b.api.Request(tgbotapi.NewSetMyCommandsWithScope(scope, menuCommands[locale.Default]...)); err != nil {
+		return fmt.Errorf("failed to set default commands: %w", err)
+	}
+
+	for lang, commands := range menuCommands {
+		config := tgbotapi.NewSetMyCommandsWithScopeAndLanguage(scope, lang, commands...)
+		if _, err := b.api.Request(config); err != nil {
+			return fmt.Errorf("failed to set commands for %s: %w", lang, err)
+		}
+	}
+	return nil
+}
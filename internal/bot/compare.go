@@ -0,0 +1,142 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// compareShownCategories - сколько категорий расходов перечисляется в сравнении периодов
+const compareShownCategories = 8
+
+// periodFormats - подсказка о форматах периода
+const periodFormats = "`01.07.2026-14.07.2026`, `01.07-14.07` в текущем году или месяц целиком: `06.2026`"
+
+// handleCompare начинает сравнение двух периодов: запрашивает первый период
+func (b *Bot) handleCompare(ctx context.Context, message *tgbotapi.Message) {
+	state := &model.UserState{
+		UserID:         message.From.ID,
+		AwaitingAction: model.StateCompareFirst,
+	}
+	if err := b.saveUserState(ctx, state); err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Ошибка при сохранении состояния")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID,
+		"⚖️ *Сравнение периодов*\n\nВведите первый период, например отпуск: "+periodFormats)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = cancelKeyboard
+	b.api.Send(msg)
+}
+
+// handleCompareCallback начинает сравнение периодов по кнопке из меню отчетов
+func (b *Bot) handleCompareCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, _ callbackArgs) error {
+	b.handleCompare(ctx, callbackMessage(callback))
+	return nil
+}
+
+// comparePeriodFromMessage принимает первый период и запрашивает второй, а после второго
+// показывает сравнение. Первый период хранится в Payload в том виде, в котором его ввели
+func (b *Bot) comparePeriodFromMessage(ctx context.Context, message *tgbotapi.Message, state *model.UserState) error {
+	now := time.Now()
+	period, err := service.ParsePeriod(message.Text, now)
+	if err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Не удалось разобрать период. Примеры: "+periodFormats)
+		msg.ParseMode = "Markdown"
+		b.api.Send(msg)
+		return nil
+	}
+
+	if state.AwaitingAction == model.StateCompareFirst {
+		next := &model.UserState{
+			UserID:         message.From.ID,
+			AwaitingAction: model.StateCompareNext,
+			Payload:        message.Text,
+		}
+		if err := b.transitionUserState(ctx, state, next); err != nil {
+			return fmt.Errorf("error saving user state: %w", err)
+		}
+		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf(
+			"Первый период: %s\n\nТеперь введите второй, например обычный месяц", period))
+		msg.ReplyMarkup = cancelKeyboard
+		b.api.Send(msg)
+		return nil
+	}
+
+	first, err := service.ParsePeriod(state.Payload, now)
+	if err != nil {
+		return fmt.Errorf("error parsing saved period: %w", err)
+	}
+	if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+		b.reportError(ctx, fmt.Errorf("error deleting user state: %w", err))
+	}
+
+	comparison, err := b.service.ComparePeriods(ctx, message.From.ID, first, period)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось сравнить периоды")
+		return fmt.Errorf("error comparing periods: %w", err)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, comparisonText(comparison))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+
+	if comparison.PrevPeriod.TotalIncome+comparison.PrevPeriod.TotalExpenses+
+		comparison.CurrentPeriod.TotalIncome+comparison.CurrentPeriod.TotalExpenses == 0 {
+		return nil
+	}
+	delivery := b.chartDelivery(ctx, message.From.ID)
+	chartData, err := delivery.charts.GenerateComparisonChart(comparison)
+	if err != nil {
+		log.Printf("Error generating comparison chart: %v", err)
+		return nil
+	}
+	b.api.Send(delivery.message(message.Chat.ID, "compare", chartData))
+	return nil
+}
+
+// comparisonText - итоги двух периодов рядом: всего, в среднем за день и по категориям
+func comparisonText(c *service.CustomComparison) string {
+	first, second := c.PrevPeriod, c.CurrentPeriod
+	text := "⚖️ *Сравнение периодов*\n\n"
+	text += fmt.Sprintf("(1) %s, %d дн.\n", c.First, c.First.Days())
+	text += fmt.Sprintf("(2) %s, %d дн.\n\n", c.Second, c.Second.Days())
+
+	text += fmt.Sprintf("💰 Доходы: %.0f₽ → %.0f₽%s\n",
+		first.TotalIncome, second.TotalIncome, changeMark(c.IncomeChange, first.TotalIncome))
+	text += fmt.Sprintf("💸 Расходы: %.0f₽ → %.0f₽%s\n",
+		first.TotalExpenses, second.TotalExpenses, changeMark(c.ExpenseChange, first.TotalExpenses))
+	text += fmt.Sprintf("📊 Баланс: %.0f₽ → %.0f₽\n", first.Balance, second.Balance)
+	text += fmt.Sprintf("📉 Расход в день: %.0f₽ → %.0f₽\n", first.DailyAvgExpense, second.DailyAvgExpense)
+
+	if len(c.Expenses) > 0 {
+		text += "\n*Расходы по категориям:*\n"
+		for i, cat := range c.Expenses {
+			if i == compareShownCategories {
+				text += fmt.Sprintf("...и еще %d\n", len(c.Expenses)-i)
+				break
+			}
+			icon, name := cat.Emoji, cat.Name
+			if name == "" {
+				icon, name = "•", "Без категории"
+			}
+			text += fmt.Sprintf("%s %s: %.0f₽ → %.0f₽\n", icon, name, cat.First, cat.Second)
+		}
+	}
+	return text
+}
+
+// changeMark - изменение в процентах после суммы, пусто, если в первом периоде сравнивать не с чем
+func changeMark(change, base float64) string {
+	if base == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%+.0f%%)", change)
+}
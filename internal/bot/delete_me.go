@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// deleteConfirmTTL - сколько действует последнее подтверждение удаления
+const deleteConfirmTTL = 10 * time.Minute
+
+// handleExportAll отправляет архив со всеми данными пользователя
+func (b *Bot) handleExportAll(ctx context.Context, message *tgbotapi.Message) {
+	if err := b.sendUserArchive(ctx, message.Chat.ID, message.From.ID); err != nil {
+		b.reportError(ctx, err)
+	}
+}
+
+// sendUserArchive выгружает все данные пользователя JSON-файлом
+func (b *Bot) sendUserArchive(ctx context.Context, chatID, userID int64) error {
+	archive, err := b.service.ExportUserData(ctx, userID)
+	if err != nil {
+		b.sendServiceError(ctx, chatID, err, "Не удалось собрать архив данных")
+		return fmt.Errorf("error exporting user data: %w", err)
+	}
+	data, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		b.sendServiceError(ctx, chatID, err, "Не удалось собрать архив данных")
+		return fmt.Errorf("error encoding user data: %w", err)
+	}
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("financial_bot_%d_%s.json", userID, archive.ExportedAt.Format("2006-01-02")),
+		Bytes: data,
+	})
+	doc.Caption = "🗄 Все ваши данные: транзакции, категории, бюджеты, цели, счета и настройки"
+	if _, err := b.api.Send(doc); err != nil {
+		b.sendErrorMessage(chatID, "Не удалось отправить архив данных")
+		return fmt.Errorf("error sending user archive: %w", err)
+	}
+	return nil
+}
+
+// handleDeleteMe отправляет архив данных и спрашивает подтверждение удаления
+func (b *Bot) handleDeleteMe(ctx context.Context, message *tgbotapi.Message) {
+	// Без архива не удаляем: пользователь должен сохранить копию данных
+	if err := b.sendUserArchive(ctx, message.Chat.ID, message.From.ID); err != nil {
+		b.reportError(ctx, err)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID,
+		"⚠️ *Удаление всех данных*\n\n"+
+			"Будут удалены транзакции, категории, бюджеты, цели, счета, настройки и интеграции. "+
+			"Если вы ведете общий бюджет, он будет удален и для участников.\n\n"+
+			"Архив выше - ваша копия. Восстановить данные после удаления нельзя")
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("🗑 Удалить мои данные", cbDeleteMe, 1),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("✖️ Отмена", cbCancel),
+		),
+	)
+	b.api.Send(msg)
+}
+
+// handleDeleteMeCallback обрабатывает подтверждения удаления.
+// Аргументы: 1 - первое подтверждение, 2 <unix-время> - окончательное
+func (b *Bot) handleDeleteMeCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	chatID := callback.Message.Chat.ID
+
+	if args.String(0) == "1" {
+		edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, callback.Message.MessageID,
+			"❗️ Точно удалить все данные? Это последнее подтверждение",
+			tgbotapi.NewInlineKeyboardMarkup(
+				tgbotapi.NewInlineKeyboardRow(
+					callbackButton("Да, удалить навсегда",
+						cbDeleteMe, 2, time.Now().Unix()),
+				),
+				tgbotapi.NewInlineKeyboardRow(
+					callbackButton("✖️ Отмена", cbCancel),
+				),
+			))
+		b.api.Send(edit)
+		return nil
+	}
+
+	// Старое подтверждение в истории чата не должно удалять данные случайным нажатием
+	issued, err := args.Int64(1)
+	if args.String(0) != "2" || err != nil {
+		return fmt.Errorf("invalid delete confirmation: %v", args)
+	}
+	if time.Since(time.Unix(issued, 0)) > deleteConfirmTTL {
+		b.sendErrorMessage(chatID, "Подтверждение устарело. Отправьте /delete_me еще раз")
+		return nil
+	}
+
+	if err := b.service.DeleteUserData(ctx, callback.From.ID); err != nil {
+		b.sendServiceError(ctx, chatID, err, "Не удалось удалить данные, ничего не изменено")
+		return fmt.Errorf("error deleting user data: %w", err)
+	}
+
+	edit := tgbotapi.NewEditMessageText(chatID, callback.Message.MessageID,
+		"✅ Все ваши данные удалены. Чтобы начать заново, отправьте /start")
+	b.api.Send(edit)
+	return nil
+}
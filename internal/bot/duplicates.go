@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// maxDuplicatesShown - количество пар дубликатов в ответе /duplicates
+const maxDuplicatesShown = 10
+
+// handleDuplicates показывает похожие друг на друга записи за последние месяцы
+func (b *Bot) handleDuplicates(ctx context.Context, message *tgbotapi.Message) {
+	now := time.Now()
+	pairs, err := b.service.FindDuplicates(ctx, message.From.ID,
+		now.AddDate(0, 0, -service.DuplicateScanDays), now)
+	if err != nil {
+		log.Printf("Error finding duplicates: %v", err)
+		b.sendErrorMessage(message.Chat.ID, "Не удалось проверить записи на дубликаты")
+		return
+	}
+
+	if len(pairs) == 0 {
+		msg := tgbotapi.NewMessage(message.Chat.ID,
+			fmt.Sprintf("✅ За последние %d дней дубликатов не найдено", service.DuplicateScanDays))
+		msg.ReplyMarkup = b.getMainKeyboard()
+		b.api.Send(msg)
+		return
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "🔁 Похоже, эти операции записаны дважды (%d):\n\n", len(pairs))
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	for i, pair := range pairs {
+		if i == maxDuplicatesShown {
+			fmt.Fprintf(&text, "... и еще %d. Объедините эти и вызовите /duplicates снова\n", len(pairs)-maxDuplicatesShown)
+			break
+		}
+		fmt.Fprintf(&text, "%d. %s\n", i+1, duplicateLine(pair))
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton(fmt.Sprintf("🔗 Объединить %d", i+1), cbDuplicate, "del", pair.Drop.ID),
+		))
+	}
+	text.WriteString("\nПри объединении остается одна запись, вторая удаляется")
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text.String())
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	b.api.Send(msg)
+}
+
+// warnDuplicate предупреждает, если только что записанная операция похожа на существующую
+func (b *Bot) warnDuplicate(ctx context.Context, userID, chatID int64, amount float64, description string) {
+	pair, err := b.service.CheckDuplicate(ctx, userID, amount, description, time.Now())
+	if err != nil {
+		log.Printf("Error checking duplicate: %v", err)
+		return
+	}
+	if pair == nil {
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID,
+		"🔁 Похоже, эта операция уже была записана:\n"+duplicateLine(*pair)+"\n\nОбъединить записи?")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("🔗 Объединить", cbDuplicate, "del", pair.Drop.ID),
+			callbackButton("Это разные операции", cbDuplicate, "ok"),
+		),
+	)
+	b.api.Send(msg)
+}
+
+// handleDuplicateCallback объединяет дубликаты или оставляет обе записи.
+// Аргументы: del <ID транзакции> - удалить лишнюю запись, ok - оставить обе
+func (b *Bot) handleDuplicateCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	chatID := callback.Message.Chat.ID
+
+	if args.String(0) == "ok" {
+		edit := tgbotapi.NewEditMessageText(chatID, callback.Message.MessageID,
+			callback.Message.Text+"\n\nОставлены обе записи ✅")
+		b.api.Send(edit)
+		return nil
+	}
+
+	transactionID := args.String(1)
+	if err := b.service.MergeDuplicate(ctx, callback.From.ID, transactionID); err != nil {
+		b.sendServiceError(ctx, chatID, err, "Не удалось объединить записи")
+		return fmt.Errorf("error merging duplicate: %w", err)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "🔗 Записи объединены: лишняя удалена ✅")
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+	return nil
+}
+
+// duplicateLine описывает пару дубликатов одной строкой
+func duplicateLine(pair service.DuplicatePair) string {
+	line := fmt.Sprintf("%s, %.2f₽", pair.Keep.Date.Format("02.01"), math.Abs(pair.Keep.Amount))
+	if description := pair.Keep.Description; description != "" {
+		line += " - " + description
+	} else if pair.Drop.Description != "" {
+		line += " - " + pair.Drop.Description
+	}
+	if pair.Keep.Date.Format("02.01") != pair.Drop.Date.Format("02.01") {
+		line += fmt.Sprintf(" (и %s)", pair.Drop.Date.Format("02.01"))
+	}
+	return line
+}
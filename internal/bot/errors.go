@@ -0,0 +1,60 @@
+package bot
+
+import (
+	"context"
+	"errors"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// sendServiceError сообщает, что действие не удалось: text - что именно не получилось,
+// дальше идет причина по виду ошибки. Если действие стоит повторить позже, к сообщению
+// прикладывается кнопка повтора
+func (b *Bot) sendServiceError(ctx context.Context, chatID int64, err error, text string) {
+	msg := tgbotapi.NewMessage(chatID, "❌ "+text+errorHint(err))
+	if retryable(err) {
+		if update, ok := ctx.Value(updateKey{}).(tgbotapi.Update); ok {
+			if retry, ok := retryButton(update); ok {
+				msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(retry))
+			}
+		}
+	}
+	b.api.Send(msg)
+}
+
+// errorHint - причина ошибки и что делать пользователю, пусто для нераспознанных ошибок
+func errorHint(err error) string {
+	switch {
+	case errors.Is(err, model.ErrRateLimited):
+		return "\n\n⏳ Слишком много запросов. Подождите минуту и попробуйте снова"
+	case retryable(err):
+		return "\n\n🔌 Сервис временно недоступен. Попробуйте еще раз через пару минут"
+	case errors.Is(err, model.ErrNotFound):
+		return "\n\n🔍 Запись не найдена - возможно, ее уже удалили"
+	case errors.Is(err, model.ErrValidation):
+		return "\n\n✏️ Проверьте введенные данные"
+	}
+	return ""
+}
+
+// retryable сообщает, что ошибка временная и то же действие может выполниться позже
+func retryable(err error) bool {
+	return errors.Is(err, model.ErrRateLimited) ||
+		errors.Is(err, model.ErrStorageUnavailable) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// callbackErrorText - короткая подсказка во всплывающем ответе на кнопку, обработка
+// которой завершилась ошибкой
+func callbackErrorText(err error) string {
+	switch {
+	case errors.Is(err, model.ErrRateLimited):
+		return "⏳ Слишком много запросов, подождите минуту"
+	case retryable(err):
+		return "🔌 Сервис временно недоступен, попробуйте позже"
+	case errors.Is(err, model.ErrNotFound):
+		return "🔍 Запись не найдена"
+	}
+	return "❌ Не удалось выполнить действие"
+}
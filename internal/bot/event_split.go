@@ -0,0 +1,190 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// eventJoinPrefix - префикс параметра /start в ссылке-приглашении в общее событие
+const eventJoinPrefix = "ev_"
+
+// handlePaid записывает платеж участника общего события: /paid 3200 Бензин
+func (b *Bot) handlePaid(ctx context.Context, message *tgbotapi.Message) {
+	args := strings.SplitN(strings.TrimSpace(message.CommandArguments()), " ", 2)
+	if args[0] == "" {
+		b.sendErrorMessage(message.Chat.ID, "Укажите сумму, которую вы заплатили за всех: /paid 3200 Бензин")
+		return
+	}
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(args[0], ",", "."), 64)
+	if err != nil || amount <= 0 {
+		b.sendErrorMessage(message.Chat.ID, "Неверный формат суммы. Используйте: /paid 3200 Бензин")
+		return
+	}
+	description := ""
+	if len(args) > 1 {
+		description = args[1]
+	}
+
+	payment, event, err := b.service.AddEventPayment(ctx, message.From.ID, amount, description)
+	if errors.Is(err, service.ErrNoSharedEvent) {
+		b.sendErrorMessage(message.Chat.ID, "Вы не участвуете в идущем общем событии. Если событие ваше, "+
+			"просто записывайте траты - они попадают в расчет сами")
+		return
+	}
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось записать платеж")
+		return
+	}
+
+	text := fmt.Sprintf("Записал ✅ Вы заплатили %.2f₽ в событии %s", payment.Amount, event.Title())
+	if payment.Description != "" {
+		text = fmt.Sprintf("Записал ✅ Вы заплатили %.2f₽ за «%s» в событии %s", payment.Amount, payment.Description, event.Title())
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		callbackButton("👥 Кто кому должен", cbEvent, "split", event.ID),
+		callbackButton("↩️ Отменить", cbEvent, "unpay", payment.ID),
+	))
+	b.api.Send(msg)
+}
+
+// shareEvent присылает владельцу ссылку, по которой попутчики вступают в событие
+func (b *Bot) shareEvent(ctx context.Context, callback *tgbotapi.CallbackQuery, eventID string) error {
+	chatID := callback.Message.Chat.ID
+	code, err := b.service.ShareEvent(ctx, callback.From.ID, displayName(callback.From), eventID)
+	switch {
+	case errors.Is(err, service.ErrEventNotFound):
+		b.sendErrorMessage(chatID, "Событие не найдено - возможно, его уже удалили")
+		return nil
+	case errors.Is(err, service.ErrEventClosed):
+		b.sendErrorMessage(chatID, "Событие уже завершено, пригласить в него нельзя")
+		return nil
+	case err != nil:
+		return fmt.Errorf("error sharing event: %w", err)
+	}
+	name, err := b.botUsername()
+	if err != nil {
+		return err
+	}
+	link := fmt.Sprintf("https://t.me/%s?start=%s%s", name, eventJoinPrefix, code)
+	b.api.Send(tgbotapi.NewMessage(chatID,
+		"Отправьте эту ссылку попутчикам. Они будут записывать свои платежи за всех командой /paid, "+
+			"а бот посчитает, кто кому сколько должен. Ваши траты попадают в расчет сами:\n\n"+link))
+	return nil
+}
+
+// handleJoinEvent принимает приглашение в общее событие из ссылки /start ev_<code>
+func (b *Bot) handleJoinEvent(ctx context.Context, message *tgbotapi.Message, code string) {
+	event, err := b.service.JoinEvent(ctx, code, message.From.ID, displayName(message.From))
+	switch {
+	case errors.Is(err, service.ErrEventInviteNotFound):
+		b.sendErrorMessage(message.Chat.ID, "Событие не найдено - возможно, его удалили")
+		return
+	case errors.Is(err, service.ErrEventClosed):
+		b.sendErrorMessage(message.Chat.ID, "Это событие уже завершено")
+		return
+	case err != nil:
+		log.Printf("Error joining event: %v", err)
+		b.sendErrorMessage(message.Chat.ID, "Не удалось присоединиться к событию")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Вы участвуете в событии %s! 👥\n"+
+		"Когда заплатите за всех, запишите это: /paid 3200 Бензин. Кто кому должен, видно в /event", event.Title()))
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+
+	split, err := b.service.GetEventSplit(ctx, message.From.ID, event.ID)
+	if err != nil {
+		b.reportError(ctx, fmt.Errorf("error getting event split: %w", err))
+		return
+	}
+	// Уведомляем остальных участников
+	for _, member := range split.Members {
+		if member.UserID != message.From.ID {
+			b.api.Send(tgbotapi.NewMessage(member.UserID,
+				fmt.Sprintf("%s присоединился к событию %s 👋", displayName(message.From), event.Title())))
+		}
+	}
+}
+
+// sendEventSplit присылает участнику расчет общего события
+func (b *Bot) sendEventSplit(ctx context.Context, chatID, userID int64, eventID string) error {
+	split, err := b.service.GetEventSplit(ctx, userID, eventID)
+	if errors.Is(err, service.ErrEventNotFound) {
+		b.sendErrorMessage(chatID, "Событие не найдено - возможно, его уже удалили")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error getting event split: %w", err)
+	}
+
+	event := split.Event
+	text := fmt.Sprintf("👥 *%s*\n", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, event.Title()))
+	if event.IsOpen() {
+		text += fmt.Sprintf("Идет с %s, расчет промежуточный\n", event.StartedAt.Format("02.01.2006"))
+	} else {
+		text += fmt.Sprintf("%s - %s, итоговый расчет\n", event.StartedAt.Format("02.01.2006"), event.ClosedAt.Format("02.01.2006"))
+	}
+	msg := tgbotapi.NewMessage(chatID, text+formatEventSplit(split))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		callbackButton("« К событиям", cbEvent),
+	))
+	b.api.Send(msg)
+	return nil
+}
+
+// notifyEventClosed присылает остальным участникам итоговый расчет завершенного события
+func (b *Bot) notifyEventClosed(ctx context.Context, ownerID int64, report *service.EventReport) {
+	if report.Split == nil {
+		return
+	}
+	for _, member := range report.Split.Members {
+		if member.UserID == ownerID {
+			continue
+		}
+		b.api.Send(tgbotapi.NewMessage(member.UserID, fmt.Sprintf("🏁 Событие %s завершено", report.Event.Title())))
+		if err := b.sendEventSplit(ctx, member.UserID, member.UserID, report.Event.ID); err != nil {
+			b.reportError(ctx, err)
+		}
+	}
+}
+
+// formatEventSplit описывает расчет: кто сколько заплатил и кто кому переводит.
+// Для необщего события возвращает пустую строку
+func formatEventSplit(split *service.EventSplit) string {
+	if split == nil {
+		return ""
+	}
+	text := fmt.Sprintf("\n*Расчет на %d участников*\n", len(split.Members))
+	text += fmt.Sprintf("Всего заплачено %.2f₽, по %.2f₽ на каждого\n\n", split.Total, split.Share)
+	for _, member := range split.Members {
+		name := tgbotapi.EscapeText(tgbotapi.ModeMarkdown, member.Name)
+		switch {
+		case member.Balance > 0:
+			text += fmt.Sprintf("💚 %s: заплачено %.2f₽, должны %.2f₽\n", name, member.Paid, member.Balance)
+		case member.Balance < 0:
+			text += fmt.Sprintf("🔴 %s: заплачено %.2f₽, долг %.2f₽\n", name, member.Paid, -member.Balance)
+		default:
+			text += fmt.Sprintf("⚪️ %s: заплачено %.2f₽, в расчете\n", name, member.Paid)
+		}
+	}
+
+	if len(split.Transfers) == 0 {
+		return text + "\nВсе в расчете 🤝"
+	}
+	text += "\n*Кто кому переводит:*\n"
+	for _, transfer := range split.Transfers {
+		text += fmt.Sprintf("%s → %s: %.2f₽\n", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, transfer.From.Name),
+			tgbotapi.EscapeText(tgbotapi.ModeMarkdown, transfer.To.Name), transfer.Amount)
+	}
+	return strings.TrimSpace(text)
+}
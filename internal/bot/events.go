@@ -0,0 +1,259 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+const (
+	// defaultEventEmoji - значок события, если пользователь не указал свой
+	defaultEventEmoji = "🏕"
+	// maxListedEvents - сколько завершенных событий показывает список
+	maxListedEvents = 5
+)
+
+// handleEvent показывает идущее событие и прошлые. «/event Отпуск в Сочи» сразу
+// начинает событие с этим названием
+func (b *Bot) handleEvent(ctx context.Context, message *tgbotapi.Message) {
+	if name := strings.TrimSpace(message.CommandArguments()); name != "" {
+		b.startEvent(ctx, message.Chat.ID, message.From.ID, name)
+		return
+	}
+	b.sendEvents(ctx, message.Chat.ID, message.From.ID)
+}
+
+// sendEvents присылает список событий: идущее с промежуточным отчетом и завершением,
+// прошлые - кнопками отчетов
+func (b *Bot) sendEvents(ctx context.Context, chatID, userID int64) {
+	events, err := b.service.GetEvents(ctx, userID)
+	if err != nil {
+		b.sendServiceError(ctx, chatID, err, "Не удалось загрузить события")
+		return
+	}
+
+	text := "🏕 *События*\n\n"
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	var open *model.Event
+	for i := range events {
+		if events[i].IsOpen() {
+			open = &events[i]
+			break
+		}
+	}
+	if open != nil {
+		text += fmt.Sprintf("Идет событие %s с %s. Новые траты записываются в него\n",
+			tgbotapi.EscapeText(tgbotapi.ModeMarkdown, open.Title()), open.StartedAt.Format("02.01.2006"))
+		buttons = append(buttons,
+			tgbotapi.NewInlineKeyboardRow(callbackButton("📊 Сколько уже потрачено", cbEvent, "report", open.ID)),
+			tgbotapi.NewInlineKeyboardRow(callbackButton("👥 Пригласить попутчиков", cbEvent, "share", open.ID)),
+			tgbotapi.NewInlineKeyboardRow(callbackButton("🏁 Завершить событие", cbEvent, "close", open.ID)),
+		)
+	} else {
+		text += "Отпуск, ремонт или переезд: начните событие, и траты за это время соберутся " +
+			"в отдельный отчет - полная стоимость, траты в день и по категориям\n"
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(callbackButton("➕ Начать событие", cbEvent, "new")))
+	}
+
+	listed := 0
+	for _, event := range events {
+		if event.IsOpen() {
+			continue
+		}
+		if listed == maxListedEvents {
+			break
+		}
+		listed++
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton(event.Title(), cbEvent, "report", event.ID),
+			callbackButton("🗑", cbEvent, "del", event.ID),
+		))
+	}
+	if listed > 0 {
+		text += "\nПрошлые события - нажмите, чтобы открыть отчет"
+	}
+
+	// Чужие общие события, в которых пользователь участвует
+	shared, err := b.service.SharedEvents(ctx, userID)
+	if err != nil {
+		b.reportError(ctx, fmt.Errorf("error getting shared events: %w", err))
+	}
+	for i, event := range shared {
+		if i == maxListedEvents {
+			break
+		}
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton("👥 "+event.Title(), cbEvent, "split", event.ID),
+		))
+	}
+	if len(shared) > 0 {
+		text += "\n👥 - общие события, где вы участник: свои платежи записывайте командой /paid"
+	}
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(callbackButton("« Назад", cbMenu)))
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	b.api.Send(msg)
+}
+
+// handleEventCallback начинает, завершает и удаляет события и присылает их отчеты
+func (b *Bot) handleEventCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	chatID, userID := callback.Message.Chat.ID, callback.From.ID
+
+	switch args.String(0) {
+	case "new":
+		state := &model.UserState{
+			UserID:         userID,
+			AwaitingAction: model.StateNewEvent,
+		}
+		if err := b.saveUserState(ctx, state); err != nil {
+			return fmt.Errorf("error saving user state: %w", err)
+		}
+		msg := tgbotapi.NewMessage(chatID, "Введите название события, можно со значком в начале:\n`🏖 Отпуск в Сочи`")
+		msg.ParseMode = "Markdown"
+		msg.ReplyMarkup = cancelKeyboard
+		b.api.Send(msg)
+
+	case "report":
+		report, err := b.service.GetEventReport(ctx, userID, args.String(1))
+		if errors.Is(err, service.ErrEventNotFound) {
+			b.sendErrorMessage(chatID, "Событие не найдено - возможно, его уже удалили")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error getting event report: %w", err)
+		}
+		b.sendEventReport(chatID, report)
+
+	case "close":
+		report, err := b.service.CloseEvent(ctx, userID, args.String(1))
+		if errors.Is(err, service.ErrEventNotFound) {
+			b.sendErrorMessage(chatID, "Событие не найдено - возможно, его уже удалили")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error closing event: %w", err)
+		}
+		b.api.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🏁 Событие %s завершено. Новые траты в него больше не попадают", report.Event.Title())))
+		b.sendEventReport(chatID, report)
+		b.notifyEventClosed(ctx, userID, report)
+
+	case "share":
+		return b.shareEvent(ctx, callback, args.String(1))
+
+	case "split":
+		return b.sendEventSplit(ctx, chatID, userID, args.String(1))
+
+	case "unpay":
+		if err := b.service.DeleteEventPayment(ctx, userID, args.String(1)); err != nil {
+			return fmt.Errorf("error deleting event payment: %w", err)
+		}
+		b.api.Send(tgbotapi.NewMessage(chatID, "Платеж отменен ↩️"))
+
+	case "del":
+		msg := tgbotapi.NewMessage(chatID, "Удалить событие? Траты останутся в истории и отчетах, пропадет только отчет события")
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			callbackButton("🗑 Удалить", cbEvent, "rm", args.String(1)),
+			callbackButton("Отмена", cbEvent),
+		))
+		b.api.Send(msg)
+
+	case "rm":
+		err := b.service.DeleteEvent(ctx, userID, args.String(1))
+		if errors.Is(err, service.ErrEventNotFound) {
+			b.sendErrorMessage(chatID, "Событие не найдено - возможно, его уже удалили")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error deleting event: %w", err)
+		}
+		b.api.Send(tgbotapi.NewMessage(chatID, "Событие удалено 🗑"))
+		b.sendEvents(ctx, chatID, userID)
+
+	default:
+		b.sendEvents(ctx, chatID, userID)
+	}
+	return nil
+}
+
+// startEventFromMessage начинает событие с названием из сообщения
+func (b *Bot) startEventFromMessage(ctx context.Context, message *tgbotapi.Message) error {
+	if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+		b.reportError(ctx, fmt.Errorf("error deleting user state: %w", err))
+	}
+	b.startEvent(ctx, message.Chat.ID, message.From.ID, message.Text)
+	return nil
+}
+
+// startEvent начинает событие и сообщает, что траты теперь записываются в него
+func (b *Bot) startEvent(ctx context.Context, chatID, userID int64, text string) {
+	emoji, name := splitCategoryName(text)
+	if emoji == "" {
+		emoji = defaultEventEmoji
+	}
+	event, err := b.service.StartEvent(ctx, userID, name, emoji)
+	if errors.Is(err, service.ErrEventOpen) {
+		b.sendErrorMessage(chatID, "Сейчас уже идет событие. Завершите его в /event, чтобы начать новое")
+		return
+	}
+	if err != nil {
+		b.sendServiceError(ctx, chatID, err, "Не удалось начать событие")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Событие %s началось! ✅\nТраты с сегодняшнего дня записываются в него, "+
+		"а прошлые можно добавить из карточки транзакции в истории. Когда событие закончится, завершите его в /event", event.Title()))
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+}
+
+// sendEventReport присылает отчет события
+func (b *Bot) sendEventReport(chatID int64, report *service.EventReport) {
+	msg := tgbotapi.NewMessage(chatID, formatEventReport(report))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		callbackButton("« К событиям", cbEvent),
+	))
+	b.api.Send(msg)
+}
+
+// formatEventReport описывает событие: полная стоимость, траты в день и по категориям
+func formatEventReport(report *service.EventReport) string {
+	event := report.Event
+	title := tgbotapi.EscapeText(tgbotapi.ModeMarkdown, event.Title())
+	text := fmt.Sprintf("📊 *%s*\n", title)
+	if event.IsOpen() {
+		text += fmt.Sprintf("Идет с %s, %d %s\n", event.StartedAt.Format("02.01.2006"), report.Days, pluralDays(report.Days))
+	} else {
+		text += fmt.Sprintf("%s - %s, %d %s\n", event.StartedAt.Format("02.01.2006"), event.ClosedAt.Format("02.01.2006"),
+			report.Days, pluralDays(report.Days))
+	}
+	if report.Transactions == 0 {
+		return strings.TrimSpace(text + "\nК событию пока не привязано ни одной траты\n" + formatEventSplit(report.Split))
+	}
+
+	text += fmt.Sprintf("\n💸 Полная стоимость: %.2f₽\n", report.Total())
+	if report.Refunds > 0 {
+		text += fmt.Sprintf("Траты %.2f₽, возвраты %.2f₽\n", report.Expenses, report.Refunds)
+	}
+	text += fmt.Sprintf("📅 В среднем за день: %.2f₽\n", report.PerDay())
+	if report.PeakAmount > 0 && report.Days > 1 {
+		text += fmt.Sprintf("🔥 Самый дорогой день: %s, %.2f₽\n", report.PeakDay.Format("02.01"), report.PeakAmount)
+	}
+	text += fmt.Sprintf("📝 Операций: %d\n", report.Transactions)
+
+	if len(report.Categories) > 0 {
+		text += "\n*По категориям:*\n"
+		for _, category := range report.Categories {
+			text += fmt.Sprintf("%s: %.2f₽ (%.0f%%)\n",
+				strings.TrimSpace(category.Emoji+" "+category.Name), category.Amount, category.Share*100)
+		}
+	}
+	return strings.TrimSpace(text + "\n" + formatEventSplit(report.Split))
+}
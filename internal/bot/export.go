@@ -0,0 +1,135 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/export/pdf"
+	"github.com/ivanoskov/financial_bot/internal/export/xlsx"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// handleExport предлагает формат выгрузки
+func (b *Bot) handleExport(message *tgbotapi.Message) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, "📤 *Выгрузка данных*\n\nВыберите формат:")
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("📑 CSV - все транзакции", cbExport, "csv"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("📗 Excel - транзакции, итоги по месяцам, бюджеты", cbExport, "xlsx"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("📄 PDF - отчет за месяц", cbExport, "pdf"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("« Назад", cbMenu),
+		),
+	)
+	b.api.Send(msg)
+}
+
+// handleExportCallback отправляет выгрузку в выбранном формате
+func (b *Bot) handleExportCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	chatID := callback.Message.Chat.ID
+
+	switch args.String(0) {
+	case "csv":
+		return b.sendCSVExport(ctx, chatID, callback.From.ID)
+	case "xlsx":
+		return b.sendXLSXExport(ctx, chatID, callback.From.ID)
+	case "pdf":
+		return b.sendPDFExport(ctx, chatID, callback.From.ID)
+	}
+	return nil
+}
+
+// sendCSVExport отправляет все транзакции пользователя CSV-файлом
+func (b *Bot) sendCSVExport(ctx context.Context, chatID, userID int64) error {
+	data, err := b.service.ExportTransactionsCSV(ctx, userID)
+	if err != nil {
+		b.api.Send(tgbotapi.NewMessage(chatID, "❌ Не удалось выгрузить транзакции"))
+		return fmt.Errorf("error exporting transactions: %w", err)
+	}
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("transactions_%s.csv", time.Now().Format("2006-01-02")),
+		Bytes: data,
+	})
+	doc.Caption = "📤 Все ваши транзакции. Файл можно открыть в Excel или Google Таблицах"
+	b.api.Send(doc)
+	return nil
+}
+
+// sendXLSXExport отправляет книгу Excel с листами для сводных таблиц
+func (b *Bot) sendXLSXExport(ctx context.Context, chatID, userID int64) error {
+	data, err := b.service.GetExportData(ctx, userID)
+	if err != nil {
+		b.api.Send(tgbotapi.NewMessage(chatID, "❌ Не удалось выгрузить данные"))
+		return fmt.Errorf("error getting export data: %w", err)
+	}
+
+	now := time.Now()
+	workbook, err := xlsx.Render(data, now)
+	if err != nil {
+		b.api.Send(tgbotapi.NewMessage(chatID, "❌ Не удалось сформировать файл Excel"))
+		return fmt.Errorf("error rendering xlsx: %w", err)
+	}
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("finances_%s.xlsx", now.Format("2006-01-02")),
+		Bytes: workbook,
+	})
+	doc.Caption = "📗 Транзакции, суммы по категориям за каждый месяц и бюджеты - готово для сводных таблиц"
+	b.api.Send(doc)
+	return nil
+}
+
+// sendPDFExport отправляет месячный отчет с графиками PDF-документом
+func (b *Bot) sendPDFExport(ctx context.Context, chatID, userID int64) error {
+	b.api.Send(tgbotapi.NewMessage(chatID, "📄 Готовлю PDF..."))
+
+	report, err := b.service.GetReport(ctx, userID, service.MonthlyReport)
+	if err != nil {
+		b.api.Send(tgbotapi.NewMessage(chatID, "❌ Не удалось сформировать отчет"))
+		return fmt.Errorf("error getting report: %w", err)
+	}
+
+	// Графики необязательны: без них выписка все равно полезна
+	var charts []pdf.Chart
+	generators := []struct {
+		title    string
+		generate func() ([]byte, error)
+	}{
+		{"Обзор", func() ([]byte, error) { return b.charts().GenerateFinancialDashboard(report) }},
+		{"Расходы по категориям", func() ([]byte, error) { return b.charts().GenerateCategoryPieChart(report, true) }},
+		{"Динамика", func() ([]byte, error) { return b.charts().GenerateTrendChart(report) }},
+		{"Баланс", func() ([]byte, error) { return b.charts().GenerateBalanceChart(report) }},
+	}
+	for _, g := range generators {
+		data, err := g.generate()
+		if err != nil {
+			log.Printf("Error generating %s chart for pdf: %v", g.title, err)
+			continue
+		}
+		charts = append(charts, pdf.Chart{Title: g.title, PNG: data})
+	}
+
+	data, err := pdf.RenderReport(report, charts)
+	if err != nil {
+		b.api.Send(tgbotapi.NewMessage(chatID, "❌ Не удалось сформировать PDF"))
+		return fmt.Errorf("error rendering pdf: %w", err)
+	}
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("report_%s.pdf", report.StartDate.Format("2006-01")),
+		Bytes: data,
+	})
+	doc.Caption = "📄 Отчет за " + report.Period
+	b.api.Send(doc)
+	return nil
+}
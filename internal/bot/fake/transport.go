@@ -0,0 +1,434 @@
+// Package fake - транспорт Telegram без сети для локальной разработки и интеграционных тестов.
+// Входящие сообщения пишутся в консоль или вызовами SendText/Press, исходящие печатаются
+// и сохраняются для проверок
+package fake
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/bot"
+)
+
+// Пользователь и бот по умолчанию
+const (
+	DefaultUserID = 1
+	BotID         = 100
+	BotUsername   = "fake_bot"
+)
+
+const (
+	// pressPrefix - строка консоли, начинающаяся с него, нажимает кнопку: "!Расход"
+	pressPrefix = "!"
+	// idleAfter - сколько бот должен молчать, чтобы считать ответ на строку законченным
+	idleAfter = 200 * time.Millisecond
+)
+
+// ErrNoButton возвращается, если у последних сообщений нет кнопки с таким текстом
+var ErrNoButton = errors.New("no such button")
+
+// Sent - запрос, отправленный ботом
+type Sent struct {
+	Method  string // sendMessage, sendPhoto, editMessageText, ... или метод MakeRequest
+	ChatID  int64
+	Text    string // текст, подпись или ответ на нажатие кнопки
+	File    string // имя отправленного файла
+	Buttons [][]tgbotapi.InlineKeyboardButton
+	Config  tgbotapi.Chattable // nil для MakeRequest и UploadFiles
+}
+
+// Transport реализует bot.Transport без обращения к Telegram
+type Transport struct {
+	user tgbotapi.User
+
+	mu        sync.Mutex
+	out       io.Writer
+	filesDir  string
+	updates   chan tgbotapi.Update
+	closeOnce sync.Once
+	sent      []Sent
+	updateID  int
+	messageID int
+	active    time.Time // последнее обновление или запрос бота
+	// Последнее сообщение бота с inline-кнопками: на него приходят нажатия
+	keyboard    *tgbotapi.InlineKeyboardMarkup
+	keyboardMsg tgbotapi.Message
+}
+
+var _ bot.Transport = (*Transport)(nil)
+
+// NewTransport создает транспорт, печатающий исходящие сообщения в out (nil - не печатать)
+func NewTransport(out io.Writer) *Transport {
+	if out == nil {
+		out = io.Discard
+	}
+	return &Transport{
+		user: tgbotapi.User{
+			ID:           DefaultUserID,
+			FirstName:    "Developer",
+			UserName:     "developer",
+			LanguageCode: "ru",
+		},
+		out:     out,
+		updates: make(chan tgbotapi.Update, 100),
+	}
+}
+
+// SaveFilesTo включает сохранение отправленных графиков и документов в каталог
+func (t *Transport) SaveFilesTo(dir string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.filesDir = dir
+}
+
+// SendText отправляет боту сообщение от пользователя. Текст с "/" в начале - команда
+func (t *Transport) SendText(text string) {
+	t.mu.Lock()
+	t.messageID++
+	message := &tgbotapi.Message{
+		MessageID: t.messageID,
+		From:      &t.user,
+		Chat:      &tgbotapi.Chat{ID: t.user.ID, Type: "private"},
+		Date:      int(time.Now().Unix()),
+		Text:      text,
+	}
+	t.mu.Unlock()
+
+	if strings.HasPrefix(text, "/") {
+		command, _, _ := strings.Cut(text, " ")
+		message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command)}}
+	}
+	t.push(tgbotapi.Update{Message: message})
+}
+
+// Press нажимает кнопку последнего сообщения бота с клавиатурой. Кнопка ищется
+// по точному тексту, а если такой нет - по вхождению
+func (t *Transport) Press(text string) error {
+	t.mu.Lock()
+	if t.keyboard == nil {
+		t.mu.Unlock()
+		return ErrNoButton
+	}
+	var found *tgbotapi.InlineKeyboardButton
+	for _, exact := range []bool{true, false} {
+		for _, row := range t.keyboard.InlineKeyboard {
+			for i := range row {
+				if found == nil && row[i].CallbackData != nil &&
+					(row[i].Text == text || !exact && strings.Contains(row[i].Text, text)) {
+					found = &row[i]
+				}
+			}
+		}
+	}
+	message := t.keyboardMsg
+	t.mu.Unlock()
+	if found == nil {
+		return fmt.Errorf("%w: %q", ErrNoButton, text)
+	}
+
+	t.push(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      fmt.Sprintf("cb%d", time.Now().UnixNano()),
+		From:    &t.user,
+		Message: &message,
+		Data:    *found.CallbackData,
+	}})
+	return nil
+}
+
+// Run читает строки из in как сообщения пользователя, пока in не закончится, после чего
+// закрывает поток обновлений. Строка "!текст" нажимает кнопку с этим текстом. Перед каждой
+// строкой Run ждет, пока бот ответит на предыдущую, поэтому сценарий можно подать из файла
+func (t *Transport) Run(in io.Reader) error {
+	defer t.Close()
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		t.waitIdle()
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, pressPrefix):
+			if err := t.Press(strings.TrimPrefix(line, pressPrefix)); err != nil {
+				fmt.Fprintf(t.output(), "⚠️ %v\n", err)
+			}
+		default:
+			t.SendText(line)
+		}
+	}
+	return scanner.Err()
+}
+
+// Close закрывает поток обновлений: Bot.Start обработает полученные и завершится
+func (t *Transport) Close() {
+	t.closeOnce.Do(func() { close(t.updates) })
+}
+
+// Sent возвращает все запросы бота по порядку
+func (t *Transport) Sent() []Sent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Sent(nil), t.sent...)
+}
+
+// Texts возвращает тексты отправленных и измененных сообщений по порядку
+func (t *Transport) Texts() []string {
+	var texts []string
+	for _, s := range t.Sent() {
+		if s.Text != "" && s.Method != "answerCallbackQuery" {
+			texts = append(texts, s.Text)
+		}
+	}
+	return texts
+}
+
+func (t *Transport) push(update tgbotapi.Update) {
+	t.mu.Lock()
+	t.updateID++
+	update.UpdateID = t.updateID
+	t.active = time.Now()
+	t.mu.Unlock()
+	t.updates <- update
+}
+
+// waitIdle ждет, пока бот разберет очередь обновлений и перестанет отправлять сообщения
+func (t *Transport) waitIdle() {
+	for {
+		t.mu.Lock()
+		idle := len(t.updates) == 0 && time.Since(t.active) >= idleAfter
+		t.mu.Unlock()
+		if idle {
+			return
+		}
+		time.Sleep(idleAfter / 4)
+	}
+}
+
+func (t *Transport) output() io.Writer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.out
+}
+
+// Request записывает запрос бота, печатает его и отвечает, как ответил бы Telegram
+func (t *Transport) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	sent, files := describe(c)
+	sent.Config = c
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, file := range files {
+		if err := t.saveFile(file); err != nil {
+			return nil, err
+		}
+	}
+	t.sent = append(t.sent, sent)
+	t.active = time.Now()
+	t.print(sent)
+
+	var result any = true
+	switch c.(type) {
+	case tgbotapi.MessageConfig, tgbotapi.PhotoConfig, tgbotapi.DocumentConfig,
+		tgbotapi.EditMessageTextConfig, tgbotapi.EditMessageReplyMarkupConfig:
+		t.messageID++
+		message := tgbotapi.Message{
+			MessageID: t.messageID,
+			From:      &tgbotapi.User{ID: BotID, IsBot: true, UserName: BotUsername},
+			Chat:      &tgbotapi.Chat{ID: sent.ChatID, Type: "private"},
+			Date:      int(time.Now().Unix()),
+			Text:      sent.Text,
+		}
+		if edit, ok := c.(tgbotapi.EditMessageTextConfig); ok {
+			message.MessageID = edit.MessageID
+		}
+		if edit, ok := c.(tgbotapi.EditMessageReplyMarkupConfig); ok {
+			message.MessageID = edit.MessageID
+		}
+		if sent.Buttons != nil {
+			t.keyboard = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: sent.Buttons}
+			t.keyboardMsg = message
+		}
+		result = message
+	case tgbotapi.MediaGroupConfig:
+		var messages []tgbotapi.Message
+		for range files {
+			t.messageID++
+			messages = append(messages, tgbotapi.Message{
+				MessageID: t.messageID,
+				Chat:      &tgbotapi.Chat{ID: sent.ChatID, Type: "private"},
+			})
+		}
+		result = messages
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return &tgbotapi.APIResponse{Ok: true, Result: data}, nil
+}
+
+// MakeRequest записывает запрос вроде setWebhook и отвечает успехом
+func (t *Transport) MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sent = append(t.sent, Sent{Method: endpoint})
+	return &tgbotapi.APIResponse{Ok: true, Result: json.RawMessage("true")}, nil
+}
+
+// UploadFiles записывает запрос с файлами и отвечает успехом
+func (t *Transport) UploadFiles(endpoint string, params tgbotapi.Params, files []tgbotapi.RequestFile) (*tgbotapi.APIResponse, error) {
+	return t.MakeRequest(endpoint, params)
+}
+
+// GetMe возвращает фиктивного бота
+func (t *Transport) GetMe() (tgbotapi.User, error) {
+	return tgbotapi.User{ID: BotID, IsBot: true, UserName: BotUsername}, nil
+}
+
+// GetFileDirectURL не поддерживается: пользователь консоли не присылает файлов
+func (t *Transport) GetFileDirectURL(fileID string) (string, error) {
+	return "", errors.New("files are not supported by the fake transport")
+}
+
+// GetWebhookInfo сообщает, что webhook не задан
+func (t *Transport) GetWebhookInfo() (tgbotapi.WebhookInfo, error) {
+	return tgbotapi.WebhookInfo{}, nil
+}
+
+// GetUpdatesChan возвращает поток сообщений пользователя
+func (t *Transport) GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	return t.updates
+}
+
+// GetUpdates ничего не возвращает: обновления приходят через GetUpdatesChan
+func (t *Transport) GetUpdates(config tgbotapi.UpdateConfig) ([]tgbotapi.Update, error) {
+	return nil, nil
+}
+
+// StopReceivingUpdates закрывает поток обновлений
+func (t *Transport) StopReceivingUpdates() {
+	t.Close()
+}
+
+// describe извлекает из запроса получателя, текст, кнопки и файлы
+func describe(c tgbotapi.Chattable) (Sent, []tgbotapi.FileBytes) {
+	var files []tgbotapi.FileBytes
+	addFile := func(data tgbotapi.RequestFileData) string {
+		if file, ok := data.(tgbotapi.FileBytes); ok {
+			files = append(files, file)
+			return file.Name
+		}
+		return fmt.Sprint(data)
+	}
+
+	switch v := c.(type) {
+	case tgbotapi.MessageConfig:
+		return Sent{Method: "sendMessage", ChatID: v.ChatID, Text: v.Text, Buttons: inlineButtons(v.ReplyMarkup)}, nil
+	case tgbotapi.PhotoConfig:
+		sent := Sent{Method: "sendPhoto", ChatID: v.ChatID, Text: v.Caption, Buttons: inlineButtons(v.ReplyMarkup)}
+		sent.File = addFile(v.File)
+		return sent, files
+	case tgbotapi.DocumentConfig:
+		sent := Sent{Method: "sendDocument", ChatID: v.ChatID, Text: v.Caption, Buttons: inlineButtons(v.ReplyMarkup)}
+		sent.File = addFile(v.File)
+		return sent, files
+	case tgbotapi.MediaGroupConfig:
+		sent := Sent{Method: "sendMediaGroup", ChatID: v.ChatID}
+		var names, captions []string
+		for _, media := range v.Media {
+			var base tgbotapi.BaseInputMedia
+			switch m := media.(type) {
+			case tgbotapi.InputMediaPhoto:
+				base = m.BaseInputMedia
+			case tgbotapi.InputMediaDocument:
+				base = m.BaseInputMedia
+			default:
+				continue
+			}
+			names = append(names, addFile(base.Media))
+			if base.Caption != "" {
+				captions = append(captions, base.Caption)
+			}
+		}
+		sent.File = strings.Join(names, ", ")
+		sent.Text = strings.Join(captions, "\n\n")
+		return sent, files
+	case tgbotapi.EditMessageTextConfig:
+		return Sent{Method: "editMessageText", ChatID: v.ChatID, Text: v.Text, Buttons: inlineButtons(v.ReplyMarkup)}, nil
+	case tgbotapi.EditMessageReplyMarkupConfig:
+		return Sent{Method: "editMessageReplyMarkup", ChatID: v.ChatID, Buttons: inlineButtons(v.ReplyMarkup)}, nil
+	case tgbotapi.DeleteMessageConfig:
+		return Sent{Method: "deleteMessage", ChatID: v.ChatID}, nil
+	case tgbotapi.CallbackConfig:
+		return Sent{Method: "answerCallbackQuery", Text: v.Text}, nil
+	}
+	return Sent{Method: fmt.Sprintf("%T", c)}, nil
+}
+
+// inlineButtons возвращает inline-кнопки разметки сообщения, nil - если их нет
+func inlineButtons(markup any) [][]tgbotapi.InlineKeyboardButton {
+	switch m := markup.(type) {
+	case tgbotapi.InlineKeyboardMarkup:
+		return m.InlineKeyboard
+	case *tgbotapi.InlineKeyboardMarkup:
+		if m != nil {
+			return m.InlineKeyboard
+		}
+	}
+	return nil
+}
+
+// saveFile сохраняет отправленный файл в каталог SaveFilesTo
+func (t *Transport) saveFile(file tgbotapi.FileBytes) error {
+	if t.filesDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(t.filesDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create files dir: %w", err)
+	}
+	return os.WriteFile(filepath.Join(t.filesDir, filepath.Base(file.Name)), file.Bytes, 0o644)
+}
+
+// print выводит сообщение бота в консоль. Служебные запросы не печатаются
+func (t *Transport) print(sent Sent) {
+	var prefix string
+	switch sent.Method {
+	case "sendMessage", "sendPhoto", "sendDocument", "sendMediaGroup":
+		prefix = "🤖"
+	case "editMessageText", "editMessageReplyMarkup":
+		prefix = "✏️"
+	case "answerCallbackQuery":
+		if sent.Text != "" {
+			fmt.Fprintf(t.out, "💬 %s\n\n", sent.Text)
+		}
+		return
+	default:
+		return
+	}
+
+	switch {
+	case sent.Text != "":
+		fmt.Fprintf(t.out, "%s %s\n", prefix, strings.ReplaceAll(sent.Text, "\n", "\n   "))
+	case sent.File == "":
+		fmt.Fprintf(t.out, "%s (кнопки)\n", prefix)
+	}
+	if sent.File != "" {
+		fmt.Fprintf(t.out, "📎 %s\n", sent.File)
+	}
+	for _, row := range sent.Buttons {
+		var labels []string
+		for _, button := range row {
+			labels = append(labels, "["+button.Text+"]")
+		}
+		fmt.Fprintf(t.out, "   %s\n", strings.Join(labels, " "))
+	}
+	fmt.Fprintln(t.out)
+}
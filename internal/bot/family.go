@@ -0,0 +1,185 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// joinPrefix - префикс параметра /start в ссылке-приглашении
+const joinPrefix = "join_"
+
+// botUsername возвращает имя бота для ссылок. В webhook-режиме оно запрашивается при первом обращении
+func (b *Bot) botUsername() (string, error) {
+	if b.username == "" {
+		self, err := b.api.GetMe()
+		if err != nil {
+			return "", fmt.Errorf("failed to get bot info: %w", err)
+		}
+		b.username = self.UserName
+	}
+	return b.username, nil
+}
+
+// displayName возвращает имя пользователя для отчетов общего бюджета
+func displayName(user *tgbotapi.User) string {
+	if user.FirstName != "" {
+		return user.FirstName
+	}
+	if user.UserName != "" {
+		return "@" + user.UserName
+	}
+	return strconv.FormatInt(user.ID, 10)
+}
+
+// handleFamily показывает участников общего бюджета
+func (b *Bot) handleFamily(ctx context.Context, message *tgbotapi.Message) {
+	members, err := b.service.GetLedgerMembers(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить участников бюджета")
+		return
+	}
+
+	text := "👨‍👩‍👧 *Общий бюджет*\n\n"
+	isOwner := true
+	if len(members) < 2 {
+		text += "Пригласите близких, чтобы вести общий учет: все участники видят и добавляют " +
+			"транзакции в один бюджет, а в отчетах виден вклад каждого\n"
+	} else {
+		for _, m := range members {
+			role := ""
+			if m.IsOwner() {
+				role = " (владелец)"
+			}
+			text += fmt.Sprintf("• %s%s\n", m.Name, role)
+			if m.MemberID == message.From.ID {
+				isOwner = m.IsOwner()
+			}
+		}
+	}
+
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	if isOwner {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton("➕ Пригласить", cbFamily, "invite"),
+		))
+		for _, m := range members {
+			if m.IsOwner() {
+				continue
+			}
+			buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+				callbackButton("🗑 "+m.Name, cbFamily, "remove", m.MemberID),
+			))
+		}
+	} else {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton("🚪 Выйти из общего бюджета", cbFamily, "leave"),
+		))
+	}
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+		callbackButton("« Назад", cbMenu),
+	))
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	b.api.Send(msg)
+}
+
+// handleFamilyCallback обрабатывает приглашение, выход и исключение участников
+func (b *Bot) handleFamilyCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	chatID := callback.Message.Chat.ID
+
+	switch args.String(0) {
+	case "invite":
+		code, err := b.service.CreateLedgerInvite(ctx, callback.From.ID, displayName(callback.From))
+		if errors.Is(err, service.ErrAlreadyInLedger) {
+			b.sendErrorMessage(chatID, "Вы участвуете в чужом общем бюджете. Приглашать может только владелец")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error creating invite: %w", err)
+		}
+		name, err := b.botUsername()
+		if err != nil {
+			return err
+		}
+		link := fmt.Sprintf("https://t.me/%s?start=%s%s", name, joinPrefix, code)
+		b.api.Send(tgbotapi.NewMessage(chatID,
+			"Отправьте эту ссылку тому, кого хотите пригласить. Ссылка одноразовая:\n\n"+link))
+
+	case "leave":
+		if err := b.service.LeaveLedger(ctx, callback.From.ID); err != nil {
+			b.sendErrorMessage(chatID, "Вы не участвуете в общем бюджете")
+			return nil
+		}
+		msg := tgbotapi.NewMessage(chatID, "Вы вышли из общего бюджета. Снова доступны ваши личные записи")
+		msg.ReplyMarkup = b.getMainKeyboard()
+		b.api.Send(msg)
+
+	case "remove":
+		memberID, err := args.Int64(1)
+		if err != nil {
+			return fmt.Errorf("invalid member id: %w", err)
+		}
+		if err := b.service.RemoveLedgerMember(ctx, callback.From.ID, memberID); err != nil {
+			b.sendErrorMessage(chatID, "Участник не найден")
+			return nil
+		}
+		b.api.Send(tgbotapi.NewMessage(memberID, "Вас исключили из общего бюджета"))
+		b.handleFamily(ctx, &tgbotapi.Message{
+			From: callback.From,
+			Chat: callback.Message.Chat,
+		})
+	}
+	return nil
+}
+
+// handleJoin принимает приглашение из ссылки /start join_<code>
+func (b *Bot) handleJoin(ctx context.Context, message *tgbotapi.Message, code string) {
+	owner, err := b.service.JoinLedger(ctx, code, message.From.ID, displayName(message.From))
+	switch {
+	case errors.Is(err, service.ErrInviteNotFound):
+		b.sendErrorMessage(message.Chat.ID, "Приглашение не найдено или уже использовано")
+		return
+	case errors.Is(err, service.ErrOwnInvite):
+		b.sendErrorMessage(message.Chat.ID, "Это ваше собственное приглашение - отправьте его тому, кого хотите пригласить")
+		return
+	case errors.Is(err, service.ErrAlreadyInLedger):
+		b.sendErrorMessage(message.Chat.ID, "Вы уже участвуете в общем бюджете. Сначала выйдите из него: /family")
+		return
+	case errors.Is(err, service.ErrLedgerHasMembers):
+		b.sendErrorMessage(message.Chat.ID, "У вас есть свой общий бюджет с участниками. Сначала исключите их: /family")
+		return
+	case err != nil:
+		log.Printf("Error joining ledger: %v", err)
+		b.sendErrorMessage(message.Chat.ID, "Не удалось присоединиться к общему бюджету")
+		return
+	}
+
+	text := "Вы присоединились к общему бюджету! 👨‍👩‍👧\nТеперь вы видите и добавляете общие транзакции"
+	if owner.Name != "" {
+		text = fmt.Sprintf("Вы присоединились к общему бюджету %s! 👨‍👩‍👧\nТеперь вы видите и добавляете общие транзакции", owner.Name)
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+
+	// Уведомляем владельца бюджета
+	b.api.Send(tgbotapi.NewMessage(owner.OwnerID,
+		fmt.Sprintf("%s присоединился к вашему общему бюджету 👋", displayName(message.From))))
+}
+
+// formatMemberStats формирует раздел отчета о вкладе участников
+func formatMemberStats(members []service.MemberStats) string {
+	text := ""
+	for _, m := range members {
+		text += fmt.Sprintf("👤 %s: 💸 %.2f₽ / 💰 %.2f₽\n", m.Name, m.Expenses, m.Income)
+	}
+	return text
+}
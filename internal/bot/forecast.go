@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// forecastShownPayments - сколько ближайших регулярных платежей перечисляется в прогнозе
+const forecastShownPayments = 5
+
+// handleForecast показывает прогноз остатка на 30 дней и график, на котором видно,
+// хватит ли денег до следующего поступления
+func (b *Bot) handleForecast(ctx context.Context, message *tgbotapi.Message) {
+	forecast, err := b.service.GetCashflowForecast(ctx, message.From.ID)
+	if err != nil {
+		log.Printf("Error getting cashflow forecast: %v", err)
+		b.sendErrorMessage(message.Chat.ID, "Не удалось построить прогноз")
+		return
+	}
+
+	end := forecast.End()
+	text := "🔮 *Прогноз остатка на 30 дней*\n\n"
+	text += fmt.Sprintf("💰 Сейчас: %.0f₽\n", forecast.Balance)
+	text += fmt.Sprintf("📅 К %s: %.0f₽\n", end.Date.Format("02.01"), end.Balance)
+	text += fmt.Sprintf("🛒 Обычные траты: ~%.0f₽ в день\n", forecast.DailySpend)
+
+	if forecast.Lowest.Balance < 0 {
+		text += fmt.Sprintf("\n⚠️ Денег может не хватить: к %s остаток опустится до %.0f₽\n",
+			forecast.Lowest.Date.Format("02.01"), forecast.Lowest.Balance)
+	} else if forecast.Lowest.Balance < forecast.Balance {
+		text += fmt.Sprintf("\n📉 Минимум %.0f₽ - %s\n", forecast.Lowest.Balance, forecast.Lowest.Date.Format("02.01"))
+	}
+
+	if len(forecast.Recurring) > 0 {
+		text += "\n*Регулярные платежи:*\n"
+		for i, p := range forecast.Recurring {
+			if i == forecastShownPayments {
+				text += fmt.Sprintf("...и еще %d\n", len(forecast.Recurring)-i)
+				break
+			}
+			name := p.Description
+			if name == "" {
+				name = "Без описания"
+			}
+			mark := ""
+			if p.Subscription {
+				mark = " 🔁"
+			}
+			text += fmt.Sprintf("• %s %s%s: %+.0f₽\n", p.NextDate.Format("02.01"), name, mark, p.Amount)
+		}
+	} else {
+		text += "\nРегулярных платежей пока не найдено: они появятся, когда зарплата, аренда или подписки повторятся хотя бы дважды"
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		callbackButton("📊 Графики", cbCharts),
+		callbackButton("« В меню", cbMenu),
+	))
+	b.api.Send(msg)
+
+	delivery := b.chartDelivery(ctx, message.From.ID)
+	chartData, err := delivery.charts.GenerateCashflowForecastChart(forecast)
+	if err != nil {
+		log.Printf("Error generating forecast chart: %v", err)
+		return
+	}
+	b.api.Send(delivery.message(message.Chat.ID, "forecast", chartData))
+}
+
+// handleForecastCallback показывает прогноз по кнопке из меню отчетов
+func (b *Bot) handleForecastCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, _ callbackArgs) error {
+	b.handleForecast(ctx, callbackMessage(callback))
+	return nil
+}
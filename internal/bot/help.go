@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"context"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// helpText - обзор возможностей бота по команде /help
+const helpText = "*Справка* ℹ️\n\n" +
+	"*Учет*\n" +
+	"/add - добавить доход или расход\n" +
+	"`/add 500 такси`, `/add +50000 зарплата` - записать сразу, категория подберется сама\n" +
+	"`/add 25$ такси`, `/add 25 USD такси` - покупка в валюте, пересчитается по курсу ЦБ\n" +
+	"`5000 Ашан: 3000 продукты, 2000 хозтовары` - разделить покупку по категориям\n" +
+	"Фото QR-кода чека - записать покупку из чека\n" +
+	"/categories - категории доходов и расходов\n" +
+	"/balance - счета и остатки\n" +
+	"/rate - курсы ваших валют, `/rate 100 USD` - пересчитать сумму\n\n" +
+	"*Отчеты и планирование*\n" +
+	"/report - отчеты и графики за период\n" +
+	"/today, /week, /month, /year - отчет за день, неделю, месяц или год сразу\n" +
+	"/forecast - прогноз остатка на 30 дней с регулярными платежами\n" +
+	"/compare - сравнение двух любых периодов\n" +
+	"/income - стабильность дохода и доли источников\n" +
+	"/year\\_review - итоги года: лучшие и худшие месяцы, главные категории и графики\n" +
+	"/budgets - бюджеты категорий\n" +
+	"/plan - крупные траты, запланированные на следующий месяц: «/plan 30000 ремонт машины»\n" +
+	"/limit - лимиты трат по категориям с предупреждением сразу при записи\n" +
+	"/goal - цели накоплений\n" +
+	"/wishlist - список желаний: сколько отложено на покупки и когда их можно будет купить\n" +
+	"/advice - рекомендации по экономии\n" +
+	"/networth - капитал: имущество, инвестиции и долги\n" +
+	"/remind - свои напоминания: «/remind каждый день в 21:00 записать траты»\n\n" +
+	"*Данные*\n" +
+	"/export - выгрузка в CSV и Excel, отчет за месяц в PDF\n" +
+	"CSV-выписка из банка - сверка с записями бота\n" +
+	"Файл OFX или QIF из банка - импорт операций с выбором категорий\n" +
+	"/duplicates - найти и объединить записанные дважды операции\n" +
+	"/trash - корзина: удаленные транзакции и категории можно вернуть в течение 30 дней\n" +
+	"/integrations - webhook'и для умного дома и своих дашбордов\n" +
+	"/export\\_all - архив со всеми вашими данными, /delete\\_me - удалить их из бота\n" +
+	"/pin - защита финансовых данных PIN-кодом\n\n" +
+	"*Прочее*\n" +
+	"/family - общий бюджет с близкими\n" +
+	"/ledgers - отдельные книги учета: личная, бизнес, поездка\n" +
+	"/quarter - квартальный отчет бизнес-книги: контрагенты, НДС и налог самозанятого\n" +
+	"/event - событие вроде отпуска или ремонта: все его траты в одном отчете\n" +
+	"/paid 3200 Бензин - записать платеж за всех в общем событии\n" +
+	"/settings - отчеты и напоминания\n" +
+	"/report\\_settings - разделы отчета и их порядок\n" +
+	"/whatsnew - что нового в боте\n" +
+	"/cancel - прервать текущее действие"
+
+// cancelKeyboard - кнопка выхода из многошагового сценария
+var cancelKeyboard = tgbotapi.NewInlineKeyboardMarkup(
+	tgbotapi.NewInlineKeyboardRow(
+		callbackButton("✖️ Отмена", cbCancel),
+	),
+)
+
+func (b *Bot) handleHelp(message *tgbotapi.Message) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, helpText)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+}
+
+// handleCancel прерывает текущий сценарий и возвращает в главное меню
+func (b *Bot) handleCancel(ctx context.Context, message *tgbotapi.Message) {
+	text := "Действие отменено"
+	if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+		log.Printf("Error deleting user state: %v", err)
+		text = "Не удалось отменить действие, попробуйте еще раз"
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text+"\n\n*Главное меню*\nВыберите нужное действие 👇")
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+}
@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleIncome показывает, насколько стабилен доход: доли источников, их регулярность
+// и разброс по месяцам с графиком
+func (b *Bot) handleIncome(ctx context.Context, message *tgbotapi.Message) {
+	stability, err := b.service.GetIncomeStability(ctx, message.From.ID)
+	if err != nil {
+		log.Printf("Error getting income stability: %v", err)
+		b.sendErrorMessage(message.Chat.ID, "Не удалось проанализировать доходы")
+		return
+	}
+
+	text := "💼 *Стабильность дохода*\n\n"
+	if len(stability.Sources) == 0 {
+		text += fmt.Sprintf("За последние %d месяцев доходов не записано", len(stability.Months))
+		msg := tgbotapi.NewMessage(message.Chat.ID, text)
+		msg.ParseMode = "Markdown"
+		b.api.Send(msg)
+		return
+	}
+
+	text += fmt.Sprintf("За %d месяцев: в среднем %.0f₽ в месяц\n", len(stability.Months), stability.Average)
+	text += fmt.Sprintf("Разброс по месяцам: %.0f%%\n\n", stability.Volatility*100)
+	for _, source := range stability.Sources {
+		regularity := "регулярно"
+		if !source.Regular() {
+			regularity = "нерегулярно"
+		}
+		text += fmt.Sprintf("%s %s: %.0f%% (%d из %d мес., %s)\n",
+			source.Emoji, source.Name, source.Share*100, source.Months, len(source.Monthly), regularity)
+	}
+	if len(stability.Sources) == 1 {
+		text += "\nВесь доход приходит из одного источника"
+	}
+	for _, warning := range stability.Warnings {
+		text += "\n⚠️ " + warning
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = "Markdown"
+	b.api.Send(msg)
+
+	delivery := b.chartDelivery(ctx, message.From.ID)
+	chartData, err := delivery.charts.GenerateIncomeStabilityChart(stability)
+	if err != nil {
+		log.Printf("Error generating income chart: %v", err)
+		return
+	}
+	b.api.Send(delivery.message(message.Chat.ID, "income", chartData))
+}
+
+// handleIncomeCallback показывает анализ доходов по кнопке из меню отчетов
+func (b *Bot) handleIncomeCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, _ callbackArgs) error {
+	b.handleIncome(ctx, callbackMessage(callback))
+	return nil
+}
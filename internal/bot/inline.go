@@ -0,0 +1,63 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// inlineHintText - подсказка в списке результатов, если запрос не похож на транзакцию
+const inlineHintText = "Введите сумму и описание: 300 кофе"
+
+// handleInlineQuery предлагает записать транзакцию из любого чата: @bot 300 кофе.
+// Сама запись происходит при выборе результата (handleChosenInlineResult)
+func (b *Bot) handleInlineQuery(query *tgbotapi.InlineQuery) error {
+	config := tgbotapi.InlineConfig{
+		InlineQueryID: query.ID,
+		IsPersonal:    true,
+		CacheTime:     0,
+	}
+
+	amount, currency, description, err := service.ParseQuickAdd(query.Query)
+	if err != nil {
+		config.Results = []interface{}{}
+		config.SwitchPMText = inlineHintText
+		config.SwitchPMParameter = "inline"
+	} else {
+		kind, emoji := "Расход", "💸"
+		if amount > 0 {
+			kind, emoji = "Доход", "💰"
+		}
+		title := fmt.Sprintf("%s %s %s", emoji, kind, formatMoney(math.Abs(amount), currency))
+		text := fmt.Sprintf("%s %s", emoji, formatMoney(math.Abs(amount), currency))
+		if description != "" {
+			title += " - " + description
+			text += " - " + description
+		}
+
+		article := tgbotapi.NewInlineQueryResultArticle("quickadd", title, text)
+		article.Description = "Записать, категория подберется автоматически"
+		config.Results = []interface{}{article}
+	}
+
+	if _, err := b.api.Request(config); err != nil {
+		return fmt.Errorf("error answering inline query: %w", err)
+	}
+	return nil
+}
+
+// handleChosenInlineResult записывает транзакцию, выбранную в inline-режиме,
+// и присылает подтверждение в личный чат с ботом
+func (b *Bot) handleChosenInlineResult(ctx context.Context, result *tgbotapi.ChosenInlineResult) error {
+	amount, currency, description, err := service.ParseQuickAdd(result.Query)
+	if err != nil {
+		log.Printf("Error parsing chosen inline result %q: %v", result.Query, err)
+		return nil
+	}
+	b.quickAdd(ctx, result.From.ID, result.From.ID, amount, currency, description)
+	return nil
+}
@@ -0,0 +1,113 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+	"github.com/ivanoskov/financial_bot/internal/webhook"
+)
+
+// handleIntegrations показывает исходящие webhook'и пользователя
+func (b *Bot) handleIntegrations(ctx context.Context, message *tgbotapi.Message) {
+	webhooks, err := b.service.GetWebhooks(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить интеграции")
+		return
+	}
+
+	text := "🔌 *Интеграции*\n\n" +
+		"Бот отправит POST-запрос с JSON на ваш URL при добавлении и удалении транзакций. " +
+		"Тело подписано HMAC-SHA256, подпись в заголовке `" + webhook.SignatureHeader + "`.\n\n"
+	if len(webhooks) == 0 {
+		text += "Webhook'ов пока нет"
+	}
+
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	for _, w := range webhooks {
+		text += "• `" + w.URL + "`\n"
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton("🧪 Проверить", cbWebhook, "test", w.ID),
+			callbackButton("🗑 Удалить", cbWebhook, "del", w.ID),
+		))
+	}
+	buttons = append(buttons,
+		tgbotapi.NewInlineKeyboardRow(callbackButton("➕ Добавить webhook", cbWebhook, "add")),
+		tgbotapi.NewInlineKeyboardRow(callbackButton("« Назад", cbMenu)),
+	)
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	b.api.Send(msg)
+}
+
+// handleIntegrationsCallback обрабатывает добавление, проверку и удаление webhook'ов
+func (b *Bot) handleIntegrationsCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	chatID := callback.Message.Chat.ID
+	message := &tgbotapi.Message{From: callback.From, Chat: callback.Message.Chat}
+
+	switch args.String(0) {
+	case "add":
+		state := &model.UserState{
+			UserID:         callback.From.ID,
+			AwaitingAction: model.StateWebhookURL,
+		}
+		if err := b.saveUserState(ctx, state); err != nil {
+			return fmt.Errorf("error saving user state: %w", err)
+		}
+		msg := tgbotapi.NewMessage(chatID, "Отправьте URL, начинающийся с https://")
+		msg.ReplyMarkup = cancelKeyboard
+		b.api.Send(msg)
+
+	case "test":
+		webhookID := args.String(1)
+		if err := b.service.PingWebhook(ctx, webhookID, callback.From.ID); err != nil {
+			b.sendErrorMessage(chatID, fmt.Sprintf("Тестовое событие не доставлено: %v", err))
+			return nil
+		}
+		b.api.Send(tgbotapi.NewMessage(chatID, "Тестовое событие доставлено ✅"))
+
+	case "del":
+		webhookID := args.String(1)
+		if err := b.service.DeleteWebhook(ctx, webhookID, callback.From.ID); err != nil {
+			return fmt.Errorf("error deleting webhook: %w", err)
+		}
+		b.handleIntegrations(ctx, message)
+	}
+	return nil
+}
+
+// createWebhookFromMessage регистрирует webhook по введенному URL и показывает ключ подписи
+func (b *Bot) createWebhookFromMessage(ctx context.Context, message *tgbotapi.Message) error {
+
+	w, err := b.service.RegisterWebhook(ctx, message.From.ID, strings.TrimSpace(message.Text))
+	if errors.Is(err, service.ErrInvalidWebhookURL) {
+		// Оставляем состояние, чтобы пользователь мог исправить URL
+		b.sendErrorMessage(message.Chat.ID, "Нужен полный URL, начинающийся с https://")
+		return nil
+	}
+
+	if delErr := b.deleteUserState(ctx, message.From.ID); delErr != nil {
+		return fmt.Errorf("error deleting user state: %w", delErr)
+	}
+	if errors.Is(err, service.ErrTooManyWebhooks) {
+		b.sendErrorMessage(message.Chat.ID, "Достигнут лимит webhook'ов, удалите один из существующих")
+		return nil
+	}
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось сохранить webhook")
+		return fmt.Errorf("error registering webhook: %w", err)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, "Webhook добавлен ✅\n\n"+
+		"Ключ подписи (сохраните его, он показывается один раз):\n`"+w.Secret+"`")
+	msg.ParseMode = "Markdown"
+	b.api.Send(msg)
+	b.handleIntegrations(ctx, message)
+	return nil
+}
@@ -1,88 +1,188 @@
 package bot
 
 import (
+	"fmt"
+	"math"
+	"strconv"
+
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
 )
 
 func (b *Bot) getMainKeyboard() tgbotapi.InlineKeyboardMarkup {
-	return tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("💰 Добавить доход", "action_add_income"),
-			tgbotapi.NewInlineKeyboardButtonData("💸 Добавить расход", "action_add_expense"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📊 Отчёты", "action_report"),
-			tgbotapi.NewInlineKeyboardButtonData("📋 Категории", "action_categories"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🗑 История транзакций", "action_transactions"),
-		),
-	)
+	return mainKeyboard
 }
 
-// Клавиатура для управления категориями (с кнопками удаления)
+// mainKeyboard собирается один раз при загрузке пакета: главное меню отправляется почти в каждом ответе
+var mainKeyboard = tgbotapi.NewInlineKeyboardMarkup(
+	tgbotapi.NewInlineKeyboardRow(
+		callbackButton("💰 Добавить доход", cbAdd, "income"),
+		callbackButton("💸 Добавить расход", cbAdd, "expense"),
+	),
+	tgbotapi.NewInlineKeyboardRow(
+		callbackButton("📊 Отчёты", cbReports),
+		callbackButton("📋 Категории", cbCategories),
+	),
+	tgbotapi.NewInlineKeyboardRow(
+		callbackButton("💳 Счета", cbBalance),
+		callbackButton("🗑 История транзакций", cbTransactions),
+	),
+	tgbotapi.NewInlineKeyboardRow(
+		callbackButton("📒 Книги учета", cbLedger),
+	),
+)
+
+// Клавиатура для управления категориями (с кнопками архивации)
 func (b *Bot) getCategoriesKeyboard(categories []model.Category) tgbotapi.InlineKeyboardMarkup {
 	var buttons [][]tgbotapi.InlineKeyboardButton
+	active := model.ActiveCategories(categories)
 	
-	for _, category := range categories {
-		emoji := "💸"
-		if category.Type == "income" {
-			emoji = "💰"
-		}
-		// Добавляем кнопку выбора категории и кнопку удаления в одном ряду
+	for _, category := range model.TopLevelCategories(active) {
+		// Добавляем кнопки выбора, подкатегорий, оформления и архивации категории в одном ряду
 		buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
-			tgbotapi.NewInlineKeyboardButtonData(
-				emoji + " " + category.Name,
-				"category_" + category.ID,
+			callbackButton(
+				category.Icon() + " " + category.Name,
+				cbCategory, category.ID,
+			),
+			callbackButton(
+				"📂",
+				cbCategories, category.ID,
 			),
-			tgbotapi.NewInlineKeyboardButtonData(
-				"🗑",
-				"delete_category_" + category.ID,
+			callbackButton(
+				"🎨",
+				cbCategoryStyle, category.ID,
+			),
+			callbackButton(
+				"🗄",
+				cbCategoryArchive, "add", category.ID,
 			),
 		})
 	}
 
 	// Добавляем кнопки управления категориями
 	buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("➕ Доход", "add_income_category"),
-		tgbotapi.NewInlineKeyboardButtonData("➕ Расход", "add_expense_category"),
+		callbackButton("➕ Доход", cbNewCategory, "income"),
+		callbackButton("➕ Расход", cbNewCategory, "expense"),
 	})
+	// Порядок категорий и архив
+	manage := []tgbotapi.InlineKeyboardButton{
+		callbackButton("↕️ Порядок", cbCategoryOrder, "expense"),
+	}
+	if len(active) < len(categories) {
+		manage = append(manage, callbackButton("🗄 Архив", cbCategoryArchive))
+	}
+	buttons = append(buttons, manage)
 
 	// Добавляем кнопку "Назад"
 	buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("« Назад", "action_back"),
+		callbackButton("« Назад", cbMenu),
 	})
 	
 	return tgbotapi.NewInlineKeyboardMarkup(buttons...)
 }
 
-// Клавиатура для выбора категории при добавлении транзакции (без кнопок удаления)
+// Клавиатура для выбора категории при добавлении транзакции (без кнопок удаления).
+// Категории из архива не показываются
 func (b *Bot) getSelectCategoryKeyboard(categories []model.Category) tgbotapi.InlineKeyboardMarkup {
 	var buttons [][]tgbotapi.InlineKeyboardButton
+	categories = model.ActiveCategories(categories)
 	
-	for _, category := range categories {
-		emoji := "💸"
-		if category.Type == "income" {
-			emoji = "💰"
+	for _, category := range model.TopLevelCategories(categories) {
+		// Категория с подкатегориями открывает их список
+		if len(model.Subcategories(categories, category.ID)) > 0 {
+			buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
+				callbackButton(
+					category.Icon() + " " + category.Name + " ›",
+					cbSubcategory, category.ID,
+				),
+			})
+			continue
 		}
 		buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
-			tgbotapi.NewInlineKeyboardButtonData(
-				emoji + " " + category.Name,
-				"category_" + category.ID,
+			callbackButton(
+				category.Icon() + " " + category.Name,
+				cbCategory, category.ID,
 			),
 		})
 	}
 
 	// Добавляем кнопки управления
 	buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("⚙️ Управление категориями", "action_categories"),
+		callbackButton("⚙️ Управление категориями", cbCategories),
 	})
 
 	// Добавляем кнопку "Назад"
 	buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("« Назад", "action_back"),
+		callbackButton("« Назад", cbMenu),
 	})
 	
 	return tgbotapi.NewInlineKeyboardMarkup(buttons...)
-}
\ No newline at end of file
+}
+
+// Клавиатура ввода транзакции: частые суммы для выбранной категории и выбор счета
+func (b *Bot) getTransactionInputKeyboard(categoryID string, amounts []float64, accounts []model.Account) tgbotapi.InlineKeyboardMarkup {
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+
+	for _, amount := range amounts {
+		row = append(row, callbackButton(
+			fmt.Sprintf("%s₽", strconv.FormatFloat(amount, 'f', -1, 64)),
+			cbQuickAmount, categoryID, amount,
+		))
+		// По три суммы в ряд
+		if len(row) == 3 {
+			buttons = append(buttons, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		buttons = append(buttons, row)
+	}
+
+	// Выбор счета имеет смысл, только если счетов несколько
+	if len(accounts) > 1 {
+		row = nil
+		for _, account := range accounts {
+			row = append(row, callbackButton(
+				accountEmoji(account.Type)+" "+account.Name,
+				cbAccount, account.ID,
+			))
+			if len(row) == 3 {
+				buttons = append(buttons, row)
+				row = nil
+			}
+		}
+		if len(row) > 0 {
+			buttons = append(buttons, row)
+		}
+	}
+
+	// Отмена сбрасывает выбранную категорию и возвращает в меню
+	buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
+		callbackButton("✖️ Отмена", cbCancel),
+	})
+
+	return tgbotapi.NewInlineKeyboardMarkup(buttons...)
+}
+
+// Клавиатура с кнопками принятия рекомендаций (установка предложенного бюджета)
+func (b *Bot) getAdviceKeyboard(advices []service.SavingsAdvice) tgbotapi.InlineKeyboardMarkup {
+	var buttons [][]tgbotapi.InlineKeyboardButton
+
+	for _, advice := range advices {
+		buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
+			callbackButton(
+				fmt.Sprintf("✅ %s: бюджет %.0f₽", advice.CategoryName, advice.SuggestedBudget),
+				cbAdvice, advice.CategoryID, math.Round(advice.SuggestedBudget),
+			),
+		})
+	}
+
+	// Добавляем кнопку "Назад"
+	buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
+		callbackButton("« Назад", cbMenu),
+	})
+
+	return tgbotapi.NewInlineKeyboardMarkup(buttons...)
+}
@@ -0,0 +1,230 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// defaultLedgerEmoji - значок книги, если пользователь не указал свой
+const defaultLedgerEmoji = "📒"
+
+// handleLedgers показывает книги учета с переключателем. «/ledgers Поездка» сразу
+// заводит книгу с этим названием
+func (b *Bot) handleLedgers(ctx context.Context, message *tgbotapi.Message) {
+	if name := strings.TrimSpace(message.CommandArguments()); name != "" {
+		b.createLedger(ctx, message.Chat.ID, message.From.ID, name)
+		return
+	}
+	b.sendLedgers(ctx, message.Chat.ID, message.From.ID)
+}
+
+// sendLedgers присылает список книг: текущая отмечена, остальные - кнопки переключения
+func (b *Bot) sendLedgers(ctx context.Context, chatID, userID int64) {
+	ledgers, err := b.service.GetLedgers(ctx, userID)
+	if err != nil {
+		b.sendServiceError(ctx, chatID, err, "Не удалось загрузить книги учета")
+		return
+	}
+	active, err := b.service.ActiveLedger(ctx, userID)
+	if err != nil {
+		b.sendServiceError(ctx, chatID, err, "Не удалось загрузить книги учета")
+		return
+	}
+
+	text := "📒 *Книги учета*\n\n"
+	if len(ledgers) == 0 {
+		text += "Ведите раздельный учет: личные траты, бизнес, поездка. У каждой книги свои " +
+			"категории, счета, бюджеты и отчеты\n"
+	} else {
+		text += fmt.Sprintf("Сейчас записи попадают в книгу %s\n", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, active.Title()))
+	}
+
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	mark := func(ledger model.Ledger) string {
+		if ledger.DataID == active.DataID {
+			return "✅ " + ledger.Title()
+		}
+		return ledger.Title()
+	}
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+		callbackButton(mark(service.MainLedger), cbLedger, "switch"),
+	))
+	for _, ledger := range ledgers {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton(mark(ledger), cbLedger, "switch", ledger.ID),
+			callbackButton("🗑", cbLedger, "del", ledger.ID),
+		))
+	}
+	// Бизнес-режим включается в текущей дополнительной книге
+	if active.ID != "" {
+		toggle := "🏢 Бизнес-режим: выключен"
+		if active.Business {
+			toggle = "🏢 Бизнес-режим: включен ✅"
+		}
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton(toggle, cbLedger, "biz", active.ID),
+		))
+	}
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+		callbackButton("➕ Новая книга", cbLedger, "new"),
+	))
+	if len(ledgers) > 0 {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton("🗂 Сводно за месяц", cbLedger, "all", int(service.MonthlyReport)),
+			callbackButton("🗂 Сводно за год", cbLedger, "all", int(service.YearlyReport)),
+		))
+	}
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+		callbackButton("« Назад", cbMenu),
+	))
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	b.api.Send(msg)
+}
+
+// handleLedgerCallback переключает, создает и удаляет книги и присылает сводные отчеты
+func (b *Bot) handleLedgerCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	chatID, userID := callback.Message.Chat.ID, callback.From.ID
+
+	switch args.String(0) {
+	case "switch":
+		err := b.service.SwitchLedger(ctx, userID, args.String(1))
+		if errors.Is(err, service.ErrLedgerNotFound) {
+			b.sendErrorMessage(chatID, "Книга не найдена - возможно, ее уже удалили")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error switching ledger: %w", err)
+		}
+		b.sendMainMenu(ctx, chatID, userID)
+
+	case "biz":
+		active, err := b.service.ActiveLedger(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("error getting active ledger: %w", err)
+		}
+		if active.ID != args.String(1) {
+			// Кнопка из старого списка: книгу уже сменили
+			b.sendLedgers(ctx, chatID, userID)
+			return nil
+		}
+		if err := b.service.SetLedgerBusiness(ctx, userID, active.ID, !active.Business); err != nil {
+			return fmt.Errorf("error switching business mode: %w", err)
+		}
+		if !active.Business {
+			b.api.Send(tgbotapi.NewMessage(chatID, "🏢 Бизнес-режим включен. В карточке транзакции из истории "+
+				"появится кнопка для контрагента, номера счета и НДС, а /quarter покажет отчет за квартал"))
+		}
+		b.sendLedgers(ctx, chatID, userID)
+
+	case "new":
+		state := &model.UserState{
+			UserID:         userID,
+			AwaitingAction: model.StateNewLedger,
+		}
+		if err := b.saveUserState(ctx, state); err != nil {
+			return fmt.Errorf("error saving user state: %w", err)
+		}
+		msg := tgbotapi.NewMessage(chatID, "Введите название книги, можно со значком в начале:\n`✈️ Поездка в Стамбул`")
+		msg.ParseMode = "Markdown"
+		msg.ReplyMarkup = cancelKeyboard
+		b.api.Send(msg)
+
+	case "del":
+		ledgers, err := b.service.GetLedgers(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("error getting ledgers: %w", err)
+		}
+		for _, ledger := range ledgers {
+			if ledger.ID != args.String(1) {
+				continue
+			}
+			msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(
+				"Удалить книгу %s? Все ее транзакции, категории, счета и бюджеты будут удалены безвозвратно", ledger.Title()))
+			msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+				callbackButton("🗑 Удалить", cbLedger, "rm", ledger.ID),
+				callbackButton("Отмена", cbLedger),
+			))
+			b.api.Send(msg)
+			return nil
+		}
+		b.sendErrorMessage(chatID, "Книга не найдена - возможно, ее уже удалили")
+
+	case "rm":
+		err := b.service.DeleteLedger(ctx, userID, args.String(1))
+		if errors.Is(err, service.ErrLedgerNotFound) {
+			b.sendErrorMessage(chatID, "Книга не найдена - возможно, ее уже удалили")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error deleting ledger: %w", err)
+		}
+		b.api.Send(tgbotapi.NewMessage(chatID, "Книга удалена 🗑"))
+		b.sendLedgers(ctx, chatID, userID)
+
+	case "all":
+		reportType, err := strconv.Atoi(args.String(1))
+		if err != nil || (reportType != int(service.MonthlyReport) && reportType != int(service.YearlyReport)) {
+			return fmt.Errorf("invalid report type: %s", callback.Data)
+		}
+		b.api.Send(tgbotapi.NewMessage(chatID, "🗂 Сводный отчет по всем книгам учета"))
+		b.sendReport(service.WithAllLedgers(ctx), chatID, userID, service.ReportType(reportType))
+
+	default:
+		b.sendLedgers(ctx, chatID, userID)
+	}
+	return nil
+}
+
+// createLedgerFromMessage заводит книгу с названием из сообщения
+func (b *Bot) createLedgerFromMessage(ctx context.Context, message *tgbotapi.Message) error {
+	if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+		b.reportError(ctx, fmt.Errorf("error deleting user state: %w", err))
+	}
+	b.createLedger(ctx, message.Chat.ID, message.From.ID, message.Text)
+	return nil
+}
+
+// createLedger заводит книгу и сообщает, что записи теперь попадают в нее
+func (b *Bot) createLedger(ctx context.Context, chatID, userID int64, text string) {
+	emoji, name := splitCategoryName(text)
+	if emoji == "" {
+		emoji = defaultLedgerEmoji
+	}
+	ledger, err := b.service.CreateLedger(ctx, userID, name, emoji)
+	if errors.Is(err, service.ErrLedgerLimit) {
+		b.sendErrorMessage(chatID, "Книг учета уже слишком много. Удалите ненужную: /ledgers")
+		return
+	}
+	if err != nil {
+		b.sendServiceError(ctx, chatID, err, "Не удалось создать книгу учета")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(
+		"Книга %s создана! ✅\nТеперь записи попадают в нее. Категории скопированы из прежней книги", ledger.Title()))
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+}
+
+// sendMainMenu присылает главное меню. Если записи идут не в основную книгу, меню
+// напоминает, в какую
+func (b *Bot) sendMainMenu(ctx context.Context, chatID, userID int64) {
+	text := "*Главное меню*\nВыберите нужное действие 👇"
+	if active, err := b.service.ActiveLedger(ctx, userID); err == nil && active.DataID != 0 {
+		text = fmt.Sprintf("*Главное меню* · %s\nВыберите нужное действие 👇", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, active.Title()))
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+}
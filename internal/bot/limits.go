@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// limitHint - подсказка по установке лимита
+const limitHint = "`/limit 10000 Кафе` - задать лимит, `/limit 0 Кафе` - снять"
+
+// handleLimit задает лимит трат категории за месяц или показывает лимиты: /limit 10000 Кафе
+func (b *Bot) handleLimit(ctx context.Context, message *tgbotapi.Message) {
+	args := strings.SplitN(strings.TrimSpace(message.CommandArguments()), " ", 2)
+	if len(args) < 2 {
+		b.showLimits(ctx, message)
+		return
+	}
+
+	limit, err := strconv.ParseFloat(args[0], 64)
+	if err != nil || limit < 0 {
+		b.sendErrorMessage(message.Chat.ID, "Неверный формат суммы. Используйте: /limit 10000 Кафе")
+		return
+	}
+
+	categories, err := b.service.GetCategories(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить категории")
+		return
+	}
+	name := strings.TrimSpace(args[1])
+	var category *model.Category
+	for _, cat := range model.ActiveCategories(categories) {
+		if cat.Type == "expense" && strings.EqualFold(cat.Name, name) {
+			category = &cat
+			break
+		}
+	}
+	if category == nil {
+		b.sendErrorMessage(message.Chat.ID, fmt.Sprintf("Категория расходов «%s» не найдена", name))
+		return
+	}
+
+	if _, err := b.service.SetCategoryLimit(ctx, message.From.ID, category.ID, limit); err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось сохранить лимит")
+		log.Printf("Error setting category limit: %v", err)
+		return
+	}
+
+	text := fmt.Sprintf("Лимит категории «%s» снят", category.Name)
+	if limit > 0 {
+		text = fmt.Sprintf("Лимит категории «%s»: %.0f₽ в месяц 🚧\nПри превышении бот предупредит сразу после записи траты",
+			category.Name, limit)
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+}
+
+// showLimits показывает лимиты категорий и траты по ним с начала месяца
+func (b *Bot) showLimits(ctx context.Context, message *tgbotapi.Message) {
+	limits, err := b.service.GetCategoryLimits(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить лимиты")
+		return
+	}
+
+	text := "*Лимиты категорий* 🚧\n\n"
+	if len(limits) == 0 {
+		text += "Лимитов пока нет. В отличие от бюджетов, о превышении лимита бот предупреждает " +
+			"сразу при записи траты\n"
+	}
+	for _, limit := range limits {
+		emoji := "✅"
+		if limit.Over() > 0 {
+			emoji = "🔴"
+		}
+		text += fmt.Sprintf("%s *%s*: %.0f₽ из %.0f₽\n",
+			emoji, limit.Category.Name, limit.Spent, limit.Category.SpendingLimit)
+	}
+	text += "\n" + limitHint
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = "Markdown"
+	b.api.Send(msg)
+}
+
+// withLimitWarning дописывает к подтверждению траты предупреждение о превышении лимита категории
+func (b *Bot) withLimitWarning(ctx context.Context, userID int64, categoryID string, amount float64, text string) string {
+	if warning := b.limitWarning(ctx, userID, categoryID, amount); warning != "" {
+		text += "\n\n⚠️ " + warning
+	}
+	return text
+}
+
+// limitWarning возвращает предупреждение о превышении лимита категории, "" - лимит не превышен.
+// В предупреждении название категории, в Markdown-сообщениях его нужно экранировать
+func (b *Bot) limitWarning(ctx context.Context, userID int64, categoryID string, amount float64) string {
+	warning, err := b.service.CheckCategoryLimit(ctx, userID, categoryID, amount)
+	if err != nil {
+		log.Printf("Error checking category limit: %v", err)
+		return ""
+	}
+	return warning
+}
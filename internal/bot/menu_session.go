@@ -0,0 +1,168 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// menuSessionPrefix начинает данные кнопки, помеченной сессией меню: "s<номер>.<данные>".
+// Данные без пометки - из сообщений до появления сессий, из групп и рассылок по расписанию
+const menuSessionPrefix = "s"
+
+// staleMenuText - ответ на кнопку из сообщения прошлой сессии
+const staleMenuText = "Меню устарело, нажмите /start"
+
+// withMenuSession помечает данные кнопки сессией. Если с пометкой данные не укладываются
+// в ограничение Telegram, кнопка остается без нее и считается действующей
+func withMenuSession(data string, session int) string {
+	_, data, _ = splitMenuSession(data)
+	stamped := menuSessionPrefix + strconv.Itoa(session) + "." + data
+	if len(stamped) > maxCallbackData {
+		return data
+	}
+	return stamped
+}
+
+// splitMenuSession отделяет сессию от данных кнопки. ok = false - кнопка без сессии
+func splitMenuSession(data string) (session int, rest string, ok bool) {
+	tail, found := strings.CutPrefix(data, menuSessionPrefix)
+	if !found {
+		return 0, data, false
+	}
+	number, rest, found := strings.Cut(tail, ".")
+	if !found {
+		return 0, data, false
+	}
+	session, err := strconv.Atoi(number)
+	if err != nil {
+		return 0, data, false
+	}
+	return session, rest, true
+}
+
+// menuSessionAPI помечает кнопки исходящих сообщений текущей сессией меню чата.
+// Кнопки собираются без знания о пользователе, поэтому пометка ставится при отправке.
+// Заодно из клавиатур убираются кнопки, пропущенные callbackButton
+type menuSessionAPI struct {
+	TelegramAPI
+	// session возвращает сессию чата, ok = false - сессия неизвестна и кнопки не помечаются
+	session func(chatID int64) (int, bool)
+}
+
+func (a *menuSessionAPI) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	return a.TelegramAPI.Request(a.stamp(c))
+}
+
+func (a *menuSessionAPI) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	return a.TelegramAPI.Send(a.stamp(c))
+}
+
+func (a *menuSessionAPI) SendContext(ctx context.Context, c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	return sendContext(ctx, a.TelegramAPI, a.stamp(c))
+}
+
+// stamp возвращает копию запроса, в inline-клавиатуре которой кнопки помечены сессией.
+// Клавиатура копируется: одни и те же клавиатуры, например cancelKeyboard, отправляются
+// в разные чаты
+func (a *menuSessionAPI) stamp(c tgbotapi.Chattable) tgbotapi.Chattable {
+	// Сессии есть только у личных чатов: ID чата совпадает с ID пользователя
+	session, ok := 0, false
+	if chatID := chatIDOf(c); chatID > 0 {
+		session, ok = a.session(chatID)
+	}
+
+	original := reflect.ValueOf(c)
+	config := reflect.New(reflect.Indirect(original).Type()).Elem()
+	config.Set(reflect.Indirect(original))
+	var markup reflect.Value
+	for _, name := range []string{"BaseChat", "BaseEdit"} {
+		if base := config.FieldByName(name); base.IsValid() && base.Kind() == reflect.Struct {
+			markup = base.FieldByName("ReplyMarkup")
+			break
+		}
+	}
+	if !markup.IsValid() {
+		return c
+	}
+
+	switch keyboard := markup.Interface().(type) {
+	case tgbotapi.InlineKeyboardMarkup:
+		markup.Set(reflect.ValueOf(stampKeyboard(keyboard, session, ok)))
+	case *tgbotapi.InlineKeyboardMarkup:
+		if keyboard == nil {
+			return c
+		}
+		stamped := stampKeyboard(*keyboard, session, ok)
+		markup.Set(reflect.ValueOf(&stamped))
+	default:
+		return c
+	}
+
+	if original.Kind() == reflect.Pointer {
+		return config.Addr().Interface().(tgbotapi.Chattable)
+	}
+	return config.Interface().(tgbotapi.Chattable)
+}
+
+// stampKeyboard возвращает копию клавиатуры без пропущенных кнопок. Если stamp = true,
+// кнопки помечаются сессией
+func stampKeyboard(keyboard tgbotapi.InlineKeyboardMarkup, session int, stamp bool) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(keyboard.InlineKeyboard))
+	for _, row := range keyboard.InlineKeyboard {
+		buttons := make([]tgbotapi.InlineKeyboardButton, 0, len(row))
+		for _, button := range row {
+			if omittedButton(button) {
+				continue
+			}
+			if stamp && button.CallbackData != nil {
+				data := withMenuSession(*button.CallbackData, session)
+				button.CallbackData = &data
+			}
+			buttons = append(buttons, button)
+		}
+		// Ряд из одних пропущенных кнопок убирается целиком
+		if len(buttons) > 0 || len(row) == 0 {
+			rows = append(rows, buttons)
+		}
+	}
+	return tgbotapi.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// loadMenuSession загружает сессию меню пользователя личного чата, чтобы кнопки ответа
+// были помечены ей. Без сессии бот продолжает работу, просто не помечая кнопки
+func (b *Bot) loadMenuSession(ctx context.Context, userID, chatID int64) {
+	if userID != chatID {
+		return
+	}
+	if _, err := b.service.MenuSession(ctx, userID); err != nil {
+		b.reportError(ctx, fmt.Errorf("error getting menu session: %w", err))
+	}
+}
+
+// staleCallback отвечает на кнопку из сообщения прошлой сессии меню и убирает
+// устаревшую клавиатуру из сообщения
+func (b *Bot) staleCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) bool {
+	session, _, ok := splitMenuSession(callback.Data)
+	if !ok || callback.Message == nil {
+		return false
+	}
+	current, err := b.service.CurrentMenuSession(ctx, callback.From.ID, session)
+	if err != nil {
+		// Лучше выполнить старую кнопку, чем не выполнить действующую
+		b.reportError(ctx, fmt.Errorf("error checking menu session: %w", err))
+		return false
+	}
+	if current {
+		return false
+	}
+
+	b.api.Request(tgbotapi.NewCallback(callback.ID, staleMenuText))
+	b.api.Request(tgbotapi.NewEditMessageReplyMarkup(callback.Message.Chat.ID, callback.Message.MessageID,
+		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}))
+	return true
+}
@@ -0,0 +1,180 @@
+// Package mocks - заглушки зависимостей бота для модульных тестов обработчиков
+package mocks
+
+import (
+	"encoding/json"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/bot"
+)
+
+// Call - вызов метода TelegramAPI
+type Call struct {
+	Method string             // имя метода: Send, Request, MakeRequest, ...
+	Config tgbotapi.Chattable // запрос Send, Request и SendMediaGroup
+	Args   []any              // аргументы остальных методов
+}
+
+// TelegramAPI - управляемая заглушка bot.TelegramAPI. Все вызовы записываются в Calls.
+// Поведение метода задается полем *Func; если оно не задано, метод возвращает успех
+// с пустым ответом. Так в тестах проверяется, что отправил обработчик, и моделируются
+// ошибки Telegram, например 403 от заблокировавшего бота пользователя
+type TelegramAPI struct {
+	SendFunc                 func(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	SendMediaGroupFunc       func(config tgbotapi.MediaGroupConfig) ([]tgbotapi.Message, error)
+	RequestFunc              func(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+	MakeRequestFunc          func(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error)
+	UploadFilesFunc          func(endpoint string, params tgbotapi.Params, files []tgbotapi.RequestFile) (*tgbotapi.APIResponse, error)
+	GetMeFunc                func() (tgbotapi.User, error)
+	GetFileDirectURLFunc     func(fileID string) (string, error)
+	GetWebhookInfoFunc       func() (tgbotapi.WebhookInfo, error)
+	GetUpdatesFunc           func(config tgbotapi.UpdateConfig) ([]tgbotapi.Update, error)
+	GetUpdatesChanFunc       func(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel
+	StopReceivingUpdatesFunc func()
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ bot.TelegramAPI = (*TelegramAPI)(nil)
+
+// Calls возвращает записанные вызовы по порядку
+func (m *TelegramAPI) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallsOf возвращает вызовы одного метода
+func (m *TelegramAPI) CallsOf(method string) []Call {
+	var calls []Call
+	for _, call := range m.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// SentTexts возвращает тексты сообщений, отправленных через Send
+func (m *TelegramAPI) SentTexts() []string {
+	var texts []string
+	for _, call := range m.CallsOf("Send") {
+		switch c := call.Config.(type) {
+		case tgbotapi.MessageConfig:
+			texts = append(texts, c.Text)
+		case tgbotapi.EditMessageTextConfig:
+			texts = append(texts, c.Text)
+		}
+	}
+	return texts
+}
+
+// Reset забывает записанные вызовы
+func (m *TelegramAPI) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+}
+
+func (m *TelegramAPI) record(call Call) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, call)
+}
+
+// okResponse - ответ Telegram на запрос без результата
+func okResponse() *tgbotapi.APIResponse {
+	return &tgbotapi.APIResponse{Ok: true, Result: json.RawMessage("true")}
+}
+
+func (m *TelegramAPI) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	m.record(Call{Method: "Send", Config: c})
+	if m.SendFunc != nil {
+		return m.SendFunc(c)
+	}
+	return tgbotapi.Message{}, nil
+}
+
+func (m *TelegramAPI) SendMediaGroup(config tgbotapi.MediaGroupConfig) ([]tgbotapi.Message, error) {
+	m.record(Call{Method: "SendMediaGroup", Config: config})
+	if m.SendMediaGroupFunc != nil {
+		return m.SendMediaGroupFunc(config)
+	}
+	return nil, nil
+}
+
+func (m *TelegramAPI) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	m.record(Call{Method: "Request", Config: c})
+	if m.RequestFunc != nil {
+		return m.RequestFunc(c)
+	}
+	return okResponse(), nil
+}
+
+func (m *TelegramAPI) MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error) {
+	m.record(Call{Method: "MakeRequest", Args: []any{endpoint, params}})
+	if m.MakeRequestFunc != nil {
+		return m.MakeRequestFunc(endpoint, params)
+	}
+	return okResponse(), nil
+}
+
+func (m *TelegramAPI) UploadFiles(endpoint string, params tgbotapi.Params, files []tgbotapi.RequestFile) (*tgbotapi.APIResponse, error) {
+	m.record(Call{Method: "UploadFiles", Args: []any{endpoint, params, files}})
+	if m.UploadFilesFunc != nil {
+		return m.UploadFilesFunc(endpoint, params, files)
+	}
+	return okResponse(), nil
+}
+
+func (m *TelegramAPI) GetMe() (tgbotapi.User, error) {
+	m.record(Call{Method: "GetMe"})
+	if m.GetMeFunc != nil {
+		return m.GetMeFunc()
+	}
+	return tgbotapi.User{ID: 1, IsBot: true, UserName: "mock_bot"}, nil
+}
+
+func (m *TelegramAPI) GetFileDirectURL(fileID string) (string, error) {
+	m.record(Call{Method: "GetFileDirectURL", Args: []any{fileID}})
+	if m.GetFileDirectURLFunc != nil {
+		return m.GetFileDirectURLFunc(fileID)
+	}
+	return "", nil
+}
+
+func (m *TelegramAPI) GetWebhookInfo() (tgbotapi.WebhookInfo, error) {
+	m.record(Call{Method: "GetWebhookInfo"})
+	if m.GetWebhookInfoFunc != nil {
+		return m.GetWebhookInfoFunc()
+	}
+	return tgbotapi.WebhookInfo{}, nil
+}
+
+func (m *TelegramAPI) GetUpdates(config tgbotapi.UpdateConfig) ([]tgbotapi.Update, error) {
+	m.record(Call{Method: "GetUpdates", Args: []any{config}})
+	if m.GetUpdatesFunc != nil {
+		return m.GetUpdatesFunc(config)
+	}
+	return nil, nil
+}
+
+// GetUpdatesChan по умолчанию возвращает закрытый канал: Bot.Start сразу завершится
+func (m *TelegramAPI) GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	m.record(Call{Method: "GetUpdatesChan", Args: []any{config}})
+	if m.GetUpdatesChanFunc != nil {
+		return m.GetUpdatesChanFunc(config)
+	}
+	updates := make(chan tgbotapi.Update)
+	close(updates)
+	return updates
+}
+
+func (m *TelegramAPI) StopReceivingUpdates() {
+	m.record(Call{Method: "StopReceivingUpdates"})
+	if m.StopReceivingUpdatesFunc != nil {
+		m.StopReceivingUpdatesFunc()
+	}
+}
@@ -0,0 +1,250 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// netWorthMonths - глубина истории капитала на графике
+const netWorthMonths = 24
+
+// handleNetWorth показывает капитал, график его изменения по месяцам и структуру капитала
+func (b *Bot) handleNetWorth(ctx context.Context, message *tgbotapi.Message) {
+
+	// Каждый просмотр обновляет снимок текущего месяца
+	summary, err := b.service.TakeNetWorthSnapshot(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось рассчитать капитал")
+		return
+	}
+	history, err := b.service.GetNetWorthHistory(ctx, message.From.ID, netWorthMonths)
+	if err != nil {
+		log.Printf("Error getting net worth history: %v", err)
+	}
+
+	snapshot := summary.Snapshot
+	text := "🏛 *Капитал*\n\n"
+	text += fmt.Sprintf("💳 Счета: %.2f₽\n", snapshot.Accounts)
+	text += fmt.Sprintf("🏠 Активы: %.2f₽\n", snapshot.Assets)
+	if len(summary.Holdings) > 0 {
+		text += fmt.Sprintf("📈 Инвестиции: %.2f₽\n", snapshot.Holdings)
+	}
+	text += fmt.Sprintf("📉 Обязательства: %.2f₽\n", snapshot.Liabilities)
+	text += fmt.Sprintf("\n*Итого:* %.2f₽", snapshot.NetWorth)
+	if len(history) > 1 {
+		prev := history[len(history)-2]
+		text += fmt.Sprintf(" (%+.2f₽ за месяц)", snapshot.NetWorth-prev.NetWorth)
+	}
+	text += "\n"
+
+	if len(summary.Assets) > 0 {
+		text += "\n"
+		for _, a := range summary.Assets {
+			sign := "+"
+			if a.Kind == model.AssetKindLiability {
+				sign = "−"
+			}
+			text += fmt.Sprintf("%s %s: %.2f₽\n", sign, a.Name, a.Amount)
+		}
+	}
+	if len(summary.Holdings) > 0 {
+		text += "\n" + formatHoldings(summary.Holdings)
+	}
+
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+		callbackButton("➕ Актив", cbNetWorth, "add", model.AssetKindAsset),
+		callbackButton("➕ Долг", cbNetWorth, "add", model.AssetKindLiability),
+	))
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+		callbackButton("➕ Акции", cbNetWorth, "hold", model.HoldingKindStock),
+		callbackButton("➕ Крипта", cbNetWorth, "hold", model.HoldingKindCrypto),
+	))
+	for _, a := range summary.Assets {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton("🗑 "+a.Name, cbNetWorth, "del", a.ID),
+		))
+	}
+	for _, h := range summary.Holdings {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton("🗑 "+h.Ticker, cbNetWorth, "unhold", h.ID),
+		))
+	}
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+		callbackButton("« Назад", cbMenu),
+	))
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	b.api.Send(msg)
+
+	delivery := b.chartDelivery(ctx, message.From.ID)
+	if len(history) >= 2 {
+		chartData, err := delivery.charts.GenerateNetWorthChart(history)
+		if err != nil {
+			log.Printf("Error generating net worth chart: %v", err)
+		} else {
+			b.api.Send(delivery.message(message.Chat.ID, "networth", chartData))
+		}
+	}
+
+	// Структура интересна, когда капитал вложен не только в деньги на счетах
+	if allocation := summary.Allocation(); len(allocation) > 1 {
+		chartData, err := delivery.charts.GenerateAllocationChart(allocation)
+		if err != nil {
+			log.Printf("Error generating allocation chart: %v", err)
+			return
+		}
+		b.api.Send(delivery.message(message.Chat.ID, "allocation", chartData))
+	}
+}
+
+// formatHoldings описывает инвестиции по рыночной стоимости
+func formatHoldings(holdings []service.HoldingValue) string {
+	text, stale := "", false
+	for _, h := range holdings {
+		mark := ""
+		if h.Stale {
+			mark, stale = " ⏳", true
+		}
+		quantity := strconv.FormatFloat(h.Quantity, 'f', -1, 64)
+		text += fmt.Sprintf("📈 %s: %s × %.2f₽ = %.2f₽%s\n", h.Ticker, quantity, h.Price, h.Value, mark)
+	}
+	if stale {
+		text += "⏳ котировка недоступна, стоимость по последней известной цене\n"
+	}
+	return text
+}
+
+// handleNetWorthCallback обрабатывает добавление и удаление активов
+func (b *Bot) handleNetWorthCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	switch args.String(0) {
+	case "add":
+		kind := args.String(1)
+		state := &model.UserState{
+			UserID:         callback.From.ID,
+			AwaitingAction: model.StateNewAsset,
+			Payload:        kind,
+		}
+		if err := b.saveUserState(ctx, state); err != nil {
+			return fmt.Errorf("error saving user state: %w", err)
+		}
+
+		example := "`Квартира 8000000`"
+		if kind == model.AssetKindLiability {
+			example = "`Ипотека 5500000`"
+		}
+		msg := tgbotapi.NewMessage(callback.Message.Chat.ID, "Введите название и сумму:\n"+example)
+		msg.ParseMode = "Markdown"
+		msg.ReplyMarkup = cancelKeyboard
+		b.api.Send(msg)
+
+	case "del":
+		assetID := args.String(1)
+		if err := b.service.DeleteAsset(ctx, assetID, callback.From.ID); err != nil {
+			return fmt.Errorf("error deleting asset: %w", err)
+		}
+		b.handleNetWorth(ctx, &tgbotapi.Message{
+			From: callback.From,
+			Chat: callback.Message.Chat,
+		})
+
+	case "hold":
+		kind := args.String(1)
+		state := &model.UserState{
+			UserID:         callback.From.ID,
+			AwaitingAction: model.StateNewHolding,
+			Payload:        kind,
+		}
+		if err := b.saveUserState(ctx, state); err != nil {
+			return fmt.Errorf("error saving user state: %w", err)
+		}
+
+		text := "Введите тикер Московской биржи и количество бумаг:\n`SBER 100`"
+		if kind == model.HoldingKindCrypto {
+			text = "Введите тикер монеты и количество:\n`BTC 0.05`"
+		}
+		msg := tgbotapi.NewMessage(callback.Message.Chat.ID, text+"\n\nЕсли тикер уже добавлен, количество заменится")
+		msg.ParseMode = "Markdown"
+		msg.ReplyMarkup = cancelKeyboard
+		b.api.Send(msg)
+
+	case "unhold":
+		holdingID := args.String(1)
+		if err := b.service.DeleteHolding(ctx, holdingID, callback.From.ID); err != nil {
+			return fmt.Errorf("error deleting holding: %w", err)
+		}
+		b.handleNetWorth(ctx, &tgbotapi.Message{
+			From: callback.From,
+			Chat: callback.Message.Chat,
+		})
+	}
+	return nil
+}
+
+// createAssetFromMessage создает актив или обязательство из введенного текста
+func (b *Bot) createAssetFromMessage(ctx context.Context, message *tgbotapi.Message, state *model.UserState) error {
+	text := strings.TrimSpace(message.Text)
+	sep := strings.LastIndex(text, " ")
+	if sep <= 0 {
+		b.sendErrorMessage(message.Chat.ID, "Укажите название и сумму, например: Квартира 8000000")
+		return nil
+	}
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(text[sep+1:], ",", "."), 64)
+	if err != nil || amount < 0 {
+		b.sendErrorMessage(message.Chat.ID, "Неверный формат суммы. Используйте число, например: 8000000")
+		return nil
+	}
+	name := strings.TrimSpace(text[:sep])
+
+	if err := b.service.CreateAsset(ctx, message.From.ID, name, state.Payload, amount); err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Ошибка при сохранении")
+		return nil
+	}
+	if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+		b.reportError(ctx, fmt.Errorf("error deleting user state: %w", err))
+	}
+
+	b.handleNetWorth(ctx, message)
+	return nil
+}
+
+// saveHoldingFromMessage записывает инвестицию из введенного текста: "SBER 100"
+func (b *Bot) saveHoldingFromMessage(ctx context.Context, message *tgbotapi.Message, state *model.UserState) error {
+	fields := strings.Fields(message.Text)
+	if len(fields) != 2 {
+		b.sendErrorMessage(message.Chat.ID, "Укажите тикер и количество, например: SBER 100")
+		return nil
+	}
+	quantity, err := strconv.ParseFloat(strings.ReplaceAll(fields[1], ",", "."), 64)
+	if err != nil || quantity <= 0 {
+		b.sendErrorMessage(message.Chat.ID, "Неверное количество. Используйте число, например: 100 или 0.05")
+		return nil
+	}
+
+	_, err = b.service.SaveHolding(ctx, message.From.ID, state.Payload, fields[0], quantity)
+	if errors.Is(err, service.ErrUnknownTicker) {
+		b.sendErrorMessage(message.Chat.ID, fmt.Sprintf("Не нашел котировку %s. Проверьте тикер: для акций и фондов - "+
+			"как на Московской бирже (SBER, TMOS), для криптовалюты - BTC, ETH, TON", strings.ToUpper(fields[0])))
+		return nil
+	}
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Ошибка при сохранении")
+		return nil
+	}
+	if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+		b.reportError(ctx, fmt.Errorf("error deleting user state: %w", err))
+	}
+
+	b.handleNetWorth(ctx, message)
+	return nil
+}
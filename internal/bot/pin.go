@@ -0,0 +1,337 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// pinProtectedActions - кнопки, которые показывают финансовые данные
+var pinProtectedActions = map[callbackAction]bool{
+	cbReports: true, cbReport: true, cbCharts: true, cbForecast: true, cbCompare: true, cbIncome: true,
+	cbBalance: true, cbTransactions: true, cbTransaction: true, cbExport: true, cbDeleteTransaction: true, cbDeleteAccount: true,
+	cbRecycleBin: true, cbNetWorth: true, cbDeleteMe: true, cbAdvice: true, cbBudget: true, cbPlan: true, cbDuplicate: true, cbRetry: true, cbPIN: true,
+	cbWishlist: true, cbGoal: true, cbLedger: true, cbEvent: true,
+}
+
+// pendingAction - команда или кнопка, отложенная до ввода PIN
+type pendingAction struct {
+	Command   string `json:"command,omitempty"`
+	Callback  string `json:"callback,omitempty"`
+	MessageID int    `json:"message_id,omitempty"`
+}
+
+// requirePIN откладывает команду с финансовыми данными и просит PIN, если доступ
+// заблокирован после бездействия. Возвращает true, если обновление обрабатывать не нужно
+func (b *Bot) requirePIN(ctx context.Context, update tgbotapi.Update) bool {
+	var (
+		pending pendingAction
+		user    *tgbotapi.User
+		chatID  int64
+	)
+	switch {
+	case update.Message != nil && update.Message.IsCommand():
+		if !b.commands[update.Message.Command()].financial {
+			return false
+		}
+		pending.Command = update.Message.Text
+		user, chatID = update.Message.From, update.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		if !pinProtectedCallback(update.CallbackQuery.Data) {
+			return false
+		}
+		pending.Callback = update.CallbackQuery.Data
+		pending.MessageID = update.CallbackQuery.Message.MessageID
+		user, chatID = update.CallbackQuery.From, update.CallbackQuery.Message.Chat.ID
+	default:
+		return false
+	}
+
+	locked, err := b.service.PINLocked(ctx, user.ID, time.Now())
+	if err != nil {
+		// Не знаем, включен ли PIN, поэтому данные не показываем
+		b.reportError(ctx, fmt.Errorf("error checking pin: %w", err))
+		b.answerPINCallback(update.CallbackQuery, "")
+		b.sendErrorMessage(chatID, "Не удалось проверить PIN, попробуйте еще раз")
+		return true
+	}
+	if !locked {
+		return false
+	}
+	b.answerPINCallback(update.CallbackQuery, "🔒 Нужен PIN")
+
+	payload, err := json.Marshal(pending)
+	if err != nil {
+		b.reportError(ctx, fmt.Errorf("error encoding pending action: %w", err))
+		return true
+	}
+	state := &model.UserState{
+		UserID:         user.ID,
+		AwaitingAction: model.StatePINUnlock,
+		Payload:        string(payload),
+	}
+	if err := b.saveUserState(ctx, state); err != nil {
+		b.reportError(ctx, fmt.Errorf("error saving user state: %w", err))
+		b.sendErrorMessage(chatID, "Не удалось запросить PIN, попробуйте еще раз")
+		return true
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "🔒 Данные защищены PIN-кодом.\n\n"+
+		"Отправьте PIN, чтобы продолжить. Сообщение с ним будет удалено")
+	msg.ReplyMarkup = cancelKeyboard
+	b.api.Send(msg)
+	return true
+}
+
+// pinProtectedCallback проверяет, показывает ли кнопка финансовые данные.
+// Устаревшие кнопки не защищаются: их роутер все равно не обработает
+func pinProtectedCallback(data string) bool {
+	action, _, err := decodeCallback(data)
+	return err == nil && pinProtectedActions[action]
+}
+
+// answerPINCallback убирает индикатор загрузки с кнопки, которая не будет обработана
+func (b *Bot) answerPINCallback(callback *tgbotapi.CallbackQuery, text string) {
+	if callback != nil {
+		b.api.Request(tgbotapi.NewCallback(callback.ID, text))
+	}
+}
+
+// unlockFromMessage проверяет введенный PIN и выполняет отложенную команду
+func (b *Bot) unlockFromMessage(ctx context.Context, message *tgbotapi.Message, state *model.UserState) error {
+	b.deletePINMessage(message)
+
+	err := b.service.UnlockPIN(ctx, message.From.ID, message.Text)
+	switch {
+	case errors.Is(err, service.ErrWrongPIN):
+		b.sendErrorMessage(message.Chat.ID, "Неверный PIN. Попробуйте еще раз или отправьте /cancel")
+		return nil
+	case errors.Is(err, service.ErrPINBlocked):
+		if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+			b.reportError(ctx, fmt.Errorf("error deleting user state: %w", err))
+		}
+		b.sendErrorMessage(message.Chat.ID, "Слишком много неверных попыток. Ввод PIN временно заблокирован, попробуйте через 15 минут")
+		return nil
+	case err != nil && !errors.Is(err, service.ErrPINNotSet):
+		b.sendErrorMessage(message.Chat.ID, "Не удалось проверить PIN, попробуйте еще раз")
+		return fmt.Errorf("error unlocking pin: %w", err)
+	}
+
+	if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+		b.reportError(ctx, fmt.Errorf("error deleting user state: %w", err))
+	}
+
+	var pending pendingAction
+	if err := json.Unmarshal([]byte(state.Payload), &pending); err != nil {
+		return fmt.Errorf("error decoding pending action: %w", err)
+	}
+	switch {
+	case pending.Command != "":
+		return b.handleCommand(ctx, commandMessage(message, pending.Command))
+	case pending.Callback != "":
+		return b.handleCallback(ctx, &tgbotapi.CallbackQuery{
+			From:    message.From,
+			Message: &tgbotapi.Message{MessageID: pending.MessageID, Chat: message.Chat},
+			Data:    pending.Callback,
+		})
+	}
+	b.api.Send(tgbotapi.NewMessage(message.Chat.ID, "🔓 Доступ открыт"))
+	return nil
+}
+
+// commandMessage собирает сообщение с командой от имени автора message
+func commandMessage(message *tgbotapi.Message, text string) *tgbotapi.Message {
+	command, _, _ := strings.Cut(text, " ")
+	return &tgbotapi.Message{
+		From: message.From,
+		Chat: message.Chat,
+		Text: text,
+		Entities: []tgbotapi.MessageEntity{
+			{Type: "bot_command", Offset: 0, Length: len(command)},
+		},
+	}
+}
+
+// deletePINMessage удаляет из чата сообщение с PIN
+func (b *Bot) deletePINMessage(message *tgbotapi.Message) {
+	b.api.Request(tgbotapi.NewDeleteMessage(message.Chat.ID, message.MessageID))
+}
+
+// handlePIN показывает настройки защиты PIN-кодом
+func (b *Bot) handlePIN(ctx context.Context, message *tgbotapi.Message) {
+	settings, err := b.service.GetUserSettings(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить настройки")
+		return
+	}
+	b.sendPINSettings(message.Chat.ID, settings)
+}
+
+// sendPINSettings отправляет состояние блокировки и кнопки управления
+func (b *Bot) sendPINSettings(chatID int64, settings *model.UserSettings) {
+	text := "🔐 *Защита PIN-кодом*\n\n" +
+		"После бездействия бот спросит PIN перед показом отчетов, баланса, выгрузок и других финансовых данных.\n\n"
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	if settings.PINEnabled() {
+		text += fmt.Sprintf("Статус: включена, PIN спрашивается после %s бездействия", pinIdleText(settings.PINIdle))
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton("✏️ Сменить PIN", cbPIN, "set"),
+			callbackButton("🔓 Выключить", cbPIN, "off"),
+		))
+	} else {
+		text += "Статус: выключена\n\nЗабытый PIN восстановить нельзя - выберите тот, который запомните"
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton("🔐 Установить PIN", cbPIN, "set"),
+		))
+	}
+
+	var idleRow []tgbotapi.InlineKeyboardButton
+	for _, minutes := range service.PINIdleOptions {
+		title := pinIdleText(minutes)
+		if minutes == settings.PINIdle {
+			title = "✅ " + title
+		}
+		idleRow = append(idleRow, callbackButton(title, cbPIN, "idle", minutes))
+	}
+	buttons = append(buttons, idleRow,
+		tgbotapi.NewInlineKeyboardRow(callbackButton("« Назад", cbSettings, "show")),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	b.api.Send(msg)
+}
+
+// pinIdleText форматирует время бездействия: "15 мин" или "4 ч"
+func pinIdleText(minutes int) string {
+	if minutes >= 60 && minutes%60 == 0 {
+		return fmt.Sprintf("%d ч", minutes/60)
+	}
+	return fmt.Sprintf("%d мин", minutes)
+}
+
+// handlePINCallback обрабатывает кнопки настроек PIN.
+// Форматы данных: pin_menu, pin_set, pin_off, pin_idle_<минуты>
+func (b *Bot) handlePINCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	chatID := callback.Message.Chat.ID
+
+	switch op := args.String(0); op {
+	case "menu":
+		settings, err := b.service.GetUserSettings(ctx, callback.From.ID)
+		if err != nil {
+			return fmt.Errorf("error getting settings: %w", err)
+		}
+		b.sendPINSettings(chatID, settings)
+
+	case "set", "off":
+		action, prompt := model.StatePINSet, "Отправьте новый PIN или кодовую фразу - от 4 символов. Сообщение будет удалено"
+		if op == "off" {
+			action, prompt = model.StatePINDisable, "Отправьте текущий PIN, чтобы выключить защиту"
+		}
+		state := &model.UserState{UserID: callback.From.ID, AwaitingAction: action}
+		if err := b.saveUserState(ctx, state); err != nil {
+			return fmt.Errorf("error saving user state: %w", err)
+		}
+		msg := tgbotapi.NewMessage(chatID, prompt)
+		msg.ReplyMarkup = cancelKeyboard
+		b.api.Send(msg)
+
+	case "idle":
+		minutes, err := args.Int(1)
+		if err != nil || minutes <= 0 {
+			return fmt.Errorf("invalid pin idle period: %s", args.String(1))
+		}
+		settings, err := b.service.SetPINIdle(ctx, callback.From.ID, minutes)
+		if err != nil {
+			return fmt.Errorf("error saving pin idle period: %w", err)
+		}
+		b.sendPINSettings(chatID, settings)
+	}
+	return nil
+}
+
+// setPINFromMessage принимает новый PIN и просит повторить его
+func (b *Bot) setPINFromMessage(ctx context.Context, message *tgbotapi.Message, state *model.UserState) error {
+	b.deletePINMessage(message)
+
+	hash, err := service.HashPIN(message.Text)
+	if errors.Is(err, service.ErrInvalidPIN) {
+		b.sendErrorMessage(message.Chat.ID, "PIN должен быть не короче 4 символов и не длиннее 72 байт. Попробуйте еще раз")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	next := &model.UserState{
+		UserID:         message.From.ID,
+		AwaitingAction: model.StatePINConfirm,
+		Payload:        hash,
+	}
+	if err := b.transitionUserState(ctx, state, next); err != nil {
+		return fmt.Errorf("error saving user state: %w", err)
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, "Повторите PIN")
+	msg.ReplyMarkup = cancelKeyboard
+	b.api.Send(msg)
+	return nil
+}
+
+// confirmPINFromMessage сверяет повторный ввод и включает защиту
+func (b *Bot) confirmPINFromMessage(ctx context.Context, message *tgbotapi.Message, state *model.UserState) error {
+	b.deletePINMessage(message)
+
+	err := b.service.SetPIN(ctx, message.From.ID, state.Payload, message.Text)
+	if errors.Is(err, service.ErrWrongPIN) {
+		next := &model.UserState{UserID: message.From.ID, AwaitingAction: model.StatePINSet}
+		if err := b.transitionUserState(ctx, state, next); err != nil {
+			return fmt.Errorf("error saving user state: %w", err)
+		}
+		b.sendErrorMessage(message.Chat.ID, "PIN не совпадает. Отправьте новый PIN еще раз")
+		return nil
+	}
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось сохранить PIN")
+		return fmt.Errorf("error setting pin: %w", err)
+	}
+
+	if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+		b.reportError(ctx, fmt.Errorf("error deleting user state: %w", err))
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, "✅ PIN установлен")
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+	return nil
+}
+
+// disablePINFromMessage выключает защиту после проверки текущего PIN
+func (b *Bot) disablePINFromMessage(ctx context.Context, message *tgbotapi.Message) error {
+	b.deletePINMessage(message)
+
+	err := b.service.DisablePIN(ctx, message.From.ID, message.Text)
+	switch {
+	case errors.Is(err, service.ErrWrongPIN):
+		b.sendErrorMessage(message.Chat.ID, "Неверный PIN. Попробуйте еще раз или отправьте /cancel")
+		return nil
+	case errors.Is(err, service.ErrPINBlocked):
+		b.sendErrorMessage(message.Chat.ID, "Слишком много неверных попыток. Ввод PIN временно заблокирован, попробуйте через 15 минут")
+	case err != nil && !errors.Is(err, service.ErrPINNotSet):
+		b.sendErrorMessage(message.Chat.ID, "Не удалось выключить защиту")
+		return fmt.Errorf("error disabling pin: %w", err)
+	default:
+		b.api.Send(tgbotapi.NewMessage(message.Chat.ID, "🔓 Защита PIN-кодом выключена"))
+	}
+
+	if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+		b.reportError(ctx, fmt.Errorf("error deleting user state: %w", err))
+	}
+	return nil
+}
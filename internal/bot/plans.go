@@ -0,0 +1,207 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/locale"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// planDraft - запланированная трата, для которой выбирается категория
+type planDraft struct {
+	Amount      float64 `json:"amount"`
+	Description string  `json:"description"`
+}
+
+// handlePlan планирует крупную трату на следующий месяц: /plan 30000 Ремонт машины.
+// Без аргументов показывает планы
+func (b *Bot) handlePlan(ctx context.Context, message *tgbotapi.Message) {
+	args := strings.SplitN(strings.TrimSpace(message.CommandArguments()), " ", 2)
+	if args[0] == "" {
+		b.showPlans(ctx, message)
+		return
+	}
+
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(args[0], ",", "."), 64)
+	if err != nil || amount <= 0 {
+		b.sendErrorMessage(message.Chat.ID, "Неверный формат суммы. Используйте: /plan 30000 Ремонт машины")
+		return
+	}
+	draft := planDraft{Amount: amount}
+	if len(args) > 1 {
+		draft.Description = strings.TrimSpace(args[1])
+	}
+
+	categories, err := b.service.GetCategories(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить категории")
+		return
+	}
+	payload, err := json.Marshal(draft)
+	if err != nil {
+		b.reportError(ctx, fmt.Errorf("error encoding plan draft: %w", err))
+		return
+	}
+	state := &model.UserState{
+		UserID:         message.From.ID,
+		AwaitingAction: model.StatePlanCategory,
+		Payload:        string(payload),
+	}
+	if err := b.saveUserState(ctx, state); err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось начать планирование")
+		return
+	}
+
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	for _, cat := range model.ActiveCategories(categories) {
+		if cat.Type != "expense" {
+			continue
+		}
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton(cat.Name, cbPlan, "cat", cat.ID),
+		))
+	}
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+		callbackButton("✖️ Отмена", cbCancel),
+	))
+
+	month := locale.FormatMonth(locale.FromContext(ctx), b.service.NextMonth())
+	msg := tgbotapi.NewMessage(message.Chat.ID,
+		fmt.Sprintf("Трата %.0f₽ на %s. Выберите категорию:", amount, month))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	b.api.Send(msg)
+}
+
+// handlePlanCallback сохраняет запланированную трату в выбранной категории или удаляет план
+func (b *Bot) handlePlanCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	chatID := callback.Message.Chat.ID
+	switch args.String(0) {
+	case "cat":
+		state, err := b.expectState(ctx, callback.From.ID, model.StatePlanCategory)
+		if err != nil {
+			return err
+		}
+		if state == nil {
+			b.sendErrorMessage(chatID, "Выбор категории устарел, отправьте /plan заново")
+			return nil
+		}
+		var draft planDraft
+		if err := json.Unmarshal([]byte(state.Payload), &draft); err != nil {
+			return fmt.Errorf("error decoding plan draft: %w", err)
+		}
+
+		plan, err := b.service.AddPlannedExpense(ctx, callback.From.ID, args.String(1),
+			draft.Description, draft.Amount, b.service.NextMonth())
+		if err != nil {
+			b.sendServiceError(ctx, chatID, err, "Не удалось сохранить план")
+			return nil
+		}
+		if err := b.deleteUserState(ctx, callback.From.ID); err != nil {
+			return fmt.Errorf("error deleting user state: %w", err)
+		}
+
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Трата %.0f₽ запланирована на %s 📝\n"+
+			"В месячном отчете появится раздел «План и факт»",
+			plan.Amount, locale.FormatMonth(locale.FromContext(ctx), plan.Month)))
+		msg.ReplyMarkup = b.getMainKeyboard()
+		b.api.Send(msg)
+	case "del":
+		if err := b.service.DeletePlannedExpense(ctx, callback.From.ID, args.String(1)); err != nil {
+			b.sendServiceError(ctx, chatID, err, "Не удалось удалить план")
+			return nil
+		}
+		b.showPlans(ctx, callbackMessage(callback))
+	default:
+		return fmt.Errorf("unknown plan action: %s", callback.Data)
+	}
+	return nil
+}
+
+// showPlans показывает план и факт текущего месяца и траты, запланированные на следующие
+func (b *Bot) showPlans(ctx context.Context, message *tgbotapi.Message) {
+	plans, err := b.service.GetPlannedExpenses(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить планы")
+		return
+	}
+	lang := locale.FromContext(ctx)
+	text := "📝 *Планируемые траты*\n\n"
+	if len(plans) == 0 {
+		text += "Планов пока нет\n"
+	}
+
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	var month time.Time
+	for _, plan := range plans {
+		if !plan.Month.Equal(month) {
+			month = plan.Month
+			if month.Before(b.service.NextMonth()) {
+				// Текущий месяц показываем вместе с фактом
+				planVsFact, err := b.service.GetPlanVsFact(ctx, message.From.ID, month)
+				if err != nil {
+					b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось сравнить план с фактом")
+					return
+				}
+				text += fmt.Sprintf("*%s:*\n%s\n", locale.FormatMonth(lang, month), formatPlanVsFact(planVsFact))
+			} else {
+				text += fmt.Sprintf("*%s:*\n", locale.FormatMonth(lang, month))
+			}
+		}
+		if !month.Before(b.service.NextMonth()) {
+			text += fmt.Sprintf("• %s: %.0f₽\n", planTitle(plan), plan.Amount)
+		}
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton(fmt.Sprintf("🗑 %s %.0f₽", planTitle(plan), plan.Amount), cbPlan, "del", plan.ID),
+		))
+	}
+	text += "\nЗапланировать трату на следующий месяц: `/plan 30000 Ремонт машины`"
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(callbackButton("« В меню", cbMenu)))
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	b.api.Send(msg)
+}
+
+// formatPlanVsFact формирует раздел «План и факт»: по каждой категории запланированное
+// и потраченное, в конце - сколько по плану еще предстоит потратить
+func formatPlanVsFact(plan *service.PlanVsFact) string {
+	if plan == nil {
+		return ""
+	}
+	text := ""
+	for _, line := range plan.Lines {
+		emoji := "⏳"
+		if line.Remaining() == 0 {
+			emoji = "✅"
+		}
+		text += fmt.Sprintf("%s *%s*: потрачено %.0f₽ из %.0f₽ по плану\n",
+			emoji, line.CategoryName, line.Actual, line.Planned)
+		for _, item := range line.Items {
+			if item.Description != "" {
+				text += fmt.Sprintf("    ◦ %s: %.0f₽\n", item.Description, item.Amount)
+			}
+		}
+	}
+	if remaining := plan.Remaining(); remaining > 0 {
+		text += fmt.Sprintf("Впереди по плану: *%.0f₽*\n", remaining)
+	} else {
+		text += "Все запланированные траты сделаны ✅\n"
+	}
+	return text
+}
+
+// planTitle - название запланированной траты для кнопок и списков
+func planTitle(plan model.PlannedExpense) string {
+	if plan.Description != "" {
+		return plan.Description
+	}
+	return "Без описания"
+}
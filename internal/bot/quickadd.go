@@ -0,0 +1,155 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// handleQuickAdd записывает транзакцию из аргументов команды: /add 500 такси, /add +50000 зарплата
+func (b *Bot) handleQuickAdd(ctx context.Context, message *tgbotapi.Message, args string) {
+	amount, currency, description, err := service.ParseQuickAdd(args)
+	if err != nil {
+		b.sendErrorMessage(message.Chat.ID,
+			fmt.Sprintf("%v\n\nФормат: `/add 500 такси`, `/add +50000 зарплата` или `/add 25$ такси`", err))
+		return
+	}
+	b.quickAdd(ctx, message.From.ID, message.Chat.ID, amount, currency, description)
+}
+
+// quickAdd записывает транзакцию с подобранной категорией и присылает подтверждение
+// с кнопкой изменения категории. Сумма в валюте пересчитывается в валюту учета
+func (b *Bot) quickAdd(ctx context.Context, userID, chatID int64, amount float64, currency, description string) {
+
+	// Записываем на первый счет, как и при вводе через меню
+	accountID := ""
+	accounts, err := b.service.GetAccounts(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting accounts: %v", err)
+	} else if len(accounts) > 0 {
+		accountID = accounts[0].ID
+	}
+
+	transaction, category, err := b.service.AddQuickTransaction(ctx, userID, accountID, amount, currency, description)
+	if err != nil {
+		b.sendServiceError(ctx, chatID, err, "Ошибка при сохранении транзакции")
+		return
+	}
+	amount = transaction.Amount
+
+	kind, emoji := "Расход", "💸"
+	if amount > 0 {
+		kind, emoji = "Доход", "💰"
+	}
+	text := fmt.Sprintf("%s *%s %.2f₽* сохранен ✅\n*Категория:* %s", emoji, kind, math.Abs(amount),
+		tgbotapi.EscapeText(tgbotapi.ModeMarkdown, category.Name))
+	if transaction.Currency != "" {
+		text += fmt.Sprintf("\n*В валюте:* %s по курсу %.4g", formatMoney(math.Abs(transaction.OriginalAmount), transaction.Currency),
+			transaction.Amount/transaction.OriginalAmount)
+	}
+	if description != "" {
+		text += "\n*Описание:* " + tgbotapi.EscapeText(tgbotapi.ModeMarkdown, description)
+	}
+
+	warning, err := b.service.CheckAmount(ctx, userID, category.ID, amount)
+	if err != nil {
+		log.Printf("Error checking amount: %v", err)
+	} else if warning != "" {
+		text += "\n\n⚠️ " + warning
+	}
+	if limit := b.limitWarning(ctx, userID, category.ID, amount); limit != "" {
+		text += "\n\n⚠️ " + tgbotapi.EscapeText(tgbotapi.ModeMarkdown, limit)
+	}
+	text = b.withTodaySpending(ctx, userID, amount, warning != "", text)
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("✏️ Изменить категорию", cbRecategorize, category.Type, transaction.ID),
+			callbackButton("🗑 Удалить", cbDeleteTransaction, transaction.ID),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("📝 Заметка или чек", cbTransaction, transaction.ID),
+		),
+	)
+	b.api.Send(msg)
+
+	b.warnDuplicate(ctx, userID, chatID, amount, description)
+}
+
+// handleRecategorizeToCallback переносит транзакцию из состояния в выбранную категорию
+func (b *Bot) handleRecategorizeToCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	chatID := callback.Message.Chat.ID
+	categoryID := args.String(0)
+
+	state, err := b.expectState(ctx, callback.From.ID, model.StateRecategorize)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		b.sendErrorMessage(chatID, "Выбор категории устарел, запишите транзакцию заново")
+		return nil
+	}
+
+	category, err := b.service.ChangeTransactionCategory(ctx, callback.From.ID, state.Payload, categoryID)
+	if err != nil {
+		b.sendServiceError(ctx, chatID, err, "Не удалось изменить категорию")
+		return fmt.Errorf("error changing category: %w", err)
+	}
+	if err := b.deleteUserState(ctx, callback.From.ID); err != nil {
+		return fmt.Errorf("error deleting user state: %w", err)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Категория изменена на «%s» ✅", category.Name))
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+	return nil
+}
+
+// handleRecategorizeCallback предлагает выбрать новую категорию транзакции.
+// Аргументы: тип категорий и ID транзакции
+func (b *Bot) handleRecategorizeCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	chatID := callback.Message.Chat.ID
+	categoryType, transactionID := args.String(0), args.String(1)
+	if transactionID == "" {
+		return fmt.Errorf("invalid recategorize data: %v", args)
+	}
+
+	categories, err := b.service.GetCategories(ctx, callback.From.ID)
+	if err != nil {
+		return fmt.Errorf("error getting categories: %w", err)
+	}
+
+	state := &model.UserState{
+		UserID:         callback.From.ID,
+		AwaitingAction: model.StateRecategorize,
+		Payload:        transactionID,
+	}
+	if err := b.saveUserState(ctx, state); err != nil {
+		return fmt.Errorf("error saving user state: %w", err)
+	}
+
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	for _, cat := range model.ActiveCategories(categories) {
+		if cat.Type != categoryType {
+			continue
+		}
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton(cat.Name, cbRecategorizeTo, cat.ID),
+		))
+	}
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+		callbackButton("✖️ Отмена", cbCancel),
+	))
+
+	msg := tgbotapi.NewMessage(chatID, "Выберите новую категорию:")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	b.api.Send(msg)
+	return nil
+}
@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// handleRate показывает сегодняшние курсы валют пользователя. «/rate 100 USD» или
+// «/rate 100$» пересчитывает сумму в валюту учета
+func (b *Bot) handleRate(ctx context.Context, message *tgbotapi.Message) {
+	if args := strings.TrimSpace(message.CommandArguments()); args != "" {
+		b.convertAmount(ctx, message.Chat.ID, args)
+		return
+	}
+
+	rates, err := b.service.CurrentRates(ctx, message.From.ID)
+	if errors.Is(err, service.ErrRateProviderNotConfigured) {
+		b.sendErrorMessage(message.Chat.ID, "Курсы валют не подключены")
+		return
+	}
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить курсы валют")
+		return
+	}
+
+	text := fmt.Sprintf("💱 *Курсы ЦБ на %s*\n\n", rates.Date.Format("02.01.2006"))
+	for _, rate := range rates.Rates {
+		text += fmt.Sprintf("1 %s = %s", rate.Code, formatMoney(rate.Rate, baseCurrencyCode(rates.Base)))
+		switch {
+		case rate.Change >= 0.005:
+			text += fmt.Sprintf(" ▲ %.2f", rate.Change)
+		case rate.Change <= -0.005:
+			text += fmt.Sprintf(" ▼ %.2f", -rate.Change)
+		}
+		text += "\n"
+	}
+	text += "\nПокупки в валюте записывайте так: `/add 25$ такси` или `/add 25 USD такси` - " +
+		"сумма пересчитается по курсу на дату покупки"
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = "Markdown"
+	b.api.Send(msg)
+}
+
+// convertAmount пересчитывает сумму из аргументов /rate в валюту учета по сегодняшнему курсу
+func (b *Bot) convertAmount(ctx context.Context, chatID int64, args string) {
+	value, rest, _ := strings.Cut(args, " ")
+	amount, currency, err := service.ParseAmount(value)
+	if err == nil && currency == "" {
+		currency = strings.ToUpper(strings.TrimSpace(rest))
+	}
+	if err != nil || currency == "" {
+		b.sendErrorMessage(chatID, "Неверный формат. Используйте: /rate 100 USD или /rate 100$")
+		return
+	}
+
+	rate, err := b.service.ExchangeRate(ctx, currency, time.Now())
+	if errors.Is(err, service.ErrUnknownCurrency) {
+		b.sendErrorMessage(chatID, fmt.Sprintf("Курса %s нет у ЦБ", currency))
+		return
+	}
+	if err != nil {
+		b.sendServiceError(ctx, chatID, err, "Не удалось загрузить курсы валют")
+		return
+	}
+	b.api.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("%s = %s (курс %.4f)",
+		formatMoney(amount, currency), formatMoney(amount*rate, baseCurrencyCode(b.service.BaseCurrency())), rate)))
+}
+
+// formatMoney форматирует сумму в валюте; пустой код - валюта учета
+func formatMoney(amount float64, currency string) string {
+	if currency == "" {
+		return fmt.Sprintf("%.2f₽", amount)
+	}
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
+
+// baseCurrencyCode возвращает код валюты учета для formatMoney: рубли показываются значком
+func baseCurrencyCode(base string) string {
+	if base == "RUB" {
+		return ""
+	}
+	return base
+}
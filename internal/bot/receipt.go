@@ -0,0 +1,192 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/receipt"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// maxReceiptItemsShown - количество позиций чека в превью
+const maxReceiptItemsShown = 15
+
+// handleReceiptPhoto распознает QR-код на фотографии чека
+func (b *Bot) handleReceiptPhoto(ctx context.Context, message *tgbotapi.Message) error {
+	// Берем фотографию максимального размера
+	photo := message.Photo[len(message.Photo)-1]
+	data, err := b.downloadFile(photo.FileID)
+	if err != nil {
+		b.sendErrorMessage(message.Chat.ID, "Не удалось загрузить фотографию")
+		return fmt.Errorf("error downloading photo: %w", err)
+	}
+
+	qrRaw, err := receipt.DecodeImage(data)
+	if err != nil || !receipt.IsQR(qrRaw) {
+		b.sendErrorMessage(message.Chat.ID,
+			"Не удалось распознать QR-код чека. Попробуйте сфотографировать ближе или пришлите текст QR-кода")
+		return nil
+	}
+
+	return b.handleReceiptQR(ctx, message, qrRaw)
+}
+
+// handleReceiptQR получает чек по QR-коду и предлагает способы импорта
+func (b *Bot) handleReceiptQR(ctx context.Context, message *tgbotapi.Message, qrRaw string) error {
+	if _, err := receipt.ParseQR(qrRaw); err != nil {
+		b.sendErrorMessage(message.Chat.ID, "QR-код чека поврежден или неполон")
+		return nil
+	}
+
+	check, err := b.service.FetchReceipt(ctx, qrRaw)
+	if errors.Is(err, service.ErrReceiptProviderNotConfigured) {
+		b.sendErrorMessage(message.Chat.ID, "Импорт чеков не настроен")
+		return nil
+	}
+	if err != nil {
+		b.sendErrorMessage(message.Chat.ID, "Не удалось получить чек. Возможно, он еще не поступил в ФНС - попробуйте позже")
+		return fmt.Errorf("error fetching receipt: %w", err)
+	}
+
+	transactionType, err := check.TransactionType()
+	if err != nil {
+		b.sendErrorMessage(message.Chat.ID, "Чеки с таким видом операции не поддерживаются")
+		return nil
+	}
+
+	payload, err := json.Marshal(check)
+	if err != nil {
+		return fmt.Errorf("error encoding receipt: %w", err)
+	}
+
+	// Сохраняем чек в состоянии до выбора способа импорта
+	state := &model.UserState{
+		UserID:          message.From.ID,
+		TransactionType: transactionType,
+		AwaitingAction:  model.StateReceipt,
+		Payload:         string(payload),
+	}
+	if err := b.saveUserState(ctx, state); err != nil {
+		return fmt.Errorf("error saving user state: %w", err)
+	}
+
+	title, question := "🧾 Чек", "Как записать чек?"
+	if transactionType == model.TransactionIncome {
+		title, question = "↩️ Чек возврата", "Возврат будет записан доходом. Как записать чек?"
+	}
+	text := fmt.Sprintf("%s от %s\n", title, check.DateTime.Format("02.01.2006 15:04"))
+	if check.Seller != "" {
+		text += fmt.Sprintf("%s\n", check.Seller)
+	}
+	text += "\n"
+	for i, item := range check.Items {
+		if i == maxReceiptItemsShown {
+			text += fmt.Sprintf("... и еще %d поз.\n", len(check.Items)-maxReceiptItemsShown)
+			break
+		}
+		text += fmt.Sprintf("• %s: %.2f₽\n", item.Name, item.Sum)
+	}
+	text += fmt.Sprintf("\nИтого: %.2f₽\n\n%s", check.Total, question)
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("💳 Одной суммой", cbReceipt, "total"),
+			callbackButton("🧾 По позициям", cbReceipt, "split"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("✖️ Отмена", cbCancel),
+		),
+	)
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyMarkup = keyboard
+	b.api.Send(msg)
+	return nil
+}
+
+// handleReceiptCallback обрабатывает выбор способа импорта и категории для чека
+func (b *Bot) handleReceiptCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	chatID := callback.Message.Chat.ID
+	mode, categoryID := args.String(0), args.String(1)
+
+	state, err := b.expectState(ctx, callback.From.ID, model.StateReceipt)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		b.sendErrorMessage(chatID, "Чек устарел, пришлите QR-код еще раз")
+		return nil
+	}
+
+	var check model.Receipt
+	if err := json.Unmarshal([]byte(state.Payload), &check); err != nil {
+		return fmt.Errorf("error decoding receipt: %w", err)
+	}
+
+	switch {
+	case categoryID == "":
+		categories, err := b.service.GetCategories(ctx, callback.From.ID)
+		if err != nil {
+			return fmt.Errorf("error getting categories: %w", err)
+		}
+
+		text := "Выберите категорию для чека:"
+		if mode == "split" {
+			text = "Позиции будут распределены по категориям по названию.\nВыберите категорию для остальных позиций:"
+		}
+
+		emoji := "💸 "
+		if state.TransactionType == model.TransactionIncome {
+			emoji = "💰 "
+		}
+		var buttons [][]tgbotapi.InlineKeyboardButton
+		for _, cat := range model.ActiveCategories(categories) {
+			if cat.Type != state.TransactionType {
+				continue
+			}
+			buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+				callbackButton(emoji+cat.Name, cbReceipt, mode, cat.ID),
+			))
+		}
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton("✖️ Отмена", cbCancel),
+		))
+
+		msg := tgbotapi.NewMessage(chatID, text)
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+		b.api.Send(msg)
+		return nil
+
+	case mode == "total":
+		if err := b.service.ImportReceiptTotal(ctx, callback.From.ID, &check, categoryID); err != nil {
+			b.sendServiceError(ctx, chatID, err, "Ошибка при сохранении транзакции")
+			return nil
+		}
+		b.finishReceiptImport(ctx, callback, fmt.Sprintf("Чек на %.2f₽ записан! ✅", check.Total))
+
+	case mode == "split":
+		imported, err := b.service.ImportReceiptItems(ctx, callback.From.ID, &check, categoryID)
+		if err != nil {
+			b.sendServiceError(ctx, chatID, err, "Чек не записан, попробуйте еще раз")
+			return nil
+		}
+		b.finishReceiptImport(ctx, callback, fmt.Sprintf("Чек записан по категориям: %d ✅", imported))
+	}
+
+	return nil
+}
+
+// finishReceiptImport очищает состояние и показывает главное меню
+func (b *Bot) finishReceiptImport(ctx context.Context, callback *tgbotapi.CallbackQuery, text string) {
+	if err := b.deleteUserState(ctx, callback.From.ID); err != nil {
+		b.reportError(ctx, fmt.Errorf("error deleting user state: %w", err))
+	}
+
+	msg := tgbotapi.NewMessage(callback.Message.Chat.ID, text)
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+}
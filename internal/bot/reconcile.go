@@ -0,0 +1,231 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+const (
+	// maxReconcileButtons - максимальное количество кнопок для каждой из сторон сверки
+	maxReconcileButtons = 10
+	// maxStatementSize - максимальный размер файла выписки
+	maxStatementSize = 5 << 20
+)
+
+// reconcileSession - операции выписки без пары в боте, которые предложены кнопками ➕.
+// Кнопка передает только номер операции: описание в данные кнопки не помещается
+type reconcileSession struct {
+	Lines []service.StatementLine `json:"lines"`
+	Added []bool                  `json:"added"`
+}
+
+// downloadFile скачивает файл, присланный пользователем в Telegram
+func (b *Bot) downloadFile(fileID string) ([]byte, error) {
+	url, err := b.api.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file url: %w", err)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download file: status %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxStatementSize))
+}
+
+// handleDocument обрабатывает присланные файлы
+func (b *Bot) handleDocument(ctx context.Context, message *tgbotapi.Message) error {
+	var parse func(io.Reader) ([]service.StatementLine, error)
+	name := strings.ToLower(message.Document.FileName)
+	switch {
+	case strings.HasSuffix(name, ".csv"):
+		parse = service.ParseStatementCSV
+	case strings.HasSuffix(name, ".ofx"), strings.HasSuffix(name, ".qfx"):
+		parse = service.ParseOFX
+	case strings.HasSuffix(name, ".qif"):
+		parse = service.ParseQIF
+	default:
+		b.sendErrorMessage(message.Chat.ID,
+			"Поддерживаются выписки в форматах CSV (дата; сумма; описание) для сверки, OFX и QIF для импорта")
+		return nil
+	}
+
+	data, err := b.downloadFile(message.Document.FileID)
+	if err != nil {
+		b.sendErrorMessage(message.Chat.ID, "Не удалось загрузить файл")
+		return fmt.Errorf("error downloading statement: %w", err)
+	}
+
+	lines, err := parse(bytes.NewReader(data))
+	if err != nil {
+		b.sendErrorMessage(message.Chat.ID, fmt.Sprintf("Не удалось разобрать выписку: %v", err))
+		return nil
+	}
+
+	// CSV сверяется с записанными транзакциями, файлы OFX и QIF импортируются
+	if !strings.HasSuffix(name, ".csv") {
+		return b.startStatementImport(ctx, message, lines)
+	}
+
+	result, err := b.service.ReconcileStatement(ctx, message.From.ID, lines)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось сверить выписку")
+		return fmt.Errorf("error reconciling statement: %w", err)
+	}
+
+	return b.sendReconciliationResult(ctx, message.From.ID, message.Chat.ID, result)
+}
+
+// sendReconciliationResult отправляет итоги сверки с кнопками для выравнивания записей.
+// Операции для кнопок ➕ сохраняются в состоянии пользователя
+func (b *Bot) sendReconciliationResult(ctx context.Context, userID, chatID int64, result *service.ReconciliationResult) error {
+	text := fmt.Sprintf("🏦 *Сверка с выпиской за %s - %s*\n\n",
+		result.StartDate.Format("02.01.2006"), result.EndDate.Format("02.01.2006"))
+	text += fmt.Sprintf("✅ Совпало операций: *%d*\n", result.Matched)
+	text += fmt.Sprintf("➕ Нет в боте: *%d*\n", len(result.MissingInBot))
+	text += fmt.Sprintf("🗑 Нет в выписке: *%d*\n", len(result.MissingInBank))
+
+	if len(result.MissingInBot) == 0 && len(result.MissingInBank) == 0 {
+		text += "\nЗаписи бота полностью совпадают с выпиской 🎉"
+		msg := tgbotapi.NewMessage(chatID, text)
+		msg.ParseMode = "Markdown"
+		msg.ReplyMarkup = b.getMainKeyboard()
+		b.api.Send(msg)
+		return nil
+	}
+
+	if len(result.MissingInBot) > 0 {
+		missing := result.MissingInBot[:min(len(result.MissingInBot), maxReconcileButtons)]
+		session := reconcileSession{Lines: missing, Added: make([]bool, len(missing))}
+		if err := b.saveReconcileSession(ctx, userID, &session); err != nil {
+			return err
+		}
+	}
+
+	text += "\nНажмите ➕, чтобы добавить операцию из выписки, или 🗑, чтобы удалить лишнюю запись"
+
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	for i, line := range result.MissingInBot {
+		if i == maxReconcileButtons {
+			break
+		}
+		buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
+			callbackButton(
+				fmt.Sprintf("➕ %s %+.2f₽ %s", line.Date.Format("02.01"), line.Amount, line.Description),
+				cbReconcile, "add", i,
+			),
+		})
+	}
+	for i, t := range result.MissingInBank {
+		if i == maxReconcileButtons {
+			break
+		}
+		buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
+			callbackButton(
+				fmt.Sprintf("🗑 %s %+.2f₽ %s", t.Date.Format("02.01"), t.Amount, t.Description),
+				cbReconcile, "del", t.ID,
+			),
+		})
+	}
+	buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
+		callbackButton("« В меню", cbMenu),
+	})
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	b.api.Send(msg)
+	return nil
+}
+
+// saveReconcileSession сохраняет операции сверки в состоянии пользователя
+func (b *Bot) saveReconcileSession(ctx context.Context, userID int64, session *reconcileSession) error {
+	payload, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("error encoding reconciliation: %w", err)
+	}
+	state := &model.UserState{
+		UserID:         userID,
+		AwaitingAction: model.StateReconcile,
+		Payload:        string(payload),
+	}
+	if err := b.saveUserState(ctx, state); err != nil {
+		return fmt.Errorf("error saving user state: %w", err)
+	}
+	return nil
+}
+
+// handleReconcileCallback выполняет действие сверки: добавление или удаление операции
+func (b *Bot) handleReconcileCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	chatID := callback.Message.Chat.ID
+
+	if args.String(0) == "del" {
+		transactionID := args.String(1)
+		if err := b.service.DeleteTransaction(ctx, transactionID, callback.From.ID); err != nil {
+			return fmt.Errorf("error deleting transaction: %w", err)
+		}
+		b.api.Send(tgbotapi.NewMessage(chatID, "Лишняя запись удалена 🗑"))
+		return nil
+	}
+
+	// Аргументы: add <номер операции в сверке>
+	if args.String(0) != "add" {
+		return fmt.Errorf("invalid reconcile data: %v", args)
+	}
+	index, err := args.Int(1)
+	if err != nil {
+		return fmt.Errorf("invalid reconcile line: %w", err)
+	}
+
+	state, err := b.expectState(ctx, callback.From.ID, model.StateReconcile)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		b.sendErrorMessage(chatID, "Сверка устарела, пришлите выписку еще раз")
+		return nil
+	}
+	var session reconcileSession
+	if err := json.Unmarshal([]byte(state.Payload), &session); err != nil {
+		return fmt.Errorf("error decoding reconciliation: %w", err)
+	}
+	if index < 0 || index >= len(session.Lines) || len(session.Added) != len(session.Lines) {
+		return fmt.Errorf("invalid reconcile line: %d", index)
+	}
+	if session.Added[index] {
+		b.sendErrorMessage(chatID, "Эта операция уже добавлена")
+		return nil
+	}
+
+	line := session.Lines[index]
+	if err := b.service.AddStatementTransaction(ctx, callback.From.ID, line.Date, line.Amount, line.Description); err != nil {
+		b.sendServiceError(ctx, chatID, err, "Ошибка при сохранении транзакции")
+		return nil
+	}
+	// Повторное нажатие не должно записать операцию второй раз
+	session.Added[index] = true
+	if err := b.saveReconcileSession(ctx, callback.From.ID, &session); err != nil {
+		b.reportError(ctx, err)
+	}
+
+	b.api.Send(tgbotapi.NewMessage(chatID,
+		fmt.Sprintf("Операция %s на %.2f₽ добавлена из выписки ✅", line.Date.Format("02.01.2006"), line.Amount)))
+	return nil
+}
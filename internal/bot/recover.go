@@ -0,0 +1,155 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/sentry"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// reportTimeout ограничивает отправку ошибки в трекер: контекст обновления к этому
+// моменту часто уже истек, а ответ пользователю не должен ждать трекер долго
+const reportTimeout = 5 * time.Second
+
+// ErrorReporter отправляет ошибки и паники обработчиков во внешний трекер ошибок
+type ErrorReporter interface {
+	Capture(ctx context.Context, event sentry.Event) error
+}
+
+type updateKey struct{}
+
+// SetErrorReporter подключает трекер ошибок
+func (b *Bot) SetErrorReporter(reporter ErrorReporter) {
+	b.errors = reporter
+}
+
+// processUpdate обрабатывает обновление, перехватывая паники: одно сломанное обновление
+// не должно останавливать цикл long polling или превращаться в ответ 500 функции,
+// на который Telegram ответит повторной доставкой
+func (b *Bot) processUpdate(ctx context.Context, update tgbotapi.Update) (err error) {
+	// Медленные запросы к базе не должны подвешивать обработку обновления
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+	ctx = context.WithValue(ctx, updateKey{}, update)
+	ctx = service.WithLedgerScope(ctx)
+
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			log.Printf("Panic handling update %d: %v\n%s", update.UpdateID, r, stack)
+			b.capture(ctx, fmt.Errorf("panic: %v", r), stack)
+			b.sendPanicFallback(update)
+			err = nil
+		}
+	}()
+
+	if err := b.handleUpdate(ctx, update); err != nil {
+		b.capture(ctx, err, nil)
+		return err
+	}
+	return nil
+}
+
+// reportError логирует ошибку, после которой обработка продолжается, и отправляет ее в трекер
+func (b *Bot) reportError(ctx context.Context, err error) {
+	log.Printf("Error handling update: %v", err)
+	b.capture(ctx, err, nil)
+}
+
+// capture отправляет ошибку в трекер вместе с пользователем, командой и текстом обновления
+func (b *Bot) capture(ctx context.Context, err error, stack []byte) {
+	if b.errors == nil {
+		return
+	}
+
+	event := sentry.Event{Err: err, Stack: stack}
+	if update, ok := ctx.Value(updateKey{}).(tgbotapi.Update); ok {
+		event.UserID, event.Command, event.Payload = describeUpdate(update)
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), reportTimeout)
+	defer cancel()
+	if err := b.errors.Capture(ctx, event); err != nil {
+		log.Printf("Error reporting error: %v", err)
+	}
+}
+
+// describeUpdate возвращает автора обновления, команду или данные кнопки и текст
+func describeUpdate(update tgbotapi.Update) (userID int64, command, payload string) {
+	switch {
+	case update.Message != nil:
+		if update.Message.From != nil {
+			userID = update.Message.From.ID
+		}
+		if update.Message.IsCommand() {
+			command = "/" + update.Message.Command()
+		}
+		payload = update.Message.Text
+		if payload == "" && update.Message.Document != nil {
+			payload = update.Message.Document.FileName
+		}
+	case update.CallbackQuery != nil:
+		userID, command = update.CallbackQuery.From.ID, update.CallbackQuery.Data
+	case update.InlineQuery != nil:
+		userID, payload = update.InlineQuery.From.ID, update.InlineQuery.Query
+	case update.ChosenInlineResult != nil:
+		userID, payload = update.ChosenInlineResult.From.ID, update.ChosenInlineResult.Query
+	}
+	return userID, command, payload
+}
+
+// sendPanicFallback извиняется перед пользователем и предлагает повторить действие
+func (b *Bot) sendPanicFallback(update tgbotapi.Update) {
+	// Паника при отправке не должна повторно уронить обработку
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic sending error message: %v", r)
+		}
+	}()
+
+	_, chatID := updateSender(update)
+	if chatID == 0 {
+		return
+	}
+
+	retry, ok := retryButton(update)
+	if !ok {
+		retry = callbackButton("🏠 Главное меню", cbMenu)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "😔 Что-то пошло не так. Мы уже разбираемся - попробуйте еще раз")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(retry))
+	b.api.Send(msg)
+}
+
+// retryButton - кнопка, повторяющая нажатую кнопку или команду. Для остальных обновлений
+// повторять нечего
+func retryButton(update tgbotapi.Update) (tgbotapi.InlineKeyboardButton, bool) {
+	switch {
+	case update.CallbackQuery != nil:
+		return tgbotapi.NewInlineKeyboardButtonData("🔄 Попробовать снова", update.CallbackQuery.Data), true
+	case update.Message != nil && update.Message.IsCommand():
+		if data, ok := encodeCallback(cbRetry, update.Message.Command()); ok {
+			return tgbotapi.NewInlineKeyboardButtonData("🔄 Попробовать снова", data), true
+		}
+	}
+	return tgbotapi.InlineKeyboardButton{}, false
+}
+
+// handleRetryCallback повторяет команду, обработка которой завершилась ошибкой
+func (b *Bot) handleRetryCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	command := "/" + args.String(0)
+	return b.handleCommand(ctx, &tgbotapi.Message{
+		From: callback.From,
+		Chat: callback.Message.Chat,
+		Text: command,
+		Entities: []tgbotapi.MessageEntity{
+			{Type: "bot_command", Offset: 0, Length: len(command)},
+		},
+	})
+}
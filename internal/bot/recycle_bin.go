@@ -0,0 +1,124 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// handleRecycleBinCallback возвращает транзакцию или категорию из корзины или показывает корзину
+func (b *Bot) handleRecycleBinCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	chatID := callback.Message.Chat.ID
+	message := callbackMessage(callback)
+
+	switch args.String(0) {
+	case "tx":
+		transaction, err := b.service.RestoreTransaction(ctx, callback.From.ID, args.String(1))
+		if errors.Is(err, service.ErrCategoryDeleted) {
+			b.sendErrorMessage(chatID, "Категория этой транзакции тоже в корзине. Сначала восстановите категорию")
+			return nil
+		}
+		if err != nil {
+			b.sendServiceError(ctx, chatID, err, "Не удалось восстановить транзакцию")
+			return fmt.Errorf("error restoring transaction: %w", err)
+		}
+		b.api.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("♻️ Транзакция на %.2f₽ восстановлена", transaction.AbsAmount())))
+	case "cat":
+		category, err := b.service.RestoreCategory(ctx, callback.From.ID, args.String(1))
+		if errors.Is(err, service.ErrCategoryExists) {
+			b.sendErrorMessage(chatID, "Категория с таким названием уже есть. Переименуйте ее, чтобы вернуть удаленную")
+			return nil
+		}
+		if text, ok := categoryErrorText(err); ok {
+			b.sendErrorMessage(chatID, text)
+			return nil
+		}
+		if err != nil {
+			b.sendServiceError(ctx, chatID, err, "Не удалось восстановить категорию")
+			return fmt.Errorf("error restoring category: %w", err)
+		}
+		text := fmt.Sprintf("♻️ Категория «%s» восстановлена", category.Name)
+		if category.Transactions > 0 {
+			text += fmt.Sprintf(" вместе с транзакциями: %d", category.Transactions)
+		}
+		b.api.Send(tgbotapi.NewMessage(chatID, text))
+	}
+	b.handleRecycleBin(ctx, message)
+	return nil
+}
+
+// handleRecycleBin показывает корзину: категории и транзакции, удаленные
+// за последние service.RecycleBinDays дней, с кнопками восстановления
+func (b *Bot) handleRecycleBin(ctx context.Context, message *tgbotapi.Message) {
+	bin, err := b.service.GetRecycleBin(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось открыть корзину")
+		return
+	}
+	categories, err := b.service.GetCategories(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить категории")
+		return
+	}
+	for _, cat := range bin.Categories {
+		categories = append(categories, cat.Category)
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, cat := range bin.Categories {
+		title := fmt.Sprintf("♻️ %s %s", cat.Icon(), cat.Name)
+		if cat.Transactions > 0 {
+			title += fmt.Sprintf(" (+%d тр.)", cat.Transactions)
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			callbackButton(title, cbRecycleBin, "cat", cat.ID),
+		))
+	}
+	for _, t := range bin.Transactions {
+		transactionType := model.TransactionExpense
+		if t.IsIncome() {
+			transactionType = model.TransactionIncome
+		}
+		icon, name := model.TypeIcon(transactionType), "Без категории"
+		if t.IsSplit {
+			icon, name = "🔀", "Несколько категорий"
+		} else if category := findCategory(categories, t.CategoryID); category != nil {
+			icon, name = category.Icon(), category.Name
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			callbackButton(fmt.Sprintf("♻️ %s %s: %.2f₽ · %s", icon, name, t.AbsAmount(), t.Date.Format("02.01")),
+				cbRecycleBin, "tx", t.ID),
+		))
+	}
+
+	text := "*🗑 Корзина*\n\n"
+	if bin.Empty() {
+		text += fmt.Sprintf("Корзина пуста. Удаленные транзакции и категории хранятся здесь %d дней", service.RecycleBinDays)
+	} else {
+		text += fmt.Sprintf("Удаленные транзакции и категории хранятся %d дней, потом удаляются безвозвратно.\n\n", service.RecycleBinDays) +
+			"♻️ - восстановить. Категория возвращается вместе с транзакциями, удаленными с ней"
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		callbackButton("« Назад", cbMenu),
+	))
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.api.Send(msg)
+}
+
+// sendMovedToRecycleBin сообщает, что запись в корзине, и предлагает сразу ее вернуть.
+// kind - tx или cat, как в аргументах cbRecycleBin
+func (b *Bot) sendMovedToRecycleBin(chatID int64, text, kind, id string) {
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🗑 %s в корзине. Вернуть можно в течение %d дней", text, service.RecycleBinDays))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		callbackButton("♻️ Восстановить", cbRecycleBin, kind, id),
+		callbackButton("🗑 Корзина", cbRecycleBin),
+	))
+	b.api.Send(msg)
+}
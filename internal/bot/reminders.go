@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// reminderExamples - подсказка о формате напоминаний
+const reminderExamples = "`/remind каждый день в 21:00 записать траты`\n" +
+	"`/remind 15 числа — оплатить интернет`\n" +
+	"`/remind по пятницам в 18:00 отложить на цель`"
+
+// handleRemind создает напоминание из аргументов команды, без аргументов - показывает напоминания
+func (b *Bot) handleRemind(ctx context.Context, message *tgbotapi.Message) {
+	text := strings.TrimSpace(message.CommandArguments())
+	if text == "" {
+		b.showReminders(ctx, message)
+		return
+	}
+
+	reminder, err := b.service.CreateReminder(ctx, message.From.ID, text)
+	switch {
+	case errors.Is(err, service.ErrInvalidReminder):
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Не удалось разобрать напоминание. Примеры:\n"+reminderExamples)
+		msg.ParseMode = "Markdown"
+		b.api.Send(msg)
+		return
+	case errors.Is(err, service.ErrTooManyReminders):
+		b.sendErrorMessage(message.Chat.ID, "Слишком много напоминаний. Удалите ненужные в /remind")
+		return
+	case err != nil:
+		b.sendErrorMessage(message.Chat.ID, "Не удалось сохранить напоминание")
+		return
+	}
+
+	b.api.Send(tgbotapi.NewMessage(message.Chat.ID,
+		fmt.Sprintf("⏰ Напомню %s: %s", reminder.Describe(), reminder.Text)))
+}
+
+// showReminders показывает напоминания пользователя с кнопками удаления
+func (b *Bot) showReminders(ctx context.Context, message *tgbotapi.Message) {
+	reminders, err := b.service.GetReminders(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить напоминания")
+		return
+	}
+
+	text := "⏰ *Напоминания*\n\n"
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if len(reminders) == 0 {
+		text += "Напоминаний пока нет.\n"
+	}
+	for i, reminder := range reminders {
+		text += fmt.Sprintf("%d. %s: %s\n", i+1, reminder.Describe(), reminder.Text)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			callbackButton(fmt.Sprintf("🗑 %d. %s", i+1, reminder.Text), cbReminder, "del", reminder.ID),
+		))
+	}
+	text += "\nНовое напоминание:\n" + reminderExamples
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = "Markdown"
+	if len(rows) > 0 {
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+	b.api.Send(msg)
+}
+
+// handleReminderCallback удаляет напоминание
+func (b *Bot) handleReminderCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	if args.String(0) != "del" {
+		return nil
+	}
+	if err := b.service.DeleteReminder(ctx, callback.From.ID, args.String(1)); err != nil {
+		b.sendServiceError(ctx, callback.Message.Chat.ID, err, "Не удалось удалить напоминание")
+		return fmt.Errorf("error deleting reminder: %w", err)
+	}
+	b.api.Send(tgbotapi.NewMessage(callback.Message.Chat.ID, "🗑 Напоминание удалено"))
+	b.showReminders(ctx, callbackMessage(callback))
+	return nil
+}
+
+// SendScheduledReminder отправляет напоминание, созданное пользователем в /remind
+func (b *Bot) SendScheduledReminder(ctx context.Context, reminder *model.Reminder) error {
+	msg := tgbotapi.NewMessage(reminder.UserID, "⏰ "+reminder.Text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("💸 Добавить расход", cbAdd, "expense"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("🔕 Больше не напоминать", cbReminder, "del", reminder.ID),
+		),
+	)
+	_, err := b.api.Send(msg)
+	return err
+}
@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/charts"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// Виды графиков в меню выбора
+const (
+	chartKindAll       = "all"
+	chartKindDashboard = "dash"
+	chartKindCategory  = "cat"
+	chartKindTrend     = "trend"
+	chartKindBalance   = "bal"
+	chartKindBudget    = "budget"
+	chartKindWeek      = "week"
+	chartKindHours     = "hours"
+)
+
+// reportChart - график отчета
+type reportChart struct {
+	kind     string
+	period   service.ReportType // за какой период строится отчет для графика
+	name     string             // имя файла без расширения
+	generate func(*charts.ChartGenerator, *service.BaseReport) ([]byte, error)
+	caption  func(*service.BaseReport) string
+}
+
+// reportCharts - графики отчетов. Графики месячного отчета идут в порядке альбома «Все графики»
+var reportCharts = []reportChart{
+	{chartKindDashboard, service.MonthlyReport, "1_dashboard", (*charts.ChartGenerator).GenerateFinancialDashboard, dashboardInsight},
+	{chartKindCategory, service.MonthlyReport, "2_expenses", func(g *charts.ChartGenerator, r *service.BaseReport) ([]byte, error) {
+		return g.GenerateCategoryPieChart(r, true)
+	}, expensesInsight},
+	{chartKindCategory, service.MonthlyReport, "3_income", func(g *charts.ChartGenerator, r *service.BaseReport) ([]byte, error) {
+		return g.GenerateCategoryPieChart(r, false)
+	}, incomeInsight},
+	{chartKindTrend, service.MonthlyReport, "4_trends", (*charts.ChartGenerator).GenerateTrendChart, trendsInsight},
+	{chartKindBalance, service.MonthlyReport, "5_balance", (*charts.ChartGenerator).GenerateBalanceChart, balanceInsight},
+	{chartKindHours, service.MonthlyReport, "6_hours", (*charts.ChartGenerator).GenerateHourlyChart, hoursInsight},
+	{chartKindWeek, service.WeeklyReport, "week", (*charts.ChartGenerator).GenerateWeeklyColumnChart, weekInsight},
+}
+
+// absoluteTrendChart заменяет график отклонений в процентах графиком расходов в рублях
+// с линией бюджета. Если бюджеты не загрузились, график строится без линии
+func (b *Bot) absoluteTrendChart(ctx context.Context, userID int64, chart reportChart) reportChart {
+	dailyBudget, err := b.service.DailyBudget(ctx, userID)
+	if err != nil {
+		b.reportError(ctx, fmt.Errorf("error getting daily budget: %w", err))
+	}
+	chart.generate = func(g *charts.ChartGenerator, r *service.BaseReport) ([]byte, error) {
+		return g.GenerateAbsoluteTrendChart(r, dailyBudget)
+	}
+	chart.caption = func(r *service.BaseReport) string {
+		return absoluteTrendsInsight(r, dailyBudget)
+	}
+	return chart
+}
+
+// chartPeriod возвращает период отчета, по которому строятся графики вида kind
+func chartPeriod(kind string) service.ReportType {
+	for _, chart := range reportCharts {
+		if chart.kind == kind {
+			return chart.period
+		}
+	}
+	return service.MonthlyReport
+}
+
+// sendChartPicker предлагает выбрать график: строить все сразу долго и тяжело для мобильного интернета
+func (b *Bot) sendChartPicker(chatID int64) {
+	msg := tgbotapi.NewMessage(chatID, "📈 Какой график построить?")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("📊 Доходы и расходы", cbCharts, chartKindDashboard),
+			callbackButton("🛒 Категории", cbCharts, chartKindCategory),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("📈 Тренды", cbCharts, chartKindTrend),
+			callbackButton("⚖️ Баланс", cbCharts, chartKindBalance),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("📉 Бюджеты", cbCharts, chartKindBudget),
+			callbackButton("📅 Неделя по дням", cbCharts, chartKindWeek),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("🕐 По часам", cbCharts, chartKindHours),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("🗂 Все графики месяца", cbCharts, chartKindAll),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("« В меню", cbMenu),
+		),
+	)
+	b.api.Send(msg)
+}
+
+// maxMediaGroup - больше файлов Telegram в один альбом не принимает
+const maxMediaGroup = 10
+
+// sendBudgetCharts отправляет графики расходования всех бюджетов
+func (b *Bot) sendBudgetCharts(ctx context.Context, chatID, userID int64) error {
+	statuses, err := b.service.GetBudgetStatuses(ctx, userID)
+	if err != nil {
+		b.sendServiceError(ctx, chatID, err, "Не удалось загрузить бюджеты")
+		return nil
+	}
+	if len(statuses) == 0 {
+		b.api.Send(tgbotapi.NewMessage(chatID, "Бюджетов пока нет. Их можно установить из рекомендаций: /advice"))
+		return nil
+	}
+
+	delivery := b.chartDelivery(ctx, userID)
+	rest := len(statuses) - maxMediaGroup
+	if rest > 0 {
+		statuses = statuses[:maxMediaGroup]
+	}
+	var media []interface{}
+	for i, status := range statuses {
+		data, err := delivery.charts.GenerateBudgetBurndownChart(status)
+		if err != nil {
+			return fmt.Errorf("error generating budget chart: %w", err)
+		}
+		caption := fmt.Sprintf("📉 *%s*: потрачено %.0f₽ из %.0f₽", status.CategoryName, status.Spent, status.Available())
+		if len(statuses) == 1 {
+			b.api.Send(delivery.captioned(chatID, "budget", data, caption))
+			return nil
+		}
+		media = append(media, delivery.media(fmt.Sprintf("budget_%d", i+1), data, caption))
+	}
+	if _, err := b.api.SendMediaGroup(tgbotapi.NewMediaGroup(chatID, media)); err != nil {
+		return fmt.Errorf("error sending budget charts: %w", err)
+	}
+	if rest > 0 {
+		b.api.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("Графики еще %d бюджетов - кнопками в /budgets", rest)))
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// reportSectionTitles - названия разделов отчета на кнопках конструктора
+var reportSectionTitles = map[string]string{
+	model.ReportSectionStats:      "📊 Статистика транзакций",
+	model.ReportSectionLargest:    "💎 Крупнейшие транзакции",
+	model.ReportSectionCategories: "🏷 Топ категорий",
+	model.ReportSectionChanges:    "📈 Изменения по категориям",
+	model.ReportSectionPrices:     "🏷 Где я переплатил",
+}
+
+const reportSectionsText = "🧩 *Разделы отчета*\n\n" +
+	"Нажмите на раздел, чтобы включить или выключить его, ⬆️ поднимает раздел выше.\n" +
+	"Разделы показываются в подробном отчете, а в ежедневной сводке - после того, как вы настроите их здесь"
+
+// handleReportSettings показывает конструктор отчета: какие разделы показывать и в каком порядке
+func (b *Bot) handleReportSettings(ctx context.Context, message *tgbotapi.Message) {
+	settings, err := b.service.GetUserSettings(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить настройки")
+		return
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, reportSectionsText)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = reportSectionsKeyboard(settings.Sections())
+	b.api.Send(msg)
+}
+
+// handleReportSectionsCallback включает, выключает и поднимает разделы отчета.
+// Сообщение редактируется на месте, чтобы отчет можно было собрать несколькими нажатиями
+func (b *Bot) handleReportSectionsCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	settings, err := b.service.GetUserSettings(ctx, callback.From.ID)
+	if err != nil {
+		return fmt.Errorf("error getting settings: %w", err)
+	}
+	var sections []string
+	switch args.String(0) {
+	case "":
+		b.handleReportSettings(ctx, callbackMessage(callback))
+		return nil
+	case "toggle":
+		sections = toggleReportSection(settings.Sections(), args.String(1))
+	case "up":
+		sections = raiseReportSection(settings.Sections(), args.String(1))
+	case "reset":
+		// nil возвращает разделы по умолчанию
+	default:
+		return fmt.Errorf("unknown report sections action: %s", callback.Data)
+	}
+	if settings, err = b.service.SetReportSections(ctx, callback.From.ID, sections); err != nil {
+		return fmt.Errorf("error saving report sections: %w", err)
+	}
+
+	msg := tgbotapi.NewEditMessageTextAndMarkup(callback.Message.Chat.ID, callback.Message.MessageID,
+		reportSectionsText, reportSectionsKeyboard(settings.Sections()))
+	msg.ParseMode = "Markdown"
+	b.api.Send(msg)
+	return nil
+}
+
+// reportSectionsKeyboard - включенные разделы по порядку с кнопкой подъема, под ними выключенные
+func reportSectionsKeyboard(sections []string) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	enabled := make(map[string]bool, len(sections))
+	for i, section := range sections {
+		enabled[section] = true
+		row := tgbotapi.NewInlineKeyboardRow(callbackButton(
+			fmt.Sprintf("✅ %d. %s", i+1, reportSectionTitles[section]), cbReportSections, "toggle", section))
+		if i > 0 {
+			row = append(row, callbackButton("⬆️", cbReportSections, "up", section))
+		}
+		rows = append(rows, row)
+	}
+	for _, section := range model.DefaultReportSections {
+		if !enabled[section] {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(callbackButton(
+				"⬜ "+reportSectionTitles[section], cbReportSections, "toggle", section)))
+		}
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(callbackButton("↩️ Как было", cbReportSections, "reset")),
+		tgbotapi.NewInlineKeyboardRow(callbackButton("« К настройкам", cbSettings)),
+	)
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// toggleReportSection выключает включенный раздел или добавляет выключенный в конец отчета.
+// Пустой, но не nil результат - отчет без разделов
+func toggleReportSection(sections []string, section string) []string {
+	toggled := make([]string, 0, len(sections)+1)
+	for _, s := range sections {
+		if s != section {
+			toggled = append(toggled, s)
+		}
+	}
+	if len(toggled) == len(sections) {
+		toggled = append(toggled, section)
+	}
+	return toggled
+}
+
+// raiseReportSection поднимает раздел на одну позицию
+func raiseReportSection(sections []string, section string) []string {
+	raised := append([]string{}, sections...)
+	for i := 1; i < len(raised); i++ {
+		if raised[i] == section {
+			raised[i-1], raised[i] = raised[i], raised[i-1]
+			break
+		}
+	}
+	return raised
+}
@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/ivanoskov/financial_bot/internal/locale"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// Шаблоны текста отчетов: <отчет>.<язык>.tmpl. Для языка без шаблона используется язык по умолчанию
+//
+//go:embed templates/*.tmpl
+var reportTemplateFiles embed.FS
+
+var reportTemplates = template.Must(template.New("").Funcs(template.FuncMap{
+	"money":   func(v float64) string { return fmt.Sprintf("%.0f₽", v) },
+	"money2":  func(v float64) string { return fmt.Sprintf("%.2f₽", v) },
+	"percent": func(v float64) string { return fmt.Sprintf("%.1f%%", v) },
+	"change":  formatChange,
+}).ParseFS(reportTemplateFiles, "templates/*.tmpl"))
+
+// reportView - данные шаблона отчета. Разделы, которые собираются отдельными
+// функциями форматирования, передаются готовым текстом
+type reportView struct {
+	*service.BaseReport
+	Compact  bool     // краткий вид отчета из настроек
+	Sections []string // разделы подробного отчета по порядку
+	Members  string   // вклад участников общего бюджета
+	Accounts string   // остатки по счетам
+	Advice   string   // рекомендации месячного отчета
+	Plan     string   // план и факт месячного отчета
+	Night    string   // границы ночи для ночных покупок месячного отчета, пусто - без раздела
+	Budget   string   // остаток бюджетов для компактной сводки за день
+}
+
+// renderReport формирует текст отчета по шаблону name на языке пользователя
+func renderReport(ctx context.Context, name string, view reportView) (string, error) {
+	tmpl := reportTemplates.Lookup(name + "." + locale.FromContext(ctx) + ".tmpl")
+	if tmpl == nil {
+		tmpl = reportTemplates.Lookup(name + "." + locale.Default + ".tmpl")
+	}
+	if tmpl == nil {
+		return "", fmt.Errorf("report template %q not found", name)
+	}
+
+	var text strings.Builder
+	if err := tmpl.Execute(&text, view); err != nil {
+		return "", fmt.Errorf("failed to render %s report: %w", name, err)
+	}
+	return strings.TrimSpace(text.String()), nil
+}
+
+// formatBudgetToday описывает остаток бюджетов одной строкой для компактной сводки
+func formatBudgetToday(budget *service.BudgetToday) string {
+	switch {
+	case budget.Remaining < 0:
+		return fmt.Sprintf("⚠️ Бюджеты превышены на %.0f₽", -budget.Remaining)
+	case budget.Remaining == 0:
+		return "🎯 Бюджеты на месяц исчерпаны"
+	}
+	return fmt.Sprintf("🎯 На сегодня: %.0f₽, до конца месяца: %.0f₽", budget.PerDay, budget.Remaining)
+}
+
+// formatChange показывает изменение к прошлому периоду: " (+12.5%⬆️)", пусто без изменений
+func formatChange(percent float64) string {
+	switch {
+	case percent > 0:
+		return fmt.Sprintf(" (+%.1f%%⬆️)", percent)
+	case percent < 0:
+		return fmt.Sprintf(" (%.1f%%⬇️)", percent)
+	}
+	return ""
+}
@@ -0,0 +1,248 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"reflect"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Ограничения Telegram на отправку сообщений
+const (
+	// globalSendRate - сообщений в секунду во все чаты
+	globalSendRate = 30.0
+	// chatSendRate - сообщений в секунду в один личный чат
+	chatSendRate = 1.0
+	// groupSendRate - сообщений в секунду в одну группу (20 в минуту)
+	groupSendRate = 20.0 / 60
+	// chatSendBurst - сколько сообщений подряд можно отправить в чат без ожидания:
+	// ответ на действие часто состоит из текста и пары графиков
+	chatSendBurst = 3.0
+	// maxSendRetries - повторы запроса после ответа 429
+	maxSendRetries = 3
+	// maxRetryAfter - дольше ждать не имеет смысла: функция может не дождаться
+	maxRetryAfter = 30 * time.Second
+)
+
+// rateLimitedAPI отправляет запросы к Telegram через очередь с учетом ограничений
+// на чат и на бота в целом, а при ответе 429 ждет указанное в retry_after время и повторяет
+// запрос. Без этого рассылки отчетов большому числу пользователей упираются в лимиты
+type rateLimitedAPI struct {
+	Transport
+	limiter *sendLimiter // nil - без ограничений, например для fake.Transport
+}
+
+func newRateLimitedAPI(api *tgbotapi.BotAPI) *rateLimitedAPI {
+	return &rateLimitedAPI{Transport: api, limiter: newSendLimiter()}
+}
+
+// Request выполняет запрос, дождавшись своей очереди
+func (a *rateLimitedAPI) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	return a.RequestContext(context.Background(), c)
+}
+
+// RequestContext выполняет запрос, дождавшись своей очереди. Ожидание очереди и повтора
+// после ответа 429 прерывается отменой ctx: тогда возвращается ctx.Err()
+func (a *rateLimitedAPI) RequestContext(ctx context.Context, c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	chatID := chatIDOf(c)
+	for attempt := 0; ; attempt++ {
+		if chatID != 0 && a.limiter != nil {
+			if err := a.limiter.wait(ctx, chatID); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := a.Transport.Request(c)
+		var apiErr *tgbotapi.Error
+		if err == nil || !errors.As(err, &apiErr) || apiErr.Code != 429 || attempt == maxSendRetries {
+			return resp, err
+		}
+
+		retryAfter := time.Duration(apiErr.RetryAfter) * time.Second
+		if retryAfter > maxRetryAfter {
+			return resp, fmt.Errorf("telegram rate limit, retry after %s: %w", retryAfter, err)
+		}
+		log.Printf("Telegram rate limit for chat %d, retrying in %s", chatID, retryAfter)
+		if chatID != 0 && a.limiter != nil {
+			// Очередь чата сдвигается, и повтор дождется ее вместе с остальными отправками
+			a.limiter.pause(chatID, retryAfter)
+		} else if err := sleep(ctx, retryAfter); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Send отправляет сообщение через очередь
+func (a *rateLimitedAPI) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	return a.SendContext(context.Background(), c)
+}
+
+// SendContext отправляет сообщение через очередь, пока не отменен ctx
+func (a *rateLimitedAPI) SendContext(ctx context.Context, c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	resp, err := a.RequestContext(ctx, c)
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+	var message tgbotapi.Message
+	err = json.Unmarshal(resp.Result, &message)
+	return message, err
+}
+
+// SendMediaGroup отправляет альбом через очередь
+func (a *rateLimitedAPI) SendMediaGroup(config tgbotapi.MediaGroupConfig) ([]tgbotapi.Message, error) {
+	resp, err := a.Request(config)
+	if err != nil {
+		return nil, err
+	}
+	var messages []tgbotapi.Message
+	err = json.Unmarshal(resp.Result, &messages)
+	return messages, err
+}
+
+// contextSender - API, ожидание очереди которого прерывается отменой контекста
+type contextSender interface {
+	SendContext(ctx context.Context, c tgbotapi.Chattable) (tgbotapi.Message, error)
+}
+
+// sendContext отправляет сообщение через api, прерывая ожидание очереди отменой ctx.
+// API без очереди, например mocks.TelegramAPI, отправляет как есть
+func sendContext(ctx context.Context, api TelegramAPI, c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	if sender, ok := api.(contextSender); ok {
+		return sender.SendContext(ctx, c)
+	}
+	return api.Send(c)
+}
+
+// sleep ждет d или отмены ctx
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// chatIDOf возвращает чат, в который адресован запрос, или 0 для запросов без чата
+// (ответы на нажатия кнопок, inline-запросы, настройка команд)
+func chatIDOf(c tgbotapi.Chattable) int64 {
+	v := reflect.Indirect(reflect.ValueOf(c))
+	if v.Kind() != reflect.Struct {
+		return 0
+	}
+	for _, name := range []string{"BaseChat", "BaseEdit"} {
+		if base := v.FieldByName(name); base.IsValid() && base.Kind() == reflect.Struct {
+			v = base
+			break
+		}
+	}
+	if id := v.FieldByName("ChatID"); id.IsValid() && id.Kind() == reflect.Int64 {
+		return id.Int()
+	}
+	return 0
+}
+
+// sendLimiter распределяет отправки во времени по алгоритму token bucket:
+// вызывающий резервирует место в очереди и ждет своей очереди
+type sendLimiter struct {
+	mu     sync.Mutex
+	global tokenBucket
+	chats  map[int64]*tokenBucket
+	now    func() time.Time // часы, в тестах подменяются
+}
+
+func newSendLimiter() *sendLimiter {
+	return newSendLimiterWithClock(time.Now)
+}
+
+func newSendLimiterWithClock(now func() time.Time) *sendLimiter {
+	return &sendLimiter{
+		global: tokenBucket{tokens: globalSendRate, last: now()},
+		chats:  make(map[int64]*tokenBucket),
+		now:    now,
+	}
+}
+
+// wait блокируется, пока отправка в чат не уложится в ограничения или не отменится ctx.
+// Место в очереди при отмене не возвращается: следующие отправки подождут чуть дольше
+func (l *sendLimiter) wait(ctx context.Context, chatID int64) error {
+	if delay := l.reserve(chatID); delay > 0 {
+		return sleep(ctx, delay)
+	}
+	return nil
+}
+
+func (l *sendLimiter) reserve(chatID int64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	chat := l.chats[chatID]
+	if chat == nil {
+		l.forgetIdle(now)
+		chat = &tokenBucket{tokens: chatSendBurst, last: now}
+		l.chats[chatID] = chat
+	}
+
+	globalDelay := l.global.take(now, globalSendRate, globalSendRate)
+	chatDelay := chat.take(now, chatRate(chatID), chatSendBurst)
+	return time.Duration(math.Max(float64(globalDelay), float64(chatDelay)))
+}
+
+// pause откладывает следующие отправки в чат после ответа 429 на время d
+func (l *sendLimiter) pause(chatID int64, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if chat := l.chats[chatID]; chat != nil {
+		chat.tokens, chat.last = 1-d.Seconds()*chatRate(chatID), l.now()
+	}
+}
+
+// chatRate возвращает допустимую скорость отправки в чат: в группы (отрицательный ID) реже
+func chatRate(chatID int64) float64 {
+	if chatID < 0 {
+		return groupSendRate
+	}
+	return chatSendRate
+}
+
+// forgetIdle удаляет чаты, в которые давно ничего не отправлялось, чтобы очередь не росла
+func (l *sendLimiter) forgetIdle(now time.Time) {
+	const idle = time.Minute
+	if len(l.chats) < 1000 {
+		return
+	}
+	for id, chat := range l.chats {
+		if now.Sub(chat.last) > idle {
+			delete(l.chats, id)
+		}
+	}
+}
+
+// tokenBucket - запас отправок, пополняемый с постоянной скоростью
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take забирает одну отправку и возвращает время ожидания, если запас исчерпан.
+// Запас может уйти в минус: так следующие вызовы встают в очередь за текущим
+func (b *tokenBucket) take(now time.Time, rate, burst float64) time.Duration {
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}
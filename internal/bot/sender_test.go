@@ -0,0 +1,148 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// testClock - часы, которые идут только по команде теста
+type testClock struct{ now time.Time }
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func (c *testClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestClock() *testClock {
+	return &testClock{now: time.Date(2026, time.March, 2, 12, 0, 0, 0, time.UTC)}
+}
+
+func TestTokenBucketTake(t *testing.T) {
+	tests := []struct {
+		name       string
+		tokens     float64
+		elapsed    time.Duration
+		rate       float64
+		burst      float64
+		wantDelay  time.Duration
+		wantTokens float64
+	}{
+		{"полный запас", chatSendBurst, 0, chatSendRate, chatSendBurst, 0, 2},
+		{"последняя отправка", 1, 0, chatSendRate, chatSendBurst, 0, 0},
+		{"запас исчерпан", 0, 0, chatSendRate, chatSendBurst, time.Second, -1},
+		{"очередь за предыдущей", -1, 0, chatSendRate, chatSendBurst, 2 * time.Second, -2},
+		{"частичное пополнение", 0, 500 * time.Millisecond, chatSendRate, chatSendBurst, 500 * time.Millisecond, -0.5},
+		{"пополнение не больше burst", 0, time.Hour, chatSendRate, chatSendBurst, 0, 2},
+		{"группа", 0, 0, groupSendRate, chatSendBurst, 3 * time.Second, -1},
+		{"все чаты", 0, 100 * time.Millisecond, globalSendRate, globalSendRate, 0, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newTestClock()
+			bucket := tokenBucket{tokens: tt.tokens, last: clock.Now()}
+			clock.Advance(tt.elapsed)
+
+			delay := bucket.take(clock.Now(), tt.rate, tt.burst)
+			if !closeDuration(delay, tt.wantDelay) {
+				t.Errorf("задержка %s, ожидалась %s", delay, tt.wantDelay)
+			}
+			if diff := bucket.tokens - tt.wantTokens; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("запас %.3f, ожидался %.3f", bucket.tokens, tt.wantTokens)
+			}
+			if !bucket.last.Equal(clock.Now()) {
+				t.Errorf("время пополнения %s, ожидалось %s", bucket.last, clock.Now())
+			}
+		})
+	}
+}
+
+// После ответа 429 следующая отправка в чат ждет retry_after, а не обычную очередь
+func TestSendLimiterPause(t *testing.T) {
+	tests := []struct {
+		name      string
+		chatID    int64
+		pause     time.Duration
+		elapsed   time.Duration
+		wantDelay time.Duration
+	}{
+		{"сразу после паузы", 42, 5 * time.Second, 0, 5 * time.Second},
+		{"часть паузы прошла", 42, 5 * time.Second, 2 * time.Second, 3 * time.Second},
+		{"пауза прошла", 42, 5 * time.Second, 10 * time.Second, 0},
+		{"группа", -42, 6 * time.Second, 0, 6 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newTestClock()
+			limiter := newSendLimiterWithClock(clock.Now)
+			if delay := limiter.reserve(tt.chatID); delay != 0 {
+				t.Fatalf("первая отправка ждет %s", delay)
+			}
+
+			limiter.pause(tt.chatID, tt.pause)
+			clock.Advance(tt.elapsed)
+			if delay := limiter.reserve(tt.chatID); !closeDuration(delay, tt.wantDelay) {
+				t.Errorf("отправка после паузы ждет %s, ожидалось %s", delay, tt.wantDelay)
+			}
+		})
+	}
+
+	// Пауза чата, в который еще не отправляли, ничего не откладывает
+	limiter := newSendLimiterWithClock(newTestClock().Now)
+	limiter.pause(7, time.Minute)
+	if delay := limiter.reserve(7); delay != 0 {
+		t.Errorf("отправка в новый чат ждет %s", delay)
+	}
+}
+
+func TestSendLimiterWaitCanceled(t *testing.T) {
+	limiter := newSendLimiterWithClock(newTestClock().Now)
+	for range int(chatSendBurst) {
+		if err := limiter.wait(context.Background(), 42); err != nil {
+			t.Fatalf("отправка в пределах burst: %v", err)
+		}
+	}
+
+	// Часы стоят, поэтому без отмены ожидание длилось бы секунду
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.wait(ctx, 42); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait после отмены: %v, ожидалось %v", err, context.Canceled)
+	}
+}
+
+// rateLimitedTransport отвечает на каждый запрос ошибкой 429
+type rateLimitedTransport struct {
+	Transport
+	requests int
+}
+
+func (t *rateLimitedTransport) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	t.requests++
+	return nil, &tgbotapi.Error{Code: 429, ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 10}}
+}
+
+func TestRequestContextRetryCanceled(t *testing.T) {
+	transport := &rateLimitedTransport{}
+	api := &rateLimitedAPI{Transport: transport}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// Без очереди повтор ждет retry_after целиком, если его не прервать
+	_, err := api.RequestContext(ctx, tgbotapi.NewCallback("1", ""))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RequestContext: %v, ожидалось %v", err, context.DeadlineExceeded)
+	}
+	if transport.requests != 1 {
+		t.Errorf("выполнено %d запросов, ожидался 1", transport.requests)
+	}
+}
+
+func closeDuration(got, want time.Duration) bool {
+	diff := got - want
+	return diff < time.Microsecond && diff > -time.Microsecond
+}
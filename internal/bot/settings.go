@@ -0,0 +1,506 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// weekdayNames - короткие названия дней недели, начиная с понедельника
+var weekdayNames = []struct {
+	day  time.Weekday
+	name string
+}{
+	{time.Monday, "Пн"},
+	{time.Tuesday, "Вт"},
+	{time.Wednesday, "Ср"},
+	{time.Thursday, "Чт"},
+	{time.Friday, "Пт"},
+	{time.Saturday, "Сб"},
+	{time.Sunday, "Вс"},
+}
+
+// quietHoursPresets - варианты тихих часов «с» и «до». Одинаковые часы - без тихих часов
+var quietHoursPresets = [][2]int{
+	{model.DefaultQuietHoursFrom, model.DefaultQuietHoursTo},
+	{22, 9},
+	{0, 7},
+	{0, 0},
+}
+
+// SendDigest отправляет пользователю регулярный отчет за период с учетом его настроек
+func (b *Bot) SendDigest(ctx context.Context, settings *model.UserSettings, reportType service.ReportType) error {
+	userID := settings.UserID
+	if reportType != service.DailyReport {
+		// Еженедельный и ежемесячный отчеты совпадают с отчетами из меню
+		b.sendReport(ctx, userID, userID, reportType)
+		return nil
+	}
+
+	report, err := b.service.GetReport(ctx, userID, service.DailyReport)
+	if err != nil {
+		return fmt.Errorf("failed to get daily report: %w", err)
+	}
+	if report.TransactionData.TotalCount > 0 {
+		return b.SendDailyReport(ctx, userID, report)
+	}
+
+	// За день без транзакций вместо нулевого отчета - короткое сообщение или ничего
+	if settings.SkipEmptyDays {
+		return nil
+	}
+	return b.sendEmptyDayMessage(ctx, userID)
+}
+
+// sendEmptyDayMessage сообщает о дне без трат и текущей серии таких дней
+func (b *Bot) sendEmptyDayMessage(ctx context.Context, userID int64) error {
+	streak, err := b.service.NoSpendStreak(ctx, userID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	text := "Сегодня трат не было 🎉"
+	if streak > 1 {
+		text += fmt.Sprintf("\nСерия без трат: %d %s", streak, pluralDays(streak))
+	}
+	text += "\n\nЕсли что-то забыли записать - самое время 👇"
+
+	msg := tgbotapi.NewMessage(userID, text)
+	msg.ReplyMarkup = b.getMainKeyboard()
+	_, err = sendContext(ctx, b.api, msg)
+	return err
+}
+
+// SendReminder напоминает пользователю записать расходы за сегодня
+func (b *Bot) SendReminder(ctx context.Context, userID int64) error {
+	msg := tgbotapi.NewMessage(userID, "Не забудьте записать расходы за сегодня ✍️")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("💸 Добавить расход", cbAdd, "expense"),
+			callbackButton("💰 Добавить доход", cbAdd, "income"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("🔕 Отключить напоминания", cbSettings, "reminder"),
+		),
+	)
+	_, err := sendContext(ctx, b.api, msg)
+	return err
+}
+
+// pluralDays склоняет слово "день" для числа
+func pluralDays(n int) string {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return "день"
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20):
+		return "дня"
+	default:
+		return "дней"
+	}
+}
+
+// handleSettings показывает настройки уведомлений
+func (b *Bot) handleSettings(ctx context.Context, message *tgbotapi.Message) {
+	settings, err := b.service.GetUserSettings(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить настройки")
+		return
+	}
+	b.sendSettings(message.Chat.ID, settings)
+}
+
+// handleSettingsCallback изменяет настройки уведомлений
+func (b *Bot) handleSettingsCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	chatID := callback.Message.Chat.ID
+	setting := args.String(0)
+
+	// Экраны выбора без изменения настроек
+	switch {
+	case setting == "hours":
+		b.sendHourPicker(chatID, "hour", "Во сколько присылать ежедневную сводку?")
+		return nil
+	case setting == "rhours":
+		b.sendHourPicker(chatID, "rhour", "Во сколько напоминать о записи расходов?")
+		return nil
+	case setting == "show":
+		settings, err := b.service.GetUserSettings(ctx, callback.From.ID)
+		if err != nil {
+			return fmt.Errorf("error getting settings: %w", err)
+		}
+		b.sendSettings(chatID, settings)
+		return nil
+	case setting == "compactdaily":
+		settings, err := b.service.GetUserSettings(ctx, callback.From.ID)
+		if err != nil {
+			return fmt.Errorf("error getting settings: %w", err)
+		}
+		if settings, err = b.service.SetCompactDaily(ctx, callback.From.ID, !settings.CompactDaily); err != nil {
+			return fmt.Errorf("error saving compact daily setting: %w", err)
+		}
+		b.sendSettings(chatID, settings)
+		return nil
+	case setting == "inflation":
+		settings, err := b.service.GetUserSettings(ctx, callback.From.ID)
+		if err != nil {
+			return fmt.Errorf("error getting settings: %w", err)
+		}
+		if settings, err = b.service.SetInflationAdjusted(ctx, callback.From.ID, !settings.InflationAdjusted); err != nil {
+			return fmt.Errorf("error saving inflation setting: %w", err)
+		}
+		b.sendSettings(chatID, settings)
+		return nil
+	case setting == "catsort":
+		settings, err := b.service.GetUserSettings(ctx, callback.From.ID)
+		if err != nil {
+			return fmt.Errorf("error getting settings: %w", err)
+		}
+		order := model.CategorySortUsage
+		if settings.SortCategoriesByUsage() {
+			order = model.CategorySortManual
+		}
+		if settings, err = b.service.SetCategorySort(ctx, callback.From.ID, order); err != nil {
+			return fmt.Errorf("error saving category sort: %w", err)
+		}
+		b.sendSettings(chatID, settings)
+		return nil
+	case setting == "layout":
+		settings, err := b.service.GetUserSettings(ctx, callback.From.ID)
+		if err != nil {
+			return fmt.Errorf("error getting settings: %w", err)
+		}
+		layout := model.ReportLayoutCompact
+		if settings.CompactReports() {
+			layout = model.ReportLayoutDetailed
+		}
+		if settings, err = b.service.SetReportLayout(ctx, callback.From.ID, layout); err != nil {
+			return fmt.Errorf("error saving report layout: %w", err)
+		}
+		b.sendSettings(chatID, settings)
+		return nil
+	case setting == "chartfmt":
+		settings, err := b.service.GetUserSettings(ctx, callback.From.ID)
+		if err != nil {
+			return fmt.Errorf("error getting settings: %w", err)
+		}
+		// Формат переключается по кругу: фото → PNG → SVG → фото
+		format := model.ChartFormatPNG
+		switch settings.ChartFormat {
+		case model.ChartFormatPNG:
+			format = model.ChartFormatSVG
+		case model.ChartFormatSVG:
+			format = model.ChartFormatPhoto
+		}
+		if settings, err = b.service.SetChartFormat(ctx, callback.From.ID, format); err != nil {
+			return fmt.Errorf("error saving chart format: %w", err)
+		}
+		b.sendSettings(chatID, settings)
+		return nil
+	case setting == "trendchart":
+		settings, err := b.service.GetUserSettings(ctx, callback.From.ID)
+		if err != nil {
+			return fmt.Errorf("error getting settings: %w", err)
+		}
+		trendChart := model.TrendChartAbsolute
+		if settings.AbsoluteTrends() {
+			trendChart = model.TrendChartChange
+		}
+		if settings, err = b.service.SetTrendChart(ctx, callback.From.ID, trendChart); err != nil {
+			return fmt.Errorf("error saving trend chart: %w", err)
+		}
+		b.sendSettings(chatID, settings)
+		return nil
+	case setting == "chartscale":
+		settings, err := b.service.GetUserSettings(ctx, callback.From.ID)
+		if err != nil {
+			return fmt.Errorf("error getting settings: %w", err)
+		}
+		// Масштаб переключается по кругу: 1x → 2x → 4x → 1x
+		scale := settings.ChartScale * 2
+		if scale > model.MaxChartScale {
+			scale = 1
+		}
+		if settings, err = b.service.SetChartScale(ctx, callback.From.ID, scale); err != nil {
+			return fmt.Errorf("error saving chart scale: %w", err)
+		}
+		b.sendSettings(chatID, settings)
+		return nil
+	case setting == "tz" && len(args) == 1:
+		b.sendTimezonePicker(chatID)
+		return nil
+	case setting == "tz":
+		// Номер пояса в списке, -1 - пояс сервера
+		index, err := args.Int(1)
+		if err != nil || index < -1 || index >= len(model.Timezones) {
+			return fmt.Errorf("invalid timezone: %s", args.String(1))
+		}
+		name := ""
+		if index >= 0 {
+			name = model.Timezones[index].Name
+		}
+		settings, err := b.service.SetTimezone(ctx, callback.From.ID, name)
+		if err != nil {
+			return fmt.Errorf("error saving timezone: %w", err)
+		}
+		b.sendSettings(chatID, settings)
+		return nil
+	}
+
+	var update func(*model.NotificationSettings)
+	showQuietDays := false
+	switch {
+	case setting == "daily":
+		update = func(n *model.NotificationSettings) { n.DailyReport = !n.DailyReport }
+	case setting == "weekly":
+		update = func(n *model.NotificationSettings) { n.WeeklyDigest = !n.WeeklyDigest }
+	case setting == "monthly":
+		update = func(n *model.NotificationSettings) { n.MonthlyDigest = !n.MonthlyDigest }
+	case setting == "closing":
+		update = func(n *model.NotificationSettings) { n.MonthlyClosing = !n.MonthlyClosing }
+	case setting == "yearreview":
+		update = func(n *model.NotificationSettings) { n.YearReview = !n.YearReview }
+	case setting == "reminder":
+		update = func(n *model.NotificationSettings) { n.Reminder = !n.Reminder }
+	case setting == "budgetalerts":
+		update = func(n *model.NotificationSettings) { n.BudgetAlerts = !n.BudgetAlerts }
+	case setting == "qhours":
+		// Тихие часы переключаются по кругу вариантов
+		update = func(n *model.NotificationSettings) {
+			next := quietHoursPresets[0]
+			for i, preset := range quietHoursPresets {
+				if preset == [2]int{n.QuietHoursFrom, n.QuietHoursTo} {
+					next = quietHoursPresets[(i+1)%len(quietHoursPresets)]
+				}
+			}
+			n.QuietHoursFrom, n.QuietHoursTo = next[0], next[1]
+		}
+	case setting == "rhour":
+		hour, err := args.Int(1)
+		if err != nil || hour < 0 || hour > 23 {
+			return fmt.Errorf("invalid reminder hour: %s", args.String(1))
+		}
+		update = func(n *model.NotificationSettings) { n.ReminderHour = hour }
+	case setting == "empty":
+		update = func(n *model.NotificationSettings) { n.SkipEmptyDays = !n.SkipEmptyDays }
+	case setting == "hour":
+		hour, err := args.Int(1)
+		if err != nil || hour < 0 || hour > 23 {
+			return fmt.Errorf("invalid delivery hour: %s", args.String(1))
+		}
+		update = func(n *model.NotificationSettings) { n.DeliveryHour = hour }
+	case setting == "quiet" && len(args) == 1:
+		update = func(n *model.NotificationSettings) {}
+		showQuietDays = true
+	case setting == "quiet":
+		day, err := args.Int(1)
+		if err != nil || day < 0 || day > 6 {
+			return fmt.Errorf("invalid quiet day: %s", args.String(1))
+		}
+		update = func(n *model.NotificationSettings) { n.ToggleQuietDay(time.Weekday(day)) }
+		showQuietDays = true
+	default:
+		return fmt.Errorf("unknown settings action: %s", setting)
+	}
+
+	settings, err := b.service.UpdateNotificationSettings(ctx, callback.From.ID, update)
+	if err != nil {
+		return fmt.Errorf("error updating settings: %w", err)
+	}
+
+	if showQuietDays {
+		b.sendQuietDaysPicker(chatID, settings)
+		return nil
+	}
+	b.sendSettings(chatID, settings)
+	return nil
+}
+
+// sendSettings отправляет экран настроек уведомлений
+func (b *Bot) sendSettings(chatID int64, settings *model.UserSettings) {
+	toggle := func(enabled bool, title, setting string) []tgbotapi.InlineKeyboardButton {
+		mark := "❌"
+		if enabled {
+			mark = "✅"
+		}
+		return tgbotapi.NewInlineKeyboardRow(callbackButton(mark+" "+title, cbSettings, setting))
+	}
+
+	quiet := "нет"
+	if len(settings.QuietDays) > 0 {
+		var names []string
+		for _, wd := range weekdayNames {
+			if settings.IsQuietDay(wd.day) {
+				names = append(names, wd.name)
+			}
+		}
+		quiet = strings.Join(names, ", ")
+	}
+
+	quietHours := "нет"
+	if settings.QuietHoursFrom != settings.QuietHoursTo {
+		quietHours = fmt.Sprintf("%02d:00-%02d:00", settings.QuietHoursFrom, settings.QuietHoursTo)
+	}
+
+	emptyDay := "короткое сообщение"
+	if settings.SkipEmptyDays {
+		emptyDay = "не присылать"
+	}
+
+	layout := "подробные"
+	if settings.CompactReports() {
+		layout = "краткие"
+	}
+
+	categorySort := "мой порядок"
+	if settings.SortCategoriesByUsage() {
+		categorySort = "частые сверху"
+	}
+
+	chartFormat := "фото"
+	switch settings.ChartFormat {
+	case model.ChartFormatPNG:
+		chartFormat = "файлы PNG"
+	case model.ChartFormatSVG:
+		chartFormat = "файлы SVG"
+	}
+
+	trendChart := "отклонение в %"
+	if settings.AbsoluteTrends() {
+		trendChart = "в рублях"
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		toggle(settings.DailyReport, "Ежедневная сводка", "daily"),
+		tgbotapi.NewInlineKeyboardRow(callbackButton(
+			fmt.Sprintf("🕘 Время сводки: %02d:00", settings.DeliveryHour), cbSettings, "hours")),
+		tgbotapi.NewInlineKeyboardRow(callbackButton(
+			"🔕 Тихие дни: "+quiet, cbSettings, "quiet")),
+		tgbotapi.NewInlineKeyboardRow(callbackButton(
+			"📭 День без трат: "+emptyDay, cbSettings, "empty")),
+		toggle(settings.Reminder, "Напоминание о записи расходов", "reminder"),
+		tgbotapi.NewInlineKeyboardRow(callbackButton(
+			fmt.Sprintf("⏰ Время напоминания: %02d:00", settings.ReminderHour), cbSettings, "rhours")),
+		toggle(settings.BudgetAlerts, "Предупреждения о бюджетах", "budgetalerts"),
+		tgbotapi.NewInlineKeyboardRow(callbackButton(
+			"🌙 Тихие часы: "+quietHours, cbSettings, "qhours")),
+		toggle(settings.WeeklyDigest, "Еженедельный отчет", "weekly"),
+		toggle(settings.MonthlyDigest, "Ежемесячный отчет", "monthly"),
+		toggle(settings.MonthlyClosing, "Итоги месяца", "closing"),
+		toggle(settings.YearReview, "Итоги года", "yearreview"),
+		tgbotapi.NewInlineKeyboardRow(callbackButton("📄 Отчеты: "+layout, cbSettings, "layout")),
+		tgbotapi.NewInlineKeyboardRow(callbackButton("🧩 Разделы отчета", cbReportSections)),
+		tgbotapi.NewInlineKeyboardRow(callbackButton("🔢 Категории: "+categorySort, cbSettings, "catsort")),
+		tgbotapi.NewInlineKeyboardRow(callbackButton("🕰 Часовой пояс: "+timezoneTitle(settings.Timezone), cbSettings, "tz")),
+		tgbotapi.NewInlineKeyboardRow(callbackButton("🖼 Графики: "+chartFormat, cbSettings, "chartfmt")),
+	}
+	text := "⚙️ *Настройки уведомлений*\n\n" +
+		"В тихие дни не приходят ежедневная сводка и напоминания.\n" +
+		"Напоминание приходит, только если за день ничего не записано.\n" +
+		"Предупреждения о бюджетах приходят при 80% и 100% бюджета, в тихие часы - после их окончания.\n" +
+		"Итоги месяца приходят 1-го числа: победы, рост трат, бюджеты и доля сбережений, итоги года с графиками - 1 января.\n" +
+		"Краткий отчет содержит только итоги и главные категории расходов.\n" +
+		"Категории при вводе показываются в вашем порядке или по частоте за 3 месяца.\n" +
+		"По часовому поясу отчеты раскладывают покупки по часам суток и находят ночные траты.\n" +
+		"Графики файлами приходят без сжатия - для печати и архива, SVG масштабируется без потери качества.\n" +
+		"Тренды в рублях показывают траты по дням, среднее за неделю и бюджет в день"
+
+	// Разрешение важно только для PNG-файлов: фото Telegram все равно сжимает, а SVG векторный
+	if settings.ChartFormat == model.ChartFormatPNG {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(callbackButton(
+			fmt.Sprintf("📐 Разрешение графиков: %dx", settings.ChartScale), cbSettings, "chartscale")))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(callbackButton("📈 Тренды: "+trendChart, cbSettings, "trendchart")))
+
+	// С краткими отчетами сводка за день и так компактная
+	if !settings.CompactReports() {
+		rows = append(rows, toggle(settings.CompactDaily, "Компактная сводка за день", "compactdaily"))
+		text += ".\nКомпактная сводка за день - по строке на показатель, крупные траты и остаток бюджета"
+	}
+
+	// Пересчет по инфляции доступен, только если подключен источник данных об инфляции
+	if b.service.InflationAvailable() {
+		rows = append(rows, toggle(settings.InflationAdjusted, "Годовой отчет с учетом инфляции", "inflation"))
+		text += ".\nГодовой отчет с учетом инфляции показывает прошлые месяцы в сегодняшних ценах"
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(callbackButton("🔐 Защита PIN-кодом", cbPIN, "menu")),
+		tgbotapi.NewInlineKeyboardRow(callbackButton("« Назад", cbMenu)),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.api.Send(msg)
+}
+
+// sendHourPicker предлагает выбрать час уведомления
+func (b *Bot) sendHourPicker(chatID int64, setting, title string) {
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for hour := 6; hour <= 23; hour++ {
+		row = append(row, callbackButton(fmt.Sprintf("%02d:00", hour), cbSettings, setting, hour))
+		if len(row) == 6 {
+			buttons = append(buttons, row)
+			row = nil
+		}
+	}
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+		callbackButton("« Назад", cbSettings, "show"),
+	))
+
+	msg := tgbotapi.NewMessage(chatID, title)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	b.api.Send(msg)
+}
+
+// sendTimezonePicker предлагает выбрать часовой пояс
+func (b *Bot) sendTimezonePicker(chatID int64) {
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	for i, tz := range model.Timezones {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(callbackButton(tz.Title, cbSettings, "tz", i)))
+	}
+	buttons = append(buttons,
+		tgbotapi.NewInlineKeyboardRow(callbackButton("🖥 Как на сервере", cbSettings, "tz", -1)),
+		tgbotapi.NewInlineKeyboardRow(callbackButton("« Назад", cbSettings, "show")),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, "Выберите часовой пояс:")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	b.api.Send(msg)
+}
+
+// timezoneTitle возвращает подпись часового пояса для настроек
+func timezoneTitle(name string) string {
+	for _, tz := range model.Timezones {
+		if tz.Name == name {
+			return tz.Title
+		}
+	}
+	if name == "" {
+		return "как на сервере"
+	}
+	return name
+}
+
+// sendQuietDaysPicker предлагает отметить дни недели без уведомлений
+func (b *Bot) sendQuietDaysPicker(chatID int64, settings *model.UserSettings) {
+	var row []tgbotapi.InlineKeyboardButton
+	for _, wd := range weekdayNames {
+		label := wd.name
+		if settings.IsQuietDay(wd.day) {
+			label = "🔕 " + wd.name
+		}
+		row = append(row, callbackButton(label, cbSettings, "quiet", int(wd.day)))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "Отметьте дни, в которые не нужно присылать уведомления:")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		row,
+		tgbotapi.NewInlineKeyboardRow(callbackButton("✅ Готово", cbSettings, "show")),
+	)
+	b.api.Send(msg)
+}
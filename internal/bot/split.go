@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// splitHint - подсказка по вводу разделенного платежа
+const splitHint = "`5000 Ашан: 3000 продукты, 2000 хозтовары`"
+
+// handleSplitInput записывает платеж, разделенный по категориям
+func (b *Bot) handleSplitInput(ctx context.Context, message *tgbotapi.Message, state *model.UserState) error {
+	categories, err := b.service.GetCategories(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Ошибка при получении категорий")
+		return fmt.Errorf("error getting categories: %w", err)
+	}
+
+	total, description, parts, err := service.ParseSplit(message.Text, model.ActiveCategories(categories))
+	if err != nil {
+		b.sendErrorMessage(message.Chat.ID,
+			fmt.Sprintf("Не удалось разобрать платеж: %v\n\nФормат: %s", err, splitHint))
+		return nil
+	}
+
+	accountID := ""
+	if state != nil {
+		accountID = state.SelectedAccount
+	}
+
+	err = b.service.AddSplitTransaction(ctx, message.From.ID, accountID, total, description, parts)
+	if errors.Is(err, service.ErrSplitMismatch) {
+		b.sendErrorMessage(message.Chat.ID,
+			fmt.Sprintf("Сумма частей не совпадает с суммой платежа %.2f₽", total))
+		return nil
+	}
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Ошибка при сохранении транзакции")
+		return nil
+	}
+
+	if state != nil {
+		if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+			b.reportError(ctx, fmt.Errorf("error deleting user state: %w", err))
+		}
+	}
+
+	text := fmt.Sprintf("Платеж на %.2f₽ разделен! ✅\n\n", total)
+	for _, part := range parts {
+		text += fmt.Sprintf("• %s: %.2f₽\n", part.CategoryName, part.Amount)
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+	return nil
+}
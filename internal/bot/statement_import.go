@@ -0,0 +1,209 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// importSession - выписка и номер группы, для которой выбирается категория
+type importSession struct {
+	Import *service.StatementImport `json:"import"`
+	Step   int                      `json:"step"`
+}
+
+// startStatementImport отбрасывает уже записанные операции и начинает выбор категорий по группам
+func (b *Bot) startStatementImport(ctx context.Context, message *tgbotapi.Message, lines []service.StatementLine) error {
+	imp, err := b.service.PrepareStatementImport(ctx, message.From.ID, lines)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось подготовить импорт выписки")
+		return fmt.Errorf("error preparing statement import: %w", err)
+	}
+
+	if len(imp.Lines) == 0 {
+		msg := tgbotapi.NewMessage(message.Chat.ID,
+			fmt.Sprintf("✅ Все операции выписки (%d) уже записаны в боте", imp.Total))
+		msg.ReplyMarkup = b.getMainKeyboard()
+		b.api.Send(msg)
+		return nil
+	}
+
+	return b.sendImportStep(ctx, message.From.ID, message.Chat.ID, &importSession{Import: imp})
+}
+
+// handleImportCallback обрабатывает выбор категории для группы операций.
+// Аргументы: cat <ID категории>, skip - не импортировать группу, all - принять предложенные
+func (b *Bot) handleImportCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	chatID := callback.Message.Chat.ID
+
+	state, err := b.expectState(ctx, callback.From.ID, model.StateImport)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		b.sendErrorMessage(chatID, "Импорт устарел, пришлите выписку еще раз")
+		return nil
+	}
+
+	var session importSession
+	if err := json.Unmarshal([]byte(state.Payload), &session); err != nil {
+		return fmt.Errorf("error decoding statement import: %w", err)
+	}
+	if session.Import == nil || session.Step >= len(session.Import.Groups) {
+		return b.finishStatementImport(ctx, callback.From.ID, chatID, &session)
+	}
+
+	group := &session.Import.Groups[session.Step]
+	switch args.String(0) {
+	case "cat":
+		group.CategoryID = args.String(1)
+		session.Step++
+	case "skip":
+		group.Skip = true
+		session.Step++
+	case "all":
+		session.Step = len(session.Import.Groups)
+	default:
+		return fmt.Errorf("invalid import data: %v", args)
+	}
+
+	return b.sendImportStep(ctx, callback.From.ID, chatID, &session)
+}
+
+// sendImportStep сохраняет сессию и спрашивает категорию для очередной группы операций
+func (b *Bot) sendImportStep(ctx context.Context, userID, chatID int64, session *importSession) error {
+	groups := session.Import.Groups
+	if session.Step >= len(groups) {
+		return b.finishStatementImport(ctx, userID, chatID, session)
+	}
+
+	payload, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("error encoding statement import: %w", err)
+	}
+	state := &model.UserState{
+		UserID:         userID,
+		AwaitingAction: model.StateImport,
+		Payload:        string(payload),
+	}
+	if err := b.saveUserState(ctx, state); err != nil {
+		return fmt.Errorf("error saving user state: %w", err)
+	}
+
+	categories, err := b.service.GetCategories(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("error getting categories: %w", err)
+	}
+
+	group := groups[session.Step]
+	kind := "Расходы"
+	if group.Type == "income" {
+		kind = "Доходы"
+	}
+
+	var text strings.Builder
+	if session.Step == 0 {
+		fmt.Fprintf(&text, "📥 В выписке %d операций, новых - %d", session.Import.Total, len(session.Import.Lines))
+		if session.Import.Duplicates > 0 {
+			fmt.Fprintf(&text, ", уже записаны в боте - %d", session.Import.Duplicates)
+		}
+		text.WriteString("\n\n")
+	}
+	fmt.Fprintf(&text, "Группа %d из %d\n%s «%s»: %d оп. на %.2f₽\n\nВыберите категорию",
+		session.Step+1, len(groups), kind, group.Name, group.Count, group.Total)
+	if group.CategoryID != "" {
+		text.WriteString(" (✓ - предложенная)")
+	}
+	text.WriteString(":")
+
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	for _, cat := range model.ActiveCategories(categories) {
+		if cat.Type != group.Type {
+			continue
+		}
+		name := cat.Name
+		if cat.ID == group.CategoryID {
+			name = "✓ " + name
+		}
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton(name, cbImport, "cat", cat.ID),
+		))
+	}
+	buttons = append(buttons,
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("⏭ Не импортировать", cbImport, "skip"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("✅ Принять предложенные для остальных", cbImport, "all"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("✖️ Отмена", cbCancel),
+		),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	b.api.Send(msg)
+	return nil
+}
+
+// finishStatementImport записывает операции с выбранными категориями
+func (b *Bot) finishStatementImport(ctx context.Context, userID, chatID int64, session *importSession) error {
+	if err := b.deleteUserState(ctx, userID); err != nil {
+		return fmt.Errorf("error deleting user state: %w", err)
+	}
+
+	imported := 0
+	if session.Import != nil {
+		var err error
+		imported, err = b.service.ApplyStatementImport(ctx, userID, session.Import)
+		if err != nil {
+			b.sendServiceError(ctx, chatID, err, fmt.Sprintf("Импорт прерван: записано операций - %d", imported))
+			return fmt.Errorf("error applying statement import: %w", err)
+		}
+	}
+
+	text := fmt.Sprintf("✅ Импортировано операций: %d", imported)
+	if session.Import != nil && session.Import.Duplicates > 0 {
+		text += fmt.Sprintf("\nПропущено как уже записанные: %d", session.Import.Duplicates)
+	}
+	if imported > 0 {
+		text += b.importDuplicatesHint(ctx, userID, session.Import.Lines)
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+	return nil
+}
+
+// importDuplicatesHint подсказывает проверить дубликаты, если за период выписки есть похожие записи
+func (b *Bot) importDuplicatesHint(ctx context.Context, userID int64, lines []service.ImportLine) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	start, end := lines[0].Date, lines[0].Date
+	for _, line := range lines {
+		if line.Date.Before(start) {
+			start = line.Date
+		}
+		if line.Date.After(end) {
+			end = line.Date
+		}
+	}
+
+	pairs, err := b.service.FindDuplicates(ctx, userID, start, end.AddDate(0, 0, 1))
+	if err != nil {
+		log.Printf("Error finding duplicates: %v", err)
+		return ""
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n\n🔁 За период выписки есть похожие записи (%d) - проверьте их командой /duplicates", len(pairs))
+}
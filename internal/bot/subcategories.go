@@ -0,0 +1,166 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// handleSubcategoryCallback показывает подкатегории для ввода транзакции
+func (b *Bot) handleSubcategoryCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	categories, err := b.service.GetInputCategories(ctx, callback.From.ID)
+	if err != nil {
+		return fmt.Errorf("error getting categories: %w", err)
+	}
+	parent := findCategory(categories, args.String(0))
+	if parent == nil {
+		b.sendErrorMessage(callback.Message.Chat.ID, "Категория не найдена")
+		return nil
+	}
+
+	msg := tgbotapi.NewMessage(callback.Message.Chat.ID,
+		fmt.Sprintf("*%s %s*\n\nВыберите подкатегорию:", parent.Icon(), parent.Name))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = subcategorySelectKeyboard(parent, model.Subcategories(categories, parent.ID))
+	b.api.Send(msg)
+	return nil
+}
+
+// handleSubcategories показывает подкатегории категории с кнопками управления
+func (b *Bot) handleSubcategories(ctx context.Context, message *tgbotapi.Message, parentID string) {
+	categories, err := b.service.GetCategories(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить категории")
+		return
+	}
+	parent := findCategory(categories, parentID)
+	if parent == nil || parent.ParentID != "" {
+		b.sendErrorMessage(message.Chat.ID, "Категория не найдена")
+		return
+	}
+
+	subcategories := model.Subcategories(model.ActiveCategories(categories), parent.ID)
+	text := fmt.Sprintf("*Подкатегории: %s %s*\n\n", parent.Icon(), parent.Name)
+	if len(subcategories) == 0 {
+		text += "Подкатегорий пока нет. Они уточняют траты внутри категории: в отчетах их суммы входят в сумму категории и показываются отдельно"
+	} else {
+		for _, sub := range subcategories {
+			text += fmt.Sprintf("• %s %s\n", sub.Icon(), sub.Name)
+		}
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = subcategoriesKeyboard(parent, subcategories)
+	b.api.Send(msg)
+}
+
+// handleAddSubcategory запрашивает название новой подкатегории
+func (b *Bot) handleAddSubcategory(ctx context.Context, message *tgbotapi.Message, parentID string) {
+	categories, err := b.service.GetCategories(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить категории")
+		return
+	}
+	parent := findCategory(categories, parentID)
+	if parent == nil {
+		b.sendErrorMessage(message.Chat.ID, "Категория не найдена")
+		return
+	}
+
+	state := &model.UserState{
+		UserID:          message.From.ID,
+		TransactionType: parent.Type,
+		AwaitingAction:  model.StateNewCategory,
+		Payload:         parent.ID,
+	}
+	if err := b.saveUserState(ctx, state); err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Ошибка при сохранении состояния")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf(
+		"*Новая подкатегория в «%s»*\n\nВведите название. Можно начать со значка: `☕ Кофе`", parent.Name))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = cancelKeyboard
+	b.api.Send(msg)
+}
+
+// subcategorySelectKeyboard - выбор подкатегории для ввода. Транзакцию можно записать
+// и в саму категорию, не уточняя подкатегорию
+func subcategorySelectKeyboard(parent *model.Category, subcategories []model.Category) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, sub := range subcategories {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			callbackButton(sub.Icon()+" "+sub.Name, cbCategory, sub.ID),
+		))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton(parent.Icon()+" "+parent.Name+" (без подкатегории)", cbCategory, parent.ID),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("« Назад", cbAdd, parent.Type),
+		),
+	)
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// subcategoriesKeyboard - управление подкатегориями: выбор, оформление и архивация
+func subcategoriesKeyboard(parent *model.Category, subcategories []model.Category) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, sub := range subcategories {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			callbackButton(sub.Icon()+" "+sub.Name, cbCategory, sub.ID),
+			callbackButton("🎨", cbCategoryStyle, sub.ID),
+			callbackButton("🗄", cbCategoryArchive, "add", sub.ID),
+		))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("➕ Подкатегория", cbNewCategory, parent.Type, parent.ID),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("« Назад", cbCategories),
+		),
+	)
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// categoryTree перечисляет категории типа categoryType с подкатегориями под родителем
+func categoryTree(categories []model.Category, categoryType string) string {
+	text := ""
+	for _, cat := range model.TopLevelCategories(categories) {
+		if cat.Type != categoryType {
+			continue
+		}
+		text += fmt.Sprintf("• %s\n", cat.Name)
+		for _, sub := range model.Subcategories(categories, cat.ID) {
+			text += fmt.Sprintf("    ◦ %s\n", sub.Name)
+		}
+	}
+	return text
+}
+
+// categoryTitle - название категории для сообщений: у подкатегории вместе с родителем
+func categoryTitle(categories []model.Category, category *model.Category) string {
+	if parent := findCategory(categories, category.ParentID); parent != nil {
+		return parent.Name + " › " + category.Name
+	}
+	return category.Name
+}
+
+// findCategory ищет категорию по ID, nil - категории нет
+func findCategory(categories []model.Category, id string) *model.Category {
+	if id == "" {
+		return nil
+	}
+	for i := range categories {
+		if categories[i].ID == id {
+			return &categories[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Ограничения на запросы одного пользователя
+const (
+	// userRequestRate - команд, сообщений и нажатий кнопок в секунду в среднем
+	userRequestRate = 1.0
+	// userRequestBurst - сколько запросов подряд проходит без ограничения: обычная
+	// работа с меню укладывается, а быстрые нажатия по кнопке - нет
+	userRequestBurst = 8.0
+	// throttleNoticeInterval - не чаще сообщаем пользователю об ограничении
+	throttleNoticeInterval = 10 * time.Second
+)
+
+// userThrottle ограничивает частоту запросов каждого пользователя по алгоритму token
+// bucket, чтобы быстрые нажатия и неисправные клиенты не создавали лавину запросов
+// к Supabase и не расходовали лимиты Telegram. Запас хранится в памяти процесса:
+// в serverless-режиме у каждого экземпляра функции свой
+type userThrottle struct {
+	mu       sync.Mutex
+	users    map[int64]*tokenBucket
+	noticeAt map[int64]time.Time
+}
+
+func newUserThrottle() *userThrottle {
+	return &userThrottle{
+		users:    make(map[int64]*tokenBucket),
+		noticeAt: make(map[int64]time.Time),
+	}
+}
+
+// allow сообщает, можно ли обработать запрос пользователя. notice - отклоненный запрос
+// первый за throttleNoticeInterval, и пользователю стоит объяснить, что происходит
+func (t *userThrottle) allow(userID int64, now time.Time) (ok, notice bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	user := t.users[userID]
+	if user == nil {
+		t.forgetIdle(now)
+		user = &tokenBucket{tokens: userRequestBurst, last: now}
+		t.users[userID] = user
+	}
+	if user.tryTake(now, userRequestRate, userRequestBurst) {
+		return true, false
+	}
+	if now.Sub(t.noticeAt[userID]) < throttleNoticeInterval {
+		return false, false
+	}
+	t.noticeAt[userID] = now
+	return false, true
+}
+
+// forgetIdle удаляет пользователей с полным запасом: для них ограничение не действует
+func (t *userThrottle) forgetIdle(now time.Time) {
+	if len(t.users) < 1000 {
+		return
+	}
+	full := time.Duration(userRequestBurst / userRequestRate * float64(time.Second))
+	for id, user := range t.users {
+		if now.Sub(user.last) > full {
+			delete(t.users, id)
+			delete(t.noticeAt, id)
+		}
+	}
+}
+
+// tryTake забирает один запрос, если запас не исчерпан. В отличие от take не ставит
+// в очередь: лишний запрос отклоняется
+func (b *tokenBucket) tryTake(now time.Time, rate, burst float64) bool {
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// throttled отклоняет обновление, если пользователь присылает запросы слишком часто.
+// На нажатие кнопки всегда отвечаем, иначе у нее бесконечно крутится индикатор загрузки
+func (b *Bot) throttled(update tgbotapi.Update, user *tgbotapi.User, chatID int64) bool {
+	ok, notice := b.throttle.allow(user.ID, time.Now())
+	if ok {
+		return false
+	}
+
+	const text = "⏳ Слишком много запросов. Подождите пару секунд"
+	if update.CallbackQuery != nil {
+		b.api.Request(tgbotapi.NewCallback(update.CallbackQuery.ID, text))
+	} else if notice {
+		b.api.Send(tgbotapi.NewMessage(chatID, text))
+	}
+	return true
+}
@@ -0,0 +1,310 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// handleTransactionCallback показывает карточку транзакции и выполняет действия с ней
+func (b *Bot) handleTransactionCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	message := callbackMessage(callback)
+	transactionID := args.String(0)
+
+	switch args.String(1) {
+	case "note":
+		return b.startTransactionInput(ctx, message, model.StateTransactionNote, transactionID,
+			fmt.Sprintf("📝 Введите заметку к транзакции, до %d символов. Чтобы удалить заметку, отправьте «-»", service.MaxNoteLength))
+	case "photo":
+		if !b.service.AttachmentsAvailable() {
+			b.sendErrorMessage(message.Chat.ID, "Хранение фото чеков не настроено")
+			return nil
+		}
+		return b.startTransactionInput(ctx, message, model.StateTransactionPhoto, transactionID,
+			"📷 Пришлите фото чека одним сообщением")
+	case "biz":
+		return b.startTransactionInput(ctx, message, model.StateCounterparty, transactionID,
+			"🏢 Введите реквизиты, каждый с новой строки:\n"+
+				"ООО Ромашка\nсчет 42\nНДС 20%\n\n"+
+				"НДС можно указать ставкой, суммой или «без НДС». Чтобы очистить реквизиты, отправьте «-»")
+	case "event":
+		if err := b.toggleTransactionEvent(ctx, message.Chat.ID, message.From.ID, transactionID); err != nil {
+			return err
+		}
+	case "show":
+		return b.sendTransactionPhoto(ctx, message.Chat.ID, message.From.ID, transactionID)
+	case "unphoto":
+		if err := b.service.DeleteTransactionPhoto(ctx, message.From.ID, transactionID); err != nil {
+			b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось удалить фото")
+			return fmt.Errorf("error deleting transaction photo: %w", err)
+		}
+	}
+	return b.showTransaction(ctx, message.Chat.ID, message.From.ID, transactionID)
+}
+
+// showTransaction присылает карточку транзакции: сумма, категория, описание и заметка
+// с кнопками для заметки, фото чека и удаления
+func (b *Bot) showTransaction(ctx context.Context, chatID, userID int64, transactionID string) error {
+	transaction, err := b.service.GetTransaction(ctx, userID, transactionID)
+	if err != nil {
+		b.sendServiceError(ctx, chatID, err, "Не удалось открыть транзакцию")
+		return fmt.Errorf("error getting transaction: %w", err)
+	}
+	categories, err := b.service.GetCategories(ctx, userID)
+	if err != nil {
+		b.sendServiceError(ctx, chatID, err, "Не удалось загрузить категории")
+		return fmt.Errorf("error getting categories: %w", err)
+	}
+
+	transactionType := model.TransactionExpense
+	if transaction.IsIncome() {
+		transactionType = model.TransactionIncome
+	}
+	icon, name := model.TypeIcon(transactionType), "Без категории"
+	if transaction.IsSplit {
+		icon, name = "🔀", "Несколько категорий"
+	} else if category := findCategory(categories, transaction.CategoryID); category != nil {
+		icon, name = category.Icon(), category.Name
+	}
+
+	text := fmt.Sprintf("%s %s: %.2f₽\n📅 %s\n", icon, name, transaction.AbsAmount(), transaction.Date.Format("02.01.2006 15:04"))
+	if transaction.Currency != "" && transaction.OriginalAmount != 0 {
+		text += fmt.Sprintf("💱 %s по курсу %.4g\n", formatMoney(math.Abs(transaction.OriginalAmount), transaction.Currency),
+			transaction.Amount/transaction.OriginalAmount)
+	}
+	if transaction.Description != "" {
+		text += fmt.Sprintf("💬 %s\n", transaction.Description)
+	}
+	if transaction.Note != "" {
+		text += fmt.Sprintf("\n📝 %s\n", transaction.Note)
+	}
+	if transaction.AttachmentPath != "" {
+		text += "\n📎 Приложено фото чека\n"
+	}
+	business, err := b.service.BusinessMode(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting business mode: %v", err)
+	}
+	if business {
+		text += formatBusinessDetails(transaction)
+	}
+	tagged, open := b.transactionEvents(ctx, userID, transaction)
+	if tagged != nil {
+		text += fmt.Sprintf("\n🏕 Событие: %s\n", tagged.Title())
+	}
+
+	noteButton := "📝 Добавить заметку"
+	if transaction.Note != "" {
+		noteButton = "📝 Изменить заметку"
+	}
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(callbackButton(noteButton, cbTransaction, transaction.ID, "note")),
+	}
+	if business {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			callbackButton("🏢 Контрагент и НДС", cbTransaction, transaction.ID, "biz"),
+		))
+	}
+	switch {
+	case tagged != nil:
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			callbackButton("✖️ Убрать из события", cbTransaction, transaction.ID, "event"),
+		))
+	case open != nil:
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			callbackButton("🏕 Добавить в "+open.Title(), cbTransaction, transaction.ID, "event"),
+		))
+	}
+	if b.service.AttachmentsAvailable() {
+		if transaction.AttachmentPath != "" {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				callbackButton("🖼 Показать чек", cbTransaction, transaction.ID, "show"),
+				callbackButton("✖️ Удалить фото", cbTransaction, transaction.ID, "unphoto"),
+			))
+		} else {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				callbackButton("📷 Приложить фото чека", cbTransaction, transaction.ID, "photo"),
+			))
+		}
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		callbackButton("🗑 Удалить", cbDeleteTransaction, transaction.ID),
+		callbackButton("« К списку", cbTransactions),
+	))
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.api.Send(msg)
+	return nil
+}
+
+// transactionEvents возвращает событие, к которому привязана транзакция, и идущее событие.
+// Без событий карточка просто не показывает кнопку, поэтому ошибка только логируется
+func (b *Bot) transactionEvents(ctx context.Context, userID int64, transaction *model.Transaction) (tagged, open *model.Event) {
+	events, err := b.service.GetEvents(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting events: %v", err)
+		return nil, nil
+	}
+	for i := range events {
+		if transaction.EventID != "" && events[i].ID == transaction.EventID {
+			tagged = &events[i]
+		}
+		if events[i].IsOpen() {
+			open = &events[i]
+		}
+	}
+	return tagged, open
+}
+
+// toggleTransactionEvent привязывает транзакцию к идущему событию или отвязывает от ее события
+func (b *Bot) toggleTransactionEvent(ctx context.Context, chatID, userID int64, transactionID string) error {
+	transaction, err := b.service.GetTransaction(ctx, userID, transactionID)
+	if err != nil {
+		b.sendServiceError(ctx, chatID, err, "Не удалось открыть транзакцию")
+		return fmt.Errorf("error getting transaction: %w", err)
+	}
+	eventID := ""
+	if transaction.EventID == "" {
+		open, err := b.service.OpenEvent(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("error getting open event: %w", err)
+		}
+		if open == nil {
+			b.sendErrorMessage(chatID, "Сейчас нет идущего события. Начать его можно в /event")
+			return nil
+		}
+		eventID = open.ID
+	}
+	if err := b.service.SetTransactionEvent(ctx, userID, transactionID, eventID); err != nil {
+		b.sendServiceError(ctx, chatID, err, "Не удалось изменить событие транзакции")
+		return fmt.Errorf("error setting transaction event: %w", err)
+	}
+	return nil
+}
+
+// sendTransactionPhoto присылает фото чека транзакции из хранилища
+func (b *Bot) sendTransactionPhoto(ctx context.Context, chatID, userID int64, transactionID string) error {
+	photo, err := b.service.GetTransactionPhoto(ctx, userID, transactionID)
+	if err != nil {
+		b.sendServiceError(ctx, chatID, err, "Не удалось загрузить фото чека")
+		return fmt.Errorf("error getting transaction photo: %w", err)
+	}
+	b.api.Send(tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "receipt.jpg", Bytes: photo}))
+	return nil
+}
+
+// startTransactionInput ждет заметку или фото к транзакции
+func (b *Bot) startTransactionInput(ctx context.Context, message *tgbotapi.Message, action model.StateAction, transactionID, prompt string) error {
+	state := &model.UserState{
+		UserID:         message.From.ID,
+		AwaitingAction: action,
+		Payload:        transactionID,
+	}
+	if err := b.saveUserState(ctx, state); err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Ошибка при сохранении состояния")
+		return fmt.Errorf("error saving user state: %w", err)
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, prompt)
+	msg.ReplyMarkup = cancelKeyboard
+	b.api.Send(msg)
+	return nil
+}
+
+// noteFromMessage сохраняет заметку к транзакции из состояния. Слишком длинную
+// заметку можно сразу ввести заново
+func (b *Bot) noteFromMessage(ctx context.Context, message *tgbotapi.Message, state *model.UserState) error {
+	note := message.Text
+	if note == "-" {
+		note = ""
+	}
+	_, err := b.service.SetTransactionNote(ctx, message.From.ID, state.Payload, note)
+	if errors.Is(err, service.ErrNoteTooLong) {
+		b.sendErrorMessage(message.Chat.ID, fmt.Sprintf("Заметка слишком длинная: не больше %d символов", service.MaxNoteLength))
+		return nil
+	}
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось сохранить заметку")
+		return fmt.Errorf("error saving transaction note: %w", err)
+	}
+	if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+		b.reportError(ctx, fmt.Errorf("error deleting user state: %w", err))
+	}
+	return b.showTransaction(ctx, message.Chat.ID, message.From.ID, state.Payload)
+}
+
+// businessFromMessage сохраняет реквизиты транзакции из состояния. Реквизиты с ошибкой
+// можно сразу ввести заново
+func (b *Bot) businessFromMessage(ctx context.Context, message *tgbotapi.Message, state *model.UserState) error {
+	_, err := b.service.SetTransactionBusiness(ctx, message.From.ID, state.Payload, message.Text)
+	if errors.Is(err, service.ErrNotBusinessLedger) {
+		if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+			b.reportError(ctx, fmt.Errorf("error deleting user state: %w", err))
+		}
+		b.sendErrorMessage(message.Chat.ID, "Бизнес-режим выключен. Включить его можно в книгах учета: /ledgers")
+		return nil
+	}
+	if errors.Is(err, model.ErrValidation) {
+		b.sendErrorMessage(message.Chat.ID, "Не удалось разобрать реквизиты. Пример:\nООО Ромашка\nсчет 42\nНДС 20%")
+		return nil
+	}
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось сохранить реквизиты")
+		return fmt.Errorf("error saving business details: %w", err)
+	}
+	if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+		b.reportError(ctx, fmt.Errorf("error deleting user state: %w", err))
+	}
+	return b.showTransaction(ctx, message.Chat.ID, message.From.ID, state.Payload)
+}
+
+// formatBusinessDetails описывает реквизиты транзакции бизнес-книги
+func formatBusinessDetails(transaction *model.Transaction) string {
+	if transaction.Counterparty == "" && transaction.InvoiceNumber == "" && transaction.VATAmount == 0 {
+		return "\n🏢 Контрагент не указан\n"
+	}
+	text := "\n"
+	if transaction.Counterparty != "" {
+		text += fmt.Sprintf("🏢 %s\n", transaction.Counterparty)
+	}
+	if transaction.InvoiceNumber != "" {
+		text += fmt.Sprintf("🧾 Счет № %s\n", transaction.InvoiceNumber)
+	}
+	if transaction.VATAmount > 0 {
+		text += fmt.Sprintf("НДС: %.2f₽\n", transaction.VATAmount)
+	} else {
+		text += "Без НДС\n"
+	}
+	return text
+}
+
+// attachPhotoFromMessage сохраняет присланное фото как чек транзакции из состояния
+func (b *Bot) attachPhotoFromMessage(ctx context.Context, message *tgbotapi.Message, state *model.UserState) error {
+	// Берем фотографию максимального размера
+	photo := message.Photo[len(message.Photo)-1]
+	data, err := b.downloadFile(photo.FileID)
+	if err != nil {
+		b.sendErrorMessage(message.Chat.ID, "Не удалось загрузить фотографию")
+		return fmt.Errorf("error downloading photo: %w", err)
+	}
+
+	err = b.service.AttachTransactionPhoto(ctx, message.From.ID, state.Payload, data)
+	if errors.Is(err, service.ErrAttachmentTooLarge) {
+		b.sendErrorMessage(message.Chat.ID, "Фото слишком большое, пришлите снимок поменьше")
+		return nil
+	}
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось сохранить фото чека")
+		return fmt.Errorf("error attaching transaction photo: %w", err)
+	}
+	if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+		b.reportError(ctx, fmt.Errorf("error deleting user state: %w", err))
+	}
+	return b.showTransaction(ctx, message.Chat.ID, message.From.ID, state.Payload)
+}
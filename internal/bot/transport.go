@@ -0,0 +1,73 @@
+package bot
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// Sender - запросы к Telegram, через которые бот отправляет сообщения и настраивает себя.
+// Send и SendMediaGroup бот выполняет через Request
+type Sender interface {
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+	MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error)
+	UploadFiles(endpoint string, params tgbotapi.Params, files []tgbotapi.RequestFile) (*tgbotapi.APIResponse, error)
+	GetMe() (tgbotapi.User, error)
+	GetFileDirectURL(fileID string) (string, error)
+	GetWebhookInfo() (tgbotapi.WebhookInfo, error)
+}
+
+// Receiver - получение обновлений в режиме long polling
+type Receiver interface {
+	GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel
+	GetUpdates(config tgbotapi.UpdateConfig) ([]tgbotapi.Update, error)
+	StopReceivingUpdates()
+}
+
+// Transport - связь бота с Telegram. Реализуется *tgbotapi.BotAPI, а для локальной
+// разработки и интеграционных тестов - fake.Transport без настоящего токена
+type Transport interface {
+	Sender
+	Receiver
+}
+
+var _ Transport = (*tgbotapi.BotAPI)(nil)
+
+// TelegramAPI - все, чем пользуются обработчики бота: транспорт и отправка сообщений.
+// В боте это транспорт с очередью отправки (rateLimitedAPI), в тестах - mocks.TelegramAPI
+type TelegramAPI interface {
+	Transport
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	SendMediaGroup(config tgbotapi.MediaGroupConfig) ([]tgbotapi.Message, error)
+}
+
+var _ TelegramAPI = (*rateLimitedAPI)(nil)
+
+// NewBotWithTransport создает бота поверх произвольного транспорта. Ограничения Telegram
+// на частоту отправки не применяются: они нужны только настоящему API
+func NewBotWithTransport(transport Transport, service *service.ExpenseTracker) *Bot {
+	return NewBotWithAPI(&rateLimitedAPI{Transport: transport}, service)
+}
+
+// NewBotWithAPI создает бота, который отправляет все запросы через api как есть,
+// например через mocks.TelegramAPI в модульных тестах обработчиков
+func NewBotWithAPI(api TelegramAPI, service *service.ExpenseTracker) *Bot {
+	// Кнопки ответов помечаются сессией меню, чтобы клавиатуры прошлых сессий распознавались
+	sessions := func(chatID int64) (int, bool) {
+		if service == nil {
+			return 0, false
+		}
+		return service.CachedMenuSession(chatID)
+	}
+	b := &Bot{
+		api:      &menuSessionAPI{TelegramAPI: api, session: sessions},
+		service:  service,
+		throttle: newUserThrottle(),
+		repeats:  newCallbackRepeats(),
+	}
+	b.commands = b.commandHandlers()
+	b.callbacks = b.callbackHandlers()
+	if service != nil {
+		service.AddPlugin(&budgetAlertPlugin{bot: b})
+	}
+	return b
+}
@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// SetAdmins задает пользователей с доступом к администрированию бота (/stats)
+func (b *Bot) SetAdmins(ids []int64) {
+	b.admins = ids
+}
+
+// isAdmin проверяет, что пользователь - администратор бота
+func (b *Bot) isAdmin(userID int64) bool {
+	for _, id := range b.admins {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// registryUser переводит пользователя Telegram в запись реестра
+func registryUser(user *tgbotapi.User) *model.User {
+	return &model.User{
+		ID:           user.ID,
+		Username:     user.UserName,
+		FirstName:    user.FirstName,
+		LanguageCode: user.LanguageCode,
+	}
+}
+
+// touchUser отмечает активность пользователя. Ошибка реестра не мешает обработке
+func (b *Bot) touchUser(ctx context.Context, user *tgbotapi.User) {
+	if err := b.service.TouchUser(ctx, registryUser(user)); err != nil {
+		log.Printf("Error updating user %d: %v", user.ID, err)
+	}
+}
+
+// handleStats показывает администратору сводку по пользователям
+func (b *Bot) handleStats(ctx context.Context, message *tgbotapi.Message) {
+	if !b.isAdmin(message.From.ID) {
+		return
+	}
+
+	stats, err := b.service.GetUserStats(ctx, time.Now())
+	if err != nil {
+		log.Printf("Error getting user stats: %v", err)
+		b.sendErrorMessage(message.Chat.ID, "Не удалось получить статистику")
+		return
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "👥 Пользователей: %d\n", stats.Total)
+	fmt.Fprintf(&text, "🆕 Новых за неделю: %d\n\n", stats.NewWeek)
+	text.WriteString("Активны:\n")
+	fmt.Fprintf(&text, "• за сутки - %d\n", stats.ActiveDay)
+	fmt.Fprintf(&text, "• за неделю - %d\n", stats.ActiveWeek)
+	fmt.Fprintf(&text, "• за месяц - %d\n", stats.ActiveMonth)
+	if len(stats.Languages) > 0 {
+		text.WriteString("\nЯзыки:")
+		for _, language := range stats.Languages {
+			fmt.Fprintf(&text, " %s - %d,", language.Language, language.Users)
+		}
+	}
+
+	b.api.Send(tgbotapi.NewMessage(message.Chat.ID, strings.TrimSuffix(text.String(), ",")))
+}
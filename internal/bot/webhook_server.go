@@ -0,0 +1,177 @@
+package bot
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// SecretTokenHeader - заголовок с секретом, заданным при регистрации webhook
+	SecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+	// maxWebhookBody - обновления Telegram намного меньше, больше читать не нужно
+	maxWebhookBody = 1 << 20
+	// webhookShutdownTimeout - сколько ждать обработки принятых обновлений при остановке сервера
+	webhookShutdownTimeout = 30 * time.Second
+)
+
+// WebhookOptions - параметры регистрации webhook в Telegram
+type WebhookOptions struct {
+	URL         string // публичный HTTPS-адрес, на который Telegram отправляет обновления
+	Secret      string // секрет заголовка X-Telegram-Bot-Api-Secret-Token, пусто - без секрета
+	Certificate string // самоподписанный сертификат для Telegram, пусто - не отправляется
+	DropPending bool   // удалить обновления, накопленные до регистрации
+}
+
+// ValidSecretToken сравнивает секрет из заголовка запроса с ожидаемым за постоянное время.
+// Пустой ожидаемый секрет не проверяется
+func ValidSecretToken(got, secret string) bool {
+	return secret == "" || subtle.ConstantTimeCompare([]byte(got), []byte(secret)) == 1
+}
+
+// SetWebhook регистрирует webhook в Telegram. Библиотека не поддерживает secret_token,
+// поэтому запрос собирается вручную
+func (b *Bot) SetWebhook(opts WebhookOptions) error {
+	params := tgbotapi.Params{"url": opts.URL}
+	params.AddNonEmpty("secret_token", opts.Secret)
+	params.AddBool("drop_pending_updates", opts.DropPending)
+
+	var err error
+	if opts.Certificate != "" {
+		_, err = b.api.UploadFiles("setWebhook", params, []tgbotapi.RequestFile{
+			{Name: "certificate", Data: tgbotapi.FilePath(opts.Certificate)},
+		})
+	} else {
+		_, err = b.api.MakeRequest("setWebhook", params)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set webhook: %w", err)
+	}
+	return nil
+}
+
+// DeleteWebhook снимает webhook. Накопленные обновления сохраняются, если не dropPending
+func (b *Bot) DeleteWebhook(dropPending bool) error {
+	params := tgbotapi.Params{}
+	params.AddBool("drop_pending_updates", dropPending)
+	if _, err := b.api.MakeRequest("deleteWebhook", params); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// WebhookInfo возвращает состояние webhook: адрес, очередь и последнюю ошибку доставки
+func (b *Bot) WebhookInfo() (tgbotapi.WebhookInfo, error) {
+	info, err := b.api.GetWebhookInfo()
+	if err != nil {
+		return info, fmt.Errorf("failed to get webhook info: %w", err)
+	}
+	return info, nil
+}
+
+// WebhookHandler принимает обновления Telegram по HTTP, проверяя секрет из заголовка
+func (b *Bot) WebhookHandler(secret string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		// Без секрета любой, кто знает адрес, мог бы писать от имени пользователей
+		if !ValidSecretToken(r.Header.Get(SecretTokenHeader), secret) {
+			log.Printf("Rejected webhook request with invalid secret token")
+			http.Error(w, "invalid secret token", http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+		if err != nil {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+
+		// Начатую обработку не прерываем, даже если Telegram закрыл соединение
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), updateTimeout)
+		defer cancel()
+		if err := b.HandleWebhook(ctx, body); err != nil {
+			log.Printf("Error handling webhook update: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// StartWebhook принимает обновления через webhook до отмены ctx или вызова Stop: слушает addr,
+// регистрирует webhook в Telegram и при остановке снимает его, чтобы обновления копились
+// у Telegram до следующего запуска. С certFile и keyFile сервер сам обслуживает HTTPS,
+// без них ожидается прокси, завершающий TLS
+func (b *Bot) StartWebhook(ctx context.Context, addr, certFile, keyFile string, opts WebhookOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer close(b.registerStop(cancel))
+
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %w", err)
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, b.WebhookHandler(opts.Secret))
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Порт открывается до регистрации, чтобы первые обновления не получили отказ
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	served := make(chan error, 1)
+	go func() {
+		if certFile != "" {
+			served <- server.ServeTLS(listener, certFile, keyFile)
+		} else {
+			served <- server.Serve(listener)
+		}
+	}()
+
+	if err := b.SetWebhook(opts); err != nil {
+		server.Close()
+		return err
+	}
+	log.Printf("Webhook server listening on %s, path %q", addr, path)
+	b.startJobs(ctx)
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+	case serveErr = <-served:
+	}
+
+	if err := b.DeleteWebhook(false); err != nil {
+		log.Printf("Error deleting webhook: %v", err)
+	}
+	if serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+		return fmt.Errorf("webhook server failed: %w", serveErr)
+	}
+
+	shutdownCtx, stop := context.WithTimeout(context.WithoutCancel(ctx), webhookShutdownTimeout)
+	defer stop()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("error waiting for webhook requests to drain: %w", err)
+	}
+	return nil
+}
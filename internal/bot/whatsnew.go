@@ -0,0 +1,63 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// maxWhatsNew - количество объявлений в /whatsnew
+const maxWhatsNew = 5
+
+// updateSender возвращает автора обновления и чат для ответа
+func updateSender(update tgbotapi.Update) (*tgbotapi.User, int64) {
+	switch {
+	case update.Message != nil:
+		return update.Message.From, update.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		return update.CallbackQuery.From, update.CallbackQuery.Message.Chat.ID
+	}
+	return nil, 0
+}
+
+// showAnnouncements однократно показывает пользователю новости о новых возможностях
+func (b *Bot) showAnnouncements(ctx context.Context, user *tgbotapi.User, chatID int64) {
+	pending, err := b.service.PendingAnnouncements(ctx, user.ID)
+	if err != nil {
+		log.Printf("Error getting announcements: %v", err)
+		return
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "🆕 *Что нового*\n\n"+formatAnnouncements(pending))
+	msg.ParseMode = "Markdown"
+	b.api.Send(msg)
+}
+
+// handleWhatsNew показывает последние объявления
+func (b *Bot) handleWhatsNew(ctx context.Context, message *tgbotapi.Message) {
+	// Отмечаем объявления показанными, чтобы они не пришли повторно после ответа
+	if _, err := b.service.PendingAnnouncements(ctx, message.From.ID); err != nil {
+		log.Printf("Error marking announcements: %v", err)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID,
+		"🆕 *Что нового*\n\n"+formatAnnouncements(service.RecentAnnouncements(maxWhatsNew)))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+}
+
+// formatAnnouncements формирует текст списка объявлений
+func formatAnnouncements(announcements []service.Announcement) string {
+	text := ""
+	for _, a := range announcements {
+		text += fmt.Sprintf("*%s*\n%s\n\n", a.Title, a.Text)
+	}
+	return text
+}
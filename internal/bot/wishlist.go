@@ -0,0 +1,139 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/locale"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// handleWishlist добавляет покупку в список желаний: /wishlist 80000 Ноутбук.
+// Без аргументов показывает список
+func (b *Bot) handleWishlist(ctx context.Context, message *tgbotapi.Message) {
+	args := strings.SplitN(strings.TrimSpace(message.CommandArguments()), " ", 2)
+	if len(args) < 2 {
+		b.showWishlist(ctx, message)
+		return
+	}
+
+	target, err := strconv.ParseFloat(strings.ReplaceAll(args[0], ",", "."), 64)
+	if err != nil || target <= 0 {
+		b.sendErrorMessage(message.Chat.ID, "Неверный формат суммы. Используйте: /wishlist 80000 Ноутбук")
+		return
+	}
+	item, err := b.service.AddWishlistItem(ctx, message.From.ID, args[1], target)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось добавить покупку")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf(
+		"«%s» на %.0f₽ добавлено в список желаний 🎁\nОткладывайте на покупку в /wishlist",
+		item.Name, item.TargetAmount))
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+}
+
+// showWishlist показывает покупки, накопленное на них и когда их можно будет купить
+func (b *Bot) showWishlist(ctx context.Context, message *tgbotapi.Message) {
+	wishlist, err := b.service.GetWishlist(ctx, message.From.ID)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось загрузить список желаний")
+		return
+	}
+
+	text := "🎁 *Список желаний*\n\n"
+	if len(wishlist) == 0 {
+		text += "Список пока пуст\n"
+	}
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	for _, progress := range wishlist {
+		text += formatWishlistProgress(ctx, progress) + "\n"
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			callbackButton("➕ "+progress.Item.Name, cbWishlist, "add", progress.Item.ID),
+			callbackButton("🗑", cbWishlist, "del", progress.Item.ID),
+		))
+	}
+	text += "\nДобавить покупку: `/wishlist 80000 Ноутбук`"
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(callbackButton("« В меню", cbMenu)))
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	b.api.Send(msg)
+}
+
+// formatWishlistProgress описывает покупку: накоплено, темп и срок
+func formatWishlistProgress(ctx context.Context, progress service.WishlistProgress) string {
+	text := fmt.Sprintf("*%s*: %.0f₽ из %.0f₽ (%.0f%%)\n", progress.Item.Name,
+		progress.Saved, progress.Item.TargetAmount, progress.Saved/progress.Item.TargetAmount*100)
+	switch {
+	case progress.Affordable():
+		text += "✅ Вся сумма отложена, можно покупать\n"
+	case progress.AffordableAt.IsZero():
+		text += "Отложите первую сумму, чтобы узнать срок покупки\n"
+	default:
+		text += fmt.Sprintf("📅 Срок покупки: %s, если откладывать ~%.0f₽ в месяц\n",
+			locale.FormatMonth(locale.FromContext(ctx), progress.AffordableAt), progress.MonthlyPace)
+	}
+	return text
+}
+
+// handleWishlistCallback начинает ввод суммы для покупки или удаляет ее из списка
+func (b *Bot) handleWishlistCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, args callbackArgs) error {
+	chatID := callback.Message.Chat.ID
+	itemID := args.String(1)
+	switch args.String(0) {
+	case "add":
+		state := &model.UserState{
+			UserID:         callback.From.ID,
+			AwaitingAction: model.StateWishlistAmount,
+			Payload:        itemID,
+		}
+		if err := b.saveUserState(ctx, state); err != nil {
+			return fmt.Errorf("error saving user state: %w", err)
+		}
+		msg := tgbotapi.NewMessage(chatID, "Сколько отложить на покупку? Отправьте сумму")
+		msg.ReplyMarkup = cancelKeyboard
+		b.api.Send(msg)
+	case "del":
+		if err := b.service.DeleteWishlistItem(ctx, callback.From.ID, itemID); err != nil {
+			b.sendServiceError(ctx, chatID, err, "Не удалось удалить покупку")
+			return nil
+		}
+		b.showWishlist(ctx, callbackMessage(callback))
+	default:
+		return fmt.Errorf("unknown wishlist action: %s", callback.Data)
+	}
+	return nil
+}
+
+// allocateFromMessage откладывает на покупку сумму из сообщения
+func (b *Bot) allocateFromMessage(ctx context.Context, message *tgbotapi.Message, state *model.UserState) error {
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(message.Text), ",", "."), 64)
+	if err != nil || amount <= 0 {
+		b.sendErrorMessage(message.Chat.ID, "Отправьте сумму числом, например 5000")
+		return nil
+	}
+
+	progress, err := b.service.AllocateToWishlist(ctx, message.From.ID, state.Payload, amount)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось отложить сумму")
+		return fmt.Errorf("error allocating to wishlist: %w", err)
+	}
+	if err := b.deleteUserState(ctx, message.From.ID); err != nil {
+		b.reportError(ctx, fmt.Errorf("error deleting user state: %w", err))
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID,
+		fmt.Sprintf("Отложено %.0f₽ 💰\n\n", amount)+formatWishlistProgress(ctx, *progress))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = b.getMainKeyboard()
+	b.api.Send(msg)
+	return nil
+}
@@ -0,0 +1,152 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanoskov/financial_bot/internal/locale"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// championMedals - значки крупнейших категорий года
+var championMedals = []string{"🥇", "🥈", "🥉"}
+
+// reviewYear возвращает год, итоги которого уместны сейчас: в январе - прошедший,
+// иначе текущий
+func reviewYear(now time.Time) int {
+	if now.Month() == time.January {
+		return now.Year() - 1
+	}
+	return now.Year()
+}
+
+// handleYearReview присылает итоги года: «/year_review 2024» - за указанный год,
+// без года - за текущий, а в январе за прошедший
+func (b *Bot) handleYearReview(ctx context.Context, message *tgbotapi.Message) {
+	now := time.Now()
+	year := reviewYear(now)
+	if arg := strings.TrimSpace(message.CommandArguments()); arg != "" {
+		parsed, err := strconv.Atoi(arg)
+		if err != nil || parsed > now.Year() {
+			b.sendErrorMessage(message.Chat.ID, "Укажите прошедший или текущий год, например: /year_review 2024")
+			return
+		}
+		year = parsed
+	}
+
+	sent, err := b.sendYearReview(ctx, message.Chat.ID, message.From.ID, year)
+	if err != nil {
+		b.sendServiceError(ctx, message.Chat.ID, err, "Не удалось подвести итоги года")
+		return
+	}
+	if !sent {
+		b.api.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("За %d год операций не записано", year)))
+	}
+}
+
+// SendYearReview присылает итоги года с графиками. Возвращает false, если за год
+// не было операций и итогов нет
+func (b *Bot) SendYearReview(ctx context.Context, userID int64, year int) (bool, error) {
+	return b.sendYearReview(ctx, userID, userID, year)
+}
+
+func (b *Bot) sendYearReview(ctx context.Context, chatID, userID int64, year int) (bool, error) {
+	review, err := b.service.GetYearReview(ctx, userID, year)
+	if err != nil {
+		return false, fmt.Errorf("error getting year review: %w", err)
+	}
+	if review.Transactions == 0 {
+		return false, nil
+	}
+
+	msg := tgbotapi.NewMessage(chatID, formatYearReview(ctx, review))
+	msg.ParseMode = "Markdown"
+	if _, err := b.api.Send(msg); err != nil {
+		return false, err
+	}
+
+	// Графики дополняют итоги: те, что не построились, просто не попадают в альбом
+	delivery := b.chartDelivery(ctx, userID)
+	type yearChart struct {
+		name, caption string
+		data          []byte
+	}
+	var bundle []yearChart
+	for _, c := range []struct {
+		name, caption string
+		generate      func(*service.YearReview) ([]byte, error)
+	}{
+		{"year_categories", "📊 Расходы по месяцам и категориям", delivery.charts.GenerateYearCategoryChart},
+		{"year_savings", "💵 Доля сохраненного дохода", delivery.charts.GenerateYearSavingsChart},
+		{"year_heatmap", "🗓 Траты по дням", delivery.charts.GenerateYearHeatmap},
+	} {
+		data, err := c.generate(review)
+		if err != nil {
+			log.Printf("Error generating %s chart: %v", c.name, err)
+			continue
+		}
+		bundle = append(bundle, yearChart{name: c.name, caption: c.caption, data: data})
+	}
+	switch len(bundle) {
+	case 0:
+	case 1:
+		// Альбом из одного графика Telegram не принимает
+		b.api.Send(delivery.captioned(chatID, bundle[0].name, bundle[0].data, bundle[0].caption))
+	default:
+		var media []interface{}
+		for _, c := range bundle {
+			media = append(media, delivery.media(c.name, c.data, c.caption))
+		}
+		if _, err := b.api.SendMediaGroup(tgbotapi.NewMediaGroup(chatID, media)); err != nil {
+			return true, fmt.Errorf("error sending year review charts: %w", err)
+		}
+	}
+	return true, nil
+}
+
+// formatYearReview описывает итоги года: суммы, лучший и худший месяц, крупнейшие категории
+func formatYearReview(ctx context.Context, review *service.YearReview) string {
+	lang := locale.FromContext(ctx)
+	month := func(i int) string {
+		return locale.MonthName(lang, review.Months[i].Month())
+	}
+
+	text := fmt.Sprintf("🎉 *Итоги %d года*\n", review.Year)
+	if last := review.Months[len(review.Months)-1]; last.Month() != time.December {
+		text = fmt.Sprintf("🎉 *Итоги %d года* (январь - %s)\n", review.Year, month(len(review.Months)-1))
+	}
+	income, expenses := review.TotalIncome(), review.TotalExpenses()
+	text += fmt.Sprintf("\n💰 Доходы: %.0f₽\n", income)
+	text += fmt.Sprintf("💸 Расходы: %.0f₽, в среднем %.0f₽ в месяц\n", expenses, expenses/float64(len(review.Months)))
+	switch balance := income - expenses; {
+	case income > 0 && balance >= 0:
+		text += fmt.Sprintf("💵 Сохранено: %.0f₽ - %.0f%% дохода\n", balance, review.SavingsRate())
+	case balance < 0:
+		text += fmt.Sprintf("💵 Расходы превысили доходы на %.0f₽\n", -balance)
+	}
+	text += fmt.Sprintf("📝 Операций: %d\n", review.Transactions)
+
+	if len(review.Months) > 1 {
+		best, worst := review.BestMonth(), review.WorstMonth()
+		text += fmt.Sprintf("\n🌟 Лучший месяц: %s, баланс %+.0f₽\n", month(best), review.Income[best]-review.Expenses[best])
+		text += fmt.Sprintf("🌧 Худший месяц: %s, баланс %+.0f₽\n", month(worst), review.Income[worst]-review.Expenses[worst])
+	}
+
+	if len(review.Categories) > 0 {
+		text += "\n🏆 *Чемпионы трат:*\n"
+		for i, category := range review.Categories {
+			if i == len(championMedals) {
+				break
+			}
+			peak := category.PeakMonth()
+			text += fmt.Sprintf("%s %s: %.0f₽ (%.0f%%), больше всего - %s\n", championMedals[i],
+				strings.TrimSpace(category.Emoji+" "+category.Name), category.Total, category.Share*100, month(peak))
+		}
+	}
+	return strings.TrimSpace(text)
+}
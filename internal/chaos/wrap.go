@@ -0,0 +1,12 @@
+//go:build !chaos
+
+// Package chaos подключает внесение сбоев в репозиторий: в сборке с тегом chaos
+// репозиторий оборачивается repository.ChaosRepository, в обычной остается как есть
+package chaos
+
+import "github.com/ivanoskov/financial_bot/internal/repository"
+
+// Wrap возвращает репозиторий без изменений
+func Wrap(repo repository.Repository) repository.Repository {
+	return repo
+}
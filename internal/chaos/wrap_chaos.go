@@ -0,0 +1,16 @@
+//go:build chaos
+
+package chaos
+
+import (
+	"log"
+
+	"github.com/ivanoskov/financial_bot/internal/repository"
+)
+
+// Wrap оборачивает репозиторий внесением сбоев согласно переменным CHAOS_*
+func Wrap(repo repository.Repository) repository.Repository {
+	cfg := repository.ChaosConfigFromEnv()
+	log.Printf("Chaos mode enabled: %+v", cfg)
+	return repository.NewChaosRepository(repo, cfg)
+}
@@ -0,0 +1,65 @@
+//go:build chaos
+
+package chaos
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/repository"
+)
+
+// categoryRepository хранит категории в памяти. Остальные методы не нужны тестам
+type categoryRepository struct {
+	repository.Repository
+	categories []model.Category
+}
+
+func (r *categoryRepository) CreateCategory(ctx context.Context, category *model.Category) error {
+	r.categories = append(r.categories, *category)
+	return nil
+}
+
+func (r *categoryRepository) GetCategories(ctx context.Context, userID int64) ([]model.Category, error) {
+	return r.categories, nil
+}
+
+func TestWrapInjectsError(t *testing.T) {
+	t.Setenv("CHAOS_ERROR_RATE", "1")
+	t.Setenv("CHAOS_SEED", "42")
+	repo := &categoryRepository{}
+
+	err := Wrap(repo).CreateCategory(context.Background(), &model.Category{Name: "Продукты"})
+	if !errors.Is(err, repository.ErrChaos) || !errors.Is(err, model.ErrStorageUnavailable) {
+		t.Fatalf("CreateCategory: %v, ожидалась внесенная ошибка хранилища", err)
+	}
+	if len(repo.categories) != 0 {
+		t.Errorf("при внесенной ошибке запись не должна доходить до базы: %+v", repo.categories)
+	}
+}
+
+func TestWrapInjectsPartialFailure(t *testing.T) {
+	t.Setenv("CHAOS_PARTIAL_RATE", "1")
+	t.Setenv("CHAOS_SEED", "42")
+	repo := &categoryRepository{}
+	wrapped := Wrap(repo)
+
+	// Запись сохраняется, но вызывающая сторона получает ошибку
+	for _, name := range []string{"Продукты", "Транспорт"} {
+		err := wrapped.CreateCategory(context.Background(), &model.Category{Name: name})
+		if !errors.Is(err, repository.ErrChaosPartial) {
+			t.Fatalf("CreateCategory: %v, ожидался частичный сбой", err)
+		}
+	}
+	if len(repo.categories) != 2 {
+		t.Fatalf("сохранено категорий: %d, ожидалось 2", len(repo.categories))
+	}
+
+	// Чтение возвращает половину данных без ошибки
+	categories, err := wrapped.GetCategories(context.Background(), 1)
+	if err != nil || len(categories) != 1 {
+		t.Errorf("GetCategories: %d категорий, %v; ожидалась одна без ошибки", len(categories), err)
+	}
+}
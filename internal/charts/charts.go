@@ -7,16 +7,21 @@ import (
 	"math"
 	"time"
 
+	"github.com/ivanoskov/financial_bot/internal/model"
 	"github.com/ivanoskov/financial_bot/internal/service"
 	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
 )
 
 // ChartGenerator генерирует различные типы графиков
-type ChartGenerator struct{}
+type ChartGenerator struct {
+	format string
+	scale  float64
+}
 
-// NewChartGenerator создает новый генератор графиков
+// NewChartGenerator создает новый генератор графиков в PNG
 func NewChartGenerator() *ChartGenerator {
-	return &ChartGenerator{}
+	return &ChartGenerator{format: FormatPNG, scale: DefaultScale}
 }
 
 // calculateMovingAverage вычисляет скользящее среднее
@@ -48,6 +53,9 @@ func (g *ChartGenerator) GenerateFinancialDashboard(report *service.BaseReport)
 	if len(report.Trends.ExpenseTrend) == 0 && len(report.Trends.IncomeTrend) == 0 {
 		return nil, nil // Возвращаем nil, если нет данных для графика
 	}
+	if len(report.Trends.ExpenseTrend) < MinLinePoints {
+		return g.generateDailyBars(fmt.Sprintf("Финансовый обзор за %s", report.Period), report)
+	}
 
 	// Подготавливаем данные для графика трат и доходов
 	xValues := make([]time.Time, len(report.Trends.ExpenseTrend))
@@ -162,7 +170,7 @@ func (g *ChartGenerator) GenerateFinancialDashboard(report *service.BaseReport)
 
 	// Рендерим график
 	buffer := bytes.NewBuffer([]byte{})
-	err := graph.Render(chart.PNG, buffer)
+	err := graph.Render(g.renderer(), buffer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to render financial dashboard: %w", err)
 	}
@@ -213,7 +221,7 @@ func (g *ChartGenerator) GenerateCategoryAnalysis(report *service.BaseReport) ([
 
 	// Рендерим график
 	buffer := bytes.NewBuffer([]byte{})
-	err := pie.Render(chart.PNG, buffer)
+	err := pie.Render(g.renderer(), buffer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to render category analysis: %w", err)
 	}
@@ -223,6 +231,13 @@ func (g *ChartGenerator) GenerateCategoryAnalysis(report *service.BaseReport) ([
 
 // GenerateExpenseChart создает график расходов
 func (g *ChartGenerator) GenerateExpenseChart(report *service.BaseReport) ([]byte, error) {
+	if len(report.Trends.ExpenseTrend) == 0 {
+		return nil, nil
+	}
+	if len(report.Trends.ExpenseTrend) < MinLinePoints {
+		return g.generateDailyBars(fmt.Sprintf("Динамика доходов и расходов за %s", report.Period), report)
+	}
+
 	// Подготавливаем данные
 	xValues := make([]time.Time, len(report.Trends.ExpenseTrend))
 	expenseValues := make([]float64, len(report.Trends.ExpenseTrend))
@@ -297,7 +312,7 @@ func (g *ChartGenerator) GenerateExpenseChart(report *service.BaseReport) ([]byt
 	}
 
 	buffer := bytes.NewBuffer([]byte{})
-	err := graph.Render(chart.PNG, buffer)
+	err := graph.Render(g.renderer(), buffer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to render expense chart: %w", err)
 	}
@@ -337,13 +352,19 @@ func (g *ChartGenerator) GenerateCategoryPieChart(report *service.BaseReport, is
 		absAmount := math.Abs(cat.Amount)
 		percentage := (absAmount / total) * 100
 		if percentage > 1.0 {
+			style := chart.Style{
+				FontSize:  12,
+				FontColor: chart.ColorBlack,
+			}
+			// Цвет, выбранный пользователем для категории; иначе цвет из палитры графика
+			if model.ValidCategoryColor(cat.Color) {
+				style.FillColor = drawing.ColorFromHex(cat.Color)
+				style.StrokeColor = chart.ColorWhite
+			}
 			values = append(values, chart.Value{
 				Label: fmt.Sprintf("%s: %.0f₽ (%.1f%%)", cat.Name, absAmount, percentage),
 				Value: absAmount,
-				Style: chart.Style{
-					FontSize:  12,
-					FontColor: chart.ColorBlack,
-				},
+				Style: style,
 			})
 			log.Printf("Добавлена секция для %s: сумма=%.2f, доля=%.2f%%", cat.Name, absAmount, percentage)
 		}
@@ -366,7 +387,7 @@ func (g *ChartGenerator) GenerateCategoryPieChart(report *service.BaseReport, is
 	}
 
 	buffer := bytes.NewBuffer([]byte{})
-	err := pie.Render(chart.PNG, buffer)
+	err := pie.Render(g.renderer(), buffer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to render category pie chart: %w", err)
 	}
@@ -376,6 +397,15 @@ func (g *ChartGenerator) GenerateCategoryPieChart(report *service.BaseReport, is
 
 // GenerateTrendChart создает график трендов
 func (g *ChartGenerator) GenerateTrendChart(report *service.BaseReport) ([]byte, error) {
+	if len(report.Trends.ExpenseTrend) == 0 {
+		return nil, nil
+	}
+	// Отклонение от среднего по одному-двум дням ничего не говорит
+	if len(report.Trends.ExpenseTrend) < MinLinePoints {
+		return g.generateNoDataCard(fmt.Sprintf("Тренды изменений за %s", report.Period),
+			fmt.Sprintf("Тренды появятся, когда операции будут хотя бы в %d днях", MinLinePoints))
+	}
+
 	// Подготавливаем данные
 	xValues := make([]time.Time, len(report.Trends.ExpenseTrend))
 	expenseChanges := make([]float64, len(report.Trends.ExpenseTrend))
@@ -453,7 +483,7 @@ func (g *ChartGenerator) GenerateTrendChart(report *service.BaseReport) ([]byte,
 	}
 
 	buffer := bytes.NewBuffer([]byte{})
-	err := graph.Render(chart.PNG, buffer)
+	err := graph.Render(g.renderer(), buffer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to render trend chart: %w", err)
 	}
@@ -461,81 +491,857 @@ func (g *ChartGenerator) GenerateTrendChart(report *service.BaseReport) ([]byte,
 	return buffer.Bytes(), nil
 }
 
-// GenerateBalanceChart создает график баланса
+// TrendAverageDays - окно скользящего среднего на графике трендов в рублях
+const TrendAverageDays = 7
+
+// GenerateAbsoluteTrendChart создает график трендов в рублях: траты по дням, их скользящее
+// среднее за неделю и, если dailyBudget больше нуля, линию бюджетов в пересчете на день
+func (g *ChartGenerator) GenerateAbsoluteTrendChart(report *service.BaseReport, dailyBudget float64) ([]byte, error) {
+	title := fmt.Sprintf("Расходы по дням за %s", report.Period)
+	days := TrendDays(report)
+	if days == 0 {
+		return nil, nil
+	}
+	if days < MinLinePoints {
+		return g.generateDailyBars(title, report)
+	}
+
+	xValues := make([]time.Time, days)
+	expenses := make([]float64, days)
+	top := dailyBudget
+	for i, point := range report.Trends.ExpenseTrend[:days] {
+		xValues[i] = point.Date
+		expenses[i] = math.Abs(point.Amount)
+		top = math.Max(top, expenses[i])
+	}
+	if top == 0 {
+		top = 1
+	}
+	// Запас сверху оставляет место легенде над пиком
+	top = math.Ceil(top*1.4/niceStep(top/5)) * niceStep(top/5)
+
+	series := []chart.Series{
+		chart.TimeSeries{
+			Name:    "Расходы за день",
+			XValues: xValues,
+			YValues: expenses,
+			Style: chart.Style{
+				StrokeColor: chart.ColorRed.WithAlpha(100),
+				StrokeWidth: 1,
+			},
+		},
+		chart.TimeSeries{
+			Name:    fmt.Sprintf("Среднее за %d дней", TrendAverageDays),
+			XValues: xValues,
+			YValues: calculateMovingAverage(expenses, TrendAverageDays),
+			Style: chart.Style{
+				StrokeColor: chart.ColorRed,
+				StrokeWidth: 3,
+			},
+		},
+	}
+	if dailyBudget > 0 {
+		budget := make([]float64, len(xValues))
+		for i := range budget {
+			budget[i] = dailyBudget
+		}
+		series = append(series, chart.TimeSeries{
+			Name:    fmt.Sprintf("Бюджет в день: %.0f₽", dailyBudget),
+			XValues: xValues,
+			YValues: budget,
+			Style: chart.Style{
+				StrokeColor:     chart.ColorBlue,
+				StrokeWidth:     2,
+				StrokeDashArray: []float64{8, 6},
+			},
+		})
+	}
+
+	graph := chart.Chart{
+		Title:  title,
+		Width:  1200,
+		Height: 600,
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top:    50,
+				Left:   50,
+				Right:  50,
+				Bottom: 50,
+			},
+			FillColor: chart.ColorWhite,
+		},
+		XAxis: chart.XAxis{
+			ValueFormatter: chart.TimeValueFormatterWithFormat("02.01"),
+			Style: chart.Style{
+				FontSize:  12,
+				FontColor: chart.ColorBlack,
+			},
+		},
+		YAxis: chart.YAxis{
+			ValueFormatter: func(v interface{}) string {
+				return fmt.Sprintf("%.0f₽", v.(float64))
+			},
+			Style: chart.Style{
+				FontSize:  12,
+				FontColor: chart.ColorBlack,
+			},
+			// Ось от нуля: иначе небольшие колебания трат выглядят скачками
+			Range: &chart.ContinuousRange{
+				Min: 0,
+				Max: top,
+			},
+		},
+		Series: series,
+	}
+	graph.Elements = []chart.Renderable{
+		chart.Legend(&graph, chart.Style{
+			FontSize:  12,
+			FontColor: chart.ColorBlack,
+		}),
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	if err := graph.Render(g.renderer(), buffer); err != nil {
+		return nil, fmt.Errorf("failed to render absolute trend chart: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// GenerateBalanceChart создает график сравнения с прошлым периодом: по доходам, расходам
+// и балансу пара столбцов «прошлый - текущий» от общей нулевой линии, с суммами над
+// столбцами и изменением под названием показателя
 func (g *ChartGenerator) GenerateBalanceChart(report *service.BaseReport) ([]byte, error) {
+	comparison := report.Trends.PeriodComparison
+	prev, current := comparison.PrevPeriod, comparison.CurrentPeriod
+	percent := func(change, prevValue float64) string {
+		if prevValue == 0 {
+			return "нет данных за прошлый период"
+		}
+		return fmt.Sprintf("%+.0f%%", change)
+	}
+	groups := []barGroup{
+		{"Доходы", prev.TotalIncome, current.TotalIncome, chart.ColorGreen, percent(comparison.IncomeChange, prev.TotalIncome)},
+		{"Расходы", prev.TotalExpenses, current.TotalExpenses, chart.ColorRed, percent(comparison.ExpenseChange, prev.TotalExpenses)},
+		// Процент изменения баланса, сменившего знак, ничего не говорит, поэтому - разница в рублях
+		{"Баланс", prev.Balance, current.Balance, chart.ColorBlue, fmt.Sprintf("%+.0f₽", current.Balance-prev.Balance)},
+	}
+	data, err := g.renderGroupedBars(fmt.Sprintf("Сравнение с прошлым периодом за %s", report.Period), groups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render balance chart: %w", err)
+	}
+	return data, nil
+}
+
+// barGroup - показатель на графике сравнения: прошлое и текущее значение
+type barGroup struct {
+	Label   string
+	Prev    float64
+	Current float64
+	Color   drawing.Color
+	Change  string // подпись изменения под названием
+}
+
+// renderGroupedBars рисует пары столбцов «прошлый - текущий» по показателям. go-chart
+// не умеет группировать столбцы и подписывать значения, поэтому график рисуется напрямую
+func (g *ChartGenerator) renderGroupedBars(title string, groups []barGroup) ([]byte, error) {
+	const (
+		width, height       = 1200, 600
+		left, right         = 120, 1150
+		top, bottom         = 120, 500
+		barWidth, barGap    = 110, 16
+		textSize, titleSize = 12.0, 14.0
+	)
+	r, err := g.renderer()(width, height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create renderer: %w", err)
+	}
+	font, err := chart.GetDefaultFont()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load font: %w", err)
+	}
+	text := func(body string, x, y int, size float64, color drawing.Color, centered bool) {
+		style := chart.Style{Font: font, FontSize: size, FontColor: color}
+		if centered {
+			x -= chart.Draw.MeasureText(r, body, style).Width() / 2
+		}
+		chart.Draw.Text(r, body, x, y, style)
+	}
+	line := func(x1, y1, x2, y2 int, color drawing.Color, width float64) {
+		r.SetStrokeColor(color)
+		r.SetStrokeWidth(width)
+		r.MoveTo(x1, y1)
+		r.LineTo(x2, y2)
+		r.Stroke()
+	}
+
+	chart.Draw.Box(r, chart.NewBox(0, 0, width, height), chart.Style{
+		FillColor:   chart.ColorWhite,
+		StrokeColor: chart.ColorWhite,
+	})
+	text(title, width/2, 40, titleSize, chart.ColorBlack, true)
+
+	// Легенда: светлый столбец - прошлый период, насыщенный - текущий
+	legend := func(x int, label string, color drawing.Color) {
+		chart.Draw.Box(r, chart.NewBox(68, x, x+14, 82), chart.Style{FillColor: color, StrokeColor: color})
+		text(label, x+22, 80, textSize, chart.ColorBlack, false)
+	}
+	legend(width/2-170, "Прошлый период", chart.ColorAlternateGray.WithAlpha(100))
+	legend(width/2+30, "Текущий период", chart.ColorAlternateGray)
+
+	// Шкала всегда включает ноль, чтобы столбцы росли от общей линии
+	minValue, maxValue := 0.0, 0.0
+	for _, group := range groups {
+		minValue = math.Min(minValue, math.Min(group.Prev, group.Current))
+		maxValue = math.Max(maxValue, math.Max(group.Prev, group.Current))
+	}
+	if maxValue == minValue {
+		maxValue = 1
+	}
+	step := niceStep((maxValue - minValue) / 5)
+	minValue = math.Floor(minValue/step) * step
+	maxValue = math.Ceil(maxValue/step) * step
+	y := func(v float64) int {
+		return bottom - int((v-minValue)/(maxValue-minValue)*float64(bottom-top))
+	}
+
+	for tick := minValue; tick <= maxValue+step/2; tick += step {
+		line(left, y(tick), right, y(tick), chart.ColorLightGray, 1)
+		label := fmt.Sprintf("%.0f₽", tick)
+		labelWidth := chart.Draw.MeasureText(r, label, chart.Style{Font: font, FontSize: textSize}).Width()
+		text(label, left-10-labelWidth, y(tick)+5, textSize, chart.ColorBlack, false)
+	}
+	line(left, y(0), right, y(0), chart.ColorBlack, 2)
+
+	groupWidth := (right - left) / len(groups)
+	for i, group := range groups {
+		center := left + groupWidth*i + groupWidth/2
+		for j, value := range []float64{group.Prev, group.Current} {
+			x := center - barWidth - barGap/2
+			color := group.Color.WithAlpha(100)
+			if j == 1 {
+				x = center + barGap/2
+				color = group.Color
+			}
+			barTop, barBottom := y(value), y(0)
+			if value < 0 {
+				barTop, barBottom = barBottom, barTop
+			}
+			chart.Draw.Box(r, chart.NewBox(barTop, x, x+barWidth, barBottom), chart.Style{
+				FillColor:   color,
+				StrokeColor: color,
+			})
+			// Сумма над положительным столбцом и под отрицательным
+			labelY := barTop - 8
+			if value < 0 {
+				labelY = barBottom + 18
+			}
+			text(fmt.Sprintf("%.0f₽", value), x+barWidth/2, labelY, textSize, chart.ColorBlack, true)
+		}
+		text(group.Label, center, bottom+40, titleSize, chart.ColorBlack, true)
+		text(group.Change, center, bottom+65, textSize, chart.ColorAlternateGray, true)
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	if err := r.Save(buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// niceStep округляет шаг шкалы до 1, 2 или 5, умноженных на степень десяти
+func niceStep(raw float64) float64 {
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	switch normalized := raw / magnitude; {
+	case normalized <= 1:
+		return magnitude
+	case normalized <= 2:
+		return 2 * magnitude
+	case normalized <= 5:
+		return 5 * magnitude
+	default:
+		return 10 * magnitude
+	}
+}
+
+// weekdayShort - короткие названия дней недели по time.Weekday
+var weekdayShort = [...]string{"Вс", "Пн", "Вт", "Ср", "Чт", "Пт", "Сб"}
+
+// GenerateWeeklyColumnChart создает столбчатый график недели: по каждому дню доход
+// над осью и расход под ней. Линии трендов рассчитаны на месяц, а за неделю
+// столбцы по дням нагляднее
+func (g *ChartGenerator) GenerateWeeklyColumnChart(report *service.BaseReport) ([]byte, error) {
+	income := make(map[string]float64)
+	expense := make(map[string]float64)
+	for _, point := range report.Trends.IncomeTrend {
+		income[point.Date.Format("2006-01-02")] += math.Abs(point.Amount)
+	}
+	for _, point := range report.Trends.ExpenseTrend {
+		expense[point.Date.Format("2006-01-02")] += math.Abs(point.Amount)
+	}
+	if len(income)+len(expense) == 0 {
+		return nil, nil
+	}
+
+	// Нулевой столбец рисуется прозрачным, иначе на оси остается черта
+	style := func(value float64, color drawing.Color) chart.Style {
+		if value == 0 {
+			color = chart.ColorTransparent
+		}
+		return chart.Style{StrokeColor: color, FillColor: color}
+	}
+	var bars []chart.Value
+	start := time.Date(report.StartDate.Year(), report.StartDate.Month(), report.StartDate.Day(), 0, 0, 0, 0, report.StartDate.Location())
+	for day := start; !day.After(report.EndDate); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		// Столбцы дня идут парой: подпись под доходом относится к обоим
+		bars = append(bars,
+			chart.Value{
+				Label: fmt.Sprintf("%s %s", weekdayShort[day.Weekday()], day.Format("02.01")),
+				Value: income[key],
+				Style: style(income[key], chart.ColorGreen),
+			},
+			chart.Value{
+				Label: " ",
+				Value: -expense[key],
+				Style: style(expense[key], chart.ColorRed),
+			},
+		)
+	}
+
+	graph := chart.BarChart{
+		Title: fmt.Sprintf("Доходы и расходы по дням за %s", report.Period),
+		TitleStyle: chart.Style{
+			FontSize:  14,
+			FontColor: chart.ColorBlack,
+		},
+		Width:        1200,
+		Height:       600,
+		BarWidth:     40,
+		BarSpacing:   10,
+		UseBaseValue: true,
+		BaseValue:    0,
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top:    50,
+				Left:   50,
+				Right:  50,
+				Bottom: 50,
+			},
+			FillColor: chart.ColorWhite,
+		},
+		XAxis: chart.Style{
+			FontSize:  11,
+			FontColor: chart.ColorBlack,
+		},
+		YAxis: chart.YAxis{
+			ValueFormatter: func(v interface{}) string {
+				return fmt.Sprintf("%.0f₽", v.(float64))
+			},
+			Style: chart.Style{
+				FontSize:  12,
+				FontColor: chart.ColorBlack,
+			},
+		},
+		Bars: bars,
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	err := graph.Render(g.renderer(), buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render weekly chart: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// GenerateHourlyChart создает столбчатую диаграмму расходов по часам суток. Ночные часы
+// выделены цветом. Если расходов со временем нет, график не строится
+func (g *ChartGenerator) GenerateHourlyChart(report *service.BaseReport) ([]byte, error) {
+	hours := report.TimeOfDay.Hours
+	if report.TimeOfDay.Timed() == 0 {
+		return nil, nil
+	}
+
+	night := drawing.ColorFromHex("2c3e50")
+	bars := make([]chart.Value, 0, len(hours))
+	for hour, stats := range hours {
+		color := chart.ColorRed
+		if service.IsNightHour(hour) {
+			color = night
+		}
+		// Нулевой столбец рисуется прозрачным, иначе на оси остается черта
+		if stats.Expenses == 0 {
+			color = chart.ColorTransparent
+		}
+		bars = append(bars, chart.Value{
+			Label: fmt.Sprintf("%02d", hour),
+			Value: stats.Expenses,
+			Style: chart.Style{StrokeColor: color, FillColor: color},
+		})
+	}
+
+	graph := chart.BarChart{
+		Title: fmt.Sprintf("Расходы по часам за %s (ночь %s)", report.Period, service.NightHours()),
+		TitleStyle: chart.Style{
+			FontSize:  14,
+			FontColor: chart.ColorBlack,
+		},
+		Width:      1200,
+		Height:     600,
+		BarWidth:   30,
+		BarSpacing: 12,
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top:    50,
+				Left:   50,
+				Right:  50,
+				Bottom: 50,
+			},
+			FillColor: chart.ColorWhite,
+		},
+		XAxis: chart.Style{
+			FontSize:  11,
+			FontColor: chart.ColorBlack,
+		},
+		YAxis: chart.YAxis{
+			ValueFormatter: func(v interface{}) string {
+				return fmt.Sprintf("%.0f₽", v.(float64))
+			},
+			Style: chart.Style{
+				FontSize:  12,
+				FontColor: chart.ColorBlack,
+			},
+		},
+		Bars: bars,
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	err := graph.Render(g.renderer(), buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render hourly chart: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// GenerateNetWorthChart создает график изменения капитала по месяцам
+func (g *ChartGenerator) GenerateNetWorthChart(snapshots []model.NetWorthSnapshot) ([]byte, error) {
+	if len(snapshots) < 2 {
+		return nil, fmt.Errorf("not enough snapshots for net worth chart: %d", len(snapshots))
+	}
+
 	// Подготавливаем данные
-	bars := []chart.Value{
-		{
-			Label: fmt.Sprintf("Баланс (пред.): %.0f₽", report.Trends.PeriodComparison.PrevPeriod.Balance),
-			Value: report.Trends.PeriodComparison.PrevPeriod.Balance,
+	xValues := make([]time.Time, len(snapshots))
+	netWorth := make([]float64, len(snapshots))
+	liabilities := make([]float64, len(snapshots))
+	for i, s := range snapshots {
+		xValues[i] = s.MonthTime()
+		netWorth[i] = s.NetWorth
+		liabilities[i] = s.Liabilities
+	}
+
+	graph := chart.Chart{
+		Title:  "Капитал по месяцам",
+		Width:  1200,
+		Height: 600,
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top:    50,
+				Left:   50,
+				Right:  50,
+				Bottom: 50,
+			},
+			FillColor: chart.ColorWhite,
+		},
+		XAxis: chart.XAxis{
+			ValueFormatter: chart.TimeValueFormatterWithFormat("01.2006"),
 			Style: chart.Style{
-				StrokeColor: chart.ColorBlue,
-				FillColor:   chart.ColorBlue.WithAlpha(100),
-				FontSize:    12,
-				FontColor:   chart.ColorBlack,
+				FontSize:  12,
+				FontColor: chart.ColorBlack,
 			},
 		},
-		{
-			Label: fmt.Sprintf("Баланс (тек.): %.0f₽", report.Trends.PeriodComparison.CurrentPeriod.Balance),
-			Value: report.Trends.PeriodComparison.CurrentPeriod.Balance,
+		YAxis: chart.YAxis{
+			ValueFormatter: func(v interface{}) string {
+				return fmt.Sprintf("%.0f₽", v.(float64))
+			},
 			Style: chart.Style{
-				StrokeColor: chart.ColorBlue,
-				FillColor:   chart.ColorBlue,
-				FontSize:    12,
-				FontColor:   chart.ColorBlack,
+				FontSize:  12,
+				FontColor: chart.ColorBlack,
 			},
 		},
-		{
-			Label: fmt.Sprintf("Расходы (пред.): %.0f₽", report.Trends.PeriodComparison.PrevPeriod.TotalExpenses),
-			Value: -report.Trends.PeriodComparison.PrevPeriod.TotalExpenses,
+		Series: []chart.Series{
+			chart.TimeSeries{
+				Name:    "Капитал",
+				XValues: xValues,
+				YValues: netWorth,
+				Style: chart.Style{
+					StrokeColor: chart.ColorBlue,
+					FillColor:   chart.ColorBlue.WithAlpha(50),
+					StrokeWidth: 3,
+					DotWidth:    5,
+					DotColor:    chart.ColorBlue,
+				},
+			},
+			chart.TimeSeries{
+				Name:    "Обязательства",
+				XValues: xValues,
+				YValues: liabilities,
+				Style: chart.Style{
+					StrokeColor: chart.ColorRed,
+					StrokeWidth: 2,
+				},
+			},
+		},
+	}
+
+	// Добавляем легенду
+	graph.Elements = []chart.Renderable{
+		chart.Legend(&graph, chart.Style{
+			FontSize:  12,
+			FontColor: chart.ColorBlack,
+		}),
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	err := graph.Render(g.renderer(), buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render net worth chart: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// GenerateAllocationChart создает круговую диаграмму структуры капитала: счета,
+// имущество и инвестиции по рыночной стоимости
+func (g *ChartGenerator) GenerateAllocationChart(slices []service.AllocationSlice) ([]byte, error) {
+	total := 0.0
+	for _, slice := range slices {
+		total += slice.Value
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("no positive values for allocation chart")
+	}
+
+	values := make([]chart.Value, 0, len(slices))
+	for _, slice := range slices {
+		values = append(values, chart.Value{
+			Label: fmt.Sprintf("%s: %.0f₽ (%.1f%%)", slice.Name, slice.Value, slice.Value/total*100),
+			Value: slice.Value,
 			Style: chart.Style{
-				StrokeColor: chart.ColorRed,
-				FillColor:   chart.ColorRed.WithAlpha(100),
-				FontSize:    12,
-				FontColor:   chart.ColorBlack,
+				FontSize:  12,
+				FontColor: chart.ColorBlack,
 			},
+		})
+	}
+
+	pie := chart.PieChart{
+		Title:  "Структура капитала",
+		Width:  800,
+		Height: 800,
+		Values: values,
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top:    50,
+				Left:   50,
+				Right:  50,
+				Bottom: 50,
+			},
+			FillColor: chart.ColorWhite,
 		},
-		{
-			Label: fmt.Sprintf("Расходы (тек.): %.0f₽", report.Trends.PeriodComparison.CurrentPeriod.TotalExpenses),
-			Value: -report.Trends.PeriodComparison.CurrentPeriod.TotalExpenses,
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	err := pie.Render(g.renderer(), buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render allocation chart: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// GenerateCashflowForecastChart создает график прогноза остатка с отметками регулярных платежей
+func (g *ChartGenerator) GenerateCashflowForecastChart(forecast *service.CashflowForecast) ([]byte, error) {
+	if len(forecast.Points) < 2 {
+		return nil, fmt.Errorf("not enough points for forecast chart: %d", len(forecast.Points))
+	}
+
+	xValues := make([]time.Time, len(forecast.Points))
+	balances := make([]float64, len(forecast.Points))
+	zero := make([]float64, len(forecast.Points))
+	for i, p := range forecast.Points {
+		xValues[i] = p.Date
+		balances[i] = p.Balance
+	}
+
+	color := chart.ColorBlue
+	if forecast.Lowest.Balance < 0 {
+		color = chart.ColorRed
+	}
+	series := []chart.Series{
+		chart.TimeSeries{
+			Name:    "Прогноз остатка",
+			XValues: xValues,
+			YValues: balances,
 			Style: chart.Style{
-				StrokeColor: chart.ColorRed,
-				FillColor:   chart.ColorRed,
-				FontSize:    12,
-				FontColor:   chart.ColorBlack,
+				StrokeColor: color,
+				FillColor:   color.WithAlpha(40),
+				StrokeWidth: 3,
 			},
 		},
-		{
-			Label: fmt.Sprintf("Доходы (пред.): %.0f₽", report.Trends.PeriodComparison.PrevPeriod.TotalIncome),
-			Value: report.Trends.PeriodComparison.PrevPeriod.TotalIncome,
+	}
+
+	// Нулевая линия показывает, когда денег может не хватить
+	if forecast.Lowest.Balance < 0 {
+		series = append(series, chart.TimeSeries{
+			Name:    "Ноль",
+			XValues: xValues,
+			YValues: zero,
 			Style: chart.Style{
-				StrokeColor: chart.ColorGreen,
-				FillColor:   chart.ColorGreen.WithAlpha(100),
-				FontSize:    12,
-				FontColor:   chart.ColorBlack,
+				StrokeColor:     chart.ColorBlack,
+				StrokeWidth:     1,
+				StrokeDashArray: []float64{5, 5},
 			},
+		})
+	}
+
+	// Отмечаем регулярные платежи на линии остатка
+	var annotations []chart.Value2
+	for _, p := range forecast.Recurring {
+		for _, point := range forecast.Points {
+			if !point.Date.Equal(p.NextDate) {
+				continue
+			}
+			label := p.Description
+			if label == "" {
+				label = "Регулярный платеж"
+			}
+			annotations = append(annotations, chart.Value2{
+				XValue: chart.TimeToFloat64(point.Date),
+				YValue: point.Balance,
+				Label:  fmt.Sprintf("%s %+.0f₽", label, p.Amount),
+			})
+		}
+	}
+	if len(annotations) > 0 {
+		series = append(series, chart.AnnotationSeries{Name: "Регулярные платежи", Annotations: annotations})
+	}
+
+	graph := chart.Chart{
+		Title:  "Прогноз остатка на 30 дней",
+		Width:  1200,
+		Height: 600,
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top:    50,
+				Left:   50,
+				Right:  50,
+				Bottom: 50,
+			},
+			FillColor: chart.ColorWhite,
 		},
-		{
-			Label: fmt.Sprintf("Доходы (тек.): %.0f₽", report.Trends.PeriodComparison.CurrentPeriod.TotalIncome),
-			Value: report.Trends.PeriodComparison.CurrentPeriod.TotalIncome,
+		XAxis: chart.XAxis{
+			ValueFormatter: chart.TimeValueFormatterWithFormat("02.01"),
 			Style: chart.Style{
-				StrokeColor: chart.ColorGreen,
-				FillColor:   chart.ColorGreen,
-				FontSize:    12,
-				FontColor:   chart.ColorBlack,
+				FontSize:  12,
+				FontColor: chart.ColorBlack,
 			},
 		},
+		YAxis: chart.YAxis{
+			ValueFormatter: func(v interface{}) string {
+				return fmt.Sprintf("%.0f₽", v.(float64))
+			},
+			Style: chart.Style{
+				FontSize:  12,
+				FontColor: chart.ColorBlack,
+			},
+		},
+		Series: series,
+	}
+
+	graph.Elements = []chart.Renderable{
+		chart.Legend(&graph, chart.Style{
+			FontSize:  12,
+			FontColor: chart.ColorBlack,
+		}),
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	err := graph.Render(g.renderer(), buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render forecast chart: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// GenerateBudgetBurndownChart создает график расходования бюджета категории за месяц:
+// остаток по дням рядом с равномерным расходованием до нуля к концу месяца.
+// Перенос с прошлых месяцев входит в начальную сумму
+func (g *ChartGenerator) GenerateBudgetBurndownChart(status service.BudgetStatus) ([]byte, error) {
+	if len(status.Daily) == 0 {
+		return nil, fmt.Errorf("no days for budget chart")
+	}
+
+	available := status.Available()
+	days := status.Month.AddDate(0, 1, -1).Day()
+	idealX := make([]time.Time, days+1)
+	idealY := make([]float64, days+1)
+	zero := make([]float64, days+1)
+	for i := range idealX {
+		idealX[i] = status.Month.AddDate(0, 0, i)
+		idealY[i] = available * float64(days-i) / float64(days)
+	}
+	remainingX := make([]time.Time, len(status.Daily)+1)
+	remainingY := make([]float64, len(status.Daily)+1)
+	remainingX[0], remainingY[0] = status.Month, available
+	for i, spent := range status.Daily {
+		remainingX[i+1] = status.Month.AddDate(0, 0, i+1)
+		remainingY[i+1] = available - spent
+	}
+
+	color := chart.ColorBlue
+	if status.Remaining() < 0 {
+		color = chart.ColorRed
+	}
+	title := fmt.Sprintf("Бюджет «%s»: %.0f₽", status.CategoryName, status.Budget.Amount)
+	if status.Carryover != 0 {
+		title += fmt.Sprintf(", перенос %+.0f₽", status.Carryover)
+	}
+
+	graph := chart.Chart{
+		Title:  title,
+		Width:  1200,
+		Height: 600,
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top:    50,
+				Left:   50,
+				Right:  50,
+				Bottom: 50,
+			},
+			FillColor: chart.ColorWhite,
+		},
+		XAxis: chart.XAxis{
+			ValueFormatter: chart.TimeValueFormatterWithFormat("02.01"),
+			Style: chart.Style{
+				FontSize:  12,
+				FontColor: chart.ColorBlack,
+			},
+		},
+		YAxis: chart.YAxis{
+			ValueFormatter: func(v interface{}) string {
+				return fmt.Sprintf("%.0f₽", v.(float64))
+			},
+			Style: chart.Style{
+				FontSize:  12,
+				FontColor: chart.ColorBlack,
+			},
+		},
+		Series: []chart.Series{
+			chart.TimeSeries{
+				Name:    "Равномерный расход",
+				XValues: idealX,
+				YValues: idealY,
+				Style: chart.Style{
+					StrokeColor:     chart.ColorAlternateGray,
+					StrokeWidth:     2,
+					StrokeDashArray: []float64{5, 5},
+				},
+			},
+			chart.TimeSeries{
+				Name:    "Ноль",
+				XValues: idealX,
+				YValues: zero,
+				Style: chart.Style{
+					StrokeColor: chart.ColorBlack,
+					StrokeWidth: 1,
+				},
+			},
+			chart.TimeSeries{
+				Name:    "Остаток",
+				XValues: remainingX,
+				YValues: remainingY,
+				Style: chart.Style{
+					StrokeColor: color,
+					FillColor:   color.WithAlpha(40),
+					StrokeWidth: 3,
+				},
+			},
+		},
+	}
+
+	graph.Elements = []chart.Renderable{
+		chart.Legend(&graph, chart.Style{
+			FontSize:  12,
+			FontColor: chart.ColorBlack,
+		}),
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	err := graph.Render(g.renderer(), buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render budget chart: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// comparisonChartCategories - сколько категорий расходов показывается на графике сравнения периодов
+const comparisonChartCategories = 4
+
+// GenerateComparisonChart создает парные столбцы для двух произвольных периодов:
+// доходы, расходы и крупнейшие категории. Первый период - бледный столбец, второй - яркий
+func (g *ChartGenerator) GenerateComparisonChart(comparison *service.CustomComparison) ([]byte, error) {
+	first, second := comparison.PrevPeriod, comparison.CurrentPeriod
+
+	pair := func(label string, a, b float64, color drawing.Color) []chart.Value {
+		return []chart.Value{
+			{
+				Label: label + " (1)",
+				Value: a,
+				Style: chart.Style{
+					StrokeColor: color,
+					FillColor:   color.WithAlpha(100),
+					FontSize:    12,
+					FontColor:   chart.ColorBlack,
+				},
+			},
+			{
+				Label: label + " (2)",
+				Value: b,
+				Style: chart.Style{
+					StrokeColor: color,
+					FillColor:   color,
+					FontSize:    12,
+					FontColor:   chart.ColorBlack,
+				},
+			},
+		}
+	}
+
+	var bars []chart.Value
+	bars = append(bars, pair("Доходы", first.TotalIncome, second.TotalIncome, chart.ColorGreen)...)
+	bars = append(bars, pair("Расходы", first.TotalExpenses, second.TotalExpenses, chart.ColorRed)...)
+	for i, cat := range comparison.Expenses {
+		if i == comparisonChartCategories {
+			break
+		}
+		name := cat.Name
+		if name == "" {
+			name = "Без категории"
+		}
+		bars = append(bars, pair(name, cat.First, cat.Second, chart.ColorBlue)...)
 	}
 
 	graph := chart.BarChart{
-		Title: fmt.Sprintf("Сравнение периодов за %s", report.Period),
+		Title: fmt.Sprintf("(1) %s и (2) %s", comparison.First, comparison.Second),
 		TitleStyle: chart.Style{
 			FontSize:  14,
 			FontColor: chart.ColorBlack,
 		},
 		Width:    1200,
 		Height:   600,
-		BarWidth: 60,
+		BarWidth: 50,
 		Background: chart.Style{
 			Padding: chart.Box{
 				Top:    50,
@@ -558,9 +1364,121 @@ func (g *ChartGenerator) GenerateBalanceChart(report *service.BaseReport) ([]byt
 	}
 
 	buffer := bytes.NewBuffer([]byte{})
-	err := graph.Render(chart.PNG, buffer)
+	err := graph.Render(g.renderer(), buffer)
 	if err != nil {
-		return nil, fmt.Errorf("failed to render balance chart: %w", err)
+		return nil, fmt.Errorf("failed to render comparison chart: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// incomeChartSources - сколько источников дохода показывается на графике стабильности
+const incomeChartSources = 5
+
+// GenerateIncomeStabilityChart создает график дохода по месяцам: линия на каждый крупный
+// источник, общий доход и средний уровень
+func (g *ChartGenerator) GenerateIncomeStabilityChart(stability *service.IncomeStability) ([]byte, error) {
+	if len(stability.Months) < 2 || len(stability.Sources) == 0 {
+		return nil, fmt.Errorf("not enough data for income chart: %d months, %d sources",
+			len(stability.Months), len(stability.Sources))
+	}
+
+	average := make([]float64, len(stability.Months))
+	for i := range average {
+		average[i] = stability.Average
+	}
+	series := []chart.Series{
+		chart.TimeSeries{
+			Name:    "Весь доход",
+			XValues: stability.Months,
+			YValues: stability.Monthly,
+			Style: chart.Style{
+				StrokeColor: chart.ColorGreen,
+				FillColor:   chart.ColorGreen.WithAlpha(40),
+				StrokeWidth: 3,
+				DotWidth:    5,
+				DotColor:    chart.ColorGreen,
+			},
+		},
+		chart.TimeSeries{
+			Name:    "В среднем",
+			XValues: stability.Months,
+			YValues: average,
+			Style: chart.Style{
+				StrokeColor:     chart.ColorBlack,
+				StrokeWidth:     1,
+				StrokeDashArray: []float64{5, 5},
+			},
+		},
+	}
+
+	// Источники показываем только при нескольких источниках, иначе линия совпадет с общей
+	if len(stability.Sources) > 1 {
+		for i, source := range stability.Sources {
+			if i == incomeChartSources {
+				break
+			}
+			color := chart.GetDefaultColor(i)
+			if model.ValidCategoryColor(source.Color) {
+				color = drawing.ColorFromHex(source.Color)
+			}
+			series = append(series, chart.TimeSeries{
+				Name:    fmt.Sprintf("%s (%.0f%%)", source.Name, source.Share*100),
+				XValues: stability.Months,
+				YValues: source.Monthly,
+				Style: chart.Style{
+					StrokeColor: color,
+					StrokeWidth: 2,
+					DotWidth:    4,
+					DotColor:    color,
+				},
+			})
+		}
+	}
+
+	graph := chart.Chart{
+		Title:  "Доходы по источникам",
+		Width:  1200,
+		Height: 600,
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top:    50,
+				Left:   50,
+				Right:  50,
+				Bottom: 50,
+			},
+			FillColor: chart.ColorWhite,
+		},
+		XAxis: chart.XAxis{
+			ValueFormatter: chart.TimeValueFormatterWithFormat("01.2006"),
+			Style: chart.Style{
+				FontSize:  12,
+				FontColor: chart.ColorBlack,
+			},
+		},
+		YAxis: chart.YAxis{
+			ValueFormatter: func(v interface{}) string {
+				return fmt.Sprintf("%.0f₽", v.(float64))
+			},
+			Style: chart.Style{
+				FontSize:  12,
+				FontColor: chart.ColorBlack,
+			},
+		},
+		Series: series,
+	}
+
+	graph.Elements = []chart.Renderable{
+		chart.Legend(&graph, chart.Style{
+			FontSize:  12,
+			FontColor: chart.ColorBlack,
+		}),
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	err := graph.Render(g.renderer(), buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render income chart: %w", err)
 	}
 
 	return buffer.Bytes(), nil
@@ -0,0 +1,271 @@
+package charts_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/charts"
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+	"github.com/ivanoskov/financial_bot/internal/service/mocks"
+)
+
+// snapshotNow - момент, на который строятся отчеты. Данные фиксированы, поэтому
+// графики от запуска к запуску отличаются только при изменении кода отрисовки
+var snapshotNow = time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC)
+
+// Категории с длинными русскими названиями, чтобы на снимках были видны подписи
+var snapshotCategories = []model.Category{
+	{ID: "food", Name: "Продукты", Type: "expense", Emoji: "🛒"},
+	{ID: "cafe", Name: "Кафе и рестораны", Type: "expense", Emoji: "🍽"},
+	{ID: "transport", Name: "Транспорт", Type: "expense", Emoji: "🚇"},
+	{ID: "home", Name: "Коммунальные платежи", Type: "expense", Emoji: "🏠"},
+	{ID: "fun", Name: "Развлечения", Type: "expense", Emoji: "🎬"},
+	{ID: "taxi", Name: "Такси", Type: "expense", ParentID: "transport"},
+	{ID: "salary", Name: "Зарплата", Type: "income", Emoji: "💼"},
+	{ID: "freelance", Name: "Фриланс", Type: "income", Emoji: "💻"},
+}
+
+// snapshot - график, который сравнивается с эталоном
+type snapshot struct {
+	Name   string
+	Render func() ([]byte, error)
+}
+
+// snapshots строит отчеты из фиксированных данных через сервис и возвращает графики,
+// которые бот отправляет пользователям
+func snapshots(ctx context.Context, g *charts.ChartGenerator) ([]snapshot, error) {
+	tracker := service.NewExpenseTracker(snapshotRepository())
+	tracker.SetClock(func() time.Time { return snapshotNow })
+
+	month, err := tracker.GetReport(ctx, 1, service.MonthlyReport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build monthly report: %w", err)
+	}
+	day, err := tracker.GetReport(ctx, 1, service.DailyReport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build daily report: %w", err)
+	}
+	week, err := tracker.GetReport(ctx, 1, service.WeeklyReport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build weekly report: %w", err)
+	}
+	year, err := tracker.GetReport(ctx, 1, service.YearlyReport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build yearly report: %w", err)
+	}
+	comparison, err := tracker.ComparePeriods(ctx, 1,
+		service.Period{Start: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2026, 2, 14, 23, 59, 59, 0, time.UTC)},
+		service.Period{Start: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2026, 3, 20, 23, 59, 59, 0, time.UTC)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare periods: %w", err)
+	}
+	budgets, err := tracker.GetBudgetStatuses(ctx, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budgets: %w", err)
+	}
+	dailyBudget, err := tracker.DailyBudget(ctx, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily budget: %w", err)
+	}
+	review, err := tracker.GetYearReview(ctx, 1, 2025)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build year review: %w", err)
+	}
+
+	return []snapshot{
+		{"dashboard", func() ([]byte, error) { return g.GenerateFinancialDashboard(month) }},
+		{"dashboard_year", func() ([]byte, error) { return g.GenerateFinancialDashboard(year) }},
+		{"dashboard_day", func() ([]byte, error) { return g.GenerateFinancialDashboard(day) }},
+		{"pie_expenses", func() ([]byte, error) { return g.GenerateCategoryPieChart(month, true) }},
+		{"pie_income", func() ([]byte, error) { return g.GenerateCategoryPieChart(month, false) }},
+		{"trend", func() ([]byte, error) { return g.GenerateTrendChart(month) }},
+		{"trend_absolute", func() ([]byte, error) { return g.GenerateAbsoluteTrendChart(month, dailyBudget) }},
+		{"trend_day", func() ([]byte, error) { return g.GenerateTrendChart(day) }},
+		{"balance", func() ([]byte, error) { return g.GenerateBalanceChart(month) }},
+		{"weekly", func() ([]byte, error) { return g.GenerateWeeklyColumnChart(week) }},
+		{"hourly", func() ([]byte, error) { return g.GenerateHourlyChart(month) }},
+		{"comparison", func() ([]byte, error) { return g.GenerateComparisonChart(comparison) }},
+		{"net_worth", func() ([]byte, error) { return g.GenerateNetWorthChart(snapshotNetWorth()) }},
+		{"allocation", func() ([]byte, error) { return g.GenerateAllocationChart(snapshotAllocation()) }},
+		{"forecast", func() ([]byte, error) { return g.GenerateCashflowForecastChart(snapshotForecast()) }},
+		{"income_stability", func() ([]byte, error) { return g.GenerateIncomeStabilityChart(snapshotIncome()) }},
+		{"budget_burndown", func() ([]byte, error) { return g.GenerateBudgetBurndownChart(budgets[0]) }},
+		{"year_categories", func() ([]byte, error) { return g.GenerateYearCategoryChart(review) }},
+		{"year_savings", func() ([]byte, error) { return g.GenerateYearSavingsChart(review) }},
+		{"year_heatmap", func() ([]byte, error) { return g.GenerateYearHeatmap(review) }},
+	}, nil
+}
+
+// snapshotRepository отдает сервису транзакции с начала прошлого года до snapshotNow
+func snapshotRepository() *mocks.Repository {
+	transactions := snapshotTransactions()
+	between := func(filter model.TransactionFilter) []model.Transaction {
+		var result []model.Transaction
+		for _, t := range transactions {
+			if (filter.StartDate == nil || !t.Date.Before(*filter.StartDate)) &&
+				(filter.EndDate == nil || !t.Date.After(*filter.EndDate)) {
+				result = append(result, t)
+			}
+		}
+		return result
+	}
+	return &mocks.Repository{
+		GetCategoriesFunc: func(ctx context.Context, userID int64) ([]model.Category, error) {
+			return snapshotCategories, nil
+		},
+		GetTransactionsFunc: func(ctx context.Context, userID int64, filter model.TransactionFilter) ([]model.Transaction, error) {
+			return between(filter), nil
+		},
+		GetBudgetsFunc: func(ctx context.Context, userID int64) ([]model.Budget, error) {
+			return snapshotBudgets(), nil
+		},
+		GetReportDataFunc: func(ctx context.Context, userID int64, current, previous model.TransactionFilter) (*model.ReportData, error) {
+			return &model.ReportData{
+				Categories: snapshotCategories,
+				Current:    between(current),
+				Previous:   between(previous),
+			}, nil
+		},
+	}
+}
+
+// snapshotBudgets - бюджет категории с подкатегорией и переносом остатка с начала года
+func snapshotBudgets() []model.Budget {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []model.Budget{{UserID: 1, CategoryID: "transport", Amount: 4500, RolloverSince: &since}}
+}
+
+// snapshotTransactions - регулярные доходы и траты с разбросом по дням, суммы в сотни
+// тысяч проверяют формат подписей осей. У покупок в магазинах, кафе, такси и развлечениях
+// есть время, часть из них ночью; платежи и доходы записаны без времени, как из выписки
+func snapshotTransactions() []model.Transaction {
+	var transactions []model.Transaction
+	add := func(date time.Time, categoryID string, amount float64) {
+		transactions = append(transactions, model.Transaction{
+			ID:         fmt.Sprintf("t%d", len(transactions)+1),
+			UserID:     1,
+			CategoryID: categoryID,
+			Amount:     amount,
+			Date:       date,
+		})
+	}
+	// Покупки последнего дня не должны оказаться позже snapshotNow
+	at := func(day time.Time, hour, minute int) time.Time {
+		if date := day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute); date.Before(snapshotNow) {
+			return date
+		}
+		return day
+	}
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for day := start; !day.After(snapshotNow); day = day.AddDate(0, 0, 1) {
+		n := int(day.Sub(start).Hours() / 24)
+		if day.Day() == 5 {
+			add(day, "salary", 185000+float64(day.Month())*1500)
+			add(day, "home", -(7400 + float64(n%3)*350))
+		}
+		if day.Day() == 20 && n%2 == 0 {
+			add(day, "freelance", 42000)
+		}
+		add(at(day, 8+n%13, n*7%60), "food", -(900 + float64(n*37%1300)))
+		if n%3 == 0 {
+			add(at(day, []int{13, 19, 23}[n%4%3], 30), "cafe", -(1200 + float64(n*53%2100)))
+		}
+		if n%2 == 0 {
+			add(day, "transport", -120)
+		}
+		if n%9 == 0 {
+			add(at(day, []int{1, 9, 18}[n%5%3], 15), "taxi", -(450 + float64(n*11%600)))
+		}
+		if day.Weekday() == time.Saturday {
+			add(at(day, 20, 0), "fun", -(2500 + float64(n*29%4000)))
+		}
+	}
+	return transactions
+}
+
+// snapshotNetWorth - капитал за год с отрицательным месяцем
+func snapshotNetWorth() []model.NetWorthSnapshot {
+	var snapshots []model.NetWorthSnapshot
+	for i := 0; i < 12; i++ {
+		month := time.Date(2025, time.Month(4+i), 1, 0, 0, 0, 0, time.UTC)
+		liabilities := 420000 - float64(i)*30000
+		snapshot := model.NetWorthSnapshot{
+			UserID:      1,
+			Month:       month.Format("2006-01-02"),
+			Accounts:    120000 + float64(i*i)*4500,
+			Assets:      250000,
+			Liabilities: liabilities,
+		}
+		snapshot.NetWorth = snapshot.Accounts + snapshot.Assets - snapshot.Liabilities
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots
+}
+
+// snapshotAllocation - структура капитала со счетами, квартирой, акциями и криптовалютой
+func snapshotAllocation() []service.AllocationSlice {
+	summary := &service.NetWorthSummary{
+		Snapshot: model.NetWorthSnapshot{Assets: 250000},
+		Balances: []model.AccountBalance{{Balance: 184000}, {Balance: -12000}, {Balance: 46500}},
+		Holdings: []service.HoldingValue{
+			{Holding: model.Holding{Ticker: "SBER"}, Value: 96300},
+			{Holding: model.Holding{Ticker: "TMOS"}, Value: 61250},
+			{Holding: model.Holding{Ticker: "BTC"}, Value: 142800},
+			{Holding: model.Holding{Ticker: "TON"}, Value: 8900},
+		},
+	}
+	return summary.Allocation()
+}
+
+// snapshotForecast - прогноз, уходящий в минус перед зарплатой
+func snapshotForecast() *service.CashflowForecast {
+	forecast := &service.CashflowForecast{Balance: 38000, DailySpend: 2400}
+	balance := forecast.Balance
+	for i := 0; i <= 30; i++ {
+		date := snapshotNow.AddDate(0, 0, i)
+		if i > 0 {
+			balance -= forecast.DailySpend
+		}
+		if date.Day() == 1 {
+			balance -= 35000
+		}
+		if date.Day() == 5 {
+			balance += 190000
+		}
+		point := service.ForecastPoint{Date: date, Balance: balance}
+		forecast.Points = append(forecast.Points, point)
+		if i == 0 || point.Balance < forecast.Lowest.Balance {
+			forecast.Lowest = point
+		}
+	}
+	forecast.Recurring = []service.RecurringPayment{
+		{Description: "Аренда", Amount: -35000, NextDate: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{Description: "Зарплата", Amount: 190000, NextDate: time.Date(2026, 4, 5, 0, 0, 0, 0, time.UTC)},
+	}
+	return forecast
+}
+
+// snapshotIncome - нерегулярный доход из двух источников за полгода
+func snapshotIncome() *service.IncomeStability {
+	salary := []float64{185000, 185000, 0, 190000, 190000, 190000}
+	freelance := []float64{42000, 0, 65000, 0, 12000, 42000}
+	stability := &service.IncomeStability{Monthly: make([]float64, len(salary))}
+	var total float64
+	for i := range salary {
+		stability.Months = append(stability.Months, time.Date(2025, time.Month(9+i), 1, 0, 0, 0, 0, time.UTC))
+		stability.Monthly[i] = salary[i] + freelance[i]
+		total += stability.Monthly[i]
+	}
+	stability.Average = total / float64(len(salary))
+	stability.Sources = []service.IncomeSource{
+		{Name: "Зарплата", Emoji: "💼", Monthly: salary, Total: 940000, Months: 5},
+		{Name: "Фриланс", Emoji: "💻", Monthly: freelance, Total: 161000, Months: 4},
+	}
+	for i := range stability.Sources {
+		stability.Sources[i].Share = stability.Sources[i].Total / total
+	}
+	return stability
+}
@@ -0,0 +1,131 @@
+package charts
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	"github.com/ivanoskov/financial_bot/internal/service"
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+// MinLinePoints - сколько дней с операциями нужно линейному графику: по одной-двум точкам линия
+// не показывает динамику и только путает
+const MinLinePoints = 3
+
+// TrendDays возвращает, сколько первых дней тренда отчета показывать. Тренд месячного
+// отчета идет до конца месяца: пустой хвост из еще не наступивших дней отрезается,
+// иначе среднее к концу графика падает до нуля
+func TrendDays(report *service.BaseReport) int {
+	days := len(report.Trends.ExpenseTrend)
+	for days > 0 && report.Trends.ExpenseTrend[days-1].Amount == 0 &&
+		(days > len(report.Trends.IncomeTrend) || report.Trends.IncomeTrend[days-1].Amount == 0) {
+		days--
+	}
+	return days
+}
+
+// generateDailyBars рисует доходы и расходы по дням столбцами. Заменяет линейные
+// графики, когда в периоде всего один-два дня с операциями
+func (g *ChartGenerator) generateDailyBars(title string, report *service.BaseReport) ([]byte, error) {
+	var bars []chart.Value
+	for i, point := range report.Trends.ExpenseTrend {
+		day := point.Date.Format("02.01")
+		if i < len(report.Trends.IncomeTrend) && report.Trends.IncomeTrend[i].Amount != 0 {
+			income := math.Abs(report.Trends.IncomeTrend[i].Amount)
+			bars = append(bars, chart.Value{
+				Label: fmt.Sprintf("%s доходы: %.0f₽", day, income),
+				Value: income,
+				Style: chart.Style{StrokeColor: chart.ColorGreen, FillColor: chart.ColorGreen},
+			})
+		}
+		if point.Amount != 0 {
+			expense := math.Abs(point.Amount)
+			bars = append(bars, chart.Value{
+				Label: fmt.Sprintf("%s расходы: %.0f₽", day, expense),
+				Value: expense,
+				Style: chart.Style{StrokeColor: chart.ColorRed, FillColor: chart.ColorRed},
+			})
+		}
+	}
+	if len(bars) == 0 {
+		return g.generateNoDataCard(title, "За период нет операций")
+	}
+	if len(bars) == 1 {
+		// go-chart не строит ось по одному значению: добавляем пустой столбец-ноль
+		bars = append(bars, chart.Value{Label: " ", Value: 0, Style: chart.Style{
+			StrokeColor: chart.ColorTransparent,
+			FillColor:   chart.ColorTransparent,
+		}})
+	}
+
+	graph := chart.BarChart{
+		Title: title,
+		TitleStyle: chart.Style{
+			FontSize:  14,
+			FontColor: chart.ColorBlack,
+		},
+		Width:    1200,
+		Height:   600,
+		BarWidth: 120,
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top:    50,
+				Left:   50,
+				Right:  50,
+				Bottom: 50,
+			},
+			FillColor: chart.ColorWhite,
+		},
+		YAxis: chart.YAxis{
+			ValueFormatter: func(v interface{}) string {
+				return fmt.Sprintf("%.0f₽", v.(float64))
+			},
+			Style: chart.Style{
+				FontSize:  12,
+				FontColor: chart.ColorBlack,
+			},
+		},
+		Bars: bars,
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	if err := graph.Render(g.renderer(), buffer); err != nil {
+		return nil, fmt.Errorf("failed to render daily bars: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// generateNoDataCard рисует карточку «мало данных» вместо графика, который по имеющимся
+// данным получился бы пустым или вводящим в заблуждение
+func (g *ChartGenerator) generateNoDataCard(title, text string) ([]byte, error) {
+	const width, height = 1200, 600
+	r, err := g.renderer()(width, height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create renderer: %w", err)
+	}
+	font, err := chart.GetDefaultFont()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load font: %w", err)
+	}
+
+	chart.Draw.Box(r, chart.NewBox(0, 0, width, height), chart.Style{
+		FillColor:   chart.ColorWhite,
+		StrokeColor: chart.ColorWhite,
+	})
+	centered := func(body string, y int, size float64, color drawing.Color) {
+		style := chart.Style{Font: font, FontSize: size, FontColor: color}
+		box := chart.Draw.MeasureText(r, body, style)
+		chart.Draw.Text(r, body, (width-box.Width())/2, y, style)
+	}
+	centered(title, 60, 14, chart.ColorBlack)
+	centered("Мало данных", height/2, 24, chart.ColorBlack)
+	centered(text, height/2+50, 14, chart.ColorAlternateGray)
+
+	buffer := bytes.NewBuffer([]byte{})
+	if err := r.Save(buffer); err != nil {
+		return nil, fmt.Errorf("failed to render no data card: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,96 @@
+package charts_test
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math/bits"
+	"strings"
+)
+
+// hashSize - сторона сетки перцептивного хэша: хэш содержит hashSize*hashSize бит.
+// Сетка мельче обычных 8x8, чтобы замечать сдвиг подписей осей и легенды
+const hashSize = 16
+
+// perceptualHash вычисляет разностный хэш (dHash) PNG: картинка уменьшается до сетки
+// (hashSize+1)xhashSize в оттенках серого, и каждый бит говорит, светлее ли ячейка соседней
+// справа. Похожие картинки дают близкие хэши, поэтому сглаживание шрифтов и другие
+// неразличимые глазом отличия не считаются изменением графика
+func perceptualHash(data []byte) ([]uint64, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode png: %w", err)
+	}
+	grid := downscale(img, hashSize+1, hashSize)
+
+	hash := make([]uint64, hashSize*hashSize/64)
+	for y := 0; y < hashSize; y++ {
+		for x := 0; x < hashSize; x++ {
+			if grid[y][x] > grid[y][x+1] {
+				bit := y*hashSize + x
+				hash[bit/64] |= 1 << (bit % 64)
+			}
+		}
+	}
+	return hash, nil
+}
+
+// downscale уменьшает картинку до width x height, усредняя яркость пикселей каждой ячейки
+func downscale(img image.Image, width, height int) [][]float64 {
+	bounds := img.Bounds()
+	grid := make([][]float64, height)
+	for y := range grid {
+		grid[y] = make([]float64, width)
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := bounds.Min.Y + (y+1)*bounds.Dy()/height
+		for x := range grid[y] {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/width
+			var sum float64
+			for py := y0; py < y1; py++ {
+				for px := x0; px < x1; px++ {
+					r, g, b, _ := img.At(px, py).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+				}
+			}
+			if n := (x1 - x0) * (y1 - y0); n > 0 {
+				grid[y][x] = sum / float64(n)
+			}
+		}
+	}
+	return grid
+}
+
+// hashDistance возвращает число различающихся бит двух хэшей
+func hashDistance(a, b []uint64) int {
+	if len(a) != len(b) {
+		return hashSize * hashSize
+	}
+	var distance int
+	for i := range a {
+		distance += bits.OnesCount64(a[i] ^ b[i])
+	}
+	return distance
+}
+
+func formatHash(hash []uint64) string {
+	parts := make([]string, len(hash))
+	for i, word := range hash {
+		parts[i] = fmt.Sprintf("%016x", word)
+	}
+	return strings.Join(parts, "")
+}
+
+func parseHash(text string) ([]uint64, error) {
+	if len(text) != hashSize*hashSize/4 {
+		return nil, fmt.Errorf("invalid hash length: %d", len(text))
+	}
+	hash := make([]uint64, hashSize*hashSize/64)
+	for i := range hash {
+		if _, err := fmt.Sscanf(text[i*16:(i+1)*16], "%016x", &hash[i]); err != nil {
+			return nil, fmt.Errorf("invalid hash %s: %w", text, err)
+		}
+	}
+	return hash, nil
+}
@@ -0,0 +1,142 @@
+package charts_test
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ivanoskov/financial_bot/internal/charts"
+)
+
+// Эталонные снимки графиков, чтобы правки internal/charts не ломали незаметно подписи
+// осей, легенды и кириллицу:
+//
+//	go test ./internal/charts                  # построить графики и сравнить с эталоном
+//	go test ./internal/charts -out /tmp/snap   # заодно сохранить PNG для просмотра
+//	go test ./internal/charts -update          # принять текущие графики как эталон
+//
+// Графики строятся сервисом из фиксированных данных с mocks.Repository. Эталон хранит
+// перцептивные хэши, поэтому неразличимые глазом отличия отрисовки не считаются поломкой
+var (
+	update = flag.Bool("update", false, "перезаписать эталон текущими графиками")
+	out    = flag.String("out", "", "каталог для PNG построенных графиков")
+)
+
+const (
+	// goldenFile - эталонные хэши графиков
+	goldenFile = "testdata/snapshots.txt"
+	// hashThreshold - сколько бит хэша может отличаться от эталона
+	hashThreshold = 8
+)
+
+func TestChartSnapshots(t *testing.T) {
+	// Сервис пишет в лог ход построения отчетов, в выводе тестов он не нужен
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	list, err := snapshots(context.Background(), charts.NewChartGenerator())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *out != "" {
+		if err := os.MkdirAll(*out, 0o755); err != nil {
+			t.Fatalf("failed to create output dir: %v", err)
+		}
+	}
+
+	hashes := make(map[string][]uint64, len(list))
+	for _, s := range list {
+		data, err := s.Render()
+		if err != nil {
+			t.Fatalf("%s: failed to render: %v", s.Name, err)
+		}
+		if hashes[s.Name], err = perceptualHash(data); err != nil {
+			t.Fatalf("%s: %v", s.Name, err)
+		}
+		if *out != "" {
+			if err := os.WriteFile(filepath.Join(*out, s.Name+".png"), data, 0o644); err != nil {
+				t.Fatalf("failed to save %s: %v", s.Name, err)
+			}
+		}
+	}
+
+	if *update {
+		if err := writeGolden(goldenFile, hashes); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("Эталон обновлен: %d графиков в %s", len(hashes), goldenFile)
+		return
+	}
+
+	expected, err := readGolden(goldenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range list {
+		t.Run(s.Name, func(t *testing.T) {
+			want, ok := expected[s.Name]
+			if !ok {
+				t.Fatalf("графика нет в эталоне, добавьте его с -update")
+			}
+			if distance := hashDistance(hashes[s.Name], want); distance > hashThreshold {
+				t.Errorf("отличается от эталона на %d бит. Проверьте график с -out и, если изменение ожидаемое, обновите эталон с -update", distance)
+			}
+		})
+		delete(expected, s.Name)
+	}
+	for name := range expected {
+		t.Errorf("%s: есть в эталоне, но не строится", name)
+	}
+}
+
+// readGolden читает эталон: строки "имя хэш", пустые строки и "#" пропускаются
+func readGolden(path string) (map[string][]uint64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open golden file: %w", err)
+	}
+	defer file.Close()
+
+	hashes := make(map[string][]uint64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("invalid golden line: %s", line)
+		}
+		if hashes[name], err = parseHash(strings.TrimSpace(value)); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return hashes, scanner.Err()
+}
+
+func writeGolden(path string, hashes map[string][]uint64) error {
+	names := make([]string, 0, len(hashes))
+	for name := range hashes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("# Перцептивные хэши графиков, обновляются командой go test ./internal/charts -update\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s %s\n", name, formatHash(hashes[name]))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create golden dir: %w", err)
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o644)
+}
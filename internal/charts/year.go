@@ -0,0 +1,304 @@
+package charts
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+// monthShort - короткие названия месяцев по time.Month
+var monthShort = [...]string{"", "Янв", "Фев", "Мар", "Апр", "Май", "Июн", "Июл", "Авг", "Сен", "Окт", "Ноя", "Дек"}
+
+// yearCategoryColor возвращает цвет категории на графиках года: свой цвет категории,
+// иначе цвет по порядку
+func yearCategoryColor(category service.YearCategory, i int) drawing.Color {
+	if model.ValidCategoryColor(category.Color) {
+		return drawing.ColorFromHex(category.Color)
+	}
+	return chart.GetDefaultColor(i)
+}
+
+// GenerateYearCategoryChart создает столбцы расходов по месяцам года, разделенные
+// на крупнейшие категории. go-chart не рисует легенду у составных столбцов,
+// поэтому график рисуется напрямую, как renderGroupedBars
+func (g *ChartGenerator) GenerateYearCategoryChart(review *service.YearReview) ([]byte, error) {
+	const (
+		width, height       = 1200, 600
+		left, right         = 120, 1150
+		top, bottom         = 120, 520
+		textSize, titleSize = 12.0, 14.0
+	)
+	if len(review.Categories) == 0 {
+		return nil, fmt.Errorf("no expenses in %d", review.Year)
+	}
+	r, err := g.renderer()(width, height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create renderer: %w", err)
+	}
+	font, err := chart.GetDefaultFont()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load font: %w", err)
+	}
+	text := func(body string, x, y int, size float64, color drawing.Color, centered bool) {
+		style := chart.Style{Font: font, FontSize: size, FontColor: color}
+		if centered {
+			x -= chart.Draw.MeasureText(r, body, style).Width() / 2
+		}
+		chart.Draw.Text(r, body, x, y, style)
+	}
+	line := func(x1, y1, x2, y2 int, color drawing.Color, width float64) {
+		r.SetStrokeColor(color)
+		r.SetStrokeWidth(width)
+		r.MoveTo(x1, y1)
+		r.LineTo(x2, y2)
+		r.Stroke()
+	}
+
+	chart.Draw.Box(r, chart.NewBox(0, 0, width, height), chart.Style{
+		FillColor:   chart.ColorWhite,
+		StrokeColor: chart.ColorWhite,
+	})
+	text(fmt.Sprintf("Расходы %d года по категориям", review.Year), width/2, 40, titleSize, chart.ColorBlack, true)
+
+	// Легенда в одну строку: категория и ее доля в расходах года
+	x := left
+	for i, category := range review.Categories {
+		color := yearCategoryColor(category, i)
+		label := fmt.Sprintf("%s %.0f%%", category.Name, category.Share*100)
+		chart.Draw.Box(r, chart.NewBox(68, x, x+14, 82), chart.Style{FillColor: color, StrokeColor: color})
+		text(label, x+22, 80, textSize, chart.ColorBlack, false)
+		x += 22 + chart.Draw.MeasureText(r, label, chart.Style{Font: font, FontSize: textSize}).Width() + 30
+	}
+
+	maxValue := 0.0
+	for _, amount := range review.Expenses {
+		maxValue = math.Max(maxValue, amount)
+	}
+	if maxValue == 0 {
+		maxValue = 1
+	}
+	step := niceStep(maxValue / 5)
+	maxValue = math.Ceil(maxValue/step) * step
+	y := func(v float64) int {
+		return bottom - int(v/maxValue*float64(bottom-top))
+	}
+	for tick := 0.0; tick <= maxValue+step/2; tick += step {
+		line(left, y(tick), right, y(tick), chart.ColorLightGray, 1)
+		label := fmt.Sprintf("%.0f₽", tick)
+		labelWidth := chart.Draw.MeasureText(r, label, chart.Style{Font: font, FontSize: textSize}).Width()
+		text(label, left-10-labelWidth, y(tick)+5, textSize, chart.ColorBlack, false)
+	}
+
+	// Место под все 12 месяцев, чтобы столбцы незаконченного года не растягивались
+	slot := (right - left) / 12
+	barWidth := slot * 3 / 5
+	for month, start := range review.Months {
+		center := left + slot*month + slot/2
+		stacked := 0.0
+		for i, category := range review.Categories {
+			amount := category.Monthly[month]
+			if amount <= 0 {
+				continue
+			}
+			color := yearCategoryColor(category, i)
+			chart.Draw.Box(r, chart.NewBox(y(stacked+amount), center-barWidth/2, center+barWidth/2, y(stacked)), chart.Style{
+				FillColor:   color,
+				StrokeColor: chart.ColorWhite,
+				StrokeWidth: 1,
+			})
+			stacked += amount
+		}
+		if stacked > 0 {
+			text(fmt.Sprintf("%.0fk", stacked/1000), center, y(stacked)-8, textSize, chart.ColorBlack, true)
+		}
+		text(monthShort[start.Month()], center, bottom+25, textSize, chart.ColorBlack, true)
+	}
+	line(left, bottom, right, bottom, chart.ColorBlack, 2)
+
+	buffer := bytes.NewBuffer([]byte{})
+	if err := r.Save(buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// GenerateYearSavingsChart создает график доли сохраненного дохода по месяцам года
+// со средней за год
+func (g *ChartGenerator) GenerateYearSavingsChart(review *service.YearReview) ([]byte, error) {
+	if len(review.Months) < 2 {
+		return nil, fmt.Errorf("not enough data for savings chart: %d months", len(review.Months))
+	}
+
+	rates := make([]float64, len(review.Months))
+	average := make([]float64, len(review.Months))
+	for i := range review.Months {
+		rates[i] = review.MonthSavingsRate(i)
+		average[i] = review.SavingsRate()
+	}
+
+	graph := chart.Chart{
+		Title:  fmt.Sprintf("Доля сохраненного дохода в %d году", review.Year),
+		Width:  1200,
+		Height: 600,
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top:    50,
+				Left:   50,
+				Right:  50,
+				Bottom: 50,
+			},
+			FillColor: chart.ColorWhite,
+		},
+		XAxis: chart.XAxis{
+			ValueFormatter: chart.TimeValueFormatterWithFormat("01.2006"),
+			Style: chart.Style{
+				FontSize:  12,
+				FontColor: chart.ColorBlack,
+			},
+		},
+		YAxis: chart.YAxis{
+			ValueFormatter: func(v interface{}) string {
+				return fmt.Sprintf("%.0f%%", v.(float64))
+			},
+			Style: chart.Style{
+				FontSize:  12,
+				FontColor: chart.ColorBlack,
+			},
+		},
+		Series: []chart.Series{
+			chart.TimeSeries{
+				Name:    "Сохранено",
+				XValues: review.Months,
+				YValues: rates,
+				Style: chart.Style{
+					StrokeColor: chart.ColorGreen,
+					FillColor:   chart.ColorGreen.WithAlpha(40),
+					StrokeWidth: 3,
+					DotWidth:    5,
+					DotColor:    chart.ColorGreen,
+				},
+			},
+			chart.TimeSeries{
+				Name:    fmt.Sprintf("За год: %.0f%%", review.SavingsRate()),
+				XValues: review.Months,
+				YValues: average,
+				Style: chart.Style{
+					StrokeColor:     chart.ColorBlack,
+					StrokeWidth:     1,
+					StrokeDashArray: []float64{5, 5},
+				},
+			},
+		},
+	}
+
+	graph.Elements = []chart.Renderable{
+		chart.Legend(&graph, chart.Style{
+			FontSize:  12,
+			FontColor: chart.ColorBlack,
+		}),
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	err := graph.Render(g.renderer(), buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render savings chart: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// heatmapLevels - сколько оттенков различает тепловая карта трат
+const heatmapLevels = 4
+
+// GenerateYearHeatmap создает тепловую карту трат по дням года: столбец - неделя,
+// строка - день недели. Оттенки считаются от 95-го перцентиля, чтобы одна крупная
+// покупка не обесцветила остальные дни
+func (g *ChartGenerator) GenerateYearHeatmap(review *service.YearReview) ([]byte, error) {
+	const (
+		width, height = 1200, 330
+		left, top     = 70, 100
+		cell, gap     = 18, 3
+		textSize      = 12.0
+		titleSize     = 14.0
+	)
+	r, err := g.renderer()(width, height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create renderer: %w", err)
+	}
+	font, err := chart.GetDefaultFont()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load font: %w", err)
+	}
+	text := func(body string, x, y int, size float64, color drawing.Color) {
+		chart.Draw.Text(r, body, x, y, chart.Style{Font: font, FontSize: size, FontColor: color})
+	}
+	square := func(x, y int, color drawing.Color) {
+		chart.Draw.Box(r, chart.NewBox(y, x, x+cell, y+cell), chart.Style{FillColor: color, StrokeColor: color})
+	}
+
+	var spent []float64
+	for _, amount := range review.Daily {
+		if amount > 0 {
+			spent = append(spent, amount)
+		}
+	}
+	sort.Float64s(spent)
+	high := 1.0
+	if len(spent) > 0 {
+		high = spent[(len(spent)-1)*95/100]
+	}
+	shade := func(amount float64) drawing.Color {
+		if amount <= 0 {
+			return drawing.ColorFromHex("ebedf0")
+		}
+		level := int(math.Ceil(math.Min(amount/high, 1) * heatmapLevels))
+		return chart.ColorRed.WithAlpha(uint8(255 * max(level, 1) / heatmapLevels))
+	}
+
+	chart.Draw.Box(r, chart.NewBox(0, 0, width, height), chart.Style{
+		FillColor:   chart.ColorWhite,
+		StrokeColor: chart.ColorWhite,
+	})
+	text(fmt.Sprintf("Траты %d года по дням", review.Year), left, 40, titleSize, chart.ColorBlack)
+
+	// Неделя начинается с понедельника, подписаны нечетные строки, как в календаре
+	for _, weekday := range []time.Weekday{time.Monday, time.Wednesday, time.Friday} {
+		row := (int(weekday) + 6) % 7
+		text(weekdayShort[weekday], left-35, top+row*(cell+gap)+cell-4, textSize, chart.ColorBlack)
+	}
+
+	start := time.Date(review.Year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(start.Weekday()) + 6) % 7
+	for i, amount := range review.Daily {
+		day := start.AddDate(0, 0, i)
+		column, row := (i+offset)/7, (int(day.Weekday())+6)%7
+		x := left + column*(cell+gap)
+		if day.Day() == 1 {
+			text(monthShort[day.Month()], x, top-10, textSize, chart.ColorBlack)
+		}
+		square(x, top+row*(cell+gap), shade(amount))
+	}
+
+	// Шкала оттенков под картой
+	legendY := top + 7*(cell+gap) + 20
+	text("Меньше", left, legendY+cell-4, textSize, chart.ColorAlternateGray)
+	x := left + chart.Draw.MeasureText(r, "Меньше", chart.Style{Font: font, FontSize: textSize}).Width() + 8
+	for level := 0; level <= heatmapLevels; level++ {
+		square(x, legendY, shade(high*float64(level)/heatmapLevels))
+		x += cell + gap
+	}
+	text(fmt.Sprintf("Больше (от %.0f₽ в день)", high), x+5, legendY+cell-4, textSize, chart.ColorAlternateGray)
+
+	buffer := bytes.NewBuffer([]byte{})
+	if err := r.Save(buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
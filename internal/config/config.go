@@ -1,24 +1,216 @@
 package config
 
 import (
+    "encoding/base64"
+    "errors"
+    "fmt"
+    "net/url"
     "os"
+    "regexp"
+    "strconv"
+    "strings"
+
     "github.com/joho/godotenv"
 )
 
+// Уровни логирования
+const (
+    LogDebug = "debug"
+    LogInfo  = "info"
+    LogWarn  = "warn"
+    LogError = "error"
+)
+
+// Значения по умолчанию для необязательных параметров
+const (
+    DefaultBaseCurrency = "RUB"
+    DefaultLogLevel     = LogInfo
+    DefaultListenAddr   = ":8080"
+    DefaultRatesURL     = "https://www.cbr-xml-daily.ru" // зеркало курсов ЦБ РФ в JSON
+    DefaultMOEXURL      = "https://iss.moex.com"         // ISS Московской биржи
+    DefaultCoinGeckoURL = "https://api.coingecko.com"    // курсы криптовалют
+)
+
+// currencyCode - код валюты ISO 4217
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// webhookSecret - допустимые символы секрета webhook по документации Telegram
+var webhookSecret = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
 type Config struct {
     SupabaseURL    string
     SupabaseKey    string
     TelegramToken  string
+    ReceiptToken   string // токен API proverkacheka.com для импорта чеков
+    AttachmentsBucket string // приватный бакет Supabase Storage для фото чеков к транзакциям, пусто - фото отключены
+    SentryDSN      string // DSN Sentry для отчетов об ошибках, пусто - отчеты отключены
+    WebhookSecret  string // секрет из заголовка X-Telegram-Bot-Api-Secret-Token, пусто - не проверяется
+    WebhookURL     string // публичный HTTPS-адрес webhook; если задан, cmd/bot работает как webhook-сервер
+    ListenAddr     string // адрес webhook-сервера cmd/bot, например ":8080"
+    TLSCertFile    string // сертификат webhook-сервера; пусто - TLS завершает прокси
+    TLSKeyFile     string // ключ сертификата TLSCertFile
+    AdminIDs       []int64 // пользователи Telegram с доступом к администрированию бота
+    BaseCurrency   string // код валюты ISO 4217, в которой ведется учет
+    RatesURL       string // архив курсов валют по дням в формате cbr-xml-daily.ru
+    MOEXURL        string // ISS Московской биржи для котировок акций и фондов
+    CoinGeckoURL   string // API CoinGecko для курсов криптовалют
+    LogLevel       string // debug, info, warn или error
+    EncryptionKey  []byte // ключ AES-256 для шифрования описаний транзакций, пусто - без шифрования
+    InflationRate  float64 // годовая инфляция в процентах для пересчета годового отчета, 0 - не задана
+    InflationCPIURL string // URL индекса цен по месяцам; если задан, используется вместо InflationRate
 }
 
+// IsAdmin проверяет, что пользователь указан в ADMIN_IDS
+func (c *Config) IsAdmin(userID int64) bool {
+    for _, id := range c.AdminIDs {
+        if id == userID {
+            return true
+        }
+    }
+    return false
+}
+
+// LoadConfig читает конфигурацию из переменных окружения. Файл .env необязателен:
+// в функции и контейнере переменные задаются окружением. Если задан SECRETS_PROVIDER,
+// недостающие переменные загружаются из хранилища секретов
 func LoadConfig() (*Config, error) {
-    if err := godotenv.Load(); err != nil {
-        return nil, err
+    if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+        return nil, fmt.Errorf("failed to load .env: %w", err)
+    }
+
+    provider, err := newSecretProvider()
+    if err != nil {
+        return nil, fmt.Errorf("invalid configuration: %w", err)
+    }
+    if provider != nil {
+        if err := loadSecrets(provider); err != nil {
+            return nil, err
+        }
+    }
+
+    var errs []error
+    cfg := &Config{
+        SupabaseURL:    required("SUPABASE_URL", &errs),
+        SupabaseKey:    required("SUPABASE_KEY", &errs),
+        TelegramToken:  required("TELEGRAM_TOKEN", &errs),
+        ReceiptToken:   os.Getenv("PROVERKACHEKA_TOKEN"),
+        AttachmentsBucket: strings.TrimSpace(os.Getenv("SUPABASE_ATTACHMENTS_BUCKET")),
+        SentryDSN:      os.Getenv("SENTRY_DSN"),
+        WebhookSecret:  os.Getenv("TELEGRAM_WEBHOOK_SECRET"),
+        WebhookURL:     strings.TrimSpace(os.Getenv("TELEGRAM_WEBHOOK_URL")),
+        ListenAddr:     withDefault("LISTEN_ADDR", DefaultListenAddr),
+        TLSCertFile:    strings.TrimSpace(os.Getenv("TLS_CERT_FILE")),
+        TLSKeyFile:     strings.TrimSpace(os.Getenv("TLS_KEY_FILE")),
+        BaseCurrency:   withDefault("BASE_CURRENCY", DefaultBaseCurrency),
+        RatesURL:       withDefault("EXCHANGE_RATES_URL", DefaultRatesURL),
+        MOEXURL:        withDefault("MOEX_ISS_URL", DefaultMOEXURL),
+        CoinGeckoURL:   withDefault("COINGECKO_URL", DefaultCoinGeckoURL),
+        LogLevel:       strings.ToLower(withDefault("LOG_LEVEL", DefaultLogLevel)),
+    }
+
+    for _, field := range strings.Split(os.Getenv("ADMIN_IDS"), ",") {
+        if field = strings.TrimSpace(field); field == "" {
+            continue
+        }
+        id, err := strconv.ParseInt(field, 10, 64)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("ADMIN_IDS: %q is not a Telegram user ID", field))
+            continue
+        }
+        cfg.AdminIDs = append(cfg.AdminIDs, id)
+    }
+
+    if key := strings.TrimSpace(os.Getenv("ENCRYPTION_KEY")); key != "" {
+        decoded, err := base64.StdEncoding.DecodeString(key)
+        if err != nil || len(decoded) != 32 {
+            errs = append(errs, errors.New("ENCRYPTION_KEY: expected 32 random bytes in base64 (openssl rand -base64 32)"))
+        } else {
+            cfg.EncryptionKey = decoded
+        }
+    }
+
+    if rate := strings.TrimSpace(os.Getenv("INFLATION_RATE")); rate != "" {
+        value, err := strconv.ParseFloat(strings.ReplaceAll(rate, ",", "."), 64)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("INFLATION_RATE: %q is not a number of percent per year", rate))
+        } else {
+            cfg.InflationRate = value
+        }
+    }
+    cfg.InflationCPIURL = strings.TrimSpace(os.Getenv("INFLATION_CPI_URL"))
+
+    if err := cfg.Validate(); err != nil {
+        errs = append(errs, err)
+    }
+    if len(errs) > 0 {
+        return nil, fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+    }
+    return cfg, nil
+}
+
+// Validate проверяет значения параметров. Отсутствие обязательных проверяет LoadConfig
+func (c *Config) Validate() error {
+    var errs []error
+    if c.SupabaseURL != "" {
+        if u, err := url.Parse(c.SupabaseURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+            errs = append(errs, fmt.Errorf("SUPABASE_URL: %q is not an http(s) URL", c.SupabaseURL))
+        }
+    }
+    if c.TelegramToken != "" && !strings.Contains(c.TelegramToken, ":") {
+        errs = append(errs, errors.New("TELEGRAM_TOKEN: expected format <bot id>:<secret> from @BotFather"))
+    }
+    if c.WebhookSecret != "" && !webhookSecret.MatchString(c.WebhookSecret) {
+        errs = append(errs, errors.New("TELEGRAM_WEBHOOK_SECRET: 1-256 characters A-Z, a-z, 0-9, _ and - are allowed"))
+    }
+    if c.WebhookURL != "" {
+        if u, err := url.Parse(c.WebhookURL); err != nil || u.Scheme != "https" || u.Host == "" {
+            errs = append(errs, fmt.Errorf("TELEGRAM_WEBHOOK_URL: %q is not an https URL, Telegram accepts only HTTPS webhooks", c.WebhookURL))
+        }
+    }
+    if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+        errs = append(errs, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+    }
+    if !currencyCode.MatchString(c.BaseCurrency) {
+        errs = append(errs, fmt.Errorf("BASE_CURRENCY: %q is not an ISO 4217 code like RUB", c.BaseCurrency))
+    }
+    if u, err := url.Parse(c.RatesURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+        errs = append(errs, fmt.Errorf("EXCHANGE_RATES_URL: %q is not an http(s) URL", c.RatesURL))
     }
+    if u, err := url.Parse(c.MOEXURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+        errs = append(errs, fmt.Errorf("MOEX_ISS_URL: %q is not an http(s) URL", c.MOEXURL))
+    }
+    if u, err := url.Parse(c.CoinGeckoURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+        errs = append(errs, fmt.Errorf("COINGECKO_URL: %q is not an http(s) URL", c.CoinGeckoURL))
+    }
+    if c.InflationCPIURL != "" {
+        if u, err := url.Parse(c.InflationCPIURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+            errs = append(errs, fmt.Errorf("INFLATION_CPI_URL: %q is not an http(s) URL", c.InflationCPIURL))
+        }
+    }
+    if c.InflationRate <= -100 {
+        errs = append(errs, fmt.Errorf("INFLATION_RATE: %v%% per year is not possible", c.InflationRate))
+    }
+    switch c.LogLevel {
+    case LogDebug, LogInfo, LogWarn, LogError:
+    default:
+        errs = append(errs, fmt.Errorf("LOG_LEVEL: %q is not one of debug, info, warn, error", c.LogLevel))
+    }
+    return errors.Join(errs...)
+}
 
-    return &Config{
-        SupabaseURL:    os.Getenv("SUPABASE_URL"),
-        SupabaseKey:    os.Getenv("SUPABASE_KEY"),
-        TelegramToken:  os.Getenv("TELEGRAM_TOKEN"),
-    }, nil
-} 
\ No newline at end of file
+// required возвращает значение обязательной переменной и запоминает ошибку, если она не задана
+func required(name string, errs *[]error) string {
+    value := strings.TrimSpace(os.Getenv(name))
+    if value == "" {
+        *errs = append(*errs, fmt.Errorf("%s is required", name))
+    }
+    return value
+}
+
+// withDefault возвращает значение переменной или значение по умолчанию
+func withDefault(name, fallback string) string {
+    if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+        return value
+    }
+    return fallback
+}
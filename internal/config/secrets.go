@@ -0,0 +1,69 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// secretsTimeout ограничивает загрузку секретов при старте
+const secretsTimeout = 10 * time.Second
+
+// SecretProvider загружает секреты из внешнего хранилища. Ключи - имена переменных
+// окружения (TELEGRAM_TOKEN, SUPABASE_KEY, ...), значения - их значения
+type SecretProvider interface {
+	Secrets(ctx context.Context) (map[string]string, error)
+}
+
+// secretsHTTPClient - клиент для запросов к хранилищам секретов
+var secretsHTTPClient = &http.Client{Timeout: secretsTimeout}
+
+// newSecretProvider выбирает хранилище по SECRETS_PROVIDER. Пустое значение - секреты
+// берутся только из окружения
+func newSecretProvider() (SecretProvider, error) {
+	switch name := strings.ToLower(strings.TrimSpace(os.Getenv("SECRETS_PROVIDER"))); name {
+	case "":
+		return nil, nil
+	case "lockbox":
+		return newLockboxProvider()
+	case "aws":
+		return newAWSSecretsProvider()
+	case "vault":
+		return newVaultProvider()
+	default:
+		return nil, fmt.Errorf("SECRETS_PROVIDER: %q is not one of lockbox, aws, vault", name)
+	}
+}
+
+// loadSecrets дополняет окружение секретами из хранилища. Значения, уже заданные
+// в окружении, не перезаписываются: так их можно переопределить при отладке
+func loadSecrets(provider SecretProvider) error {
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+
+	secrets, err := provider.Secrets(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load secrets: %w", err)
+	}
+	for name, value := range secrets {
+		if _, ok := os.LookupEnv(name); ok {
+			continue
+		}
+		if err := os.Setenv(name, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// requireEnv возвращает значение переменной, нужной хранилищу секретов
+func requireEnv(name string) (string, error) {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return "", fmt.Errorf("%s is required for SECRETS_PROVIDER=%s", name, os.Getenv("SECRETS_PROVIDER"))
+	}
+	return value, nil
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// awsSecretsProvider читает секрет AWS Secrets Manager: SecretString - JSON-объект
+// с переменными окружения. Запрос подписывается Signature V4 без SDK
+type awsSecretsProvider struct {
+	secretID     string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+// newAWSSecretsProvider настраивается переменной AWS_SECRET_ID. Регион и ключи доступа
+// Lambda задает сама: AWS_REGION, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN
+func newAWSSecretsProvider() (SecretProvider, error) {
+	p := &awsSecretsProvider{sessionToken: os.Getenv("AWS_SESSION_TOKEN")}
+	for _, v := range []struct {
+		name string
+		dst  *string
+	}{
+		{"AWS_SECRET_ID", &p.secretID},
+		{"AWS_REGION", &p.region},
+		{"AWS_ACCESS_KEY_ID", &p.accessKey},
+		{"AWS_SECRET_ACCESS_KEY", &p.secretKey},
+	} {
+		value, err := requireEnv(v.name)
+		if err != nil {
+			return nil, err
+		}
+		*v.dst = value
+	}
+	return p, nil
+}
+
+func (p *awsSecretsProvider) Secrets(ctx context.Context) (map[string]string, error) {
+	body, err := json.Marshal(map[string]string{"SecretId": p.secretID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	host := fmt.Sprintf("secretsmanager.%s.amazonaws.com", p.region)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://"+host+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	p.sign(req, host, body, time.Now().UTC())
+
+	var value struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := doSecretsRequest(req, &value); err != nil {
+		return nil, fmt.Errorf("failed to get secret value: %w", err)
+	}
+
+	var secrets map[string]string
+	if err := json.Unmarshal([]byte(value.SecretString), &secrets); err != nil {
+		return nil, fmt.Errorf("secret must be a JSON object of strings: %w", err)
+	}
+	return secrets, nil
+}
+
+// sign добавляет к запросу подпись AWS Signature Version 4
+func (p *awsSecretsProvider) sign(req *http.Request, host string, body []byte, now time.Time) {
+	const service = "secretsmanager"
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("Host", host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	signedHeaders := "content-type;host;x-amz-date;x-amz-target"
+	canonicalHeaders := fmt.Sprintf("content-type:%s\nhost:%s\nx-amz-date:%s\nx-amz-target:%s\n",
+		req.Header.Get("Content-Type"), host, amzDate, req.Header.Get("X-Amz-Target"))
+	if p.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", p.sessionToken)
+		signedHeaders = "content-type;host;x-amz-date;x-amz-security-token;x-amz-target"
+		canonicalHeaders = fmt.Sprintf("content-type:%s\nhost:%s\nx-amz-date:%s\nx-amz-security-token:%s\nx-amz-target:%s\n",
+			req.Header.Get("Content-Type"), host, amzDate, p.sessionToken, req.Header.Get("X-Amz-Target"))
+	}
+
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := fmt.Sprintf("POST\n/\n\n%s\n%s\n%s",
+		canonicalHeaders, signedHeaders, hex.EncodeToString(payloadHash[:]))
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, p.region, service)
+	stringToSign := fmt.Sprintf("AWS4-HMAC-SHA256\n%s\n%s\n%s", amzDate, scope, hex.EncodeToString(requestHash[:]))
+
+	key := hmacSHA256([]byte("AWS4"+p.secretKey), date)
+	key = hmacSHA256(key, p.region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		p.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
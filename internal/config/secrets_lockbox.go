@@ -0,0 +1,95 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+const (
+	lockboxEndpoint = "https://payload.lockbox.api.cloud.yandex.net/lockbox/v1/secrets/"
+	// metadataTokenURL выдает IAM-токен сервисного аккаунта функции
+	metadataTokenURL = "http://169.254.169.254/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// lockboxProvider читает секрет Yandex Lockbox: каждый ключ секрета - переменная окружения
+type lockboxProvider struct {
+	secretID string
+	iamToken string
+}
+
+// newLockboxProvider настраивается переменными LOCKBOX_SECRET_ID и необязательной YC_IAM_TOKEN.
+// Без токена используется сервисный аккаунт функции через сервис метаданных
+func newLockboxProvider() (SecretProvider, error) {
+	secretID, err := requireEnv("LOCKBOX_SECRET_ID")
+	if err != nil {
+		return nil, err
+	}
+	return &lockboxProvider{secretID: secretID, iamToken: os.Getenv("YC_IAM_TOKEN")}, nil
+}
+
+func (p *lockboxProvider) Secrets(ctx context.Context) (map[string]string, error) {
+	token := p.iamToken
+	if token == "" {
+		var err error
+		if token, err = metadataIAMToken(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, lockboxEndpoint+url.PathEscape(p.secretID)+"/payload", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var payload struct {
+		Entries []struct {
+			Key       string `json:"key"`
+			TextValue string `json:"textValue"`
+		} `json:"entries"`
+	}
+	if err := doSecretsRequest(req, &payload); err != nil {
+		return nil, fmt.Errorf("failed to get lockbox payload: %w", err)
+	}
+
+	secrets := make(map[string]string, len(payload.Entries))
+	for _, entry := range payload.Entries {
+		secrets[entry.Key] = entry.TextValue
+	}
+	return secrets, nil
+}
+
+// metadataIAMToken получает IAM-токен сервисного аккаунта, привязанного к функции
+func metadataIAMToken(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataTokenURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doSecretsRequest(req, &token); err != nil {
+		return "", fmt.Errorf("failed to get IAM token from metadata service: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+// doSecretsRequest выполняет запрос к хранилищу секретов и разбирает JSON-ответ
+func doSecretsRequest(req *http.Request, result interface{}) error {
+	resp, err := secretsHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
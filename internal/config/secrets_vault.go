@@ -0,0 +1,67 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// vaultProvider читает секрет HashiCorp Vault из хранилища KV версии 1 или 2
+type vaultProvider struct {
+	addr  string
+	token string
+	path  string
+}
+
+// newVaultProvider настраивается переменными VAULT_ADDR, VAULT_TOKEN и VAULT_SECRET_PATH
+// (для KV v2 - полный путь API, например secret/data/financial_bot)
+func newVaultProvider() (SecretProvider, error) {
+	addr, err := requireEnv("VAULT_ADDR")
+	if err != nil {
+		return nil, err
+	}
+	token, err := requireEnv("VAULT_TOKEN")
+	if err != nil {
+		return nil, err
+	}
+	path, err := requireEnv("VAULT_SECRET_PATH")
+	if err != nil {
+		return nil, err
+	}
+	return &vaultProvider{
+		addr:  strings.TrimRight(addr, "/"),
+		token: token,
+		path:  strings.Trim(path, "/"),
+	}, nil
+}
+
+func (p *vaultProvider) Secrets(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.addr+"/v1/"+p.path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+
+	var secret struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := doSecretsRequest(req, &secret); err != nil {
+		return nil, fmt.Errorf("failed to read vault secret: %w", err)
+	}
+
+	// KV v2 вкладывает значения в data.data вместе с метаданными версии
+	var v2 struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(secret.Data, &v2); err == nil && v2.Data != nil {
+		return v2.Data, nil
+	}
+
+	var v1 map[string]string
+	if err := json.Unmarshal(secret.Data, &v1); err != nil {
+		return nil, fmt.Errorf("vault secret values must be strings: %w", err)
+	}
+	return v1, nil
+}
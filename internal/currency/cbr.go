@@ -0,0 +1,97 @@
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxLookback - на сколько дней назад искать курс: ЦБ не устанавливает курсы
+// на воскресенья, понедельники после праздников и праздничные дни
+const maxLookback = 10
+
+// CBRClient загружает официальные курсы ЦБ РФ из архива по дням:
+// <url>/archive/2026/10/16/daily_json.js
+type CBRClient struct {
+	url    string
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]map[string]float64 // курсы по дню запроса, YYYY-MM-DD
+}
+
+// NewCBRClient создает клиент архива курсов ЦБ
+func NewCBRClient(url string) *CBRClient {
+	return &CBRClient{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: 15 * time.Second},
+		cache:  make(map[string]map[string]float64),
+	}
+}
+
+// cbrDaily - ответ архива курсов
+type cbrDaily struct {
+	Valute map[string]struct {
+		Nominal float64 `json:"Nominal"`
+		Value   float64 `json:"Value"`
+	} `json:"Valute"`
+}
+
+// DailyRates возвращает курсы на день: сколько рублей стоит единица валюты. Если на этот
+// день ЦБ курс не устанавливал, действует последний установленный до него
+func (c *CBRClient) DailyRates(ctx context.Context, day time.Time) (map[string]float64, error) {
+	key := day.Format("2006-01-02")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if rates, ok := c.cache[key]; ok {
+		return rates, nil
+	}
+
+	for i := 0; i < maxLookback; i++ {
+		rates, err := c.fetch(ctx, day.AddDate(0, 0, -i))
+		if err != nil {
+			return nil, err
+		}
+		if rates != nil {
+			c.cache[key] = rates
+			return rates, nil
+		}
+	}
+	return nil, fmt.Errorf("no CBR rates for %d days before %s", maxLookback, key)
+}
+
+// fetch загружает курсы за день. Возвращает nil без ошибки, если на этот день курсов нет
+func (c *CBRClient) fetch(ctx context.Context, day time.Time) (map[string]float64, error) {
+	url := fmt.Sprintf("%s/archive/%s/daily_json.js", c.url, day.Format("2006/01/02"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CBR request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch CBR rates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CBR rates source returned status %d", resp.StatusCode)
+	}
+
+	var daily cbrDaily
+	if err := json.NewDecoder(resp.Body).Decode(&daily); err != nil {
+		return nil, fmt.Errorf("failed to decode CBR rates: %w", err)
+	}
+	rates := make(map[string]float64, len(daily.Valute))
+	for code, valute := range daily.Valute {
+		if valute.Nominal > 0 {
+			rates[code] = valute.Value / valute.Nominal
+		}
+	}
+	return rates, nil
+}
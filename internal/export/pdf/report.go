@@ -0,0 +1,128 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/wcharczuk/go-chart/v2/roboto"
+)
+
+const (
+	// fontFamily - шрифт с кириллицей, встроенный в go-chart
+	fontFamily = "Roboto"
+	// pageWidth - ширина области печати A4 с полями 15 мм
+	pageWidth = 180.0
+	// maxCategoryRows - сколько категорий выводить в таблицах
+	maxCategoryRows = 15
+)
+
+// Chart - график, встраиваемый в отчет
+type Chart struct {
+	Title string
+	PNG   []byte
+}
+
+// RenderReport формирует PDF-выписку: итоги периода, таблицы категорий и графики
+func RenderReport(report *service.BaseReport, charts []Chart) ([]byte, error) {
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetMargins(15, 15, 15)
+	doc.SetAutoPageBreak(true, 15)
+	doc.AddUTF8FontFromBytes(fontFamily, "", roboto.Roboto)
+	doc.SetTitle("Финансовый отчет: "+report.Period, true)
+	doc.AddPage()
+
+	doc.SetFont(fontFamily, "", 18)
+	doc.CellFormat(pageWidth, 10, "Финансовый отчет", "", 1, "L", false, 0, "")
+	doc.SetFont(fontFamily, "", 12)
+	doc.CellFormat(pageWidth, 7, report.Period, "", 1, "L", false, 0, "")
+	doc.Ln(4)
+
+	heading(doc, "Итоги")
+	table(doc, []float64{120, 60}, [][]string{
+		{"Доходы", money(report.TotalIncome)},
+		{"Расходы", money(report.TotalExpenses)},
+		{"Баланс", money(report.Balance)},
+		{"Транзакций", fmt.Sprintf("%d", report.TransactionData.TotalCount)},
+		{"Средний расход в день", money(report.TransactionData.DailyAvgExpense)},
+	})
+
+	categoryTable(doc, "Расходы по категориям", report.CategoryData.Expenses)
+	categoryTable(doc, "Доходы по категориям", report.CategoryData.Income)
+
+	if len(report.Members) > 0 {
+		heading(doc, "Участники")
+		rows := [][]string{{"Участник", "Доходы", "Расходы"}}
+		for _, m := range report.Members {
+			rows = append(rows, []string{m.Name, money(m.Income), money(m.Expenses)})
+		}
+		table(doc, []float64{90, 45, 45}, rows)
+	}
+
+	for i, chart := range charts {
+		if len(chart.PNG) == 0 {
+			continue
+		}
+		doc.AddPage()
+		heading(doc, chart.Title)
+
+		name := fmt.Sprintf("chart%d", i)
+		options := gofpdf.ImageOptions{ImageType: "PNG", ReadDpi: false}
+		doc.RegisterImageOptionsReader(name, options, bytes.NewReader(chart.PNG))
+		doc.ImageOptions(name, doc.GetX(), doc.GetY(), pageWidth, 0, true, options, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := doc.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render pdf: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// categoryTable выводит таблицу категорий с суммами и долями
+func categoryTable(doc *gofpdf.Fpdf, title string, stats []model.CategoryStats) {
+	if len(stats) == 0 {
+		return
+	}
+	heading(doc, title)
+
+	rows := [][]string{{"Категория", "Сумма", "Доля"}}
+	for i, s := range stats {
+		if i == maxCategoryRows {
+			break
+		}
+		rows = append(rows, []string{s.Name, money(math.Abs(s.Amount)), fmt.Sprintf("%.1f%%", s.Share)})
+		for _, sub := range s.Subcategories {
+			rows = append(rows, []string{"    " + sub.Name, money(math.Abs(sub.Amount)), fmt.Sprintf("%.1f%%", sub.Share)})
+		}
+	}
+	table(doc, []float64{110, 45, 25}, rows)
+}
+
+func heading(doc *gofpdf.Fpdf, text string) {
+	doc.Ln(3)
+	doc.SetFont(fontFamily, "", 14)
+	doc.CellFormat(pageWidth, 8, text, "", 1, "L", false, 0, "")
+	doc.SetFont(fontFamily, "", 11)
+}
+
+// table выводит строки таблицы; все колонки, кроме первой, выравниваются по правому краю
+func table(doc *gofpdf.Fpdf, widths []float64, rows [][]string) {
+	for _, row := range rows {
+		for i, cell := range row {
+			align := "R"
+			if i == 0 {
+				align = "L"
+			}
+			doc.CellFormat(widths[i], 7, cell, "B", 0, align, false, 0, "")
+		}
+		doc.Ln(-1)
+	}
+}
+
+func money(amount float64) string {
+	return fmt.Sprintf("%.2f ₽", amount)
+}
@@ -0,0 +1,189 @@
+package xlsx
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+	"github.com/xuri/excelize/v2"
+)
+
+// Названия листов книги
+const (
+	sheetTransactions = "Транзакции"
+	sheetMonthly      = "По месяцам"
+	sheetBudgets      = "Бюджеты"
+)
+
+// Render формирует книгу Excel из листов в "длинном" формате, удобном для сводных таблиц:
+// все транзакции, суммы по категориям за каждый месяц и бюджеты с тратами текущего месяца
+func Render(data *service.ExportData, now time.Time) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := f.SetSheetName("Sheet1", sheetTransactions); err != nil {
+		return nil, fmt.Errorf("failed to rename sheet: %w", err)
+	}
+	for _, name := range []string{sheetMonthly, sheetBudgets} {
+		if _, err := f.NewSheet(name); err != nil {
+			return nil, fmt.Errorf("failed to create sheet %s: %w", name, err)
+		}
+	}
+
+	dateStyle, err := f.NewStyle(&excelize.Style{NumFmt: 14})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create date style: %w", err)
+	}
+	moneyStyle, err := f.NewStyle(&excelize.Style{NumFmt: 4})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create money style: %w", err)
+	}
+
+	categories := make(map[string]string, len(data.Categories))
+	categoryTypes := make(map[string]string, len(data.Categories))
+	for _, c := range data.Categories {
+		categories[c.ID] = c.Name
+		categoryTypes[c.ID] = c.Type
+	}
+	accounts := make(map[string]string, len(data.Accounts))
+	for _, a := range data.Accounts {
+		accounts[a.ID] = a.Name
+	}
+
+	// Транзакции: сумма со знаком, как в боте
+	rows := [][]interface{}{{"Дата", "Тип", "Категория", "Счет", "Сумма", "Описание"}}
+	for _, t := range data.Transactions {
+		rows = append(rows, []interface{}{
+			t.Date, typeName(t), categories[t.CategoryID], accounts[t.AccountID], t.SignedAmount(), t.Description,
+		})
+	}
+	if err := writeSheet(f, sheetTransactions, rows, []float64{12, 10, 20, 16, 14, 40}); err != nil {
+		return nil, err
+	}
+	if len(rows) > 1 {
+		last := len(rows)
+		f.SetCellStyle(sheetTransactions, "A2", fmt.Sprintf("A%d", last), dateStyle)
+		f.SetCellStyle(sheetTransactions, "E2", fmt.Sprintf("E%d", last), moneyStyle)
+	}
+
+	// Суммы по категориям за месяц
+	type monthKey struct {
+		month      string
+		categoryID string
+	}
+	type monthTotal struct {
+		amount float64
+		count  int
+	}
+	totals := make(map[monthKey]*monthTotal)
+	for _, t := range data.Transactions {
+		key := monthKey{month: t.Date.Format("2006-01"), categoryID: t.CategoryID}
+		if totals[key] == nil {
+			totals[key] = &monthTotal{}
+		}
+		totals[key].amount += t.AbsAmount()
+		totals[key].count++
+	}
+	keys := make([]monthKey, 0, len(totals))
+	for key := range totals {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].month != keys[j].month {
+			return keys[i].month < keys[j].month
+		}
+		return categories[keys[i].categoryID] < categories[keys[j].categoryID]
+	})
+
+	rows = [][]interface{}{{"Месяц", "Категория", "Тип", "Сумма", "Транзакций"}}
+	for _, key := range keys {
+		rows = append(rows, []interface{}{
+			key.month, categories[key.categoryID], categoryTypeName(categoryTypes[key.categoryID]),
+			totals[key].amount, totals[key].count,
+		})
+	}
+	if err := writeSheet(f, sheetMonthly, rows, []float64{10, 20, 10, 14, 12}); err != nil {
+		return nil, err
+	}
+	if len(rows) > 1 {
+		f.SetCellStyle(sheetMonthly, "D2", fmt.Sprintf("D%d", len(rows)), moneyStyle)
+	}
+
+	// Бюджеты и траты текущего месяца
+	currentMonth := now.Format("2006-01")
+	rows = [][]interface{}{{"Категория", "Лимит в месяц", "Потрачено", "Остаток"}}
+	for _, budget := range data.Budgets {
+		spent := 0.0
+		if total := totals[monthKey{month: currentMonth, categoryID: budget.CategoryID}]; total != nil {
+			spent = total.amount
+		}
+		rows = append(rows, []interface{}{categories[budget.CategoryID], budget.Amount, spent, budget.Amount - spent})
+	}
+	if err := writeSheet(f, sheetBudgets, rows, []float64{20, 16, 14, 14}); err != nil {
+		return nil, err
+	}
+	if len(rows) > 1 {
+		f.SetCellStyle(sheetBudgets, "B2", fmt.Sprintf("D%d", len(rows)), moneyStyle)
+	}
+
+	f.SetActiveSheet(0)
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		return nil, fmt.Errorf("failed to write xlsx: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeSheet записывает строки, закрепляет заголовок и включает автофильтр
+func writeSheet(f *excelize.File, sheet string, rows [][]interface{}, widths []float64) error {
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			return fmt.Errorf("failed to write %s: %w", sheet, err)
+		}
+	}
+
+	for i, width := range widths {
+		col, err := excelize.ColumnNumberToName(i + 1)
+		if err != nil {
+			return err
+		}
+		f.SetColWidth(sheet, col, col, width)
+	}
+
+	f.SetPanes(sheet, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	})
+
+	lastCol, err := excelize.ColumnNumberToName(len(rows[0]))
+	if err != nil {
+		return err
+	}
+	if err := f.AutoFilter(sheet, fmt.Sprintf("A1:%s%d", lastCol, len(rows)), nil); err != nil {
+		return fmt.Errorf("failed to set autofilter on %s: %w", sheet, err)
+	}
+	return nil
+}
+
+func typeName(t model.Transaction) string {
+	if t.IsIncome() {
+		return "Доход"
+	}
+	return "Расход"
+}
+
+func categoryTypeName(categoryType string) string {
+	if categoryType == "income" {
+		return "Доход"
+	}
+	return "Расход"
+}
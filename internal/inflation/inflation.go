@@ -0,0 +1,105 @@
+package inflation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// monthKey - ключ месяца в данных об инфляции
+const monthKey = "2006-01"
+
+// Fixed - постоянная инфляция: годовой процент распределяется по месяцам поровну
+type Fixed struct {
+	monthly float64
+}
+
+// NewFixed создает источник с постоянной годовой инфляцией в процентах
+func NewFixed(annualPercent float64) *Fixed {
+	return &Fixed{monthly: (math.Pow(1+annualPercent/100, 1.0/12) - 1) * 100}
+}
+
+// MonthlyInflation возвращает одинаковый рост цен для каждого месяца периода
+func (f *Fixed) MonthlyInflation(_ context.Context, from, to time.Time) (map[string]float64, error) {
+	rates := make(map[string]float64)
+	for month := startOfMonth(from); !month.After(to); month = month.AddDate(0, 1, 0) {
+		rates[month.Format(monthKey)] = f.monthly
+	}
+	return rates, nil
+}
+
+// cpiCacheTTL - как долго хранятся загруженные данные индекса цен. Индекс публикуется раз в месяц
+const cpiCacheTTL = 24 * time.Hour
+
+// CPIClient загружает индекс потребительских цен по URL. Ответ - JSON-объект с ростом цен
+// за месяц в процентах к предыдущему месяцу: {"2026-01": 0.62, "2026-02": 0.48}
+type CPIClient struct {
+	url    string
+	client *http.Client
+
+	mu      sync.Mutex
+	rates   map[string]float64
+	fetched time.Time
+}
+
+// NewCPIClient создает клиент источника индекса цен
+func NewCPIClient(url string) *CPIClient {
+	return &CPIClient{
+		url:    url,
+		client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// MonthlyInflation возвращает рост цен за месяцы периода. Месяцев, по которым данных
+// еще нет, в ответе нет
+func (c *CPIClient) MonthlyInflation(ctx context.Context, from, to time.Time) (map[string]float64, error) {
+	all, err := c.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rates := make(map[string]float64)
+	for month := startOfMonth(from); !month.After(to); month = month.AddDate(0, 1, 0) {
+		if rate, ok := all[month.Format(monthKey)]; ok {
+			rates[month.Format(monthKey)] = rate
+		}
+	}
+	return rates, nil
+}
+
+// load возвращает данные индекса из кэша или загружает их заново
+func (c *CPIClient) load(ctx context.Context) (map[string]float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rates != nil && time.Since(c.fetched) < cpiCacheTTL {
+		return c.rates, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CPI request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch CPI: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CPI source returned status %d", resp.StatusCode)
+	}
+
+	var rates map[string]float64
+	if err := json.NewDecoder(resp.Body).Decode(&rates); err != nil {
+		return nil, fmt.Errorf("failed to decode CPI: %w", err)
+	}
+	c.rates, c.fetched = rates, time.Now()
+	return rates, nil
+}
+
+// startOfMonth возвращает первый день месяца
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,45 @@
+package locale
+
+import (
+	"context"
+	"strings"
+)
+
+// Поддерживаемые языки
+const (
+	Russian = "ru"
+	English = "en"
+
+	// Default - язык по умолчанию
+	Default = Russian
+)
+
+type contextKey struct{}
+
+// Normalize приводит код языка Telegram (например, "ru-RU") к поддерживаемому языку
+func Normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		lang = lang[:i]
+	}
+
+	switch lang {
+	case Russian, English:
+		return lang
+	default:
+		return Default
+	}
+}
+
+// WithLanguage сохраняет язык пользователя в контексте
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, contextKey{}, Normalize(lang))
+}
+
+// FromContext возвращает язык пользователя из контекста или язык по умолчанию
+func FromContext(ctx context.Context) string {
+	if lang, ok := ctx.Value(contextKey{}).(string); ok {
+		return lang
+	}
+	return Default
+}
@@ -0,0 +1,87 @@
+package locale
+
+import (
+	"fmt"
+	"time"
+)
+
+// Названия месяцев в именительном падеже ("апрель 2024")
+var monthsNominative = map[string][12]string{
+	Russian: {
+		"январь", "февраль", "март", "апрель", "май", "июнь",
+		"июль", "август", "сентябрь", "октябрь", "ноябрь", "декабрь",
+	},
+	English: {
+		"January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December",
+	},
+}
+
+// Названия месяцев в родительном падеже ("1 апреля 2024")
+var monthsGenitive = map[string][12]string{
+	Russian: {
+		"января", "февраля", "марта", "апреля", "мая", "июня",
+		"июля", "августа", "сентября", "октября", "ноября", "декабря",
+	},
+	English: monthsNominative[English],
+}
+
+// MonthName возвращает название месяца в именительном падеже
+func MonthName(lang string, month time.Month) string {
+	return monthsNominative[Normalize(lang)][month-1]
+}
+
+// FormatDay форматирует дату: "5 апреля 2024" / "April 5, 2024"
+func FormatDay(lang string, t time.Time) string {
+	lang = Normalize(lang)
+	if lang == English {
+		return fmt.Sprintf("%s %d, %d", monthsGenitive[lang][t.Month()-1], t.Day(), t.Year())
+	}
+	return fmt.Sprintf("%d %s %d", t.Day(), monthsGenitive[lang][t.Month()-1], t.Year())
+}
+
+// FormatMonth форматирует месяц: "апрель 2024" / "April 2024"
+func FormatMonth(lang string, t time.Time) string {
+	return fmt.Sprintf("%s %d", MonthName(lang, t.Month()), t.Year())
+}
+
+// FormatYear форматирует год: "2024 год" / "2024"
+func FormatYear(lang string, t time.Time) string {
+	if Normalize(lang) == English {
+		return fmt.Sprintf("%d", t.Year())
+	}
+	return fmt.Sprintf("%d год", t.Year())
+}
+
+// FormatRange форматирует диапазон дат, не повторяя общий месяц и год:
+// "1–7 апреля 2024", "28 марта – 3 апреля 2024", "28 декабря 2023 – 3 января 2024"
+func FormatRange(lang string, start, end time.Time) string {
+	lang = Normalize(lang)
+	months := monthsGenitive[lang]
+
+	if start.Year() == end.Year() && start.Month() == end.Month() && start.Day() == end.Day() {
+		return FormatDay(lang, start)
+	}
+
+	if lang == English {
+		switch {
+		case start.Year() == end.Year() && start.Month() == end.Month():
+			return fmt.Sprintf("%s %d–%d, %d", months[start.Month()-1], start.Day(), end.Day(), end.Year())
+		case start.Year() == end.Year():
+			return fmt.Sprintf("%s %d – %s %d, %d",
+				months[start.Month()-1], start.Day(), months[end.Month()-1], end.Day(), end.Year())
+		default:
+			return fmt.Sprintf("%s – %s", FormatDay(lang, start), FormatDay(lang, end))
+		}
+	}
+
+	switch {
+	case start.Year() == end.Year() && start.Month() == end.Month():
+		return fmt.Sprintf("%d–%d %s %d", start.Day(), end.Day(), months[end.Month()-1], end.Year())
+	case start.Year() == end.Year():
+		return fmt.Sprintf("%d %s – %d %s %d",
+			start.Day(), months[start.Month()-1], end.Day(), months[end.Month()-1], end.Year())
+	default:
+		return fmt.Sprintf("%s – %s", FormatDay(lang, start), FormatDay(lang, end))
+	}
+}
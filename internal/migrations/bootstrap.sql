@@ -0,0 +1,34 @@
+-- Однократная установка миграций: выполните в SQL-редакторе Supabase после migrations/init.sql.
+-- Дальше схема обновляется командой go run ./cmd/migrate up
+
+-- Примененные миграции
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INT PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Применение одной миграции. Функция выполняется в одной транзакции: если SQL миграции
+-- завершился ошибкой, ни изменения, ни отметка о применении не сохраняются
+CREATE OR REPLACE FUNCTION apply_migration(p_version INT, p_name TEXT, p_sql TEXT)
+RETURNS BOOLEAN
+LANGUAGE plpgsql
+SECURITY DEFINER
+SET search_path = public
+AS $$
+BEGIN
+    -- Два одновременных запуска не применят одну миграцию дважды
+    PERFORM pg_advisory_xact_lock(hashtext('schema_migrations'));
+    IF EXISTS (SELECT 1 FROM schema_migrations WHERE version = p_version) THEN
+        RETURN FALSE;
+    END IF;
+
+    EXECUTE p_sql;
+    INSERT INTO schema_migrations (version, name) VALUES (p_version, p_name);
+    -- PostgREST должен увидеть новые таблицы и колонки без перезапуска
+    NOTIFY pgrst, 'reload schema';
+    RETURN TRUE;
+END;
+$$;
+
+REVOKE EXECUTE ON FUNCTION apply_migration(INT, TEXT, TEXT) FROM PUBLIC, anon, authenticated;
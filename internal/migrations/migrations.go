@@ -0,0 +1,118 @@
+// Package migrations содержит версионированные миграции схемы базы. Каждая миграция -
+// файл sql/NNNN_название.sql; номер задает порядок применения и не меняется после релиза.
+// Уже выпущенные файлы не редактируются: изменение схемы - всегда новый файл
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// Bootstrap - SQL таблицы schema_migrations и функции apply_migration. Выполняется
+// вручную один раз: без этой функции применить остальные миграции нечем
+//
+//go:embed bootstrap.sql
+var Bootstrap string
+
+// fileName - имя файла миграции: номер и название через подчеркивание
+var fileName = regexp.MustCompile(`^(\d{4})_([a-z0-9_]+)\.sql$`)
+
+// Migration - одна миграция схемы
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Store применяет миграции к базе и помнит, какие уже применены
+type Store interface {
+	// AppliedMigrations возвращает номера примененных миграций
+	AppliedMigrations(ctx context.Context) ([]int, error)
+	// ApplyMigration применяет миграцию в одной транзакции вместе с отметкой о ней.
+	// applied = false, если миграцию уже применил другой запуск
+	ApplyMigration(ctx context.Context, version int, name, sql string) (applied bool, err error)
+}
+
+// All возвращает все миграции по возрастанию номера
+func All() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	seen := make(map[int]string, len(entries))
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q: expected NNNN_name.sql", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, other, entry.Name())
+		}
+		seen[version] = entry.Name()
+
+		data, err := files.ReadFile(path.Join("sql", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: match[2], SQL: string(data)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Pending возвращает миграции, которых нет среди примененных, по возрастанию номера
+func Pending(ctx context.Context, store Store) ([]Migration, error) {
+	migrations, err := All()
+	if err != nil {
+		return nil, err
+	}
+	versions, err := store.AppliedMigrations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	applied := make(map[int]bool, len(versions))
+	for _, version := range versions {
+		applied[version] = true
+	}
+
+	var pending []Migration
+	for _, migration := range migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up применяет ожидающие миграции по порядку и возвращает примененные. На первой ошибке
+// применение останавливается: следующие миграции могут зависеть от неудавшейся
+func Up(ctx context.Context, store Store) ([]Migration, error) {
+	pending, err := Pending(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range pending {
+		applied, err := store.ApplyMigration(ctx, migration.Version, migration.Name, migration.SQL)
+		if err != nil {
+			return done, fmt.Errorf("failed to apply migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if applied {
+			done = append(done, migration)
+		}
+	}
+	return done, nil
+}
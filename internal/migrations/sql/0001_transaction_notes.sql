@@ -0,0 +1,5 @@
+-- Заметки и фото чеков к транзакциям. Сами фото хранятся в приватном бакете Supabase Storage
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS note TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS attachment_path TEXT;
+INSERT INTO storage.buckets (id, name, public) VALUES ('attachments', 'attachments', false)
+    ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,2 @@
+-- Сессия меню: номер растет при каждом /start, кнопки сообщений прошлых сессий устаревают
+ALTER TABLE users ADD COLUMN IF NOT EXISTS menu_session INT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Перенос остатка бюджета: с какого месяца неизрасходованный остаток или перерасход
+-- переходит в следующий месяц. NULL - перенос выключен
+ALTER TABLE budgets ADD COLUMN IF NOT EXISTS rollover_since TIMESTAMPTZ;
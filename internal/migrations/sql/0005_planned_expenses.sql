@@ -0,0 +1,37 @@
+-- Планируемые крупные траты месяца: раздел «План и факт» месячного отчета
+CREATE TABLE IF NOT EXISTS planned_expenses (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id BIGINT NOT NULL,
+    category_id UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    description TEXT NOT NULL DEFAULT '',
+    amount DECIMAL NOT NULL CHECK (amount > 0),
+    month TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_planned_expenses_user_month ON planned_expenses(user_id, month);
+
+CREATE OR REPLACE FUNCTION delete_user_data(p_user_id BIGINT) RETURNS VOID
+LANGUAGE plpgsql AS $$
+BEGIN
+    DELETE FROM transactions WHERE user_id = p_user_id;
+    UPDATE transactions SET author_id = NULL WHERE author_id = p_user_id;
+    DELETE FROM budgets WHERE user_id = p_user_id;
+    DELETE FROM planned_expenses WHERE user_id = p_user_id;
+    DELETE FROM categories WHERE user_id = p_user_id;
+    DELETE FROM goals WHERE user_id = p_user_id;
+    DELETE FROM accounts WHERE user_id = p_user_id;
+    DELETE FROM assets WHERE user_id = p_user_id;
+    DELETE FROM net_worth_snapshots WHERE user_id = p_user_id;
+    DELETE FROM webhooks WHERE user_id = p_user_id;
+    DELETE FROM reminders WHERE user_id = p_user_id;
+    DELETE FROM announcement_deliveries WHERE user_id = p_user_id;
+    DELETE FROM user_baselines WHERE user_id = p_user_id;
+    DELETE FROM user_states WHERE user_id = p_user_id;
+    DELETE FROM user_settings WHERE user_id = p_user_id;
+    DELETE FROM ledger_members WHERE member_id = p_user_id OR owner_id = p_user_id;
+    DELETE FROM ledger_invites WHERE owner_id = p_user_id;
+    DELETE FROM report_cache WHERE owner_id = p_user_id;
+    DELETE FROM users WHERE id = p_user_id;
+END;
+$$;
@@ -0,0 +1,45 @@
+-- Список желаний: будущие покупки и суммы, которые пользователь откладывает на них
+CREATE TABLE IF NOT EXISTS wishlist_items (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    target_amount DECIMAL NOT NULL CHECK (target_amount > 0),
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS wishlist_allocations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    item_id UUID NOT NULL REFERENCES wishlist_items(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    amount DECIMAL NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_wishlist_items_user_id ON wishlist_items(user_id);
+CREATE INDEX IF NOT EXISTS idx_wishlist_allocations_user_id ON wishlist_allocations(user_id);
+
+CREATE OR REPLACE FUNCTION delete_user_data(p_user_id BIGINT) RETURNS VOID
+LANGUAGE plpgsql AS $$
+BEGIN
+    DELETE FROM transactions WHERE user_id = p_user_id;
+    UPDATE transactions SET author_id = NULL WHERE author_id = p_user_id;
+    DELETE FROM budgets WHERE user_id = p_user_id;
+    DELETE FROM planned_expenses WHERE user_id = p_user_id;
+    DELETE FROM categories WHERE user_id = p_user_id;
+    DELETE FROM goals WHERE user_id = p_user_id;
+    DELETE FROM wishlist_items WHERE user_id = p_user_id;
+    DELETE FROM accounts WHERE user_id = p_user_id;
+    DELETE FROM assets WHERE user_id = p_user_id;
+    DELETE FROM net_worth_snapshots WHERE user_id = p_user_id;
+    DELETE FROM webhooks WHERE user_id = p_user_id;
+    DELETE FROM reminders WHERE user_id = p_user_id;
+    DELETE FROM announcement_deliveries WHERE user_id = p_user_id;
+    DELETE FROM user_baselines WHERE user_id = p_user_id;
+    DELETE FROM user_states WHERE user_id = p_user_id;
+    DELETE FROM user_settings WHERE user_id = p_user_id;
+    DELETE FROM ledger_members WHERE member_id = p_user_id OR owner_id = p_user_id;
+    DELETE FROM ledger_invites WHERE owner_id = p_user_id;
+    DELETE FROM report_cache WHERE owner_id = p_user_id;
+    DELETE FROM users WHERE id = p_user_id;
+END;
+$$;
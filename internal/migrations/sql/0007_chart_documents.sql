@@ -0,0 +1,5 @@
+-- Графики файлами: формат доставки (сжатое фото или PNG-документ) и масштаб файлов
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS chart_format TEXT NOT NULL DEFAULT 'photo'
+    CHECK (chart_format IN ('photo', 'png'));
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS chart_scale INT NOT NULL DEFAULT 2
+    CHECK (chart_scale BETWEEN 1 AND 4);
@@ -0,0 +1,4 @@
+-- Графики файлами в SVG
+ALTER TABLE user_settings DROP CONSTRAINT IF EXISTS user_settings_chart_format_check;
+ALTER TABLE user_settings ADD CONSTRAINT user_settings_chart_format_check
+    CHECK (chart_format IN ('photo', 'png', 'svg'));
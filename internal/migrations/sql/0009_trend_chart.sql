@@ -0,0 +1,3 @@
+-- Вид графика трендов: отклонение в процентах или расходы в рублях с линией бюджета
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS trend_chart TEXT NOT NULL DEFAULT 'change'
+    CHECK (trend_chart IN ('change', 'absolute'));
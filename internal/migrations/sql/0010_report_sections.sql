@@ -0,0 +1,2 @@
+-- Конструктор отчета: включенные разделы по порядку, NULL - разделы по умолчанию
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS report_sections TEXT[];
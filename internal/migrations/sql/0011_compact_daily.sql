@@ -0,0 +1,2 @@
+-- Компактная сводка за день: по строке на показатель для экрана телефона
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS compact_daily BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,6 @@
+-- Предупреждения о бюджетах: последний порог, о котором бот сообщил, и тихие часы без сообщений
+ALTER TABLE budgets ADD COLUMN IF NOT EXISTS alerted_percent INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE budgets ADD COLUMN IF NOT EXISTS alerted_at TIMESTAMPTZ;
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS budget_alerts BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS quiet_hours_from INTEGER NOT NULL DEFAULT 23;
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS quiet_hours_to INTEGER NOT NULL DEFAULT 8;
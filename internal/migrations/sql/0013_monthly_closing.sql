@@ -0,0 +1,2 @@
+-- Итоги месяца 1-го числа: победы, провалы, бюджеты и доля сбережений
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS monthly_closing BOOLEAN NOT NULL DEFAULT TRUE;
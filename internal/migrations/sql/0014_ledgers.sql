@@ -0,0 +1,71 @@
+-- Отдельные книги учета пользователя: личная, бизнес, поездка. Данные книги хранятся в тех же
+-- таблицах под отрицательным data_id вместо ID пользователя
+CREATE SEQUENCE IF NOT EXISTS ledger_data_ids;
+
+CREATE TABLE IF NOT EXISTS ledgers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id BIGINT NOT NULL,
+    data_id BIGINT NOT NULL UNIQUE DEFAULT -nextval('ledger_data_ids'),
+    name TEXT NOT NULL,
+    emoji TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledgers_user_id ON ledgers(user_id);
+
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS active_ledger BIGINT NOT NULL DEFAULT 0;
+
+-- Данные одной книги: все, что хранится под ее data_id
+CREATE OR REPLACE FUNCTION delete_ledger_data(p_data_id BIGINT) RETURNS VOID
+LANGUAGE plpgsql AS $$
+BEGIN
+    DELETE FROM transactions WHERE user_id = p_data_id;
+    DELETE FROM budgets WHERE user_id = p_data_id;
+    DELETE FROM planned_expenses WHERE user_id = p_data_id;
+    DELETE FROM categories WHERE user_id = p_data_id;
+    DELETE FROM goals WHERE user_id = p_data_id;
+    DELETE FROM wishlist_items WHERE user_id = p_data_id;
+    DELETE FROM accounts WHERE user_id = p_data_id;
+    DELETE FROM assets WHERE user_id = p_data_id;
+    DELETE FROM net_worth_snapshots WHERE user_id = p_data_id;
+    DELETE FROM webhooks WHERE user_id = p_data_id;
+    DELETE FROM user_baselines WHERE user_id = p_data_id;
+    DELETE FROM report_cache WHERE owner_id = p_data_id;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION delete_ledger(p_id UUID, p_user_id BIGINT) RETURNS VOID
+LANGUAGE plpgsql AS $$
+BEGIN
+    PERFORM delete_ledger_data(data_id) FROM ledgers WHERE id = p_id AND user_id = p_user_id;
+    DELETE FROM ledgers WHERE id = p_id AND user_id = p_user_id;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION delete_user_data(p_user_id BIGINT) RETURNS VOID
+LANGUAGE plpgsql AS $$
+BEGIN
+    PERFORM delete_ledger_data(data_id) FROM ledgers WHERE user_id = p_user_id;
+    DELETE FROM ledgers WHERE user_id = p_user_id;
+    DELETE FROM transactions WHERE user_id = p_user_id;
+    UPDATE transactions SET author_id = NULL WHERE author_id = p_user_id;
+    DELETE FROM budgets WHERE user_id = p_user_id;
+    DELETE FROM planned_expenses WHERE user_id = p_user_id;
+    DELETE FROM categories WHERE user_id = p_user_id;
+    DELETE FROM goals WHERE user_id = p_user_id;
+    DELETE FROM wishlist_items WHERE user_id = p_user_id;
+    DELETE FROM accounts WHERE user_id = p_user_id;
+    DELETE FROM assets WHERE user_id = p_user_id;
+    DELETE FROM net_worth_snapshots WHERE user_id = p_user_id;
+    DELETE FROM webhooks WHERE user_id = p_user_id;
+    DELETE FROM reminders WHERE user_id = p_user_id;
+    DELETE FROM announcement_deliveries WHERE user_id = p_user_id;
+    DELETE FROM user_baselines WHERE user_id = p_user_id;
+    DELETE FROM user_states WHERE user_id = p_user_id;
+    DELETE FROM user_settings WHERE user_id = p_user_id;
+    DELETE FROM ledger_members WHERE member_id = p_user_id OR owner_id = p_user_id;
+    DELETE FROM ledger_invites WHERE owner_id = p_user_id;
+    DELETE FROM report_cache WHERE owner_id = p_user_id;
+    DELETE FROM users WHERE id = p_user_id;
+END;
+$$;
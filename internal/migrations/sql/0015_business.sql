@@ -0,0 +1,6 @@
+-- Бизнес-режим книги учета: контрагент, номер счета и НДС в транзакциях
+ALTER TABLE ledgers ADD COLUMN IF NOT EXISTS business BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS counterparty TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS invoice_number TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS vat_amount DECIMAL NOT NULL DEFAULT 0;
@@ -0,0 +1,56 @@
+-- Общие события: участники по ссылке-приглашению и их платежи. Траты владельца - это
+-- транзакции события, платежи остальных участников хранятся отдельно
+ALTER TABLE events ADD COLUMN IF NOT EXISTS invite_code TEXT UNIQUE;
+
+CREATE TABLE IF NOT EXISTS event_members (
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    owner BOOLEAN NOT NULL DEFAULT FALSE,
+    joined_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (event_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_members_user_id ON event_members(user_id);
+
+CREATE TABLE IF NOT EXISTS event_payments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    amount DECIMAL NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_payments_event_id ON event_payments(event_id);
+
+CREATE OR REPLACE FUNCTION delete_user_data(p_user_id BIGINT) RETURNS VOID
+LANGUAGE plpgsql AS $$
+BEGIN
+    PERFORM delete_ledger_data(data_id) FROM ledgers WHERE user_id = p_user_id;
+    DELETE FROM ledgers WHERE user_id = p_user_id;
+    DELETE FROM transactions WHERE user_id = p_user_id;
+    UPDATE transactions SET author_id = NULL WHERE author_id = p_user_id;
+    DELETE FROM events WHERE user_id = p_user_id;
+    DELETE FROM event_members WHERE user_id = p_user_id;
+    DELETE FROM event_payments WHERE user_id = p_user_id;
+    DELETE FROM budgets WHERE user_id = p_user_id;
+    DELETE FROM planned_expenses WHERE user_id = p_user_id;
+    DELETE FROM categories WHERE user_id = p_user_id;
+    DELETE FROM goals WHERE user_id = p_user_id;
+    DELETE FROM wishlist_items WHERE user_id = p_user_id;
+    DELETE FROM accounts WHERE user_id = p_user_id;
+    DELETE FROM assets WHERE user_id = p_user_id;
+    DELETE FROM net_worth_snapshots WHERE user_id = p_user_id;
+    DELETE FROM webhooks WHERE user_id = p_user_id;
+    DELETE FROM reminders WHERE user_id = p_user_id;
+    DELETE FROM announcement_deliveries WHERE user_id = p_user_id;
+    DELETE FROM user_baselines WHERE user_id = p_user_id;
+    DELETE FROM user_states WHERE user_id = p_user_id;
+    DELETE FROM user_settings WHERE user_id = p_user_id;
+    DELETE FROM ledger_members WHERE member_id = p_user_id OR owner_id = p_user_id;
+    DELETE FROM ledger_invites WHERE owner_id = p_user_id;
+    DELETE FROM report_cache WHERE owner_id = p_user_id;
+    DELETE FROM users WHERE id = p_user_id;
+END;
+$$;
@@ -0,0 +1,14 @@
+-- Курсы валют по дням. Курс за день загружается у источника один раз и дальше
+-- читается из таблицы: прошлые транзакции пересчитываются по курсу своей даты
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    date DATE NOT NULL,
+    base TEXT NOT NULL,
+    currency TEXT NOT NULL,
+    rate DECIMAL NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (date, base, currency)
+);
+
+-- Сумма в валюте покупки; amount остается в валюте учета
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS currency TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS original_amount DECIMAL;
@@ -0,0 +1,67 @@
+-- Инвестиции: акции и криптовалюта по тикерам. Стоимость считается по котировкам,
+-- последняя полученная цена хранится на случай недоступности источника котировок
+CREATE TABLE IF NOT EXISTS holdings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id BIGINT NOT NULL,
+    kind TEXT NOT NULL,
+    ticker TEXT NOT NULL,
+    quantity DECIMAL NOT NULL,
+    price DECIMAL NOT NULL DEFAULT 0,
+    priced_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (user_id, kind, ticker)
+);
+
+ALTER TABLE net_worth_snapshots ADD COLUMN IF NOT EXISTS holdings DECIMAL NOT NULL DEFAULT 0;
+
+CREATE OR REPLACE FUNCTION delete_ledger_data(p_data_id BIGINT) RETURNS VOID
+LANGUAGE plpgsql AS $$
+BEGIN
+    DELETE FROM transactions WHERE user_id = p_data_id;
+    DELETE FROM events WHERE user_id = p_data_id;
+    DELETE FROM budgets WHERE user_id = p_data_id;
+    DELETE FROM planned_expenses WHERE user_id = p_data_id;
+    DELETE FROM categories WHERE user_id = p_data_id;
+    DELETE FROM goals WHERE user_id = p_data_id;
+    DELETE FROM wishlist_items WHERE user_id = p_data_id;
+    DELETE FROM accounts WHERE user_id = p_data_id;
+    DELETE FROM assets WHERE user_id = p_data_id;
+    DELETE FROM holdings WHERE user_id = p_data_id;
+    DELETE FROM net_worth_snapshots WHERE user_id = p_data_id;
+    DELETE FROM webhooks WHERE user_id = p_data_id;
+    DELETE FROM user_baselines WHERE user_id = p_data_id;
+    DELETE FROM report_cache WHERE owner_id = p_data_id;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION delete_user_data(p_user_id BIGINT) RETURNS VOID
+LANGUAGE plpgsql AS $$
+BEGIN
+    PERFORM delete_ledger_data(data_id) FROM ledgers WHERE user_id = p_user_id;
+    DELETE FROM ledgers WHERE user_id = p_user_id;
+    DELETE FROM transactions WHERE user_id = p_user_id;
+    UPDATE transactions SET author_id = NULL WHERE author_id = p_user_id;
+    DELETE FROM events WHERE user_id = p_user_id;
+    DELETE FROM event_members WHERE user_id = p_user_id;
+    DELETE FROM event_payments WHERE user_id = p_user_id;
+    DELETE FROM budgets WHERE user_id = p_user_id;
+    DELETE FROM planned_expenses WHERE user_id = p_user_id;
+    DELETE FROM categories WHERE user_id = p_user_id;
+    DELETE FROM goals WHERE user_id = p_user_id;
+    DELETE FROM wishlist_items WHERE user_id = p_user_id;
+    DELETE FROM accounts WHERE user_id = p_user_id;
+    DELETE FROM assets WHERE user_id = p_user_id;
+    DELETE FROM holdings WHERE user_id = p_user_id;
+    DELETE FROM net_worth_snapshots WHERE user_id = p_user_id;
+    DELETE FROM webhooks WHERE user_id = p_user_id;
+    DELETE FROM reminders WHERE user_id = p_user_id;
+    DELETE FROM announcement_deliveries WHERE user_id = p_user_id;
+    DELETE FROM user_baselines WHERE user_id = p_user_id;
+    DELETE FROM user_states WHERE user_id = p_user_id;
+    DELETE FROM user_settings WHERE user_id = p_user_id;
+    DELETE FROM ledger_members WHERE member_id = p_user_id OR owner_id = p_user_id;
+    DELETE FROM ledger_invites WHERE owner_id = p_user_id;
+    DELETE FROM report_cache WHERE owner_id = p_user_id;
+    DELETE FROM users WHERE id = p_user_id;
+END;
+$$;
@@ -0,0 +1,2 @@
+-- Часовой пояс пользователя из базы IANA, пусто - пояс сервера
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT '';
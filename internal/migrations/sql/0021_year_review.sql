@@ -0,0 +1,4 @@
+-- Итоги года 1 января. Раньше они приходили вместе с итогами месяца, поэтому
+-- у отключивших итоги месяца итоги года тоже остаются выключенными
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS year_review BOOLEAN NOT NULL DEFAULT TRUE;
+UPDATE user_settings SET year_review = monthly_closing;
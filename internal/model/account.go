@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// Типы счетов
+const (
+	AccountTypeCash    = "cash"    // наличные
+	AccountTypeCard    = "card"    // банковская карта
+	AccountTypeDeposit = "deposit" // вклад
+)
+
+// Account представляет счет (кошелек) пользователя
+type Account struct {
+	ID             string    `json:"id,omitempty"`
+	UserID         int64     `json:"user_id"`
+	Name           string    `json:"name"`
+	Type           string    `json:"type"`
+	InitialBalance float64   `json:"initial_balance"` // остаток на момент создания счета
+	CreatedAt      time.Time `json:"created_at,omitempty"`
+}
+
+// AccountBalance содержит текущий остаток по счету
+type AccountBalance struct {
+	Account Account
+	Balance float64
+}
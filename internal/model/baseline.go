@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// UserBaseline содержит предрасчитанную статистику типичных трат пользователя
+type UserBaseline struct {
+	UserID          int64              `json:"user_id"`
+	DailyMedian     float64            `json:"daily_median"`     // медиана расходов за день (по дням с тратами)
+	DailyP90        float64            `json:"daily_p90"`        // 90-й перцентиль расходов за день
+	CategoryMedians map[string]float64 `json:"category_medians"` // медиана суммы транзакции по категориям
+	ComputedAt      time.Time          `json:"computed_at"`
+}
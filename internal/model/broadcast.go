@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// Статусы рассылки
+const (
+	BroadcastDraft    = "draft"    // составлена, получатели не выбраны
+	BroadcastQueued   = "queued"   // подтверждена и отправляется
+	BroadcastDone     = "done"     // отправлена всем получателям
+	BroadcastCanceled = "canceled" // отменена до отправки
+)
+
+// Сегменты получателей рассылки
+const (
+	SegmentAll      = "all"      // все пользователи
+	SegmentActive   = "active"   // были активны за последние 30 дней
+	SegmentInactive = "inactive" // не заходили больше 30 дней
+	SegmentNew      = "new"      // зарегистрировались за последние 7 дней
+	SegmentRussian  = "ru"       // язык Telegram - русский
+	SegmentOther    = "other"    // язык Telegram - не русский
+)
+
+// Broadcast - объявление администратора (новости версии, совет), рассылаемое пользователям
+// из таблицы announcements
+type Broadcast struct {
+	ID         string     `json:"id"`
+	Text       string     `json:"text"`
+	Segment    string     `json:"segment"`
+	Status     string     `json:"status"`
+	CreatedBy  int64      `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	QueuedAt   *time.Time `json:"queued_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// BroadcastDelivery - отправка рассылки одному пользователю. Запись создается и при ошибке
+// (например, пользователь заблокировал бота), чтобы не отправлять повторно
+type BroadcastDelivery struct {
+	BroadcastID string    `json:"announcement_id"`
+	UserID      int64     `json:"user_id"`
+	Error       string    `json:"error,omitempty"`
+	DeliveredAt time.Time `json:"delivered_at"`
+}
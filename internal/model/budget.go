@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// Budget представляет месячный лимит расходов по категории
+type Budget struct {
+	ID         string    `json:"id,omitempty"`
+	UserID     int64     `json:"user_id"`
+	CategoryID string    `json:"category_id"`
+	Amount     float64   `json:"amount"` // лимит на месяц, положительное число
+	CreatedAt  time.Time `json:"created_at,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at,omitempty"`
+	// RolloverSince - первый месяц, остаток которого переносится на следующий. nil - без переноса
+	RolloverSince *time.Time `json:"rollover_since,omitempty"`
+	// AlertedPercent - порог исполнения в процентах, о котором бот уже предупредил в месяце AlertedAt
+	AlertedPercent int        `json:"alerted_percent"`
+	AlertedAt      *time.Time `json:"alerted_at,omitempty"`
+}
+
+// Rollover сообщает, включен ли перенос остатка
+func (b Budget) Rollover() bool {
+	return b.RolloverSince != nil
+}
+
+// AlertedIn возвращает порог, о котором бот предупредил в месяце month. 0 - предупреждений не было
+func (b Budget) AlertedIn(month time.Time) int {
+	if b.AlertedAt == nil {
+		return 0
+	}
+	at := b.AlertedAt.In(month.Location())
+	if at.Year() != month.Year() || at.Month() != month.Month() {
+		return 0
+	}
+	return b.AlertedPercent
+}
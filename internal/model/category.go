@@ -1,11 +1,130 @@
 package model
 
-import "time"
+import (
+    "encoding/hex"
+    "sort"
+    "time"
+    "unicode"
+)
 
 type Category struct {
     ID          string    `json:"id,omitempty"`
     UserID      int64     `json:"user_id"`
     Name        string    `json:"name"`
     Type        string    `json:"type"` // expense или income
+    ParentID    string    `json:"parent_id,omitempty"` // родительская категория, пусто - категория верхнего уровня
+    Archived    bool      `json:"archived"` // категория в архиве: не предлагается для новых транзакций
+    SortOrder   int       `json:"sort_order"` // место в списке, заданное пользователем
+    SpendingLimit float64 `json:"spending_limit"` // лимит трат за календарный месяц, 0 - без лимита
+    Emoji       string    `json:"emoji,omitempty"` // значок, по умолчанию 💸 или 💰 по типу
+    Color       string    `json:"color,omitempty"` // цвет на графиках, #rrggbb
     CreatedAt   time.Time `json:"created_at,omitempty"`
-} 
\ No newline at end of file
+    DeletedAt   *time.Time `json:"deleted_at,omitempty"` // время переноса в корзину, nil - категория не удалена
+} 
+
+// Icon возвращает значок категории: выбранный пользователем или значок типа
+func (c Category) Icon() string {
+    if c.Emoji != "" {
+        return c.Emoji
+    }
+    return TypeIcon(c.Type)
+}
+
+// SortCategories упорядочивает категории по месту в списке, затем по времени создания
+func SortCategories(categories []Category) {
+    sort.SliceStable(categories, func(i, j int) bool {
+        if categories[i].SortOrder != categories[j].SortOrder {
+            return categories[i].SortOrder < categories[j].SortOrder
+        }
+        return categories[i].CreatedAt.Before(categories[j].CreatedAt)
+    })
+}
+
+// ActiveCategories возвращает категории не из архива
+func ActiveCategories(categories []Category) []Category {
+    var result []Category
+    for _, c := range categories {
+        if !c.Archived {
+            result = append(result, c)
+        }
+    }
+    return result
+}
+
+// TopLevelCategories возвращает категории верхнего уровня. Подкатегория, родитель которой
+// не найден в списке, тоже считается категорией верхнего уровня
+func TopLevelCategories(categories []Category) []Category {
+    ids := make(map[string]bool, len(categories))
+    for _, c := range categories {
+        ids[c.ID] = true
+    }
+    var result []Category
+    for _, c := range categories {
+        if c.ParentID == "" || !ids[c.ParentID] {
+            result = append(result, c)
+        }
+    }
+    return result
+}
+
+// Subcategories возвращает подкатегории категории parentID
+func Subcategories(categories []Category, parentID string) []Category {
+    var result []Category
+    for _, c := range categories {
+        if c.ParentID != "" && c.ParentID == parentID {
+            result = append(result, c)
+        }
+    }
+    return result
+}
+
+// TypeIcon возвращает значок доходов или расходов
+func TypeIcon(categoryType string) string {
+    if categoryType == "income" {
+        return "💰"
+    }
+    return "💸"
+}
+
+// CategoryColor - цвет категории из палитры
+type CategoryColor struct {
+    Mark string // кружок этого цвета для кнопок
+    Hex  string
+}
+
+// CategoryColors - палитра цветов категорий
+var CategoryColors = []CategoryColor{
+    {"🔴", "#e53935"},
+    {"🟠", "#fb8c00"},
+    {"🟡", "#fdd835"},
+    {"🟢", "#43a047"},
+    {"🔵", "#1e88e5"},
+    {"🟣", "#8e24aa"},
+    {"🟤", "#6d4c41"},
+    {"⚫", "#424242"},
+}
+
+// ValidCategoryEmoji проверяет, похож ли текст на один значок: короткий, с символом-пиктограммой
+// и без букв и цифр
+func ValidCategoryEmoji(emoji string) bool {
+    if emoji == "" || len(emoji) > 16 {
+        return false
+    }
+    symbol := false
+    for _, r := range emoji {
+        if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) {
+            return false
+        }
+        symbol = symbol || unicode.Is(unicode.So, r)
+    }
+    return symbol
+}
+
+// ValidCategoryColor проверяет цвет в формате #rrggbb
+func ValidCategoryColor(color string) bool {
+    if len(color) != 7 || color[0] != '#' {
+        return false
+    }
+    _, err := hex.DecodeString(color[1:])
+    return err == nil
+}
@@ -0,0 +1,16 @@
+package model
+
+import "errors"
+
+// Виды ошибок, по которым бот выбирает сообщение пользователю. Репозиторий и сервис
+// оборачивают в них конкретные ошибки, проверяются они через errors.Is
+var (
+	// ErrNotFound - запись не найдена или уже удалена
+	ErrNotFound = errors.New("not found")
+	// ErrValidation - данные не прошли проверку сервиса или ограничения базы
+	ErrValidation = errors.New("validation failed")
+	// ErrRateLimited - слишком много запросов, повторить можно позже
+	ErrRateLimited = errors.New("rate limited")
+	// ErrStorageUnavailable - база недоступна или не ответила вовремя, повторить можно позже
+	ErrStorageUnavailable = errors.New("storage unavailable")
+)
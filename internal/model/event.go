@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+// Event - временное событие вроде отпуска или ремонта. Пока событие открыто, новые траты
+// привязываются к нему, оставаясь в своих категориях, а после закрытия событие дает отчет
+// о полной стоимости
+type Event struct {
+	ID        string     `json:"id,omitempty"`
+	UserID    int64      `json:"user_id"`
+	Name      string     `json:"name"`
+	Emoji     string     `json:"emoji"`
+	StartedAt time.Time  `json:"started_at"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`   // nil - событие еще идет
+	Invite    string     `json:"invite_code,omitempty"` // код ссылки для участников общего события
+	CreatedAt time.Time  `json:"created_at,omitempty"`
+}
+
+// Title возвращает название события со значком
+func (e Event) Title() string {
+	if e.Emoji == "" {
+		return e.Name
+	}
+	return e.Emoji + " " + e.Name
+}
+
+// IsOpen сообщает, что событие еще идет и к нему привязываются новые траты
+func (e Event) IsOpen() bool {
+	return e.ClosedAt == nil
+}
+
+// EventMember - участник общего события. Владелец события тоже хранится как участник
+// с Owner: его платежи - транзакции события, платежи остальных - EventPayment
+type EventMember struct {
+	EventID  string    `json:"event_id"`
+	UserID   int64     `json:"user_id"`
+	Name     string    `json:"name"`
+	Owner    bool      `json:"owner"`
+	JoinedAt time.Time `json:"joined_at,omitempty"`
+}
+
+// EventPayment - платеж участника общего события за всех
+type EventPayment struct {
+	ID          string    `json:"id,omitempty"`
+	EventID     string    `json:"event_id"`
+	UserID      int64     `json:"user_id"`
+	Amount      float64   `json:"amount"` // положительное число
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// ExchangeRate - курс валюты за день: сколько единиц валюты учета Base стоит единица Currency
+type ExchangeRate struct {
+	Date      string    `json:"date"` // день курса, YYYY-MM-DD
+	Base      string    `json:"base"`
+	Currency  string    `json:"currency"`
+	Rate      float64   `json:"rate"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
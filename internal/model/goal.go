@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// Goal представляет финансовую цель пользователя (накопление на покупку)
+type Goal struct {
+	ID           string    `json:"id,omitempty"`
+	UserID       int64     `json:"user_id"`
+	Name         string    `json:"name"`
+	TargetAmount float64   `json:"target_amount"`
+	SavedAmount  float64   `json:"saved_amount"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+}
+
+// Remaining возвращает сумму, которую осталось накопить
+func (g Goal) Remaining() float64 {
+	if g.SavedAmount >= g.TargetAmount {
+		return 0
+	}
+	return g.TargetAmount - g.SavedAmount
+}
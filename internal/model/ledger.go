@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// LedgerMember - участник общего бюджета. Владелец бюджета тоже хранится как участник
+// с MemberID == OwnerID
+type LedgerMember struct {
+	MemberID int64     `json:"member_id"`
+	OwnerID  int64     `json:"owner_id"`
+	Name     string    `json:"name"`
+	JoinedAt time.Time `json:"joined_at,omitempty"`
+}
+
+// IsOwner сообщает, является ли участник владельцем бюджета
+func (m LedgerMember) IsOwner() bool {
+	return m.MemberID == m.OwnerID
+}
+
+// LedgerInvite - одноразовое приглашение в общий бюджет
+type LedgerInvite struct {
+	Code      string    `json:"code"`
+	OwnerID   int64     `json:"owner_id"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// Ledger - отдельная книга учета пользователя, например «Бизнес» или «Поездка». У каждой книги
+// свои транзакции, категории, счета и бюджеты: они хранятся под DataID вместо ID пользователя.
+// Основная книга - данные самого пользователя, записи в таблице книг у нее нет
+type Ledger struct {
+	ID        string    `json:"id,omitempty"`
+	UserID    int64     `json:"user_id"`
+	DataID    int64     `json:"data_id,omitempty"` // отрицательный, чтобы не совпасть с ID пользователей; назначается базой
+	Name      string    `json:"name"`
+	Emoji     string    `json:"emoji"`
+	Business  bool      `json:"business"` // бизнес-режим: контрагент, номер счета и НДС в транзакциях
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// Title возвращает название книги со значком
+func (l Ledger) Title() string {
+	if l.Emoji == "" {
+		return l.Name
+	}
+	return l.Emoji + " " + l.Name
+}
@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// Виды ручных записей капитала
+const (
+	AssetKindAsset     = "asset"     // актив: недвижимость, автомобиль, долг вам
+	AssetKindLiability = "liability" // обязательство: кредит, ипотека, долг
+)
+
+// Asset - актив или обязательство, не являющееся счетом
+type Asset struct {
+	ID        string    `json:"id,omitempty"`
+	UserID    int64     `json:"user_id"`
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	Amount    float64   `json:"amount"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// Виды инвестиций, у каждого свой источник котировок
+const (
+	HoldingKindStock  = "stock"  // акции и фонды Московской биржи
+	HoldingKindCrypto = "crypto" // криптовалюта
+)
+
+// Holding - инвестиция: количество бумаг или монет по тикеру. Стоимость считается по котировке
+type Holding struct {
+	ID        string     `json:"id,omitempty"`
+	UserID    int64      `json:"user_id"`
+	Kind      string     `json:"kind"`
+	Ticker    string     `json:"ticker"`
+	Quantity  float64    `json:"quantity"`
+	Price     float64    `json:"price"`               // последняя цена в валюте учета
+	PricedAt  *time.Time `json:"priced_at,omitempty"` // время получения Price, nil - котировки еще не было
+	CreatedAt time.Time  `json:"created_at,omitempty"`
+}
+
+// Quote - котировка инструмента в валюте торгов
+type Quote struct {
+	Price    float64
+	Currency string // код ISO 4217
+}
+
+// NetWorthSnapshot - состояние капитала на конец месяца
+type NetWorthSnapshot struct {
+	UserID      int64     `json:"user_id"`
+	Month       string    `json:"month"` // первый день месяца, YYYY-MM-DD
+	Accounts    float64   `json:"accounts"`
+	Assets      float64   `json:"assets"`
+	Holdings    float64   `json:"holdings"` // инвестиции по котировкам
+	Liabilities float64   `json:"liabilities"`
+	NetWorth    float64   `json:"net_worth"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+}
+
+// MonthTime возвращает месяц снимка как время
+func (s NetWorthSnapshot) MonthTime() time.Time {
+	t, err := time.Parse("2006-01-02", s.Month)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// PlannedExpense - крупная трата, запланированная на месяц
+type PlannedExpense struct {
+	ID          string    `json:"id,omitempty"`
+	UserID      int64     `json:"user_id"`
+	CategoryID  string    `json:"category_id"`
+	Description string    `json:"description"`
+	Amount      float64   `json:"amount"` // положительное число
+	Month       time.Time `json:"month"`  // начало месяца
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+}
@@ -0,0 +1,9 @@
+package model
+
+import "time"
+
+// ProcessedUpdate - обновление Telegram, уже обработанное ботом
+type ProcessedUpdate struct {
+	UpdateID    int64     `json:"update_id"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
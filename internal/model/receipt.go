@@ -0,0 +1,45 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReceiptOperation - признак расчета из QR-кода чека (параметр n)
+type ReceiptOperation int
+
+const (
+	ReceiptSale         ReceiptOperation = 1 // приход: покупатель заплатил продавцу
+	ReceiptSaleRefund   ReceiptOperation = 2 // возврат прихода: продавец вернул деньги за покупку
+	ReceiptPayout       ReceiptOperation = 3 // расход: продавец выплатил деньги покупателю
+	ReceiptPayoutRefund ReceiptOperation = 4 // возврат расхода: покупатель вернул выплату
+)
+
+// Receipt представляет кассовый чек, полученный по QR-коду ФНС
+type Receipt struct {
+	Seller    string           `json:"seller"`
+	DateTime  time.Time        `json:"date_time"`
+	Total     float64          `json:"total"`
+	Operation ReceiptOperation `json:"operation,omitempty"` // 0 у чеков, сохраненных до появления поля, - приход
+	Items     []ReceiptItem    `json:"items"`
+}
+
+// ReceiptItem представляет позицию чека
+type ReceiptItem struct {
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+	Sum      float64 `json:"sum"`
+}
+
+// TransactionType возвращает тип транзакции для чека с точки зрения покупателя:
+// покупка и возврат выплаты - расход, возврат покупки и выплата - доход
+func (r Receipt) TransactionType() (string, error) {
+	switch r.Operation {
+	case 0, ReceiptSale, ReceiptPayoutRefund:
+		return TransactionExpense, nil
+	case ReceiptSaleRefund, ReceiptPayout:
+		return TransactionIncome, nil
+	}
+	return "", fmt.Errorf("%w: unknown receipt operation %d", ErrValidation, r.Operation)
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Расписания напоминаний
+const (
+	ReminderDaily   = "daily"   // каждый день
+	ReminderWeekly  = "weekly"  // раз в неделю, день недели в Day (time.Weekday)
+	ReminderMonthly = "monthly" // раз в месяц, число в Day; в коротком месяце - в последний день
+)
+
+// ReminderWindow - сколько времени после назначенного момента напоминание еще отправляется.
+// Обработчик вызывается по расписанию, поэтому напоминание уходит при первом вызове после своего времени
+const ReminderWindow = time.Hour
+
+// Reminder - напоминание пользователя по расписанию: "каждый день в 21:00 записать траты"
+type Reminder struct {
+	ID         string     `json:"id,omitempty"`
+	UserID     int64      `json:"user_id"`
+	Text       string     `json:"text"`
+	Schedule   string     `json:"schedule"`
+	Day        int        `json:"day"`
+	Hour       int        `json:"hour"`
+	Minute     int        `json:"minute"`
+	LastSentAt *time.Time `json:"last_sent_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at,omitempty"`
+}
+
+// weekdaysAccusative - дни недели для описания расписания: "каждый понедельник"
+var weekdaysAccusative = [...]string{"каждое воскресенье", "каждый понедельник", "каждый вторник",
+	"каждую среду", "каждый четверг", "каждую пятницу", "каждую субботу"}
+
+// ScheduledAt возвращает время напоминания в день now; false - в этот день напоминания нет
+func (r Reminder) ScheduledAt(now time.Time) (time.Time, bool) {
+	switch r.Schedule {
+	case ReminderDaily:
+	case ReminderWeekly:
+		if now.Weekday() != time.Weekday(r.Day) {
+			return time.Time{}, false
+		}
+	case ReminderMonthly:
+		day := r.Day
+		if last := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location()).Day(); day > last {
+			day = last
+		}
+		if now.Day() != day {
+			return time.Time{}, false
+		}
+	default:
+		return time.Time{}, false
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), r.Hour, r.Minute, 0, 0, now.Location()), true
+}
+
+// Due сообщает, пора ли отправить напоминание: его время наступило не раньше ReminderWindow
+// назад, и после этого времени оно еще не отправлялось
+func (r Reminder) Due(now time.Time) bool {
+	at, ok := r.ScheduledAt(now)
+	if !ok || now.Before(at) || now.Sub(at) >= ReminderWindow {
+		return false
+	}
+	return r.LastSentAt == nil || r.LastSentAt.Before(at)
+}
+
+// Describe описывает расписание: "каждый день в 21:00", "15 числа в 10:00"
+func (r Reminder) Describe() string {
+	at := fmt.Sprintf("в %02d:%02d", r.Hour, r.Minute)
+	switch r.Schedule {
+	case ReminderWeekly:
+		return weekdaysAccusative[time.Weekday(r.Day)%7] + " " + at
+	case ReminderMonthly:
+		return fmt.Sprintf("%d числа %s", r.Day, at)
+	default:
+		return "каждый день " + at
+	}
+}
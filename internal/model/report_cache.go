@@ -0,0 +1,14 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CachedReport - готовый отчет, сохраненный для повторных запросов того же периода
+type CachedReport struct {
+	OwnerID   int64           `json:"owner_id"` // владелец бюджета, по данным которого построен отчет
+	Key       string          `json:"key"`      // тип отчета, язык, часовой пояс и начало периода
+	Report    json.RawMessage `json:"report"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
@@ -0,0 +1,8 @@
+package model
+
+// ReportData - данные для построения отчета: категории и транзакции за период и предыдущий период
+type ReportData struct {
+	Categories []Category
+	Current    []Transaction
+	Previous   []Transaction
+}
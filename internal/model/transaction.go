@@ -1,18 +1,41 @@
 package model
 
 import (
+	"math"
 	"time"
 	"github.com/google/uuid"
 )
 
+// Направление транзакции, совпадает с типом категории
+const (
+	TransactionExpense = "expense"
+	TransactionIncome  = "income"
+)
+
 type Transaction struct {
 	ID          string    `json:"id"`
 	UserID      int64     `json:"user_id"`
-	CategoryID  string    `json:"category_id"`
-	Amount      float64   `json:"amount"`
+	CategoryID  string    `json:"category_id,omitempty"`
+	AccountID   string    `json:"account_id,omitempty"`
+	AuthorID    int64     `json:"author_id,omitempty"` // участник общего бюджета, добавивший транзакцию
+	ParentID    string    `json:"parent_id,omitempty"` // платеж, частью которого является транзакция
+	IsSplit     bool      `json:"is_split,omitempty"`  // платеж разделен на дочерние транзакции по категориям
+	Type        string    `json:"type"`                // expense или income
+	Amount      float64   `json:"amount"`              // расход хранится отрицательным
 	Description string    `json:"description"`
+	Note        string    `json:"note,omitempty"`            // подробная заметка к транзакции
+	AttachmentPath string `json:"attachment_path,omitempty"` // путь фото чека в хранилище файлов
+	EventID     string    `json:"event_id,omitempty"` // событие (отпуск, ремонт), к которому относится трата
+	// Реквизиты бизнес-режима книги учета
+	Counterparty  string  `json:"counterparty,omitempty"`   // покупатель или поставщик
+	InvoiceNumber string  `json:"invoice_number,omitempty"` // номер счета или акта
+	VATAmount     float64 `json:"vat_amount,omitempty"`     // НДС в сумме транзакции
+	// Покупка в другой валюте: Amount пересчитан в валюту учета по курсу на дату транзакции
+	Currency       string  `json:"currency,omitempty"`        // код валюты ISO 4217
+	OriginalAmount float64 `json:"original_amount,omitempty"` // сумма в Currency, знак как у Amount
 	Date        time.Time `json:"date"`
 	CreatedAt   time.Time `json:"created_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"` // время переноса в корзину, nil - транзакция не удалена
 }
 
 // GenerateID генерирует новый UUID для транзакции, если он еще не установлен
@@ -22,11 +45,47 @@ func (t *Transaction) GenerateID() {
 	}
 }
 
-// TransactionFilter представляет фильтр для транзакций
+// IsIncome сообщает, что транзакция - доход. У записей без типа направление определяется знаком суммы
+func (t Transaction) IsIncome() bool {
+	if t.Type != "" {
+		return t.Type == TransactionIncome
+	}
+	return t.Amount > 0
+}
+
+// AbsAmount возвращает сумму без знака
+func (t Transaction) AbsAmount() float64 {
+	return math.Abs(t.Amount)
+}
+
+// SignedAmount возвращает сумму со знаком по типу: доход положительный, расход отрицательный
+func (t Transaction) SignedAmount() float64 {
+	if t.IsIncome() {
+		return t.AbsAmount()
+	}
+	return -t.AbsAmount()
+}
+
+// SetType задает направление транзакции и приводит к нему знак суммы
+func (t *Transaction) SetType(transactionType string) {
+	t.Type = transactionType
+	t.Amount = t.SignedAmount()
+}
+
+// TransactionFilter представляет фильтр для транзакций. Пустые поля не ограничивают выборку
 type TransactionFilter struct {
-	StartDate *time.Time
-	EndDate   *time.Time
-	Limit     int
+	StartDate   *time.Time
+	EndDate     *time.Time
+	Type        string   // тип транзакции: "expense" или "income"
+	CategoryIDs []string // любая из категорий
+	MinAmount   *float64 // границы суммы без учета знака: расход 700₽ входит в 500-1000
+	MaxAmount   *float64
+	Search      string // подстрока описания без учета регистра
+	EventID     string // транзакции события
+	Limit       int
+	// Logical возвращает покупки целиком: разделенные платежи без их частей.
+	// По умолчанию возвращаются части, чтобы суммы по категориям были точными
+	Logical bool
 }
 
 // TransactionInfo содержит информацию о транзакции
@@ -41,11 +100,14 @@ type TransactionInfo struct {
 type CategoryStats struct {
 	CategoryID  string
 	Name       string
+	Emoji      string // значок категории
+	Color      string // цвет категории на графиках, пусто - цвет по умолчанию
 	Amount     float64
 	Count      int
 	AvgAmount  float64
 	Share      float64
 	TrendPercent float64
+	Subcategories []CategoryStats // подкатегории с транзакциями; их суммы уже учтены в категории
 }
 
 // CategoryChange представляет изменение в категории
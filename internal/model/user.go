@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// User - пользователь бота. Регистрируется при /start, last_seen обновляется при активности
+type User struct {
+	ID           int64     `json:"id"`
+	Username     string    `json:"username"`
+	FirstName    string    `json:"first_name"`
+	LanguageCode string    `json:"language_code"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastSeen     time.Time `json:"last_seen"`
+	MenuSession  int       `json:"menu_session"` // растет при каждом /start, кнопки прошлых сессий устаревают
+}
@@ -0,0 +1,263 @@
+package model
+
+import (
+	"time"
+	// База часовых поясов встроена в бинарник: в окружении облачной функции ее может не быть
+	_ "time/tzdata"
+)
+
+const (
+	// DefaultDeliveryHour - час отправки ежедневной сводки по умолчанию
+	DefaultDeliveryHour = 21
+	// DefaultReminderHour - час напоминания о записи расходов по умолчанию
+	DefaultReminderHour = 20
+	// DefaultQuietHoursFrom и DefaultQuietHoursTo - тихие часы по умолчанию: с 23:00 до 8:00
+	DefaultQuietHoursFrom = 23
+	DefaultQuietHoursTo   = 8
+)
+
+// NotificationSettings - настройки уведомлений пользователя
+type NotificationSettings struct {
+	DailyReport   bool  `json:"daily_report"`   // ежедневная сводка
+	WeeklyDigest  bool  `json:"weekly_digest"`  // еженедельный отчет
+	MonthlyDigest bool  `json:"monthly_digest"` // ежемесячный отчет
+	DeliveryHour  int   `json:"delivery_hour"`  // час отправки ежедневной сводки
+	QuietDays     []int `json:"quiet_days"`     // дни недели без ежедневных уведомлений (time.Weekday)
+	// SkipEmptyDays отключает сводку за день без транзакций; иначе приходит короткое сообщение
+	SkipEmptyDays bool `json:"skip_empty_days"`
+	// Reminder включает вечернее напоминание, если за день не записано ни одной транзакции
+	Reminder     bool `json:"reminder"`
+	ReminderHour int  `json:"reminder_hour"`
+	// MonthlyClosing включает итоги прошедшего месяца 1-го числа
+	MonthlyClosing bool `json:"monthly_closing"`
+	// YearReview включает итоги прошедшего года с графиками 1 января
+	YearReview bool `json:"year_review"`
+	// BudgetAlerts включает сообщения о бюджетах, исполнение которых перешло порог
+	BudgetAlerts bool `json:"budget_alerts"`
+	// QuietHoursFrom и QuietHoursTo - часы без сообщений о бюджетах, с From до To.
+	// Одинаковые значения - тихих часов нет
+	QuietHoursFrom int `json:"quiet_hours_from"`
+	QuietHoursTo   int `json:"quiet_hours_to"`
+}
+
+// IsQuietDay сообщает, отключены ли ежедневные уведомления в этот день недели
+func (n NotificationSettings) IsQuietDay(day time.Weekday) bool {
+	for _, d := range n.QuietDays {
+		if time.Weekday(d) == day {
+			return true
+		}
+	}
+	return false
+}
+
+// IsQuietHour сообщает, попадает ли час в тихие часы. Тихие часы могут переходить через полночь
+func (n NotificationSettings) IsQuietHour(hour int) bool {
+	switch {
+	case n.QuietHoursFrom == n.QuietHoursTo:
+		return false
+	case n.QuietHoursFrom < n.QuietHoursTo:
+		return hour >= n.QuietHoursFrom && hour < n.QuietHoursTo
+	}
+	return hour >= n.QuietHoursFrom || hour < n.QuietHoursTo
+}
+
+// ToggleQuietDay включает или выключает тихий день
+func (n *NotificationSettings) ToggleQuietDay(day time.Weekday) {
+	for i, d := range n.QuietDays {
+		if time.Weekday(d) == day {
+			n.QuietDays = append(n.QuietDays[:i], n.QuietDays[i+1:]...)
+			return
+		}
+	}
+	n.QuietDays = append(n.QuietDays, int(day))
+}
+
+// DefaultPINIdleMinutes - через сколько минут бездействия бот снова спрашивает PIN
+const DefaultPINIdleMinutes = 15
+
+// PINSettings - блокировка финансовых данных PIN-кодом
+type PINSettings struct {
+	PINHash     string `json:"pin_hash"`         // bcrypt-хэш PIN, пусто - блокировка выключена
+	PINIdle     int    `json:"pin_idle_minutes"` // минут бездействия до повторного запроса PIN
+	PINFailures int    `json:"pin_failures"`     // неверных попыток подряд
+	// UnlockedUntil - до какого времени данные доступны без PIN
+	UnlockedUntil *time.Time `json:"pin_unlocked_until"`
+	// BlockedUntil - до какого времени ввод PIN заблокирован после неверных попыток
+	BlockedUntil *time.Time `json:"pin_blocked_until"`
+}
+
+// PINEnabled сообщает, включена ли блокировка
+func (p PINSettings) PINEnabled() bool {
+	return p.PINHash != ""
+}
+
+// Вид текста отчетов
+const (
+	ReportLayoutDetailed = "detailed" // все показатели, категории и изменения
+	ReportLayoutCompact  = "compact"  // итоги и крупнейшие категории расходов
+)
+
+// Разделы подробного отчета, которые пользователь включает и упорядочивает
+const (
+	ReportSectionStats      = "stats"      // статистика транзакций
+	ReportSectionLargest    = "largest"    // крупнейшие транзакции
+	ReportSectionCategories = "categories" // топ категорий расходов и доходов
+	ReportSectionChanges    = "changes"    // значительные изменения по категориям
+	ReportSectionPrices     = "prices"     // категории, где вырос средний чек
+)
+
+// DefaultReportSections - разделы отчета и их порядок, пока пользователь не собрал отчет сам
+var DefaultReportSections = []string{
+	ReportSectionStats,
+	ReportSectionLargest,
+	ReportSectionCategories,
+	ReportSectionChanges,
+	ReportSectionPrices,
+}
+
+// Порядок категорий при вводе транзакции
+const (
+	CategorySortManual = "manual" // порядок, заданный пользователем
+	CategorySortUsage  = "usage"  // сначала самые используемые
+)
+
+// Как присылаются графики
+const (
+	ChartFormatPhoto = "photo" // сжатые фото в чате
+	ChartFormatPNG   = "png"   // PNG-файлы без сжатия для печати и архива
+	ChartFormatSVG   = "svg"   // векторные SVG-файлы, масштабируются без потери качества
+)
+
+// Вид графика трендов
+const (
+	TrendChartChange   = "change"   // отклонение от среднего в процентах
+	TrendChartAbsolute = "absolute" // расходы в рублях со скользящим средним и линией бюджета
+)
+
+// Timezone - часовой пояс, который можно выбрать в настройках
+type Timezone struct {
+	Name  string // название из базы IANA
+	Title string // подпись в настройках
+}
+
+// Timezones - часовые пояса России по порядку. Пустой пояс - пояс сервера (переменная TZ)
+var Timezones = []Timezone{
+	{"Europe/Kaliningrad", "Калининград (UTC+2)"},
+	{"Europe/Moscow", "Москва (UTC+3)"},
+	{"Europe/Samara", "Самара (UTC+4)"},
+	{"Asia/Yekaterinburg", "Екатеринбург (UTC+5)"},
+	{"Asia/Omsk", "Омск (UTC+6)"},
+	{"Asia/Novosibirsk", "Новосибирск (UTC+7)"},
+	{"Asia/Irkutsk", "Иркутск (UTC+8)"},
+	{"Asia/Yakutsk", "Якутск (UTC+9)"},
+	{"Asia/Vladivostok", "Владивосток (UTC+10)"},
+	{"Asia/Magadan", "Магадан (UTC+11)"},
+	{"Asia/Kamchatka", "Камчатка (UTC+12)"},
+}
+
+const (
+	// DefaultChartScale - во сколько раз графики-файлы крупнее фото по умолчанию
+	DefaultChartScale = 2
+	// MaxChartScale - наибольший масштаб графиков-файлов
+	MaxChartScale = 4
+)
+
+// UserSettings - персональные настройки пользователя
+type UserSettings struct {
+	UserID          int64  `json:"user_id"`
+	LastSeenVersion int    `json:"last_seen_version"` // последняя показанная версия "Что нового"
+	ReportLayout    string `json:"report_layout"`     // вид текста отчетов
+	CategorySort    string `json:"category_sort"`     // порядок категорий при вводе
+	// InflationAdjusted пересчитывает годовой отчет по инфляции в цены текущего месяца
+	InflationAdjusted bool `json:"inflation_adjusted"`
+	// ChartFormat - как присылать графики, ChartScale - во сколько раз графики-файлы крупнее фото
+	ChartFormat string `json:"chart_format"`
+	ChartScale  int    `json:"chart_scale"`
+	// TrendChart - вид графика трендов
+	TrendChart string `json:"trend_chart"`
+	// ReportSections - включенные разделы отчета по порядку. nil - разделы по умолчанию
+	ReportSections []string `json:"report_sections"`
+	// CompactDaily - компактная сводка за день при подробных отчетах за период
+	CompactDaily bool `json:"compact_daily"`
+	// ActiveLedger - DataID книги, в которую идут записи и отчеты, 0 - основная книга
+	ActiveLedger int64 `json:"active_ledger"`
+	// Timezone - часовой пояс пользователя из базы IANA, пусто - пояс сервера
+	Timezone string `json:"timezone"`
+	NotificationSettings
+	PINSettings
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// CompactReports сообщает, выбран ли краткий вид отчетов
+func (s *UserSettings) CompactReports() bool {
+	return s.ReportLayout == ReportLayoutCompact
+}
+
+// CompactDailyReports сообщает, присылается ли компактная сводка за день: ее выбирают
+// отдельно или вместе с краткими отчетами
+func (s *UserSettings) CompactDailyReports() bool {
+	return s.CompactDaily || s.CompactReports()
+}
+
+// SortCategoriesByUsage сообщает, показываются ли частые категории первыми
+func (s *UserSettings) SortCategoriesByUsage() bool {
+	return s.CategorySort == CategorySortUsage
+}
+
+// ChartDocuments сообщает, присылаются ли графики файлами вместо сжатых фото
+func (s *UserSettings) ChartDocuments() bool {
+	return s.ChartFormat == ChartFormatPNG || s.ChartFormat == ChartFormatSVG
+}
+
+// AbsoluteTrends сообщает, строится ли график трендов в рублях вместо процентов
+func (s *UserSettings) AbsoluteTrends() bool {
+	return s.TrendChart == TrendChartAbsolute
+}
+
+// Location возвращает часовой пояс пользователя. Если пояс не выбран или неизвестен,
+// используется пояс сервера
+func (s *UserSettings) Location() *time.Location {
+	if s.Timezone == "" {
+		return time.Local
+	}
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return location
+}
+
+// Sections возвращает включенные разделы отчета по порядку
+func (s *UserSettings) Sections() []string {
+	if s.ReportSections == nil {
+		return DefaultReportSections
+	}
+	return s.ReportSections
+}
+
+// DefaultUserSettings возвращает настройки пользователя, который их еще не менял
+func DefaultUserSettings(userID int64) *UserSettings {
+	return &UserSettings{
+		UserID:       userID,
+		ReportLayout: ReportLayoutDetailed,
+		CategorySort: CategorySortManual,
+		ChartFormat:  ChartFormatPhoto,
+		ChartScale:   DefaultChartScale,
+		TrendChart:   TrendChartChange,
+		NotificationSettings: NotificationSettings{
+			DailyReport:   true,
+			WeeklyDigest:  true,
+			MonthlyDigest: true,
+			DeliveryHour:  DefaultDeliveryHour,
+			ReminderHour:  DefaultReminderHour,
+			QuietDays:     []int{},
+			// Предупреждения о бюджетах не будят по ночам
+			BudgetAlerts:   true,
+			QuietHoursFrom: DefaultQuietHoursFrom,
+			QuietHoursTo:   DefaultQuietHoursTo,
+			MonthlyClosing: true,
+			YearReview:     true,
+		},
+		PINSettings: PINSettings{PINIdle: DefaultPINIdleMinutes},
+	}
+}
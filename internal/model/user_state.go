@@ -2,11 +2,135 @@ package model
 
 import "time"
 
+// StateAction - шаг многошагового сценария, на котором бот ждет ответа пользователя
+type StateAction string
+
+// Состояния диалога
+const (
+	StateAmount           StateAction = "amount"            // выбрана категория, ждем сумму и описание
+	StateNewCategory      StateAction = "new_category"      // название новой категории, родительская категория в Payload
+	StateRenameCategory   StateAction = "rename_category"   // новое название категории, ID категории в Payload
+	StateReceipt          StateAction = "receipt"           // выбор способа импорта чека, чек в Payload
+	StateRecategorize     StateAction = "recategorize"      // новая категория транзакции из Payload
+	StateImport           StateAction = "statement_import"  // категории для выписки, сессия в Payload
+	StateReconcile        StateAction = "reconcile"         // сверка с выпиской, операции без пары в боте в Payload
+	StateNewAccount       StateAction = "new_account"       // название нового счета, тип в Payload
+	StateWebhookURL       StateAction = "webhook_url"       // URL нового webhook'а
+	StateNewAsset         StateAction = "new_asset"         // актив или обязательство, вид в Payload
+	StateNewHolding       StateAction = "new_holding"       // тикер и количество инвестиции, вид в Payload
+	StatePINUnlock        StateAction = "pin_unlock"        // PIN перед отложенной командой из Payload
+	StatePINSet           StateAction = "pin_set"           // новый PIN
+	StatePINConfirm       StateAction = "pin_confirm"       // повтор нового PIN, хэш первого ввода в Payload
+	StatePINDisable       StateAction = "pin_disable"       // текущий PIN для выключения защиты
+	StateCompareFirst     StateAction = "compare_first"     // первый период для сравнения
+	StateCompareNext      StateAction = "compare_second"    // второй период, первый в Payload
+	StateTransactionNote  StateAction = "transaction_note"  // заметка к транзакции, ID транзакции в Payload
+	StateTransactionPhoto StateAction = "transaction_photo" // фото чека к транзакции, ID транзакции в Payload
+	StatePlanCategory     StateAction = "plan_category"     // категория запланированной траты, трата в Payload
+	StateWishlistAmount   StateAction = "wishlist_amount"   // сумма, откладываемая на покупку, ID покупки в Payload
+	StateGoalAmount       StateAction = "goal_amount"       // сумма, откладываемая на цель, ID цели в Payload
+	StateNewLedger        StateAction = "new_ledger"        // название новой книги учета
+	StateCounterparty     StateAction = "counterparty"      // контрагент, счет и НДС транзакции, ID транзакции в Payload
+	StateNewEvent         StateAction = "new_event"         // название нового события
+)
+
+// stateSpec - правила состояния. Состояние без from начинает сценарий: в него переходят
+// кнопкой или командой из любого состояния, предыдущий сценарий при этом бросается.
+// В продолжение сценария можно перейти только из состояний from
+type stateSpec struct {
+	ttl  time.Duration
+	from []StateAction
+}
+
+var stateSpecs = map[StateAction]stateSpec{
+	StateAmount:           {ttl: time.Hour},
+	StateNewCategory:      {ttl: time.Hour},
+	StateRenameCategory:   {ttl: time.Hour},
+	StateReceipt:          {ttl: time.Hour},
+	StateRecategorize:     {ttl: time.Hour},
+	StateImport:           {ttl: 6 * time.Hour},
+	StateReconcile:        {ttl: 6 * time.Hour},
+	StateNewAccount:       {ttl: time.Hour},
+	StateWebhookURL:       {ttl: time.Hour},
+	StateNewAsset:         {ttl: time.Hour},
+	StateNewHolding:       {ttl: time.Hour},
+	StatePINUnlock:        {ttl: 10 * time.Minute},
+	StatePINSet:           {ttl: 10 * time.Minute},
+	StatePINConfirm:       {ttl: 10 * time.Minute, from: []StateAction{StatePINSet}},
+	StatePINDisable:       {ttl: 10 * time.Minute},
+	StateCompareFirst:     {ttl: time.Hour},
+	StateCompareNext:      {ttl: time.Hour, from: []StateAction{StateCompareFirst}},
+	StateTransactionNote:  {ttl: time.Hour},
+	StateTransactionPhoto: {ttl: time.Hour},
+	StatePlanCategory:     {ttl: time.Hour},
+	StateWishlistAmount:   {ttl: time.Hour},
+	StateGoalAmount:       {ttl: time.Hour},
+	StateNewLedger:        {ttl: time.Hour},
+	StateCounterparty:     {ttl: time.Hour},
+	StateNewEvent:         {ttl: time.Hour},
+}
+
+// Valid сообщает, известно ли состояние
+func (a StateAction) Valid() bool {
+	_, ok := stateSpecs[a]
+	return ok
+}
+
+// Entry сообщает, начинает ли состояние сценарий
+func (a StateAction) Entry() bool {
+	spec, ok := stateSpecs[a]
+	return ok && len(spec.from) == 0
+}
+
+// TTL возвращает, сколько состояние ждет ответа пользователя
+func (a StateAction) TTL() time.Duration {
+	return stateSpecs[a].ttl
+}
+
+// CanTransition проверяет, разрешен ли переход между состояниями
+func CanTransition(from, to StateAction) bool {
+	spec, ok := stateSpecs[to]
+	if !ok {
+		return false
+	}
+	if len(spec.from) == 0 {
+		return true
+	}
+	for _, allowed := range spec.from {
+		if allowed == from {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxStateTTL - срок жизни самого долгого состояния. Более старые записи можно удалять
+func MaxStateTTL() time.Duration {
+	var max time.Duration
+	for _, spec := range stateSpecs {
+		if spec.ttl > max {
+			max = spec.ttl
+		}
+	}
+	return max
+}
+
 // UserState представляет текущее состояние пользователя
 type UserState struct {
-	UserID           int64     `json:"user_id"`
-	SelectedCategory string    `json:"selected_category_id"`
-	TransactionType  string    `json:"transaction_type"`
-	AwaitingAction   string    `json:"awaiting_action"`
-	UpdatedAt        time.Time `json:"updated_at"`
+	UserID           int64       `json:"user_id"`
+	SelectedCategory string      `json:"selected_category_id"`
+	SelectedAccount  string      `json:"selected_account_id"`
+	TransactionType  string      `json:"transaction_type"`
+	AwaitingAction   StateAction `json:"awaiting_action"`
+	Payload          string      `json:"payload"` // данные многошаговых сценариев (например, чек в JSON)
+	UpdatedAt        time.Time   `json:"updated_at"`
+}
+
+// Expired сообщает, что пользователь не ответил за время жизни состояния.
+// Неизвестные состояния, например оставшиеся от старых версий бота, считаются истекшими
+func (s *UserState) Expired(now time.Time) bool {
+	if !s.AwaitingAction.Valid() {
+		return true
+	}
+	return now.Sub(s.UpdatedAt) > s.AwaitingAction.TTL()
 }
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// События, о которых сообщают исходящие webhook'и
+const (
+	WebhookEventTransactionCreated = "transaction.created"
+	WebhookEventTransactionDeleted = "transaction.deleted"
+	WebhookEventPing               = "ping"
+)
+
+// Webhook - URL пользователя, на который отправляются события о транзакциях
+type Webhook struct {
+	ID        string    `json:"id,omitempty"`
+	UserID    int64     `json:"user_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret"` // ключ HMAC-подписи тела запроса
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// WebhookPayload - тело запроса webhook'а
+type WebhookPayload struct {
+	Event       string       `json:"event"`
+	Transaction *Transaction `json:"transaction,omitempty"`
+	SentAt      time.Time    `json:"sent_at"`
+}
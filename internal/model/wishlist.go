@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// WishlistItem - будущая покупка из списка желаний, на которую пользователь откладывает деньги
+type WishlistItem struct {
+	ID           string    `json:"id,omitempty"`
+	UserID       int64     `json:"user_id"`
+	Name         string    `json:"name"`
+	TargetAmount float64   `json:"target_amount"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+}
+
+// WishlistAllocation - сумма, отложенная на покупку из списка желаний
+type WishlistAllocation struct {
+	ID        string    `json:"id,omitempty"`
+	ItemID    string    `json:"item_id"`
+	UserID    int64     `json:"user_id"`
+	Amount    float64   `json:"amount"` // положительное число
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
@@ -0,0 +1,90 @@
+package quotes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// coinIDs - идентификаторы CoinGecko популярных монет по тикерам
+var coinIDs = map[string]string{
+	"BTC":  "bitcoin",
+	"ETH":  "ethereum",
+	"USDT": "tether",
+	"USDC": "usd-coin",
+	"TON":  "the-open-network",
+	"SOL":  "solana",
+	"BNB":  "binancecoin",
+	"XRP":  "ripple",
+	"ADA":  "cardano",
+	"DOGE": "dogecoin",
+	"TRX":  "tron",
+	"DOT":  "polkadot",
+	"LTC":  "litecoin",
+	"AVAX": "avalanche-2",
+	"LINK": "chainlink",
+	"NOT":  "notcoin",
+}
+
+// CoinGeckoClient загружает курсы криптовалют в долларах:
+// <url>/api/v3/simple/price?ids=bitcoin,ethereum&vs_currencies=usd
+type CoinGeckoClient struct {
+	url    string
+	client *http.Client
+}
+
+// NewCoinGeckoClient создает клиент CoinGecko
+func NewCoinGeckoClient(url string) *CoinGeckoClient {
+	return &CoinGeckoClient{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Quotes возвращает курсы монет в долларах. Тикеры, которых нет в coinIDs, пропускаются
+func (c *CoinGeckoClient) Quotes(ctx context.Context, tickers []string) (map[string]model.Quote, error) {
+	var ids []string
+	for _, ticker := range tickers {
+		if id, ok := coinIDs[ticker]; ok {
+			ids = append(ids, id)
+		}
+	}
+	quotes := make(map[string]model.Quote)
+	if len(ids) == 0 {
+		return quotes, nil
+	}
+
+	query := url.Values{"ids": {strings.Join(ids, ",")}, "vs_currencies": {"usd"}}
+	endpoint := fmt.Sprintf("%s/api/v3/simple/price?%s", c.url, query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CoinGecko request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch CoinGecko prices: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CoinGecko returned status %d", resp.StatusCode)
+	}
+
+	var prices map[string]struct {
+		USD float64 `json:"usd"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&prices); err != nil {
+		return nil, fmt.Errorf("failed to decode CoinGecko prices: %w", err)
+	}
+	for _, ticker := range tickers {
+		if price, ok := prices[coinIDs[ticker]]; ok && price.USD > 0 {
+			quotes[ticker] = model.Quote{Price: price.USD, Currency: "USD"}
+		}
+	}
+	return quotes, nil
+}
@@ -0,0 +1,153 @@
+package quotes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// moexBoards - основные режимы торгов: акции и фонды. Бумага торгуется в нескольких
+// режимах, цена берется из основного
+var moexBoards = map[string]int{"TQBR": 0, "TQTF": 1}
+
+// MOEXClient загружает котировки акций и фондов Московской биржи через ISS:
+// <url>/iss/engines/stock/markets/shares/securities.json?securities=SBER,TMOS
+type MOEXClient struct {
+	url    string
+	client *http.Client
+}
+
+// NewMOEXClient создает клиент ISS Московской биржи
+func NewMOEXClient(url string) *MOEXClient {
+	return &MOEXClient{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// moexTable - таблица ответа ISS: названия колонок и строки значений
+type moexTable struct {
+	Columns []string            `json:"columns"`
+	Data    [][]json.RawMessage `json:"data"`
+}
+
+// rows возвращает строки таблицы как словари колонка - значение
+func (t moexTable) rows() []map[string]json.RawMessage {
+	rows := make([]map[string]json.RawMessage, 0, len(t.Data))
+	for _, values := range t.Data {
+		row := make(map[string]json.RawMessage, len(t.Columns))
+		for i, column := range t.Columns {
+			if i < len(values) {
+				row[column] = values[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// moexResponse - ответ ISS со сведениями о бумагах и торгами по ним
+type moexResponse struct {
+	Securities moexTable `json:"securities"`
+	Marketdata moexTable `json:"marketdata"`
+}
+
+// Quotes возвращает последние цены бумаг: цену последней сделки, а до начала торгов -
+// цену закрытия предыдущего дня
+func (c *MOEXClient) Quotes(ctx context.Context, tickers []string) (map[string]model.Quote, error) {
+	query := url.Values{
+		"iss.meta":   {"off"},
+		"iss.only":   {"securities,marketdata"},
+		"securities": {strings.Join(tickers, ",")},
+	}
+	endpoint := fmt.Sprintf("%s/iss/engines/stock/markets/shares/securities.json?%s", c.url, query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MOEX request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch MOEX quotes: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("MOEX returned status %d", resp.StatusCode)
+	}
+
+	var data moexResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode MOEX quotes: %w", err)
+	}
+
+	// Валюта и цена закрытия - в сведениях о бумаге, последняя сделка - в торгах
+	type security struct {
+		board    string
+		currency string
+		price    float64
+	}
+	securities := make(map[string]security)
+	for _, row := range data.Securities.rows() {
+		board := moexString(row["BOARDID"])
+		if _, ok := moexBoards[board]; !ok {
+			continue
+		}
+		ticker := moexString(row["SECID"])
+		if current, ok := securities[ticker]; ok && moexBoards[current.board] <= moexBoards[board] {
+			continue
+		}
+		securities[ticker] = security{
+			board:    board,
+			currency: moexCurrency(moexString(row["CURRENCYID"])),
+			price:    moexNumber(row["PREVPRICE"]),
+		}
+	}
+	for _, row := range data.Marketdata.rows() {
+		ticker := moexString(row["SECID"])
+		current, ok := securities[ticker]
+		if !ok || current.board != moexString(row["BOARDID"]) {
+			continue
+		}
+		if last := moexNumber(row["LAST"]); last > 0 {
+			current.price = last
+			securities[ticker] = current
+		}
+	}
+
+	quotes := make(map[string]model.Quote, len(securities))
+	for ticker, s := range securities {
+		if s.price > 0 {
+			quotes[ticker] = model.Quote{Price: s.price, Currency: s.currency}
+		}
+	}
+	return quotes, nil
+}
+
+// moexString разбирает строковое значение ячейки, null - пустая строка
+func moexString(value json.RawMessage) string {
+	var s string
+	json.Unmarshal(value, &s)
+	return s
+}
+
+// moexNumber разбирает числовое значение ячейки, null - ноль
+func moexNumber(value json.RawMessage) float64 {
+	var f float64
+	json.Unmarshal(value, &f)
+	return f
+}
+
+// moexCurrency переводит код валюты биржи в ISO 4217: рубль на бирже - SUR
+func moexCurrency(code string) string {
+	switch code {
+	case "", "SUR":
+		return "RUB"
+	default:
+		return code
+	}
+}
@@ -0,0 +1,104 @@
+package receipt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+const proverkachekaURL = "https://proverkacheka.com/api/v1/check/get"
+
+// ProverkachekaClient получает содержимое чеков через API proverkacheka.com
+type ProverkachekaClient struct {
+	token  string
+	client *http.Client
+}
+
+// NewProverkachekaClient создает клиент API proverkacheka.com
+func NewProverkachekaClient(token string) *ProverkachekaClient {
+	return &ProverkachekaClient{
+		token:  token,
+		client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// proverkachekaResponse - ответ API; суммы указаны в копейках
+type proverkachekaResponse struct {
+	Code int `json:"code"`
+	Data struct {
+		JSON struct {
+			User     string `json:"user"`
+			DateTime string `json:"dateTime"`
+			TotalSum int64  `json:"totalSum"`
+			Items    []struct {
+				Name     string  `json:"name"`
+				Price    int64   `json:"price"`
+				Quantity float64 `json:"quantity"`
+				Sum      int64   `json:"sum"`
+			} `json:"items"`
+		} `json:"json"`
+	} `json:"data"`
+}
+
+// GetReceipt запрашивает содержимое чека по строке QR-кода
+func (c *ProverkachekaClient) GetReceipt(ctx context.Context, qrRaw string) (*model.Receipt, error) {
+	qr, err := ParseQR(qrRaw)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("token", c.token)
+	form.Set("qrraw", qr.Raw)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, proverkachekaURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request receipt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("receipt api returned status %d", resp.StatusCode)
+	}
+
+	var result proverkachekaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse receipt: %w", err)
+	}
+	if result.Code != 1 {
+		return nil, fmt.Errorf("receipt not found (code %d)", result.Code)
+	}
+
+	data := result.Data.JSON
+	receipt := &model.Receipt{
+		Seller:    strings.TrimSpace(data.User),
+		DateTime:  qr.Time,
+		Total:     float64(data.TotalSum) / 100,
+		Operation: model.ReceiptOperation(qr.Type),
+	}
+	if dateTime, err := time.ParseInLocation("2006-01-02T15:04:05", data.DateTime, time.Local); err == nil {
+		receipt.DateTime = dateTime
+	}
+	for _, item := range data.Items {
+		receipt.Items = append(receipt.Items, model.ReceiptItem{
+			Name:     strings.TrimSpace(item.Name),
+			Price:    float64(item.Price) / 100,
+			Quantity: item.Quantity,
+			Sum:      float64(item.Sum) / 100,
+		})
+	}
+
+	return receipt, nil
+}
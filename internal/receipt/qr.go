@@ -0,0 +1,102 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+// QR содержит реквизиты чека из QR-кода ФНС: t=20240405T1530&s=1234.00&fn=...&i=...&fp=...&n=1
+type QR struct {
+	Raw  string
+	Time time.Time
+	Sum  float64
+	FN   string // номер фискального накопителя
+	FD   string // номер фискального документа
+	FP   string // фискальный признак документа
+	Type int    // вид операции: 1 - приход, 2 - возврат прихода, 3 - расход, 4 - возврат расхода
+}
+
+// qrTimeLayouts - форматы времени в QR-коде (секунды указываются не всегда)
+var qrTimeLayouts = []string{"20060102T150405", "20060102T1504"}
+
+// IsQR проверяет, похожа ли строка на содержимое QR-кода чека
+func IsQR(text string) bool {
+	text = strings.TrimSpace(text)
+	return strings.Contains(text, "fn=") && strings.Contains(text, "fp=") && strings.Contains(text, "s=")
+}
+
+// ParseQR разбирает содержимое QR-кода чека
+func ParseQR(raw string) (*QR, error) {
+	raw = strings.TrimSpace(raw)
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid receipt qr: %w", err)
+	}
+
+	qr := &QR{
+		Raw: raw,
+		FN:  values.Get("fn"),
+		FD:  values.Get("i"),
+		FP:  values.Get("fp"),
+	}
+	if qr.FN == "" || qr.FD == "" || qr.FP == "" {
+		return nil, fmt.Errorf("receipt qr is missing fiscal attributes")
+	}
+
+	if qr.Sum, err = strconv.ParseFloat(values.Get("s"), 64); err != nil {
+		return nil, fmt.Errorf("invalid receipt sum: %w", err)
+	}
+
+	for _, layout := range qrTimeLayouts {
+		if qr.Time, err = time.ParseInLocation(layout, values.Get("t"), time.Local); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid receipt time: %w", err)
+	}
+
+	qr.Type = 1
+	if n := values.Get("n"); n != "" {
+		if qr.Type, err = strconv.Atoi(n); err != nil {
+			return nil, fmt.Errorf("invalid receipt operation type: %w", err)
+		}
+	}
+	if qr.Type < 1 || qr.Type > 4 {
+		return nil, fmt.Errorf("invalid receipt operation type %d", qr.Type)
+	}
+
+	return qr, nil
+}
+
+// DecodeImage распознает QR-код на фотографии чека и возвращает его содержимое
+func DecodeImage(data []byte) (string, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return "", fmt.Errorf("failed to prepare image: %w", err)
+	}
+
+	hints := map[gozxing.DecodeHintType]interface{}{
+		gozxing.DecodeHintType_TRY_HARDER: true,
+	}
+	result, err := qrcode.NewQRCodeReader().Decode(bitmap, hints)
+	if err != nil {
+		return "", fmt.Errorf("qr code not found: %w", err)
+	}
+	return result.GetText(), nil
+}
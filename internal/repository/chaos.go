@@ -0,0 +1,127 @@
+package repository
+
+//go:generate go run ./chaosgen
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+var (
+	// ErrChaos - искусственная ошибка, внесенная ChaosRepository. Для бота выглядит как сбой базы
+	ErrChaos = fmt.Errorf("chaos: injected failure: %w", model.ErrStorageUnavailable)
+	// ErrChaosPartial - запись выполнена, но вызывающей стороне возвращена ошибка
+	ErrChaosPartial = fmt.Errorf("chaos: injected partial failure: %w", model.ErrStorageUnavailable)
+)
+
+// ChaosConfig задает вероятности сбоев. Вероятности указываются от 0 до 1
+type ChaosConfig struct {
+	ErrorRate   float64       // доля вызовов, завершающихся ошибкой без обращения к БД
+	LatencyRate float64       // доля вызовов со случайной задержкой
+	MaxLatency  time.Duration // максимальная задержка
+	PartialRate float64       // доля частичных сбоев: чтение возвращает половину данных, запись - ошибку после сохранения
+	Seed        int64         // зерно генератора для воспроизводимых прогонов, 0 - случайное
+}
+
+// ChaosConfigFromEnv читает настройки сбоев из переменных окружения CHAOS_*
+func ChaosConfigFromEnv() ChaosConfig {
+	cfg := ChaosConfig{
+		ErrorRate:   envFloat("CHAOS_ERROR_RATE"),
+		LatencyRate: envFloat("CHAOS_LATENCY_RATE"),
+		PartialRate: envFloat("CHAOS_PARTIAL_RATE"),
+		MaxLatency:  2 * time.Second,
+	}
+	if d, err := time.ParseDuration(os.Getenv("CHAOS_MAX_LATENCY")); err == nil {
+		cfg.MaxLatency = d
+	}
+	if seed, err := strconv.ParseInt(os.Getenv("CHAOS_SEED"), 10, 64); err == nil {
+		cfg.Seed = seed
+	}
+	return cfg
+}
+
+func envFloat(key string) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// ChaosRepository - декоратор репозитория для проверки устойчивости бота и планировщиков.
+// Вносит задержки, ошибки и частичные сбои с заданной вероятностью.
+// Предназначен только для тестовых окружений. Методы репозитория генерируются
+// по интерфейсу Repository в chaos_gen.go
+type ChaosRepository struct {
+	repo Repository
+	cfg  ChaosConfig
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// NewChaosRepository оборачивает репозиторий внесением сбоев
+func NewChaosRepository(repo Repository, cfg ChaosConfig) *ChaosRepository {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &ChaosRepository{
+		repo: repo,
+		cfg:  cfg,
+		rnd:  rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (c *ChaosRepository) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rnd.Float64() < rate
+}
+
+func (c *ChaosRepository) latency() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Duration(c.rnd.Int63n(int64(c.cfg.MaxLatency) + 1))
+}
+
+// inject вносит задержку и ошибку перед обращением к репозиторию
+func (c *ChaosRepository) inject(ctx context.Context, op string) error {
+	if c.cfg.MaxLatency > 0 && c.roll(c.cfg.LatencyRate) {
+		select {
+		case <-time.After(c.latency()):
+		case <-ctx.Done():
+			return classifyError(ctx.Err())
+		}
+	}
+	if c.roll(c.cfg.ErrorRate) {
+		return fmt.Errorf("%s: %w", op, ErrChaos)
+	}
+	return nil
+}
+
+// partialWrite возвращает ошибку после успешной записи
+func (c *ChaosRepository) partialWrite(op string, err error) error {
+	if err == nil && c.roll(c.cfg.PartialRate) {
+		return fmt.Errorf("%s: %w", op, ErrChaosPartial)
+	}
+	return err
+}
+
+// partialRead отбрасывает половину прочитанных данных
+func partialRead[T any](c *ChaosRepository, items []T, err error) ([]T, error) {
+	if err == nil && len(items) > 1 && c.roll(c.cfg.PartialRate) {
+		return items[:len(items)/2], nil
+	}
+	return items, err
+}
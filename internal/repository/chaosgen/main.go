@@ -0,0 +1,169 @@
+// Команда chaosgen генерирует методы ChaosRepository по интерфейсу Repository:
+//
+//	go generate ./internal/repository
+//
+// Каждый метод сначала вносит задержку и ошибку. Метод, возвращающий только ошибку, -
+// запись: после сохранения он может вернуть ErrChaosPartial. Метод Get*, возвращающий
+// срез, - чтение: он может вернуть половину данных. Остальные методы передаются как есть
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	source := flag.String("source", "repository.go", "файл с интерфейсом Repository")
+	output := flag.String("output", "chaos_gen.go", "файл для сгенерированных методов")
+	flag.Parse()
+
+	code, err := generate(*source)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, code, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate возвращает исходный код методов ChaosRepository
+func generate(source string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, source, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	iface := findInterface(file, "Repository")
+	if iface == nil {
+		return nil, fmt.Errorf("%s: interface Repository not found", source)
+	}
+
+	var methods bytes.Buffer
+	for _, method := range iface.Methods.List {
+		fn, ok := method.Type.(*ast.FuncType)
+		if !ok || len(method.Names) != 1 {
+			return nil, fmt.Errorf("%s: embedded interfaces are not supported", fset.Position(method.Pos()))
+		}
+		if err := writeMethod(&methods, fset, method.Names[0].Name, fn); err != nil {
+			return nil, err
+		}
+	}
+
+	// Импортируются только пакеты, которые встречаются в сигнатурах
+	imports := []string{`"context"`}
+	if strings.Contains(methods.String(), "time.") {
+		imports = append(imports, `"time"`)
+	}
+	imports = append(imports, "")
+	if strings.Contains(methods.String(), "model.") {
+		imports = append(imports, `"github.com/ivanoskov/financial_bot/internal/model"`)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by chaosgen from repository.go. DO NOT EDIT.\n\n")
+	b.WriteString("package repository\n\n")
+	fmt.Fprintf(&b, "import (\n\t%s\n)\n\n", strings.Join(imports, "\n\t"))
+	b.WriteString("var _ Repository = (*ChaosRepository)(nil)\n")
+	b.Write(methods.Bytes())
+	return format.Source(b.Bytes())
+}
+
+func findInterface(file *ast.File, name string) *ast.InterfaceType {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name == name {
+				iface, _ := ts.Type.(*ast.InterfaceType)
+				return iface
+			}
+		}
+	}
+	return nil
+}
+
+// writeMethod пишет метод-обертку: сбой перед вызовом и частичный сбой после него
+func writeMethod(b *bytes.Buffer, fset *token.FileSet, name string, fn *ast.FuncType) error {
+	var params, args []string
+	for _, field := range fn.Params.List {
+		if len(field.Names) == 0 {
+			return fmt.Errorf("%s: %s has unnamed parameters", fset.Position(field.Pos()), name)
+		}
+		var names []string
+		for _, n := range field.Names {
+			names = append(names, n.Name)
+			args = append(args, n.Name)
+		}
+		params = append(params, strings.Join(names, ", ")+" "+typeString(fset, field.Type))
+	}
+
+	var results []string
+	for _, field := range fn.Results.List {
+		results = append(results, typeString(fset, field.Type))
+	}
+	if len(results) == 0 || results[len(results)-1] != "error" {
+		return fmt.Errorf("%s: %s must return error last", fset.Position(fn.Pos()), name)
+	}
+
+	zeros := make([]string, 0, len(results))
+	for _, result := range results[:len(results)-1] {
+		zero, err := zeroValue(result)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		zeros = append(zeros, zero)
+	}
+
+	call := fmt.Sprintf("c.repo.%s(%s)", name, strings.Join(args, ", "))
+	resultList := results[0]
+	if len(results) > 1 {
+		resultList = "(" + strings.Join(results, ", ") + ")"
+	}
+
+	fmt.Fprintf(b, "\nfunc (c *ChaosRepository) %s(%s) %s {\n", name, strings.Join(params, ", "), resultList)
+	fmt.Fprintf(b, "\tif err := c.inject(ctx, %q); err != nil {\n", name)
+	fmt.Fprintf(b, "\t\treturn %s\n\t}\n", strings.Join(append(zeros, "err"), ", "))
+	switch {
+	case len(results) == 1:
+		fmt.Fprintf(b, "\treturn c.partialWrite(%q, %s)\n", name, call)
+	case len(results) == 2 && strings.HasPrefix(name, "Get") && strings.HasPrefix(results[0], "[]"):
+		fmt.Fprintf(b, "\titems, err := %s\n\treturn partialRead(c, items, err)\n", call)
+	default:
+		fmt.Fprintf(b, "\treturn %s\n", call)
+	}
+	b.WriteString("}\n")
+	return nil
+}
+
+func typeString(fset *token.FileSet, expr ast.Expr) string {
+	var b bytes.Buffer
+	if err := format.Node(&b, fset, expr); err != nil {
+		log.Fatal(err)
+	}
+	return b.String()
+}
+
+// zeroValue возвращает нулевое значение результата для возврата вместе с ошибкой
+func zeroValue(typ string) (string, error) {
+	switch {
+	case strings.HasPrefix(typ, "*"), strings.HasPrefix(typ, "[]"), strings.HasPrefix(typ, "map["):
+		return "nil", nil
+	case typ == "bool":
+		return "false", nil
+	case typ == "string":
+		return `""`, nil
+	case typ == "int", typ == "int64", typ == "float64":
+		return "0", nil
+	}
+	return "", fmt.Errorf("unsupported result type %s", typ)
+}
@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// encryptedPrefix отличает зашифрованные значения от записанных до включения шифрования
+const encryptedPrefix = "enc:v1:"
+
+// EncryptedRepository - декоратор репозитория, шифрующий описания и заметки транзакций AES-GCM
+// перед записью в базу и расшифровывающий их при чтении. Готовые отчеты в кэше
+// содержат описания крупнейших операций, поэтому шифруются целиком.
+// Записи, сделанные до включения шифрования, читаются как есть
+type EncryptedRepository struct {
+	Repository
+	aead cipher.AEAD
+}
+
+// NewEncryptedRepository создает декоратор с 32-байтным ключом AES-256
+func NewEncryptedRepository(repo Repository, key []byte) (*EncryptedRepository, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return &EncryptedRepository{Repository: repo, aead: aead}, nil
+}
+
+func (e *EncryptedRepository) CreateTransaction(ctx context.Context, transaction *model.Transaction) error {
+	// Шифруем копию: вызывающая сторона продолжает работать с открытым описанием
+	encrypted := *transaction
+	var err error
+	if encrypted.Description, err = e.encrypt(transaction.Description); err != nil {
+		return err
+	}
+	if encrypted.Note, err = e.encrypt(transaction.Note); err != nil {
+		return err
+	}
+	if err := e.Repository.CreateTransaction(ctx, &encrypted); err != nil {
+		return err
+	}
+	transaction.ID, transaction.CreatedAt = encrypted.ID, encrypted.CreatedAt
+	return nil
+}
+
+func (e *EncryptedRepository) CreateTransactions(ctx context.Context, transactions []*model.Transaction) error {
+	encrypted := make([]*model.Transaction, len(transactions))
+	for i, transaction := range transactions {
+		copied := *transaction
+		var err error
+		if copied.Description, err = e.encrypt(transaction.Description); err != nil {
+			return err
+		}
+		if copied.Note, err = e.encrypt(transaction.Note); err != nil {
+			return err
+		}
+		encrypted[i] = &copied
+	}
+	if err := e.Repository.CreateTransactions(ctx, encrypted); err != nil {
+		return err
+	}
+	for i, transaction := range transactions {
+		transaction.ID, transaction.CreatedAt = encrypted[i].ID, encrypted[i].CreatedAt
+	}
+	return nil
+}
+
+// GetTransactions ищет по описанию после расшифровки: в базе хранится шифротекст
+func (e *EncryptedRepository) GetTransactions(ctx context.Context, userID int64, filter model.TransactionFilter) ([]model.Transaction, error) {
+	search, limit := filter.Search, filter.Limit
+	if search != "" {
+		filter.Search, filter.Limit = "", 0
+	}
+	transactions, err := e.Repository.GetTransactions(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.decryptAll(transactions); err != nil {
+		return nil, err
+	}
+	if search == "" {
+		return transactions, nil
+	}
+
+	search = strings.ToLower(search)
+	found := transactions[:0]
+	for _, t := range transactions {
+		if strings.Contains(strings.ToLower(t.Description), search) {
+			found = append(found, t)
+		}
+		if limit > 0 && len(found) == limit {
+			break
+		}
+	}
+	return found, nil
+}
+
+func (e *EncryptedRepository) GetTransaction(ctx context.Context, id string, userID int64) (*model.Transaction, error) {
+	transaction, err := e.Repository.GetTransaction(ctx, id, userID)
+	if err != nil || transaction == nil {
+		return transaction, err
+	}
+	transactions := []model.Transaction{*transaction}
+	if err := e.decryptAll(transactions); err != nil {
+		return nil, err
+	}
+	return &transactions[0], nil
+}
+
+func (e *EncryptedRepository) UpdateTransactionDetails(ctx context.Context, id string, userID int64, note, attachmentPath string) error {
+	encrypted, err := e.encrypt(note)
+	if err != nil {
+		return err
+	}
+	return e.Repository.UpdateTransactionDetails(ctx, id, userID, encrypted, attachmentPath)
+}
+
+func (e *EncryptedRepository) GetDeletedTransactions(ctx context.Context, userID int64, since time.Time) ([]model.Transaction, error) {
+	transactions, err := e.Repository.GetDeletedTransactions(ctx, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	return transactions, e.decryptAll(transactions)
+}
+
+func (e *EncryptedRepository) GetTransactionsByCategory(ctx context.Context, userID int64, categoryID string) ([]model.Transaction, error) {
+	transactions, err := e.Repository.GetTransactionsByCategory(ctx, userID, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	return transactions, e.decryptAll(transactions)
+}
+
+func (e *EncryptedRepository) GetReportData(ctx context.Context, userID int64, current, previous model.TransactionFilter) (*model.ReportData, error) {
+	data, err := e.Repository.GetReportData(ctx, userID, current, previous)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.decryptAll(data.Current); err != nil {
+		return nil, err
+	}
+	if err := e.decryptAll(data.Previous); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (e *EncryptedRepository) SaveCachedReport(ctx context.Context, report *model.CachedReport) error {
+	sealed, err := e.encrypt(string(report.Report))
+	if err != nil {
+		return err
+	}
+	// Колонка report - JSONB, поэтому шифротекст хранится JSON-строкой
+	encoded, err := json.Marshal(sealed)
+	if err != nil {
+		return fmt.Errorf("failed to encode cached report: %w", err)
+	}
+	encrypted := *report
+	encrypted.Report = encoded
+	return e.Repository.SaveCachedReport(ctx, &encrypted)
+}
+
+func (e *EncryptedRepository) GetCachedReport(ctx context.Context, ownerID int64, key string) (*model.CachedReport, error) {
+	report, err := e.Repository.GetCachedReport(ctx, ownerID, key)
+	if err != nil || report == nil {
+		return report, err
+	}
+
+	var sealed string
+	if json.Unmarshal(report.Report, &sealed) != nil {
+		// Отчет сохранен до включения шифрования
+		return report, nil
+	}
+	plain, err := e.decrypt(sealed)
+	if err != nil {
+		return nil, err
+	}
+	report.Report = json.RawMessage(plain)
+	return report, nil
+}
+
+func (e *EncryptedRepository) decryptAll(transactions []model.Transaction) error {
+	for i := range transactions {
+		description, err := e.decrypt(transactions[i].Description)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt transaction %s: %w", transactions[i].ID, err)
+		}
+		note, err := e.decrypt(transactions[i].Note)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt transaction %s: %w", transactions[i].ID, err)
+		}
+		transactions[i].Description, transactions[i].Note = description, note
+	}
+	return nil
+}
+
+// encrypt возвращает "enc:v1:" + base64(nonce + шифротекст). Пустая строка не шифруется
+func (e *EncryptedRepository) encrypt(plain string) (string, error) {
+	if plain == "" {
+		return "", nil
+	}
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := e.aead.Seal(nonce, nonce, []byte(plain), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt расшифровывает значение; значения без префикса возвращаются без изменений
+func (e *EncryptedRepository) decrypt(value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, encryptedPrefix)
+	if !ok {
+		return value, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode ciphertext: %w", err)
+	}
+	if len(sealed) < e.aead.NonceSize() {
+		return "", errors.New("ciphertext is too short")
+	}
+	nonce, ciphertext := sealed[:e.aead.NonceSize()], sealed[e.aead.NonceSize():]
+	plain, err := e.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return string(plain), nil
+}
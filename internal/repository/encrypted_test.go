@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// memoryRepository хранит транзакции и отчеты в памяти, как их видит база.
+// Остальные методы тестам не нужны
+type memoryRepository struct {
+	Repository
+	transactions []model.Transaction
+	reports      map[string]model.CachedReport
+	filters      []model.TransactionFilter
+}
+
+func (r *memoryRepository) CreateTransaction(ctx context.Context, transaction *model.Transaction) error {
+	transaction.ID = fmt.Sprintf("t%d", len(r.transactions)+1)
+	r.transactions = append(r.transactions, *transaction)
+	return nil
+}
+
+func (r *memoryRepository) CreateTransactions(ctx context.Context, transactions []*model.Transaction) error {
+	for _, transaction := range transactions {
+		if err := r.CreateTransaction(ctx, transaction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepository) GetTransactions(ctx context.Context, userID int64, filter model.TransactionFilter) ([]model.Transaction, error) {
+	r.filters = append(r.filters, filter)
+	return append([]model.Transaction(nil), r.transactions...), nil
+}
+
+func (r *memoryRepository) GetTransaction(ctx context.Context, id string, userID int64) (*model.Transaction, error) {
+	for _, t := range r.transactions {
+		if t.ID == id {
+			return &t, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryRepository) SaveCachedReport(ctx context.Context, report *model.CachedReport) error {
+	if r.reports == nil {
+		r.reports = make(map[string]model.CachedReport)
+	}
+	r.reports[report.Key] = *report
+	return nil
+}
+
+func (r *memoryRepository) GetCachedReport(ctx context.Context, ownerID int64, key string) (*model.CachedReport, error) {
+	report, ok := r.reports[key]
+	if !ok {
+		return nil, nil
+	}
+	return &report, nil
+}
+
+func newTestEncryptedRepository(t *testing.T) (*EncryptedRepository, *memoryRepository) {
+	t.Helper()
+	store := &memoryRepository{}
+	repo, err := NewEncryptedRepository(store, bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("NewEncryptedRepository: %v", err)
+	}
+	return repo, store
+}
+
+func TestEncryptedRoundTrip(t *testing.T) {
+	repo, store := newTestEncryptedRepository(t)
+	ctx := context.Background()
+
+	transaction := &model.Transaction{UserID: 1, Amount: -300, Description: "Кофе у дома", Note: "с коллегой"}
+	if err := repo.CreateTransaction(ctx, transaction); err != nil {
+		t.Fatalf("CreateTransaction: %v", err)
+	}
+	if transaction.ID == "" || transaction.Description != "Кофе у дома" {
+		t.Errorf("вызывающая сторона получила %+v, ожидались ID и открытое описание", transaction)
+	}
+	stored := store.transactions[0]
+	for _, value := range []string{stored.Description, stored.Note} {
+		if !strings.HasPrefix(value, encryptedPrefix) || strings.Contains(value, "Кофе") {
+			t.Errorf("в базе хранится %q, ожидался шифротекст", value)
+		}
+	}
+
+	got, err := repo.GetTransaction(ctx, transaction.ID, 1)
+	if err != nil {
+		t.Fatalf("GetTransaction: %v", err)
+	}
+	if got.Description != "Кофе у дома" || got.Note != "с коллегой" {
+		t.Errorf("расшифровано %q и %q", got.Description, got.Note)
+	}
+}
+
+func TestEncryptedCreateTransactions(t *testing.T) {
+	repo, store := newTestEncryptedRepository(t)
+
+	batch := []*model.Transaction{
+		{UserID: 1, Amount: -100, Description: "Хлеб"},
+		{UserID: 1, Amount: -200, Description: "Молоко"},
+	}
+	if err := repo.CreateTransactions(context.Background(), batch); err != nil {
+		t.Fatalf("CreateTransactions: %v", err)
+	}
+	for i, transaction := range batch {
+		if transaction.ID != store.transactions[i].ID || transaction.ID == "" {
+			t.Errorf("транзакция %d: ID %q, в базе %q", i, transaction.ID, store.transactions[i].ID)
+		}
+		if !strings.HasPrefix(store.transactions[i].Description, encryptedPrefix) {
+			t.Errorf("в базе хранится %q, ожидался шифротекст", store.transactions[i].Description)
+		}
+	}
+	if batch[1].Description != "Молоко" {
+		t.Errorf("описание вызывающей стороны изменилось: %q", batch[1].Description)
+	}
+}
+
+func TestEncryptedLegacyPlaintext(t *testing.T) {
+	repo, store := newTestEncryptedRepository(t)
+	// Запись сделана до включения шифрования
+	store.transactions = []model.Transaction{{ID: "old", Amount: -50, Description: "Проезд"}}
+
+	transactions, err := repo.GetTransactions(context.Background(), 1, model.TransactionFilter{})
+	if err != nil {
+		t.Fatalf("GetTransactions: %v", err)
+	}
+	if len(transactions) != 1 || transactions[0].Description != "Проезд" {
+		t.Errorf("старая запись прочитана как %+v", transactions)
+	}
+
+	// Испорченный шифротекст - ошибка, а не мусор в описании
+	store.transactions[0].Description = encryptedPrefix + "!!!"
+	if _, err := repo.GetTransactions(context.Background(), 1, model.TransactionFilter{}); err == nil {
+		t.Errorf("испорченный шифротекст прочитан без ошибки")
+	}
+}
+
+func TestEncryptedSearchWithLimit(t *testing.T) {
+	repo, store := newTestEncryptedRepository(t)
+	ctx := context.Background()
+	for _, description := range []string{"Кофе", "Такси", "кофе с собой", "Кофейня"} {
+		if err := repo.CreateTransaction(ctx, &model.Transaction{UserID: 1, Amount: -100, Description: description}); err != nil {
+			t.Fatalf("CreateTransaction: %v", err)
+		}
+	}
+
+	found, err := repo.GetTransactions(ctx, 1, model.TransactionFilter{Search: "КОФЕ", Limit: 2})
+	if err != nil {
+		t.Fatalf("GetTransactions: %v", err)
+	}
+	if len(found) != 2 || found[0].Description != "Кофе" || found[1].Description != "кофе с собой" {
+		t.Errorf("найдено %+v, ожидались две первые траты на кофе", found)
+	}
+	// В базе описания зашифрованы, поэтому поиск и лимит применяются после расшифровки
+	if filter := store.filters[0]; filter.Search != "" || filter.Limit != 0 {
+		t.Errorf("в базу передан фильтр %+v, ожидался без поиска и лимита", filter)
+	}
+}
+
+func TestEncryptedCachedReport(t *testing.T) {
+	repo, store := newTestEncryptedRepository(t)
+	ctx := context.Background()
+	plain := json.RawMessage(`{"period":"Март 2026","largest":"Ремонт"}`)
+
+	if err := repo.SaveCachedReport(ctx, &model.CachedReport{OwnerID: 1, Key: "month", Report: plain}); err != nil {
+		t.Fatalf("SaveCachedReport: %v", err)
+	}
+	var sealed string
+	if err := json.Unmarshal(store.reports["month"].Report, &sealed); err != nil || !strings.HasPrefix(sealed, encryptedPrefix) {
+		t.Errorf("в базе хранится %s, ожидался шифротекст JSON-строкой", store.reports["month"].Report)
+	}
+	report, err := repo.GetCachedReport(ctx, 1, "month")
+	if err != nil {
+		t.Fatalf("GetCachedReport: %v", err)
+	}
+	if string(report.Report) != string(plain) {
+		t.Errorf("отчет прочитан как %s, ожидался %s", report.Report, plain)
+	}
+
+	// Отчет сохранен до включения шифрования
+	store.reports["year"] = model.CachedReport{OwnerID: 1, Key: "year", Report: plain}
+	report, err = repo.GetCachedReport(ctx, 1, "year")
+	if err != nil || string(report.Report) != string(plain) {
+		t.Errorf("старый отчет прочитан как %v, %v", report, err)
+	}
+	if report, err := repo.GetCachedReport(ctx, 1, "week"); report != nil || err != nil {
+		t.Errorf("отсутствующий отчет: %v, %v", report, err)
+	}
+}
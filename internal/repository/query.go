@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// queryTimeout - предельное время одного запроса к Supabase
+const queryTimeout = 10 * time.Second
+
+// executor - построенный запрос postgrest
+type executor interface {
+	Execute() ([]byte, int64, error)
+}
+
+type executeResult struct {
+	data  []byte
+	count int64
+	err   error
+}
+
+// execute выполняет запрос с учетом отмены контекста и queryTimeout. Клиент Supabase
+// не принимает контекст, поэтому запрос выполняется в отдельной горутине: при отмене
+// вызывающий сразу получает ошибку, а запрос завершается в фоне
+func execute(ctx context.Context, q executor) ([]byte, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	done := make(chan executeResult, 1)
+	go func() {
+		data, count, err := q.Execute()
+		done <- executeResult{data: data, count: count, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.data, res.count, classifyError(res.err)
+	case <-ctx.Done():
+		return nil, 0, classifyError(ctx.Err())
+	}
+}
+
+// classifyError оборачивает ошибку запроса в вид ошибки из model, чтобы бот мог объяснить
+// пользователю, что произошло. Клиент Supabase теряет HTTP-статус и возвращает ошибку
+// строкой "(код) сообщение", поэтому вид определяется по коду PostgreSQL или PostgREST
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if kind := errorKind(err); kind != nil {
+		return fmt.Errorf("%w: %w", kind, err)
+	}
+	return err
+}
+
+// errorKind возвращает вид ошибки или nil, если ошибка не распознана
+func errorKind(err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) {
+		return model.ErrStorageUnavailable
+	}
+
+	message := err.Error()
+	if strings.HasPrefix(message, "error parsing error response") {
+		// Вместо JSON с ошибкой пришла страница шлюза: Supabase перегружен или недоступен
+		return model.ErrStorageUnavailable
+	}
+	if !strings.HasPrefix(message, "(") {
+		return nil
+	}
+	code, _, _ := strings.Cut(message[1:], ")")
+	switch {
+	case code == "PGRST116":
+		// Запрос одной записи не нашел ни одной
+		return model.ErrNotFound
+	case code == "23505", code == "23514", code == "23502", code == "23503", code == "22P02", code == "22001":
+		// Нарушены ограничения таблицы или значение неверного формата
+		return model.ErrValidation
+	case code == "57014", code == "53300", strings.HasPrefix(code, "08"), strings.HasPrefix(code, "PGRST00"):
+		// Запрос отменен по таймауту, кончились подключения или PostgREST потерял связь с базой
+		return model.ErrStorageUnavailable
+	}
+	return nil
+}
+
+// rpcClient выполняет вызовы функций базы. Время запроса ограничивает контекст
+var rpcClient = &http.Client{}
+
+// rpc вызывает функцию базы через PostgREST. Функция выполняется в одной транзакции:
+// при ошибке изменения откатываются целиком
+func (r *SupabaseRepository) rpc(ctx context.Context, name string, params interface{}) ([]byte, error) {
+	return r.rpcWithTimeout(ctx, name, params, queryTimeout)
+}
+
+// rpcWithTimeout - rpc для долгих функций, например миграций схемы
+func (r *SupabaseRepository) rpcWithTimeout(ctx context.Context, name string, params interface{}, timeout time.Duration) ([]byte, error) {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.restURL+"/rpc/"+name, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("apikey", r.key)
+	req.Header.Set("Authorization", "Bearer "+r.key)
+
+	resp, err := rpcClient.Do(req)
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		// Ошибка в том же формате, что и у запросов через клиент: "(код) сообщение"
+		var pgErr struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		err := fmt.Errorf("rpc %s responded with status %d", name, resp.StatusCode)
+		if json.Unmarshal(data, &pgErr) == nil && pgErr.Message != "" {
+			err = fmt.Errorf("(%s) %s", pgErr.Code, pgErr.Message)
+		}
+		// Здесь статус известен, поэтому лимит запросов и сбои шлюза различимы и без кода
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			return nil, fmt.Errorf("%w: %w", model.ErrRateLimited, err)
+		case resp.StatusCode >= 500 && errorKind(err) == nil:
+			return nil, fmt.Errorf("%w: %w", model.ErrStorageUnavailable, err)
+		}
+		return nil, classifyError(err)
+	}
+	return data, nil
+}
@@ -16,22 +16,143 @@ type Repository interface {
 
 	// Транзакции
 	CreateTransaction(ctx context.Context, transaction *model.Transaction) error
+	CreateTransactions(ctx context.Context, transactions []*model.Transaction) error
 	GetTransactions(ctx context.Context, userID int64, filter model.TransactionFilter) ([]model.Transaction, error)
 	GetTransactionsByCategory(ctx context.Context, userID int64, categoryID string) ([]model.Transaction, error)
 	DeleteTransaction(ctx context.Context, id string, userID int64) error
+	UpdateTransactionCategory(ctx context.Context, id string, userID int64, categoryID string) error
+	GetTransaction(ctx context.Context, id string, userID int64) (*model.Transaction, error)
+	UpdateTransactionDetails(ctx context.Context, id string, userID int64, note, attachmentPath string) error
+	UpdateTransactionBusiness(ctx context.Context, id string, userID int64, counterparty, invoiceNumber string, vatAmount float64) error
+	UpdateTransactionEvent(ctx context.Context, id string, userID int64, eventID string) error
+
+	// Корзина: удаленные транзакции и категории хранятся до очистки PurgeDeleted
+	GetDeletedTransactions(ctx context.Context, userID int64, since time.Time) ([]model.Transaction, error)
+	GetDeletedCategories(ctx context.Context, userID int64, since time.Time) ([]model.Category, error)
+	RestoreTransaction(ctx context.Context, id string, userID int64) error
+	RestoreCategory(ctx context.Context, category *model.Category) error
+	PurgeDeleted(ctx context.Context, before time.Time) ([]string, error)
 
 	// Методы для работы с состояниями пользователей
 	GetUserState(ctx context.Context, userID int64) (*model.UserState, error)
 	SaveUserState(ctx context.Context, state *model.UserState) error
 	DeleteUserState(ctx context.Context, userID int64) error
+	DeleteUserStatesBefore(ctx context.Context, before time.Time) error
 
-	// Добавленные методы
+	// Реестр пользователей
 	GetAllUsers(ctx context.Context) ([]int64, error)
-}
+	SaveUser(ctx context.Context, user *model.User) error
+	GetUsers(ctx context.Context) ([]model.User, error)
+	GetUser(ctx context.Context, userID int64) (*model.User, error)
+	SetMenuSession(ctx context.Context, userID int64, session int) error
+	DeleteUserData(ctx context.Context, userID int64) error
+
+	// Бюджеты и цели
+	GetBudgets(ctx context.Context, userID int64) ([]model.Budget, error)
+	SaveBudget(ctx context.Context, budget *model.Budget) error
+	GetGoals(ctx context.Context, userID int64) ([]model.Goal, error)
+	CreateGoal(ctx context.Context, goal *model.Goal) error
+	UpdateGoalSaved(ctx context.Context, id string, userID int64, saved float64) error
+
+	// Запланированные траты
+	GetPlannedExpenses(ctx context.Context, userID int64, since time.Time) ([]model.PlannedExpense, error)
+	CreatePlannedExpense(ctx context.Context, plan *model.PlannedExpense) error
+	DeletePlannedExpense(ctx context.Context, id string, userID int64) error
+
+	// Список желаний
+	GetWishlist(ctx context.Context, userID int64) ([]model.WishlistItem, error)
+	CreateWishlistItem(ctx context.Context, item *model.WishlistItem) error
+	DeleteWishlistItem(ctx context.Context, id string, userID int64) error
+	GetWishlistAllocations(ctx context.Context, userID int64) ([]model.WishlistAllocation, error)
+	CreateWishlistAllocation(ctx context.Context, allocation *model.WishlistAllocation) error
+
+	// Предрасчитанная статистика пользователей
+	GetUserBaseline(ctx context.Context, userID int64) (*model.UserBaseline, error)
+	SaveUserBaseline(ctx context.Context, baseline *model.UserBaseline) error
+
+	// Счета
+	GetAccounts(ctx context.Context, userID int64) ([]model.Account, error)
+	CreateAccount(ctx context.Context, account *model.Account) error
+	DeleteAccount(ctx context.Context, id string, userID int64) error
+
+	// Общие бюджеты
+	GetLedgerMember(ctx context.Context, memberID int64) (*model.LedgerMember, error)
+	GetLedgerMembers(ctx context.Context, ownerID int64) ([]model.LedgerMember, error)
+	SaveLedgerMember(ctx context.Context, member *model.LedgerMember) error
+	DeleteLedgerMember(ctx context.Context, memberID int64) error
+	CreateLedgerInvite(ctx context.Context, invite *model.LedgerInvite) error
+	GetLedgerInvite(ctx context.Context, code string) (*model.LedgerInvite, error)
+	DeleteLedgerInvite(ctx context.Context, code string) error
+
+	// Книги учета пользователя
+	GetLedgers(ctx context.Context, userID int64) ([]model.Ledger, error)
+	CreateLedger(ctx context.Context, ledger *model.Ledger) error
+	UpdateLedger(ctx context.Context, ledger *model.Ledger) error
+	DeleteLedger(ctx context.Context, id string, userID int64) error
+
+	// События: отпуск, ремонт
+	GetEvents(ctx context.Context, userID int64) ([]model.Event, error)
+	CreateEvent(ctx context.Context, event *model.Event) error
+	UpdateEvent(ctx context.Context, event *model.Event) error
+	DeleteEvent(ctx context.Context, id string, userID int64) error
+	GetEvent(ctx context.Context, id string) (*model.Event, error)
+	GetEventByInvite(ctx context.Context, code string) (*model.Event, error)
+	GetEventMembers(ctx context.Context, eventID string) ([]model.EventMember, error)
+	GetEventMemberships(ctx context.Context, userID int64) ([]model.EventMember, error)
+	SaveEventMember(ctx context.Context, member *model.EventMember) error
+	GetEventPayments(ctx context.Context, eventID string) ([]model.EventPayment, error)
+	CreateEventPayment(ctx context.Context, payment *model.EventPayment) error
+	DeleteEventPayment(ctx context.Context, id string, userID int64) error
+
+	// Курсы валют, общие для всех пользователей
+	GetExchangeRates(ctx context.Context, base, date string) ([]model.ExchangeRate, error)
+	SaveExchangeRates(ctx context.Context, rates []model.ExchangeRate) error
+
+	// Настройки пользователей
+	GetUserSettings(ctx context.Context, userID int64) (*model.UserSettings, error)
+	SaveUserSettings(ctx context.Context, settings *model.UserSettings) error
+
+	// Капитал
+	GetAssets(ctx context.Context, userID int64) ([]model.Asset, error)
+	CreateAsset(ctx context.Context, asset *model.Asset) error
+	DeleteAsset(ctx context.Context, id string, userID int64) error
+	GetHoldings(ctx context.Context, userID int64) ([]model.Holding, error)
+	SaveHolding(ctx context.Context, holding *model.Holding) error
+	UpdateHoldingPrice(ctx context.Context, holding *model.Holding) error
+	DeleteHolding(ctx context.Context, id string, userID int64) error
+	GetNetWorthSnapshots(ctx context.Context, userID int64, limit int) ([]model.NetWorthSnapshot, error)
+	SaveNetWorthSnapshot(ctx context.Context, snapshot *model.NetWorthSnapshot) error
+
+	// Исходящие webhook'и
+	GetWebhooks(ctx context.Context, userID int64) ([]model.Webhook, error)
+	CreateWebhook(ctx context.Context, webhook *model.Webhook) error
+	DeleteWebhook(ctx context.Context, id string, userID int64) error
+
+	// Напоминания по расписанию
+	GetReminders(ctx context.Context, userID int64) ([]model.Reminder, error)
+	GetAllReminders(ctx context.Context) ([]model.Reminder, error)
+	CreateReminder(ctx context.Context, reminder *model.Reminder) error
+	DeleteReminder(ctx context.Context, id string, userID int64) error
+	MarkReminderSent(ctx context.Context, id string, sentAt time.Time) error
+
+	// Рассылки администратора и их доставка пользователям
+	CreateBroadcast(ctx context.Context, broadcast *model.Broadcast) error
+	GetBroadcast(ctx context.Context, id string) (*model.Broadcast, error)
+	UpdateBroadcast(ctx context.Context, broadcast *model.Broadcast) error
+	GetQueuedBroadcasts(ctx context.Context) ([]model.Broadcast, error)
+	GetBroadcastDeliveries(ctx context.Context, broadcastID string) ([]model.BroadcastDelivery, error)
+	SaveBroadcastDelivery(ctx context.Context, delivery *model.BroadcastDelivery) error
+
+	// Обработанные обновления Telegram
+	MarkUpdateProcessed(ctx context.Context, update *model.ProcessedUpdate) (bool, error)
+	DeleteProcessedUpdate(ctx context.Context, updateID int64) error
+	DeleteProcessedUpdates(ctx context.Context, before time.Time) error
+
+	// Данные отчета одним вызовом
+	GetReportData(ctx context.Context, userID int64, current, previous model.TransactionFilter) (*model.ReportData, error)
 
-type TransactionFilter struct {
-	StartDate *time.Time
-	EndDate   *time.Time
-	Type      string // "expense" или "income"
-	Limit     int    // ограничение количества результатов
+	// Кэш отчетов
+	GetCachedReport(ctx context.Context, ownerID int64, key string) (*model.CachedReport, error)
+	SaveCachedReport(ctx context.Context, report *model.CachedReport) error
+	DeleteCachedReports(ctx context.Context, ownerID int64) error
 }
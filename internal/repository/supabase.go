@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ivanoskov/financial_bot/internal/model"
@@ -14,6 +15,10 @@ import (
 
 type SupabaseRepository struct {
 	client *supabase.Client
+
+	// Вызов функций базы (rpc) выполняется напрямую: клиент не возвращает ошибки запроса
+	restURL string
+	key     string
 }
 
 func NewSupabaseRepository(url, key string) (*SupabaseRepository, error) {
@@ -23,13 +28,15 @@ func NewSupabaseRepository(url, key string) (*SupabaseRepository, error) {
 	}
 
 	return &SupabaseRepository{
-		client: client,
+		client:  client,
+		restURL: strings.TrimRight(url, "/") + "/rest/v1",
+		key:     key,
 	}, nil
 }
 
 func (r *SupabaseRepository) CreateCategory(ctx context.Context, category *model.Category) error {
 	fmt.Printf("Creating category: %+v\n", category)
-	data, count, err := r.client.From("categories").Insert(category, true, "", "", "").Execute()
+	data, count, err := execute(ctx, r.client.From("categories").Insert(category, true, "", "", ""))
 	if err != nil {
 		fmt.Printf("Error creating category: %v\n", err)
 		return fmt.Errorf("failed to create category: %w", err)
@@ -50,10 +57,10 @@ func (r *SupabaseRepository) CreateCategory(ctx context.Context, category *model
 
 func (r *SupabaseRepository) GetCategories(ctx context.Context, userID int64) ([]model.Category, error) {
 	var categories []model.Category
-	data, count, err := r.client.From("categories").
+	data, count, err := execute(ctx, r.client.From("categories").
 		Select("*", "", false).
 		Eq("user_id", strconv.FormatInt(userID, 10)).
-		Execute()
+		Is("deleted_at", "null"))
 	if err != nil {
 		return nil, err
 	}
@@ -67,7 +74,7 @@ func (r *SupabaseRepository) GetCategories(ctx context.Context, userID int64) ([
 
 func (r *SupabaseRepository) CreateTransaction(ctx context.Context, transaction *model.Transaction) error {
 	fmt.Printf("Creating transaction: %+v\n", transaction)
-	data, count, err := r.client.From("transactions").Insert(transaction, true, "", "", "").Execute()
+	data, count, err := execute(ctx, r.client.From("transactions").Insert(transaction, true, "", "", ""))
 	if err != nil {
 		fmt.Printf("Error creating transaction: %v\n", err)
 		return fmt.Errorf("failed to create transaction: %w", err)
@@ -86,16 +93,59 @@ func (r *SupabaseRepository) CreateTransaction(ctx context.Context, transaction
 	return nil
 }
 
+// CreateTransactions записывает транзакции одним запросом: вставка либо проходит целиком,
+// либо не записывает ничего
+func (r *SupabaseRepository) CreateTransactions(ctx context.Context, transactions []*model.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+	data, _, err := execute(ctx, r.client.From("transactions").Insert(transactions, true, "", "", ""))
+	if err != nil {
+		return fmt.Errorf("failed to create transactions: %w", err)
+	}
+
+	var created []model.Transaction
+	if err := json.Unmarshal(data, &created); err != nil {
+		return fmt.Errorf("failed to parse created transactions: %w", err)
+	}
+	// Строки возвращаются в порядке вставки
+	for i := range created {
+		if i < len(transactions) {
+			transactions[i].ID = created[i].ID
+			transactions[i].CreatedAt = created[i].CreatedAt
+		}
+	}
+	return nil
+}
+
 func (r *SupabaseRepository) GetTransactions(ctx context.Context, userID int64, filter model.TransactionFilter) ([]model.Transaction, error) {
 	query := r.client.From("transactions").
 		Select("*", "", false).
-		Eq("user_id", strconv.FormatInt(userID, 10))
+		Eq("user_id", strconv.FormatInt(userID, 10)).
+		Is("deleted_at", "null")
 
-	if filter.StartDate != nil {
-		query = query.Gte("date", filter.StartDate.Format(time.RFC3339))
+	// Фильтры по одной колонке перезаписывают друг друга, поэтому границы
+	// диапазонов передаются одним условием and
+	bounds := append(dateBounds(filter), amountBounds(filter)...)
+	if len(bounds) > 0 {
+		query = query.And(strings.Join(bounds, ","), "")
 	}
-	if filter.EndDate != nil {
-		query = query.Lte("date", filter.EndDate.Format(time.RFC3339))
+	if filter.Type != "" {
+		query = query.Eq("type", filter.Type)
+	}
+	if len(filter.CategoryIDs) > 0 {
+		query = query.In("category_id", filter.CategoryIDs)
+	}
+	if filter.Search != "" {
+		query = query.Ilike("description", "*"+filter.Search+"*")
+	}
+	if filter.EventID != "" {
+		query = query.Eq("event_id", filter.EventID)
+	}
+	if filter.Logical {
+		query = query.Is("parent_id", "null")
+	} else {
+		query = query.Eq("is_split", "false")
 	}
 	if filter.Limit > 0 {
 		query = query.Limit(filter.Limit, "")
@@ -104,7 +154,7 @@ func (r *SupabaseRepository) GetTransactions(ctx context.Context, userID int64,
 	// Сортируем по дате транзакции, а не по дате создания
 	query = query.Order("date", nil)
 
-	data, _, err := query.Execute()
+	data, _, err := execute(ctx, query)
 	if err != nil {
 		log.Printf("Error getting transactions: %v", err)
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
@@ -120,13 +170,71 @@ func (r *SupabaseRepository) GetTransactions(ctx context.Context, userID int64,
 	return transactions, nil
 }
 
+// dateBounds возвращает условия PostgREST для границ даты. Время передается с долями
+// секунды, иначе конец дня 23:59:59.999999999 обрезался бы до 23:59:59 и отчет терял
+// покупки последней секунды. Доли отбрасываются до микросекунд, которые хранит PostgreSQL:
+// при округлении конец дня превратился бы в полночь следующего
+func dateBounds(filter model.TransactionFilter) []string {
+	var bounds []string
+	if filter.StartDate != nil {
+		bounds = append(bounds, fmt.Sprintf("date.gte.%q", formatTimestamp(*filter.StartDate)))
+	}
+	if filter.EndDate != nil {
+		bounds = append(bounds, fmt.Sprintf("date.lte.%q", formatTimestamp(*filter.EndDate)))
+	}
+	return bounds
+}
+
+// formatTimestamp форматирует время для колонки TIMESTAMPTZ с точностью до микросекунд
+func formatTimestamp(t time.Time) string {
+	return t.Truncate(time.Microsecond).Format(time.RFC3339Nano)
+}
+
+// amountBounds возвращает условия PostgREST для границ суммы. Границы задаются без учета
+// знака, а расходы хранятся с минусом: расход "от 500" - это amount <= -500. Без типа
+// в фильтре подходят и доходы, и расходы из диапазона
+func amountBounds(filter model.TransactionFilter) []string {
+	if filter.MinAmount == nil && filter.MaxAmount == nil {
+		return nil
+	}
+	var income, expense []string
+	if filter.MinAmount != nil {
+		income = append(income, "amount.gte."+formatAmount(*filter.MinAmount))
+		expense = append(expense, "amount.lte."+formatAmount(-*filter.MinAmount))
+	}
+	if filter.MaxAmount != nil {
+		income = append(income, "amount.lte."+formatAmount(*filter.MaxAmount))
+		expense = append(expense, "amount.gte."+formatAmount(-*filter.MaxAmount))
+	}
+
+	switch filter.Type {
+	case model.TransactionIncome:
+		return income
+	case model.TransactionExpense:
+		return expense
+	}
+	return []string{"or(" + groupConditions(income) + "," + groupConditions(expense) + ")"}
+}
+
+// groupConditions объединяет условия через and, одно условие возвращается как есть
+func groupConditions(conditions []string) string {
+	if len(conditions) == 1 {
+		return conditions[0]
+	}
+	return "and(" + strings.Join(conditions, ",") + ")"
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
+
 func (r *SupabaseRepository) GetTransactionsByCategory(ctx context.Context, userID int64, categoryID string) ([]model.Transaction, error) {
 	var transactions []model.Transaction
-	data, count, err := r.client.From("transactions").
+	data, count, err := execute(ctx, r.client.From("transactions").
 		Select("*", "", false).
 		Eq("user_id", strconv.FormatInt(userID, 10)).
 		Eq("category_id", categoryID).
-		Execute()
+		Is("deleted_at", "null"))
 	if err != nil {
 		return nil, err
 	}
@@ -138,27 +246,102 @@ func (r *SupabaseRepository) GetTransactionsByCategory(ctx context.Context, user
 	return transactions, nil
 }
 
+// DeleteTransaction переносит транзакцию в корзину вместе с частями разделенного платежа
 func (r *SupabaseRepository) DeleteTransaction(ctx context.Context, id string, userID int64) error {
-	fmt.Printf("Deleting transaction %s for user %d\n", id, userID)
-	data, count, err := r.client.From("transactions").
-		Delete("", "").
-		Eq("id", id).
+	_, _, err := execute(ctx, r.client.From("transactions").
+		Update(map[string]interface{}{"deleted_at": deletedNow()}, "minimal", "").
+		Or(fmt.Sprintf("id.eq.%s,parent_id.eq.%s", id, id), "").
 		Eq("user_id", strconv.FormatInt(userID, 10)).
-		Execute()
+		Is("deleted_at", "null"))
 	if err != nil {
-		fmt.Printf("Error deleting transaction: %v\n", err)
 		return fmt.Errorf("failed to delete transaction: %w", err)
 	}
-	fmt.Printf("Transaction deleted successfully. Response data: %s, count: %d\n", string(data), count)
+	return nil
+}
+
+// UpdateTransactionCategory переносит транзакцию в другую категорию
+func (r *SupabaseRepository) UpdateTransactionCategory(ctx context.Context, id string, userID int64, categoryID string) error {
+	_, _, err := execute(ctx, r.client.From("transactions").
+		Update(map[string]interface{}{"category_id": categoryID}, "minimal", "").
+		Eq("id", id).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to update transaction category: %w", err)
+	}
+	return nil
+}
+
+// GetTransaction возвращает транзакцию пользователя или nil, если ее нет или она в корзине
+func (r *SupabaseRepository) GetTransaction(ctx context.Context, id string, userID int64) (*model.Transaction, error) {
+	data, _, err := execute(ctx, r.client.From("transactions").
+		Select("*", "", false).
+		Eq("id", id).
+		Eq("user_id", strconv.FormatInt(userID, 10)).
+		Is("deleted_at", "null"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	var transactions []model.Transaction
+	if err := json.Unmarshal(data, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to parse transaction: %w", err)
+	}
+	if len(transactions) == 0 {
+		return nil, nil
+	}
+	return &transactions[0], nil
+}
+
+// UpdateTransactionDetails сохраняет заметку и путь фото чека транзакции
+func (r *SupabaseRepository) UpdateTransactionDetails(ctx context.Context, id string, userID int64, note, attachmentPath string) error {
+	_, _, err := execute(ctx, r.client.From("transactions").
+		Update(map[string]interface{}{"note": note, "attachment_path": attachmentPath}, "minimal", "").
+		Eq("id", id).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to update transaction details: %w", err)
+	}
+	return nil
+}
+
+// UpdateTransactionBusiness сохраняет реквизиты бизнес-режима: контрагента, номер счета и НДС
+func (r *SupabaseRepository) UpdateTransactionBusiness(ctx context.Context, id string, userID int64, counterparty, invoiceNumber string, vatAmount float64) error {
+	_, _, err := execute(ctx, r.client.From("transactions").
+		Update(map[string]interface{}{
+			"counterparty":   counterparty,
+			"invoice_number": invoiceNumber,
+			"vat_amount":     vatAmount,
+		}, "minimal", "").
+		Eq("id", id).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to update transaction business details: %w", err)
+	}
+	return nil
+}
+
+// UpdateTransactionEvent привязывает транзакцию вместе с частями разделенного платежа
+// к событию, пустой eventID отвязывает
+func (r *SupabaseRepository) UpdateTransactionEvent(ctx context.Context, id string, userID int64, eventID string) error {
+	var event interface{}
+	if eventID != "" {
+		event = eventID
+	}
+	_, _, err := execute(ctx, r.client.From("transactions").
+		Update(map[string]interface{}{"event_id": event}, "minimal", "").
+		Or(fmt.Sprintf("id.eq.%s,parent_id.eq.%s", id, id), "").
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to update transaction event: %w", err)
+	}
 	return nil
 }
 
 func (r *SupabaseRepository) UpdateCategory(ctx context.Context, category *model.Category) error {
-	_, count, err := r.client.From("categories").
+	_, count, err := execute(ctx, r.client.From("categories").
 		Update(category, "", "").
 		Eq("id", category.ID).
-		Eq("user_id", strconv.FormatInt(category.UserID, 10)).
-		Execute()
+		Eq("user_id", strconv.FormatInt(category.UserID, 10)))
 	if err != nil {
 		return err
 	}
@@ -166,78 +349,66 @@ func (r *SupabaseRepository) UpdateCategory(ctx context.Context, category *model
 	return nil
 }
 
+// DeleteCategory переносит категорию в корзину вместе с ее транзакциями. Транзакции
+// получают то же время удаления, по нему RestoreCategory возвращает их вместе с категорией
 func (r *SupabaseRepository) DeleteCategory(ctx context.Context, id string, userID int64) error {
-	fmt.Printf("Deleting category %s for user %d\n", id, userID)
-
-	// Сначала удаляем все транзакции, связанные с этой категорией
-	data, count, err := r.client.From("transactions").
-		Delete("", "").
+	deletedAt := deletedNow()
+	_, _, err := execute(ctx, r.client.From("transactions").
+		Update(map[string]interface{}{"deleted_at": deletedAt}, "minimal", "").
 		Eq("category_id", id).
 		Eq("user_id", strconv.FormatInt(userID, 10)).
-		Execute()
+		Is("deleted_at", "null"))
 	if err != nil {
-		fmt.Printf("Error deleting related transactions: %v\n", err)
 		return fmt.Errorf("failed to delete related transactions: %w", err)
 	}
-	fmt.Printf("Deleted %d related transactions. Response data: %s\n", count, string(data))
 
-	// Теперь удаляем саму категорию
-	data, count, err = r.client.From("categories").
-		Delete("", "").
+	_, _, err = execute(ctx, r.client.From("categories").
+		Update(map[string]interface{}{"deleted_at": deletedAt}, "minimal", "").
 		Eq("id", id).
-		Eq("user_id", strconv.FormatInt(userID, 10)).
-		Execute()
+		Eq("user_id", strconv.FormatInt(userID, 10)))
 	if err != nil {
-		fmt.Printf("Error deleting category: %v\n", err)
 		return fmt.Errorf("failed to delete category: %w", err)
 	}
-	fmt.Printf("Category deleted successfully. Response data: %s, count: %d\n", string(data), count)
+
+	// Подкатегории остаются и становятся самостоятельными, как при ON DELETE SET NULL
+	_, _, err = execute(ctx, r.client.From("categories").
+		Update(map[string]interface{}{"parent_id": nil}, "minimal", "").
+		Eq("parent_id", id).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to detach subcategories: %w", err)
+	}
 	return nil
 }
 
-// GetAllUsers возвращает список ID всех пользователей
+// GetAllUsers возвращает список ID всех пользователей из реестра users,
+// в том числе тех, кто еще не записал ни одной транзакции
 func (r *SupabaseRepository) GetAllUsers(ctx context.Context) ([]int64, error) {
-	// Получаем уникальные user_id из таблицы transactions
-	query := r.client.From("transactions").
-		Select("user_id", "", false).
-		Not("user_id", "is", "null")
-
-	var data []byte
-	var err error
-	if data, _, err = query.Execute(); err != nil {
+	data, _, err := execute(ctx, r.client.From("users").Select("id", "", false))
+	if err != nil {
 		return nil, fmt.Errorf("failed to get users: %w", err)
 	}
 
-	// Парсим результат
 	var result []struct {
-		UserID int64 `json:"user_id"`
+		ID int64 `json:"id"`
 	}
 	if err := json.Unmarshal(data, &result); err != nil {
 		return nil, fmt.Errorf("failed to parse users: %w", err)
 	}
 
-	// Создаем map для уникальности
-	usersMap := make(map[int64]bool)
+	users := make([]int64, 0, len(result))
 	for _, r := range result {
-		usersMap[r.UserID] = true
-	}
-
-	// Преобразуем map в slice
-	users := make([]int64, 0, len(usersMap))
-	for userID := range usersMap {
-		users = append(users, userID)
+		users = append(users, r.ID)
 	}
-
 	return users, nil
 }
 
 // GetUserState возвращает текущее состояние пользователя
 func (r *SupabaseRepository) GetUserState(ctx context.Context, userID int64) (*model.UserState, error) {
 	fmt.Printf("Getting state for user %d\n", userID)
-	data, count, err := r.client.From("user_states").
+	data, count, err := execute(ctx, r.client.From("user_states").
 		Select("*", "", false).
-		Eq("user_id", strconv.FormatInt(userID, 10)).
-		Execute()
+		Eq("user_id", strconv.FormatInt(userID, 10)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user state: %w", err)
 	}
@@ -258,15 +429,16 @@ func (r *SupabaseRepository) GetUserState(ctx context.Context, userID int64) (*m
 func (r *SupabaseRepository) SaveUserState(ctx context.Context, state *model.UserState) error {
 	fmt.Printf("Saving user state: %+v\n", state)
 	state.UpdatedAt = time.Now()
-	data, count, err := r.client.From("user_states").
+	data, count, err := execute(ctx, r.client.From("user_states").
 		Upsert(map[string]interface{}{
 			"user_id":              state.UserID,
 			"selected_category_id": state.SelectedCategory,
+			"selected_account_id":  state.SelectedAccount,
 			"transaction_type":     state.TransactionType,
 			"awaiting_action":      state.AwaitingAction,
+			"payload":              state.Payload,
 			"updated_at":           state.UpdatedAt,
-		}, "", "", "user_id").
-		Execute()
+		}, "", "", "user_id"))
 	if err != nil {
 		return fmt.Errorf("failed to save user state: %w", err)
 	}
@@ -277,10 +449,9 @@ func (r *SupabaseRepository) SaveUserState(ctx context.Context, state *model.Use
 // DeleteUserState удаляет состояние пользователя
 func (r *SupabaseRepository) DeleteUserState(ctx context.Context, userID int64) error {
 	fmt.Printf("Deleting user state for user %d\n", userID)
-	data, count, err := r.client.From("user_states").
+	data, count, err := execute(ctx, r.client.From("user_states").
 		Delete("", "").
-		Eq("user_id", strconv.FormatInt(userID, 10)).
-		Execute()
+		Eq("user_id", strconv.FormatInt(userID, 10)))
 	if err != nil {
 		return fmt.Errorf("failed to delete user state: %w", err)
 	}
@@ -288,4 +459,15 @@ func (r *SupabaseRepository) DeleteUserState(ctx context.Context, userID int64)
 	return nil
 }
 
+// DeleteUserStatesBefore удаляет состояния, не обновлявшиеся с указанного времени
+func (r *SupabaseRepository) DeleteUserStatesBefore(ctx context.Context, before time.Time) error {
+	_, _, err := execute(ctx, r.client.From("user_states").
+		Delete("", "").
+		Lt("updated_at", before.Format(time.RFC3339)))
+	if err != nil {
+		return fmt.Errorf("failed to delete expired user states: %w", err)
+	}
+	return nil
+}
+
 // Реализация остальных методов репозитория...
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// GetAccounts возвращает счета пользователя
+func (r *SupabaseRepository) GetAccounts(ctx context.Context, userID int64) ([]model.Account, error) {
+	data, _, err := execute(ctx, r.client.From("accounts").
+		Select("*", "", false).
+		Eq("user_id", strconv.FormatInt(userID, 10)).
+		Order("created_at", nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+
+	var accounts []model.Account
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return nil, fmt.Errorf("failed to parse accounts: %w", err)
+	}
+	return accounts, nil
+}
+
+// CreateAccount создает новый счет
+func (r *SupabaseRepository) CreateAccount(ctx context.Context, account *model.Account) error {
+	data, _, err := execute(ctx, r.client.From("accounts").Insert(account, false, "", "", ""))
+	if err != nil {
+		return fmt.Errorf("failed to create account: %w", err)
+	}
+
+	var created []model.Account
+	if err := json.Unmarshal(data, &created); err != nil {
+		return fmt.Errorf("failed to parse created account: %w", err)
+	}
+	if len(created) > 0 {
+		account.ID = created[0].ID
+		account.CreatedAt = created[0].CreatedAt
+	}
+	return nil
+}
+
+// DeleteAccount удаляет счет; транзакции счета остаются без привязки
+func (r *SupabaseRepository) DeleteAccount(ctx context.Context, id string, userID int64) error {
+	_, _, err := execute(ctx, r.client.From("accounts").
+		Delete("", "").
+		Eq("id", id).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to delete account: %w", err)
+	}
+	return nil
+}
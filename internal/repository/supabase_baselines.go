@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// GetUserBaseline возвращает предрасчитанную статистику пользователя или nil, если ее еще нет
+func (r *SupabaseRepository) GetUserBaseline(ctx context.Context, userID int64) (*model.UserBaseline, error) {
+	data, _, err := execute(ctx, r.client.From("user_baselines").
+		Select("*", "", false).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user baseline: %w", err)
+	}
+
+	var baselines []model.UserBaseline
+	if err := json.Unmarshal(data, &baselines); err != nil {
+		return nil, fmt.Errorf("failed to parse user baseline: %w", err)
+	}
+	if len(baselines) == 0 {
+		return nil, nil
+	}
+	return &baselines[0], nil
+}
+
+// SaveUserBaseline сохраняет статистику пользователя
+func (r *SupabaseRepository) SaveUserBaseline(ctx context.Context, baseline *model.UserBaseline) error {
+	_, _, err := execute(ctx, r.client.From("user_baselines").
+		Upsert(baseline, "user_id", "minimal", ""))
+	if err != nil {
+		return fmt.Errorf("failed to save user baseline: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// CreateBroadcast сохраняет черновик рассылки
+func (r *SupabaseRepository) CreateBroadcast(ctx context.Context, broadcast *model.Broadcast) error {
+	_, _, err := execute(ctx, r.client.From("announcements").Insert(broadcast, false, "", "minimal", ""))
+	if err != nil {
+		return fmt.Errorf("failed to create broadcast: %w", err)
+	}
+	return nil
+}
+
+// GetBroadcast возвращает рассылку или nil, если ее нет
+func (r *SupabaseRepository) GetBroadcast(ctx context.Context, id string) (*model.Broadcast, error) {
+	data, _, err := execute(ctx, r.client.From("announcements").
+		Select("*", "", false).
+		Eq("id", id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get broadcast: %w", err)
+	}
+
+	var broadcasts []model.Broadcast
+	if err := json.Unmarshal(data, &broadcasts); err != nil {
+		return nil, fmt.Errorf("failed to parse broadcast: %w", err)
+	}
+	if len(broadcasts) == 0 {
+		return nil, nil
+	}
+	return &broadcasts[0], nil
+}
+
+// UpdateBroadcast сохраняет сегмент, статус и время отправки рассылки
+func (r *SupabaseRepository) UpdateBroadcast(ctx context.Context, broadcast *model.Broadcast) error {
+	_, _, err := execute(ctx, r.client.From("announcements").
+		Update(map[string]interface{}{
+			"segment":     broadcast.Segment,
+			"status":      broadcast.Status,
+			"queued_at":   broadcast.QueuedAt,
+			"finished_at": broadcast.FinishedAt,
+		}, "minimal", "").
+		Eq("id", broadcast.ID))
+	if err != nil {
+		return fmt.Errorf("failed to update broadcast: %w", err)
+	}
+	return nil
+}
+
+// GetQueuedBroadcasts возвращает подтвержденные, но еще не разосланные рассылки
+func (r *SupabaseRepository) GetQueuedBroadcasts(ctx context.Context) ([]model.Broadcast, error) {
+	data, _, err := execute(ctx, r.client.From("announcements").
+		Select("*", "", false).
+		Eq("status", model.BroadcastQueued).
+		Order("queued_at", nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queued broadcasts: %w", err)
+	}
+
+	var broadcasts []model.Broadcast
+	if err := json.Unmarshal(data, &broadcasts); err != nil {
+		return nil, fmt.Errorf("failed to parse broadcasts: %w", err)
+	}
+	return broadcasts, nil
+}
+
+// GetBroadcastDeliveries возвращает отправки рассылки пользователям
+func (r *SupabaseRepository) GetBroadcastDeliveries(ctx context.Context, broadcastID string) ([]model.BroadcastDelivery, error) {
+	data, _, err := execute(ctx, r.client.From("announcement_deliveries").
+		Select("*", "", false).
+		Eq("announcement_id", broadcastID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get broadcast deliveries: %w", err)
+	}
+
+	var deliveries []model.BroadcastDelivery
+	if err := json.Unmarshal(data, &deliveries); err != nil {
+		return nil, fmt.Errorf("failed to parse broadcast deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// SaveBroadcastDelivery отмечает отправку рассылки пользователю. Повторная запись
+// для того же пользователя перезаписывает прежнюю
+func (r *SupabaseRepository) SaveBroadcastDelivery(ctx context.Context, delivery *model.BroadcastDelivery) error {
+	_, _, err := execute(ctx, r.client.From("announcement_deliveries").
+		Upsert(delivery, "announcement_id,user_id", "minimal", ""))
+	if err != nil {
+		return fmt.Errorf("failed to save broadcast delivery: %w", err)
+	}
+	return nil
+}
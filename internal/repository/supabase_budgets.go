@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// GetBudgets возвращает месячные бюджеты пользователя по категориям
+func (r *SupabaseRepository) GetBudgets(ctx context.Context, userID int64) ([]model.Budget, error) {
+	data, _, err := execute(ctx, r.client.From("budgets").
+		Select("*", "", false).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budgets: %w", err)
+	}
+
+	var budgets []model.Budget
+	if err := json.Unmarshal(data, &budgets); err != nil {
+		return nil, fmt.Errorf("failed to parse budgets: %w", err)
+	}
+	return budgets, nil
+}
+
+// SaveBudget создает или обновляет бюджет категории
+func (r *SupabaseRepository) SaveBudget(ctx context.Context, budget *model.Budget) error {
+	budget.UpdatedAt = time.Now()
+	data, _, err := execute(ctx, r.client.From("budgets").
+		Upsert(map[string]interface{}{
+			"user_id":         budget.UserID,
+			"category_id":     budget.CategoryID,
+			"amount":          budget.Amount,
+			"rollover_since":  budget.RolloverSince,
+			"alerted_percent": budget.AlertedPercent,
+			"alerted_at":      budget.AlertedAt,
+			"updated_at":      budget.UpdatedAt,
+		}, "user_id,category_id", "", ""))
+	if err != nil {
+		return fmt.Errorf("failed to save budget: %w", err)
+	}
+
+	var saved []model.Budget
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("failed to parse saved budget: %w", err)
+	}
+	if len(saved) > 0 {
+		budget.ID = saved[0].ID
+		budget.CreatedAt = saved[0].CreatedAt
+	}
+	return nil
+}
+
+// GetGoals возвращает финансовые цели пользователя
+func (r *SupabaseRepository) GetGoals(ctx context.Context, userID int64) ([]model.Goal, error) {
+	data, _, err := execute(ctx, r.client.From("goals").
+		Select("*", "", false).
+		Eq("user_id", strconv.FormatInt(userID, 10)).
+		Order("created_at", nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goals: %w", err)
+	}
+
+	var goals []model.Goal
+	if err := json.Unmarshal(data, &goals); err != nil {
+		return nil, fmt.Errorf("failed to parse goals: %w", err)
+	}
+	return goals, nil
+}
+
+// CreateGoal создает новую финансовую цель
+func (r *SupabaseRepository) CreateGoal(ctx context.Context, goal *model.Goal) error {
+	data, _, err := execute(ctx, r.client.From("goals").Insert(goal, false, "", "", ""))
+	if err != nil {
+		return fmt.Errorf("failed to create goal: %w", err)
+	}
+
+	var created []model.Goal
+	if err := json.Unmarshal(data, &created); err != nil {
+		return fmt.Errorf("failed to parse created goal: %w", err)
+	}
+	if len(created) > 0 {
+		goal.ID = created[0].ID
+		goal.CreatedAt = created[0].CreatedAt
+	}
+	return nil
+}
+
+// UpdateGoalSaved сохраняет сумму, накопленную на цель
+func (r *SupabaseRepository) UpdateGoalSaved(ctx context.Context, id string, userID int64, saved float64) error {
+	_, _, err := execute(ctx, r.client.From("goals").
+		Update(map[string]interface{}{"saved_amount": saved}, "minimal", "").
+		Eq("id", id).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to update goal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// GetEvents возвращает события пользователя, новые первыми
+func (r *SupabaseRepository) GetEvents(ctx context.Context, userID int64) ([]model.Event, error) {
+	data, _, err := execute(ctx, r.client.From("events").
+		Select("*", "", false).
+		Eq("user_id", strconv.FormatInt(userID, 10)).
+		Order("started_at", nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+
+	var events []model.Event
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, fmt.Errorf("failed to parse events: %w", err)
+	}
+	return events, nil
+}
+
+// CreateEvent создает событие
+func (r *SupabaseRepository) CreateEvent(ctx context.Context, event *model.Event) error {
+	data, _, err := execute(ctx, r.client.From("events").Insert(event, false, "", "", ""))
+	if err != nil {
+		return fmt.Errorf("failed to create event: %w", err)
+	}
+
+	var created []model.Event
+	if err := json.Unmarshal(data, &created); err != nil {
+		return fmt.Errorf("failed to parse created event: %w", err)
+	}
+	if len(created) > 0 {
+		event.ID, event.CreatedAt = created[0].ID, created[0].CreatedAt
+	}
+	return nil
+}
+
+// UpdateEvent сохраняет название, значок, время закрытия и код приглашения события
+func (r *SupabaseRepository) UpdateEvent(ctx context.Context, event *model.Event) error {
+	var invite interface{}
+	if event.Invite != "" {
+		invite = event.Invite
+	}
+	_, _, err := execute(ctx, r.client.From("events").
+		Update(map[string]interface{}{
+			"name":        event.Name,
+			"emoji":       event.Emoji,
+			"closed_at":   event.ClosedAt,
+			"invite_code": invite,
+		}, "minimal", "").
+		Eq("id", event.ID).
+		Eq("user_id", strconv.FormatInt(event.UserID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to update event: %w", err)
+	}
+	return nil
+}
+
+// DeleteEvent удаляет событие. Транзакции остаются, база только отвязывает их от события
+func (r *SupabaseRepository) DeleteEvent(ctx context.Context, id string, userID int64) error {
+	_, _, err := execute(ctx, r.client.From("events").
+		Delete("", "").
+		Eq("id", id).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to delete event: %w", err)
+	}
+	return nil
+}
+
+// GetEvent возвращает событие по ID или nil. Участники общего события читают его
+// по ID, а не по владельцу
+func (r *SupabaseRepository) GetEvent(ctx context.Context, id string) (*model.Event, error) {
+	data, _, err := execute(ctx, r.client.From("events").
+		Select("*", "", false).
+		Eq("id", id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+
+	var events []model.Event
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, fmt.Errorf("failed to parse event: %w", err)
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+	return &events[0], nil
+}
+
+// GetEventByInvite возвращает событие по коду приглашения или nil
+func (r *SupabaseRepository) GetEventByInvite(ctx context.Context, code string) (*model.Event, error) {
+	data, _, err := execute(ctx, r.client.From("events").
+		Select("*", "", false).
+		Eq("invite_code", code))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event by invite: %w", err)
+	}
+
+	var events []model.Event
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, fmt.Errorf("failed to parse event: %w", err)
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+	return &events[0], nil
+}
+
+// GetEventMembers возвращает участников общего события, включая владельца
+func (r *SupabaseRepository) GetEventMembers(ctx context.Context, eventID string) ([]model.EventMember, error) {
+	data, _, err := execute(ctx, r.client.From("event_members").
+		Select("*", "", false).
+		Eq("event_id", eventID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event members: %w", err)
+	}
+
+	var members []model.EventMember
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, fmt.Errorf("failed to parse event members: %w", err)
+	}
+	return members, nil
+}
+
+// GetEventMemberships возвращает участие пользователя в общих событиях, последние первыми
+func (r *SupabaseRepository) GetEventMemberships(ctx context.Context, userID int64) ([]model.EventMember, error) {
+	data, _, err := execute(ctx, r.client.From("event_members").
+		Select("*", "", false).
+		Eq("user_id", strconv.FormatInt(userID, 10)).
+		Order("joined_at", nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event memberships: %w", err)
+	}
+
+	var members []model.EventMember
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, fmt.Errorf("failed to parse event memberships: %w", err)
+	}
+	return members, nil
+}
+
+// SaveEventMember добавляет участника в общее событие. Повторное вступление только
+// обновляет имя
+func (r *SupabaseRepository) SaveEventMember(ctx context.Context, member *model.EventMember) error {
+	_, _, err := execute(ctx, r.client.From("event_members").
+		Upsert(member, "event_id,user_id", "minimal", ""))
+	if err != nil {
+		return fmt.Errorf("failed to save event member: %w", err)
+	}
+	return nil
+}
+
+// GetEventPayments возвращает платежи участников общего события
+func (r *SupabaseRepository) GetEventPayments(ctx context.Context, eventID string) ([]model.EventPayment, error) {
+	data, _, err := execute(ctx, r.client.From("event_payments").
+		Select("*", "", false).
+		Eq("event_id", eventID).
+		Order("created_at", nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event payments: %w", err)
+	}
+
+	var payments []model.EventPayment
+	if err := json.Unmarshal(data, &payments); err != nil {
+		return nil, fmt.Errorf("failed to parse event payments: %w", err)
+	}
+	return payments, nil
+}
+
+// CreateEventPayment сохраняет платеж участника общего события
+func (r *SupabaseRepository) CreateEventPayment(ctx context.Context, payment *model.EventPayment) error {
+	data, _, err := execute(ctx, r.client.From("event_payments").Insert(payment, false, "", "", ""))
+	if err != nil {
+		return fmt.Errorf("failed to create event payment: %w", err)
+	}
+
+	var created []model.EventPayment
+	if err := json.Unmarshal(data, &created); err != nil {
+		return fmt.Errorf("failed to parse created event payment: %w", err)
+	}
+	if len(created) > 0 {
+		payment.ID, payment.CreatedAt = created[0].ID, created[0].CreatedAt
+	}
+	return nil
+}
+
+// DeleteEventPayment удаляет платеж участника. Удалить можно только свой платеж
+func (r *SupabaseRepository) DeleteEventPayment(ctx context.Context, id string, userID int64) error {
+	_, _, err := execute(ctx, r.client.From("event_payments").
+		Delete("", "").
+		Eq("id", id).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to delete event payment: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// GetLedgerMember возвращает участие пользователя в общем бюджете или nil
+func (r *SupabaseRepository) GetLedgerMember(ctx context.Context, memberID int64) (*model.LedgerMember, error) {
+	data, _, err := execute(ctx, r.client.From("ledger_members").
+		Select("*", "", false).
+		Eq("member_id", strconv.FormatInt(memberID, 10)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger member: %w", err)
+	}
+
+	var members []model.LedgerMember
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, fmt.Errorf("failed to parse ledger member: %w", err)
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+	return &members[0], nil
+}
+
+// GetLedgerMembers возвращает участников общего бюджета, включая владельца
+func (r *SupabaseRepository) GetLedgerMembers(ctx context.Context, ownerID int64) ([]model.LedgerMember, error) {
+	data, _, err := execute(ctx, r.client.From("ledger_members").
+		Select("*", "", false).
+		Eq("owner_id", strconv.FormatInt(ownerID, 10)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger members: %w", err)
+	}
+
+	var members []model.LedgerMember
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, fmt.Errorf("failed to parse ledger members: %w", err)
+	}
+	return members, nil
+}
+
+// SaveLedgerMember добавляет участника в общий бюджет
+func (r *SupabaseRepository) SaveLedgerMember(ctx context.Context, member *model.LedgerMember) error {
+	_, _, err := execute(ctx, r.client.From("ledger_members").
+		Upsert(member, "member_id", "minimal", ""))
+	if err != nil {
+		return fmt.Errorf("failed to save ledger member: %w", err)
+	}
+	return nil
+}
+
+// DeleteLedgerMember исключает участника из общего бюджета
+func (r *SupabaseRepository) DeleteLedgerMember(ctx context.Context, memberID int64) error {
+	_, _, err := execute(ctx, r.client.From("ledger_members").
+		Delete("", "").
+		Eq("member_id", strconv.FormatInt(memberID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to delete ledger member: %w", err)
+	}
+	return nil
+}
+
+// CreateLedgerInvite сохраняет приглашение
+func (r *SupabaseRepository) CreateLedgerInvite(ctx context.Context, invite *model.LedgerInvite) error {
+	_, _, err := execute(ctx, r.client.From("ledger_invites").
+		Insert(invite, false, "", "minimal", ""))
+	if err != nil {
+		return fmt.Errorf("failed to create ledger invite: %w", err)
+	}
+	return nil
+}
+
+// GetLedgerInvite возвращает приглашение по коду или nil
+func (r *SupabaseRepository) GetLedgerInvite(ctx context.Context, code string) (*model.LedgerInvite, error) {
+	data, _, err := execute(ctx, r.client.From("ledger_invites").
+		Select("*", "", false).
+		Eq("code", code))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger invite: %w", err)
+	}
+
+	var invites []model.LedgerInvite
+	if err := json.Unmarshal(data, &invites); err != nil {
+		return nil, fmt.Errorf("failed to parse ledger invite: %w", err)
+	}
+	if len(invites) == 0 {
+		return nil, nil
+	}
+	return &invites[0], nil
+}
+
+// DeleteLedgerInvite удаляет использованное приглашение
+func (r *SupabaseRepository) DeleteLedgerInvite(ctx context.Context, code string) error {
+	_, _, err := execute(ctx, r.client.From("ledger_invites").
+		Delete("", "").
+		Eq("code", code))
+	if err != nil {
+		return fmt.Errorf("failed to delete ledger invite: %w", err)
+	}
+	return nil
+}
+
+// GetLedgers возвращает дополнительные книги учета пользователя, новые первыми
+func (r *SupabaseRepository) GetLedgers(ctx context.Context, userID int64) ([]model.Ledger, error) {
+	data, _, err := execute(ctx, r.client.From("ledgers").
+		Select("*", "", false).
+		Eq("user_id", strconv.FormatInt(userID, 10)).
+		Order("created_at", nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledgers: %w", err)
+	}
+
+	var ledgers []model.Ledger
+	if err := json.Unmarshal(data, &ledgers); err != nil {
+		return nil, fmt.Errorf("failed to parse ledgers: %w", err)
+	}
+	return ledgers, nil
+}
+
+// CreateLedger создает книгу учета. ID и ключ данных книги назначает база
+func (r *SupabaseRepository) CreateLedger(ctx context.Context, ledger *model.Ledger) error {
+	data, _, err := execute(ctx, r.client.From("ledgers").Insert(ledger, false, "", "", ""))
+	if err != nil {
+		return fmt.Errorf("failed to create ledger: %w", err)
+	}
+
+	var created []model.Ledger
+	if err := json.Unmarshal(data, &created); err != nil {
+		return fmt.Errorf("failed to parse created ledger: %w", err)
+	}
+	if len(created) > 0 {
+		ledger.ID, ledger.DataID, ledger.CreatedAt = created[0].ID, created[0].DataID, created[0].CreatedAt
+	}
+	return nil
+}
+
+// UpdateLedger сохраняет название, значок и режим книги учета
+func (r *SupabaseRepository) UpdateLedger(ctx context.Context, ledger *model.Ledger) error {
+	_, _, err := execute(ctx, r.client.From("ledgers").
+		Update(map[string]interface{}{
+			"name":     ledger.Name,
+			"emoji":    ledger.Emoji,
+			"business": ledger.Business,
+		}, "minimal", "").
+		Eq("id", ledger.ID).
+		Eq("user_id", strconv.FormatInt(ledger.UserID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to update ledger: %w", err)
+	}
+	return nil
+}
+
+// DeleteLedger удаляет книгу учета вместе со всеми ее данными
+func (r *SupabaseRepository) DeleteLedger(ctx context.Context, id string, userID int64) error {
+	if _, err := r.rpc(ctx, "delete_ledger", map[string]interface{}{"p_id": id, "p_user_id": userID}); err != nil {
+		return fmt.Errorf("failed to delete ledger: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// migrationTimeout - предельное время одной миграции: изменение больших таблиц
+// идет дольше обычного запроса
+const migrationTimeout = 5 * time.Minute
+
+// AppliedMigrations возвращает номера миграций схемы, уже примененных к базе
+func (r *SupabaseRepository) AppliedMigrations(ctx context.Context) ([]int, error) {
+	data, _, err := execute(ctx, r.client.From("schema_migrations").
+		Select("version", "", false).
+		Order("version", nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema migrations: %w", err)
+	}
+
+	var rows []struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("failed to parse schema migrations: %w", err)
+	}
+	versions := make([]int, 0, len(rows))
+	for _, row := range rows {
+		versions = append(versions, row.Version)
+	}
+	return versions, nil
+}
+
+// ApplyMigration применяет миграцию функцией базы apply_migration: SQL и отметка
+// о применении сохраняются в одной транзакции
+func (r *SupabaseRepository) ApplyMigration(ctx context.Context, version int, name, sql string) (bool, error) {
+	data, err := r.rpcWithTimeout(ctx, "apply_migration", map[string]interface{}{
+		"p_version": version,
+		"p_name":    name,
+		"p_sql":     sql,
+	}, migrationTimeout)
+	if err != nil {
+		return false, err
+	}
+
+	var applied bool
+	if err := json.Unmarshal(data, &applied); err != nil {
+		return false, fmt.Errorf("failed to parse apply_migration result: %w", err)
+	}
+	return applied, nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// GetAssets возвращает активы и обязательства пользователя
+func (r *SupabaseRepository) GetAssets(ctx context.Context, userID int64) ([]model.Asset, error) {
+	data, _, err := execute(ctx, r.client.From("assets").
+		Select("*", "", false).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assets: %w", err)
+	}
+
+	var assets []model.Asset
+	if err := json.Unmarshal(data, &assets); err != nil {
+		return nil, fmt.Errorf("failed to parse assets: %w", err)
+	}
+	return assets, nil
+}
+
+// CreateAsset сохраняет актив или обязательство
+func (r *SupabaseRepository) CreateAsset(ctx context.Context, asset *model.Asset) error {
+	_, _, err := execute(ctx, r.client.From("assets").Insert(asset, false, "", "minimal", ""))
+	if err != nil {
+		return fmt.Errorf("failed to create asset: %w", err)
+	}
+	return nil
+}
+
+// DeleteAsset удаляет актив или обязательство
+func (r *SupabaseRepository) DeleteAsset(ctx context.Context, id string, userID int64) error {
+	_, _, err := execute(ctx, r.client.From("assets").
+		Delete("", "").
+		Eq("id", id).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to delete asset: %w", err)
+	}
+	return nil
+}
+
+// GetHoldings возвращает инвестиции пользователя
+func (r *SupabaseRepository) GetHoldings(ctx context.Context, userID int64) ([]model.Holding, error) {
+	data, _, err := execute(ctx, r.client.From("holdings").
+		Select("*", "", false).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holdings: %w", err)
+	}
+
+	var holdings []model.Holding
+	if err := json.Unmarshal(data, &holdings); err != nil {
+		return nil, fmt.Errorf("failed to parse holdings: %w", err)
+	}
+	return holdings, nil
+}
+
+// SaveHolding сохраняет инвестицию. Повторное сохранение того же тикера заменяет количество
+func (r *SupabaseRepository) SaveHolding(ctx context.Context, holding *model.Holding) error {
+	_, _, err := execute(ctx, r.client.From("holdings").
+		Upsert(holding, "user_id,kind,ticker", "minimal", ""))
+	if err != nil {
+		return fmt.Errorf("failed to save holding: %w", err)
+	}
+	return nil
+}
+
+// UpdateHoldingPrice сохраняет последнюю котировку инвестиции
+func (r *SupabaseRepository) UpdateHoldingPrice(ctx context.Context, holding *model.Holding) error {
+	_, _, err := execute(ctx, r.client.From("holdings").
+		Update(map[string]interface{}{
+			"price":     holding.Price,
+			"priced_at": holding.PricedAt,
+		}, "minimal", "").
+		Eq("id", holding.ID).
+		Eq("user_id", strconv.FormatInt(holding.UserID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to update holding price: %w", err)
+	}
+	return nil
+}
+
+// DeleteHolding удаляет инвестицию
+func (r *SupabaseRepository) DeleteHolding(ctx context.Context, id string, userID int64) error {
+	_, _, err := execute(ctx, r.client.From("holdings").
+		Delete("", "").
+		Eq("id", id).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to delete holding: %w", err)
+	}
+	return nil
+}
+
+// GetNetWorthSnapshots возвращает последние снимки капитала, начиная с самого нового.
+// При limit <= 0 возвращаются все снимки
+func (r *SupabaseRepository) GetNetWorthSnapshots(ctx context.Context, userID int64, limit int) ([]model.NetWorthSnapshot, error) {
+	query := r.client.From("net_worth_snapshots").
+		Select("*", "", false).
+		Eq("user_id", strconv.FormatInt(userID, 10)).
+		Order("month", nil)
+	if limit > 0 {
+		query = query.Limit(limit, "")
+	}
+	data, _, err := execute(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get net worth snapshots: %w", err)
+	}
+
+	var snapshots []model.NetWorthSnapshot
+	if err := json.Unmarshal(data, &snapshots); err != nil {
+		return nil, fmt.Errorf("failed to parse net worth snapshots: %w", err)
+	}
+	return snapshots, nil
+}
+
+// SaveNetWorthSnapshot сохраняет снимок капитала, заменяя снимок того же месяца
+func (r *SupabaseRepository) SaveNetWorthSnapshot(ctx context.Context, snapshot *model.NetWorthSnapshot) error {
+	_, _, err := execute(ctx, r.client.From("net_worth_snapshots").
+		Upsert(snapshot, "user_id,month", "minimal", ""))
+	if err != nil {
+		return fmt.Errorf("failed to save net worth snapshot: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// GetPlannedExpenses возвращает запланированные траты пользователя на месяцы начиная с since
+func (r *SupabaseRepository) GetPlannedExpenses(ctx context.Context, userID int64, since time.Time) ([]model.PlannedExpense, error) {
+	data, _, err := execute(ctx, r.client.From("planned_expenses").
+		Select("*", "", false).
+		Eq("user_id", strconv.FormatInt(userID, 10)).
+		Gte("month", since.Format(time.RFC3339)).
+		Order("month", nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get planned expenses: %w", err)
+	}
+
+	var plans []model.PlannedExpense
+	if err := json.Unmarshal(data, &plans); err != nil {
+		return nil, fmt.Errorf("failed to parse planned expenses: %w", err)
+	}
+	return plans, nil
+}
+
+// CreatePlannedExpense сохраняет запланированную трату
+func (r *SupabaseRepository) CreatePlannedExpense(ctx context.Context, plan *model.PlannedExpense) error {
+	data, _, err := execute(ctx, r.client.From("planned_expenses").Insert(plan, false, "", "", ""))
+	if err != nil {
+		return fmt.Errorf("failed to create planned expense: %w", err)
+	}
+
+	var created []model.PlannedExpense
+	if err := json.Unmarshal(data, &created); err != nil {
+		return fmt.Errorf("failed to parse created planned expense: %w", err)
+	}
+	if len(created) > 0 {
+		plan.ID = created[0].ID
+		plan.CreatedAt = created[0].CreatedAt
+	}
+	return nil
+}
+
+// DeletePlannedExpense удаляет запланированную трату пользователя
+func (r *SupabaseRepository) DeletePlannedExpense(ctx context.Context, id string, userID int64) error {
+	_, _, err := execute(ctx, r.client.From("planned_expenses").
+		Delete("", "").
+		Eq("id", id).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to delete planned expense: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// GetExchangeRates возвращает сохраненные курсы к валюте учета base за день date (YYYY-MM-DD)
+func (r *SupabaseRepository) GetExchangeRates(ctx context.Context, base, date string) ([]model.ExchangeRate, error) {
+	data, _, err := execute(ctx, r.client.From("exchange_rates").
+		Select("*", "", false).
+		Eq("base", base).
+		Eq("date", date))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+
+	var rates []model.ExchangeRate
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return nil, fmt.Errorf("failed to parse exchange rates: %w", err)
+	}
+	return rates, nil
+}
+
+// SaveExchangeRates сохраняет курсы за день. Повторное сохранение того же дня заменяет курсы
+func (r *SupabaseRepository) SaveExchangeRates(ctx context.Context, rates []model.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	_, _, err := execute(ctx, r.client.From("exchange_rates").
+		Upsert(rates, "date,base,currency", "minimal", ""))
+	if err != nil {
+		return fmt.Errorf("failed to save exchange rates: %w", err)
+	}
+	return nil
+}
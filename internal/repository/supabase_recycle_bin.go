@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// deletedNow возвращает время удаления для колонки deleted_at. Время округляется
+// до микросекунд, как в PostgreSQL, чтобы по нему можно было найти удаленные вместе записи
+func deletedNow() string {
+	return formatDeletedAt(time.Now())
+}
+
+func formatDeletedAt(t time.Time) string {
+	return t.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)
+}
+
+// GetDeletedTransactions возвращает транзакции из корзины, удаленные после since.
+// Части разделенных платежей не возвращаются: они восстанавливаются вместе с платежом
+func (r *SupabaseRepository) GetDeletedTransactions(ctx context.Context, userID int64, since time.Time) ([]model.Transaction, error) {
+	data, _, err := execute(ctx, r.client.From("transactions").
+		Select("*", "", false).
+		Eq("user_id", strconv.FormatInt(userID, 10)).
+		Gte("deleted_at", since.Format(time.RFC3339)).
+		Is("parent_id", "null").
+		Order("deleted_at", nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted transactions: %w", err)
+	}
+
+	var transactions []model.Transaction
+	if err := json.Unmarshal(data, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to parse deleted transactions: %w", err)
+	}
+	return transactions, nil
+}
+
+// GetDeletedCategories возвращает категории из корзины, удаленные после since
+func (r *SupabaseRepository) GetDeletedCategories(ctx context.Context, userID int64, since time.Time) ([]model.Category, error) {
+	data, _, err := execute(ctx, r.client.From("categories").
+		Select("*", "", false).
+		Eq("user_id", strconv.FormatInt(userID, 10)).
+		Gte("deleted_at", since.Format(time.RFC3339)).
+		Order("deleted_at", nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted categories: %w", err)
+	}
+
+	var categories []model.Category
+	if err := json.Unmarshal(data, &categories); err != nil {
+		return nil, fmt.Errorf("failed to parse deleted categories: %w", err)
+	}
+	return categories, nil
+}
+
+// RestoreTransaction возвращает транзакцию из корзины вместе с частями разделенного платежа
+func (r *SupabaseRepository) RestoreTransaction(ctx context.Context, id string, userID int64) error {
+	_, _, err := execute(ctx, r.client.From("transactions").
+		Update(map[string]interface{}{"deleted_at": nil}, "minimal", "").
+		Or(fmt.Sprintf("id.eq.%s,parent_id.eq.%s", id, id), "").
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to restore transaction: %w", err)
+	}
+	return nil
+}
+
+// RestoreCategory возвращает категорию из корзины и транзакции, удаленные вместе с ней.
+// Транзакции, удаленные раньше по одной, остаются в корзине
+func (r *SupabaseRepository) RestoreCategory(ctx context.Context, category *model.Category) error {
+	if category.DeletedAt == nil {
+		return nil
+	}
+	_, _, err := execute(ctx, r.client.From("categories").
+		Update(map[string]interface{}{"deleted_at": nil}, "minimal", "").
+		Eq("id", category.ID).
+		Eq("user_id", strconv.FormatInt(category.UserID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to restore category: %w", err)
+	}
+
+	_, _, err = execute(ctx, r.client.From("transactions").
+		Update(map[string]interface{}{"deleted_at": nil}, "minimal", "").
+		Eq("category_id", category.ID).
+		Eq("user_id", strconv.FormatInt(category.UserID, 10)).
+		Eq("deleted_at", formatDeletedAt(*category.DeletedAt)))
+	if err != nil {
+		return fmt.Errorf("failed to restore category transactions: %w", err)
+	}
+	category.DeletedAt = nil
+	return nil
+}
+
+// PurgeDeleted безвозвратно удаляет записи, попавшие в корзину до before, у всех
+// пользователей. Возвращает пути фото чеков удаленных транзакций
+func (r *SupabaseRepository) PurgeDeleted(ctx context.Context, before time.Time) ([]string, error) {
+	// Сначала транзакции: они ссылаются на категории
+	data, _, err := execute(ctx, r.client.From("transactions").
+		Delete("representation", "").
+		Lt("deleted_at", before.Format(time.RFC3339)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge transactions: %w", err)
+	}
+	var purged []model.Transaction
+	if err := json.Unmarshal(data, &purged); err != nil {
+		return nil, fmt.Errorf("failed to parse purged transactions: %w", err)
+	}
+
+	_, _, err = execute(ctx, r.client.From("categories").
+		Delete("minimal", "").
+		Lt("deleted_at", before.Format(time.RFC3339)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge categories: %w", err)
+	}
+
+	var attachments []string
+	for _, t := range purged {
+		if t.AttachmentPath != "" {
+			attachments = append(attachments, t.AttachmentPath)
+		}
+	}
+	return attachments, nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// GetReminders возвращает напоминания пользователя
+func (r *SupabaseRepository) GetReminders(ctx context.Context, userID int64) ([]model.Reminder, error) {
+	data, _, err := execute(ctx, r.client.From("reminders").
+		Select("*", "", false).
+		Eq("user_id", strconv.FormatInt(userID, 10)).
+		Order("created_at", nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminders: %w", err)
+	}
+
+	var reminders []model.Reminder
+	if err := json.Unmarshal(data, &reminders); err != nil {
+		return nil, fmt.Errorf("failed to parse reminders: %w", err)
+	}
+	return reminders, nil
+}
+
+// GetAllReminders возвращает напоминания всех пользователей для отправки по расписанию
+func (r *SupabaseRepository) GetAllReminders(ctx context.Context) ([]model.Reminder, error) {
+	data, _, err := execute(ctx, r.client.From("reminders").Select("*", "", false))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminders: %w", err)
+	}
+
+	var reminders []model.Reminder
+	if err := json.Unmarshal(data, &reminders); err != nil {
+		return nil, fmt.Errorf("failed to parse reminders: %w", err)
+	}
+	return reminders, nil
+}
+
+// CreateReminder сохраняет напоминание
+func (r *SupabaseRepository) CreateReminder(ctx context.Context, reminder *model.Reminder) error {
+	_, _, err := execute(ctx, r.client.From("reminders").Insert(reminder, false, "", "minimal", ""))
+	if err != nil {
+		return fmt.Errorf("failed to create reminder: %w", err)
+	}
+	return nil
+}
+
+// DeleteReminder удаляет напоминание пользователя
+func (r *SupabaseRepository) DeleteReminder(ctx context.Context, id string, userID int64) error {
+	_, _, err := execute(ctx, r.client.From("reminders").
+		Delete("", "").
+		Eq("id", id).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+	return nil
+}
+
+// MarkReminderSent запоминает время отправки напоминания, чтобы не отправить его повторно
+func (r *SupabaseRepository) MarkReminderSent(ctx context.Context, id string, sentAt time.Time) error {
+	_, _, err := execute(ctx, r.client.From("reminders").
+		Update(map[string]interface{}{"last_sent_at": sentAt}, "minimal", "").
+		Eq("id", id))
+	if err != nil {
+		return fmt.Errorf("failed to mark reminder sent: %w", err)
+	}
+	return nil
+}
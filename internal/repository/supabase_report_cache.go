@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// GetCachedReport возвращает сохраненный отчет или nil, если его нет
+func (r *SupabaseRepository) GetCachedReport(ctx context.Context, ownerID int64, key string) (*model.CachedReport, error) {
+	data, _, err := execute(ctx, r.client.From("report_cache").
+		Select("*", "", false).
+		Eq("owner_id", strconv.FormatInt(ownerID, 10)).
+		Eq("key", key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached report: %w", err)
+	}
+
+	var reports []model.CachedReport
+	if err := json.Unmarshal(data, &reports); err != nil {
+		return nil, fmt.Errorf("failed to parse cached report: %w", err)
+	}
+	if len(reports) == 0 {
+		return nil, nil
+	}
+	return &reports[0], nil
+}
+
+// SaveCachedReport сохраняет отчет, заменяя предыдущий с тем же ключом
+func (r *SupabaseRepository) SaveCachedReport(ctx context.Context, report *model.CachedReport) error {
+	_, _, err := execute(ctx, r.client.From("report_cache").
+		Upsert(report, "owner_id,key", "minimal", ""))
+	if err != nil {
+		return fmt.Errorf("failed to save cached report: %w", err)
+	}
+	return nil
+}
+
+// DeleteCachedReports удаляет все сохраненные отчеты по данным владельца бюджета
+func (r *SupabaseRepository) DeleteCachedReports(ctx context.Context, ownerID int64) error {
+	_, _, err := execute(ctx, r.client.From("report_cache").
+		Delete("", "").
+		Eq("owner_id", strconv.FormatInt(ownerID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to delete cached reports: %w", err)
+	}
+	return nil
+}
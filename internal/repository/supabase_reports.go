@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// GetReportData загружает категории и транзакции обоих периодов параллельно:
+// три последовательных запроса заметно удлиняют ответ, особенно при холодном старте
+func (r *SupabaseRepository) GetReportData(ctx context.Context, userID int64, current, previous model.TransactionFilter) (*model.ReportData, error) {
+	var (
+		data                               model.ReportData
+		wg                                 sync.WaitGroup
+		categoriesErr, currentErr, prevErr error
+	)
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		data.Categories, categoriesErr = r.GetCategories(ctx, userID)
+	}()
+	go func() {
+		defer wg.Done()
+		data.Current, currentErr = r.GetTransactions(ctx, userID, current)
+	}()
+	go func() {
+		defer wg.Done()
+		data.Previous, prevErr = r.GetTransactions(ctx, userID, previous)
+	}()
+	wg.Wait()
+
+	if categoriesErr != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", categoriesErr)
+	}
+	if currentErr != nil {
+		return nil, fmt.Errorf("failed to get current period transactions: %w", currentErr)
+	}
+	if prevErr != nil {
+		return nil, fmt.Errorf("failed to get previous period transactions: %w", prevErr)
+	}
+	return &data, nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// GetUserSettings возвращает настройки пользователя или nil, если они еще не сохранялись
+func (r *SupabaseRepository) GetUserSettings(ctx context.Context, userID int64) (*model.UserSettings, error) {
+	data, _, err := execute(ctx, r.client.From("user_settings").
+		Select("*", "", false).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user settings: %w", err)
+	}
+
+	var settings []model.UserSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse user settings: %w", err)
+	}
+	if len(settings) == 0 {
+		return nil, nil
+	}
+	return &settings[0], nil
+}
+
+// SaveUserSettings сохраняет настройки пользователя
+func (r *SupabaseRepository) SaveUserSettings(ctx context.Context, settings *model.UserSettings) error {
+	_, _, err := execute(ctx, r.client.From("user_settings").
+		Upsert(settings, "user_id", "minimal", ""))
+	if err != nil {
+		return fmt.Errorf("failed to save user settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// SupabaseStorage хранит файлы в бакете Supabase Storage. Запросы выполняются
+// напрямую, как rpc: клиент Storage не принимает контекст и меняет общие заголовки
+// при каждой загрузке
+type SupabaseStorage struct {
+	objectURL string // адрес объектов бакета: .../storage/v1/object/<бакет>
+	key       string
+}
+
+// NewSupabaseStorage создает хранилище файлов в бакете проекта Supabase. Бакет
+// должен быть приватным: файлы отдаются только через ключ сервиса
+func NewSupabaseStorage(url, key, bucket string) *SupabaseStorage {
+	return &SupabaseStorage{
+		objectURL: strings.TrimRight(url, "/") + "/storage/v1/object/" + bucket,
+		key:       key,
+	}
+}
+
+// Upload сохраняет файл, заменяя существующий по тому же пути
+func (s *SupabaseStorage) Upload(ctx context.Context, path string, data []byte, contentType string) error {
+	header := http.Header{}
+	header.Set("Content-Type", contentType)
+	header.Set("x-upsert", "true")
+	if _, err := s.do(ctx, http.MethodPost, "/"+path, bytes.NewReader(data), header); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", path, err)
+	}
+	return nil
+}
+
+// Download возвращает содержимое файла
+func (s *SupabaseStorage) Download(ctx context.Context, path string) ([]byte, error) {
+	data, err := s.do(ctx, http.MethodGet, "/"+path, nil, http.Header{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// Delete удаляет файлы. Отсутствующие файлы пропускаются
+func (s *SupabaseStorage) Delete(ctx context.Context, paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(map[string][]string{"prefixes": paths})
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	if _, err := s.do(ctx, http.MethodDelete, "", bytes.NewReader(body), header); err != nil {
+		return fmt.Errorf("failed to delete files: %w", err)
+	}
+	return nil
+}
+
+// do выполняет запрос к Storage и возвращает тело ответа. Статус ответа переводится
+// в вид ошибки из model так же, как в rpc
+func (s *SupabaseStorage) do(ctx context.Context, method, path string, body io.Reader, header http.Header) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+	req.Header.Set("apikey", s.key)
+	req.Header.Set("Authorization", "Bearer "+s.key)
+
+	resp, err := rpcClient.Do(req)
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	if resp.StatusCode < 300 {
+		return data, nil
+	}
+
+	err = fmt.Errorf("storage responded with status %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	switch {
+	case resp.StatusCode == http.StatusNotFound || bytes.Contains(data, []byte("not_found")):
+		// Storage отвечает на отсутствующий объект и статусом 400 с кодом not_found
+		return nil, fmt.Errorf("%w: %w", model.ErrNotFound, err)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, fmt.Errorf("%w: %w", model.ErrRateLimited, err)
+	case resp.StatusCode >= 500:
+		return nil, fmt.Errorf("%w: %w", model.ErrStorageUnavailable, err)
+	case resp.StatusCode == http.StatusRequestEntityTooLarge:
+		return nil, fmt.Errorf("%w: %w", model.ErrValidation, err)
+	}
+	return nil, err
+}
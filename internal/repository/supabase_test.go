@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+func TestAmountBounds(t *testing.T) {
+	amount := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name   string
+		filter model.TransactionFilter
+		want   []string
+	}{
+		{
+			name:   "без границ",
+			filter: model.TransactionFilter{Type: model.TransactionExpense},
+		},
+		{
+			name:   "расходы от 500 до 1000",
+			filter: model.TransactionFilter{Type: model.TransactionExpense, MinAmount: amount(500), MaxAmount: amount(1000)},
+			want:   []string{"amount.lte.-500", "amount.gte.-1000"},
+		},
+		{
+			name:   "доходы от 500 до 1000",
+			filter: model.TransactionFilter{Type: model.TransactionIncome, MinAmount: amount(500), MaxAmount: amount(1000)},
+			want:   []string{"amount.gte.500", "amount.lte.1000"},
+		},
+		{
+			name:   "расходы до 99.5",
+			filter: model.TransactionFilter{Type: model.TransactionExpense, MaxAmount: amount(99.5)},
+			want:   []string{"amount.gte.-99.5"},
+		},
+		{
+			name:   "любой тип от 500 до 1000",
+			filter: model.TransactionFilter{MinAmount: amount(500), MaxAmount: amount(1000)},
+			want:   []string{"or(and(amount.gte.500,amount.lte.1000),and(amount.lte.-500,amount.gte.-1000))"},
+		},
+		{
+			name:   "любой тип от 500",
+			filter: model.TransactionFilter{MinAmount: amount(500)},
+			want:   []string{"or(amount.gte.500,amount.lte.-500)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := amountBounds(tt.filter); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("amountBounds() = %q, ожидалось %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateBounds(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, msk)
+	end := time.Date(2026, 3, 31, 23, 59, 59, 999999999, msk)
+
+	got := dateBounds(model.TransactionFilter{StartDate: &start, EndDate: &end})
+	want := []string{`date.gte."2026-03-01T00:00:00+03:00"`, `date.lte."2026-03-31T23:59:59.999999+03:00"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dateBounds() = %q, ожидалось %q", got, want)
+	}
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// uniqueViolation - код ошибки PostgreSQL при нарушении уникальности. Ищется внутри
+// текста: execute добавляет перед ним вид ошибки
+const uniqueViolation = "(23505)"
+
+// MarkUpdateProcessed запоминает обновление. Возвращает false, если оно уже было записано
+func (r *SupabaseRepository) MarkUpdateProcessed(ctx context.Context, update *model.ProcessedUpdate) (bool, error) {
+	_, _, err := execute(ctx, r.client.From("processed_updates").Insert(update, false, "", "minimal", ""))
+	if err != nil {
+		if strings.Contains(err.Error(), uniqueViolation) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to mark update processed: %w", err)
+	}
+	return true, nil
+}
+
+// DeleteProcessedUpdate забывает обновление, чтобы повторная доставка обработала его заново
+func (r *SupabaseRepository) DeleteProcessedUpdate(ctx context.Context, updateID int64) error {
+	_, _, err := execute(ctx, r.client.From("processed_updates").
+		Delete("", "").
+		Eq("update_id", strconv.FormatInt(updateID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to delete processed update: %w", err)
+	}
+	return nil
+}
+
+// DeleteProcessedUpdates удаляет записи об обновлениях, обработанных до указанного времени
+func (r *SupabaseRepository) DeleteProcessedUpdates(ctx context.Context, before time.Time) error {
+	_, _, err := execute(ctx, r.client.From("processed_updates").
+		Delete("", "").
+		Lt("processed_at", before.Format(time.RFC3339)))
+	if err != nil {
+		return fmt.Errorf("failed to delete processed updates: %w", err)
+	}
+	return nil
+}
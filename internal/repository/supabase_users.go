@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// SaveUser добавляет пользователя или обновляет его профиль и время активности.
+// Дата регистрации задается базой при первой записи и не перезаписывается
+func (r *SupabaseRepository) SaveUser(ctx context.Context, user *model.User) error {
+	row := map[string]interface{}{
+		"id":            user.ID,
+		"username":      user.Username,
+		"first_name":    user.FirstName,
+		"language_code": user.LanguageCode,
+		"last_seen":     user.LastSeen.Format(time.RFC3339),
+	}
+	_, _, err := execute(ctx, r.client.From("users").Upsert(row, "id", "minimal", ""))
+	if err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+	return nil
+}
+
+// GetUsers возвращает всех зарегистрированных пользователей
+func (r *SupabaseRepository) GetUsers(ctx context.Context) ([]model.User, error) {
+	data, _, err := execute(ctx, r.client.From("users").Select("*", "", false))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	var users []model.User
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("failed to parse users: %w", err)
+	}
+	return users, nil
+}
+
+// GetUser возвращает пользователя из реестра или nil, если он не зарегистрирован
+func (r *SupabaseRepository) GetUser(ctx context.Context, userID int64) (*model.User, error) {
+	data, _, err := execute(ctx, r.client.From("users").
+		Select("*", "", false).
+		Eq("id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	var users []model.User
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("failed to parse user: %w", err)
+	}
+	if len(users) == 0 {
+		return nil, nil
+	}
+	return &users[0], nil
+}
+
+// SetMenuSession сохраняет номер сессии меню пользователя
+func (r *SupabaseRepository) SetMenuSession(ctx context.Context, userID int64, session int) error {
+	_, _, err := execute(ctx, r.client.From("users").
+		Update(map[string]interface{}{"menu_session": session}, "minimal", "").
+		Eq("id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to save menu session: %w", err)
+	}
+	return nil
+}
+
+// DeleteUserData удаляет все данные пользователя одной транзакцией (функция delete_user_data)
+func (r *SupabaseRepository) DeleteUserData(ctx context.Context, userID int64) error {
+	if _, err := r.rpc(ctx, "delete_user_data", map[string]int64{"p_user_id": userID}); err != nil {
+		return fmt.Errorf("failed to delete user data: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// GetWebhooks возвращает webhook'и пользователя
+func (r *SupabaseRepository) GetWebhooks(ctx context.Context, userID int64) ([]model.Webhook, error) {
+	data, _, err := execute(ctx, r.client.From("webhooks").
+		Select("*", "", false).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	var webhooks []model.Webhook
+	if err := json.Unmarshal(data, &webhooks); err != nil {
+		return nil, fmt.Errorf("failed to parse webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// CreateWebhook сохраняет webhook
+func (r *SupabaseRepository) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	_, _, err := execute(ctx, r.client.From("webhooks").Insert(webhook, false, "", "minimal", ""))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return nil
+}
+
+// DeleteWebhook удаляет webhook
+func (r *SupabaseRepository) DeleteWebhook(ctx context.Context, id string, userID int64) error {
+	_, _, err := execute(ctx, r.client.From("webhooks").
+		Delete("", "").
+		Eq("id", id).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
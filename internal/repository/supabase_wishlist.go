@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// GetWishlist возвращает список желаний пользователя в порядке добавления
+func (r *SupabaseRepository) GetWishlist(ctx context.Context, userID int64) ([]model.WishlistItem, error) {
+	data, _, err := execute(ctx, r.client.From("wishlist_items").
+		Select("*", "", false).
+		Eq("user_id", strconv.FormatInt(userID, 10)).
+		Order("created_at", nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wishlist: %w", err)
+	}
+
+	var items []model.WishlistItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse wishlist: %w", err)
+	}
+	return items, nil
+}
+
+// CreateWishlistItem добавляет покупку в список желаний
+func (r *SupabaseRepository) CreateWishlistItem(ctx context.Context, item *model.WishlistItem) error {
+	data, _, err := execute(ctx, r.client.From("wishlist_items").Insert(item, false, "", "", ""))
+	if err != nil {
+		return fmt.Errorf("failed to create wishlist item: %w", err)
+	}
+
+	var created []model.WishlistItem
+	if err := json.Unmarshal(data, &created); err != nil {
+		return fmt.Errorf("failed to parse created wishlist item: %w", err)
+	}
+	if len(created) > 0 {
+		item.ID = created[0].ID
+		item.CreatedAt = created[0].CreatedAt
+	}
+	return nil
+}
+
+// DeleteWishlistItem удаляет покупку из списка желаний вместе с отложенными суммами
+func (r *SupabaseRepository) DeleteWishlistItem(ctx context.Context, id string, userID int64) error {
+	_, _, err := execute(ctx, r.client.From("wishlist_items").
+		Delete("", "").
+		Eq("id", id).
+		Eq("user_id", strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to delete wishlist item: %w", err)
+	}
+	return nil
+}
+
+// GetWishlistAllocations возвращает все суммы, отложенные пользователем на покупки
+func (r *SupabaseRepository) GetWishlistAllocations(ctx context.Context, userID int64) ([]model.WishlistAllocation, error) {
+	data, _, err := execute(ctx, r.client.From("wishlist_allocations").
+		Select("*", "", false).
+		Eq("user_id", strconv.FormatInt(userID, 10)).
+		Order("created_at", nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wishlist allocations: %w", err)
+	}
+
+	var allocations []model.WishlistAllocation
+	if err := json.Unmarshal(data, &allocations); err != nil {
+		return nil, fmt.Errorf("failed to parse wishlist allocations: %w", err)
+	}
+	return allocations, nil
+}
+
+// CreateWishlistAllocation сохраняет сумму, отложенную на покупку
+func (r *SupabaseRepository) CreateWishlistAllocation(ctx context.Context, allocation *model.WishlistAllocation) error {
+	_, _, err := execute(ctx, r.client.From("wishlist_allocations").Insert(allocation, false, "", "minimal", ""))
+	if err != nil {
+		return fmt.Errorf("failed to create wishlist allocation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package sentry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clientName передается Sentry в заголовке авторизации
+const clientName = "financial_bot/1.0"
+
+// Event - ошибка обработчика с контекстом обновления
+type Event struct {
+	// Err - ошибка обработчика; для паник - значение panic
+	Err error
+	// Stack - стек горутины, для паник
+	Stack []byte
+	// UserID - пользователь Telegram, 0 - неизвестен
+	UserID int64
+	// Command - команда или данные нажатой кнопки
+	Command string
+	// Payload - текст сообщения или inline-запроса
+	Payload string
+}
+
+// Client отправляет ошибки в Sentry через HTTP API без SDK
+type Client struct {
+	endpoint string
+	key      string
+	client   *http.Client
+}
+
+// NewClient создает клиент по DSN вида https://<key>@<host>/<project>
+func NewClient(dsn string) (*Client, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sentry dsn: %w", err)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("sentry dsn has no public key")
+	}
+
+	path := strings.Trim(u.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	project := path[slash+1:]
+	if _, err := strconv.Atoi(project); err != nil {
+		return nil, fmt.Errorf("sentry dsn has invalid project id %q", project)
+	}
+	prefix := ""
+	if slash >= 0 {
+		prefix = "/" + path[:slash]
+	}
+
+	return &Client{
+		endpoint: fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, project),
+		key:      u.User.Username(),
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+// Capture отправляет событие об ошибке
+func (c *Client) Capture(ctx context.Context, event Event) error {
+	body, err := json.Marshal(c.payload(event))
+	if err != nil {
+		return fmt.Errorf("failed to encode sentry event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s", clientName, c.key))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send sentry event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// payload собирает событие в формате Sentry
+func (c *Client) payload(event Event) map[string]interface{} {
+	level, kind := "error", "error"
+	if len(event.Stack) > 0 {
+		level, kind = "fatal", "panic"
+	}
+	message := "unknown error"
+	if event.Err != nil {
+		message = event.Err.Error()
+	}
+
+	payload := map[string]interface{}{
+		"event_id":  eventID(),
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"platform":  "go",
+		"level":     level,
+		"logger":    "bot",
+		"exception": map[string]interface{}{
+			"values": []map[string]interface{}{{"type": kind, "value": message}},
+		},
+		"tags":  map[string]string{"command": event.Command},
+		"extra": map[string]string{"payload": event.Payload, "stack": string(event.Stack)},
+	}
+	if event.UserID != 0 {
+		payload["user"] = map[string]string{"id": strconv.FormatInt(event.UserID, 10)}
+	}
+	return payload
+}
+
+// eventID возвращает случайный идентификатор события: 32 шестнадцатеричных символа
+func eventID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
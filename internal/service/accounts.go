@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// GetAccounts возвращает счета пользователя
+func (s *ExpenseTracker) GetAccounts(ctx context.Context, userID int64) ([]model.Account, error) {
+	return s.repo.GetAccounts(ctx, userID)
+}
+
+// CreateAccount создает счет с начальным остатком
+func (s *ExpenseTracker) CreateAccount(ctx context.Context, userID int64, name, accountType string, initialBalance float64) (*model.Account, error) {
+	switch accountType {
+	case model.AccountTypeCash, model.AccountTypeCard, model.AccountTypeDeposit:
+	default:
+		return nil, fmt.Errorf("unknown account type: %s", accountType)
+	}
+
+	account := &model.Account{
+		UserID:         userID,
+		Name:           name,
+		Type:           accountType,
+		InitialBalance: initialBalance,
+		CreatedAt:      time.Now(),
+	}
+	if err := s.repo.CreateAccount(ctx, account); err != nil {
+		return nil, err
+	}
+	markWrote(ctx)
+	return account, nil
+}
+
+// DeleteAccount удаляет счет
+func (s *ExpenseTracker) DeleteAccount(ctx context.Context, accountID string, userID int64) error {
+	return s.repo.DeleteAccount(ctx, accountID, userID)
+}
+
+// GetAccountBalances рассчитывает текущие остатки по всем счетам пользователя
+func (s *ExpenseTracker) GetAccountBalances(ctx context.Context, userID int64) ([]model.AccountBalance, error) {
+	accounts, err := s.repo.GetAccounts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	if len(accounts) == 0 {
+		return nil, nil
+	}
+
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	totals := make(map[string]float64)
+	for _, t := range transactions {
+		if t.AccountID != "" {
+			totals[t.AccountID] += t.Amount
+		}
+	}
+
+	balances := make([]model.AccountBalance, 0, len(accounts))
+	for _, account := range accounts {
+		balances = append(balances, model.AccountBalance{
+			Account: account,
+			Balance: account.InitialBalance + totals[account.ID],
+		})
+	}
+	return balances, nil
+}
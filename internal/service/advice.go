@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+const (
+	// adviceCutPercent - доля расходов категории, которую предлагаем перенести в накопления
+	adviceCutPercent = 10.0
+	// adviceHistoryMonths - количество полных месяцев для расчета средних значений
+	adviceHistoryMonths = 3
+	// maxAdviceCount - максимальное количество рекомендаций в отчете
+	maxAdviceCount = 3
+)
+
+// SavingsAdvice представляет рекомендацию по экономии в категории
+type SavingsAdvice struct {
+	CategoryID      string
+	CategoryName    string
+	AvgMonthly      float64 // средние расходы категории в месяц
+	MonthlySaving   float64 // ожидаемая экономия в месяц
+	SuggestedBudget float64 // предлагаемый месячный бюджет категории
+	TrendPercent    float64 // изменение последнего месяца относительно предыдущих
+	Text            string
+}
+
+// GetBudgets возвращает бюджеты пользователя
+func (s *ExpenseTracker) GetBudgets(ctx context.Context, userID int64) ([]model.Budget, error) {
+	return s.repo.GetBudgets(ctx, userID)
+}
+
+// SetBudget устанавливает месячный бюджет категории расходов из текущей книги пользователя
+func (s *ExpenseTracker) SetBudget(ctx context.Context, userID int64, categoryID string, amount float64) error {
+	if amount <= 0 {
+		return fmt.Errorf("%w: budget amount must be positive", model.ErrValidation)
+	}
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+	category := findCategory(categories, categoryID)
+	if category == nil {
+		return fmt.Errorf("category %s %w", categoryID, model.ErrNotFound)
+	}
+	if category.Type != model.TransactionExpense {
+		return ErrCategoryType
+	}
+	budget := model.Budget{UserID: userID, CategoryID: categoryID}
+	if existing, err := s.findBudget(ctx, userID, categoryID); err == nil {
+		// Новая сумма не выключает перенос остатка
+		budget = *existing
+	} else if !errors.Is(err, model.ErrNotFound) {
+		return err
+	}
+	budget.Amount = amount
+	// С новой суммой пороги исполнения считаются заново
+	budget.AlertedPercent, budget.AlertedAt = 0, nil
+	return s.repo.SaveBudget(ctx, &budget)
+}
+
+// GetGoals возвращает финансовые цели пользователя
+func (s *ExpenseTracker) GetGoals(ctx context.Context, userID int64) ([]model.Goal, error) {
+	return s.repo.GetGoals(ctx, userID)
+}
+
+// CreateGoal создает финансовую цель
+func (s *ExpenseTracker) CreateGoal(ctx context.Context, userID int64, name string, target float64) error {
+	if target <= 0 {
+		return fmt.Errorf("%w: goal amount must be positive", model.ErrValidation)
+	}
+	if err := s.repo.CreateGoal(ctx, &model.Goal{
+		UserID:       userID,
+		Name:         name,
+		TargetAmount: target,
+		CreatedAt:    s.now(),
+	}); err != nil {
+		return err
+	}
+	markWrote(ctx)
+	return nil
+}
+
+// AddGoalSavings записывает сумму, отложенную на цель, и возвращает обновленную цель
+func (s *ExpenseTracker) AddGoalSavings(ctx context.Context, userID int64, goalID string, amount float64) (*model.Goal, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: savings amount must be positive", model.ErrValidation)
+	}
+	goals, err := s.repo.GetGoals(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goals: %w", err)
+	}
+	var goal *model.Goal
+	for i := range goals {
+		if goals[i].ID == goalID {
+			goal = &goals[i]
+			break
+		}
+	}
+	if goal == nil {
+		return nil, fmt.Errorf("goal %s not found: %w", goalID, model.ErrNotFound)
+	}
+
+	goal.SavedAmount += amount
+	if err := s.repo.UpdateGoalSaved(ctx, goal.ID, userID, goal.SavedAmount); err != nil {
+		return nil, fmt.Errorf("failed to update goal: %w", err)
+	}
+	markWrote(ctx)
+	return goal, nil
+}
+
+// GetSavingsAdvice формирует рекомендации по экономии на основе трендов, бюджетов и целей
+func (s *ExpenseTracker) GetSavingsAdvice(ctx context.Context, userID int64) ([]SavingsAdvice, error) {
+	now := time.Now()
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	startDate := currentMonth.AddDate(0, -adviceHistoryMonths, 0)
+	endDate := currentMonth.Add(-time.Nanosecond)
+
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{
+		StartDate: &startDate,
+		EndDate:   &endDate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	if len(transactions) == 0 {
+		return nil, nil
+	}
+
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	budgets, err := s.repo.GetBudgets(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budgets: %w", err)
+	}
+	goals, err := s.repo.GetGoals(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goals: %w", err)
+	}
+
+	categoryNames := make(map[string]string)
+	for _, cat := range categories {
+		categoryNames[cat.ID] = cat.Name
+	}
+	budgetByCategory := make(map[string]float64)
+	for _, b := range budgets {
+		budgetByCategory[b.CategoryID] = b.Amount
+	}
+
+	// Суммируем расходы по категориям: за весь период и отдельно за последний месяц
+	lastMonth := currentMonth.AddDate(0, -1, 0)
+	totalByCategory := make(map[string]float64)
+	lastByCategory := make(map[string]float64)
+	var totalIncome, totalExpenses float64
+	for _, t := range transactions {
+		if t.IsIncome() {
+			totalIncome += t.AbsAmount()
+			continue
+		}
+		expense := t.AbsAmount()
+		totalExpenses += expense
+		totalByCategory[t.CategoryID] += expense
+		if !t.Date.Before(lastMonth) {
+			lastByCategory[t.CategoryID] += expense
+		}
+	}
+
+	monthlySavings := (totalIncome - totalExpenses) / adviceHistoryMonths
+
+	// Выбираем цель, до которой осталось накопить больше всего
+	var goal *model.Goal
+	for i := range goals {
+		if goals[i].Remaining() > 0 && (goal == nil || goals[i].Remaining() > goal.Remaining()) {
+			goal = &goals[i]
+		}
+	}
+
+	advices := make([]SavingsAdvice, 0)
+	for categoryID, total := range totalByCategory {
+		avgMonthly := total / adviceHistoryMonths
+		suggested := math.Round(avgMonthly * (100 - adviceCutPercent) / 100)
+		if suggested <= 0 {
+			continue
+		}
+		// Уже действующий бюджет не выше предлагаемого - советовать нечего
+		if current, ok := budgetByCategory[categoryID]; ok && current <= suggested {
+			continue
+		}
+
+		// Сравниваем последний месяц со средним за предыдущие
+		prevAvg := (total - lastByCategory[categoryID]) / (adviceHistoryMonths - 1)
+		trend := 0.0
+		if prevAvg > 0 {
+			trend = calculateTrendPercent(lastByCategory[categoryID], prevAvg)
+		}
+
+		advice := SavingsAdvice{
+			CategoryID:      categoryID,
+			CategoryName:    categoryNames[categoryID],
+			AvgMonthly:      avgMonthly,
+			MonthlySaving:   avgMonthly - suggested,
+			SuggestedBudget: suggested,
+			TrendPercent:    trend,
+		}
+		advice.Text = formatAdviceText(advice, goal, monthlySavings)
+		advices = append(advices, advice)
+	}
+
+	// В приоритете растущие категории, затем самые крупные
+	sort.Slice(advices, func(i, j int) bool {
+		if (advices[i].TrendPercent > 0) != (advices[j].TrendPercent > 0) {
+			return advices[i].TrendPercent > 0
+		}
+		return advices[i].AvgMonthly > advices[j].AvgMonthly
+	})
+
+	if len(advices) > maxAdviceCount {
+		advices = advices[:maxAdviceCount]
+	}
+	return advices, nil
+}
+
+// formatAdviceText формирует текст рекомендации с учетом цели пользователя
+func formatAdviceText(advice SavingsAdvice, goal *model.Goal, monthlySavings float64) string {
+	text := fmt.Sprintf("перенос %.0f%% из «%s» (~%.0f₽ в месяц)",
+		adviceCutPercent, advice.CategoryName, advice.MonthlySaving)
+
+	if goal != nil && monthlySavings > 0 {
+		monthsBefore := math.Ceil(goal.Remaining() / monthlySavings)
+		monthsAfter := math.Ceil(goal.Remaining() / (monthlySavings + advice.MonthlySaving))
+		if diff := int(monthsBefore - monthsAfter); diff > 0 {
+			return fmt.Sprintf("%s закроет цель «%s» на %d %s раньше",
+				text, goal.Name, diff, pluralMonths(diff))
+		}
+	}
+
+	text = fmt.Sprintf("%s сэкономит %.0f₽ за год", text, advice.MonthlySaving*12)
+	if advice.TrendPercent > 0 {
+		text += fmt.Sprintf(", расходы в категории растут (+%.0f%%)", advice.TrendPercent)
+	}
+	return text
+}
+
+// pluralMonths возвращает слово "месяц" в нужной форме
+func pluralMonths(n int) string {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return "месяц"
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20):
+		return "месяца"
+	default:
+		return "месяцев"
+	}
+}
@@ -0,0 +1,66 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+	"github.com/ivanoskov/financial_bot/internal/service/mocks"
+)
+
+func TestSetBudgetChecksCategory(t *testing.T) {
+	repo := &mocks.Repository{
+		GetCategoriesFunc: func(ctx context.Context, userID int64) ([]model.Category, error) {
+			return []model.Category{
+				{ID: "food", Name: "Продукты", Type: model.TransactionExpense},
+				{ID: "salary", Name: "Зарплата", Type: model.TransactionIncome},
+			}, nil
+		},
+	}
+	tracker := service.NewExpenseTracker(repo)
+	ctx := context.Background()
+
+	if err := tracker.SetBudget(ctx, 1, "food", 15000); err != nil {
+		t.Fatalf("SetBudget: %v", err)
+	}
+	if err := tracker.SetBudget(ctx, 1, "foreign", 15000); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("бюджет чужой категории: %v, ожидалось ErrNotFound", err)
+	}
+	if err := tracker.SetBudget(ctx, 1, "salary", 15000); !errors.Is(err, model.ErrValidation) {
+		t.Errorf("бюджет категории доходов: %v, ожидалась ошибка проверки", err)
+	}
+	if calls := repo.CallsOf("SaveBudget"); len(calls) != 1 {
+		t.Errorf("сохранено бюджетов: %d, ожидался 1", len(calls))
+	}
+}
+
+func TestAddGoalSavings(t *testing.T) {
+	goals := []model.Goal{{ID: "trip", UserID: 1, Name: "Отпуск", TargetAmount: 100000, SavedAmount: 20000}}
+	repo := &mocks.Repository{
+		GetGoalsFunc: func(ctx context.Context, userID int64) ([]model.Goal, error) {
+			return append([]model.Goal(nil), goals...), nil
+		},
+	}
+	tracker := service.NewExpenseTracker(repo)
+
+	goal, err := tracker.AddGoalSavings(context.Background(), 1, "trip", 5000)
+	if err != nil {
+		t.Fatalf("AddGoalSavings: %v", err)
+	}
+	if goal.SavedAmount != 25000 || goal.Remaining() != 75000 {
+		t.Errorf("накоплено %.0f, осталось %.0f; ожидалось 25000 и 75000", goal.SavedAmount, goal.Remaining())
+	}
+	calls := repo.CallsOf("UpdateGoalSaved")
+	if len(calls) != 1 || calls[0].Args[0] != "trip" || calls[0].Args[2] != 25000.0 {
+		t.Errorf("сохранено %v, ожидалось 25000 для цели trip", calls)
+	}
+
+	if _, err := tracker.AddGoalSavings(context.Background(), 1, "car", 5000); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("неизвестная цель: %v, ожидалось ErrNotFound", err)
+	}
+	if _, err := tracker.AddGoalSavings(context.Background(), 1, "trip", 0); !errors.Is(err, model.ErrValidation) {
+		t.Errorf("нулевая сумма: %v, ожидалась ошибка проверки", err)
+	}
+}
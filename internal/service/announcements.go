@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// Announcement - объявление о новых возможностях бота
+type Announcement struct {
+	Version int
+	Title   string
+	Text    string
+}
+
+// announcements - история объявлений "Что нового", новые добавляются в конец
+// с увеличением версии. Пользователь увидит каждое объявление один раз
+var announcements = []Announcement{
+	{
+		Version: 1,
+		Title:   "Рекомендации и бюджеты",
+		Text:    "Бот подскажет, где можно сэкономить (/advice), поможет установить бюджеты категорий (/budgets) и копить на цели (/goal)",
+	},
+	{
+		Version: 2,
+		Title:   "Сверка с банком",
+		Text:    "Пришлите выписку в формате CSV - бот найдет операции, которые вы забыли записать",
+	},
+	{
+		Version: 3,
+		Title:   "Чеки по QR-коду",
+		Text:    "Сфотографируйте QR-код чека - покупка запишется одной суммой или по позициям",
+	},
+	{
+		Version: 4,
+		Title:   "Счета",
+		Text:    "Ведите наличные, карты и вклады отдельно и смотрите остатки по каждому счету: /balance",
+	},
+	{
+		Version: 5,
+		Title:   "Общий бюджет",
+		Text:    "Пригласите близких в общий бюджет и ведите учет вместе: /family",
+	},
+	{
+		Version: 6,
+		Title:   "Разделение покупок",
+		Text:    "Одну покупку можно разнести по нескольким категориям: `5000 Ашан: 3000 продукты, 2000 хозтовары`",
+	},
+	{
+		Version: 7,
+		Title:   "Капитал",
+		Text:    "Следите за капиталом по месяцам: счета, имущество и долги в одном месте - /networth",
+	},
+	{
+		Version: 8,
+		Title:   "Выгрузка",
+		Text:    "Команда /export пришлет все транзакции CSV-файлом, а основные команды теперь есть в меню Telegram",
+	},
+	{
+		Version: 9,
+		Title:   "Запись одной командой",
+		Text:    "`/add 500 такси` или `/add +50000 зарплата` - бот сам подберет категорию, а изменить ее можно одной кнопкой",
+	},
+	{
+		Version: 10,
+		Title:   "Запись из любого чата",
+		Text:    "Наберите в любой переписке имя бота и трату, например `@bot 300 кофе`, и выберите подсказку - расход запишется сразу",
+	},
+	{
+		Version: 11,
+		Title:   "Отчет в PDF",
+		Text:    "/export теперь присылает и отчет за месяц с графиками в PDF - удобно хранить и показывать",
+	},
+	{
+		Version: 12,
+		Title:   "Выгрузка в Excel",
+		Text:    "В /export появилась книга Excel: транзакции, суммы по категориям за каждый месяц и бюджеты на отдельных листах",
+	},
+	{
+		Version: 13,
+		Title:   "Импорт выписок OFX и QIF",
+		Text:    "Пришлите файл OFX или QIF из банка - бот пропустит уже записанные операции и предложит категории для остальных",
+	},
+	{
+		Version: 14,
+		Title:   "Поиск дубликатов",
+		Text:    "Бот предупредит, если операция похожа на уже записанную, а /duplicates найдет такие записи за последние месяцы - объединить их можно одной кнопкой",
+	},
+	{
+		Version: 15,
+		Title:   "Ваши данные под вашим контролем",
+		Text:    "/export\\_all выгрузит архив со всеми вашими данными, а /delete\\_me удалит их из бота после двойного подтверждения",
+	},
+	{
+		Version: 16,
+		Title:   "PIN-код для финансовых данных",
+		Text:    "Включите /pin, и после бездействия бот спросит PIN перед показом отчетов, баланса и выгрузок",
+	},
+	{
+		Version: 17,
+		Title:   "Краткие отчеты",
+		Text:    "В /settings можно выбрать краткий вид отчетов: только итоги и главные категории расходов",
+	},
+	{
+		Version: 18,
+		Title:   "Значки и цвета категорий",
+		Text:    "Нажмите 🎨 рядом с категорией в управлении категориями, чтобы выбрать ей значок и цвет на графиках",
+	},
+	{
+		Version: 19,
+		Title:   "Подкатегории",
+		Text:    "Нажмите 📂 рядом с категорией, чтобы добавить подкатегории, например «Кафе» внутри «Еды». В отчетах они входят в сумму категории и показываются отдельно",
+	},
+	{
+		Version: 20,
+		Title:   "Архив категорий",
+		Text:    "Ненужную категорию теперь можно убрать в архив кнопкой 🗄: она пропадет из выбора при вводе, а ее транзакции останутся в отчетах",
+	},
+	{
+		Version: 21,
+		Title:   "Свой порядок категорий",
+		Text:    "Кнопка ↕️ Порядок в управлении категориями меняет их порядок при вводе, а в /settings можно показывать первыми самые частые категории",
+	},
+	{
+		Version: 22,
+		Title:   "Лимиты категорий",
+		Text:    "Задайте лимит трат командой `/limit 10000 Кафе` - при превышении бот предупредит сразу после записи траты",
+	},
+	{
+		Version: 23,
+		Title:   "Прогноз остатка",
+		Text:    "/forecast показывает остаток на 30 дней вперед с учетом зарплаты, подписок и обычных трат, чтобы заранее увидеть, хватит ли денег до поступления",
+	},
+	{
+		Version: 24,
+		Title:   "Сравнение периодов",
+		Text:    "/compare сравнивает два любых периода, например отпуск и обычный месяц: итоги, траты в день и по категориям с графиком",
+	},
+	{
+		Version: 25,
+		Title:   "Стабильность дохода",
+		Text:    "/income показывает, какая доля дохода приходит из одного источника, насколько он меняется от месяца к месяцу и какие поступления нерегулярны",
+	},
+	{
+		Version: 26,
+		Title:   "Год с учетом инфляции",
+		Text:    "Если бот подключен к данным об инфляции, в /settings можно включить пересчет годового отчета: прошлые месяцы показываются в сегодняшних ценах, и сравнение с прошлым годом отражает реальную покупательную способность",
+	},
+	{
+		Version: 27,
+		Title:   "Свои напоминания",
+		Text:    "Команда /remind создает напоминания по расписанию: «/remind каждый день в 21:00 записать траты» или «/remind 15 числа — оплатить интернет». Без текста команда покажет список напоминаний",
+	},
+}
+
+// LatestAnnouncementVersion возвращает версию последнего объявления
+func LatestAnnouncementVersion() int {
+	if len(announcements) == 0 {
+		return 0
+	}
+	return announcements[len(announcements)-1].Version
+}
+
+// RecentAnnouncements возвращает последние объявления, начиная с самого нового
+func RecentAnnouncements(limit int) []Announcement {
+	result := make([]Announcement, 0, limit)
+	for i := len(announcements) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, announcements[i])
+	}
+	return result
+}
+
+// getUserSettings возвращает настройки пользователя или настройки по умолчанию
+func (s *ExpenseTracker) getUserSettings(ctx context.Context, userID int64) (*model.UserSettings, bool, error) {
+	settings, err := s.repo.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get user settings: %w", err)
+	}
+	if settings == nil {
+		return model.DefaultUserSettings(userID), false, nil
+	}
+	return settings, true, nil
+}
+
+// PendingAnnouncements возвращает непоказанные пользователю объявления и отмечает их показанными.
+// Пользователю, для которого настройки еще не сохранялись, показывается только последнее объявление
+func (s *ExpenseTracker) PendingAnnouncements(ctx context.Context, userID int64) ([]Announcement, error) {
+	latest := LatestAnnouncementVersion()
+	if seen, ok := s.seenAnnouncements.Load(userID); ok && seen.(int) >= latest {
+		return nil, nil
+	}
+
+	settings, exists, err := s.getUserSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings.LastSeenVersion >= latest {
+		s.seenAnnouncements.Store(userID, settings.LastSeenVersion)
+		return nil, nil
+	}
+
+	var pending []Announcement
+	if !exists {
+		pending = RecentAnnouncements(1)
+	} else {
+		for _, a := range announcements {
+			if a.Version > settings.LastSeenVersion {
+				pending = append(pending, a)
+			}
+		}
+	}
+
+	if err := s.markAnnouncementsSeen(ctx, settings); err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+// markAnnouncementsSeen отмечает все объявления показанными
+func (s *ExpenseTracker) markAnnouncementsSeen(ctx context.Context, settings *model.UserSettings) error {
+	settings.LastSeenVersion = LatestAnnouncementVersion()
+	settings.UpdatedAt = time.Now()
+	if err := s.repo.SaveUserSettings(ctx, settings); err != nil {
+		return err
+	}
+	s.seenAnnouncements.Store(settings.UserID, settings.LastSeenVersion)
+	return nil
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+const (
+	// MaxNoteLength - наибольшая длина заметки к транзакции в символах
+	MaxNoteLength = 1000
+	// MaxAttachmentSize - наибольший размер фото чека в байтах
+	MaxAttachmentSize = 5 << 20
+)
+
+var (
+	// ErrAttachmentsNotConfigured возвращается, если хранилище файлов не подключено
+	ErrAttachmentsNotConfigured = errors.New("attachment storage is not configured")
+	// ErrNoteTooLong - заметка длиннее MaxNoteLength
+	ErrNoteTooLong = fmt.Errorf("%w: note is too long", model.ErrValidation)
+	// ErrAttachmentTooLarge - фото больше MaxAttachmentSize
+	ErrAttachmentTooLarge = fmt.Errorf("%w: attachment is too large", model.ErrValidation)
+	// ErrTransactionNotFound - транзакции нет или она принадлежит другому бюджету
+	ErrTransactionNotFound = fmt.Errorf("transaction %w", model.ErrNotFound)
+)
+
+// AttachmentStorage хранит файлы, приложенные к транзакциям
+type AttachmentStorage interface {
+	Upload(ctx context.Context, path string, data []byte, contentType string) error
+	Download(ctx context.Context, path string) ([]byte, error)
+	Delete(ctx context.Context, paths ...string) error
+}
+
+// SetAttachmentStorage подключает хранилище фото чеков
+func (s *ExpenseTracker) SetAttachmentStorage(storage AttachmentStorage) {
+	s.attachments = storage
+}
+
+// AttachmentsAvailable сообщает, можно ли прикладывать к транзакциям фото
+func (s *ExpenseTracker) AttachmentsAvailable() bool {
+	return s.attachments != nil
+}
+
+// GetTransaction возвращает транзакцию вместе с заметкой и путем фото чека
+func (s *ExpenseTracker) GetTransaction(ctx context.Context, userID int64, transactionID string) (*model.Transaction, error) {
+	transaction, err := s.repo.GetTransaction(ctx, transactionID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if transaction == nil {
+		return nil, ErrTransactionNotFound
+	}
+	return transaction, nil
+}
+
+// SetTransactionNote сохраняет заметку к транзакции. Пустая заметка удаляет прежнюю
+func (s *ExpenseTracker) SetTransactionNote(ctx context.Context, userID int64, transactionID, note string) (*model.Transaction, error) {
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > MaxNoteLength {
+		return nil, ErrNoteTooLong
+	}
+	transaction, err := s.GetTransaction(ctx, userID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateTransactionDetails(ctx, transactionID, userID, note, transaction.AttachmentPath); err != nil {
+		return nil, fmt.Errorf("failed to save note: %w", err)
+	}
+	transaction.Note = note
+	return transaction, nil
+}
+
+// AttachTransactionPhoto сохраняет фото чека в хранилище и запоминает его путь в транзакции.
+// Новое фото заменяет прежнее
+func (s *ExpenseTracker) AttachTransactionPhoto(ctx context.Context, userID int64, transactionID string, photo []byte) error {
+	if s.attachments == nil {
+		return ErrAttachmentsNotConfigured
+	}
+	if len(photo) > MaxAttachmentSize {
+		return ErrAttachmentTooLarge
+	}
+	transaction, err := s.GetTransaction(ctx, userID, transactionID)
+	if err != nil {
+		return err
+	}
+
+	// Путь привязан к транзакции: повторная загрузка перезаписывает файл
+	path := fmt.Sprintf("%d/%s.jpg", transaction.UserID, transaction.ID)
+	if err := s.attachments.Upload(ctx, path, photo, "image/jpeg"); err != nil {
+		return fmt.Errorf("failed to upload photo: %w", err)
+	}
+	if err := s.repo.UpdateTransactionDetails(ctx, transactionID, userID, transaction.Note, path); err != nil {
+		s.deleteAttachments(ctx, path)
+		return fmt.Errorf("failed to save attachment: %w", err)
+	}
+	return nil
+}
+
+// GetTransactionPhoto возвращает фото чека транзакции
+func (s *ExpenseTracker) GetTransactionPhoto(ctx context.Context, userID int64, transactionID string) ([]byte, error) {
+	if s.attachments == nil {
+		return nil, ErrAttachmentsNotConfigured
+	}
+	transaction, err := s.GetTransaction(ctx, userID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.AttachmentPath == "" {
+		return nil, fmt.Errorf("transaction has no photo: %w", model.ErrNotFound)
+	}
+	photo, err := s.attachments.Download(ctx, transaction.AttachmentPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download photo: %w", err)
+	}
+	return photo, nil
+}
+
+// DeleteTransactionPhoto открепляет фото чека от транзакции и удаляет файл
+func (s *ExpenseTracker) DeleteTransactionPhoto(ctx context.Context, userID int64, transactionID string) error {
+	transaction, err := s.GetTransaction(ctx, userID, transactionID)
+	if err != nil {
+		return err
+	}
+	if transaction.AttachmentPath == "" {
+		return nil
+	}
+	if err := s.repo.UpdateTransactionDetails(ctx, transactionID, userID, transaction.Note, ""); err != nil {
+		return fmt.Errorf("failed to detach photo: %w", err)
+	}
+	s.deleteAttachments(ctx, transaction.AttachmentPath)
+	return nil
+}
+
+// deleteAttachments удаляет файлы из хранилища. Ошибка только логируется: запись уже
+// изменена, а оставшийся файл ни на что не влияет
+func (s *ExpenseTracker) deleteAttachments(ctx context.Context, paths ...string) {
+	var remove []string
+	for _, path := range paths {
+		if path != "" {
+			remove = append(remove, path)
+		}
+	}
+	if s.attachments == nil || len(remove) == 0 {
+		return
+	}
+	if err := s.attachments.Delete(ctx, remove...); err != nil {
+		log.Printf("Error deleting attachments: %v", err)
+	}
+}
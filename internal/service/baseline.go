@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+const (
+	// baselineHistoryDays - глубина истории для расчета типичных трат
+	baselineHistoryDays = 90
+	// typoMultiplier - во сколько раз сумма должна превышать медиану категории, чтобы заподозрить опечатку
+	typoMultiplier = 10.0
+)
+
+// RecalculateBaseline пересчитывает и сохраняет типичные траты пользователя. Учитываются
+// только завершенные дни: от первой траты в истории до вчерашнего дня включительно, дни без
+// трат идут в расчет с нулем. Редкие крупные покупки отбрасываются, чтобы одна покупка
+// ноутбука не сделала обычными все следующие дорогие дни
+func (s *ExpenseTracker) RecalculateBaseline(ctx context.Context, userID int64) (*model.UserBaseline, error) {
+	now := s.now()
+	loc := now.Location()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	startDate := today.AddDate(0, 0, -baselineHistoryDays)
+	endDate := today.Add(-time.Nanosecond)
+
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{
+		StartDate: &startDate,
+		EndDate:   &endDate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	dailyExpenses := make(map[string]float64)
+	categoryAmounts := make(map[string][]float64)
+	firstDay := today
+	for _, t := range transactions {
+		if t.IsIncome() {
+			continue
+		}
+		expense := t.AbsAmount()
+		date := t.Date.In(loc)
+		dailyExpenses[date.Format("2006-01-02")] += expense
+		categoryAmounts[t.CategoryID] = append(categoryAmounts[t.CategoryID], expense)
+		if date.Before(firstDay) {
+			firstDay = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+		}
+	}
+
+	// Выбросы ищутся среди дней с тратами: при редких покупках нулевые дни сдвинули бы
+	// квартили к нулю и отбросили бы все траты
+	spent := make([]float64, 0, len(dailyExpenses))
+	for _, amount := range dailyExpenses {
+		spent = append(spent, amount)
+	}
+	daily := withoutOutliers(spent)
+	for day := firstDay; day.Before(today); day = day.AddDate(0, 0, 1) {
+		if _, ok := dailyExpenses[day.Format("2006-01-02")]; !ok {
+			daily = append(daily, 0)
+		}
+	}
+
+	baseline := &model.UserBaseline{
+		UserID:          userID,
+		DailyMedian:     percentile(daily, 50),
+		DailyP90:        percentile(daily, 90),
+		CategoryMedians: make(map[string]float64),
+		ComputedAt:      now,
+	}
+	for categoryID, amounts := range categoryAmounts {
+		baseline.CategoryMedians[categoryID] = percentile(withoutOutliers(amounts), 50)
+	}
+
+	if err := s.repo.SaveUserBaseline(ctx, baseline); err != nil {
+		return nil, fmt.Errorf("failed to save baseline: %w", err)
+	}
+	return baseline, nil
+}
+
+// CheckAmount сверяет сумму новой траты с типичными значениями пользователя
+// и возвращает предупреждение, если сумма похожа на опечатку или аномалию
+func (s *ExpenseTracker) CheckAmount(ctx context.Context, userID int64, categoryID string, amount float64) (string, error) {
+	if amount >= 0 {
+		return "", nil
+	}
+
+	baseline, err := s.repo.GetUserBaseline(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get baseline: %w", err)
+	}
+	if baseline == nil {
+		return "", nil
+	}
+
+	expense := -amount
+	if median := baseline.CategoryMedians[categoryID]; median > 0 && expense >= median*typoMultiplier {
+		return fmt.Sprintf("Сумма в %.0f раз больше обычной для категории (обычно ~%.0f₽). Проверьте, нет ли опечатки",
+			math.Floor(expense/median), median), nil
+	}
+	if baseline.DailyP90 > 0 && expense > baseline.DailyP90 {
+		return fmt.Sprintf("Крупная трата: больше, чем вы обычно тратите за целый день (~%.0f₽)",
+			baseline.DailyMedian), nil
+	}
+	return "", nil
+}
+
+// TodaySpending - траты за сегодня на фоне типичных трат пользователя из предрасчитанной
+// статистики
+type TodaySpending struct {
+	Spent   float64 // потрачено сегодня
+	Typical float64 // медиана трат за день
+	Unusual float64 // 90-й перцентиль трат за день: дни дороже считаются аномальными
+}
+
+// SafeToSpend возвращает, сколько еще можно потратить сегодня, чтобы день остался обычным.
+// 0 - обычный день уже превышен
+func (t TodaySpending) SafeToSpend() float64 {
+	return math.Max(t.Typical-t.Spent, 0)
+}
+
+// Anomaly сообщает, что сегодня потрачено больше, чем в девяти днях из десяти
+func (t TodaySpending) Anomaly() bool {
+	return t.Unusual > 0 && t.Spent > t.Unusual
+}
+
+// BecameAnomaly сообщает, что день стал аномальным именно из-за траты amount. Так
+// предупреждение приходит один раз, а не после каждой следующей траты этого дня
+func (t TodaySpending) BecameAnomaly(amount float64) bool {
+	return t.Anomaly() && t.Spent-math.Abs(amount) <= t.Unusual
+}
+
+// GetTodaySpending сравнивает сегодняшние расходы с типичным днем пользователя. Читаются
+// только транзакции за сегодня, типичные траты берутся из статистики, пересчитываемой раз
+// в неделю. Без статистики возвращает nil
+func (s *ExpenseTracker) GetTodaySpending(ctx context.Context, userID int64) (*TodaySpending, error) {
+	baseline, err := s.repo.GetUserBaseline(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get baseline: %w", err)
+	}
+	if baseline == nil || baseline.DailyMedian <= 0 {
+		return nil, nil
+	}
+
+	now := s.now()
+	startDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{
+		StartDate: &startDate,
+		EndDate:   &now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	today := &TodaySpending{Typical: baseline.DailyMedian, Unusual: baseline.DailyP90}
+	for _, t := range transactions {
+		if !t.IsIncome() {
+			today.Spent += t.AbsAmount()
+		}
+	}
+	return today, nil
+}
+
+// withoutOutliers отбрасывает значения выше верхней границы Тьюки: третий квартиль плюс
+// полтора межквартильных размаха. Для трех значений и меньше квартили не имеют смысла,
+// они возвращаются как есть
+func withoutOutliers(values []float64) []float64 {
+	if len(values) < 4 {
+		return values
+	}
+	q1, q3 := percentile(values, 25), percentile(values, 75)
+	limit := q3 + 1.5*(q3-q1)
+
+	kept := make([]float64, 0, len(values))
+	for _, v := range values {
+		if v <= limit {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+// percentile вычисляет перцентиль p (0-100) методом ближайшего ранга
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
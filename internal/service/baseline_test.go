@@ -0,0 +1,188 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+	"github.com/ivanoskov/financial_bot/internal/service/mocks"
+)
+
+// baselineRepository отдает статистику пользователя и транзакции, попавшие в фильтр
+func baselineRepository(baseline *model.UserBaseline, transactions []model.Transaction) *mocks.Repository {
+	return &mocks.Repository{
+		GetUserBaselineFunc: func(ctx context.Context, userID int64) (*model.UserBaseline, error) {
+			return baseline, nil
+		},
+		GetTransactionsFunc: func(ctx context.Context, userID int64, filter model.TransactionFilter) ([]model.Transaction, error) {
+			var result []model.Transaction
+			for _, t := range transactions {
+				if !t.Date.Before(*filter.StartDate) && !t.Date.After(*filter.EndDate) {
+					result = append(result, t)
+				}
+			}
+			return result, nil
+		},
+	}
+}
+
+func TestRecalculateBaseline(t *testing.T) {
+	now := time.Date(2026, 3, 20, 12, 0, 0, 0, msk)
+	day := func(n int) time.Time { return now.AddDate(0, 0, -n) }
+	var saved *model.UserBaseline
+	repo := baselineRepository(nil, []model.Transaction{
+		// Траты по дням: 100, 200, 0, 400, 300, 20000 и 250. Первая сделана в полночь
+		// по Москве, в UTC это еще предыдущий день
+		{CategoryID: "food", Amount: -100, Date: time.Date(2026, 3, 18, 21, 30, 0, 0, time.UTC)},
+		{CategoryID: "food", Amount: -150, Date: day(2)},
+		{CategoryID: "cafe", Amount: -50, Date: day(2)},
+		{CategoryID: "cafe", Amount: -400, Date: day(4)},
+		{CategoryID: "food", Amount: -300, Date: day(5)},
+		{CategoryID: "food", Amount: -20000, Date: day(6)}, // банкет - выброс и для дня, и для категории
+		{CategoryID: "food", Amount: -250, Date: day(7)},
+		// Доходы, сегодняшние траты и траты старше 90 дней не учитываются
+		{CategoryID: "salary", Type: model.TransactionIncome, Amount: 90000, Date: day(1)},
+		{CategoryID: "food", Amount: -5000, Date: now.Add(-time.Hour)},
+		{CategoryID: "food", Amount: -100000, Date: day(120)},
+	})
+	repo.SaveUserBaselineFunc = func(ctx context.Context, baseline *model.UserBaseline) error {
+		saved = baseline
+		return nil
+	}
+	tracker := service.NewExpenseTracker(repo)
+	tracker.SetClock(func() time.Time { return now })
+
+	baseline, err := tracker.RecalculateBaseline(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("RecalculateBaseline: %v", err)
+	}
+	if saved != baseline {
+		t.Fatalf("статистика не сохранена")
+	}
+	// Без банкета остаются дни 0, 100, 200, 250, 300 и 400
+	if baseline.DailyMedian != 200 || baseline.DailyP90 != 400 {
+		t.Errorf("медиана дня %.0f, 90-й перцентиль %.0f; ожидалось 200 и 400", baseline.DailyMedian, baseline.DailyP90)
+	}
+	if got := baseline.CategoryMedians["food"]; got != 150 {
+		t.Errorf("медиана траты в продуктах %.0f, ожидалось 150", got)
+	}
+	if got := baseline.CategoryMedians["cafe"]; got != 50 {
+		t.Errorf("медиана траты в кафе %.0f, ожидалось 50", got)
+	}
+}
+
+func TestRecalculateBaselineRareSpending(t *testing.T) {
+	now := time.Date(2026, 3, 20, 12, 0, 0, 0, msk)
+	// Пользователь тратит раз в неделю: обычный день - без трат, но сами траты не выбросы
+	var transactions []model.Transaction
+	for week := 1; week <= 6; week++ {
+		transactions = append(transactions, model.Transaction{CategoryID: "food", Amount: -3000, Date: now.AddDate(0, 0, -7*week)})
+	}
+	tracker := service.NewExpenseTracker(baselineRepository(nil, transactions))
+	tracker.SetClock(func() time.Time { return now })
+
+	baseline, err := tracker.RecalculateBaseline(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("RecalculateBaseline: %v", err)
+	}
+	if baseline.DailyMedian != 0 || baseline.DailyP90 != 3000 {
+		t.Errorf("медиана дня %.0f, 90-й перцентиль %.0f; ожидалось 0 и 3000", baseline.DailyMedian, baseline.DailyP90)
+	}
+}
+
+func TestCheckAmount(t *testing.T) {
+	baseline := &model.UserBaseline{
+		DailyMedian:     1500,
+		DailyP90:        4000,
+		CategoryMedians: map[string]float64{"coffee": 250},
+	}
+	tests := []struct {
+		name       string
+		baseline   *model.UserBaseline
+		categoryID string
+		amount     float64
+		want       string // фрагмент предупреждения, пусто - предупреждения нет
+	}{
+		{"обычная трата", baseline, "coffee", -300, ""},
+		{"доход не проверяется", baseline, "coffee", 50000, ""},
+		{"без статистики", nil, "coffee", -50000, ""},
+		{"лишний ноль", baseline, "coffee", -2500, "в 10 раз больше обычной"},
+		{"больше обычного дня", baseline, "furniture", -12000, "Крупная трата"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := service.NewExpenseTracker(baselineRepository(tt.baseline, nil))
+			warning, err := tracker.CheckAmount(context.Background(), 1, tt.categoryID, tt.amount)
+			if err != nil {
+				t.Fatalf("CheckAmount: %v", err)
+			}
+			if tt.want == "" && warning != "" || !strings.Contains(warning, tt.want) {
+				t.Errorf("предупреждение %q, ожидалось %q", warning, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTodaySpending(t *testing.T) {
+	now := time.Date(2026, 3, 20, 18, 0, 0, 0, msk)
+	baseline := &model.UserBaseline{DailyMedian: 1000, DailyP90: 3000}
+	transactions := []model.Transaction{
+		{Amount: -400, Date: time.Date(2026, 3, 20, 0, 0, 0, 0, msk)},
+		{Type: model.TransactionExpense, Amount: -300, Date: time.Date(2026, 3, 20, 13, 0, 0, 0, msk)},
+		{Type: model.TransactionIncome, Amount: 5000, Date: time.Date(2026, 3, 20, 9, 0, 0, 0, msk)},
+		// Вчерашние траты в сегодняшний день не входят
+		{Amount: -2000, Date: time.Date(2026, 3, 19, 23, 30, 0, 0, msk)},
+	}
+
+	tracker := service.NewExpenseTracker(baselineRepository(baseline, transactions))
+	tracker.SetClock(func() time.Time { return now })
+	today, err := tracker.GetTodaySpending(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetTodaySpending: %v", err)
+	}
+	if today.Spent != 700 || today.SafeToSpend() != 300 || today.Anomaly() {
+		t.Errorf("потрачено %.0f, можно еще %.0f, аномалия %v; ожидалось 700, 300, false",
+			today.Spent, today.SafeToSpend(), today.Anomaly())
+	}
+
+	tracker = service.NewExpenseTracker(baselineRepository(nil, transactions))
+	if today, err := tracker.GetTodaySpending(context.Background(), 1); err != nil || today != nil {
+		t.Errorf("без статистики: %+v, %v; ожидалось nil", today, err)
+	}
+}
+
+func TestTodaySpendingAnomaly(t *testing.T) {
+	tests := []struct {
+		name    string
+		spent   float64
+		amount  float64
+		safe    float64
+		anomaly bool
+		became  bool
+	}{
+		{"обычный день", 600, -200, 400, false, false},
+		{"обычный день превышен", 1500, -200, 0, false, false},
+		{"ровно на границе аномалии", 3000, -500, 0, false, false},
+		{"трата сделала день аномальным", 3200, -500, 0, true, true},
+		{"день уже был аномальным", 4000, -500, 0, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			today := service.TodaySpending{Spent: tt.spent, Typical: 1000, Unusual: 3000}
+			if got := today.SafeToSpend(); got != tt.safe {
+				t.Errorf("SafeToSpend = %.0f, ожидалось %.0f", got, tt.safe)
+			}
+			if got := today.Anomaly(); got != tt.anomaly {
+				t.Errorf("Anomaly = %v, ожидалось %v", got, tt.anomaly)
+			}
+			if got := today.BecameAnomaly(tt.amount); got != tt.became {
+				t.Errorf("BecameAnomaly = %v, ожидалось %v", got, tt.became)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+const (
+	// broadcastActiveDays - сколько дней после последней активности пользователь считается активным
+	broadcastActiveDays = 30
+	// broadcastNewDays - сколько дней после регистрации пользователь считается новым
+	broadcastNewDays = 7
+)
+
+var (
+	// ErrBroadcastNotFound возвращается, если рассылки нет
+	ErrBroadcastNotFound = fmt.Errorf("broadcast %w", model.ErrNotFound)
+	// ErrBroadcastNotDraft возвращается при попытке изменить уже отправляемую рассылку
+	ErrBroadcastNotDraft = errors.New("broadcast is not a draft")
+)
+
+// BroadcastSegment - группа получателей рассылки
+type BroadcastSegment struct {
+	ID    string
+	Title string
+}
+
+// BroadcastSegments - сегменты получателей в порядке кнопок
+var BroadcastSegments = []BroadcastSegment{
+	{ID: model.SegmentAll, Title: "👥 Всем"},
+	{ID: model.SegmentActive, Title: "🔥 Активным за 30 дней"},
+	{ID: model.SegmentInactive, Title: "💤 Неактивным"},
+	{ID: model.SegmentNew, Title: "🆕 Новым за неделю"},
+	{ID: model.SegmentRussian, Title: "🇷🇺 Русский язык"},
+	{ID: model.SegmentOther, Title: "🌍 Другие языки"},
+}
+
+// BroadcastSegmentTitle возвращает название сегмента для сообщений администратору
+func BroadcastSegmentTitle(segment string) string {
+	for _, s := range BroadcastSegments {
+		if s.ID == segment {
+			return s.Title
+		}
+	}
+	return segment
+}
+
+// inBroadcastSegment проверяет, что пользователь входит в сегмент рассылки
+func inBroadcastSegment(user model.User, segment string, now time.Time) bool {
+	switch segment {
+	case model.SegmentAll:
+		return true
+	case model.SegmentActive:
+		return now.Sub(user.LastSeen) <= broadcastActiveDays*24*time.Hour
+	case model.SegmentInactive:
+		return now.Sub(user.LastSeen) > broadcastActiveDays*24*time.Hour
+	case model.SegmentNew:
+		return now.Sub(user.CreatedAt) <= broadcastNewDays*24*time.Hour
+	case model.SegmentRussian:
+		return strings.HasPrefix(user.LanguageCode, "ru")
+	case model.SegmentOther:
+		return !strings.HasPrefix(user.LanguageCode, "ru")
+	}
+	return false
+}
+
+// CreateBroadcast сохраняет черновик рассылки администратора. По умолчанию - всем пользователям
+func (s *ExpenseTracker) CreateBroadcast(ctx context.Context, adminID int64, text string) (*model.Broadcast, error) {
+	broadcast := &model.Broadcast{
+		ID:        uuid.New().String(),
+		Text:      text,
+		Segment:   model.SegmentAll,
+		Status:    model.BroadcastDraft,
+		CreatedBy: adminID,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.CreateBroadcast(ctx, broadcast); err != nil {
+		return nil, fmt.Errorf("failed to create broadcast: %w", err)
+	}
+	markWrote(ctx)
+	return broadcast, nil
+}
+
+// GetBroadcast возвращает рассылку или ErrBroadcastNotFound
+func (s *ExpenseTracker) GetBroadcast(ctx context.Context, id string) (*model.Broadcast, error) {
+	broadcast, err := s.repo.GetBroadcast(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get broadcast: %w", err)
+	}
+	if broadcast == nil {
+		return nil, ErrBroadcastNotFound
+	}
+	return broadcast, nil
+}
+
+// draftBroadcast возвращает рассылку, которую еще можно изменить
+func (s *ExpenseTracker) draftBroadcast(ctx context.Context, id string) (*model.Broadcast, error) {
+	broadcast, err := s.GetBroadcast(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if broadcast.Status != model.BroadcastDraft {
+		return nil, ErrBroadcastNotDraft
+	}
+	return broadcast, nil
+}
+
+// SetBroadcastSegment выбирает получателей черновика и возвращает их число
+func (s *ExpenseTracker) SetBroadcastSegment(ctx context.Context, id, segment string) (*model.Broadcast, int, error) {
+	broadcast, err := s.draftBroadcast(ctx, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	broadcast.Segment = segment
+	if err := s.repo.UpdateBroadcast(ctx, broadcast); err != nil {
+		return nil, 0, fmt.Errorf("failed to update broadcast: %w", err)
+	}
+	recipients, err := s.BroadcastRecipients(ctx, broadcast, time.Now())
+	if err != nil {
+		return nil, 0, err
+	}
+	return broadcast, len(recipients), nil
+}
+
+// QueueBroadcast подтверждает черновик: рассылка начнется при следующей доставке
+func (s *ExpenseTracker) QueueBroadcast(ctx context.Context, id string) (*model.Broadcast, error) {
+	broadcast, err := s.draftBroadcast(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	broadcast.Status, broadcast.QueuedAt = model.BroadcastQueued, &now
+	if err := s.repo.UpdateBroadcast(ctx, broadcast); err != nil {
+		return nil, fmt.Errorf("failed to queue broadcast: %w", err)
+	}
+	return broadcast, nil
+}
+
+// CancelBroadcast отменяет черновик рассылки
+func (s *ExpenseTracker) CancelBroadcast(ctx context.Context, id string) error {
+	broadcast, err := s.draftBroadcast(ctx, id)
+	if err != nil {
+		return err
+	}
+	broadcast.Status = model.BroadcastCanceled
+	if err := s.repo.UpdateBroadcast(ctx, broadcast); err != nil {
+		return fmt.Errorf("failed to cancel broadcast: %w", err)
+	}
+	return nil
+}
+
+// QueuedBroadcasts возвращает подтвержденные рассылки, которые еще не отправлены всем
+func (s *ExpenseTracker) QueuedBroadcasts(ctx context.Context) ([]model.Broadcast, error) {
+	broadcasts, err := s.repo.GetQueuedBroadcasts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queued broadcasts: %w", err)
+	}
+	return broadcasts, nil
+}
+
+// BroadcastRecipients возвращает пользователей сегмента, которым рассылка еще не отправлялась.
+// Сегмент вычисляется при каждой доставке, поэтому новые пользователи сегмента тоже ее получат
+func (s *ExpenseTracker) BroadcastRecipients(ctx context.Context, broadcast *model.Broadcast, now time.Time) ([]int64, error) {
+	users, err := s.repo.GetUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	deliveries, err := s.repo.GetBroadcastDeliveries(ctx, broadcast.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get broadcast deliveries: %w", err)
+	}
+	delivered := make(map[int64]bool, len(deliveries))
+	for _, d := range deliveries {
+		delivered[d.UserID] = true
+	}
+
+	var recipients []int64
+	for _, user := range users {
+		if !delivered[user.ID] && inBroadcastSegment(user, broadcast.Segment, now) {
+			recipients = append(recipients, user.ID)
+		}
+	}
+	return recipients, nil
+}
+
+// RecordBroadcastDelivery отмечает отправку рассылки пользователю, в том числе неудачную
+func (s *ExpenseTracker) RecordBroadcastDelivery(ctx context.Context, broadcastID string, userID int64, sendErr error) error {
+	delivery := &model.BroadcastDelivery{
+		BroadcastID: broadcastID,
+		UserID:      userID,
+		DeliveredAt: time.Now(),
+	}
+	if sendErr != nil {
+		delivery.Error = sendErr.Error()
+	}
+	if err := s.repo.SaveBroadcastDelivery(ctx, delivery); err != nil {
+		return fmt.Errorf("failed to save broadcast delivery: %w", err)
+	}
+	return nil
+}
+
+// BroadcastResult - итог рассылки для администратора
+type BroadcastResult struct {
+	Sent   int
+	Failed int
+}
+
+// FinishBroadcast отмечает рассылку отправленной и возвращает итоги доставки
+func (s *ExpenseTracker) FinishBroadcast(ctx context.Context, broadcast *model.Broadcast) (*BroadcastResult, error) {
+	now := time.Now()
+	broadcast.Status, broadcast.FinishedAt = model.BroadcastDone, &now
+	if err := s.repo.UpdateBroadcast(ctx, broadcast); err != nil {
+		return nil, fmt.Errorf("failed to finish broadcast: %w", err)
+	}
+
+	deliveries, err := s.repo.GetBroadcastDeliveries(ctx, broadcast.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get broadcast deliveries: %w", err)
+	}
+	result := &BroadcastResult{}
+	for _, d := range deliveries {
+		if d.Error != "" {
+			result.Failed++
+		} else {
+			result.Sent++
+		}
+	}
+	return result, nil
+}
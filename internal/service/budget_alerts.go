@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// budgetAlertThresholds - пороги исполнения бюджета в процентах по возрастанию,
+// о которых бот сообщает сам, не дожидаясь отчета
+var budgetAlertThresholds = []int{80, 100}
+
+// BudgetAlert - бюджет, исполнение которого перешло порог
+type BudgetAlert struct {
+	UserID    int64 // кому отправить предупреждение
+	Status    BudgetStatus
+	Threshold int // порог в процентах
+}
+
+// PendingBudgetAlerts возвращает бюджеты пользователя, которые в этом месяце перешли порог,
+// о котором он еще не предупрежден. Из нескольких пройденных порогов остается старший.
+// Пусто, если предупреждения выключены или сейчас тихие часы: тогда предупреждения отправит
+// следующая проверка по расписанию
+func (s *ExpenseTracker) PendingBudgetAlerts(ctx context.Context, userID int64) ([]BudgetAlert, error) {
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.BudgetAlerts || settings.IsQuietHour(s.now().Hour()) {
+		return nil, nil
+	}
+
+	statuses, err := s.GetBudgetStatuses(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var alerts []BudgetAlert
+	for _, status := range statuses {
+		if threshold := crossedThreshold(status); threshold > status.Budget.AlertedIn(status.Month) {
+			alerts = append(alerts, BudgetAlert{UserID: userID, Status: status, Threshold: threshold})
+		}
+	}
+	return alerts, nil
+}
+
+// crossedThreshold возвращает старший пройденный порог исполнения бюджета, 0 - ни одного.
+// Бюджет, съеденный перерасходом прошлых месяцев, превышен первой же тратой
+func crossedThreshold(status BudgetStatus) int {
+	crossed := 0
+	for _, threshold := range budgetAlertThresholds {
+		if status.Spent > 0 && status.Spent >= status.Available()*float64(threshold)/100 {
+			crossed = threshold
+		}
+	}
+	return crossed
+}
+
+// DueBudgetAlerts возвращает неотправленные предупреждения о бюджетах всех пользователей,
+// например о тратах, записанных в тихие часы
+func (s *ExpenseTracker) DueBudgetAlerts(ctx context.Context) ([]BudgetAlert, error) {
+	users, err := s.repo.GetUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	var due []BudgetAlert
+	for _, user := range users {
+		alerts, err := s.PendingBudgetAlerts(ctx, user.ID)
+		if err != nil {
+			// Ошибка одного пользователя не останавливает проверку остальных
+			log.Printf("Error checking budget alerts of user %d: %v", user.ID, err)
+			continue
+		}
+		due = append(due, alerts...)
+	}
+	return due, nil
+}
+
+// MarkBudgetAlertSent запоминает порог, о котором пользователь предупрежден. Ошибка только
+// пишется в лог: в худшем случае предупреждение придет еще раз при следующей проверке
+func (s *ExpenseTracker) MarkBudgetAlertSent(ctx context.Context, alert BudgetAlert) {
+	budget := alert.Status.Budget
+	sentAt := s.now()
+	budget.AlertedPercent, budget.AlertedAt = alert.Threshold, &sentAt
+	if err := s.repo.SaveBudget(ctx, &budget); err != nil {
+		log.Printf("Error marking budget alert for category %s sent: %v", budget.CategoryID, err)
+	}
+}
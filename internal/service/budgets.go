@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// budgetRolloverMonths - за сколько прошлых месяцев накапливается перенос остатка бюджета,
+// чтобы давно забытый остаток не раздувал бюджет
+const budgetRolloverMonths = 12
+
+// BudgetStatus - исполнение бюджета категории в текущем месяце
+type BudgetStatus struct {
+	Budget       model.Budget
+	CategoryName string
+	Month        time.Time // начало месяца
+	// Carryover - перенос с прошлых месяцев: неизрасходованный остаток со знаком плюс,
+	// перерасход со знаком минус. Без переноса - 0
+	Carryover float64
+	Spent     float64
+	// Daily - траты нарастающим итогом по дням месяца, до сегодняшнего включительно
+	Daily []float64
+}
+
+// Available возвращает сумму, доступную в месяце: бюджет с учетом переноса
+func (b BudgetStatus) Available() float64 {
+	return b.Budget.Amount + b.Carryover
+}
+
+// Remaining возвращает остаток бюджета, отрицательный - перерасход
+func (b BudgetStatus) Remaining() float64 {
+	return b.Available() - b.Spent
+}
+
+// GetBudgetStatuses возвращает бюджеты с тратами текущего месяца и переносом остатка
+// прошлых месяцев. Траты подкатегорий учитываются и в бюджете родителя
+func (s *ExpenseTracker) GetBudgetStatuses(ctx context.Context, userID int64) ([]BudgetStatus, error) {
+	return s.budgetStatusesAt(ctx, userID, s.now())
+}
+
+// budgetStatusesAt возвращает исполнение бюджетов в месяце момента now с тратами до now включительно
+func (s *ExpenseTracker) budgetStatusesAt(ctx context.Context, userID int64, now time.Time) ([]BudgetStatus, error) {
+	budgets, err := s.repo.GetBudgets(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budgets: %w", err)
+	}
+	if len(budgets) == 0 {
+		return nil, nil
+	}
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	start := month
+	for _, budget := range budgets {
+		if since := rolloverStart(budget, month); since.Before(start) {
+			start = since
+		}
+	}
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{
+		StartDate: &start,
+		EndDate:   &now,
+		Type:      "expense",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	names := make(map[string]string, len(categories))
+	parents := make(map[string]string, len(categories))
+	for _, cat := range categories {
+		names[cat.ID] = cat.Name
+		parents[cat.ID] = cat.ParentID
+	}
+	// Траты по категориям и месяцам, для текущего месяца - еще и по дням
+	monthly := make(map[string]map[time.Time]float64)
+	daily := make(map[string][]float64)
+	add := func(categoryID string, t model.Transaction) {
+		date := t.Date.In(now.Location())
+		key := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, now.Location())
+		if monthly[categoryID] == nil {
+			monthly[categoryID] = make(map[time.Time]float64)
+		}
+		monthly[categoryID][key] += t.AbsAmount()
+		if key.Equal(month) {
+			if daily[categoryID] == nil {
+				daily[categoryID] = make([]float64, now.Day())
+			}
+			if day := date.Day() - 1; day < len(daily[categoryID]) {
+				daily[categoryID][day] += t.AbsAmount()
+			}
+		}
+	}
+	for _, t := range transactions {
+		if t.IsIncome() {
+			continue
+		}
+		add(t.CategoryID, t)
+		if parent := parents[t.CategoryID]; parent != "" {
+			add(parent, t)
+		}
+	}
+
+	statuses := make([]BudgetStatus, 0, len(budgets))
+	for _, budget := range budgets {
+		status := BudgetStatus{
+			Budget:       budget,
+			CategoryName: names[budget.CategoryID],
+			Month:        month,
+			Spent:        monthly[budget.CategoryID][month],
+			Daily:        make([]float64, now.Day()),
+		}
+		for m := rolloverStart(budget, month); m.Before(month); m = m.AddDate(0, 1, 0) {
+			status.Carryover += budget.Amount - monthly[budget.CategoryID][m]
+		}
+		var total float64
+		for day := range status.Daily {
+			if spent := daily[budget.CategoryID]; spent != nil {
+				total += spent[day]
+			}
+			status.Daily[day] = total
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// rolloverStart возвращает первый месяц, остаток которого переносится в month.
+// Без переноса - сам month
+func rolloverStart(budget model.Budget, month time.Time) time.Time {
+	if !budget.Rollover() {
+		return month
+	}
+	since := budget.RolloverSince.In(month.Location())
+	start := time.Date(since.Year(), since.Month(), 1, 0, 0, 0, 0, month.Location())
+	if earliest := month.AddDate(0, -budgetRolloverMonths, 0); start.Before(earliest) {
+		return earliest
+	}
+	return start
+}
+
+// DailyBudget возвращает сумму бюджетов текущего месяца в пересчете на день - линию лимита
+// для графика расходов по дням. Без бюджетов - 0
+func (s *ExpenseTracker) DailyBudget(ctx context.Context, userID int64) (float64, error) {
+	budgets, err := s.repo.GetBudgets(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get budgets: %w", err)
+	}
+	if len(budgets) == 0 {
+		return 0, nil
+	}
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	counted := countedBudgets(budgets, categories)
+	var total float64
+	for _, budget := range budgets {
+		if counted[budget.CategoryID] {
+			total += budget.Amount
+		}
+	}
+
+	now := s.now()
+	days := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location()).Day()
+	return total / float64(days), nil
+}
+
+// countedBudgets возвращает категории, бюджеты которых входят в общую сумму бюджетов.
+// Бюджет подкатегории уже входит в бюджет родителя и считается, только если у родителя бюджета нет
+func countedBudgets(budgets []model.Budget, categories []model.Category) map[string]bool {
+	parents := make(map[string]string, len(categories))
+	for _, cat := range categories {
+		parents[cat.ID] = cat.ParentID
+	}
+	budgeted := make(map[string]bool, len(budgets))
+	for _, budget := range budgets {
+		budgeted[budget.CategoryID] = true
+	}
+	counted := make(map[string]bool, len(budgets))
+	for _, budget := range budgets {
+		if parent := parents[budget.CategoryID]; parent == "" || !budgeted[parent] {
+			counted[budget.CategoryID] = true
+		}
+	}
+	return counted
+}
+
+// BudgetToday - сколько осталось от бюджетов месяца
+type BudgetToday struct {
+	Remaining float64 // остаток бюджетов с учетом переноса, отрицательный - перерасход
+	PerDay    float64 // остаток на каждый оставшийся день месяца, включая сегодняшний
+	DaysLeft  int
+}
+
+// GetBudgetToday возвращает остаток бюджетов месяца и сколько можно тратить в день до его
+// конца. Без бюджетов - nil
+func (s *ExpenseTracker) GetBudgetToday(ctx context.Context, userID int64) (*BudgetToday, error) {
+	statuses, err := s.GetBudgetStatuses(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	budgets := make([]model.Budget, len(statuses))
+	for i, status := range statuses {
+		budgets[i] = status.Budget
+	}
+	counted := countedBudgets(budgets, categories)
+	today := &BudgetToday{}
+	for _, status := range statuses {
+		if counted[status.Budget.CategoryID] {
+			today.Remaining += status.Remaining()
+		}
+	}
+
+	now := s.now()
+	daysInMonth := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location()).Day()
+	today.DaysLeft = daysInMonth - now.Day() + 1
+	if today.Remaining > 0 {
+		today.PerDay = today.Remaining / float64(today.DaysLeft)
+	}
+	return today, nil
+}
+
+// SetBudgetRollover включает или выключает перенос остатка бюджета категории.
+// Перенос начинается с текущего месяца: его остаток перейдет в следующий
+func (s *ExpenseTracker) SetBudgetRollover(ctx context.Context, userID int64, categoryID string, enabled bool) (*model.Budget, error) {
+	budget, err := s.findBudget(ctx, userID, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled == budget.Rollover() {
+		return budget, nil
+	}
+	budget.RolloverSince = nil
+	if enabled {
+		now := s.now()
+		since := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		budget.RolloverSince = &since
+	}
+	if err := s.repo.SaveBudget(ctx, budget); err != nil {
+		return nil, fmt.Errorf("failed to save budget: %w", err)
+	}
+	return budget, nil
+}
+
+// findBudget ищет бюджет категории
+func (s *ExpenseTracker) findBudget(ctx context.Context, userID int64, categoryID string) (*model.Budget, error) {
+	budgets, err := s.repo.GetBudgets(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budgets: %w", err)
+	}
+	for i := range budgets {
+		if budgets[i].CategoryID == categoryID {
+			return &budgets[i], nil
+		}
+	}
+	return nil, fmt.Errorf("budget for category %s not found: %w", categoryID, model.ErrNotFound)
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// Налог на профессиональный доход самозанятых
+const (
+	// NPDIndividualRate - ставка с доходов от физических лиц
+	NPDIndividualRate = 0.04
+	// NPDCompanyRate - ставка с доходов от организаций и ИП
+	NPDCompanyRate = 0.06
+	// NPDYearLimit - предельный доход самозанятого за календарный год
+	NPDYearLimit = 2_400_000
+)
+
+// maxBusinessField - длина названия контрагента и номера счета в символах
+const maxBusinessField = 100
+
+// ErrNotBusinessLedger возвращается, если в текущей книге учета бизнес-режим выключен
+var ErrNotBusinessLedger = fmt.Errorf("%w: business mode is off", model.ErrValidation)
+
+// companyForms - организационно-правовые формы, по которым контрагент считается организацией или ИП
+var companyForms = []string{"ООО", "АО", "ПАО", "ЗАО", "ОАО", "НКО", "АНО", "ИП", "LLC", "LTD", "INC", "GMBH"}
+
+// IsCompany сообщает, что контрагент - организация или ИП: название начинается с правовой формы
+func IsCompany(counterparty string) bool {
+	first, _, _ := strings.Cut(strings.TrimSpace(counterparty), " ")
+	first = strings.ToUpper(strings.Trim(first, ".,«»\""))
+	for _, form := range companyForms {
+		if first == form {
+			return true
+		}
+	}
+	return false
+}
+
+// BusinessDetails - реквизиты бизнес-транзакции
+type BusinessDetails struct {
+	Counterparty  string
+	InvoiceNumber string
+	VATAmount     float64
+}
+
+// ParseBusinessDetails разбирает реквизиты из сообщения: строки или части через «;» вида
+// «ООО Ромашка», «счет 42» и «НДС 20%», «НДС 1500» или «без НДС». Ставка считается
+// от суммы amount, которая уже включает налог. «-» очищает реквизиты
+func ParseBusinessDetails(text string, amount float64) (BusinessDetails, error) {
+	var details BusinessDetails
+	text = strings.TrimSpace(text)
+	if text == "-" {
+		return details, nil
+	}
+
+	parts := strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == ';' })
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		lower := strings.ToLower(part)
+		switch {
+		case part == "":
+		case strings.HasPrefix(lower, "ндс") || strings.HasPrefix(lower, "без ндс"):
+			vat, err := parseVAT(lower, amount)
+			if err != nil {
+				return details, err
+			}
+			details.VATAmount = vat
+		case isInvoiceNumber(lower):
+			number := strings.TrimSpace(strings.TrimLeft(part[invoiceLabelLength(lower):], " :№#"))
+			if number == "" {
+				return details, fmt.Errorf("%w: empty invoice number", model.ErrValidation)
+			}
+			details.InvoiceNumber = number
+		default:
+			details.Counterparty = part
+		}
+	}
+	if utf8.RuneCountInString(details.Counterparty) > maxBusinessField || utf8.RuneCountInString(details.InvoiceNumber) > maxBusinessField {
+		return details, fmt.Errorf("%w: business details must be at most %d characters", model.ErrValidation, maxBusinessField)
+	}
+	return details, nil
+}
+
+// parseVAT возвращает НДС по ставке («ндс 20%») или сумме («ндс 1500»), «без ндс» - ноль
+func parseVAT(part string, amount float64) (float64, error) {
+	if strings.HasPrefix(part, "без") {
+		return 0, nil
+	}
+	value := strings.TrimSpace(strings.TrimPrefix(part, "ндс"))
+	value = strings.ReplaceAll(strings.TrimSuffix(value, "₽"), ",", ".")
+	if rate, ok := strings.CutSuffix(value, "%"); ok {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return 0, fmt.Errorf("%w: invalid VAT rate %q", model.ErrValidation, rate)
+		}
+		return math.Round(amount*percent/(100+percent)*100) / 100, nil
+	}
+	vat, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || vat < 0 || vat > amount {
+		return 0, fmt.Errorf("%w: invalid VAT amount %q", model.ErrValidation, value)
+	}
+	return vat, nil
+}
+
+// invoiceLabels - слова, с которых начинается номер счета
+var invoiceLabels = []string{"счет", "счёт", "акт", "№", "#"}
+
+// isInvoiceNumber сообщает, что часть сообщения - номер счета: «счет 42», «акт №7», «№ 15».
+// После слова должен идти разделитель, чтобы «Счетная палата» осталась контрагентом
+func isInvoiceNumber(lower string) bool {
+	n := invoiceLabelLength(lower)
+	if n == 0 {
+		return false
+	}
+	rest := lower[n:]
+	return strings.HasPrefix(lower, "№") || strings.HasPrefix(lower, "#") || rest == "" ||
+		strings.HasPrefix(rest, " ") || strings.HasPrefix(rest, ":") || strings.HasPrefix(rest, "№")
+}
+
+// invoiceLabelLength возвращает длину слова-метки номера счета в байтах, 0 - метки нет
+func invoiceLabelLength(lower string) int {
+	for _, label := range invoiceLabels {
+		if strings.HasPrefix(lower, label) {
+			return len(label)
+		}
+	}
+	return 0
+}
+
+// BusinessMode сообщает, включен ли бизнес-режим в текущей книге учета пользователя
+func (s *ExpenseTracker) BusinessMode(ctx context.Context, userID int64) (bool, error) {
+	ledger, err := s.ActiveLedger(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return ledger.Business, nil
+}
+
+// SetLedgerBusiness включает или выключает бизнес-режим книги учета. Реквизиты
+// транзакций при выключении сохраняются
+func (s *ExpenseTracker) SetLedgerBusiness(ctx context.Context, userID int64, ledgerID string, business bool) error {
+	ledger, err := s.findLedger(ctx, userID, ledgerID)
+	if err != nil {
+		return err
+	}
+	ledger.Business = business
+	return s.repo.UpdateLedger(ctx, ledger)
+}
+
+// SetTransactionBusiness сохраняет реквизиты транзакции из сообщения в формате ParseBusinessDetails
+func (s *ExpenseTracker) SetTransactionBusiness(ctx context.Context, userID int64, transactionID, text string) (*model.Transaction, error) {
+	business, err := s.BusinessMode(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !business {
+		return nil, ErrNotBusinessLedger
+	}
+	transaction, err := s.GetTransaction(ctx, userID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	details, err := ParseBusinessDetails(text, transaction.AbsAmount())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateTransactionBusiness(ctx, transactionID, userID, details.Counterparty, details.InvoiceNumber, details.VATAmount); err != nil {
+		return nil, fmt.Errorf("failed to save business details: %w", err)
+	}
+	transaction.Counterparty, transaction.InvoiceNumber, transaction.VATAmount = details.Counterparty, details.InvoiceNumber, details.VATAmount
+	return transaction, nil
+}
+
+// CounterpartyTotal - обороты с контрагентом за квартал
+type CounterpartyTotal struct {
+	Name     string
+	Company  bool
+	Income   float64
+	Expenses float64
+	Invoices int // операции с номером счета
+}
+
+// QuarterReport - квартальный отчет бизнес-книги: доходы и расходы, НДС, налог
+// самозанятого и обороты по контрагентам
+type QuarterReport struct {
+	Year, Quarter int
+	Start, End    time.Time
+	Income        float64
+	Expenses      float64
+	VATCollected  float64 // НДС в доходах
+	VATPaid       float64 // НДС в расходах
+	// Доходы для налога самозанятого. Доходы без контрагента считаются полученными от физлиц
+	IndividualIncome float64
+	CompanyIncome    float64
+	YearIncome       float64 // доходы с начала года по конец квартала
+	// Counterparties - контрагенты по убыванию оборота
+	Counterparties []CounterpartyTotal
+	Transactions   int
+	Untagged       int // операции без контрагента
+}
+
+// NPD возвращает налог на профессиональный доход за квартал
+func (r *QuarterReport) NPD() float64 {
+	return r.IndividualIncome*NPDIndividualRate + r.CompanyIncome*NPDCompanyRate
+}
+
+// VATDue возвращает разницу между НДС в доходах и расходах
+func (r *QuarterReport) VATDue() float64 {
+	return r.VATCollected - r.VATPaid
+}
+
+// LimitLeft возвращает, сколько еще можно заработать в году без потери статуса самозанятого
+func (r *QuarterReport) LimitLeft() float64 {
+	return NPDYearLimit - r.YearIncome
+}
+
+// QuarterOf возвращает номер квартала даты
+func QuarterOf(date time.Time) int {
+	return (int(date.Month())-1)/3 + 1
+}
+
+// GetQuarterReport строит квартальный отчет бизнес-книги. Незаконченный квартал
+// считается до сегодняшнего дня
+func (s *ExpenseTracker) GetQuarterReport(ctx context.Context, userID int64, year, quarter int) (*QuarterReport, error) {
+	if quarter < 1 || quarter > 4 {
+		return nil, fmt.Errorf("%w: quarter must be 1-4", model.ErrValidation)
+	}
+	business, err := s.BusinessMode(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !business {
+		return nil, ErrNotBusinessLedger
+	}
+
+	now := s.now()
+	yearStart := time.Date(year, time.January, 1, 0, 0, 0, 0, now.Location())
+	start := yearStart.AddDate(0, 3*(quarter-1), 0)
+	end := start.AddDate(0, 3, 0).Add(-time.Nanosecond)
+	if now.Before(end) {
+		end = now
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("%w: quarter %d of %d has not started", model.ErrValidation, quarter, year)
+	}
+
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{
+		StartDate: &yearStart,
+		EndDate:   &end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	report := &QuarterReport{Year: year, Quarter: quarter, Start: start, End: end}
+	counterparties := make(map[string]*CounterpartyTotal)
+	for _, t := range transactions {
+		if t.IsIncome() {
+			report.YearIncome += t.AbsAmount()
+		}
+		if t.Date.Before(start) {
+			continue
+		}
+
+		report.Transactions++
+		if t.IsIncome() {
+			report.Income += t.AbsAmount()
+			report.VATCollected += t.VATAmount
+			if IsCompany(t.Counterparty) {
+				report.CompanyIncome += t.AbsAmount()
+			} else {
+				report.IndividualIncome += t.AbsAmount()
+			}
+		} else {
+			report.Expenses += t.AbsAmount()
+			report.VATPaid += t.VATAmount
+		}
+
+		if t.Counterparty == "" {
+			report.Untagged++
+			continue
+		}
+		key := strings.ToLower(t.Counterparty)
+		total, ok := counterparties[key]
+		if !ok {
+			total = &CounterpartyTotal{Name: t.Counterparty, Company: IsCompany(t.Counterparty)}
+			counterparties[key] = total
+		}
+		if t.IsIncome() {
+			total.Income += t.AbsAmount()
+		} else {
+			total.Expenses += t.AbsAmount()
+		}
+		if t.InvoiceNumber != "" {
+			total.Invoices++
+		}
+	}
+
+	for _, total := range counterparties {
+		report.Counterparties = append(report.Counterparties, *total)
+	}
+	sort.Slice(report.Counterparties, func(i, j int) bool {
+		a, b := report.Counterparties[i], report.Counterparties[j]
+		return a.Income+a.Expenses > b.Income+b.Expenses
+	})
+	return report, nil
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// categoryUsageDays - за сколько дней считается частота использования категорий
+const categoryUsageDays = 90
+
+const (
+	// MaxCategoryNameLength - сколько символов может быть в названии категории
+	MaxCategoryNameLength = 32
+	// MaxCategories - сколько категорий, включая подкатегории и архив, может быть у пользователя
+	MaxCategories = 100
+)
+
+// Ошибки проверки категории
+var (
+	ErrCategoryNameEmpty   = fmt.Errorf("%w: category name is empty", model.ErrValidation)
+	ErrCategoryNameTooLong = fmt.Errorf("%w: category name is too long", model.ErrValidation)
+	ErrCategoryType        = fmt.Errorf("%w: invalid category type", model.ErrValidation)
+	ErrCategoryExists      = fmt.Errorf("%w: category already exists", model.ErrValidation)
+	ErrTooManyCategories   = fmt.Errorf("%w: too many categories", model.ErrValidation)
+)
+
+// validateCategory проверяет название и тип категории перед сохранением. Название
+// уникально среди категорий того же типа с тем же родителем, без учета регистра.
+// categories - все категории пользователя, сама проверяемая категория пропускается
+func validateCategory(categories []model.Category, category *model.Category) error {
+	category.Name = strings.Join(strings.Fields(category.Name), " ")
+	if category.Name == "" {
+		return ErrCategoryNameEmpty
+	}
+	if utf8.RuneCountInString(category.Name) > MaxCategoryNameLength {
+		return fmt.Errorf("%w: %d characters", ErrCategoryNameTooLong, utf8.RuneCountInString(category.Name))
+	}
+	if category.Type != "expense" && category.Type != "income" {
+		return fmt.Errorf("%w: %q", ErrCategoryType, category.Type)
+	}
+	for _, c := range categories {
+		if c.ID != category.ID && c.Type == category.Type && c.ParentID == category.ParentID &&
+			strings.EqualFold(c.Name, category.Name) {
+			return fmt.Errorf("%w: %s", ErrCategoryExists, category.Name)
+		}
+	}
+	return nil
+}
+
+// GetInputCategories возвращает категории для выбора при вводе транзакции: без архива,
+// в порядке пользователя или сначала самые используемые, если так выбрано в настройках
+func (s *ExpenseTracker) GetInputCategories(ctx context.Context, userID int64) ([]model.Category, error) {
+	categories, err := s.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	categories = model.ActiveCategories(categories)
+
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.SortCategoriesByUsage() {
+		return categories, nil
+	}
+
+	since := time.Now().AddDate(0, 0, -categoryUsageDays)
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{StartDate: &since})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	// Транзакции подкатегорий поднимают и родительскую категорию
+	parents := make(map[string]string, len(categories))
+	for _, cat := range categories {
+		parents[cat.ID] = cat.ParentID
+	}
+	usage := make(map[string]int)
+	for _, t := range transactions {
+		usage[t.CategoryID]++
+		if parent := parents[t.CategoryID]; parent != "" {
+			usage[parent]++
+		}
+	}
+
+	// При равной частоте сохраняется порядок пользователя
+	sort.SliceStable(categories, func(i, j int) bool {
+		return usage[categories[i].ID] > usage[categories[j].ID]
+	})
+	return categories, nil
+}
+
+// MoveCategory сдвигает категорию на одно место вверх или вниз среди категорий того же
+// типа и уровня. Места соседей пересчитываются, чтобы порядок не зависел от старых значений
+func (s *ExpenseTracker) MoveCategory(ctx context.Context, userID int64, categoryID string, up bool) error {
+	categories, err := s.GetCategories(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	var target *model.Category
+	for i := range categories {
+		if categories[i].ID == categoryID {
+			target = &categories[i]
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("category %s not found: %w", categoryID, model.ErrNotFound)
+	}
+
+	var siblings []model.Category
+	index := -1
+	for _, cat := range model.ActiveCategories(categories) {
+		if cat.Type != target.Type || cat.ParentID != target.ParentID {
+			continue
+		}
+		if cat.ID == categoryID {
+			index = len(siblings)
+		}
+		siblings = append(siblings, cat)
+	}
+
+	neighbour := index + 1
+	if up {
+		neighbour = index - 1
+	}
+	if index < 0 || neighbour < 0 || neighbour >= len(siblings) {
+		return nil
+	}
+	siblings[index], siblings[neighbour] = siblings[neighbour], siblings[index]
+
+	for i := range siblings {
+		if siblings[i].SortOrder == i+1 {
+			continue
+		}
+		siblings[i].SortOrder = i + 1
+		if err := s.repo.UpdateCategory(ctx, &siblings[i]); err != nil {
+			return fmt.Errorf("failed to update category: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetCategorySort сохраняет порядок категорий при вводе транзакции
+func (s *ExpenseTracker) SetCategorySort(ctx context.Context, userID int64, order string) (*model.UserSettings, error) {
+	if order != model.CategorySortManual && order != model.CategorySortUsage {
+		return nil, fmt.Errorf("unknown category sort: %s", order)
+	}
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings.CategorySort = order
+	if err := s.SaveUserSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SetCategoryLimit задает лимит трат категории за месяц, 0 снимает лимит.
+// Лимит не связан с бюджетами: о его превышении бот предупреждает сразу при записи траты
+func (s *ExpenseTracker) SetCategoryLimit(ctx context.Context, userID int64, categoryID string, limit float64) (*model.Category, error) {
+	if limit < 0 {
+		return nil, fmt.Errorf("%w: invalid category limit: %.2f", model.ErrValidation, limit)
+	}
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	for _, category := range categories {
+		if category.ID != categoryID {
+			continue
+		}
+		if category.Type != "expense" {
+			return nil, fmt.Errorf("category %s is not an expense category", categoryID)
+		}
+		category.SpendingLimit = limit
+		if err := s.repo.UpdateCategory(ctx, &category); err != nil {
+			return nil, fmt.Errorf("failed to update category: %w", err)
+		}
+		return &category, nil
+	}
+	return nil, fmt.Errorf("category %s not found: %w", categoryID, model.ErrNotFound)
+}
+
+// CategoryLimit - лимит категории и траты по ней с начала месяца
+type CategoryLimit struct {
+	Category model.Category
+	Spent    float64
+}
+
+// Over возвращает перерасход, 0 - лимит не превышен
+func (l CategoryLimit) Over() float64 {
+	return math.Max(l.Spent-l.Category.SpendingLimit, 0)
+}
+
+// GetCategoryLimits возвращает категории с лимитами и траты по ним с начала месяца.
+// Траты подкатегорий учитываются и в лимите родителя
+func (s *ExpenseTracker) GetCategoryLimits(ctx context.Context, userID int64) ([]CategoryLimit, error) {
+	categories, err := s.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	var limits []CategoryLimit
+	for _, cat := range categories {
+		if cat.SpendingLimit > 0 {
+			limits = append(limits, CategoryLimit{Category: cat})
+		}
+	}
+	if len(limits) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{
+		StartDate: &monthStart,
+		Type:      "expense",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	parents := make(map[string]string, len(categories))
+	for _, cat := range categories {
+		parents[cat.ID] = cat.ParentID
+	}
+	spent := make(map[string]float64)
+	for _, t := range transactions {
+		if t.IsIncome() {
+			continue
+		}
+		spent[t.CategoryID] += t.AbsAmount()
+		if parent := parents[t.CategoryID]; parent != "" {
+			spent[parent] += t.AbsAmount()
+		}
+	}
+	for i := range limits {
+		limits[i].Spent = spent[limits[i].Category.ID]
+	}
+	return limits, nil
+}
+
+// CheckCategoryLimit вызывается после записи траты и возвращает предупреждение, если
+// категория или ее родитель вышли за лимит месяца: на сколько и сколько дней до конца месяца
+func (s *ExpenseTracker) CheckCategoryLimit(ctx context.Context, userID int64, categoryID string, amount float64) (string, error) {
+	if amount >= 0 {
+		return "", nil
+	}
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get categories: %w", err)
+	}
+	category := findCategory(categories, categoryID)
+	if category == nil {
+		return "", nil
+	}
+	parent := findCategory(categories, category.ParentID)
+	if category.SpendingLimit <= 0 && (parent == nil || parent.SpendingLimit <= 0) {
+		return "", nil
+	}
+
+	limits, err := s.GetCategoryLimits(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	daysLeft := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location()).Day() - now.Day()
+	remaining := fmt.Sprintf("до конца месяца %d %s", daysLeft, pluralDays(daysLeft))
+	if daysLeft == 0 {
+		remaining = "сегодня последний день месяца"
+	}
+
+	var warnings []string
+	for _, limit := range limits {
+		if limit.Over() == 0 || (limit.Category.ID != category.ID && (parent == nil || limit.Category.ID != parent.ID)) {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf(
+			"Лимит категории «%s» превышен на %.0f₽: потрачено %.0f₽ из %.0f₽, %s",
+			limit.Category.Name, limit.Over(), limit.Spent, limit.Category.SpendingLimit, remaining))
+	}
+	return strings.Join(warnings, "\n⚠️ "), nil
+}
+
+// findCategory ищет категорию по ID, nil - категории нет
+func findCategory(categories []model.Category, id string) *model.Category {
+	if id == "" {
+		return nil
+	}
+	for i := range categories {
+		if categories[i].ID == id {
+			return &categories[i]
+		}
+	}
+	return nil
+}
+
+// pluralDays возвращает слово "день" в нужной форме
+func pluralDays(n int) string {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return "день"
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20):
+		return "дня"
+	default:
+		return "дней"
+	}
+}
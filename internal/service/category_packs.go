@@ -0,0 +1,85 @@
+package service
+
+import "github.com/ivanoskov/financial_bot/internal/model"
+
+// CategoryPack - стартовый набор категорий, который пользователь выбирает при первом запуске
+type CategoryPack struct {
+	ID         string
+	Title      string
+	Categories []model.Category // название, тип и значок; остальное заполняется при создании
+}
+
+// DefaultCategoryPack - набор для пользователя, который не выбрал другой
+const DefaultCategoryPack = "minimal"
+
+var categoryPacks = []CategoryPack{
+	{
+		ID:    "student",
+		Title: "🎓 Студент",
+		Categories: []model.Category{
+			{Name: "Продукты", Type: "expense", Emoji: "🛒"},
+			{Name: "Кафе и столовые", Type: "expense", Emoji: "🍔"},
+			{Name: "Транспорт", Type: "expense", Emoji: "🚌"},
+			{Name: "Учеба", Type: "expense", Emoji: "📚"},
+			{Name: "Связь и подписки", Type: "expense", Emoji: "📱"},
+			{Name: "Развлечения", Type: "expense", Emoji: "🎉"},
+			{Name: "Стипендия", Type: "income", Emoji: "🎓"},
+			{Name: "Подработка", Type: "income", Emoji: "💼"},
+			{Name: "Помощь родителей", Type: "income", Emoji: "🎁"},
+		},
+	},
+	{
+		ID:    "family",
+		Title: "👪 Семья",
+		Categories: []model.Category{
+			{Name: "Продукты", Type: "expense", Emoji: "🛒"},
+			{Name: "Жилье и ЖКХ", Type: "expense", Emoji: "🏠"},
+			{Name: "Транспорт", Type: "expense", Emoji: "🚗"},
+			{Name: "Дети", Type: "expense", Emoji: "🧸"},
+			{Name: "Здоровье", Type: "expense", Emoji: "💊"},
+			{Name: "Одежда", Type: "expense", Emoji: "👕"},
+			{Name: "Развлечения", Type: "expense", Emoji: "🎉"},
+			{Name: "Зарплата", Type: "income", Emoji: "💼"},
+			{Name: "Пособия", Type: "income", Emoji: "🎁"},
+		},
+	},
+	{
+		ID:    "freelance",
+		Title: "💻 Фрилансер",
+		Categories: []model.Category{
+			{Name: "Продукты", Type: "expense", Emoji: "🛒"},
+			{Name: "Жилье", Type: "expense", Emoji: "🏠"},
+			{Name: "Техника и софт", Type: "expense", Emoji: "💻"},
+			{Name: "Налоги", Type: "expense", Emoji: "🧾"},
+			{Name: "Транспорт", Type: "expense", Emoji: "🚕"},
+			{Name: "Развлечения", Type: "expense", Emoji: "🎉"},
+			{Name: "Заказы", Type: "income", Emoji: "💼"},
+			{Name: "Проценты", Type: "income", Emoji: "📈"},
+		},
+	},
+	{
+		ID:    DefaultCategoryPack,
+		Title: "🧺 Минимальный",
+		Categories: []model.Category{
+			{Name: "Продукты", Type: "expense"},
+			{Name: "Транспорт", Type: "expense"},
+			{Name: "Развлечения", Type: "expense"},
+			{Name: "Зарплата", Type: "income"},
+		},
+	},
+}
+
+// CategoryPacks возвращает стартовые наборы категорий в порядке показа
+func CategoryPacks() []CategoryPack {
+	return categoryPacks
+}
+
+// findCategoryPack возвращает набор по ID, для неизвестного ID - набор по умолчанию
+func findCategoryPack(id string) CategoryPack {
+	for _, pack := range categoryPacks {
+		if pack.ID == id {
+			return pack
+		}
+	}
+	return findCategoryPack(DefaultCategoryPack)
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// closingHighlights - сколько категорий показывать среди побед и провалов месяца
+	closingHighlights = 3
+	// closingBudgetStep - до скольких рублей округляется предложенный бюджет вместо превышенного
+	closingBudgetStep = 500
+)
+
+// SuggestedBudget - бюджет категории, который предлагается установить на следующий месяц
+type SuggestedBudget struct {
+	CategoryID   string
+	CategoryName string
+	Amount       float64
+}
+
+// MonthClosing - итоги закрытого месяца в сравнении с предыдущим
+type MonthClosing struct {
+	*CustomComparison // First - предыдущий месяц, Second - закрытый
+	// Wins - категории, траты в которых снизились сильнее всего, Regressions - выросли
+	Wins        []CategoryComparison
+	Regressions []CategoryComparison
+	// Budgets - исполнение бюджетов на конец месяца
+	Budgets []BudgetStatus
+	// Suggestions - бюджеты на следующий месяц: превышенные бюджеты по фактическим тратам
+	// и рекомендации по экономии
+	Suggestions []SuggestedBudget
+}
+
+// GetMonthClosing подводит итоги месяца, в который попадает day: доходы, расходы и доля
+// сбережений в сравнении с предыдущим месяцем, победы и провалы по категориям и исполнение
+// бюджетов. Для месяца без операций возвращает nil
+func (s *ExpenseTracker) GetMonthClosing(ctx context.Context, userID int64, day time.Time) (*MonthClosing, error) {
+	start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+	month := Period{Start: start, End: start.AddDate(0, 1, 0).Add(-time.Nanosecond)}
+	prev := Period{Start: start.AddDate(0, -1, 0), End: start.Add(-time.Nanosecond)}
+
+	comparison, err := s.ComparePeriods(ctx, userID, prev, month)
+	if err != nil {
+		return nil, err
+	}
+	if comparison.CurrentPeriod.TotalIncome == 0 && comparison.CurrentPeriod.TotalExpenses == 0 {
+		return nil, nil
+	}
+	closing := &MonthClosing{CustomComparison: comparison}
+
+	// Новые категории и категории без трат в прошлом месяце не сравниваются:
+	// разовая покупка - еще не провал
+	for _, category := range comparison.Expenses {
+		switch {
+		case category.First == 0 || category.Second == category.First:
+		case category.Second < category.First:
+			closing.Wins = append(closing.Wins, category)
+		default:
+			closing.Regressions = append(closing.Regressions, category)
+		}
+	}
+	sort.SliceStable(closing.Wins, func(i, j int) bool {
+		return closing.Wins[i].First-closing.Wins[i].Second > closing.Wins[j].First-closing.Wins[j].Second
+	})
+	sort.SliceStable(closing.Regressions, func(i, j int) bool {
+		return closing.Regressions[i].Second-closing.Regressions[i].First > closing.Regressions[j].Second-closing.Regressions[j].First
+	})
+	closing.Wins = closing.Wins[:min(len(closing.Wins), closingHighlights)]
+	closing.Regressions = closing.Regressions[:min(len(closing.Regressions), closingHighlights)]
+
+	if closing.Budgets, err = s.budgetStatusesAt(ctx, userID, month.End); err != nil {
+		return nil, err
+	}
+	suggested := make(map[string]bool)
+	for _, status := range closing.Budgets {
+		if status.Remaining() < 0 {
+			suggested[status.Budget.CategoryID] = true
+			closing.Suggestions = append(closing.Suggestions, SuggestedBudget{
+				CategoryID:   status.Budget.CategoryID,
+				CategoryName: status.CategoryName,
+				Amount:       math.Ceil(status.Spent/closingBudgetStep) * closingBudgetStep,
+			})
+		}
+	}
+	advices, err := s.GetSavingsAdvice(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, advice := range advices {
+		if !suggested[advice.CategoryID] {
+			closing.Suggestions = append(closing.Suggestions, SuggestedBudget{
+				CategoryID:   advice.CategoryID,
+				CategoryName: advice.CategoryName,
+				Amount:       advice.SuggestedBudget,
+			})
+		}
+	}
+	return closing, nil
+}
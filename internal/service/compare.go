@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// Period - произвольный период для сравнения, End - последний момент периода
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// String возвращает период в виде "01.07.2026 - 14.07.2026"
+func (p Period) String() string {
+	return p.Start.Format("02.01.2006") + " - " + p.End.Format("02.01.2006")
+}
+
+// Days возвращает число дней в периоде
+func (p Period) Days() int {
+	return int(p.End.Sub(p.Start).Hours()/24) + 1
+}
+
+// Previous возвращает предыдущий период такой же длительности, который заканчивается
+// прямо перед началом p: у месяца - столько же дней до его начала
+func (p Period) Previous() Period {
+	end := p.Start.Add(-time.Nanosecond)
+	return Period{Start: end.Add(-p.End.Sub(p.Start)), End: end}
+}
+
+// ParsePeriod разбирает период из текста: "01.07.2026-14.07.2026", "01.07-14.07"
+// (в текущем году) или месяц целиком "07.2026"
+func ParsePeriod(text string, now time.Time) (Period, error) {
+	text = strings.ReplaceAll(strings.TrimSpace(text), " ", "")
+	text = strings.NewReplacer("—", "-", "–", "-").Replace(text)
+
+	if month, err := time.ParseInLocation("01.2006", text, now.Location()); err == nil {
+		return Period{
+			Start: month,
+			End:   month.AddDate(0, 1, 0).Add(-time.Nanosecond),
+		}, nil
+	}
+
+	from, to, ok := strings.Cut(text, "-")
+	if !ok {
+		return Period{}, fmt.Errorf("invalid period: %s", text)
+	}
+	start, err := parsePeriodDate(from, now)
+	if err != nil {
+		return Period{}, err
+	}
+	end, err := parsePeriodDate(to, now)
+	if err != nil {
+		return Period{}, err
+	}
+	if end.Before(start) {
+		return Period{}, fmt.Errorf("period ends before it starts: %s", text)
+	}
+	return Period{Start: start, End: end.AddDate(0, 0, 1).Add(-time.Nanosecond)}, nil
+}
+
+// parsePeriodDate разбирает дату "02.01.2006" или "02.01" в текущем году
+func parsePeriodDate(value string, now time.Time) (time.Time, error) {
+	if date, err := time.ParseInLocation("02.01.2006", value, now.Location()); err == nil {
+		return date, nil
+	}
+	date, err := time.ParseInLocation("02.01", value, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %s: %w", value, err)
+	}
+	return time.Date(now.Year(), date.Month(), date.Day(), 0, 0, 0, 0, now.Location()), nil
+}
+
+// CategoryComparison - траты категории в двух сравниваемых периодах
+type CategoryComparison struct {
+	Name   string
+	Emoji  string
+	First  float64
+	Second float64
+}
+
+// CustomComparison - сравнение двух произвольных периодов, например отпуска и обычного месяца.
+// В PeriodComparison первый период - PrevPeriod, второй - CurrentPeriod
+type CustomComparison struct {
+	First  Period
+	Second Period
+	PeriodComparison
+	// Expenses - расходы по категориям в обоих периодах, подкатегории входят в родителя.
+	// Отсортированы по большей из двух сумм
+	Expenses []CategoryComparison
+}
+
+// ComparePeriods сравнивает доходы, расходы и траты по категориям за два произвольных периода.
+// Периоды могут быть разной длины, поэтому для сравнения есть и средние значения в день
+func (s *ExpenseTracker) ComparePeriods(ctx context.Context, userID int64, first, second Period) (*CustomComparison, error) {
+	data, err := s.repo.GetReportData(ctx, userID,
+		model.TransactionFilter{StartDate: &second.Start, EndDate: &second.End},
+		model.TransactionFilter{StartDate: &first.Start, EndDate: &first.End})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report data: %w", err)
+	}
+
+	// Траты подкатегорий считаются в родительской категории, как в отчетах
+	names := make(map[string]string, len(data.Categories))
+	emojis := make(map[string]string, len(data.Categories))
+	for _, cat := range data.Categories {
+		names[cat.ID] = cat.Name
+		emojis[cat.Name] = cat.Icon()
+	}
+	for _, cat := range data.Categories {
+		if parent, ok := names[cat.ParentID]; ok {
+			names[cat.ID] = parent
+		}
+	}
+
+	firstStats := analyzePeriod(data.Previous, first.Start, first.End, names)
+	secondStats := analyzePeriod(data.Current, second.Start, second.End, names)
+
+	comparison := &CustomComparison{
+		First:            first,
+		Second:           second,
+		PeriodComparison: newPeriodComparison(firstStats, secondStats),
+	}
+	seen := make(map[string]bool)
+	for _, byCategory := range []map[string]float64{firstStats.ExpensesByCategory, secondStats.ExpensesByCategory} {
+		for name := range byCategory {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			comparison.Expenses = append(comparison.Expenses, CategoryComparison{
+				Name:   name,
+				Emoji:  emojis[name],
+				First:  firstStats.ExpensesByCategory[name],
+				Second: secondStats.ExpensesByCategory[name],
+			})
+		}
+	}
+	sort.Slice(comparison.Expenses, func(i, j int) bool {
+		a, b := comparison.Expenses[i], comparison.Expenses[j]
+		return max(a.First, a.Second) > max(b.First, b.Second)
+	})
+	return comparison, nil
+}
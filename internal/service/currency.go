@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// defaultCurrency - валюта учета, если она не задана в конфигурации
+const defaultCurrency = "RUB"
+
+// defaultRateCurrencies - валюты для /rate, пока пользователь не записывал покупок в валюте
+var defaultRateCurrencies = []string{"USD", "EUR", "CNY"}
+
+// currencySymbols - значки валют, которые можно писать рядом с суммой
+var currencySymbols = map[string]string{
+	"$": "USD", "€": "EUR", "£": "GBP", "¥": "CNY", "₸": "KZT", "₺": "TRY", "₾": "GEL", "₽": "RUB",
+	"Р": "RUB", "РУБ": "RUB",
+}
+
+// currencyCode - код валюты ISO 4217
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+var (
+	// ErrRateProviderNotConfigured возвращается, если источник курсов валют не подключен
+	ErrRateProviderNotConfigured = errors.New("exchange rate provider is not configured")
+	// ErrUnknownCurrency возвращается для валюты, курса которой нет у источника
+	ErrUnknownCurrency = fmt.Errorf("%w: unknown currency", model.ErrValidation)
+)
+
+// RateProvider возвращает официальные курсы валют на день: сколько рублей стоит единица
+// валюты. Если на этот день курс не устанавливался, возвращается последний до него
+type RateProvider interface {
+	DailyRates(ctx context.Context, day time.Time) (map[string]float64, error)
+}
+
+// SetRateProvider подключает источник курсов валют
+func (s *ExpenseTracker) SetRateProvider(provider RateProvider) {
+	s.rates = provider
+}
+
+// SetBaseCurrency задает валюту учета: в ней хранятся суммы транзакций
+func (s *ExpenseTracker) SetBaseCurrency(code string) {
+	s.currency = code
+}
+
+// BaseCurrency возвращает валюту учета
+func (s *ExpenseTracker) BaseCurrency() string {
+	return s.currency
+}
+
+// ParseAmount разбирает сумму с необязательной валютой: "25", "25.5$", "$25", "25usd", "€25".
+// Возвращает сумму и код валюты, пустой для суммы без валюты
+func ParseAmount(value string) (float64, string, error) {
+	number, code := strings.TrimSpace(value), ""
+	for symbol, symbolCode := range currencySymbols {
+		if rest, ok := strings.CutPrefix(number, symbol); ok && !unicode.IsLetter([]rune(symbol)[0]) {
+			number, code = rest, symbolCode
+			break
+		}
+	}
+	if code == "" {
+		// Код или значок валюты после суммы: "25usd", "25$", "300р"
+		if i := strings.IndexFunc(number, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' && r != ',' }); i > 0 {
+			number, code = number[:i], strings.ToUpper(strings.TrimSpace(number[i:]))
+			if symbolCode, ok := currencySymbols[code]; ok {
+				code = symbolCode
+			} else if !currencyCode.MatchString(code) {
+				return 0, "", fmt.Errorf("неверная сумма: %q", value)
+			}
+		}
+	}
+
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(number, ",", "."), 64)
+	if err != nil || amount <= 0 {
+		return 0, "", fmt.Errorf("неверная сумма: %q", value)
+	}
+	return amount, code, nil
+}
+
+// cutCurrencyCode отделяет код валюты, записанный отдельным словом после суммы:
+// "USD такси" - USD и "такси". Код распознается только заглавными буквами, чтобы
+// не путать его с описанием
+func cutCurrencyCode(description string) (string, string) {
+	code, rest, _ := strings.Cut(description, " ")
+	if !currencyCode.MatchString(code) {
+		return "", description
+	}
+	return code, strings.TrimSpace(rest)
+}
+
+// ExchangeRate возвращает курс валюты на день: сколько единиц валюты учета стоит единица валюты
+func (s *ExpenseTracker) ExchangeRate(ctx context.Context, code string, day time.Time) (float64, error) {
+	if code == s.currency {
+		return 1, nil
+	}
+	rates, err := s.dailyRates(ctx, day)
+	if err != nil {
+		return 0, err
+	}
+	rate, ok := rates[code]
+	if !ok {
+		return 0, fmt.Errorf("%w %s", ErrUnknownCurrency, code)
+	}
+	return rate, nil
+}
+
+// dailyRates возвращает курсы к валюте учета за день. Курсы дня загружаются у источника
+// один раз и сохраняются в базе; курсов на будущие дни нет, для них берется сегодняшний
+func (s *ExpenseTracker) dailyRates(ctx context.Context, day time.Time) (map[string]float64, error) {
+	if now := s.now(); day.After(now) {
+		day = now
+	}
+	date := day.Format("2006-01-02")
+	cached, err := s.repo.GetExchangeRates(ctx, s.currency, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+	rates := make(map[string]float64, len(cached))
+	if len(cached) > 0 {
+		for _, rate := range cached {
+			rates[rate.Currency] = rate.Rate
+		}
+		return rates, nil
+	}
+
+	if s.rates == nil {
+		return nil, ErrRateProviderNotConfigured
+	}
+	rub, err := s.rates.DailyRates(ctx, day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exchange rates: %w", err)
+	}
+	rub["RUB"] = 1
+	// Источник дает курсы к рублю, к другой валюте учета пересчитываем через рубль
+	base, ok := rub[s.currency]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownCurrency, s.currency)
+	}
+	records := make([]model.ExchangeRate, 0, len(rub))
+	for code, value := range rub {
+		if code == s.currency {
+			continue
+		}
+		rates[code] = value / base
+		records = append(records, model.ExchangeRate{Date: date, Base: s.currency, Currency: code, Rate: rates[code]})
+	}
+	// Без сохранения курсы загрузятся еще раз при следующем запросе
+	if err := s.repo.SaveExchangeRates(ctx, records); err != nil {
+		log.Printf("Error saving exchange rates for %s: %v", date, err)
+	}
+	return rates, nil
+}
+
+// convertCurrency пересчитывает покупку в другой валюте в валюту учета по курсу
+// на дату транзакции. Сумма в валюте покупки сохраняется в OriginalAmount
+func (s *ExpenseTracker) convertCurrency(ctx context.Context, transaction *model.Transaction) error {
+	if transaction.Currency == s.currency {
+		transaction.Currency = ""
+	}
+	if transaction.Currency == "" || transaction.OriginalAmount != 0 {
+		return nil
+	}
+	rate, err := s.ExchangeRate(ctx, transaction.Currency, transaction.Date)
+	if err != nil {
+		return err
+	}
+	transaction.OriginalAmount = transaction.Amount
+	transaction.Amount = math.Round(transaction.OriginalAmount*rate*100) / 100
+	return nil
+}
+
+// CurrencyRate - курс валюты и его изменение за день
+type CurrencyRate struct {
+	Code   string
+	Rate   float64
+	Change float64 // разница с курсом предыдущего дня
+}
+
+// CurrencyRates - курсы валют пользователя к валюте учета на сегодня
+type CurrencyRates struct {
+	Base  string
+	Date  time.Time
+	Rates []CurrencyRate
+}
+
+// CurrentRates возвращает сегодняшние курсы валют, в которых пользователь делал покупки
+// за последний год, частые первыми. Без таких покупок показывает основные валюты
+func (s *ExpenseTracker) CurrentRates(ctx context.Context, userID int64) (*CurrencyRates, error) {
+	now := s.now()
+	since := now.AddDate(-1, 0, 0)
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{StartDate: &since})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	counts := make(map[string]int)
+	for _, t := range transactions {
+		if t.Currency != "" && t.Currency != s.currency {
+			counts[t.Currency]++
+		}
+	}
+	var codes []string
+	for code := range counts {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if counts[codes[i]] != counts[codes[j]] {
+			return counts[codes[i]] > counts[codes[j]]
+		}
+		return codes[i] < codes[j]
+	})
+	if len(codes) == 0 {
+		for _, code := range defaultRateCurrencies {
+			if code != s.currency {
+				codes = append(codes, code)
+			}
+		}
+	}
+
+	today, err := s.dailyRates(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	yesterday, err := s.dailyRates(ctx, now.AddDate(0, 0, -1))
+	if err != nil {
+		// Без вчерашних курсов показываем курсы без изменения
+		log.Printf("Error getting previous exchange rates: %v", err)
+	}
+
+	result := &CurrencyRates{Base: s.currency, Date: now}
+	for _, code := range codes {
+		rate, ok := today[code]
+		if !ok {
+			continue
+		}
+		current := CurrencyRate{Code: code, Rate: rate}
+		if previous, ok := yesterday[code]; ok {
+			current.Change = rate - previous
+		}
+		result.Rates = append(result.Rates, current)
+	}
+	return result, nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// GetUserSettings возвращает настройки пользователя, для нового пользователя - настройки по умолчанию
+func (s *ExpenseTracker) GetUserSettings(ctx context.Context, userID int64) (*model.UserSettings, error) {
+	settings, _, err := s.getUserSettings(ctx, userID)
+	return settings, err
+}
+
+// SaveUserSettings сохраняет настройки пользователя
+func (s *ExpenseTracker) SaveUserSettings(ctx context.Context, settings *model.UserSettings) error {
+	settings.UpdatedAt = time.Now()
+	return s.repo.SaveUserSettings(ctx, settings)
+}
+
+// UpdateNotificationSettings изменяет настройки уведомлений пользователя
+func (s *ExpenseTracker) UpdateNotificationSettings(ctx context.Context, userID int64, update func(*model.NotificationSettings)) (*model.UserSettings, error) {
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	update(&settings.NotificationSettings)
+	if err := s.SaveUserSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SetReportLayout сохраняет вид текста отчетов
+func (s *ExpenseTracker) SetReportLayout(ctx context.Context, userID int64, layout string) (*model.UserSettings, error) {
+	if layout != model.ReportLayoutDetailed && layout != model.ReportLayoutCompact {
+		return nil, fmt.Errorf("unknown report layout: %s", layout)
+	}
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings.ReportLayout = layout
+	if err := s.SaveUserSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SetCompactDaily включает или выключает компактную сводку за день
+func (s *ExpenseTracker) SetCompactDaily(ctx context.Context, userID int64, compact bool) (*model.UserSettings, error) {
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings.CompactDaily = compact
+	if err := s.SaveUserSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SetChartFormat сохраняет, как присылать графики: сжатыми фото или файлами
+func (s *ExpenseTracker) SetChartFormat(ctx context.Context, userID int64, format string) (*model.UserSettings, error) {
+	switch format {
+	case model.ChartFormatPhoto, model.ChartFormatPNG, model.ChartFormatSVG:
+	default:
+		return nil, fmt.Errorf("unknown chart format: %s", format)
+	}
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings.ChartFormat = format
+	if err := s.SaveUserSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SetChartScale сохраняет масштаб графиков-файлов
+func (s *ExpenseTracker) SetChartScale(ctx context.Context, userID int64, scale int) (*model.UserSettings, error) {
+	if scale < 1 || scale > model.MaxChartScale {
+		return nil, fmt.Errorf("%w: chart scale must be between 1 and %d", model.ErrValidation, model.MaxChartScale)
+	}
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings.ChartScale = scale
+	if err := s.SaveUserSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SetTrendChart сохраняет вид графика трендов
+func (s *ExpenseTracker) SetTrendChart(ctx context.Context, userID int64, trendChart string) (*model.UserSettings, error) {
+	if trendChart != model.TrendChartChange && trendChart != model.TrendChartAbsolute {
+		return nil, fmt.Errorf("unknown trend chart: %s", trendChart)
+	}
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings.TrendChart = trendChart
+	if err := s.SaveUserSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SetTimezone сохраняет часовой пояс пользователя, пустое название - пояс сервера
+func (s *ExpenseTracker) SetTimezone(ctx context.Context, userID int64, name string) (*model.UserSettings, error) {
+	if name != "" {
+		if _, err := time.LoadLocation(name); err != nil {
+			return nil, fmt.Errorf("%w: unknown timezone %s", model.ErrValidation, name)
+		}
+	}
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings.Timezone = name
+	if err := s.SaveUserSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// userLocation возвращает часовой пояс пользователя. Без настроек отчет строится
+// в поясе сервера
+func (s *ExpenseTracker) userLocation(ctx context.Context, userID int64) *time.Location {
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting settings for timezone: %v", err)
+		return time.Local
+	}
+	return settings.Location()
+}
+
+// SetReportSections сохраняет включенные разделы отчета и их порядок. nil возвращает
+// разделы по умолчанию
+func (s *ExpenseTracker) SetReportSections(ctx context.Context, userID int64, sections []string) (*model.UserSettings, error) {
+	known := make(map[string]bool, len(model.DefaultReportSections))
+	for _, section := range model.DefaultReportSections {
+		known[section] = true
+	}
+	seen := make(map[string]bool, len(sections))
+	for _, section := range sections {
+		if !known[section] {
+			return nil, fmt.Errorf("unknown report section: %s", section)
+		}
+		if seen[section] {
+			return nil, fmt.Errorf("duplicate report section: %s", section)
+		}
+		seen[section] = true
+	}
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings.ReportSections = sections
+	if err := s.SaveUserSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// DigestEnabled сообщает, получает ли пользователь регулярный отчет за период
+func DigestEnabled(settings *model.UserSettings, reportType ReportType) bool {
+	switch reportType {
+	case DailyReport:
+		return settings.DailyReport
+	case WeeklyReport:
+		return settings.WeeklyDigest
+	case MonthlyReport:
+		return settings.MonthlyDigest
+	}
+	return false
+}
+
+// DigestDue сообщает, пора ли отправить пользователю регулярный отчет.
+// Ежедневная сводка уходит в выбранный час и не отправляется в тихие дни;
+// еженедельный и ежемесячный отчеты отправляются при каждом срабатывании триггера
+func DigestDue(settings *model.UserSettings, reportType ReportType, now time.Time) bool {
+	if !DigestEnabled(settings, reportType) {
+		return false
+	}
+	if reportType != DailyReport {
+		return true
+	}
+	return now.Hour() == settings.DeliveryHour && !settings.IsQuietDay(now.Weekday())
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+const (
+	// duplicateDateWindow - допустимое расхождение дат у повторно записанной операции
+	duplicateDateWindow = 24 * time.Hour
+	// DuplicateScanDays - период, за который ищутся дубликаты по команде /duplicates
+	DuplicateScanDays = 90
+)
+
+// DuplicatePair - две записи, похожие на одну и ту же операцию.
+// Keep - запись, которую стоит оставить, Drop - которую предлагается удалить
+type DuplicatePair struct {
+	Keep model.Transaction
+	Drop model.Transaction
+}
+
+// FindDuplicates ищет за период пары транзакций с одинаковой суммой, датой в пределах дня
+// и похожим описанием. Каждая транзакция входит не больше чем в одну пару
+func (s *ExpenseTracker) FindDuplicates(ctx context.Context, userID int64, start, end time.Time) ([]DuplicatePair, error) {
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{
+		StartDate: &start,
+		EndDate:   &end,
+		Logical:   true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	return findDuplicatePairs(transactions), nil
+}
+
+// CheckDuplicate проверяет, не повторяет ли только что записанная операция уже существующую.
+// Возвращает nil, если похожих записей нет
+func (s *ExpenseTracker) CheckDuplicate(ctx context.Context, userID int64, amount float64, description string, date time.Time) (*DuplicatePair, error) {
+	start, end := date.Add(-duplicateDateWindow), date.Add(duplicateDateWindow)
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{
+		StartDate: &start,
+		EndDate:   &end,
+		Logical:   true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	var matches []model.Transaction
+	for _, t := range transactions {
+		if !t.IsSplit && math.Abs(t.Amount-amount) <= reconcileAmountEpsilon && similarDescriptions(t.Description, description) {
+			matches = append(matches, t)
+		}
+	}
+	if len(matches) < 2 {
+		return nil, nil
+	}
+
+	// Новая запись создана последней, сравниваем ее с предыдущей
+	sort.Slice(matches, func(i, j int) bool { return matches[i].CreatedAt.Before(matches[j].CreatedAt) })
+	pair := newDuplicatePair(matches[len(matches)-2], matches[len(matches)-1])
+	return &pair, nil
+}
+
+// findDuplicatePairs сопоставляет транзакции попарно в порядке создания
+func findDuplicatePairs(transactions []model.Transaction) []DuplicatePair {
+	sorted := make([]model.Transaction, 0, len(transactions))
+	for _, t := range transactions {
+		// Разделенный платеж сравнивать не с чем: его части записаны отдельно
+		if !t.IsSplit {
+			sorted = append(sorted, t)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+
+	var pairs []DuplicatePair
+	paired := make([]bool, len(sorted))
+	for i := range sorted {
+		if paired[i] {
+			continue
+		}
+		for j := i + 1; j < len(sorted); j++ {
+			if paired[j] || !isDuplicateTransaction(sorted[i], sorted[j]) {
+				continue
+			}
+			paired[i], paired[j] = true, true
+			pairs = append(pairs, newDuplicatePair(sorted[i], sorted[j]))
+			break
+		}
+	}
+	return pairs
+}
+
+func isDuplicateTransaction(a, b model.Transaction) bool {
+	if math.Abs(a.Amount-b.Amount) > reconcileAmountEpsilon {
+		return false
+	}
+	diff := a.Date.Sub(b.Date)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= duplicateDateWindow && similarDescriptions(a.Description, b.Description)
+}
+
+// newDuplicatePair оставляет более раннюю запись, если только у более поздней нет
+// описания, которого не хватает ранней: при объединении сохраняется более полная запись
+func newDuplicatePair(earlier, later model.Transaction) DuplicatePair {
+	if earlier.Description == "" && later.Description != "" {
+		return DuplicatePair{Keep: later, Drop: earlier}
+	}
+	return DuplicatePair{Keep: earlier, Drop: later}
+}
+
+// MergeDuplicate объединяет пару дубликатов, удаляя лишнюю запись
+func (s *ExpenseTracker) MergeDuplicate(ctx context.Context, userID int64, dropID string) error {
+	if err := s.DeleteTransaction(ctx, dropID, userID); err != nil {
+		return fmt.Errorf("failed to delete duplicate: %w", err)
+	}
+	return nil
+}
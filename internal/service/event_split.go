@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// maxPaymentDescription - длина описания платежа участника в символах
+const maxPaymentDescription = 100
+
+var (
+	// ErrEventInviteNotFound возвращается для неизвестного кода приглашения в событие
+	ErrEventInviteNotFound = fmt.Errorf("event invite %w", model.ErrNotFound)
+	// ErrEventClosed возвращается при попытке изменить завершенное событие
+	ErrEventClosed = fmt.Errorf("%w: event is closed", model.ErrValidation)
+	// ErrNoSharedEvent возвращается, если пользователь не участвует ни в одном идущем чужом событии
+	ErrNoSharedEvent = fmt.Errorf("shared event %w", model.ErrNotFound)
+)
+
+// ShareEvent делает событие общим и возвращает код приглашения. Код один на событие:
+// по ссылке может вступить любое число участников, пока событие идет
+func (s *ExpenseTracker) ShareEvent(ctx context.Context, userID int64, ownerName, eventID string) (string, error) {
+	event, err := s.findEvent(ctx, userID, eventID)
+	if err != nil {
+		return "", err
+	}
+	if !event.IsOpen() {
+		return "", ErrEventClosed
+	}
+
+	// Владелец хранится среди участников, чтобы его имя было в расчете
+	if err := s.repo.SaveEventMember(ctx, &model.EventMember{
+		EventID:  event.ID,
+		UserID:   userID,
+		Name:     ownerName,
+		Owner:    true,
+		JoinedAt: time.Now(),
+	}); err != nil {
+		return "", err
+	}
+	if event.Invite != "" {
+		return event.Invite, nil
+	}
+
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate invite code: %w", err)
+	}
+	event.Invite = hex.EncodeToString(buf)
+	if err := s.repo.UpdateEvent(ctx, event); err != nil {
+		return "", err
+	}
+	return event.Invite, nil
+}
+
+// JoinEvent добавляет пользователя в общее событие по коду приглашения
+func (s *ExpenseTracker) JoinEvent(ctx context.Context, code string, userID int64, name string) (*model.Event, error) {
+	event, err := s.repo.GetEventByInvite(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+	if event == nil {
+		return nil, ErrEventInviteNotFound
+	}
+	if !event.IsOpen() {
+		return nil, ErrEventClosed
+	}
+
+	members, err := s.repo.GetEventMembers(ctx, event.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event members: %w", err)
+	}
+	for _, member := range members {
+		if member.UserID == userID {
+			// Повторный переход по ссылке, в том числе владельцем
+			return event, nil
+		}
+	}
+	if err := s.repo.SaveEventMember(ctx, &model.EventMember{
+		EventID:  event.ID,
+		UserID:   userID,
+		Name:     name,
+		JoinedAt: time.Now(),
+	}); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// SharedEvents возвращает чужие общие события, в которых участвует пользователь, новые первыми
+func (s *ExpenseTracker) SharedEvents(ctx context.Context, userID int64) ([]model.Event, error) {
+	memberships, err := s.repo.GetEventMemberships(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event memberships: %w", err)
+	}
+	var events []model.Event
+	for _, membership := range memberships {
+		if membership.Owner {
+			continue
+		}
+		event, err := s.repo.GetEvent(ctx, membership.EventID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get event: %w", err)
+		}
+		if event != nil {
+			events = append(events, *event)
+		}
+	}
+	return events, nil
+}
+
+// AddEventPayment записывает платеж участника за всех в идущее общее событие. Владелец
+// события платит обычными тратами, они попадают в событие сами
+func (s *ExpenseTracker) AddEventPayment(ctx context.Context, userID int64, amount float64, description string) (*model.EventPayment, *model.Event, error) {
+	description = strings.TrimSpace(description)
+	if amount <= 0 || math.IsInf(amount, 0) || math.IsNaN(amount) {
+		return nil, nil, fmt.Errorf("%w: payment amount must be positive", model.ErrValidation)
+	}
+	if utf8.RuneCountInString(description) > maxPaymentDescription {
+		return nil, nil, fmt.Errorf("%w: payment description must be at most %d characters", model.ErrValidation, maxPaymentDescription)
+	}
+
+	events, err := s.SharedEvents(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, event := range events {
+		if !event.IsOpen() {
+			continue
+		}
+		payment := &model.EventPayment{
+			EventID:     event.ID,
+			UserID:      userID,
+			Amount:      math.Round(amount*100) / 100,
+			Description: description,
+			CreatedAt:   time.Now(),
+		}
+		if err := s.repo.CreateEventPayment(ctx, payment); err != nil {
+			return nil, nil, err
+		}
+		markWrote(ctx)
+		return payment, &event, nil
+	}
+	return nil, nil, ErrNoSharedEvent
+}
+
+// DeleteEventPayment отменяет платеж участника
+func (s *ExpenseTracker) DeleteEventPayment(ctx context.Context, userID int64, paymentID string) error {
+	return s.repo.DeleteEventPayment(ctx, paymentID, userID)
+}
+
+// GetEventSplit возвращает расчет общего события для его участника
+func (s *ExpenseTracker) GetEventSplit(ctx context.Context, userID int64, eventID string) (*EventSplit, error) {
+	event, err := s.repo.GetEvent(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+	if event == nil {
+		return nil, ErrEventNotFound
+	}
+	members, err := s.repo.GetEventMembers(ctx, event.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event members: %w", err)
+	}
+	for _, member := range members {
+		if member.UserID == userID {
+			return s.eventSplit(ctx, event, members)
+		}
+	}
+	return nil, ErrEventNotFound
+}
+
+// SplitMember - участник в расчете общего события
+type SplitMember struct {
+	UserID  int64
+	Name    string
+	Owner   bool
+	Paid    float64
+	Balance float64 // больше нуля - участнику должны, меньше - должен он
+}
+
+// SplitTransfer - перевод, которым участники рассчитываются друг с другом
+type SplitTransfer struct {
+	From, To SplitMember
+	Amount   float64
+}
+
+// EventSplit - расчет общего события: кто сколько заплатил и кто кому должен перевести.
+// Расходы делятся поровну между всеми участниками
+type EventSplit struct {
+	Event     model.Event
+	Members   []SplitMember // владелец первым, остальные в порядке вступления
+	Total     float64
+	Share     float64 // доля одного участника
+	Transfers []SplitTransfer
+}
+
+// eventSplit считает, кто сколько заплатил и как рассчитаться. Платежи владельца - траты
+// события за вычетом возвратов, остальных - их EventPayment
+func (s *ExpenseTracker) eventSplit(ctx context.Context, event *model.Event, members []model.EventMember) (*EventSplit, error) {
+	// Траты хранятся под ключом данных события, а расчет могут смотреть все участники
+	transactions, err := s.ledger.Repository.GetTransactions(ctx, event.UserID, model.TransactionFilter{EventID: event.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	payments, err := s.repo.GetEventPayments(ctx, event.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event payments: %w", err)
+	}
+
+	sort.SliceStable(members, func(i, j int) bool {
+		if members[i].Owner != members[j].Owner {
+			return members[i].Owner
+		}
+		return members[i].JoinedAt.Before(members[j].JoinedAt)
+	})
+	paid := make(map[int64]float64, len(members))
+	for _, member := range members {
+		if member.Owner {
+			for _, t := range transactions {
+				paid[member.UserID] -= t.SignedAmount()
+			}
+		}
+	}
+	for _, payment := range payments {
+		paid[payment.UserID] += payment.Amount
+	}
+
+	split := &EventSplit{Event: *event}
+	// Считаем в копейках, чтобы доли сходились с общей суммой без погрешности
+	cents := make([]int64, len(members))
+	var total int64
+	for i, member := range members {
+		cents[i] = int64(math.Round(paid[member.UserID] * 100))
+		total += cents[i]
+	}
+	if len(members) == 0 {
+		return split, nil
+	}
+	share, rest := total/int64(len(members)), total%int64(len(members))
+	balances := make([]int64, len(members))
+	for i, member := range members {
+		owed := share
+		if int64(i) < rest {
+			owed++
+		}
+		balances[i] = cents[i] - owed
+		split.Members = append(split.Members, SplitMember{
+			UserID:  member.UserID,
+			Name:    member.Name,
+			Owner:   member.Owner,
+			Paid:    float64(cents[i]) / 100,
+			Balance: float64(balances[i]) / 100,
+		})
+	}
+	split.Total = float64(total) / 100
+	split.Share = split.Total / float64(len(members))
+	split.Transfers = settleUp(split.Members, balances)
+	return split, nil
+}
+
+// settleUp подбирает переводы: должники по убыванию долга переводят тем, кому должны
+// больше всего. Так переводов получается не больше, чем участников
+func settleUp(members []SplitMember, balances []int64) []SplitTransfer {
+	var creditors, debtors []int
+	for i, balance := range balances {
+		switch {
+		case balance > 0:
+			creditors = append(creditors, i)
+		case balance < 0:
+			debtors = append(debtors, i)
+		}
+	}
+	left := append([]int64(nil), balances...)
+	sort.SliceStable(creditors, func(a, b int) bool { return left[creditors[a]] > left[creditors[b]] })
+	sort.SliceStable(debtors, func(a, b int) bool { return left[debtors[a]] < left[debtors[b]] })
+
+	var transfers []SplitTransfer
+	for c, d := 0, 0; c < len(creditors) && d < len(debtors); {
+		to, from := creditors[c], debtors[d]
+		amount := min(left[to], -left[from])
+		transfers = append(transfers, SplitTransfer{From: members[from], To: members[to], Amount: float64(amount) / 100})
+		left[to] -= amount
+		left[from] += amount
+		if left[to] == 0 {
+			c++
+		}
+		if left[from] == 0 {
+			d++
+		}
+	}
+	return transfers
+}
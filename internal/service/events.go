@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// maxEventName - длина названия события в символах, чтобы оно помещалось на кнопку
+const maxEventName = 32
+
+var (
+	// ErrEventNotFound возвращается для чужого или удаленного события
+	ErrEventNotFound = fmt.Errorf("event %w", model.ErrNotFound)
+	// ErrEventOpen возвращается при попытке начать событие, пока идет другое
+	ErrEventOpen = fmt.Errorf("%w: another event is open", model.ErrValidation)
+)
+
+// GetEvents возвращает события пользователя, новые первыми
+func (s *ExpenseTracker) GetEvents(ctx context.Context, userID int64) ([]model.Event, error) {
+	events, err := s.repo.GetEvents(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+	return events, nil
+}
+
+// OpenEvent возвращает идущее событие или nil, если его нет
+func (s *ExpenseTracker) OpenEvent(ctx context.Context, userID int64) (*model.Event, error) {
+	events, err := s.GetEvents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		if event.IsOpen() {
+			return &event, nil
+		}
+	}
+	return nil, nil
+}
+
+// StartEvent начинает событие: с этого дня новые траты привязываются к нему.
+// Одновременно может идти только одно событие
+func (s *ExpenseTracker) StartEvent(ctx context.Context, userID int64, name, emoji string) (*model.Event, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxEventName {
+		return nil, fmt.Errorf("%w: event name must be 1-%d characters", model.ErrValidation, maxEventName)
+	}
+	open, err := s.OpenEvent(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if open != nil {
+		return nil, ErrEventOpen
+	}
+
+	event := &model.Event{UserID: userID, Name: name, Emoji: emoji, StartedAt: s.now()}
+	if err := s.repo.CreateEvent(ctx, event); err != nil {
+		return nil, err
+	}
+	markWrote(ctx)
+	return event, nil
+}
+
+// CloseEvent завершает событие и возвращает его итоговый отчет
+func (s *ExpenseTracker) CloseEvent(ctx context.Context, userID int64, eventID string) (*EventReport, error) {
+	event, err := s.findEvent(ctx, userID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if event.IsOpen() {
+		closedAt := s.now()
+		event.ClosedAt = &closedAt
+		if err := s.repo.UpdateEvent(ctx, event); err != nil {
+			return nil, err
+		}
+	}
+	return s.eventReport(ctx, userID, event)
+}
+
+// DeleteEvent удаляет событие. Его транзакции остаются в истории и отчетах
+func (s *ExpenseTracker) DeleteEvent(ctx context.Context, userID int64, eventID string) error {
+	if _, err := s.findEvent(ctx, userID, eventID); err != nil {
+		return err
+	}
+	return s.repo.DeleteEvent(ctx, eventID, userID)
+}
+
+// SetTransactionEvent привязывает транзакцию к событию, пустой eventID отвязывает
+func (s *ExpenseTracker) SetTransactionEvent(ctx context.Context, userID int64, transactionID, eventID string) error {
+	if eventID != "" {
+		if _, err := s.findEvent(ctx, userID, eventID); err != nil {
+			return err
+		}
+	}
+	if _, err := s.GetTransaction(ctx, userID, transactionID); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateTransactionEvent(ctx, transactionID, userID, eventID); err != nil {
+		return fmt.Errorf("failed to update transaction event: %w", err)
+	}
+	return nil
+}
+
+// GetEventReport строит отчет события. Для идущего события отчет промежуточный
+func (s *ExpenseTracker) GetEventReport(ctx context.Context, userID int64, eventID string) (*EventReport, error) {
+	event, err := s.findEvent(ctx, userID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return s.eventReport(ctx, userID, event)
+}
+
+// tagOpenEvent привязывает новую трату к идущему событию. Доходы и траты задним числом
+// до начала события не привязываются; их можно добавить из карточки транзакции
+func (s *ExpenseTracker) tagOpenEvent(ctx context.Context, transaction *model.Transaction) {
+	if transaction.EventID != "" || transaction.IsIncome() || transaction.IsSplit {
+		return
+	}
+	event, err := s.OpenEvent(ctx, transaction.UserID)
+	if err != nil {
+		// Без события трата все равно записывается, привязать ее можно позже
+		log.Printf("Error getting open event for user %d: %v", transaction.UserID, err)
+		return
+	}
+	if event == nil {
+		return
+	}
+	start := event.StartedAt.In(transaction.Date.Location())
+	if transaction.Date.Before(time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())) {
+		return
+	}
+	transaction.EventID = event.ID
+}
+
+// findEvent возвращает событие пользователя по ID
+func (s *ExpenseTracker) findEvent(ctx context.Context, userID int64, eventID string) (*model.Event, error) {
+	events, err := s.GetEvents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		if event.ID == eventID {
+			return &event, nil
+		}
+	}
+	return nil, ErrEventNotFound
+}
+
+// EventCategory - траты события в одной категории
+type EventCategory struct {
+	Name   string
+	Emoji  string
+	Amount float64
+	Share  float64 // доля в тратах события, 0..1
+}
+
+// EventReport - отчет события: полная стоимость, траты в день и по категориям
+type EventReport struct {
+	Event    model.Event
+	Days     int // длительность события в днях, не меньше одного
+	Expenses float64
+	Refunds  float64 // доходы, привязанные к событию: возвраты и компенсации
+	// Categories - категории расходов по убыванию, подкатегории входят в родительскую
+	Categories   []EventCategory
+	PeakDay      time.Time // день с наибольшими тратами
+	PeakAmount   float64
+	Transactions int
+	// Split - расчет между участниками, если событие общее
+	Split *EventSplit
+}
+
+// Total возвращает полную стоимость события за вычетом возвратов
+func (r *EventReport) Total() float64 {
+	return r.Expenses - r.Refunds
+}
+
+// PerDay возвращает среднюю стоимость дня события
+func (r *EventReport) PerDay() float64 {
+	return r.Total() / float64(r.Days)
+}
+
+// eventReport собирает отчет по транзакциям события
+func (s *ExpenseTracker) eventReport(ctx context.Context, userID int64, event *model.Event) (*EventReport, error) {
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{EventID: event.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	end := s.now()
+	if event.ClosedAt != nil {
+		end = *event.ClosedAt
+	}
+	report := &EventReport{Event: *event, Days: calendarDays(event.StartedAt, end)}
+
+	groupOf := make(map[string]string, len(categories))
+	for _, cat := range categories {
+		groupOf[cat.ID] = cat.ID
+		if cat.ParentID != "" {
+			groupOf[cat.ID] = cat.ParentID
+		}
+	}
+	groups := make(map[string]*EventCategory)
+	daily := make(map[time.Time]float64)
+	for _, t := range transactions {
+		report.Transactions++
+		if t.IsIncome() {
+			report.Refunds += t.AbsAmount()
+			continue
+		}
+		report.Expenses += t.AbsAmount()
+
+		day := time.Date(t.Date.Year(), t.Date.Month(), t.Date.Day(), 0, 0, 0, 0, t.Date.Location())
+		daily[day] += t.AbsAmount()
+		if daily[day] > report.PeakAmount {
+			report.PeakDay, report.PeakAmount = day, daily[day]
+		}
+
+		id := groupOf[t.CategoryID]
+		group, ok := groups[id]
+		if !ok {
+			group = &EventCategory{Name: "Без категории"}
+			if cat := findCategory(categories, id); cat != nil {
+				group.Name, group.Emoji = cat.Name, cat.Icon()
+			}
+			groups[id] = group
+		}
+		group.Amount += t.AbsAmount()
+	}
+
+	for _, group := range groups {
+		group.Share = group.Amount / report.Expenses
+		report.Categories = append(report.Categories, *group)
+	}
+	sort.Slice(report.Categories, func(i, j int) bool {
+		return report.Categories[i].Amount > report.Categories[j].Amount
+	})
+
+	members, err := s.repo.GetEventMembers(ctx, event.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event members: %w", err)
+	}
+	if len(members) > 1 {
+		if report.Split, err = s.eventSplit(ctx, event, members); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// calendarDays возвращает число календарных дней от start до end включительно, не меньше одного
+func calendarDays(start, end time.Time) int {
+	end = end.In(start.Location())
+	from := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(to.Sub(from).Hours()/24) + 1
+	if days < 1 {
+		return 1
+	}
+	return days
+}
@@ -6,8 +6,11 @@ import (
 	"log"
 	"math"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/ivanoskov/financial_bot/internal/locale"
 	"github.com/ivanoskov/financial_bot/internal/model"
 )
 
@@ -23,44 +26,257 @@ const (
 
 // ExpenseTracker предоставляет методы для работы с финансовыми данными
 type ExpenseTracker struct {
-	repo Repository
+	repo        Repository
+	ledger      *ledgerScope
+	receipts    ReceiptProvider
+	inflation   InflationProvider
+	rates       RateProvider
+	prices      map[string]PriceProvider
+	currency    string // валюта учета, ISO 4217
+	webhooks    WebhookSender
+	reports     ReportCache
+	attachments AttachmentStorage
+	plugins     []Plugin
+	// now возвращает текущее время, в тестах подменяется через SetClock
+	now func() time.Time
+
+	// Фоновые доставки исходящих webhook'ов
+	deliveries sync.WaitGroup
+
+	// Кэш показанных версий "Что нового", чтобы не читать настройки на каждое обновление
+	seenAnnouncements sync.Map
+	// Время последней записи активности пользователя, чтобы не писать в базу на каждое обновление
+	seenUsers sync.Map
+	// Номера сессий меню пользователей, чтобы не читать реестр на каждое обновление
+	menuSessions sync.Map
+	// Время последней очистки обработанных обновлений, в наносекундах Unix
+	lastUpdatePrune atomic.Int64
 }
 
 // Repository определяет интерфейс для работы с хранилищем данных
 type Repository interface {
 	GetTransactions(ctx context.Context, userID int64, filter model.TransactionFilter) ([]model.Transaction, error)
 	GetCategories(ctx context.Context, userID int64) ([]model.Category, error)
+	GetTransactionsByCategory(ctx context.Context, userID int64, categoryID string) ([]model.Transaction, error)
 	CreateTransaction(ctx context.Context, transaction *model.Transaction) error
+	CreateTransactions(ctx context.Context, transactions []*model.Transaction) error
 	DeleteTransaction(ctx context.Context, transactionID string, userID int64) error
+	UpdateTransactionCategory(ctx context.Context, transactionID string, userID int64, categoryID string) error
+	GetTransaction(ctx context.Context, transactionID string, userID int64) (*model.Transaction, error)
+	UpdateTransactionDetails(ctx context.Context, transactionID string, userID int64, note, attachmentPath string) error
+	UpdateTransactionBusiness(ctx context.Context, transactionID string, userID int64, counterparty, invoiceNumber string, vatAmount float64) error
+	UpdateTransactionEvent(ctx context.Context, transactionID string, userID int64, eventID string) error
+	GetDeletedTransactions(ctx context.Context, userID int64, since time.Time) ([]model.Transaction, error)
+	GetDeletedCategories(ctx context.Context, userID int64, since time.Time) ([]model.Category, error)
+	RestoreTransaction(ctx context.Context, transactionID string, userID int64) error
+	RestoreCategory(ctx context.Context, category *model.Category) error
+	PurgeDeleted(ctx context.Context, before time.Time) ([]string, error)
 	CreateCategory(ctx context.Context, category *model.Category) error
+	UpdateCategory(ctx context.Context, category *model.Category) error
 	DeleteCategory(ctx context.Context, categoryID string, userID int64) error
 	GetUserState(ctx context.Context, userID int64) (*model.UserState, error)
 	SaveUserState(ctx context.Context, state *model.UserState) error
 	DeleteUserState(ctx context.Context, userID int64) error
+	DeleteUserStatesBefore(ctx context.Context, before time.Time) error
+	SaveUser(ctx context.Context, user *model.User) error
+	GetUsers(ctx context.Context) ([]model.User, error)
+	GetUser(ctx context.Context, userID int64) (*model.User, error)
+	SetMenuSession(ctx context.Context, userID int64, session int) error
+	DeleteUserData(ctx context.Context, userID int64) error
+	GetBudgets(ctx context.Context, userID int64) ([]model.Budget, error)
+	SaveBudget(ctx context.Context, budget *model.Budget) error
+	GetPlannedExpenses(ctx context.Context, userID int64, since time.Time) ([]model.PlannedExpense, error)
+	CreatePlannedExpense(ctx context.Context, plan *model.PlannedExpense) error
+	DeletePlannedExpense(ctx context.Context, id string, userID int64) error
+	GetWishlist(ctx context.Context, userID int64) ([]model.WishlistItem, error)
+	CreateWishlistItem(ctx context.Context, item *model.WishlistItem) error
+	DeleteWishlistItem(ctx context.Context, id string, userID int64) error
+	GetWishlistAllocations(ctx context.Context, userID int64) ([]model.WishlistAllocation, error)
+	CreateWishlistAllocation(ctx context.Context, allocation *model.WishlistAllocation) error
+	GetGoals(ctx context.Context, userID int64) ([]model.Goal, error)
+	CreateGoal(ctx context.Context, goal *model.Goal) error
+	UpdateGoalSaved(ctx context.Context, id string, userID int64, saved float64) error
+	GetUserBaseline(ctx context.Context, userID int64) (*model.UserBaseline, error)
+	SaveUserBaseline(ctx context.Context, baseline *model.UserBaseline) error
+	GetAccounts(ctx context.Context, userID int64) ([]model.Account, error)
+	CreateAccount(ctx context.Context, account *model.Account) error
+	DeleteAccount(ctx context.Context, id string, userID int64) error
+	GetLedgerMember(ctx context.Context, memberID int64) (*model.LedgerMember, error)
+	GetLedgerMembers(ctx context.Context, ownerID int64) ([]model.LedgerMember, error)
+	SaveLedgerMember(ctx context.Context, member *model.LedgerMember) error
+	DeleteLedgerMember(ctx context.Context, memberID int64) error
+	CreateLedgerInvite(ctx context.Context, invite *model.LedgerInvite) error
+	GetLedgerInvite(ctx context.Context, code string) (*model.LedgerInvite, error)
+	DeleteLedgerInvite(ctx context.Context, code string) error
+	GetLedgers(ctx context.Context, userID int64) ([]model.Ledger, error)
+	CreateLedger(ctx context.Context, ledger *model.Ledger) error
+	UpdateLedger(ctx context.Context, ledger *model.Ledger) error
+	DeleteLedger(ctx context.Context, id string, userID int64) error
+	GetEvents(ctx context.Context, userID int64) ([]model.Event, error)
+	CreateEvent(ctx context.Context, event *model.Event) error
+	UpdateEvent(ctx context.Context, event *model.Event) error
+	DeleteEvent(ctx context.Context, id string, userID int64) error
+	GetEvent(ctx context.Context, id string) (*model.Event, error)
+	GetEventByInvite(ctx context.Context, code string) (*model.Event, error)
+	GetEventMembers(ctx context.Context, eventID string) ([]model.EventMember, error)
+	GetEventMemberships(ctx context.Context, userID int64) ([]model.EventMember, error)
+	SaveEventMember(ctx context.Context, member *model.EventMember) error
+	GetEventPayments(ctx context.Context, eventID string) ([]model.EventPayment, error)
+	CreateEventPayment(ctx context.Context, payment *model.EventPayment) error
+	DeleteEventPayment(ctx context.Context, id string, userID int64) error
+	GetExchangeRates(ctx context.Context, base, date string) ([]model.ExchangeRate, error)
+	SaveExchangeRates(ctx context.Context, rates []model.ExchangeRate) error
+	GetUserSettings(ctx context.Context, userID int64) (*model.UserSettings, error)
+	SaveUserSettings(ctx context.Context, settings *model.UserSettings) error
+	GetAssets(ctx context.Context, userID int64) ([]model.Asset, error)
+	CreateAsset(ctx context.Context, asset *model.Asset) error
+	DeleteAsset(ctx context.Context, id string, userID int64) error
+	GetHoldings(ctx context.Context, userID int64) ([]model.Holding, error)
+	SaveHolding(ctx context.Context, holding *model.Holding) error
+	UpdateHoldingPrice(ctx context.Context, holding *model.Holding) error
+	DeleteHolding(ctx context.Context, id string, userID int64) error
+	GetNetWorthSnapshots(ctx context.Context, userID int64, limit int) ([]model.NetWorthSnapshot, error)
+	SaveNetWorthSnapshot(ctx context.Context, snapshot *model.NetWorthSnapshot) error
+	GetWebhooks(ctx context.Context, userID int64) ([]model.Webhook, error)
+	CreateWebhook(ctx context.Context, webhook *model.Webhook) error
+	DeleteWebhook(ctx context.Context, id string, userID int64) error
+	GetReminders(ctx context.Context, userID int64) ([]model.Reminder, error)
+	GetAllReminders(ctx context.Context) ([]model.Reminder, error)
+	CreateReminder(ctx context.Context, reminder *model.Reminder) error
+	DeleteReminder(ctx context.Context, id string, userID int64) error
+	MarkReminderSent(ctx context.Context, id string, sentAt time.Time) error
+	CreateBroadcast(ctx context.Context, broadcast *model.Broadcast) error
+	GetBroadcast(ctx context.Context, id string) (*model.Broadcast, error)
+	UpdateBroadcast(ctx context.Context, broadcast *model.Broadcast) error
+	GetQueuedBroadcasts(ctx context.Context) ([]model.Broadcast, error)
+	GetBroadcastDeliveries(ctx context.Context, broadcastID string) ([]model.BroadcastDelivery, error)
+	SaveBroadcastDelivery(ctx context.Context, delivery *model.BroadcastDelivery) error
+	GetReportData(ctx context.Context, userID int64, current, previous model.TransactionFilter) (*model.ReportData, error)
+	MarkUpdateProcessed(ctx context.Context, update *model.ProcessedUpdate) (bool, error)
+	DeleteProcessedUpdate(ctx context.Context, updateID int64) error
+	DeleteProcessedUpdates(ctx context.Context, before time.Time) error
 }
 
 // NewExpenseTracker создает новый экземпляр ExpenseTracker
 func NewExpenseTracker(repo Repository) *ExpenseTracker {
+	// Все запросы к данным идут через общий бюджет, в котором состоит пользователь
+	ledger := newLedgerScope(repo)
 	return &ExpenseTracker{
-		repo: repo,
+		repo:     ledger,
+		ledger:   ledger,
+		plugins:  registeredPlugins(),
+		currency: defaultCurrency,
+		now:      time.Now,
 	}
 }
 
-func (s *ExpenseTracker) AddTransaction(ctx context.Context, userID int64, categoryID string, amount float64, description string) error {
-	now := time.Now()
-	// Нормализуем дату до начала дня
-	transactionDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+// SetClock подменяет источник текущего времени, от которого считаются периоды отчетов
+func (s *ExpenseTracker) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// AddTransaction записывает транзакцию в категорию. Сумма передается без знака:
+// доход это или расход, определяет тип категории. Возвращает сохраненную транзакцию
+func (s *ExpenseTracker) AddTransaction(ctx context.Context, userID int64, categoryID, accountID string, amount float64, description string) (*model.Transaction, error) {
+	return s.AddCurrencyTransaction(ctx, userID, categoryID, accountID, amount, "", description)
+}
+
+// AddCurrencyTransaction записывает транзакцию с суммой в валюте currency; пустая валюта -
+// валюта учета. Сумма пересчитывается по курсу на дату транзакции
+func (s *ExpenseTracker) AddCurrencyTransaction(ctx context.Context, userID int64, categoryID, accountID string, amount float64, currency, description string) (*model.Transaction, error) {
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	category := findCategory(categories, categoryID)
+	if category == nil {
+		return nil, fmt.Errorf("category %s not found: %w", categoryID, model.ErrNotFound)
+	}
 
+	// Время покупки сохраняется: по нему строится разбивка трат по часам суток
+	now := time.Now()
 	transaction := &model.Transaction{
 		UserID:      userID,
 		CategoryID:  categoryID,
+		AccountID:   accountID,
 		Amount:      amount,
+		Currency:    currency,
 		Description: description,
-		Date:        transactionDate,
+		Date:        now,
 		CreatedAt:   now,
 	}
+	transaction.SetType(category.Type)
 	transaction.GenerateID()
-	return s.repo.CreateTransaction(ctx, transaction)
+	if err := s.createTransaction(ctx, transaction); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// createTransaction сохраняет транзакцию и уведомляет плагины. Тип, если его не задали,
+// определяется по знаку суммы, а знак всегда приводится к типу. Покупка в другой валюте
+// пересчитывается в валюту учета, трата во время события привязывается к нему
+func (s *ExpenseTracker) createTransaction(ctx context.Context, transaction *model.Transaction) error {
+	if err := s.prepareTransaction(ctx, transaction); err != nil {
+		return err
+	}
+	if err := s.repo.CreateTransaction(ctx, transaction); err != nil {
+		return err
+	}
+	markWrote(ctx)
+	s.invalidateReports(ctx, transaction.UserID)
+	s.transactionCreated(ctx, transaction)
+	return nil
+}
+
+// createTransactions записывает несколько транзакций одним запросом: при ошибке
+// не сохраняется ни одна, и повторная попытка не создаст дублей
+func (s *ExpenseTracker) createTransactions(ctx context.Context, transactions []*model.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+	for _, transaction := range transactions {
+		if err := s.prepareTransaction(ctx, transaction); err != nil {
+			return err
+		}
+	}
+	if err := s.repo.CreateTransactions(ctx, transactions); err != nil {
+		return err
+	}
+	markWrote(ctx)
+	s.invalidateReports(ctx, transactions[0].UserID)
+	for _, transaction := range transactions {
+		s.transactionCreated(ctx, transaction)
+	}
+	return nil
+}
+
+// prepareTransaction проставляет тип, пересчитывает валюту и привязывает транзакцию к событию
+func (s *ExpenseTracker) prepareTransaction(ctx context.Context, transaction *model.Transaction) error {
+	transaction.SetType(transactionType(*transaction))
+	if err := s.convertCurrency(ctx, transaction); err != nil {
+		return err
+	}
+	s.tagOpenEvent(ctx, transaction)
+	return nil
+}
+
+// transactionCreated оповещает подписчиков о сохраненной транзакции. О разделенном платеже
+// целиком не оповещает: подписчики получают его части, и платеж посчитался бы дважды
+func (s *ExpenseTracker) transactionCreated(ctx context.Context, transaction *model.Transaction) {
+	if transaction.IsSplit {
+		return
+	}
+	s.notifyTransactionCreated(ctx, transaction)
+	s.notifyWebhooks(ctx, model.WebhookEventTransactionCreated, transaction)
+}
+
+// transactionType возвращает тип транзакции, а для транзакции без типа - направление по знаку суммы
+func transactionType(t model.Transaction) string {
+	if t.IsIncome() {
+		return model.TransactionIncome
+	}
+	return model.TransactionExpense
 }
 
 func (s *ExpenseTracker) GetMonthlyReport(ctx context.Context, userID int64) (*BaseReport, error) {
@@ -68,31 +284,16 @@ func (s *ExpenseTracker) GetMonthlyReport(ctx context.Context, userID int64) (*B
 	currentStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
 	currentEnd := currentStart.AddDate(0, 1, 0).Add(-time.Second)
 
-	// Получаем данные за текущий месяц
-	currentTransactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{
-		StartDate: &currentStart,
-		EndDate:   &currentEnd,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get current month transactions: %w", err)
-	}
-
-	// Получаем данные за предыдущий месяц
+	// Получаем данные за текущий и предыдущий месяц и категории для имен
 	prevStart := currentStart.AddDate(0, -1, 0)
 	prevEnd := currentStart.Add(-time.Second)
-	prevTransactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{
-		StartDate: &prevStart,
-		EndDate:   &prevEnd,
-	})
+	data, err := s.repo.GetReportData(ctx, userID,
+		model.TransactionFilter{StartDate: &currentStart, EndDate: &currentEnd},
+		model.TransactionFilter{StartDate: &prevStart, EndDate: &prevEnd})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get previous month transactions: %w", err)
-	}
-
-	// Получаем категории для имен
-	categories, err := s.repo.GetCategories(ctx, userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get categories: %w", err)
+		return nil, err
 	}
+	currentTransactions, prevTransactions, categories := data.Current, data.Previous, data.Categories
 	categoryNames := make(map[string]string)
 	for _, cat := range categories {
 		categoryNames[cat.ID] = cat.Name
@@ -119,13 +320,8 @@ func (s *ExpenseTracker) GetMonthlyReport(ctx context.Context, userID int64) (*B
 	expenseTrend, incomeTrend := s.calculateTrends(currentTransactions)
 
 	// Форматируем отчет
-	monthNames := []string{
-		"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь",
-		"Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь",
-	}
-
 	report := &BaseReport{
-		Period: fmt.Sprintf("%s %d", monthNames[now.Month()-1], now.Year()),
+		Period: locale.FormatMonth(locale.FromContext(ctx), now),
 		Text: fmt.Sprintf(
 			"💰 Доходы: %.2f₽%s\n"+
 				"💸 Расходы: %.2f₽%s\n"+
@@ -157,7 +353,9 @@ func (s *ExpenseTracker) GetMonthlyReport(ctx context.Context, userID int64) (*B
 	return report, nil
 }
 
-func (s *ExpenseTracker) CreateDefaultCategories(ctx context.Context, userID int64) error {
+// CreateDefaultCategories создает категории из стартового набора packID при первом запуске.
+// Если категории у пользователя уже есть, ничего не делает
+func (s *ExpenseTracker) CreateDefaultCategories(ctx context.Context, userID int64, packID string) error {
 	// Проверяем, есть ли уже категории у пользователя
 	existingCategories, err := s.repo.GetCategories(ctx, userID)
 	if err != nil {
@@ -170,69 +368,261 @@ func (s *ExpenseTracker) CreateDefaultCategories(ctx context.Context, userID int
 	}
 
 	now := time.Now()
-	defaultCategories := []model.Category{
-		{
-			UserID:    userID,
-			Name:      "Продукты",
-			Type:      "expense",
-			CreatedAt: now,
-		},
-		{
-			UserID:    userID,
-			Name:      "Транспорт",
-			Type:      "expense",
-			CreatedAt: now,
-		},
-		{
-			UserID:    userID,
-			Name:      "Развлечения",
-			Type:      "expense",
-			CreatedAt: now,
-		},
-		{
-			UserID:    userID,
-			Name:      "Зарплата",
-			Type:      "income",
-			CreatedAt: now,
-		},
-	}
+	defaultCategories := findCategoryPack(packID).Categories
 
-	for _, category := range defaultCategories {
+	for i, category := range defaultCategories {
+		category.UserID = userID
+		category.CreatedAt = now
+		category.SortOrder = i + 1
 		if err := s.repo.CreateCategory(ctx, &category); err != nil {
 			return fmt.Errorf("error creating category %s: %w", category.Name, err)
 		}
 	}
 
+	// Категорий не было - значит, пользователь запустил бота впервые
+	s.notifyUserRegistered(ctx, userID)
+
+	// Новому пользователю не показываем объявления о прошлых версиях
+	settings, _, err := s.getUserSettings(ctx, userID)
+	if err == nil {
+		err = s.markAnnouncementsSeen(ctx, settings)
+	}
+	if err != nil {
+		log.Printf("Error saving announcements version: %v", err)
+	}
+
 	return nil
 }
 
 func (s *ExpenseTracker) GetCategories(ctx context.Context, userID int64) ([]model.Category, error) {
-	return s.repo.GetCategories(ctx, userID)
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	model.SortCategories(categories)
+	return categories, nil
 }
 
 func (s *ExpenseTracker) CreateCategory(ctx context.Context, category *model.Category) error {
 	category.CreatedAt = time.Now()
+	categories, err := s.repo.GetCategories(ctx, category.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+	if len(categories) >= MaxCategories {
+		return ErrTooManyCategories
+	}
+	if category.ParentID != "" {
+		if err := checkParentCategory(categories, category); err != nil {
+			return err
+		}
+	}
+	if err := validateCategory(categories, category); err != nil {
+		return err
+	}
+	// Новая категория встает в конец списка
+	for _, c := range categories {
+		if c.SortOrder >= category.SortOrder {
+			category.SortOrder = c.SortOrder + 1
+		}
+	}
 	return s.repo.CreateCategory(ctx, category)
 }
 
+// checkParentCategory проверяет родителя новой подкатегории: вложенность только в категорию
+// верхнего уровня. Тип подкатегории всегда совпадает с типом родителя
+func checkParentCategory(categories []model.Category, category *model.Category) error {
+	for _, parent := range categories {
+		if parent.ID != category.ParentID {
+			continue
+		}
+		if parent.ParentID != "" {
+			return fmt.Errorf("category %s is already a subcategory", parent.ID)
+		}
+		category.Type = parent.Type
+		return nil
+	}
+	return fmt.Errorf("parent category %s not found: %w", category.ParentID, model.ErrNotFound)
+}
+
+// UpdateCategory сохраняет измененную категорию, например новое название. Тип и родитель
+// не меняются: транзакции и подкатегории остаются на своих местах
+func (s *ExpenseTracker) UpdateCategory(ctx context.Context, category *model.Category) error {
+	categories, err := s.repo.GetCategories(ctx, category.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+	return s.updateCategory(ctx, categories, category)
+}
+
+// RenameCategory меняет название категории
+func (s *ExpenseTracker) RenameCategory(ctx context.Context, userID int64, categoryID, name string) (*model.Category, error) {
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	category := findCategory(categories, categoryID)
+	if category == nil {
+		return nil, fmt.Errorf("category %s not found: %w", categoryID, model.ErrNotFound)
+	}
+	renamed := *category
+	renamed.Name = name
+	if err := s.updateCategory(ctx, categories, &renamed); err != nil {
+		return nil, err
+	}
+	return &renamed, nil
+}
+
+// updateCategory проверяет и сохраняет категорию. categories - все категории пользователя
+func (s *ExpenseTracker) updateCategory(ctx context.Context, categories []model.Category, category *model.Category) error {
+	current := findCategory(categories, category.ID)
+	if current == nil {
+		return fmt.Errorf("category %s not found: %w", category.ID, model.ErrNotFound)
+	}
+	category.Type, category.ParentID = current.Type, current.ParentID
+	if err := validateCategory(categories, category); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateCategory(ctx, category); err != nil {
+		return fmt.Errorf("failed to update category: %w", err)
+	}
+	s.invalidateReports(ctx, category.UserID)
+	return nil
+}
+
 func (s *ExpenseTracker) DeleteCategory(ctx context.Context, categoryID string, userID int64) error {
-	return s.repo.DeleteCategory(ctx, categoryID, userID)
+	if err := s.repo.DeleteCategory(ctx, categoryID, userID); err != nil {
+		return err
+	}
+	s.invalidateReports(ctx, userID)
+	return nil
+}
+
+// SetCategoryStyle меняет значок и цвет категории. Пустое значение оставляет прежнее
+func (s *ExpenseTracker) SetCategoryStyle(ctx context.Context, userID int64, categoryID, emoji, color string) (*model.Category, error) {
+	if emoji != "" && !model.ValidCategoryEmoji(emoji) {
+		return nil, fmt.Errorf("%w: invalid category emoji: %q", model.ErrValidation, emoji)
+	}
+	if color != "" && !model.ValidCategoryColor(color) {
+		return nil, fmt.Errorf("%w: invalid category color: %q", model.ErrValidation, color)
+	}
+
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	for _, category := range categories {
+		if category.ID != categoryID {
+			continue
+		}
+		if emoji != "" {
+			category.Emoji = emoji
+		}
+		if color != "" {
+			category.Color = color
+		}
+		if err := s.repo.UpdateCategory(ctx, &category); err != nil {
+			return nil, fmt.Errorf("failed to update category: %w", err)
+		}
+		s.invalidateReports(ctx, userID)
+		return &category, nil
+	}
+	return nil, fmt.Errorf("category %s not found: %w", categoryID, model.ErrNotFound)
+}
+
+// SetCategoryArchived убирает категорию в архив или возвращает из него. Подкатегории
+// следуют за родителем, а возвращенная подкатегория возвращает и родителя
+func (s *ExpenseTracker) SetCategoryArchived(ctx context.Context, userID int64, categoryID string, archived bool) (*model.Category, error) {
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	var target *model.Category
+	for i := range categories {
+		if categories[i].ID == categoryID {
+			target = &categories[i]
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("category %s not found: %w", categoryID, model.ErrNotFound)
+	}
+
+	for i := range categories {
+		category := &categories[i]
+		affected := category.ID == categoryID || category.ParentID == categoryID ||
+			(!archived && target.ParentID != "" && category.ID == target.ParentID)
+		if !affected || category.Archived == archived {
+			continue
+		}
+		category.Archived = archived
+		if err := s.repo.UpdateCategory(ctx, category); err != nil {
+			return nil, fmt.Errorf("failed to update category: %w", err)
+		}
+	}
+	return target, nil
 }
 
 func (s *ExpenseTracker) GetRecentTransactions(ctx context.Context, userID int64, limit int) ([]model.Transaction, error) {
 	filter := model.TransactionFilter{
-		Limit: limit,
+		Limit:   limit,
+		Logical: true,
 	}
 	return s.repo.GetTransactions(ctx, userID, filter)
 }
 
+// GetFrequentAmounts возвращает самые частые суммы транзакций в категории (по модулю)
+func (s *ExpenseTracker) GetFrequentAmounts(ctx context.Context, userID int64, categoryID string, limit int) ([]float64, error) {
+	transactions, err := s.repo.GetTransactionsByCategory(ctx, userID, categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category transactions: %w", err)
+	}
+
+	// Считаем, сколько раз встречалась каждая сумма
+	counts := make(map[float64]int)
+	for _, t := range transactions {
+		counts[t.AbsAmount()]++
+	}
+
+	amounts := make([]float64, 0, len(counts))
+	for amount := range counts {
+		amounts = append(amounts, amount)
+	}
+
+	// Сортируем по частоте, при равенстве - по возрастанию суммы
+	sort.Slice(amounts, func(i, j int) bool {
+		if counts[amounts[i]] != counts[amounts[j]] {
+			return counts[amounts[i]] > counts[amounts[j]]
+		}
+		return amounts[i] < amounts[j]
+	})
+
+	if limit > 0 && len(amounts) > limit {
+		amounts = amounts[:limit]
+	}
+	return amounts, nil
+}
+
 func (s *ExpenseTracker) DeleteTransaction(ctx context.Context, transactionID string, userID int64) error {
-	return s.repo.DeleteTransaction(ctx, transactionID, userID)
+	// Транзакция попадает в корзину, фото чека удаляется только при очистке корзины
+	if err := s.repo.DeleteTransaction(ctx, transactionID, userID); err != nil {
+		return err
+	}
+	s.invalidateReports(ctx, userID)
+
+	// Транзакции хранятся под владельцем бюджета, в событии указываем его же
+	ownerID, err := s.ledger.owner(ctx, userID)
+	if err != nil {
+		log.Printf("Error resolving ledger owner: %v", err)
+		return nil
+	}
+	s.notifyWebhooks(ctx, model.WebhookEventTransactionDeleted, &model.Transaction{ID: transactionID, UserID: ownerID})
+	return nil
 }
 
 // BaseReport представляет базовый отчет
 type BaseReport struct {
 	Period          string
+	PricesOf        string // месяц, в цены которого пересчитаны суммы по инфляции; пусто - без пересчета
 	Text            string
 	StartDate       time.Time
 	EndDate         time.Time
@@ -249,17 +639,28 @@ type BaseReport struct {
 		DailyAvgExpense float64
 		MaxIncome       model.TransactionInfo
 		MaxExpense      model.TransactionInfo
+		// TopExpenses - крупнейшие расходы периода по убыванию, не больше topExpensesCount
+		TopExpenses []model.TransactionInfo
+		// Расходы будних дней и выходных за период и за предыдущий период
+		Week     WeekSplit
+		PrevWeek WeekSplit
 	}
 	CategoryData struct {
 		Expenses []model.CategoryStats
 		Income   []model.CategoryStats
 		Changes  model.CategoryChanges
+		// PriceChanges - категории, где подорожал средний чек, по убыванию переплаты
+		PriceChanges []PriceChange
 	}
 	Trends struct {
 		ExpenseTrend     []TrendPoint
 		IncomeTrend      []TrendPoint
 		PeriodComparison PeriodComparison
 	}
+	// TimeOfDay - расходы по часам суток и ночные покупки
+	TimeOfDay TimeOfDay
+	// Members - вклад участников, заполняется только для общего бюджета
+	Members []MemberStats
 }
 
 // CategoryData содержит данные по категориям
@@ -267,6 +668,8 @@ type CategoryData struct {
 	Expenses []model.CategoryStats
 	Income   []model.CategoryStats
 	Changes  model.CategoryChanges
+	// PriceChanges - категории, где подорожал средний чек, по убыванию переплаты
+	PriceChanges []PriceChange
 }
 
 // CategoryStat представляет статистику по категории
@@ -299,6 +702,27 @@ type PeriodComparison struct {
 	BalanceChange float64
 }
 
+// newPeriodComparison сравнивает два периода. Изменения ограничены пределами [-100%, +200%]
+func newPeriodComparison(prev, current PeriodStats) PeriodComparison {
+	comparison := PeriodComparison{
+		PrevPeriod:    prev,
+		CurrentPeriod: current,
+	}
+	if prev.TotalExpenses > 0 {
+		expenseChange := calculateTrendPercent(current.TotalExpenses, prev.TotalExpenses)
+		comparison.ExpenseChange = math.Max(math.Min(expenseChange, 200), -100)
+	}
+	if prev.TotalIncome > 0 {
+		incomeChange := calculateTrendPercent(current.TotalIncome, prev.TotalIncome)
+		comparison.IncomeChange = math.Max(math.Min(incomeChange, 200), -100)
+	}
+	if prev.Balance != 0 {
+		balanceChange := calculateTrendPercent(current.Balance, prev.Balance)
+		comparison.BalanceChange = math.Max(math.Min(balanceChange, 200), -100)
+	}
+	return comparison
+}
+
 // PeriodStats содержит статистику за период
 type PeriodStats struct {
 	TotalIncome        float64
@@ -312,6 +736,14 @@ type PeriodStats struct {
 	IncomeByCategory   map[string]float64
 }
 
+// SavingsRate возвращает долю сохраненного дохода в процентах, 0 - без дохода
+func (p PeriodStats) SavingsRate() float64 {
+	if p.TotalIncome <= 0 {
+		return 0
+	}
+	return p.Balance / p.TotalIncome * 100
+}
+
 // calculateTrendPercent вычисляет процент изменения
 func calculateTrendPercent(current, previous float64) float64 {
 	if previous == 0 {
@@ -328,7 +760,7 @@ func calculateTrendPercent(current, previous float64) float64 {
 
 	// Для случаев, когда текущее значение намного меньше предыдущего
 	if math.Abs(current) < math.Abs(previous) {
-		decrease := ((math.Abs(previous) - math.Abs(current)) / math.Abs(current)) * 100
+		decrease := ((math.Abs(previous) - math.Abs(current)) / math.Abs(previous)) * 100
 		return -decrease // Возвращаем отрицательный процент
 	}
 
@@ -391,16 +823,17 @@ func analyzePeriod(transactions []model.Transaction, start, end time.Time, categ
 		IncomeByCategory:   make(map[string]float64),
 	}
 
-	days := end.Sub(start).Hours() / 24
+	// Период короче дня считается за день, чтобы средние не делились на ноль
+	days := math.Max(end.Sub(start).Hours()/24, 1)
 
 	for _, t := range transactions {
 		categoryName := categoryNames[t.CategoryID]
-		if t.Amount > 0 {
-			stats.TotalIncome += t.Amount
-			stats.IncomeByCategory[categoryName] += t.Amount
+		if t.IsIncome() {
+			stats.TotalIncome += t.AbsAmount()
+			stats.IncomeByCategory[categoryName] += t.AbsAmount()
 		} else {
-			stats.TotalExpenses += -t.Amount
-			stats.ExpensesByCategory[categoryName] += -t.Amount
+			stats.TotalExpenses += t.AbsAmount()
+			stats.ExpensesByCategory[categoryName] += t.AbsAmount()
 		}
 	}
 
@@ -426,10 +859,10 @@ func (s *ExpenseTracker) calculateTrends(transactions []model.Transaction) ([]Tr
 
 	for _, t := range transactions {
 		date := time.Date(t.Date.Year(), t.Date.Month(), t.Date.Day(), 0, 0, 0, 0, time.UTC)
-		if t.Amount > 0 {
-			dailyIncome[date] += t.Amount
+		if t.IsIncome() {
+			dailyIncome[date] += t.AbsAmount()
 		} else {
-			dailyExpenses[date] += -t.Amount
+			dailyExpenses[date] += t.AbsAmount()
 		}
 	}
 
@@ -477,80 +910,124 @@ func (s *ExpenseTracker) calculateTrends(transactions []model.Transaction) ([]Tr
 	return expenseTrend, incomeTrend
 }
 
-func (s *ExpenseTracker) GetReport(ctx context.Context, userID int64, reportType ReportType) (*BaseReport, error) {
-	now := time.Now()
-	var startDate, endDate time.Time
-
+// reportPeriods возвращает период отчета на момент now и предыдущий период такой же
+// длительности, с которым он сравнивается
+func reportPeriods(reportType ReportType, now time.Time) (current, previous Period) {
 	switch reportType {
 	case DailyReport:
 		// Устанавливаем начало дня (00:00:00) и конец дня (23:59:59)
-		startDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-		endDate = time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 999999999, now.Location())
+		current.Start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		current.End = time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 999999999, now.Location())
 	case WeeklyReport:
 		// Начало недели (7 дней назад)
-		startDate = time.Date(now.Year(), now.Month(), now.Day()-7, 0, 0, 0, 0, now.Location())
-		endDate = time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 999999999, now.Location())
+		current.Start = time.Date(now.Year(), now.Month(), now.Day()-7, 0, 0, 0, 0, now.Location())
+		current.End = time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 999999999, now.Location())
 	case MonthlyReport:
 		// Начало текущего месяца
-		startDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		current.Start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 		// Конец текущего месяца
-		endDate = time.Date(now.Year(), now.Month()+1, 0, 23, 59, 59, 999999999, now.Location())
+		current.End = time.Date(now.Year(), now.Month()+1, 0, 23, 59, 59, 999999999, now.Location())
 	case YearlyReport:
 		// Начало текущего года
-		startDate = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
+		current.Start = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
 		// Конец текущего года
-		endDate = time.Date(now.Year(), 12, 31, 23, 59, 59, 999999999, now.Location())
+		current.End = time.Date(now.Year(), 12, 31, 23, 59, 59, 999999999, now.Location())
 	}
 
-	// Получаем транзакции за текущий период
+	return current, current.Previous()
+}
+
+func (s *ExpenseTracker) GetReport(ctx context.Context, userID int64, reportType ReportType) (*BaseReport, error) {
+	now := s.now()
+	current, previous := reportPeriods(reportType, now)
+	startDate, endDate := current.Start, current.End
+
+	// Годовой отчет можно пересчитать по инфляции в цены текущего месяца
+	adjusted := reportType == YearlyReport && s.inflationAdjusted(ctx, userID)
+
+	// Часы покупок раскладываются в часовом поясе пользователя
+	location := s.userLocation(ctx, userID)
+
+	// Готовый отчет строится по данным владельца бюджета и общий для всех участников
+	// с тем же часовым поясом
+	var ownerID int64
+	cacheKey := reportCacheKey(ctx, reportType, startDate, location)
+	if adjusted {
+		cacheKey += ":real"
+	}
+	// Сводный отчет по всем книгам не кэшируется: записи в любую из книг его меняют
+	cached := s.reports != nil && !allLedgers(ctx)
+	if cached {
+		var err error
+		if ownerID, err = s.ledger.owner(ctx, userID); err != nil {
+			return nil, err
+		}
+		if report, ok := s.reports.Get(ctx, ownerID, cacheKey); ok {
+			return report, nil
+		}
+	}
+
+	// Текущий период и предыдущий такой же длительности
 	currentFilter := model.TransactionFilter{
 		StartDate: &startDate,
 		EndDate:   &endDate,
 	}
-	currentTransactions, err := s.repo.GetTransactions(ctx, userID, currentFilter)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get current period transactions: %w", err)
-	}
-	log.Printf("Получено транзакций за текущий период: %d", len(currentTransactions))
-
-	// Получаем транзакции за предыдущий период такой же длительности
-	var prevStartDate, prevEndDate time.Time
-	periodDuration := endDate.Sub(startDate)
-	prevEndDate = startDate.Add(-time.Nanosecond)
-	prevStartDate = prevEndDate.Add(-periodDuration).Add(time.Nanosecond)
-
 	prevFilter := model.TransactionFilter{
-		StartDate: &prevStartDate,
-		EndDate:   &prevEndDate,
-	}
-	prevTransactions, err := s.repo.GetTransactions(ctx, userID, prevFilter)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get previous period transactions: %w", err)
+		StartDate: &previous.Start,
+		EndDate:   &previous.End,
 	}
-	log.Printf("Получено транзакций за предыдущий период: %d", len(prevTransactions))
 
-	// Получаем категории
-	categories, err := s.repo.GetCategories(ctx, userID)
+	// Категории и транзакции обоих периодов загружаются одним вызовом
+	data, err := s.repo.GetReportData(ctx, userID, currentFilter, prevFilter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get categories: %w", err)
+		return nil, err
 	}
+	currentTransactions, prevTransactions, categories := data.Current, data.Previous, data.Categories
+	log.Printf("Получено транзакций за текущий период: %d, за предыдущий: %d", len(currentTransactions), len(prevTransactions))
 
 	// Создаем базовый отчет
 	report := &BaseReport{
-		Period:    s.formatPeriod(reportType, startDate, endDate),
+		Period:    s.formatPeriod(locale.FromContext(ctx), reportType, startDate, endDate),
 		StartDate: startDate,
 		EndDate:   endDate,
 	}
 
+	if adjusted {
+		lists, err := s.adjustForInflation(ctx, now, currentTransactions, prevTransactions)
+		if err != nil {
+			// Без данных об инфляции показываем отчет в исходных суммах
+			log.Printf("Error adjusting report for inflation: %v", err)
+		} else {
+			currentTransactions, prevTransactions = lists[0], lists[1]
+			report.PricesOf = locale.FormatMonth(locale.FromContext(ctx), now)
+		}
+	}
+
 	// Заполняем данные отчета
-	s.fillTransactionStats(report, currentTransactions, categories)
+	s.fillTransactionStats(report, currentTransactions, prevTransactions, categories)
 	s.fillCategoryAnalytics(report, currentTransactions, prevTransactions, categories)
+	s.fillPriceChanges(report, currentTransactions, prevTransactions, categories)
 	s.fillTrendAnalytics(report, currentTransactions, prevTransactions, categories)
+	s.fillTimeOfDay(report, location, currentTransactions, prevTransactions, categories)
+
+	members, err := s.memberStats(ctx, userID, currentTransactions)
+	if err != nil {
+		log.Printf("Error calculating member stats: %v", err)
+	}
+	report.Members = members
 
+	s.notifyReportGenerated(ctx, userID, report)
+
+	if cached {
+		s.reports.Set(ctx, ownerID, cacheKey, report)
+	}
 	return report, nil
 }
 
-func (s *ExpenseTracker) fillTransactionStats(report *BaseReport, transactions []model.Transaction, categories []model.Category) {
+// topExpensesCount - сколько крупнейших расходов сохраняется в отчете
+const topExpensesCount = 3
+
+func (s *ExpenseTracker) fillTransactionStats(report *BaseReport, transactions, prevTransactions []model.Transaction, categories []model.Category) {
 	log.Printf("Начинаем анализ транзакций. Всего транзакций: %d, период: %s - %s",
 		len(transactions), report.StartDate.Format("2006-01-02"), report.EndDate.Format("2006-01-02"))
 
@@ -573,31 +1050,39 @@ func (s *ExpenseTracker) fillTransactionStats(report *BaseReport, transactions [
 		log.Printf("Обработка транзакции: ID=%s, Сумма=%.2f, Дата=%s, Категория=%s, Описание=%s",
 			t.ID, t.Amount, t.Date.Format("2006-01-02"), categoryNames[t.CategoryID], t.Description)
 
-		if t.Amount > 0 {
-			totalIncome += t.Amount
+		if t.IsIncome() {
+			totalIncome += t.AbsAmount()
 			incomeCount++
-			if t.Amount > stats.MaxIncome.Amount {
+			if t.AbsAmount() > stats.MaxIncome.Amount {
 				stats.MaxIncome = model.TransactionInfo{
-					Amount:      t.Amount,
+					Amount:      t.AbsAmount(),
 					CategoryID:  t.CategoryID,
 					Date:        t.Date,
 					Description: t.Description,
 				}
 			}
 		} else {
-			expense := -t.Amount
+			expense := t.AbsAmount()
 			totalExpense += expense
 			expenseCount++
+			info := model.TransactionInfo{
+				Amount:      expense,
+				CategoryID:  t.CategoryID,
+				Date:        t.Date,
+				Description: t.Description,
+			}
 			if expense > stats.MaxExpense.Amount {
-				stats.MaxExpense = model.TransactionInfo{
-					Amount:      expense,
-					CategoryID:  t.CategoryID,
-					Date:        t.Date,
-					Description: t.Description,
-				}
+				stats.MaxExpense = info
 			}
+			stats.TopExpenses = append(stats.TopExpenses, info)
 		}
 	}
+	sort.SliceStable(stats.TopExpenses, func(i, j int) bool {
+		return stats.TopExpenses[i].Amount > stats.TopExpenses[j].Amount
+	})
+	if len(stats.TopExpenses) > topExpensesCount {
+		stats.TopExpenses = stats.TopExpenses[:topExpensesCount]
+	}
 
 	stats.TotalCount = incomeCount + expenseCount
 	stats.IncomeCount = incomeCount
@@ -622,12 +1107,85 @@ func (s *ExpenseTracker) fillTransactionStats(report *BaseReport, transactions [
 		stats.AvgExpense = totalExpense / float64(expenseCount)
 	}
 
+	// Будни и выходные текущего периода считаются по прошедшим дням, предыдущего - целиком
+	end := report.EndDate
+	if now := s.now(); now.Before(end) {
+		end = now
+	}
+	stats.Week = weekSplit(transactions, report.StartDate, end)
+	prev := Period{Start: report.StartDate, End: report.EndDate}.Previous()
+	stats.PrevWeek = weekSplit(prevTransactions, prev.Start, prev.End)
+
 	log.Printf("Итоги анализа за %d дней:", int(days))
 	log.Printf("Доходы=%.2f (среднее в день=%.2f), Кол-во=%d, Средний доход=%.2f",
 		totalIncome, stats.DailyAvgIncome, incomeCount, stats.AvgIncome)
 	log.Printf("Расходы=%.2f (среднее в день=%.2f), Кол-во=%d, Средний расход=%.2f",
 		totalExpense, stats.DailyAvgExpense, expenseCount, stats.AvgExpense)
 	log.Printf("Баланс=%.2f", report.Balance)
+	log.Printf("Будни=%.2f (%d дней), выходные=%.2f (%d дней)",
+		stats.Week.WeekdayExpenses, stats.Week.WeekdayDays, stats.Week.WeekendExpenses, stats.Week.WeekendDays)
+}
+
+// WeekSplit - расходы будних дней и выходных за период
+type WeekSplit struct {
+	WeekdayExpenses float64
+	WeekendExpenses float64
+	WeekdayDays     int // будних дней в периоде
+	WeekendDays     int // суббот и воскресений в периоде
+}
+
+// WeekdayDaily возвращает средние расходы буднего дня
+func (w WeekSplit) WeekdayDaily() float64 {
+	if w.WeekdayDays == 0 {
+		return 0
+	}
+	return w.WeekdayExpenses / float64(w.WeekdayDays)
+}
+
+// WeekendDaily возвращает средние расходы дня выходных
+func (w WeekSplit) WeekendDaily() float64 {
+	if w.WeekendDays == 0 {
+		return 0
+	}
+	return w.WeekendExpenses / float64(w.WeekendDays)
+}
+
+// Ratio возвращает, во сколько раз день выходных дороже буднего. 0 - сравнивать не с чем:
+// в периоде нет будней или выходных либо в будни не было трат
+func (w WeekSplit) Ratio() float64 {
+	if w.WeekendDays == 0 || w.WeekdayDaily() == 0 {
+		return 0
+	}
+	return w.WeekendDaily() / w.WeekdayDaily()
+}
+
+// weekSplit делит расходы периода [start, end] на будни и выходные. Дни недели считаются
+// в часовом поясе отчета: покупка в пятницу в 23:30 по UTC в Москве уже субботняя
+func weekSplit(transactions []model.Transaction, start, end time.Time) WeekSplit {
+	var split WeekSplit
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if isWeekend(day) {
+			split.WeekendDays++
+		} else {
+			split.WeekdayDays++
+		}
+	}
+	for _, t := range transactions {
+		if t.IsIncome() || t.Date.Before(start) || t.Date.After(end) {
+			continue
+		}
+		if isWeekend(t.Date.In(start.Location())) {
+			split.WeekendExpenses += t.AbsAmount()
+		} else {
+			split.WeekdayExpenses += t.AbsAmount()
+		}
+	}
+	return split
+}
+
+// isWeekend сообщает, что день - суббота или воскресенье
+func isWeekend(day time.Time) bool {
+	return day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
 }
 
 func (s *ExpenseTracker) fillCategoryAnalytics(report *BaseReport, currentTransactions, prevTransactions []model.Transaction, categories []model.Category) {
@@ -638,13 +1196,17 @@ func (s *ExpenseTracker) fillCategoryAnalytics(report *BaseReport, currentTransa
 	categoryStats := make(map[string]*model.CategoryStats)
 	prevCategoryAmounts := make(map[string]float64)
 	categoryTypes := make(map[string]string)
+	categoryParents := make(map[string]string)
 
 	// Инициализируем мапы категорий
 	for _, cat := range categories {
 		categoryTypes[cat.ID] = cat.Type
+		categoryParents[cat.ID] = cat.ParentID
 		categoryStats[cat.ID] = &model.CategoryStats{
 			CategoryID: cat.ID,
 			Name:       cat.Name,
+			Emoji:      cat.Icon(),
+			Color:      cat.Color,
 			Amount:     0,
 			Count:      0,
 		}
@@ -659,29 +1221,45 @@ func (s *ExpenseTracker) fillCategoryAnalytics(report *BaseReport, currentTransa
 		}
 
 		if stats, ok := categoryStats[t.CategoryID]; ok {
-			stats.Amount += t.Amount // Сохраняем оригинальное значение (положительное для доходов, отрицательное для расходов)
+			stats.Amount += t.SignedAmount() // Знак по типу транзакции (положительное для доходов, отрицательное для расходов)
 			stats.Count++
 			log.Printf("Добавлена транзакция в категорию %s: %.2f (всего: %.2f)", stats.Name, t.Amount, stats.Amount)
 		}
 	}
 
 	// Получаем даты для предыдущего периода
-	periodDuration := report.EndDate.Sub(report.StartDate)
-	prevPeriodEnd := report.StartDate.Add(-time.Nanosecond)
-	prevPeriodStart := prevPeriodEnd.Add(-periodDuration).Add(time.Nanosecond)
+	prevPeriod := Period{Start: report.StartDate, End: report.EndDate}.Previous()
 
 	// Анализируем предыдущий период
 	for _, t := range prevTransactions {
 		// Проверяем, что транзакция входит в предыдущий период
-		if t.Date.Before(prevPeriodStart) || t.Date.After(prevPeriodEnd) {
+		if t.Date.Before(prevPeriod.Start) || t.Date.After(prevPeriod.End) {
 			continue
 		}
 
 		if _, ok := categoryStats[t.CategoryID]; ok {
-			prevCategoryAmounts[t.CategoryID] += t.Amount
+			prevCategoryAmounts[t.CategoryID] += t.SignedAmount()
 		}
 	}
 
+	// Подкатегории входят в сумму родителя, а в отчете показываются внутри него
+	subcategories := make(map[string][]*model.CategoryStats)
+	for _, cat := range categories {
+		parent, ok := categoryStats[cat.ParentID]
+		if !ok || categoryParents[cat.ParentID] != "" {
+			continue
+		}
+		stats := categoryStats[cat.ID]
+		delete(categoryStats, cat.ID)
+		prevCategoryAmounts[cat.ParentID] += prevCategoryAmounts[cat.ID]
+		if stats.Count == 0 {
+			continue
+		}
+		parent.Amount += stats.Amount
+		parent.Count += stats.Count
+		subcategories[cat.ParentID] = append(subcategories[cat.ParentID], stats)
+	}
+
 	// Вычисляем статистику по категориям
 	var totalIncome, totalExpense float64
 	for _, stats := range categoryStats {
@@ -711,6 +1289,24 @@ func (s *ExpenseTracker) fillCategoryAnalytics(report *BaseReport, currentTransa
 			stats.TrendPercent = calculateTrendPercent(stats.Amount, prevAmount)
 		}
 
+		for _, sub := range subcategories[stats.CategoryID] {
+			sub.AvgAmount = sub.Amount / float64(sub.Count)
+			if prevAmount := prevCategoryAmounts[sub.CategoryID]; prevAmount != 0 {
+				sub.TrendPercent = calculateTrendPercent(sub.Amount, prevAmount)
+			}
+			if categoryTypes[stats.CategoryID] == "income" {
+				if totalIncome > 0 {
+					sub.Share = (sub.Amount / totalIncome) * 100
+				}
+			} else if totalExpense > 0 {
+				sub.Share = (math.Abs(sub.Amount) / totalExpense) * 100
+			}
+			stats.Subcategories = append(stats.Subcategories, *sub)
+		}
+		sort.Slice(stats.Subcategories, func(i, j int) bool {
+			return math.Abs(stats.Subcategories[i].Amount) > math.Abs(stats.Subcategories[j].Amount)
+		})
+
 		if categoryTypes[stats.CategoryID] == "income" {
 			if totalIncome > 0 {
 				stats.Share = (stats.Amount / totalIncome) * 100
@@ -822,10 +1418,10 @@ func (s *ExpenseTracker) fillTrendAnalytics(report *BaseReport, currentTransacti
 		if t.Date.Before(report.StartDate) || t.Date.After(report.EndDate) {
 			continue
 		}
-		if t.Amount > 0 {
-			currentPeriod.TotalIncome += t.Amount
+		if t.IsIncome() {
+			currentPeriod.TotalIncome += t.AbsAmount()
 		} else {
-			currentPeriod.TotalExpenses += -t.Amount
+			currentPeriod.TotalExpenses += t.AbsAmount()
 		}
 	}
 	currentPeriod.Balance = currentPeriod.TotalIncome - currentPeriod.TotalExpenses
@@ -833,41 +1429,24 @@ func (s *ExpenseTracker) fillTrendAnalytics(report *BaseReport, currentTransacti
 	currentPeriod.DailyAvgExpense = currentPeriod.TotalExpenses / days
 
 	// Получаем даты для предыдущего периода
-	periodDuration := report.EndDate.Sub(report.StartDate)
-	prevPeriodEnd := report.StartDate.Add(-time.Nanosecond)
-	prevPeriodStart := prevPeriodEnd.Add(-periodDuration).Add(time.Nanosecond)
+	previous := Period{Start: report.StartDate, End: report.EndDate}.Previous()
 
 	// Считаем предыдущий период
 	for _, t := range prevTransactions {
-		if t.Date.Before(prevPeriodStart) || t.Date.After(prevPeriodEnd) {
+		if t.Date.Before(previous.Start) || t.Date.After(previous.End) {
 			continue
 		}
-		if t.Amount > 0 {
-			prevPeriod.TotalIncome += t.Amount
+		if t.IsIncome() {
+			prevPeriod.TotalIncome += t.AbsAmount()
 		} else {
-			prevPeriod.TotalExpenses += -t.Amount
+			prevPeriod.TotalExpenses += t.AbsAmount()
 		}
 	}
 	prevPeriod.Balance = prevPeriod.TotalIncome - prevPeriod.TotalExpenses
 	prevPeriod.DailyAvgIncome = prevPeriod.TotalIncome / days
 	prevPeriod.DailyAvgExpense = prevPeriod.TotalExpenses / days
 
-	// Вычисляем изменения с ограничением в пределах [-100%, +200%]
-	if prevPeriod.TotalExpenses > 0 {
-		expenseChange := calculateTrendPercent(currentPeriod.TotalExpenses, prevPeriod.TotalExpenses)
-		report.Trends.PeriodComparison.ExpenseChange = math.Max(math.Min(expenseChange, 200), -100)
-	}
-	if prevPeriod.TotalIncome > 0 {
-		incomeChange := calculateTrendPercent(currentPeriod.TotalIncome, prevPeriod.TotalIncome)
-		report.Trends.PeriodComparison.IncomeChange = math.Max(math.Min(incomeChange, 200), -100)
-	}
-	if prevPeriod.Balance != 0 {
-		balanceChange := calculateTrendPercent(currentPeriod.Balance, prevPeriod.Balance)
-		report.Trends.PeriodComparison.BalanceChange = math.Max(math.Min(balanceChange, 200), -100)
-	}
-
-	report.Trends.PeriodComparison.CurrentPeriod = currentPeriod
-	report.Trends.PeriodComparison.PrevPeriod = prevPeriod
+	report.Trends.PeriodComparison = newPeriodComparison(prevPeriod, currentPeriod)
 
 	log.Printf("Сравнение периодов: Текущий (Доходы=%.2f, Расходы=%.2f, Баланс=%.2f), Предыдущий (Доходы=%.2f, Расходы=%.2f, Баланс=%.2f)",
 		currentPeriod.TotalIncome, currentPeriod.TotalExpenses, currentPeriod.Balance,
@@ -888,10 +1467,10 @@ func (s *ExpenseTracker) groupTransactionsByDay(transactions []model.Transaction
 	for _, t := range transactions {
 		day := t.Date.Format("2006-01-02")
 		stats := daily[day]
-		if t.Amount > 0 {
-			stats.income += t.Amount
+		if t.IsIncome() {
+			stats.income += t.AbsAmount()
 		} else {
-			stats.expense += -t.Amount
+			stats.expense += t.AbsAmount()
 		}
 		daily[day] = stats
 	}
@@ -936,36 +1515,16 @@ func (s *ExpenseTracker) findCategoryChanges(changes *model.CategoryChanges, cur
 	changes.LargestDropIncome = maxDropIncome
 }
 
-func (s *ExpenseTracker) formatPeriod(reportType ReportType, start, end time.Time) string {
+// formatPeriod форматирует период отчета с учетом языка пользователя
+func (s *ExpenseTracker) formatPeriod(lang string, reportType ReportType, start, end time.Time) string {
 	switch reportType {
 	case DailyReport:
-		return start.Format("02.01.2006")
-	case WeeklyReport:
-		return fmt.Sprintf("%s - %s",
-			start.Format("02.01.2006"),
-			end.Format("02.01.2006"))
+		return locale.FormatDay(lang, start)
 	case MonthlyReport:
-		return start.Format("January 2006")
+		return locale.FormatMonth(lang, start)
 	case YearlyReport:
-		return start.Format("2006")
+		return locale.FormatYear(lang, start)
 	default:
-		return fmt.Sprintf("%s - %s",
-			start.Format("02.01.2006"),
-			end.Format("02.01.2006"))
+		return locale.FormatRange(lang, start, end)
 	}
 }
-
-// GetUserState возвращает текущее состояние пользователя
-func (s *ExpenseTracker) GetUserState(ctx context.Context, userID int64) (*model.UserState, error) {
-	return s.repo.GetUserState(ctx, userID)
-}
-
-// SaveUserState сохраняет состояние пользователя
-func (s *ExpenseTracker) SaveUserState(ctx context.Context, state *model.UserState) error {
-	return s.repo.SaveUserState(ctx, state)
-}
-
-// DeleteUserState удаляет состояние пользователя
-func (s *ExpenseTracker) DeleteUserState(ctx context.Context, userID int64) error {
-	return s.repo.DeleteUserState(ctx, userID)
-}
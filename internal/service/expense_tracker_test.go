@@ -0,0 +1,280 @@
+package service_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+	"github.com/ivanoskov/financial_bot/internal/service/mocks"
+)
+
+// msk - часовой пояс не UTC, чтобы границы периодов проверялись в поясе пользователя
+var msk = time.FixedZone("MSK", 3*60*60)
+
+// endOfDay - последний момент дня, которым заканчиваются периоды отчетов
+func endOfDay(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 23, 59, 59, 999999999, msk)
+}
+
+func midnight(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, msk)
+}
+
+func TestGetReportPeriods(t *testing.T) {
+	tests := []struct {
+		name       string
+		reportType service.ReportType
+		now        time.Time
+		current    service.Period
+		previous   service.Period
+	}{
+		{
+			name:       "день",
+			reportType: service.DailyReport,
+			now:        time.Date(2026, 3, 20, 15, 4, 0, 0, msk),
+			current:    service.Period{Start: midnight(2026, 3, 20), End: endOfDay(2026, 3, 20)},
+			previous:   service.Period{Start: midnight(2026, 3, 19), End: endOfDay(2026, 3, 19)},
+		},
+		{
+			name:       "неделя - восемь дней по сегодня",
+			reportType: service.WeeklyReport,
+			now:        time.Date(2026, 3, 20, 9, 0, 0, 0, msk),
+			current:    service.Period{Start: midnight(2026, 3, 13), End: endOfDay(2026, 3, 20)},
+			previous:   service.Period{Start: midnight(2026, 3, 5), End: endOfDay(2026, 3, 12)},
+		},
+		{
+			name:       "месяц целиком, прошлый период той же длины",
+			reportType: service.MonthlyReport,
+			now:        time.Date(2026, 3, 20, 12, 0, 0, 0, msk),
+			current:    service.Period{Start: midnight(2026, 3, 1), End: endOfDay(2026, 3, 31)},
+			previous:   service.Period{Start: midnight(2026, 1, 29), End: endOfDay(2026, 2, 28)},
+		},
+		{
+			name:       "февраль високосного года",
+			reportType: service.MonthlyReport,
+			now:        time.Date(2024, 2, 10, 12, 0, 0, 0, msk),
+			current:    service.Period{Start: midnight(2024, 2, 1), End: endOfDay(2024, 2, 29)},
+			previous:   service.Period{Start: midnight(2024, 1, 3), End: endOfDay(2024, 1, 31)},
+		},
+		{
+			name:       "январь - прошлый период в прошлом году",
+			reportType: service.MonthlyReport,
+			now:        time.Date(2026, 1, 1, 0, 30, 0, 0, msk),
+			current:    service.Period{Start: midnight(2026, 1, 1), End: endOfDay(2026, 1, 31)},
+			previous:   service.Period{Start: midnight(2025, 12, 1), End: endOfDay(2025, 12, 31)},
+		},
+		{
+			name:       "год",
+			reportType: service.YearlyReport,
+			now:        time.Date(2026, 12, 31, 23, 0, 0, 0, msk),
+			current:    service.Period{Start: midnight(2026, 1, 1), End: endOfDay(2026, 12, 31)},
+			previous:   service.Period{Start: midnight(2025, 1, 1), End: endOfDay(2025, 12, 31)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.Repository{}
+			tracker := service.NewExpenseTracker(repo)
+			tracker.SetClock(func() time.Time { return tt.now })
+
+			report, err := tracker.GetReport(context.Background(), 1, tt.reportType)
+			if err != nil {
+				t.Fatalf("GetReport: %v", err)
+			}
+			if !report.StartDate.Equal(tt.current.Start) || !report.EndDate.Equal(tt.current.End) {
+				t.Errorf("период отчета %v - %v, ожидался %v - %v",
+					report.StartDate, report.EndDate, tt.current.Start, tt.current.End)
+			}
+
+			calls := repo.CallsOf("GetReportData")
+			if len(calls) != 1 {
+				t.Fatalf("GetReportData вызван %d раз, ожидался 1", len(calls))
+			}
+			current := calls[0].Args[1].(model.TransactionFilter)
+			previous := calls[0].Args[2].(model.TransactionFilter)
+			if !current.StartDate.Equal(tt.current.Start) || !current.EndDate.Equal(tt.current.End) {
+				t.Errorf("фильтр текущего периода %v - %v, ожидался %v - %v",
+					current.StartDate, current.EndDate, tt.current.Start, tt.current.End)
+			}
+			if !previous.StartDate.Equal(tt.previous.Start) || !previous.EndDate.Equal(tt.previous.End) {
+				t.Errorf("фильтр прошлого периода %v - %v, ожидался %v - %v",
+					previous.StartDate, previous.EndDate, tt.previous.Start, tt.previous.End)
+			}
+		})
+	}
+}
+
+// Транзакции на границах периодов: записанные ровно в полночь первого дня (импорт выписок)
+// входят в период, а последний момент прошлого периода не попадает в текущий
+func TestGetReportPeriodBoundaries(t *testing.T) {
+	repo := &mocks.Repository{
+		GetReportDataFunc: func(ctx context.Context, userID int64, current, previous model.TransactionFilter) (*model.ReportData, error) {
+			return &model.ReportData{
+				Current: []model.Transaction{
+					{ID: "first", Type: model.TransactionExpense, Amount: -100, Date: midnight(2026, 3, 1)},
+					{ID: "last", Type: model.TransactionExpense, Amount: -200, Date: endOfDay(2026, 3, 31)},
+				},
+				Previous: []model.Transaction{
+					{ID: "prev-first", Type: model.TransactionExpense, Amount: -300, Date: midnight(2026, 1, 29)},
+					{ID: "prev-last", Type: model.TransactionExpense, Amount: -400, Date: endOfDay(2026, 2, 28)},
+					{ID: "too-early", Type: model.TransactionExpense, Amount: -1000, Date: endOfDay(2026, 1, 28)},
+				},
+			}, nil
+		},
+	}
+	tracker := service.NewExpenseTracker(repo)
+	tracker.SetClock(func() time.Time { return time.Date(2026, 3, 20, 12, 0, 0, 0, msk) })
+
+	report, err := tracker.GetReport(context.Background(), 1, service.MonthlyReport)
+	if err != nil {
+		t.Fatalf("GetReport: %v", err)
+	}
+	comparison := report.Trends.PeriodComparison
+	if got := comparison.CurrentPeriod.TotalExpenses; got != 300 {
+		t.Errorf("расходы текущего периода %.0f, ожидалось 300", got)
+	}
+	if got := comparison.PrevPeriod.TotalExpenses; got != 700 {
+		t.Errorf("расходы прошлого периода %.0f, ожидалось 700", got)
+	}
+}
+
+func TestCalculateTrendPercent(t *testing.T) {
+	tests := []struct {
+		name              string
+		current, previous float64
+		want              float64
+	}{
+		{"оба нуля", 0, 0, 0},
+		{"рост с нуля", 150, 0, 100},
+		{"отрицательное значение после нуля не считается ростом", -150, 0, 0},
+		{"падение до нуля", 0, 200, -100},
+		{"без изменений", 200, 200, 0},
+		{"рост", 150, 100, 50},
+		{"рост в разы не ограничивается", 500, 100, 400},
+		// Снижение считается от прошлого значения: с 200 до 150 - это -25%, а не -33%
+		{"снижение", 150, 200, -25},
+		{"снижение отрицательных значений по модулю", -100, -200, -50},
+		{"смена знака", -50, 100, -100},
+		{"смена знака с минуса на плюс", 50, -100, -100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := service.CalculateTrendPercent(tt.current, tt.previous)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("calculateTrendPercent(%v, %v) = %v, ожидалось %v", tt.current, tt.previous, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzePeriod(t *testing.T) {
+	names := map[string]string{"food": "Продукты", "cafe": "Кафе", "salary": "Зарплата"}
+	transactions := []model.Transaction{
+		{CategoryID: "salary", Type: model.TransactionIncome, Amount: 100000},
+		{CategoryID: "food", Type: model.TransactionExpense, Amount: -3000},
+		{CategoryID: "food", Type: model.TransactionExpense, Amount: -2000},
+		{CategoryID: "cafe", Type: model.TransactionExpense, Amount: -1000},
+		// Старые записи без типа: направление определяется знаком суммы
+		{CategoryID: "cafe", Amount: -4000},
+	}
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		days       float64
+	}{
+		{"десять дней", midnight(2026, 3, 1), midnight(2026, 3, 11), 10},
+		// Период короче дня считается за день, иначе средние в день были бы больше сумм
+		// или делились бы на ноль
+		{"несколько часов", midnight(2026, 3, 1), midnight(2026, 3, 1).Add(6 * time.Hour), 1},
+		{"пустой период", midnight(2026, 3, 1), midnight(2026, 3, 1), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := service.AnalyzePeriod(transactions, tt.start, tt.end, names)
+
+			if stats.TotalIncome != 100000 || stats.TotalExpenses != 10000 || stats.Balance != 90000 {
+				t.Errorf("итоги: доход %.0f, расход %.0f, баланс %.0f; ожидалось 100000, 10000, 90000",
+					stats.TotalIncome, stats.TotalExpenses, stats.Balance)
+			}
+			if stats.ExpensesByCategory["Продукты"] != 5000 || stats.ExpensesByCategory["Кафе"] != 5000 {
+				t.Errorf("расходы по категориям: %v", stats.ExpensesByCategory)
+			}
+			if stats.IncomeByCategory["Зарплата"] != 100000 || len(stats.IncomeByCategory) != 1 {
+				t.Errorf("доходы по категориям: %v", stats.IncomeByCategory)
+			}
+			if want := 10000 / tt.days; math.Abs(stats.DailyAvgExpense-want) > 1e-9 {
+				t.Errorf("расход в день %v, ожидалось %v", stats.DailyAvgExpense, want)
+			}
+			if want := 100000 / tt.days; math.Abs(stats.DailyAvgIncome-want) > 1e-9 {
+				t.Errorf("доход в день %v, ожидалось %v", stats.DailyAvgIncome, want)
+			}
+		})
+	}
+}
+
+func TestWeekSplit(t *testing.T) {
+	// Неделя с понедельника 16 по воскресенье 22 марта 2026 по Москве, время транзакций - UTC
+	start, end := midnight(2026, 3, 16), endOfDay(2026, 3, 22)
+	transactions := []model.Transaction{
+		// Пятница 22:30 по UTC - уже суббота 01:30 по Москве
+		{Amount: -1000, Date: time.Date(2026, 3, 20, 22, 30, 0, 0, time.UTC)},
+		// Воскресенье 21:30 по UTC - понедельник 00:30 по Москве, начало периода
+		{Amount: -300, Date: time.Date(2026, 3, 15, 21, 30, 0, 0, time.UTC)},
+		{Amount: -700, Date: time.Date(2026, 3, 16, 10, 0, 0, 0, time.UTC)},
+		// Воскресенье 23:59 по Москве
+		{Amount: -500, Date: time.Date(2026, 3, 22, 20, 59, 0, 0, time.UTC)},
+		// Доходы и траты вне периода не учитываются
+		{Type: model.TransactionIncome, Amount: 50000, Date: time.Date(2026, 3, 21, 9, 0, 0, 0, time.UTC)},
+		{Amount: -9000, Date: time.Date(2026, 3, 22, 21, 0, 0, 0, time.UTC)},
+	}
+
+	split := service.SplitWeek(transactions, start, end)
+	if split.WeekdayDays != 5 || split.WeekendDays != 2 {
+		t.Errorf("будних дней %d, выходных %d; ожидалось 5 и 2", split.WeekdayDays, split.WeekendDays)
+	}
+	if split.WeekdayExpenses != 1000 || split.WeekendExpenses != 1500 {
+		t.Errorf("расходы в будни %.0f, в выходные %.0f; ожидалось 1000 и 1500",
+			split.WeekdayExpenses, split.WeekendExpenses)
+	}
+}
+
+// Сервер работает в UTC, а покупки раскладываются по часам в часовом поясе пользователя
+func TestGetReportTimeOfDayInUserTimezone(t *testing.T) {
+	repo := &mocks.Repository{
+		GetUserSettingsFunc: func(ctx context.Context, userID int64) (*model.UserSettings, error) {
+			settings := model.DefaultUserSettings(userID)
+			settings.Timezone = "Europe/Moscow"
+			return settings, nil
+		},
+		GetReportDataFunc: func(ctx context.Context, userID int64, current, previous model.TransactionFilter) (*model.ReportData, error) {
+			return &model.ReportData{
+				Current: []model.Transaction{
+					{Type: model.TransactionExpense, Amount: -500, Date: time.Date(2026, 3, 10, 20, 30, 0, 0, time.UTC)},
+					{Type: model.TransactionExpense, Amount: -300, Date: time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)},
+				},
+			}, nil
+		},
+	}
+	tracker := service.NewExpenseTracker(repo)
+	tracker.SetClock(func() time.Time { return time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC) })
+
+	report, err := tracker.GetReport(context.Background(), 1, service.MonthlyReport)
+	if err != nil {
+		t.Fatalf("GetReport: %v", err)
+	}
+	stats := report.TimeOfDay
+	if stats.Hours[23].Expenses != 500 || stats.Hours[12].Expenses != 300 {
+		t.Errorf("расходы в 23 часа %.0f, в 12 часов %.0f; ожидалось 500 и 300",
+			stats.Hours[23].Expenses, stats.Hours[12].Expenses)
+	}
+	if stats.Night.Count != 1 {
+		t.Errorf("ночных покупок %d, ожидалась 1", stats.Night.Count)
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// ExportData - данные пользователя для выгрузки в файлы
+type ExportData struct {
+	// Transactions - транзакции по возрастанию даты; разделенные платежи представлены частями
+	Transactions []model.Transaction
+	Categories   []model.Category
+	Accounts     []model.Account
+	Budgets      []model.Budget
+}
+
+// GetExportData загружает все данные, которые попадают в выгрузки
+func (s *ExpenseTracker) GetExportData(ctx context.Context, userID int64) (*ExportData, error) {
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].Date.Before(transactions[j].Date)
+	})
+
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	accounts, err := s.repo.GetAccounts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	budgets, err := s.repo.GetBudgets(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budgets: %w", err)
+	}
+
+	return &ExportData{
+		Transactions: transactions,
+		Categories:   categories,
+		Accounts:     accounts,
+		Budgets:      budgets,
+	}, nil
+}
+
+// CategoryNames возвращает названия категорий по ID
+func (d *ExportData) CategoryNames() map[string]string {
+	names := make(map[string]string, len(d.Categories))
+	for _, c := range d.Categories {
+		names[c.ID] = c.Name
+	}
+	return names
+}
+
+// ExportTransactionsCSV выгружает все транзакции пользователя в CSV.
+// Формат совместим с загрузкой выписки: дата; сумма; описание; категория
+func (s *ExpenseTracker) ExportTransactionsCSV(ctx context.Context, userID int64) ([]byte, error) {
+	data, err := s.GetExportData(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	categoryNames := data.CategoryNames()
+
+	var buf bytes.Buffer
+	// BOM нужен, чтобы Excel правильно определил кодировку
+	buf.WriteString("\ufeff")
+	writer := csv.NewWriter(&buf)
+	writer.Comma = ';'
+	writer.Write([]string{"Дата", "Сумма", "Описание", "Категория"})
+	for _, t := range data.Transactions {
+		writer.Write([]string{
+			t.Date.Format("02.01.2006"),
+			strconv.FormatFloat(t.Amount, 'f', 2, 64),
+			t.Description,
+			categoryNames[t.CategoryID],
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write csv: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
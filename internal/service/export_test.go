@@ -0,0 +1,9 @@
+package service
+
+// Внутренние расчеты отчетов, открытые для тестов пакета service_test: тесты сервиса
+// используют mocks.Repository, который сам импортирует service
+var (
+	CalculateTrendPercent = calculateTrendPercent
+	AnalyzePeriod         = analyzePeriod
+	SplitWeek             = weekSplit
+)
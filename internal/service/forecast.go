@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+const (
+	// forecastDays - на сколько дней вперед строится прогноз остатка
+	forecastDays = 30
+	// forecastHistoryDays - по скольким дням истории ищутся регулярные платежи и средние траты
+	forecastHistoryDays = 90
+)
+
+// RecurringPayment - платеж, который повторяется примерно раз в месяц: зарплата, аренда, подписка
+type RecurringPayment struct {
+	Description  string
+	CategoryID   string
+	Amount       float64 // средняя сумма, расход отрицательный
+	LastDate     time.Time
+	NextDate     time.Time
+	Subscription bool // списывается одна и та же сумма
+}
+
+// ForecastPoint - прогнозируемый остаток на конец дня
+type ForecastPoint struct {
+	Date    time.Time
+	Balance float64
+}
+
+// CashflowForecast - прогноз остатка на forecastDays дней вперед
+type CashflowForecast struct {
+	Balance    float64            // остаток сейчас
+	DailySpend float64            // средние нерегулярные траты в день
+	Recurring  []RecurringPayment // регулярные платежи, ожидаемые в прогнозе, по дате
+	Points     []ForecastPoint    // первая точка - сегодня
+	Lowest     ForecastPoint      // самый низкий остаток за период
+}
+
+// End возвращает остаток в последний день прогноза
+func (f *CashflowForecast) End() ForecastPoint {
+	return f.Points[len(f.Points)-1]
+}
+
+// GetCashflowForecast прогнозирует остаток на 30 дней: к текущему остатку добавляются
+// ожидаемые регулярные платежи и вычитаются средние траты в день. Нерегулярные доходы
+// в прогноз не входят, чтобы не обещать денег, которых может не быть
+func (s *ExpenseTracker) GetCashflowForecast(ctx context.Context, userID int64) (*CashflowForecast, error) {
+	accounts, err := s.repo.GetAccounts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{Logical: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	forecast := &CashflowForecast{}
+	for _, account := range accounts {
+		forecast.Balance += account.InitialBalance
+	}
+	for _, t := range transactions {
+		forecast.Balance += t.SignedAmount()
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	since := today.AddDate(0, 0, -forecastHistoryDays)
+	var history []model.Transaction
+	for _, t := range transactions {
+		if !t.Date.Before(since) && !t.Date.After(now) {
+			history = append(history, t)
+		}
+	}
+
+	recurring, recurringIDs := findRecurringPayments(history, today)
+	var spent float64
+	for _, t := range history {
+		if !t.IsIncome() && !recurringIDs[t.ID] {
+			spent += t.AbsAmount()
+		}
+	}
+	forecast.DailySpend = spent / forecastHistoryDays
+
+	// Ожидаемые платежи по дням прогноза
+	end := today.AddDate(0, 0, forecastDays)
+	expected := make(map[string]float64)
+	for _, p := range recurring {
+		for date := p.NextDate; !date.After(end); date = date.AddDate(0, 1, 0) {
+			expected[date.Format("2006-01-02")] += p.Amount
+			if date.Equal(p.NextDate) {
+				forecast.Recurring = append(forecast.Recurring, p)
+			}
+		}
+	}
+	sort.Slice(forecast.Recurring, func(i, j int) bool {
+		return forecast.Recurring[i].NextDate.Before(forecast.Recurring[j].NextDate)
+	})
+
+	balance := forecast.Balance
+	forecast.Points = append(forecast.Points, ForecastPoint{Date: today, Balance: balance})
+	forecast.Lowest = forecast.Points[0]
+	for day := 1; day <= forecastDays; day++ {
+		date := today.AddDate(0, 0, day)
+		balance += expected[date.Format("2006-01-02")] - forecast.DailySpend
+		point := ForecastPoint{Date: date, Balance: balance}
+		forecast.Points = append(forecast.Points, point)
+		if point.Balance < forecast.Lowest.Balance {
+			forecast.Lowest = point
+		}
+	}
+	return forecast, nil
+}
+
+// findRecurringPayments ищет в истории платежи, которые повторялись хотя бы дважды с шагом
+// около месяца и с почти одинаковой суммой. Платежи сравниваются по описанию, а без
+// описания - по категории. Возвращает регулярные платежи со следующей датой после today
+// и ID транзакций, из которых они найдены
+func findRecurringPayments(history []model.Transaction, today time.Time) ([]RecurringPayment, map[string]bool) {
+	groups := make(map[string][]model.Transaction)
+	for _, t := range history {
+		if t.Amount == 0 {
+			continue
+		}
+		key := "d:" + strings.ToLower(strings.TrimSpace(t.Description))
+		if key == "d:" {
+			key = "c:" + t.CategoryID
+		}
+		if t.IsIncome() {
+			key += ":+"
+		}
+		groups[key] = append(groups[key], t)
+	}
+
+	var payments []RecurringPayment
+	ids := make(map[string]bool)
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].Date.Before(group[j].Date)
+		})
+
+		monthly := true
+		same := true
+		minAmount, maxAmount, total := group[0].AbsAmount(), 0.0, 0.0
+		for i, t := range group {
+			amount := t.AbsAmount()
+			minAmount = math.Min(minAmount, amount)
+			maxAmount = math.Max(maxAmount, amount)
+			total += t.SignedAmount()
+			if t.Amount != group[0].Amount {
+				same = false
+			}
+			if i > 0 {
+				gap := t.Date.Sub(group[i-1].Date).Hours() / 24
+				if gap < 25 || gap > 35 {
+					monthly = false
+				}
+			}
+		}
+		if !monthly || maxAmount > minAmount*1.1 {
+			continue
+		}
+
+		last := group[len(group)-1]
+		next := last.Date.AddDate(0, 1, 0)
+		next = time.Date(next.Year(), next.Month(), next.Day(), 0, 0, 0, 0, today.Location())
+		if next.Before(today) {
+			// Платеж пропущен: вероятно, подписку отменили
+			continue
+		}
+		if next.Equal(today) {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		payments = append(payments, RecurringPayment{
+			Description:  last.Description,
+			CategoryID:   last.CategoryID,
+			Amount:       total / float64(len(group)),
+			LastDate:     last.Date,
+			NextDate:     next,
+			Subscription: same && !last.IsIncome(),
+		})
+		for _, t := range group {
+			ids[t.ID] = true
+		}
+	}
+	return payments, ids
+}
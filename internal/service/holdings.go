@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// maxAllocationSlices - сколько долей показывать в структуре капитала, остальное - "Прочее"
+const maxAllocationSlices = 8
+
+// holdingTicker - тикер бумаги или монеты: SBER, TMOS, BTC
+var holdingTicker = regexp.MustCompile(`^[A-Z0-9.\-]{1,12}$`)
+
+// ErrUnknownTicker возвращается для тикера, котировки которого нет у источника
+var ErrUnknownTicker = fmt.Errorf("%w: unknown ticker", model.ErrValidation)
+
+// PriceProvider возвращает текущие котировки по тикерам. Тикеров, которых источник
+// не знает, в ответе нет
+type PriceProvider interface {
+	Quotes(ctx context.Context, tickers []string) (map[string]model.Quote, error)
+}
+
+// SetPriceProvider подключает источник котировок для вида инвестиций
+func (s *ExpenseTracker) SetPriceProvider(kind string, provider PriceProvider) {
+	if s.prices == nil {
+		s.prices = make(map[string]PriceProvider)
+	}
+	s.prices[kind] = provider
+}
+
+// HoldingValue - инвестиция, оцененная по рынку
+type HoldingValue struct {
+	model.Holding
+	Value float64 // стоимость в валюте учета
+	Stale bool    // свежей котировки нет, стоимость по последней известной цене
+}
+
+// GetHoldings возвращает инвестиции пользователя
+func (s *ExpenseTracker) GetHoldings(ctx context.Context, userID int64) ([]model.Holding, error) {
+	return s.repo.GetHoldings(ctx, userID)
+}
+
+// SaveHolding записывает, сколько бумаг или монет есть у пользователя. Повторная запись
+// того же тикера заменяет количество
+func (s *ExpenseTracker) SaveHolding(ctx context.Context, userID int64, kind, ticker string, quantity float64) (*model.Holding, error) {
+	if kind != model.HoldingKindStock && kind != model.HoldingKindCrypto {
+		return nil, fmt.Errorf("unknown holding kind: %s", kind)
+	}
+	ticker = strings.ToUpper(strings.TrimSpace(ticker))
+	if !holdingTicker.MatchString(ticker) {
+		return nil, fmt.Errorf("%w: invalid ticker %q", model.ErrValidation, ticker)
+	}
+	if quantity <= 0 || math.IsInf(quantity, 0) || math.IsNaN(quantity) {
+		return nil, fmt.Errorf("%w: quantity must be positive", model.ErrValidation)
+	}
+
+	holding := &model.Holding{
+		UserID:    userID,
+		Kind:      kind,
+		Ticker:    ticker,
+		Quantity:  quantity,
+		CreatedAt: s.now(),
+	}
+	if provider, ok := s.prices[kind]; ok {
+		quotes, err := provider.Quotes(ctx, []string{ticker})
+		if err != nil {
+			// Источник недоступен: сохраняем без цены, она появится при следующей оценке
+			log.Printf("Error fetching quote for %s: %v", ticker, err)
+		} else if quote, ok := quotes[ticker]; !ok {
+			return nil, fmt.Errorf("%w %s", ErrUnknownTicker, ticker)
+		} else if price, err := s.quotePrice(ctx, quote); err != nil {
+			log.Printf("Error converting quote for %s: %v", ticker, err)
+		} else {
+			now := s.now()
+			holding.Price, holding.PricedAt = price, &now
+		}
+	}
+	if err := s.repo.SaveHolding(ctx, holding); err != nil {
+		return nil, err
+	}
+	return holding, nil
+}
+
+// DeleteHolding удаляет инвестицию
+func (s *ExpenseTracker) DeleteHolding(ctx context.Context, holdingID string, userID int64) error {
+	return s.repo.DeleteHolding(ctx, holdingID, userID)
+}
+
+// quotePrice пересчитывает котировку в валюту учета по сегодняшнему курсу
+func (s *ExpenseTracker) quotePrice(ctx context.Context, quote model.Quote) (float64, error) {
+	if quote.Currency == "" {
+		return quote.Price, nil
+	}
+	rate, err := s.ExchangeRate(ctx, quote.Currency, s.now())
+	if err != nil {
+		return 0, err
+	}
+	return quote.Price * rate, nil
+}
+
+// valueHoldings оценивает инвестиции по текущим котировкам и сохраняет новые цены.
+// Если котировку получить не удалось, стоимость считается по последней известной цене
+func (s *ExpenseTracker) valueHoldings(ctx context.Context, holdings []model.Holding) []HoldingValue {
+	tickers := make(map[string][]string)
+	for _, h := range holdings {
+		tickers[h.Kind] = append(tickers[h.Kind], h.Ticker)
+	}
+	quotes := make(map[string]map[string]model.Quote, len(tickers))
+	for kind, list := range tickers {
+		provider, ok := s.prices[kind]
+		if !ok {
+			continue
+		}
+		result, err := provider.Quotes(ctx, list)
+		if err != nil {
+			log.Printf("Error fetching %s quotes: %v", kind, err)
+			continue
+		}
+		quotes[kind] = result
+	}
+
+	now := s.now()
+	values := make([]HoldingValue, 0, len(holdings))
+	for _, h := range holdings {
+		value := HoldingValue{Holding: h, Stale: true}
+		if quote, ok := quotes[h.Kind][h.Ticker]; ok {
+			if price, err := s.quotePrice(ctx, quote); err != nil {
+				log.Printf("Error converting quote for %s: %v", h.Ticker, err)
+			} else {
+				value.Price, value.PricedAt, value.Stale = price, &now, false
+				// Цена хранится под ключом данных инвестиции, запрос идет мимо общего бюджета
+				if err := s.ledger.Repository.UpdateHoldingPrice(ctx, &value.Holding); err != nil {
+					log.Printf("Error saving price for %s: %v", h.Ticker, err)
+				}
+			}
+		}
+		value.Value = math.Round(value.Quantity*value.Price*100) / 100
+		values = append(values, value)
+	}
+	sort.SliceStable(values, func(i, j int) bool { return values[i].Value > values[j].Value })
+	return values
+}
+
+// AllocationSlice - доля в структуре капитала
+type AllocationSlice struct {
+	Name  string
+	Value float64
+}
+
+// Allocation возвращает структуру капитала: деньги на счетах, имущество и каждая
+// инвестиция отдельно, крупные первыми. Мелкие доли сверх maxAllocationSlices
+// собираются в "Прочее"
+func (n *NetWorthSummary) Allocation() []AllocationSlice {
+	var slices []AllocationSlice
+	accounts := 0.0
+	for _, b := range n.Balances {
+		// Ушедшие в минус счета уменьшают капитал, но не входят в его структуру
+		if b.Balance > 0 {
+			accounts += b.Balance
+		}
+	}
+	if accounts > 0 {
+		slices = append(slices, AllocationSlice{Name: "Счета", Value: accounts})
+	}
+	if n.Snapshot.Assets > 0 {
+		slices = append(slices, AllocationSlice{Name: "Имущество", Value: n.Snapshot.Assets})
+	}
+	for _, h := range n.Holdings {
+		if h.Value > 0 {
+			slices = append(slices, AllocationSlice{Name: h.Ticker, Value: h.Value})
+		}
+	}
+	sort.SliceStable(slices, func(i, j int) bool { return slices[i].Value > slices[j].Value })
+
+	if len(slices) <= maxAllocationSlices {
+		return slices
+	}
+	other := AllocationSlice{Name: "Прочее"}
+	for _, slice := range slices[maxAllocationSlices-1:] {
+		other.Value += slice.Value
+	}
+	return append(slices[:maxAllocationSlices-1], other)
+}
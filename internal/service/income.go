@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+const (
+	// incomeStabilityMonths - за сколько полных месяцев анализируются доходы
+	incomeStabilityMonths = 6
+	// incomeConcentrationWarning - доля одного источника, при которой доход считается зависимым от него
+	incomeConcentrationWarning = 0.7
+	// incomeVolatilityWarning - коэффициент вариации месячного дохода, выше которого доход нестабилен
+	incomeVolatilityWarning = 0.3
+)
+
+// IncomeSource - доход из одной категории по месяцам
+type IncomeSource struct {
+	Name    string
+	Emoji   string
+	Color   string
+	Monthly []float64 // суммы по месяцам IncomeStability.Months
+	Total   float64
+	Share   float64 // доля в общем доходе, 0..1
+	Months  int     // в скольких месяцах был доход
+}
+
+// Regular сообщает, что доход приходил хотя бы в двух месяцах из трех
+func (s IncomeSource) Regular() bool {
+	return s.Months*3 >= len(s.Monthly)*2
+}
+
+// IncomeStability - регулярность и концентрация доходов за последние месяцы
+type IncomeStability struct {
+	Months     []time.Time    // первые дни месяцев по порядку
+	Monthly    []float64      // общий доход по месяцам
+	Sources    []IncomeSource // по убыванию суммы
+	Average    float64        // средний доход в месяц
+	Volatility float64        // коэффициент вариации месячного дохода: 0 - одинаковый каждый месяц
+	Warnings   []string
+}
+
+// Top возвращает крупнейший источник дохода
+func (s *IncomeStability) Top() *IncomeSource {
+	if len(s.Sources) == 0 {
+		return nil
+	}
+	return &s.Sources[0]
+}
+
+// GetIncomeStability анализирует доходы за последние полные месяцы: какая доля приходится
+// на крупнейший источник, насколько доход меняется от месяца к месяцу и какие источники
+// нерегулярны. Подкатегории доходов считаются в родительской категории
+func (s *ExpenseTracker) GetIncomeStability(ctx context.Context, userID int64) (*IncomeStability, error) {
+	now := time.Now()
+	end := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	start := end.AddDate(0, -incomeStabilityMonths, 0)
+	last := end.Add(-time.Nanosecond)
+
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{
+		StartDate: &start,
+		EndDate:   &last,
+		Type:      "income",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	stability := &IncomeStability{Monthly: make([]float64, incomeStabilityMonths)}
+	for i := 0; i < incomeStabilityMonths; i++ {
+		stability.Months = append(stability.Months, start.AddDate(0, i, 0))
+	}
+
+	sourceOf := make(map[string]string, len(categories))
+	for _, cat := range categories {
+		sourceOf[cat.ID] = cat.ID
+		if cat.ParentID != "" {
+			sourceOf[cat.ID] = cat.ParentID
+		}
+	}
+	sources := make(map[string]*IncomeSource)
+	for _, t := range transactions {
+		if !t.IsIncome() {
+			continue
+		}
+		month := (t.Date.Year()-start.Year())*12 + int(t.Date.Month()) - int(start.Month())
+		if month < 0 || month >= incomeStabilityMonths {
+			continue
+		}
+		id := sourceOf[t.CategoryID]
+		source, ok := sources[id]
+		if !ok {
+			source = &IncomeSource{Name: "Без категории", Monthly: make([]float64, incomeStabilityMonths)}
+			if cat := findCategory(categories, id); cat != nil {
+				source.Name, source.Emoji, source.Color = cat.Name, cat.Icon(), cat.Color
+			}
+			sources[id] = source
+		}
+		source.Monthly[month] += t.AbsAmount()
+		source.Total += t.AbsAmount()
+		stability.Monthly[month] += t.AbsAmount()
+	}
+
+	var total float64
+	for _, source := range sources {
+		for _, amount := range source.Monthly {
+			if amount > 0 {
+				source.Months++
+			}
+		}
+		total += source.Total
+		stability.Sources = append(stability.Sources, *source)
+	}
+	if total == 0 {
+		return stability, nil
+	}
+	for i := range stability.Sources {
+		stability.Sources[i].Share = stability.Sources[i].Total / total
+	}
+	sort.Slice(stability.Sources, func(i, j int) bool {
+		return stability.Sources[i].Total > stability.Sources[j].Total
+	})
+
+	stability.Average = total / incomeStabilityMonths
+	var variance float64
+	for _, amount := range stability.Monthly {
+		variance += (amount - stability.Average) * (amount - stability.Average)
+	}
+	stability.Volatility = math.Sqrt(variance/incomeStabilityMonths) / stability.Average
+
+	stability.Warnings = incomeWarnings(stability)
+	return stability, nil
+}
+
+// incomeWarnings предупреждает о зависимости от одного источника, скачках дохода
+// и нерегулярных источниках
+func incomeWarnings(stability *IncomeStability) []string {
+	var warnings []string
+	if top := stability.Top(); len(stability.Sources) > 1 && top.Share >= incomeConcentrationWarning {
+		warnings = append(warnings, fmt.Sprintf(
+			"%.0f%% дохода из одной категории «%s»: если этот доход пропадет, денег почти не останется", top.Share*100, top.Name))
+	}
+	if stability.Volatility > incomeVolatilityWarning {
+		low, high := stability.Monthly[0], stability.Monthly[0]
+		for _, amount := range stability.Monthly {
+			low, high = math.Min(low, amount), math.Max(high, amount)
+		}
+		warnings = append(warnings, fmt.Sprintf(
+			"Доход заметно меняется: от %.0f₽ до %.0f₽ в месяц. Планируйте траты от нижней границы", low, high))
+	}
+	for _, source := range stability.Sources {
+		if !source.Regular() && source.Share >= 0.2 {
+			warnings = append(warnings, fmt.Sprintf(
+				"Доход «%s» приходил только в %d из %d месяцев", source.Name, source.Months, len(source.Monthly)))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// ErrInflationProviderNotConfigured возвращается, если данные об инфляции не подключены
+var ErrInflationProviderNotConfigured = errors.New("inflation provider is not configured")
+
+// InflationProvider возвращает рост цен за месяцы периода в процентах к предыдущему месяцу.
+// Ключ - месяц в виде "2006-01"
+type InflationProvider interface {
+	MonthlyInflation(ctx context.Context, from, to time.Time) (map[string]float64, error)
+}
+
+// SetInflationProvider подключает источник данных об инфляции
+func (s *ExpenseTracker) SetInflationProvider(provider InflationProvider) {
+	s.inflation = provider
+}
+
+// InflationAvailable сообщает, можно ли пересчитывать отчеты с учетом инфляции
+func (s *ExpenseTracker) InflationAvailable() bool {
+	return s.inflation != nil
+}
+
+// SetInflationAdjusted включает или выключает пересчет годового отчета с учетом инфляции
+func (s *ExpenseTracker) SetInflationAdjusted(ctx context.Context, userID int64, adjusted bool) (*model.UserSettings, error) {
+	if adjusted && s.inflation == nil {
+		return nil, ErrInflationProviderNotConfigured
+	}
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings.InflationAdjusted = adjusted
+	if err := s.SaveUserSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// inflationAdjusted сообщает, нужно ли пересчитать годовой отчет пользователя по инфляции
+func (s *ExpenseTracker) inflationAdjusted(ctx context.Context, userID int64) bool {
+	if s.inflation == nil {
+		return false
+	}
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting settings for inflation: %v", err)
+		return false
+	}
+	return settings.InflationAdjusted
+}
+
+// adjustForInflation пересчитывает суммы транзакций в цены месяца now: сумма месяца
+// умножается на рост цен за все следующие месяцы. Для месяцев без данных рост цен
+// считается нулевым. Возвращает копии транзакций
+func (s *ExpenseTracker) adjustForInflation(ctx context.Context, now time.Time, transactions ...[]model.Transaction) ([][]model.Transaction, error) {
+	from := now
+	for _, list := range transactions {
+		for _, t := range list {
+			if t.Date.Before(from) {
+				from = t.Date
+			}
+		}
+	}
+
+	rates, err := s.inflation.MonthlyInflation(ctx, from, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inflation: %w", err)
+	}
+
+	// Множитель месяца - произведение роста цен всех месяцев после него до now включительно
+	factors := make(map[string]float64)
+	factor := 1.0
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	start := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, now.Location())
+	for month := current; !month.Before(start); month = month.AddDate(0, -1, 0) {
+		factors[month.Format("2006-01")] = factor
+		factor *= 1 + rates[month.Format("2006-01")]/100
+	}
+
+	adjusted := make([][]model.Transaction, len(transactions))
+	for i, list := range transactions {
+		adjusted[i] = make([]model.Transaction, len(list))
+		for j, t := range list {
+			if f, ok := factors[t.Date.Format("2006-01")]; ok {
+				t.Amount *= f
+			}
+			adjusted[i][j] = t
+		}
+	}
+	return adjusted, nil
+}
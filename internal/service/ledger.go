@@ -0,0 +1,787 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+var (
+	// ErrInviteNotFound возвращается для неизвестного или уже использованного приглашения
+	ErrInviteNotFound = fmt.Errorf("ledger invite %w", model.ErrNotFound)
+	// ErrOwnInvite возвращается при попытке принять собственное приглашение
+	ErrOwnInvite = errors.New("cannot join own ledger")
+	// ErrAlreadyInLedger возвращается, если пользователь уже участвует в чужом общем бюджете
+	ErrAlreadyInLedger = errors.New("user already belongs to another ledger")
+	// ErrLedgerHasMembers возвращается, если у пользователя есть свой общий бюджет с участниками
+	ErrLedgerHasMembers = errors.New("user owns a ledger with members")
+	// ErrNotLedgerMember возвращается при выходе из бюджета пользователем, который в нем не участвует
+	ErrNotLedgerMember = errors.New("user is not a ledger member")
+)
+
+// MemberStats - вклад участника общего бюджета за период отчета
+type MemberStats struct {
+	UserID   int64
+	Name     string
+	Income   float64
+	Expenses float64
+}
+
+// ledgerScope переводит запросы пользователя на данные выбранной им книги учета, а участника
+// общего бюджета - на данные владельца. Состояния и настройки пользователей остаются личными,
+// поэтому их методы не переопределяются
+type ledgerScope struct {
+	Repository
+}
+
+func newLedgerScope(repo Repository) *ledgerScope {
+	return &ledgerScope{Repository: repo}
+}
+
+type ledgerOwnersKey struct{}
+
+// ledgerOwners - владельцы данных, найденные за время обработки одного обновления
+type ledgerOwners struct {
+	mu     sync.Mutex
+	owners map[int64]int64
+}
+
+// WithLedgerScope запоминает книгу пользователя на время обработки одного обновления, чтобы
+// не читать user_settings и ledger_members при каждом запросе к данным. Между обновлениями
+// книга не кэшируется: бот работает в нескольких экземплярах функции, и смена книги или
+// исключение из бюджета в одном из них должны сразу действовать во всех
+func WithLedgerScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ledgerOwnersKey{}, &ledgerOwners{owners: make(map[int64]int64)})
+}
+
+// scopeOwners возвращает владельцев, запомненных для текущего обновления, или nil
+func scopeOwners(ctx context.Context) *ledgerOwners {
+	owners, _ := ctx.Value(ledgerOwnersKey{}).(*ledgerOwners)
+	return owners
+}
+
+// owner возвращает ключ данных, с которыми работает пользователь: выбранной книги учета,
+// бюджета, в котором он участвует, или его собственных
+func (l *ledgerScope) owner(ctx context.Context, userID int64) (int64, error) {
+	memo := scopeOwners(ctx)
+	if memo != nil {
+		memo.mu.Lock()
+		ownerID, ok := memo.owners[userID]
+		memo.mu.Unlock()
+		if ok {
+			return ownerID, nil
+		}
+	}
+
+	settings, err := l.Repository.GetUserSettings(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve ledger: %w", err)
+	}
+	ownerID := int64(0)
+	if settings != nil {
+		ownerID = settings.ActiveLedger
+	}
+	if ownerID == 0 {
+		if ownerID, err = l.mainOwner(ctx, userID); err != nil {
+			return 0, err
+		}
+	}
+
+	if memo != nil {
+		memo.mu.Lock()
+		memo.owners[userID] = ownerID
+		memo.mu.Unlock()
+	}
+	return ownerID, nil
+}
+
+// mainOwner возвращает ключ данных основной книги: ID владельца бюджета, в котором
+// участвует пользователь, или его собственный
+func (l *ledgerScope) mainOwner(ctx context.Context, userID int64) (int64, error) {
+	member, err := l.Repository.GetLedgerMember(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve ledger: %w", err)
+	}
+	if member != nil {
+		return member.OwnerID, nil
+	}
+	return userID, nil
+}
+
+// readScopes возвращает ключи данных, из которых читаются отчеты: текущей книги,
+// а в сводном режиме - основной и всех книг пользователя
+func (l *ledgerScope) readScopes(ctx context.Context, userID int64) ([]int64, error) {
+	if !allLedgers(ctx) {
+		ownerID, err := l.owner(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return []int64{ownerID}, nil
+	}
+
+	mainID, err := l.mainOwner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	ledgers, err := l.Repository.GetLedgers(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledgers: %w", err)
+	}
+	scopes := []int64{mainID}
+	for _, ledger := range ledgers {
+		scopes = append(scopes, ledger.DataID)
+	}
+	return scopes, nil
+}
+
+// forget сбрасывает запомненную для обновления книгу после изменения состава бюджета
+// или смены книги
+func (l *ledgerScope) forget(ctx context.Context, userIDs ...int64) {
+	memo := scopeOwners(ctx)
+	if memo == nil {
+		return
+	}
+	memo.mu.Lock()
+	defer memo.mu.Unlock()
+	for _, id := range userIDs {
+		delete(memo.owners, id)
+	}
+}
+
+func (l *ledgerScope) GetTransactions(ctx context.Context, userID int64, filter model.TransactionFilter) ([]model.Transaction, error) {
+	scopes, err := l.readScopes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(scopes) == 1 {
+		return l.Repository.GetTransactions(ctx, scopes[0], filter)
+	}
+
+	var transactions []model.Transaction
+	for _, scope := range scopes {
+		items, err := l.Repository.GetTransactions(ctx, scope, filter)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, items...)
+	}
+	// Хранилище отдает транзакции от новых к старым, сводный список сохраняет этот порядок
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].Date.After(transactions[j].Date)
+	})
+	return transactions, nil
+}
+
+func (l *ledgerScope) GetTransactionsByCategory(ctx context.Context, userID int64, categoryID string) ([]model.Transaction, error) {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return l.Repository.GetTransactionsByCategory(ctx, ownerID, categoryID)
+}
+
+// CreateTransaction запоминает автора транзакции и сохраняет ее в бюджет владельца
+func (l *ledgerScope) CreateTransaction(ctx context.Context, transaction *model.Transaction) error {
+	ownerID, err := l.owner(ctx, transaction.UserID)
+	if err != nil {
+		return err
+	}
+	if transaction.AuthorID == 0 {
+		transaction.AuthorID = transaction.UserID
+	}
+	transaction.UserID = ownerID
+	return l.Repository.CreateTransaction(ctx, transaction)
+}
+
+// CreateTransactions сохраняет транзакции в бюджеты владельцев, запоминая авторов
+func (l *ledgerScope) CreateTransactions(ctx context.Context, transactions []*model.Transaction) error {
+	for _, transaction := range transactions {
+		ownerID, err := l.owner(ctx, transaction.UserID)
+		if err != nil {
+			return err
+		}
+		if transaction.AuthorID == 0 {
+			transaction.AuthorID = transaction.UserID
+		}
+		transaction.UserID = ownerID
+	}
+	return l.Repository.CreateTransactions(ctx, transactions)
+}
+
+func (l *ledgerScope) DeleteTransaction(ctx context.Context, transactionID string, userID int64) error {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return l.Repository.DeleteTransaction(ctx, transactionID, ownerID)
+}
+
+func (l *ledgerScope) UpdateTransactionCategory(ctx context.Context, transactionID string, userID int64, categoryID string) error {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return l.Repository.UpdateTransactionCategory(ctx, transactionID, ownerID, categoryID)
+}
+
+func (l *ledgerScope) GetTransaction(ctx context.Context, transactionID string, userID int64) (*model.Transaction, error) {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return l.Repository.GetTransaction(ctx, transactionID, ownerID)
+}
+
+func (l *ledgerScope) UpdateTransactionDetails(ctx context.Context, transactionID string, userID int64, note, attachmentPath string) error {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return l.Repository.UpdateTransactionDetails(ctx, transactionID, ownerID, note, attachmentPath)
+}
+
+func (l *ledgerScope) UpdateTransactionBusiness(ctx context.Context, transactionID string, userID int64, counterparty, invoiceNumber string, vatAmount float64) error {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return l.Repository.UpdateTransactionBusiness(ctx, transactionID, ownerID, counterparty, invoiceNumber, vatAmount)
+}
+
+func (l *ledgerScope) UpdateTransactionEvent(ctx context.Context, transactionID string, userID int64, eventID string) error {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return l.Repository.UpdateTransactionEvent(ctx, transactionID, ownerID, eventID)
+}
+
+func (l *ledgerScope) GetDeletedTransactions(ctx context.Context, userID int64, since time.Time) ([]model.Transaction, error) {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return l.Repository.GetDeletedTransactions(ctx, ownerID, since)
+}
+
+func (l *ledgerScope) GetDeletedCategories(ctx context.Context, userID int64, since time.Time) ([]model.Category, error) {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return l.Repository.GetDeletedCategories(ctx, ownerID, since)
+}
+
+func (l *ledgerScope) RestoreTransaction(ctx context.Context, transactionID string, userID int64) error {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return l.Repository.RestoreTransaction(ctx, transactionID, ownerID)
+}
+
+func (l *ledgerScope) GetCategories(ctx context.Context, userID int64) ([]model.Category, error) {
+	scopes, err := l.readScopes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var categories []model.Category
+	for _, scope := range scopes {
+		items, err := l.Repository.GetCategories(ctx, scope)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, items...)
+	}
+	return categories, nil
+}
+
+func (l *ledgerScope) GetReportData(ctx context.Context, userID int64, current, previous model.TransactionFilter) (*model.ReportData, error) {
+	scopes, err := l.readScopes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(scopes) == 1 {
+		return l.Repository.GetReportData(ctx, scopes[0], current, previous)
+	}
+
+	data := &model.ReportData{}
+	for _, scope := range scopes {
+		part, err := l.Repository.GetReportData(ctx, scope, current, previous)
+		if err != nil {
+			return nil, err
+		}
+		data.Categories = append(data.Categories, part.Categories...)
+		data.Current = append(data.Current, part.Current...)
+		data.Previous = append(data.Previous, part.Previous...)
+	}
+	return data, nil
+}
+
+func (l *ledgerScope) CreateCategory(ctx context.Context, category *model.Category) error {
+	ownerID, err := l.owner(ctx, category.UserID)
+	if err != nil {
+		return err
+	}
+	category.UserID = ownerID
+	return l.Repository.CreateCategory(ctx, category)
+}
+
+func (l *ledgerScope) DeleteCategory(ctx context.Context, categoryID string, userID int64) error {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return l.Repository.DeleteCategory(ctx, categoryID, ownerID)
+}
+
+func (l *ledgerScope) GetBudgets(ctx context.Context, userID int64) ([]model.Budget, error) {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return l.Repository.GetBudgets(ctx, ownerID)
+}
+
+func (l *ledgerScope) SaveBudget(ctx context.Context, budget *model.Budget) error {
+	ownerID, err := l.owner(ctx, budget.UserID)
+	if err != nil {
+		return err
+	}
+	budget.UserID = ownerID
+	return l.Repository.SaveBudget(ctx, budget)
+}
+
+func (l *ledgerScope) GetPlannedExpenses(ctx context.Context, userID int64, since time.Time) ([]model.PlannedExpense, error) {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return l.Repository.GetPlannedExpenses(ctx, ownerID, since)
+}
+
+func (l *ledgerScope) CreatePlannedExpense(ctx context.Context, plan *model.PlannedExpense) error {
+	ownerID, err := l.owner(ctx, plan.UserID)
+	if err != nil {
+		return err
+	}
+	plan.UserID = ownerID
+	return l.Repository.CreatePlannedExpense(ctx, plan)
+}
+
+func (l *ledgerScope) DeletePlannedExpense(ctx context.Context, id string, userID int64) error {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return l.Repository.DeletePlannedExpense(ctx, id, ownerID)
+}
+
+func (l *ledgerScope) GetWishlist(ctx context.Context, userID int64) ([]model.WishlistItem, error) {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return l.Repository.GetWishlist(ctx, ownerID)
+}
+
+func (l *ledgerScope) CreateWishlistItem(ctx context.Context, item *model.WishlistItem) error {
+	ownerID, err := l.owner(ctx, item.UserID)
+	if err != nil {
+		return err
+	}
+	item.UserID = ownerID
+	return l.Repository.CreateWishlistItem(ctx, item)
+}
+
+func (l *ledgerScope) DeleteWishlistItem(ctx context.Context, id string, userID int64) error {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return l.Repository.DeleteWishlistItem(ctx, id, ownerID)
+}
+
+func (l *ledgerScope) GetWishlistAllocations(ctx context.Context, userID int64) ([]model.WishlistAllocation, error) {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return l.Repository.GetWishlistAllocations(ctx, ownerID)
+}
+
+func (l *ledgerScope) CreateWishlistAllocation(ctx context.Context, allocation *model.WishlistAllocation) error {
+	ownerID, err := l.owner(ctx, allocation.UserID)
+	if err != nil {
+		return err
+	}
+	allocation.UserID = ownerID
+	return l.Repository.CreateWishlistAllocation(ctx, allocation)
+}
+
+func (l *ledgerScope) GetGoals(ctx context.Context, userID int64) ([]model.Goal, error) {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return l.Repository.GetGoals(ctx, ownerID)
+}
+
+func (l *ledgerScope) CreateGoal(ctx context.Context, goal *model.Goal) error {
+	ownerID, err := l.owner(ctx, goal.UserID)
+	if err != nil {
+		return err
+	}
+	goal.UserID = ownerID
+	return l.Repository.CreateGoal(ctx, goal)
+}
+
+func (l *ledgerScope) GetUserBaseline(ctx context.Context, userID int64) (*model.UserBaseline, error) {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return l.Repository.GetUserBaseline(ctx, ownerID)
+}
+
+func (l *ledgerScope) SaveUserBaseline(ctx context.Context, baseline *model.UserBaseline) error {
+	ownerID, err := l.owner(ctx, baseline.UserID)
+	if err != nil {
+		return err
+	}
+	baseline.UserID = ownerID
+	return l.Repository.SaveUserBaseline(ctx, baseline)
+}
+
+func (l *ledgerScope) GetAccounts(ctx context.Context, userID int64) ([]model.Account, error) {
+	scopes, err := l.readScopes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var accounts []model.Account
+	for _, scope := range scopes {
+		items, err := l.Repository.GetAccounts(ctx, scope)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, items...)
+	}
+	return accounts, nil
+}
+
+func (l *ledgerScope) CreateAccount(ctx context.Context, account *model.Account) error {
+	ownerID, err := l.owner(ctx, account.UserID)
+	if err != nil {
+		return err
+	}
+	account.UserID = ownerID
+	return l.Repository.CreateAccount(ctx, account)
+}
+
+func (l *ledgerScope) DeleteAccount(ctx context.Context, id string, userID int64) error {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return l.Repository.DeleteAccount(ctx, id, ownerID)
+}
+
+func (l *ledgerScope) GetEvents(ctx context.Context, userID int64) ([]model.Event, error) {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return l.Repository.GetEvents(ctx, ownerID)
+}
+
+func (l *ledgerScope) CreateEvent(ctx context.Context, event *model.Event) error {
+	ownerID, err := l.owner(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+	event.UserID = ownerID
+	return l.Repository.CreateEvent(ctx, event)
+}
+
+func (l *ledgerScope) DeleteEvent(ctx context.Context, id string, userID int64) error {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return l.Repository.DeleteEvent(ctx, id, ownerID)
+}
+
+// CreateLedgerInvite создает одноразовый код приглашения в общий бюджет пользователя
+func (s *ExpenseTracker) CreateLedgerInvite(ctx context.Context, ownerID int64, ownerName string) (string, error) {
+	member, err := s.repo.GetLedgerMember(ctx, ownerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get ledger member: %w", err)
+	}
+	if member != nil && !member.IsOwner() {
+		return "", ErrAlreadyInLedger
+	}
+
+	// Владелец хранится среди участников, чтобы его имя было в отчетах
+	if member == nil {
+		if err := s.repo.SaveLedgerMember(ctx, &model.LedgerMember{
+			MemberID: ownerID,
+			OwnerID:  ownerID,
+			Name:     ownerName,
+			JoinedAt: time.Now(),
+		}); err != nil {
+			return "", err
+		}
+	}
+
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate invite code: %w", err)
+	}
+	invite := &model.LedgerInvite{
+		Code:      hex.EncodeToString(buf),
+		OwnerID:   ownerID,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.CreateLedgerInvite(ctx, invite); err != nil {
+		return "", err
+	}
+	return invite.Code, nil
+}
+
+// JoinLedger принимает приглашение и возвращает владельца бюджета
+func (s *ExpenseTracker) JoinLedger(ctx context.Context, code string, memberID int64, memberName string) (*model.LedgerMember, error) {
+	invite, err := s.repo.GetLedgerInvite(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invite: %w", err)
+	}
+	if invite == nil {
+		return nil, ErrInviteNotFound
+	}
+	if invite.OwnerID == memberID {
+		return nil, ErrOwnInvite
+	}
+
+	current, err := s.repo.GetLedgerMember(ctx, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger member: %w", err)
+	}
+	if current != nil {
+		if !current.IsOwner() {
+			return nil, ErrAlreadyInLedger
+		}
+		members, err := s.repo.GetLedgerMembers(ctx, memberID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ledger members: %w", err)
+		}
+		if len(members) > 1 {
+			return nil, ErrLedgerHasMembers
+		}
+	}
+
+	if err := s.repo.SaveLedgerMember(ctx, &model.LedgerMember{
+		MemberID: memberID,
+		OwnerID:  invite.OwnerID,
+		Name:     memberName,
+		JoinedAt: time.Now(),
+	}); err != nil {
+		return nil, err
+	}
+	if err := s.repo.DeleteLedgerInvite(ctx, code); err != nil {
+		return nil, err
+	}
+	s.ledger.forget(ctx, memberID)
+
+	owner, err := s.repo.GetLedgerMember(ctx, invite.OwnerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger owner: %w", err)
+	}
+	if owner == nil {
+		owner = &model.LedgerMember{MemberID: invite.OwnerID, OwnerID: invite.OwnerID}
+	}
+	return owner, nil
+}
+
+// LeaveLedger выводит участника из общего бюджета; ему снова доступны его личные данные
+func (s *ExpenseTracker) LeaveLedger(ctx context.Context, memberID int64) error {
+	member, err := s.repo.GetLedgerMember(ctx, memberID)
+	if err != nil {
+		return fmt.Errorf("failed to get ledger member: %w", err)
+	}
+	if member == nil || member.IsOwner() {
+		return ErrNotLedgerMember
+	}
+	if err := s.repo.DeleteLedgerMember(ctx, memberID); err != nil {
+		return err
+	}
+	s.ledger.forget(ctx, memberID)
+	return nil
+}
+
+// RemoveLedgerMember исключает участника из бюджета владельца
+func (s *ExpenseTracker) RemoveLedgerMember(ctx context.Context, ownerID, memberID int64) error {
+	member, err := s.repo.GetLedgerMember(ctx, memberID)
+	if err != nil {
+		return fmt.Errorf("failed to get ledger member: %w", err)
+	}
+	if member == nil || member.OwnerID != ownerID || member.IsOwner() {
+		return ErrNotLedgerMember
+	}
+	if err := s.repo.DeleteLedgerMember(ctx, memberID); err != nil {
+		return err
+	}
+	s.ledger.forget(ctx, memberID)
+	return nil
+}
+
+// GetLedgerMembers возвращает участников бюджета, в котором состоит пользователь.
+// Для личного бюджета список пуст
+func (s *ExpenseTracker) GetLedgerMembers(ctx context.Context, userID int64) ([]model.LedgerMember, error) {
+	ownerID, err := s.ledger.owner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.repo.GetLedgerMembers(ctx, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger members: %w", err)
+	}
+	// Владелец первым, остальные в порядке вступления
+	sort.SliceStable(members, func(i, j int) bool {
+		if members[i].IsOwner() != members[j].IsOwner() {
+			return members[i].IsOwner()
+		}
+		return members[i].JoinedAt.Before(members[j].JoinedAt)
+	})
+	return members, nil
+}
+
+// memberStats считает доходы и расходы каждого участника общего бюджета
+func (s *ExpenseTracker) memberStats(ctx context.Context, userID int64, transactions []model.Transaction) ([]MemberStats, error) {
+	members, err := s.GetLedgerMembers(ctx, userID)
+	if err != nil || len(members) < 2 {
+		return nil, err
+	}
+
+	stats := make([]MemberStats, len(members))
+	index := make(map[int64]int, len(members))
+	for i, m := range members {
+		stats[i] = MemberStats{UserID: m.MemberID, Name: m.Name}
+		index[m.MemberID] = i
+	}
+
+	for _, t := range transactions {
+		// Транзакции без автора созданы владельцем до появления общего бюджета
+		author := t.AuthorID
+		if author == 0 {
+			author = t.UserID
+		}
+		i, ok := index[author]
+		if !ok {
+			continue
+		}
+		if t.IsIncome() {
+			stats[i].Income += t.AbsAmount()
+		} else {
+			stats[i].Expenses += t.AbsAmount()
+		}
+	}
+	return stats, nil
+}
+
+func (l *ledgerScope) GetAssets(ctx context.Context, userID int64) ([]model.Asset, error) {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return l.Repository.GetAssets(ctx, ownerID)
+}
+
+func (l *ledgerScope) CreateAsset(ctx context.Context, asset *model.Asset) error {
+	ownerID, err := l.owner(ctx, asset.UserID)
+	if err != nil {
+		return err
+	}
+	asset.UserID = ownerID
+	return l.Repository.CreateAsset(ctx, asset)
+}
+
+func (l *ledgerScope) DeleteAsset(ctx context.Context, id string, userID int64) error {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return l.Repository.DeleteAsset(ctx, id, ownerID)
+}
+
+func (l *ledgerScope) GetHoldings(ctx context.Context, userID int64) ([]model.Holding, error) {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return l.Repository.GetHoldings(ctx, ownerID)
+}
+
+func (l *ledgerScope) SaveHolding(ctx context.Context, holding *model.Holding) error {
+	ownerID, err := l.owner(ctx, holding.UserID)
+	if err != nil {
+		return err
+	}
+	holding.UserID = ownerID
+	return l.Repository.SaveHolding(ctx, holding)
+}
+
+func (l *ledgerScope) DeleteHolding(ctx context.Context, id string, userID int64) error {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return l.Repository.DeleteHolding(ctx, id, ownerID)
+}
+
+func (l *ledgerScope) GetNetWorthSnapshots(ctx context.Context, userID int64, limit int) ([]model.NetWorthSnapshot, error) {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return l.Repository.GetNetWorthSnapshots(ctx, ownerID, limit)
+}
+
+func (l *ledgerScope) SaveNetWorthSnapshot(ctx context.Context, snapshot *model.NetWorthSnapshot) error {
+	ownerID, err := l.owner(ctx, snapshot.UserID)
+	if err != nil {
+		return err
+	}
+	snapshot.UserID = ownerID
+	return l.Repository.SaveNetWorthSnapshot(ctx, snapshot)
+}
+
+func (l *ledgerScope) GetWebhooks(ctx context.Context, userID int64) ([]model.Webhook, error) {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return l.Repository.GetWebhooks(ctx, ownerID)
+}
+
+func (l *ledgerScope) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	ownerID, err := l.owner(ctx, webhook.UserID)
+	if err != nil {
+		return err
+	}
+	webhook.UserID = ownerID
+	return l.Repository.CreateWebhook(ctx, webhook)
+}
+
+func (l *ledgerScope) DeleteWebhook(ctx context.Context, id string, userID int64) error {
+	ownerID, err := l.owner(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return l.Repository.DeleteWebhook(ctx, id, ownerID)
+}
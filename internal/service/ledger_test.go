@@ -0,0 +1,62 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+	"github.com/ivanoskov/financial_bot/internal/service/mocks"
+)
+
+// recentScope возвращает ключ данных, из которых прочитаны последние транзакции
+func recentScope(t *testing.T, ctx context.Context, tracker *service.ExpenseTracker, repo *mocks.Repository) int64 {
+	t.Helper()
+	if _, err := tracker.GetRecentTransactions(ctx, 2, 5); err != nil {
+		t.Fatalf("GetRecentTransactions: %v", err)
+	}
+	calls := repo.CallsOf("GetTransactions")
+	return calls[len(calls)-1].Args[0].(int64)
+}
+
+func TestLedgerScopeFollowsMembership(t *testing.T) {
+	member := &model.LedgerMember{MemberID: 2, OwnerID: 1}
+	repo := &mocks.Repository{
+		GetLedgerMemberFunc: func(ctx context.Context, memberID int64) (*model.LedgerMember, error) {
+			if member != nil && member.MemberID == memberID {
+				return member, nil
+			}
+			return nil, nil
+		},
+	}
+	tracker := service.NewExpenseTracker(repo)
+
+	if scope := recentScope(t, context.Background(), tracker, repo); scope != 1 {
+		t.Fatalf("участник читает данные %d, ожидались данные владельца 1", scope)
+	}
+
+	// Исключение из бюджета, сделанное другим экземпляром, действует со следующего запроса
+	member = nil
+	if scope := recentScope(t, context.Background(), tracker, repo); scope != 2 {
+		t.Errorf("после исключения читаются данные %d, ожидались собственные 2", scope)
+	}
+}
+
+func TestLedgerScopeResolvedOncePerUpdate(t *testing.T) {
+	repo := &mocks.Repository{
+		GetUserSettingsFunc: func(ctx context.Context, userID int64) (*model.UserSettings, error) {
+			return &model.UserSettings{UserID: userID, ActiveLedger: -7}, nil
+		},
+	}
+	tracker := service.NewExpenseTracker(repo)
+
+	ctx := service.WithLedgerScope(context.Background())
+	for i := 0; i < 3; i++ {
+		if scope := recentScope(t, ctx, tracker, repo); scope != -7 {
+			t.Fatalf("читаются данные %d, ожидалась выбранная книга -7", scope)
+		}
+	}
+	if calls := repo.CallsOf("GetUserSettings"); len(calls) != 1 {
+		t.Errorf("настройки прочитаны %d раз за обновление, ожидался 1", len(calls))
+	}
+}
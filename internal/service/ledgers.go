@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+const (
+	// maxLedgers - сколько дополнительных книг учета может завести пользователь
+	maxLedgers = 5
+	// maxLedgerName - длина названия книги в символах, чтобы оно помещалось на кнопку
+	maxLedgerName = 32
+)
+
+var (
+	// ErrLedgerNotFound возвращается для чужой или удаленной книги учета
+	ErrLedgerNotFound = fmt.Errorf("ledger %w", model.ErrNotFound)
+	// ErrLedgerLimit возвращается при попытке завести книг больше maxLedgers
+	ErrLedgerLimit = fmt.Errorf("%w: ledger limit of %d reached", model.ErrValidation, maxLedgers)
+)
+
+// MainLedger - основная книга: данные, которые хранятся под ID пользователя
+var MainLedger = model.Ledger{Name: "Личная", Emoji: "👤"}
+
+type allLedgersKey struct{}
+
+// WithAllLedgers включает сводный режим: отчеты читают данные основной и всех дополнительных
+// книг пользователя. Записи по-прежнему попадают в текущую книгу
+func WithAllLedgers(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allLedgersKey{}, true)
+}
+
+// allLedgers сообщает, включен ли сводный режим
+func allLedgers(ctx context.Context) bool {
+	all, _ := ctx.Value(allLedgersKey{}).(bool)
+	return all
+}
+
+// GetLedgers возвращает дополнительные книги учета пользователя. Основная книга в список
+// не входит: это MainLedger
+func (s *ExpenseTracker) GetLedgers(ctx context.Context, userID int64) ([]model.Ledger, error) {
+	ledgers, err := s.repo.GetLedgers(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledgers: %w", err)
+	}
+	return ledgers, nil
+}
+
+// ActiveLedger возвращает книгу, с которой сейчас работает пользователь
+func (s *ExpenseTracker) ActiveLedger(ctx context.Context, userID int64) (model.Ledger, error) {
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return model.Ledger{}, err
+	}
+	if settings.ActiveLedger == 0 {
+		return MainLedger, nil
+	}
+	ledgers, err := s.GetLedgers(ctx, userID)
+	if err != nil {
+		return model.Ledger{}, err
+	}
+	for _, ledger := range ledgers {
+		if ledger.DataID == settings.ActiveLedger {
+			return ledger, nil
+		}
+	}
+	// Книгу удалили, а настройки не успели сбросить
+	return MainLedger, nil
+}
+
+// CreateLedger заводит книгу учета и сразу переключает на нее пользователя. В новую книгу
+// копируются категории текущей, чтобы записывать можно было сразу
+func (s *ExpenseTracker) CreateLedger(ctx context.Context, userID int64, name, emoji string) (*model.Ledger, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxLedgerName {
+		return nil, fmt.Errorf("%w: ledger name must be 1-%d characters", model.ErrValidation, maxLedgerName)
+	}
+	ledgers, err := s.GetLedgers(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ledgers) >= maxLedgers {
+		return nil, ErrLedgerLimit
+	}
+
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	ledger := &model.Ledger{UserID: userID, Name: name, Emoji: emoji, CreatedAt: time.Now()}
+	if err := s.repo.CreateLedger(ctx, ledger); err != nil {
+		return nil, err
+	}
+	markWrote(ctx)
+	if err := s.SwitchLedger(ctx, userID, ledger.ID); err != nil {
+		return nil, err
+	}
+
+	// Родительские категории создаются первыми, подкатегории получают их новые ID
+	model.SortCategories(categories)
+	parents := make(map[string]string)
+	for _, parent := range []bool{true, false} {
+		for _, category := range model.ActiveCategories(categories) {
+			if (category.ParentID == "") != parent {
+				continue
+			}
+			copied := category
+			copied.ID, copied.UserID, copied.CreatedAt = "", userID, time.Now()
+			if !parent {
+				if copied.ParentID = parents[category.ParentID]; copied.ParentID == "" {
+					continue
+				}
+			}
+			if err := s.repo.CreateCategory(ctx, &copied); err != nil {
+				return nil, fmt.Errorf("failed to copy category %s: %w", category.Name, err)
+			}
+			parents[category.ID] = copied.ID
+		}
+	}
+	return ledger, nil
+}
+
+// SwitchLedger переключает пользователя на книгу ledgerID, пустой ID - на основную
+func (s *ExpenseTracker) SwitchLedger(ctx context.Context, userID int64, ledgerID string) error {
+	active := int64(0)
+	if ledgerID != "" {
+		ledger, err := s.findLedger(ctx, userID, ledgerID)
+		if err != nil {
+			return err
+		}
+		active = ledger.DataID
+	}
+
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return err
+	}
+	settings.ActiveLedger = active
+	if err := s.SaveUserSettings(ctx, settings); err != nil {
+		return err
+	}
+	s.ledger.forget(ctx, userID)
+	return nil
+}
+
+// DeleteLedger безвозвратно удаляет книгу учета со всеми ее данными. Если пользователь
+// работал с ней, он возвращается к основной книге
+func (s *ExpenseTracker) DeleteLedger(ctx context.Context, userID int64, ledgerID string) error {
+	ledger, err := s.findLedger(ctx, userID, ledgerID)
+	if err != nil {
+		return err
+	}
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if settings.ActiveLedger == ledger.DataID {
+		if err := s.SwitchLedger(ctx, userID, ""); err != nil {
+			return err
+		}
+	}
+	attachments, err := s.storedAttachments(ctx, ledger.DataID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteLedger(ctx, ledger.ID, userID); err != nil {
+		return err
+	}
+	s.deleteAttachments(ctx, attachments...)
+	if s.reports != nil {
+		s.reports.Invalidate(ctx, ledger.DataID)
+	}
+	return nil
+}
+
+// findLedger возвращает книгу пользователя по ID
+func (s *ExpenseTracker) findLedger(ctx context.Context, userID int64, ledgerID string) (*model.Ledger, error) {
+	ledgers, err := s.GetLedgers(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, ledger := range ledgers {
+		if ledger.ID == ledgerID {
+			return &ledger, nil
+		}
+	}
+	return nil, ErrLedgerNotFound
+}
@@ -0,0 +1,961 @@
+// Package mocks - заглушка хранилища для модульных тестов сервиса
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+)
+
+// Call - вызов метода хранилища
+type Call struct {
+	Method string
+	Args   []any // аргументы без контекста
+}
+
+// Repository - управляемая заглушка service.Repository. Все вызовы записываются в Calls.
+// Поведение метода задается полем *Func; если оно не задано, метод возвращает пустой
+// результат без ошибки. Так в тестах подставляются данные и моделируются сбои базы
+type Repository struct {
+	GetTransactionsFunc           func(ctx context.Context, userID int64, filter model.TransactionFilter) ([]model.Transaction, error)
+	GetCategoriesFunc             func(ctx context.Context, userID int64) ([]model.Category, error)
+	GetTransactionsByCategoryFunc func(ctx context.Context, userID int64, categoryID string) ([]model.Transaction, error)
+	CreateTransactionFunc         func(ctx context.Context, transaction *model.Transaction) error
+	CreateTransactionsFunc        func(ctx context.Context, transactions []*model.Transaction) error
+	DeleteTransactionFunc         func(ctx context.Context, transactionID string, userID int64) error
+	UpdateTransactionCategoryFunc func(ctx context.Context, transactionID string, userID int64, categoryID string) error
+	GetTransactionFunc            func(ctx context.Context, transactionID string, userID int64) (*model.Transaction, error)
+	UpdateTransactionDetailsFunc  func(ctx context.Context, transactionID string, userID int64, note, attachmentPath string) error
+	UpdateTransactionBusinessFunc func(ctx context.Context, transactionID string, userID int64, counterparty, invoiceNumber string, vatAmount float64) error
+	UpdateTransactionEventFunc    func(ctx context.Context, transactionID string, userID int64, eventID string) error
+	GetDeletedTransactionsFunc    func(ctx context.Context, userID int64, since time.Time) ([]model.Transaction, error)
+	GetDeletedCategoriesFunc      func(ctx context.Context, userID int64, since time.Time) ([]model.Category, error)
+	RestoreTransactionFunc        func(ctx context.Context, transactionID string, userID int64) error
+	RestoreCategoryFunc           func(ctx context.Context, category *model.Category) error
+	PurgeDeletedFunc              func(ctx context.Context, before time.Time) ([]string, error)
+	CreateCategoryFunc            func(ctx context.Context, category *model.Category) error
+	UpdateCategoryFunc            func(ctx context.Context, category *model.Category) error
+	DeleteCategoryFunc            func(ctx context.Context, categoryID string, userID int64) error
+	GetUserStateFunc              func(ctx context.Context, userID int64) (*model.UserState, error)
+	SaveUserStateFunc             func(ctx context.Context, state *model.UserState) error
+	DeleteUserStateFunc           func(ctx context.Context, userID int64) error
+	DeleteUserStatesBeforeFunc    func(ctx context.Context, before time.Time) error
+	SaveUserFunc                  func(ctx context.Context, user *model.User) error
+	GetUsersFunc                  func(ctx context.Context) ([]model.User, error)
+	GetUserFunc                   func(ctx context.Context, userID int64) (*model.User, error)
+	SetMenuSessionFunc            func(ctx context.Context, userID int64, session int) error
+	DeleteUserDataFunc            func(ctx context.Context, userID int64) error
+	GetBudgetsFunc                func(ctx context.Context, userID int64) ([]model.Budget, error)
+	SaveBudgetFunc                func(ctx context.Context, budget *model.Budget) error
+	GetPlannedExpensesFunc        func(ctx context.Context, userID int64, since time.Time) ([]model.PlannedExpense, error)
+	CreatePlannedExpenseFunc      func(ctx context.Context, plan *model.PlannedExpense) error
+	DeletePlannedExpenseFunc      func(ctx context.Context, id string, userID int64) error
+	GetWishlistFunc               func(ctx context.Context, userID int64) ([]model.WishlistItem, error)
+	CreateWishlistItemFunc        func(ctx context.Context, item *model.WishlistItem) error
+	DeleteWishlistItemFunc        func(ctx context.Context, id string, userID int64) error
+	GetWishlistAllocationsFunc    func(ctx context.Context, userID int64) ([]model.WishlistAllocation, error)
+	CreateWishlistAllocationFunc  func(ctx context.Context, allocation *model.WishlistAllocation) error
+	GetGoalsFunc                  func(ctx context.Context, userID int64) ([]model.Goal, error)
+	CreateGoalFunc                func(ctx context.Context, goal *model.Goal) error
+	UpdateGoalSavedFunc           func(ctx context.Context, id string, userID int64, saved float64) error
+	GetUserBaselineFunc           func(ctx context.Context, userID int64) (*model.UserBaseline, error)
+	SaveUserBaselineFunc          func(ctx context.Context, baseline *model.UserBaseline) error
+	GetAccountsFunc               func(ctx context.Context, userID int64) ([]model.Account, error)
+	CreateAccountFunc             func(ctx context.Context, account *model.Account) error
+	DeleteAccountFunc             func(ctx context.Context, id string, userID int64) error
+	GetLedgerMemberFunc           func(ctx context.Context, memberID int64) (*model.LedgerMember, error)
+	GetLedgerMembersFunc          func(ctx context.Context, ownerID int64) ([]model.LedgerMember, error)
+	SaveLedgerMemberFunc          func(ctx context.Context, member *model.LedgerMember) error
+	DeleteLedgerMemberFunc        func(ctx context.Context, memberID int64) error
+	CreateLedgerInviteFunc        func(ctx context.Context, invite *model.LedgerInvite) error
+	GetLedgerInviteFunc           func(ctx context.Context, code string) (*model.LedgerInvite, error)
+	DeleteLedgerInviteFunc        func(ctx context.Context, code string) error
+	GetLedgersFunc                func(ctx context.Context, userID int64) ([]model.Ledger, error)
+	CreateLedgerFunc              func(ctx context.Context, ledger *model.Ledger) error
+	UpdateLedgerFunc              func(ctx context.Context, ledger *model.Ledger) error
+	DeleteLedgerFunc              func(ctx context.Context, id string, userID int64) error
+	GetEventsFunc                 func(ctx context.Context, userID int64) ([]model.Event, error)
+	CreateEventFunc               func(ctx context.Context, event *model.Event) error
+	UpdateEventFunc               func(ctx context.Context, event *model.Event) error
+	DeleteEventFunc               func(ctx context.Context, id string, userID int64) error
+	GetEventFunc                  func(ctx context.Context, id string) (*model.Event, error)
+	GetEventByInviteFunc          func(ctx context.Context, code string) (*model.Event, error)
+	GetEventMembersFunc           func(ctx context.Context, eventID string) ([]model.EventMember, error)
+	GetEventMembershipsFunc       func(ctx context.Context, userID int64) ([]model.EventMember, error)
+	SaveEventMemberFunc           func(ctx context.Context, member *model.EventMember) error
+	GetEventPaymentsFunc          func(ctx context.Context, eventID string) ([]model.EventPayment, error)
+	CreateEventPaymentFunc        func(ctx context.Context, payment *model.EventPayment) error
+	DeleteEventPaymentFunc        func(ctx context.Context, id string, userID int64) error
+	GetExchangeRatesFunc          func(ctx context.Context, base, date string) ([]model.ExchangeRate, error)
+	SaveExchangeRatesFunc         func(ctx context.Context, rates []model.ExchangeRate) error
+	GetUserSettingsFunc           func(ctx context.Context, userID int64) (*model.UserSettings, error)
+	SaveUserSettingsFunc          func(ctx context.Context, settings *model.UserSettings) error
+	GetAssetsFunc                 func(ctx context.Context, userID int64) ([]model.Asset, error)
+	CreateAssetFunc               func(ctx context.Context, asset *model.Asset) error
+	DeleteAssetFunc               func(ctx context.Context, id string, userID int64) error
+	GetHoldingsFunc               func(ctx context.Context, userID int64) ([]model.Holding, error)
+	SaveHoldingFunc               func(ctx context.Context, holding *model.Holding) error
+	UpdateHoldingPriceFunc        func(ctx context.Context, holding *model.Holding) error
+	DeleteHoldingFunc             func(ctx context.Context, id string, userID int64) error
+	GetNetWorthSnapshotsFunc      func(ctx context.Context, userID int64, limit int) ([]model.NetWorthSnapshot, error)
+	SaveNetWorthSnapshotFunc      func(ctx context.Context, snapshot *model.NetWorthSnapshot) error
+	GetWebhooksFunc               func(ctx context.Context, userID int64) ([]model.Webhook, error)
+	CreateWebhookFunc             func(ctx context.Context, webhook *model.Webhook) error
+	DeleteWebhookFunc             func(ctx context.Context, id string, userID int64) error
+	GetRemindersFunc              func(ctx context.Context, userID int64) ([]model.Reminder, error)
+	GetAllRemindersFunc           func(ctx context.Context) ([]model.Reminder, error)
+	CreateReminderFunc            func(ctx context.Context, reminder *model.Reminder) error
+	DeleteReminderFunc            func(ctx context.Context, id string, userID int64) error
+	MarkReminderSentFunc          func(ctx context.Context, id string, sentAt time.Time) error
+	CreateBroadcastFunc           func(ctx context.Context, broadcast *model.Broadcast) error
+	GetBroadcastFunc              func(ctx context.Context, id string) (*model.Broadcast, error)
+	UpdateBroadcastFunc           func(ctx context.Context, broadcast *model.Broadcast) error
+	GetQueuedBroadcastsFunc       func(ctx context.Context) ([]model.Broadcast, error)
+	GetBroadcastDeliveriesFunc    func(ctx context.Context, broadcastID string) ([]model.BroadcastDelivery, error)
+	SaveBroadcastDeliveryFunc     func(ctx context.Context, delivery *model.BroadcastDelivery) error
+	GetReportDataFunc             func(ctx context.Context, userID int64, current, previous model.TransactionFilter) (*model.ReportData, error)
+	MarkUpdateProcessedFunc       func(ctx context.Context, update *model.ProcessedUpdate) (bool, error)
+	DeleteProcessedUpdateFunc     func(ctx context.Context, updateID int64) error
+	DeleteProcessedUpdatesFunc    func(ctx context.Context, before time.Time) error
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ service.Repository = (*Repository)(nil)
+
+// Calls возвращает записанные вызовы по порядку
+func (m *Repository) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallsOf возвращает вызовы одного метода
+func (m *Repository) CallsOf(method string) []Call {
+	var calls []Call
+	for _, call := range m.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset забывает записанные вызовы
+func (m *Repository) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+}
+
+func (m *Repository) record(method string, args ...any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+}
+
+func (m *Repository) GetTransactions(ctx context.Context, userID int64, filter model.TransactionFilter) ([]model.Transaction, error) {
+	m.record("GetTransactions", userID, filter)
+	if m.GetTransactionsFunc != nil {
+		return m.GetTransactionsFunc(ctx, userID, filter)
+	}
+	return nil, nil
+}
+
+func (m *Repository) GetCategories(ctx context.Context, userID int64) ([]model.Category, error) {
+	m.record("GetCategories", userID)
+	if m.GetCategoriesFunc != nil {
+		return m.GetCategoriesFunc(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) GetTransactionsByCategory(ctx context.Context, userID int64, categoryID string) ([]model.Transaction, error) {
+	m.record("GetTransactionsByCategory", userID, categoryID)
+	if m.GetTransactionsByCategoryFunc != nil {
+		return m.GetTransactionsByCategoryFunc(ctx, userID, categoryID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) CreateTransaction(ctx context.Context, transaction *model.Transaction) error {
+	m.record("CreateTransaction", transaction)
+	if m.CreateTransactionFunc != nil {
+		return m.CreateTransactionFunc(ctx, transaction)
+	}
+	return nil
+}
+
+func (m *Repository) CreateTransactions(ctx context.Context, transactions []*model.Transaction) error {
+	m.record("CreateTransactions", transactions)
+	if m.CreateTransactionsFunc != nil {
+		return m.CreateTransactionsFunc(ctx, transactions)
+	}
+	return nil
+}
+
+func (m *Repository) DeleteTransaction(ctx context.Context, transactionID string, userID int64) error {
+	m.record("DeleteTransaction", transactionID, userID)
+	if m.DeleteTransactionFunc != nil {
+		return m.DeleteTransactionFunc(ctx, transactionID, userID)
+	}
+	return nil
+}
+
+func (m *Repository) UpdateTransactionCategory(ctx context.Context, transactionID string, userID int64, categoryID string) error {
+	m.record("UpdateTransactionCategory", transactionID, userID, categoryID)
+	if m.UpdateTransactionCategoryFunc != nil {
+		return m.UpdateTransactionCategoryFunc(ctx, transactionID, userID, categoryID)
+	}
+	return nil
+}
+
+func (m *Repository) GetTransaction(ctx context.Context, transactionID string, userID int64) (*model.Transaction, error) {
+	m.record("GetTransaction", transactionID, userID)
+	if m.GetTransactionFunc != nil {
+		return m.GetTransactionFunc(ctx, transactionID, userID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) UpdateTransactionDetails(ctx context.Context, transactionID string, userID int64, note, attachmentPath string) error {
+	m.record("UpdateTransactionDetails", transactionID, userID, note, attachmentPath)
+	if m.UpdateTransactionDetailsFunc != nil {
+		return m.UpdateTransactionDetailsFunc(ctx, transactionID, userID, note, attachmentPath)
+	}
+	return nil
+}
+
+func (m *Repository) UpdateTransactionBusiness(ctx context.Context, transactionID string, userID int64, counterparty, invoiceNumber string, vatAmount float64) error {
+	m.record("UpdateTransactionBusiness", transactionID, userID, counterparty, invoiceNumber, vatAmount)
+	if m.UpdateTransactionBusinessFunc != nil {
+		return m.UpdateTransactionBusinessFunc(ctx, transactionID, userID, counterparty, invoiceNumber, vatAmount)
+	}
+	return nil
+}
+
+func (m *Repository) UpdateTransactionEvent(ctx context.Context, transactionID string, userID int64, eventID string) error {
+	m.record("UpdateTransactionEvent", transactionID, userID, eventID)
+	if m.UpdateTransactionEventFunc != nil {
+		return m.UpdateTransactionEventFunc(ctx, transactionID, userID, eventID)
+	}
+	return nil
+}
+
+func (m *Repository) GetDeletedTransactions(ctx context.Context, userID int64, since time.Time) ([]model.Transaction, error) {
+	m.record("GetDeletedTransactions", userID, since)
+	if m.GetDeletedTransactionsFunc != nil {
+		return m.GetDeletedTransactionsFunc(ctx, userID, since)
+	}
+	return nil, nil
+}
+
+func (m *Repository) GetDeletedCategories(ctx context.Context, userID int64, since time.Time) ([]model.Category, error) {
+	m.record("GetDeletedCategories", userID, since)
+	if m.GetDeletedCategoriesFunc != nil {
+		return m.GetDeletedCategoriesFunc(ctx, userID, since)
+	}
+	return nil, nil
+}
+
+func (m *Repository) RestoreTransaction(ctx context.Context, transactionID string, userID int64) error {
+	m.record("RestoreTransaction", transactionID, userID)
+	if m.RestoreTransactionFunc != nil {
+		return m.RestoreTransactionFunc(ctx, transactionID, userID)
+	}
+	return nil
+}
+
+func (m *Repository) RestoreCategory(ctx context.Context, category *model.Category) error {
+	m.record("RestoreCategory", category)
+	if m.RestoreCategoryFunc != nil {
+		return m.RestoreCategoryFunc(ctx, category)
+	}
+	return nil
+}
+
+func (m *Repository) PurgeDeleted(ctx context.Context, before time.Time) ([]string, error) {
+	m.record("PurgeDeleted", before)
+	if m.PurgeDeletedFunc != nil {
+		return m.PurgeDeletedFunc(ctx, before)
+	}
+	return nil, nil
+}
+
+func (m *Repository) CreateCategory(ctx context.Context, category *model.Category) error {
+	m.record("CreateCategory", category)
+	if m.CreateCategoryFunc != nil {
+		return m.CreateCategoryFunc(ctx, category)
+	}
+	return nil
+}
+
+func (m *Repository) UpdateCategory(ctx context.Context, category *model.Category) error {
+	m.record("UpdateCategory", category)
+	if m.UpdateCategoryFunc != nil {
+		return m.UpdateCategoryFunc(ctx, category)
+	}
+	return nil
+}
+
+func (m *Repository) DeleteCategory(ctx context.Context, categoryID string, userID int64) error {
+	m.record("DeleteCategory", categoryID, userID)
+	if m.DeleteCategoryFunc != nil {
+		return m.DeleteCategoryFunc(ctx, categoryID, userID)
+	}
+	return nil
+}
+
+func (m *Repository) GetUserState(ctx context.Context, userID int64) (*model.UserState, error) {
+	m.record("GetUserState", userID)
+	if m.GetUserStateFunc != nil {
+		return m.GetUserStateFunc(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) SaveUserState(ctx context.Context, state *model.UserState) error {
+	m.record("SaveUserState", state)
+	if m.SaveUserStateFunc != nil {
+		return m.SaveUserStateFunc(ctx, state)
+	}
+	return nil
+}
+
+func (m *Repository) DeleteUserState(ctx context.Context, userID int64) error {
+	m.record("DeleteUserState", userID)
+	if m.DeleteUserStateFunc != nil {
+		return m.DeleteUserStateFunc(ctx, userID)
+	}
+	return nil
+}
+
+func (m *Repository) DeleteUserStatesBefore(ctx context.Context, before time.Time) error {
+	m.record("DeleteUserStatesBefore", before)
+	if m.DeleteUserStatesBeforeFunc != nil {
+		return m.DeleteUserStatesBeforeFunc(ctx, before)
+	}
+	return nil
+}
+
+func (m *Repository) SaveUser(ctx context.Context, user *model.User) error {
+	m.record("SaveUser", user)
+	if m.SaveUserFunc != nil {
+		return m.SaveUserFunc(ctx, user)
+	}
+	return nil
+}
+
+func (m *Repository) GetUsers(ctx context.Context) ([]model.User, error) {
+	m.record("GetUsers")
+	if m.GetUsersFunc != nil {
+		return m.GetUsersFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *Repository) GetUser(ctx context.Context, userID int64) (*model.User, error) {
+	m.record("GetUser", userID)
+	if m.GetUserFunc != nil {
+		return m.GetUserFunc(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) SetMenuSession(ctx context.Context, userID int64, session int) error {
+	m.record("SetMenuSession", userID, session)
+	if m.SetMenuSessionFunc != nil {
+		return m.SetMenuSessionFunc(ctx, userID, session)
+	}
+	return nil
+}
+
+func (m *Repository) DeleteUserData(ctx context.Context, userID int64) error {
+	m.record("DeleteUserData", userID)
+	if m.DeleteUserDataFunc != nil {
+		return m.DeleteUserDataFunc(ctx, userID)
+	}
+	return nil
+}
+
+func (m *Repository) GetBudgets(ctx context.Context, userID int64) ([]model.Budget, error) {
+	m.record("GetBudgets", userID)
+	if m.GetBudgetsFunc != nil {
+		return m.GetBudgetsFunc(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) SaveBudget(ctx context.Context, budget *model.Budget) error {
+	m.record("SaveBudget", budget)
+	if m.SaveBudgetFunc != nil {
+		return m.SaveBudgetFunc(ctx, budget)
+	}
+	return nil
+}
+
+func (m *Repository) GetPlannedExpenses(ctx context.Context, userID int64, since time.Time) ([]model.PlannedExpense, error) {
+	m.record("GetPlannedExpenses", userID, since)
+	if m.GetPlannedExpensesFunc != nil {
+		return m.GetPlannedExpensesFunc(ctx, userID, since)
+	}
+	return nil, nil
+}
+
+func (m *Repository) CreatePlannedExpense(ctx context.Context, plan *model.PlannedExpense) error {
+	m.record("CreatePlannedExpense", plan)
+	if m.CreatePlannedExpenseFunc != nil {
+		return m.CreatePlannedExpenseFunc(ctx, plan)
+	}
+	return nil
+}
+
+func (m *Repository) DeletePlannedExpense(ctx context.Context, id string, userID int64) error {
+	m.record("DeletePlannedExpense", id, userID)
+	if m.DeletePlannedExpenseFunc != nil {
+		return m.DeletePlannedExpenseFunc(ctx, id, userID)
+	}
+	return nil
+}
+
+func (m *Repository) GetWishlist(ctx context.Context, userID int64) ([]model.WishlistItem, error) {
+	m.record("GetWishlist", userID)
+	if m.GetWishlistFunc != nil {
+		return m.GetWishlistFunc(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) CreateWishlistItem(ctx context.Context, item *model.WishlistItem) error {
+	m.record("CreateWishlistItem", item)
+	if m.CreateWishlistItemFunc != nil {
+		return m.CreateWishlistItemFunc(ctx, item)
+	}
+	return nil
+}
+
+func (m *Repository) DeleteWishlistItem(ctx context.Context, id string, userID int64) error {
+	m.record("DeleteWishlistItem", id, userID)
+	if m.DeleteWishlistItemFunc != nil {
+		return m.DeleteWishlistItemFunc(ctx, id, userID)
+	}
+	return nil
+}
+
+func (m *Repository) GetWishlistAllocations(ctx context.Context, userID int64) ([]model.WishlistAllocation, error) {
+	m.record("GetWishlistAllocations", userID)
+	if m.GetWishlistAllocationsFunc != nil {
+		return m.GetWishlistAllocationsFunc(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) CreateWishlistAllocation(ctx context.Context, allocation *model.WishlistAllocation) error {
+	m.record("CreateWishlistAllocation", allocation)
+	if m.CreateWishlistAllocationFunc != nil {
+		return m.CreateWishlistAllocationFunc(ctx, allocation)
+	}
+	return nil
+}
+
+func (m *Repository) GetGoals(ctx context.Context, userID int64) ([]model.Goal, error) {
+	m.record("GetGoals", userID)
+	if m.GetGoalsFunc != nil {
+		return m.GetGoalsFunc(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) CreateGoal(ctx context.Context, goal *model.Goal) error {
+	m.record("CreateGoal", goal)
+	if m.CreateGoalFunc != nil {
+		return m.CreateGoalFunc(ctx, goal)
+	}
+	return nil
+}
+
+func (m *Repository) UpdateGoalSaved(ctx context.Context, id string, userID int64, saved float64) error {
+	m.record("UpdateGoalSaved", id, userID, saved)
+	if m.UpdateGoalSavedFunc != nil {
+		return m.UpdateGoalSavedFunc(ctx, id, userID, saved)
+	}
+	return nil
+}
+
+func (m *Repository) GetUserBaseline(ctx context.Context, userID int64) (*model.UserBaseline, error) {
+	m.record("GetUserBaseline", userID)
+	if m.GetUserBaselineFunc != nil {
+		return m.GetUserBaselineFunc(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) SaveUserBaseline(ctx context.Context, baseline *model.UserBaseline) error {
+	m.record("SaveUserBaseline", baseline)
+	if m.SaveUserBaselineFunc != nil {
+		return m.SaveUserBaselineFunc(ctx, baseline)
+	}
+	return nil
+}
+
+func (m *Repository) GetAccounts(ctx context.Context, userID int64) ([]model.Account, error) {
+	m.record("GetAccounts", userID)
+	if m.GetAccountsFunc != nil {
+		return m.GetAccountsFunc(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) CreateAccount(ctx context.Context, account *model.Account) error {
+	m.record("CreateAccount", account)
+	if m.CreateAccountFunc != nil {
+		return m.CreateAccountFunc(ctx, account)
+	}
+	return nil
+}
+
+func (m *Repository) DeleteAccount(ctx context.Context, id string, userID int64) error {
+	m.record("DeleteAccount", id, userID)
+	if m.DeleteAccountFunc != nil {
+		return m.DeleteAccountFunc(ctx, id, userID)
+	}
+	return nil
+}
+
+func (m *Repository) GetLedgerMember(ctx context.Context, memberID int64) (*model.LedgerMember, error) {
+	m.record("GetLedgerMember", memberID)
+	if m.GetLedgerMemberFunc != nil {
+		return m.GetLedgerMemberFunc(ctx, memberID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) GetLedgerMembers(ctx context.Context, ownerID int64) ([]model.LedgerMember, error) {
+	m.record("GetLedgerMembers", ownerID)
+	if m.GetLedgerMembersFunc != nil {
+		return m.GetLedgerMembersFunc(ctx, ownerID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) SaveLedgerMember(ctx context.Context, member *model.LedgerMember) error {
+	m.record("SaveLedgerMember", member)
+	if m.SaveLedgerMemberFunc != nil {
+		return m.SaveLedgerMemberFunc(ctx, member)
+	}
+	return nil
+}
+
+func (m *Repository) DeleteLedgerMember(ctx context.Context, memberID int64) error {
+	m.record("DeleteLedgerMember", memberID)
+	if m.DeleteLedgerMemberFunc != nil {
+		return m.DeleteLedgerMemberFunc(ctx, memberID)
+	}
+	return nil
+}
+
+func (m *Repository) CreateLedgerInvite(ctx context.Context, invite *model.LedgerInvite) error {
+	m.record("CreateLedgerInvite", invite)
+	if m.CreateLedgerInviteFunc != nil {
+		return m.CreateLedgerInviteFunc(ctx, invite)
+	}
+	return nil
+}
+
+func (m *Repository) GetLedgerInvite(ctx context.Context, code string) (*model.LedgerInvite, error) {
+	m.record("GetLedgerInvite", code)
+	if m.GetLedgerInviteFunc != nil {
+		return m.GetLedgerInviteFunc(ctx, code)
+	}
+	return nil, nil
+}
+
+func (m *Repository) DeleteLedgerInvite(ctx context.Context, code string) error {
+	m.record("DeleteLedgerInvite", code)
+	if m.DeleteLedgerInviteFunc != nil {
+		return m.DeleteLedgerInviteFunc(ctx, code)
+	}
+	return nil
+}
+
+func (m *Repository) GetLedgers(ctx context.Context, userID int64) ([]model.Ledger, error) {
+	m.record("GetLedgers", userID)
+	if m.GetLedgersFunc != nil {
+		return m.GetLedgersFunc(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) CreateLedger(ctx context.Context, ledger *model.Ledger) error {
+	m.record("CreateLedger", ledger)
+	if m.CreateLedgerFunc != nil {
+		return m.CreateLedgerFunc(ctx, ledger)
+	}
+	return nil
+}
+
+func (m *Repository) UpdateLedger(ctx context.Context, ledger *model.Ledger) error {
+	m.record("UpdateLedger", ledger)
+	if m.UpdateLedgerFunc != nil {
+		return m.UpdateLedgerFunc(ctx, ledger)
+	}
+	return nil
+}
+
+func (m *Repository) DeleteLedger(ctx context.Context, id string, userID int64) error {
+	m.record("DeleteLedger", id, userID)
+	if m.DeleteLedgerFunc != nil {
+		return m.DeleteLedgerFunc(ctx, id, userID)
+	}
+	return nil
+}
+
+func (m *Repository) GetEvents(ctx context.Context, userID int64) ([]model.Event, error) {
+	m.record("GetEvents", userID)
+	if m.GetEventsFunc != nil {
+		return m.GetEventsFunc(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) CreateEvent(ctx context.Context, event *model.Event) error {
+	m.record("CreateEvent", event)
+	if m.CreateEventFunc != nil {
+		return m.CreateEventFunc(ctx, event)
+	}
+	return nil
+}
+
+func (m *Repository) UpdateEvent(ctx context.Context, event *model.Event) error {
+	m.record("UpdateEvent", event)
+	if m.UpdateEventFunc != nil {
+		return m.UpdateEventFunc(ctx, event)
+	}
+	return nil
+}
+
+func (m *Repository) DeleteEvent(ctx context.Context, id string, userID int64) error {
+	m.record("DeleteEvent", id, userID)
+	if m.DeleteEventFunc != nil {
+		return m.DeleteEventFunc(ctx, id, userID)
+	}
+	return nil
+}
+
+func (m *Repository) GetEvent(ctx context.Context, id string) (*model.Event, error) {
+	m.record("GetEvent", id)
+	if m.GetEventFunc != nil {
+		return m.GetEventFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *Repository) GetEventByInvite(ctx context.Context, code string) (*model.Event, error) {
+	m.record("GetEventByInvite", code)
+	if m.GetEventByInviteFunc != nil {
+		return m.GetEventByInviteFunc(ctx, code)
+	}
+	return nil, nil
+}
+
+func (m *Repository) GetEventMembers(ctx context.Context, eventID string) ([]model.EventMember, error) {
+	m.record("GetEventMembers", eventID)
+	if m.GetEventMembersFunc != nil {
+		return m.GetEventMembersFunc(ctx, eventID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) GetEventMemberships(ctx context.Context, userID int64) ([]model.EventMember, error) {
+	m.record("GetEventMemberships", userID)
+	if m.GetEventMembershipsFunc != nil {
+		return m.GetEventMembershipsFunc(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) SaveEventMember(ctx context.Context, member *model.EventMember) error {
+	m.record("SaveEventMember", member)
+	if m.SaveEventMemberFunc != nil {
+		return m.SaveEventMemberFunc(ctx, member)
+	}
+	return nil
+}
+
+func (m *Repository) GetEventPayments(ctx context.Context, eventID string) ([]model.EventPayment, error) {
+	m.record("GetEventPayments", eventID)
+	if m.GetEventPaymentsFunc != nil {
+		return m.GetEventPaymentsFunc(ctx, eventID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) CreateEventPayment(ctx context.Context, payment *model.EventPayment) error {
+	m.record("CreateEventPayment", payment)
+	if m.CreateEventPaymentFunc != nil {
+		return m.CreateEventPaymentFunc(ctx, payment)
+	}
+	return nil
+}
+
+func (m *Repository) DeleteEventPayment(ctx context.Context, id string, userID int64) error {
+	m.record("DeleteEventPayment", id, userID)
+	if m.DeleteEventPaymentFunc != nil {
+		return m.DeleteEventPaymentFunc(ctx, id, userID)
+	}
+	return nil
+}
+
+func (m *Repository) GetExchangeRates(ctx context.Context, base, date string) ([]model.ExchangeRate, error) {
+	m.record("GetExchangeRates", base, date)
+	if m.GetExchangeRatesFunc != nil {
+		return m.GetExchangeRatesFunc(ctx, base, date)
+	}
+	return nil, nil
+}
+
+func (m *Repository) SaveExchangeRates(ctx context.Context, rates []model.ExchangeRate) error {
+	m.record("SaveExchangeRates", rates)
+	if m.SaveExchangeRatesFunc != nil {
+		return m.SaveExchangeRatesFunc(ctx, rates)
+	}
+	return nil
+}
+
+func (m *Repository) GetUserSettings(ctx context.Context, userID int64) (*model.UserSettings, error) {
+	m.record("GetUserSettings", userID)
+	if m.GetUserSettingsFunc != nil {
+		return m.GetUserSettingsFunc(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) SaveUserSettings(ctx context.Context, settings *model.UserSettings) error {
+	m.record("SaveUserSettings", settings)
+	if m.SaveUserSettingsFunc != nil {
+		return m.SaveUserSettingsFunc(ctx, settings)
+	}
+	return nil
+}
+
+func (m *Repository) GetAssets(ctx context.Context, userID int64) ([]model.Asset, error) {
+	m.record("GetAssets", userID)
+	if m.GetAssetsFunc != nil {
+		return m.GetAssetsFunc(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) CreateAsset(ctx context.Context, asset *model.Asset) error {
+	m.record("CreateAsset", asset)
+	if m.CreateAssetFunc != nil {
+		return m.CreateAssetFunc(ctx, asset)
+	}
+	return nil
+}
+
+func (m *Repository) DeleteAsset(ctx context.Context, id string, userID int64) error {
+	m.record("DeleteAsset", id, userID)
+	if m.DeleteAssetFunc != nil {
+		return m.DeleteAssetFunc(ctx, id, userID)
+	}
+	return nil
+}
+
+func (m *Repository) GetHoldings(ctx context.Context, userID int64) ([]model.Holding, error) {
+	m.record("GetHoldings", userID)
+	if m.GetHoldingsFunc != nil {
+		return m.GetHoldingsFunc(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) SaveHolding(ctx context.Context, holding *model.Holding) error {
+	m.record("SaveHolding", holding)
+	if m.SaveHoldingFunc != nil {
+		return m.SaveHoldingFunc(ctx, holding)
+	}
+	return nil
+}
+
+func (m *Repository) UpdateHoldingPrice(ctx context.Context, holding *model.Holding) error {
+	m.record("UpdateHoldingPrice", holding)
+	if m.UpdateHoldingPriceFunc != nil {
+		return m.UpdateHoldingPriceFunc(ctx, holding)
+	}
+	return nil
+}
+
+func (m *Repository) DeleteHolding(ctx context.Context, id string, userID int64) error {
+	m.record("DeleteHolding", id, userID)
+	if m.DeleteHoldingFunc != nil {
+		return m.DeleteHoldingFunc(ctx, id, userID)
+	}
+	return nil
+}
+
+func (m *Repository) GetNetWorthSnapshots(ctx context.Context, userID int64, limit int) ([]model.NetWorthSnapshot, error) {
+	m.record("GetNetWorthSnapshots", userID, limit)
+	if m.GetNetWorthSnapshotsFunc != nil {
+		return m.GetNetWorthSnapshotsFunc(ctx, userID, limit)
+	}
+	return nil, nil
+}
+
+func (m *Repository) SaveNetWorthSnapshot(ctx context.Context, snapshot *model.NetWorthSnapshot) error {
+	m.record("SaveNetWorthSnapshot", snapshot)
+	if m.SaveNetWorthSnapshotFunc != nil {
+		return m.SaveNetWorthSnapshotFunc(ctx, snapshot)
+	}
+	return nil
+}
+
+func (m *Repository) GetWebhooks(ctx context.Context, userID int64) ([]model.Webhook, error) {
+	m.record("GetWebhooks", userID)
+	if m.GetWebhooksFunc != nil {
+		return m.GetWebhooksFunc(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	m.record("CreateWebhook", webhook)
+	if m.CreateWebhookFunc != nil {
+		return m.CreateWebhookFunc(ctx, webhook)
+	}
+	return nil
+}
+
+func (m *Repository) DeleteWebhook(ctx context.Context, id string, userID int64) error {
+	m.record("DeleteWebhook", id, userID)
+	if m.DeleteWebhookFunc != nil {
+		return m.DeleteWebhookFunc(ctx, id, userID)
+	}
+	return nil
+}
+
+func (m *Repository) GetReminders(ctx context.Context, userID int64) ([]model.Reminder, error) {
+	m.record("GetReminders", userID)
+	if m.GetRemindersFunc != nil {
+		return m.GetRemindersFunc(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) GetAllReminders(ctx context.Context) ([]model.Reminder, error) {
+	m.record("GetAllReminders")
+	if m.GetAllRemindersFunc != nil {
+		return m.GetAllRemindersFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *Repository) CreateReminder(ctx context.Context, reminder *model.Reminder) error {
+	m.record("CreateReminder", reminder)
+	if m.CreateReminderFunc != nil {
+		return m.CreateReminderFunc(ctx, reminder)
+	}
+	return nil
+}
+
+func (m *Repository) DeleteReminder(ctx context.Context, id string, userID int64) error {
+	m.record("DeleteReminder", id, userID)
+	if m.DeleteReminderFunc != nil {
+		return m.DeleteReminderFunc(ctx, id, userID)
+	}
+	return nil
+}
+
+func (m *Repository) MarkReminderSent(ctx context.Context, id string, sentAt time.Time) error {
+	m.record("MarkReminderSent", id, sentAt)
+	if m.MarkReminderSentFunc != nil {
+		return m.MarkReminderSentFunc(ctx, id, sentAt)
+	}
+	return nil
+}
+
+func (m *Repository) CreateBroadcast(ctx context.Context, broadcast *model.Broadcast) error {
+	m.record("CreateBroadcast", broadcast)
+	if m.CreateBroadcastFunc != nil {
+		return m.CreateBroadcastFunc(ctx, broadcast)
+	}
+	return nil
+}
+
+func (m *Repository) GetBroadcast(ctx context.Context, id string) (*model.Broadcast, error) {
+	m.record("GetBroadcast", id)
+	if m.GetBroadcastFunc != nil {
+		return m.GetBroadcastFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *Repository) UpdateBroadcast(ctx context.Context, broadcast *model.Broadcast) error {
+	m.record("UpdateBroadcast", broadcast)
+	if m.UpdateBroadcastFunc != nil {
+		return m.UpdateBroadcastFunc(ctx, broadcast)
+	}
+	return nil
+}
+
+func (m *Repository) GetQueuedBroadcasts(ctx context.Context) ([]model.Broadcast, error) {
+	m.record("GetQueuedBroadcasts")
+	if m.GetQueuedBroadcastsFunc != nil {
+		return m.GetQueuedBroadcastsFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *Repository) GetBroadcastDeliveries(ctx context.Context, broadcastID string) ([]model.BroadcastDelivery, error) {
+	m.record("GetBroadcastDeliveries", broadcastID)
+	if m.GetBroadcastDeliveriesFunc != nil {
+		return m.GetBroadcastDeliveriesFunc(ctx, broadcastID)
+	}
+	return nil, nil
+}
+
+func (m *Repository) SaveBroadcastDelivery(ctx context.Context, delivery *model.BroadcastDelivery) error {
+	m.record("SaveBroadcastDelivery", delivery)
+	if m.SaveBroadcastDeliveryFunc != nil {
+		return m.SaveBroadcastDeliveryFunc(ctx, delivery)
+	}
+	return nil
+}
+
+// GetReportData по умолчанию возвращает пустые данные отчета
+func (m *Repository) GetReportData(ctx context.Context, userID int64, current, previous model.TransactionFilter) (*model.ReportData, error) {
+	m.record("GetReportData", userID, current, previous)
+	if m.GetReportDataFunc != nil {
+		return m.GetReportDataFunc(ctx, userID, current, previous)
+	}
+	return &model.ReportData{}, nil
+}
+
+// MarkUpdateProcessed по умолчанию считает каждое обновление новым
+func (m *Repository) MarkUpdateProcessed(ctx context.Context, update *model.ProcessedUpdate) (bool, error) {
+	m.record("MarkUpdateProcessed", update)
+	if m.MarkUpdateProcessedFunc != nil {
+		return m.MarkUpdateProcessedFunc(ctx, update)
+	}
+	return true, nil
+}
+
+func (m *Repository) DeleteProcessedUpdate(ctx context.Context, updateID int64) error {
+	m.record("DeleteProcessedUpdate", updateID)
+	if m.DeleteProcessedUpdateFunc != nil {
+		return m.DeleteProcessedUpdateFunc(ctx, updateID)
+	}
+	return nil
+}
+
+func (m *Repository) DeleteProcessedUpdates(ctx context.Context, before time.Time) error {
+	m.record("DeleteProcessedUpdates", before)
+	if m.DeleteProcessedUpdatesFunc != nil {
+		return m.DeleteProcessedUpdatesFunc(ctx, before)
+	}
+	return nil
+}
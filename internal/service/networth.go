@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// NetWorthSummary - текущий капитал с разбивкой по счетам, активам, инвестициям и обязательствам
+type NetWorthSummary struct {
+	Snapshot model.NetWorthSnapshot
+	Balances []model.AccountBalance
+	Assets   []model.Asset
+	Holdings []HoldingValue // крупные первыми
+}
+
+// GetAssets возвращает активы и обязательства пользователя
+func (s *ExpenseTracker) GetAssets(ctx context.Context, userID int64) ([]model.Asset, error) {
+	return s.repo.GetAssets(ctx, userID)
+}
+
+// CreateAsset добавляет актив или обязательство
+func (s *ExpenseTracker) CreateAsset(ctx context.Context, userID int64, name, kind string, amount float64) error {
+	if kind != model.AssetKindAsset && kind != model.AssetKindLiability {
+		return fmt.Errorf("unknown asset kind: %s", kind)
+	}
+	if amount < 0 {
+		return fmt.Errorf("%w: amount must not be negative", model.ErrValidation)
+	}
+	if err := s.repo.CreateAsset(ctx, &model.Asset{
+		UserID:    userID,
+		Name:      name,
+		Kind:      kind,
+		Amount:    amount,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return err
+	}
+	markWrote(ctx)
+	return nil
+}
+
+// DeleteAsset удаляет актив или обязательство
+func (s *ExpenseTracker) DeleteAsset(ctx context.Context, assetID string, userID int64) error {
+	return s.repo.DeleteAsset(ctx, assetID, userID)
+}
+
+// TakeNetWorthSnapshot рассчитывает текущий капитал и сохраняет его как снимок текущего месяца
+func (s *ExpenseTracker) TakeNetWorthSnapshot(ctx context.Context, userID int64) (*NetWorthSummary, error) {
+	balances, err := s.GetAccountBalances(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	assets, err := s.repo.GetAssets(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assets: %w", err)
+	}
+	holdings, err := s.repo.GetHoldings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holdings: %w", err)
+	}
+	values := s.valueHoldings(ctx, holdings)
+
+	now := time.Now()
+	snapshot := model.NetWorthSnapshot{
+		UserID:    userID,
+		Month:     time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).Format("2006-01-02"),
+		CreatedAt: now,
+	}
+	for _, b := range balances {
+		snapshot.Accounts += b.Balance
+	}
+	for _, a := range assets {
+		if a.Kind == model.AssetKindLiability {
+			snapshot.Liabilities += a.Amount
+		} else {
+			snapshot.Assets += a.Amount
+		}
+	}
+	for _, h := range values {
+		snapshot.Holdings += h.Value
+	}
+	snapshot.NetWorth = snapshot.Accounts + snapshot.Assets + snapshot.Holdings - snapshot.Liabilities
+
+	if err := s.repo.SaveNetWorthSnapshot(ctx, &snapshot); err != nil {
+		return nil, err
+	}
+
+	return &NetWorthSummary{
+		Snapshot: snapshot,
+		Balances: balances,
+		Assets:   assets,
+		Holdings: values,
+	}, nil
+}
+
+// GetNetWorthHistory возвращает снимки капитала за последние месяцы в хронологическом порядке
+func (s *ExpenseTracker) GetNetWorthHistory(ctx context.Context, userID int64, months int) ([]model.NetWorthSnapshot, error) {
+	snapshots, err := s.repo.GetNetWorthSnapshots(ctx, userID, months)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Month < snapshots[j].Month
+	})
+	return snapshots, nil
+}
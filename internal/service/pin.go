@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// minPINLength - минимальная длина PIN или кодовой фразы в символах
+	minPINLength = 4
+	// maxPINBytes - bcrypt учитывает только первые 72 байта
+	maxPINBytes = 72
+	// maxPINAttempts - неверных попыток подряд до временной блокировки ввода
+	maxPINAttempts = 5
+	// pinLockout - на сколько блокируется ввод после maxPINAttempts ошибок
+	pinLockout = 15 * time.Minute
+)
+
+// PINIdleOptions - варианты времени бездействия до запроса PIN в минутах
+var PINIdleOptions = []int{5, 15, 60, 240}
+
+var (
+	// ErrInvalidPIN - PIN слишком короткий или длинный
+	ErrInvalidPIN = fmt.Errorf("%w: pin must be 4 to 72 bytes long", model.ErrValidation)
+	// ErrWrongPIN - введен неверный PIN
+	ErrWrongPIN = errors.New("wrong pin")
+	// ErrPINBlocked - ввод PIN временно заблокирован после неверных попыток
+	ErrPINBlocked = fmt.Errorf("%w: too many wrong pin attempts", model.ErrRateLimited)
+	// ErrPINNotSet - блокировка не включена
+	ErrPINNotSet = errors.New("pin is not set")
+)
+
+// HashPIN проверяет длину PIN и возвращает его хэш. Хэш хранится в состоянии пользователя
+// до повторного ввода, чтобы PIN с опечаткой не заблокировал доступ к данным
+func HashPIN(pin string) (string, error) {
+	if utf8.RuneCountInString(pin) < minPINLength || len(pin) > maxPINBytes {
+		return "", ErrInvalidPIN
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash pin: %w", err)
+	}
+	return string(hash), nil
+}
+
+// SetPIN включает блокировку или меняет PIN, если повторный ввод совпал с хэшем из HashPIN.
+// Сразу после установки данные доступны
+func (s *ExpenseTracker) SetPIN(ctx context.Context, userID int64, hash, pin string) error {
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(pin)) != nil {
+		return ErrWrongPIN
+	}
+
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return err
+	}
+	settings.PINHash = hash
+	settings.PINFailures, settings.BlockedUntil = 0, nil
+	if settings.PINIdle <= 0 {
+		settings.PINIdle = model.DefaultPINIdleMinutes
+	}
+	unlock(&settings.PINSettings, time.Now())
+	return s.SaveUserSettings(ctx, settings)
+}
+
+// DisablePIN выключает блокировку после проверки текущего PIN
+func (s *ExpenseTracker) DisablePIN(ctx context.Context, userID int64, pin string) error {
+	settings, err := s.checkPIN(ctx, userID, pin, time.Now())
+	if err != nil {
+		return err
+	}
+	settings.PINSettings = model.PINSettings{PINIdle: settings.PINIdle}
+	return s.SaveUserSettings(ctx, settings)
+}
+
+// SetPINIdle задает время бездействия до повторного запроса PIN
+func (s *ExpenseTracker) SetPINIdle(ctx context.Context, userID int64, minutes int) (*model.UserSettings, error) {
+	if minutes <= 0 {
+		return nil, fmt.Errorf("invalid pin idle period: %d", minutes)
+	}
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings.PINIdle = minutes
+	if settings.PINEnabled() {
+		unlock(&settings.PINSettings, time.Now())
+	}
+	if err := s.SaveUserSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// PINLocked сообщает, нужно ли ввести PIN перед показом финансовых данных.
+// Каждое обращение к данным продлевает доступ: PIN спрашивается только после бездействия
+func (s *ExpenseTracker) PINLocked(ctx context.Context, userID int64, now time.Time) (bool, error) {
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if !settings.PINEnabled() {
+		return false, nil
+	}
+	if settings.UnlockedUntil == nil || now.After(*settings.UnlockedUntil) {
+		return true, nil
+	}
+
+	// Продлеваем не на каждое действие, а когда прошла половина срока, чтобы не писать в базу постоянно
+	idle := time.Duration(settings.PINIdle) * time.Minute
+	if settings.UnlockedUntil.Sub(now) < idle/2 {
+		unlock(&settings.PINSettings, now)
+		if err := s.SaveUserSettings(ctx, settings); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// UnlockPIN проверяет PIN и открывает доступ к данным на время бездействия
+func (s *ExpenseTracker) UnlockPIN(ctx context.Context, userID int64, pin string) error {
+	now := time.Now()
+	settings, err := s.checkPIN(ctx, userID, pin, now)
+	if err != nil {
+		return err
+	}
+	unlock(&settings.PINSettings, now)
+	return s.SaveUserSettings(ctx, settings)
+}
+
+// checkPIN сверяет PIN с хэшем и считает неверные попытки
+func (s *ExpenseTracker) checkPIN(ctx context.Context, userID int64, pin string, now time.Time) (*model.UserSettings, error) {
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.PINEnabled() {
+		return nil, ErrPINNotSet
+	}
+	if settings.BlockedUntil != nil && now.Before(*settings.BlockedUntil) {
+		return nil, ErrPINBlocked
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(settings.PINHash), []byte(pin)) != nil {
+		settings.PINFailures++
+		result := ErrWrongPIN
+		if settings.PINFailures >= maxPINAttempts {
+			blockedUntil := now.Add(pinLockout)
+			settings.BlockedUntil, settings.PINFailures = &blockedUntil, 0
+			result = ErrPINBlocked
+		}
+		if err := s.SaveUserSettings(ctx, settings); err != nil {
+			return nil, err
+		}
+		return nil, result
+	}
+
+	settings.PINFailures, settings.BlockedUntil = 0, nil
+	return settings, nil
+}
+
+// unlock открывает доступ к данным на время бездействия
+func unlock(pin *model.PINSettings, now time.Time) {
+	until := now.Add(time.Duration(pin.PINIdle) * time.Minute)
+	pin.UnlockedUntil = &until
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// MaxPlannedExpenses - сколько трат можно запланировать на один месяц
+const MaxPlannedExpenses = 30
+
+// PlanLine - план и факт по категории: запланированные траты и все траты категории
+// за месяц, включая подкатегории
+type PlanLine struct {
+	CategoryID   string
+	CategoryName string
+	Items        []model.PlannedExpense
+	Planned      float64
+	Actual       float64
+}
+
+// Remaining возвращает, сколько из плана еще не потрачено
+func (l PlanLine) Remaining() float64 {
+	return math.Max(l.Planned-l.Actual, 0)
+}
+
+// PlanVsFact - план и факт месяца по категориям, крупные планы первыми
+type PlanVsFact struct {
+	Month time.Time
+	Lines []PlanLine
+}
+
+// Planned возвращает сумму запланированных трат
+func (p PlanVsFact) Planned() float64 {
+	var total float64
+	for _, line := range p.Lines {
+		total += line.Planned
+	}
+	return total
+}
+
+// Remaining возвращает сумму запланированных трат, которые еще предстоят
+func (p PlanVsFact) Remaining() float64 {
+	var total float64
+	for _, line := range p.Lines {
+		total += line.Remaining()
+	}
+	return total
+}
+
+// NextMonth возвращает начало следующего месяца: на него по умолчанию планируются траты
+func (s *ExpenseTracker) NextMonth() time.Time {
+	now := s.now()
+	return time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+}
+
+// AddPlannedExpense планирует крупную трату в категории расходов на месяц month
+func (s *ExpenseTracker) AddPlannedExpense(ctx context.Context, userID int64, categoryID, description string, amount float64, month time.Time) (*model.PlannedExpense, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: planned amount must be positive", model.ErrValidation)
+	}
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	category := findCategory(categories, categoryID)
+	if category == nil {
+		return nil, fmt.Errorf("category %s not found: %w", categoryID, model.ErrNotFound)
+	}
+	if category.Type != "expense" {
+		return nil, fmt.Errorf("%w: category %s is not an expense category", model.ErrValidation, categoryID)
+	}
+
+	month = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	plans, err := s.repo.GetPlannedExpenses(ctx, userID, month)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get planned expenses: %w", err)
+	}
+	if len(plansOfMonth(plans, month)) >= MaxPlannedExpenses {
+		return nil, fmt.Errorf("%w: at most %d planned expenses per month", model.ErrValidation, MaxPlannedExpenses)
+	}
+
+	plan := &model.PlannedExpense{
+		UserID:      userID,
+		CategoryID:  categoryID,
+		Description: strings.TrimSpace(description),
+		Amount:      amount,
+		Month:       month,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.repo.CreatePlannedExpense(ctx, plan); err != nil {
+		return nil, fmt.Errorf("failed to create planned expense: %w", err)
+	}
+	markWrote(ctx)
+	return plan, nil
+}
+
+// GetPlannedExpenses возвращает траты, запланированные на текущий и следующие месяцы
+func (s *ExpenseTracker) GetPlannedExpenses(ctx context.Context, userID int64) ([]model.PlannedExpense, error) {
+	now := s.now()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	return s.repo.GetPlannedExpenses(ctx, userID, month)
+}
+
+// DeletePlannedExpense удаляет запланированную трату
+func (s *ExpenseTracker) DeletePlannedExpense(ctx context.Context, userID int64, id string) error {
+	return s.repo.DeletePlannedExpense(ctx, id, userID)
+}
+
+// GetPlanVsFact сравнивает траты, запланированные на месяц, с фактическими тратами
+// их категорий. nil - на месяц ничего не запланировано
+func (s *ExpenseTracker) GetPlanVsFact(ctx context.Context, userID int64, month time.Time) (*PlanVsFact, error) {
+	month = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	plans, err := s.repo.GetPlannedExpenses(ctx, userID, month)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get planned expenses: %w", err)
+	}
+	plans = plansOfMonth(plans, month)
+	if len(plans) == 0 {
+		return nil, nil
+	}
+
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	end := month.AddDate(0, 1, 0).Add(-time.Nanosecond)
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{
+		StartDate: &month,
+		EndDate:   &end,
+		Type:      "expense",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	names := make(map[string]string, len(categories))
+	parents := make(map[string]string, len(categories))
+	for _, cat := range categories {
+		names[cat.ID] = cat.Name
+		parents[cat.ID] = cat.ParentID
+	}
+	spent := make(map[string]float64)
+	for _, t := range transactions {
+		if t.IsIncome() {
+			continue
+		}
+		spent[t.CategoryID] += t.AbsAmount()
+		if parent := parents[t.CategoryID]; parent != "" {
+			spent[parent] += t.AbsAmount()
+		}
+	}
+
+	lines := make(map[string]*PlanLine)
+	result := &PlanVsFact{Month: month}
+	for _, plan := range plans {
+		line := lines[plan.CategoryID]
+		if line == nil {
+			line = &PlanLine{
+				CategoryID:   plan.CategoryID,
+				CategoryName: names[plan.CategoryID],
+				Actual:       spent[plan.CategoryID],
+			}
+			lines[plan.CategoryID] = line
+		}
+		line.Items = append(line.Items, plan)
+		line.Planned += plan.Amount
+	}
+	for _, line := range lines {
+		result.Lines = append(result.Lines, *line)
+	}
+	sort.Slice(result.Lines, func(i, j int) bool {
+		if result.Lines[i].Planned != result.Lines[j].Planned {
+			return result.Lines[i].Planned > result.Lines[j].Planned
+		}
+		return result.Lines[i].CategoryName < result.Lines[j].CategoryName
+	})
+	return result, nil
+}
+
+// plansOfMonth оставляет траты, запланированные на month
+func plansOfMonth(plans []model.PlannedExpense, month time.Time) []model.PlannedExpense {
+	var result []model.PlannedExpense
+	for _, plan := range plans {
+		planned := plan.Month.In(month.Location())
+		if planned.Year() == month.Year() && planned.Month() == month.Month() {
+			result = append(result, plan)
+		}
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// Plugin - расширение поведения бота без изменения кода обработчиков.
+// Плагин может реализовать любой набор интерфейсов-хуков ниже
+type Plugin interface {
+	Name() string
+}
+
+// TransactionCreatedHook вызывается после сохранения новой транзакции
+type TransactionCreatedHook interface {
+	OnTransactionCreated(ctx context.Context, transaction *model.Transaction) error
+}
+
+// ReportGeneratedHook вызывается после формирования отчета
+type ReportGeneratedHook interface {
+	OnReportGenerated(ctx context.Context, userID int64, report *BaseReport) error
+}
+
+// UserRegisteredHook вызывается при первом запуске бота пользователем
+type UserRegisteredHook interface {
+	OnUserRegistered(ctx context.Context, userID int64) error
+}
+
+var (
+	pluginsMu sync.Mutex
+	plugins   []Plugin
+)
+
+// RegisterPlugin регистрирует плагин для всех создаваемых ExpenseTracker.
+// Обычно вызывается из init() в отдельном файле пакета main:
+//
+//	func init() { service.RegisterPlugin(&myPlugin{}) }
+func RegisterPlugin(plugin Plugin) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	plugins = append(plugins, plugin)
+}
+
+// registeredPlugins возвращает копию списка зарегистрированных плагинов
+func registeredPlugins() []Plugin {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	return append([]Plugin(nil), plugins...)
+}
+
+// AddPlugin подключает плагин к конкретному экземпляру ExpenseTracker
+func (s *ExpenseTracker) AddPlugin(plugin Plugin) {
+	s.plugins = append(s.plugins, plugin)
+}
+
+// Ошибки плагинов только логируются: расширения не должны ломать основной сценарий
+
+func (s *ExpenseTracker) notifyTransactionCreated(ctx context.Context, transaction *model.Transaction) {
+	for _, p := range s.plugins {
+		if hook, ok := p.(TransactionCreatedHook); ok {
+			if err := hook.OnTransactionCreated(ctx, transaction); err != nil {
+				log.Printf("Plugin %s OnTransactionCreated error: %v", p.Name(), err)
+			}
+		}
+	}
+}
+
+func (s *ExpenseTracker) notifyReportGenerated(ctx context.Context, userID int64, report *BaseReport) {
+	for _, p := range s.plugins {
+		if hook, ok := p.(ReportGeneratedHook); ok {
+			if err := hook.OnReportGenerated(ctx, userID, report); err != nil {
+				log.Printf("Plugin %s OnReportGenerated error: %v", p.Name(), err)
+			}
+		}
+	}
+}
+
+func (s *ExpenseTracker) notifyUserRegistered(ctx context.Context, userID int64) {
+	for _, p := range s.plugins {
+		if hook, ok := p.(UserRegisteredHook); ok {
+			if err := hook.OnUserRegistered(ctx, userID); err != nil {
+				log.Printf("Plugin %s OnUserRegistered error: %v", p.Name(), err)
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// Пороги анализа цен: сравниваются только категории, где покупок достаточно для
+// осмысленного среднего, число покупок почти не изменилось, а средний чек заметно вырос
+const (
+	priceMinCount      = 3  // покупок в каждом из периодов
+	priceFlatCount     = 20 // отклонение частоты покупок в процентах, которое считается "столько же"
+	priceGrowthPercent = 10 // рост среднего чека в процентах
+	priceChangesCount  = 3  // сколько категорий показывать
+)
+
+// PriceChange - категория, где средний чек вырос при том же числе покупок: траты растут
+// не потому, что покупок больше, а потому, что каждая обходится дороже
+type PriceChange struct {
+	CategoryID    string
+	Name          string
+	Emoji         string
+	Count         int
+	AvgAmount     float64
+	PrevAvgAmount float64
+	ChangePercent float64 // рост среднего чека
+	Overpaid      float64 // сколько стоили покупки периода сверх прошлых средних цен
+}
+
+// fillPriceChanges сравнивает средний чек расходов по категориям с прошлым периодом.
+// Подкатегории считаются вместе с родителем, как в топе категорий. Текущий период может
+// быть еще не закончен, поэтому число покупок сравнивается с прошлым пропорционально
+// прошедшим дням
+func (s *ExpenseTracker) fillPriceChanges(report *BaseReport, currentTransactions, prevTransactions []model.Transaction, categories []model.Category) {
+	byID := make(map[string]model.Category, len(categories))
+	for _, cat := range categories {
+		byID[cat.ID] = cat
+	}
+	topLevel := func(categoryID string) (model.Category, bool) {
+		cat, ok := byID[categoryID]
+		if parent, found := byID[cat.ParentID]; ok && found {
+			return parent, true
+		}
+		return cat, ok
+	}
+
+	type purchases struct {
+		count int
+		total float64
+	}
+	collect := func(transactions []model.Transaction, start, end time.Time) map[string]purchases {
+		result := make(map[string]purchases)
+		for _, t := range transactions {
+			if t.IsIncome() || t.Date.Before(start) || t.Date.After(end) {
+				continue
+			}
+			cat, ok := topLevel(t.CategoryID)
+			if !ok {
+				continue
+			}
+			p := result[cat.ID]
+			p.count++
+			p.total += t.AbsAmount()
+			result[cat.ID] = p
+		}
+		return result
+	}
+
+	prev := Period{Start: report.StartDate, End: report.EndDate}.Previous()
+	current := collect(currentTransactions, report.StartDate, report.EndDate)
+	previous := collect(prevTransactions, prev.Start, prev.End)
+
+	elapsed := report.EndDate.Sub(report.StartDate)
+	if now := s.now(); now.Before(report.EndDate) {
+		elapsed = now.Sub(report.StartDate)
+	}
+	share := elapsed.Hours() / report.EndDate.Sub(report.StartDate).Hours()
+
+	report.CategoryData.PriceChanges = nil
+	for categoryID, cur := range current {
+		prev := previous[categoryID]
+		if cur.count < priceMinCount || prev.count < priceMinCount {
+			continue
+		}
+		expected := float64(prev.count) * share
+		if math.Abs(calculateTrendPercent(float64(cur.count), expected)) > priceFlatCount {
+			continue
+		}
+		avg, prevAvg := cur.total/float64(cur.count), prev.total/float64(prev.count)
+		change := calculateTrendPercent(avg, prevAvg)
+		if change < priceGrowthPercent {
+			continue
+		}
+		cat := byID[categoryID]
+		report.CategoryData.PriceChanges = append(report.CategoryData.PriceChanges, PriceChange{
+			CategoryID:    categoryID,
+			Name:          cat.Name,
+			Emoji:         cat.Icon(),
+			Count:         cur.count,
+			AvgAmount:     avg,
+			PrevAvgAmount: prevAvg,
+			ChangePercent: change,
+			Overpaid:      (avg - prevAvg) * float64(cur.count),
+		})
+	}
+
+	changes := report.CategoryData.PriceChanges
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Overpaid > changes[j].Overpaid })
+	if len(changes) > priceChangesCount {
+		report.CategoryData.PriceChanges = changes[:priceChangesCount]
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+const (
+	// quickAddHistoryLimit - сколько последних транзакций просматривать при подборе категории по описанию
+	quickAddHistoryLimit = 200
+	// quickAddFallbackCategory - категория, если подобрать подходящую не удалось
+	quickAddFallbackCategory = "Прочее"
+)
+
+// categoryKeywords - начала слов, по которым описание относится к категории с таким названием.
+// Используются, только если у пользователя есть категория с этим названием
+var categoryKeywords = map[string][]string{
+	"Продукты":    {"продукт", "магазин", "пятерочк", "перекрест", "ашан", "магнит", "лента", "вкусвилл", "хлеб", "молок"},
+	"Транспорт":   {"такси", "метро", "автобус", "трамва", "электричк", "бензин", "заправк", "парковк", "каршеринг", "проезд"},
+	"Развлечения": {"кино", "театр", "концерт", "бар", "игр", "подписк", "музей"},
+	"Кафе":        {"кафе", "кофе", "ресторан", "обед", "ужин", "завтрак", "доставк"},
+	"Здоровье":    {"аптек", "врач", "лекарств", "анализ", "стоматолог"},
+	"Зарплата":    {"зарплат", "зп", "аванс", "преми"},
+}
+
+// ParseQuickAdd разбирает аргументы быстрого добавления: "500 такси" - расход, "+50000 зарплата" - доход,
+// "25$ такси" или "25 USD такси" - расход в валюте. Возвращает сумму со знаком, код валюты
+// (пустой для валюты учета) и описание
+func ParseQuickAdd(text string) (float64, string, string, error) {
+	text = strings.TrimSpace(text)
+	value, description, _ := strings.Cut(text, " ")
+
+	income := strings.HasPrefix(value, "+")
+	value = strings.TrimLeft(value, "+-")
+	amount, currency, err := ParseAmount(value)
+	if err != nil {
+		return 0, "", "", err
+	}
+	description = strings.TrimSpace(description)
+	if currency == "" {
+		currency, description = cutCurrencyCode(description)
+	}
+
+	if !income {
+		amount = -amount
+	}
+	return amount, currency, description, nil
+}
+
+// AddQuickTransaction записывает транзакцию без выбора категории: категория подбирается по описанию.
+// Возвращает созданную транзакцию и выбранную категорию, чтобы ее можно было сразу изменить
+func (s *ExpenseTracker) AddQuickTransaction(ctx context.Context, userID int64, accountID string, amount float64, currency, description string) (*model.Transaction, *model.Category, error) {
+	category, err := s.guessCategory(ctx, userID, amount, description)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	transaction := &model.Transaction{
+		UserID:      userID,
+		CategoryID:  category.ID,
+		AccountID:   accountID,
+		Amount:      amount,
+		Currency:    currency,
+		Description: description,
+		Date:        now,
+		CreatedAt:   now,
+	}
+	transaction.GenerateID()
+	if err := s.createTransaction(ctx, transaction); err != nil {
+		return nil, nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
+	return transaction, category, nil
+}
+
+// ChangeTransactionCategory переносит транзакцию в другую категорию пользователя
+func (s *ExpenseTracker) ChangeTransactionCategory(ctx context.Context, userID int64, transactionID, categoryID string) (*model.Category, error) {
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	for i, cat := range categories {
+		if cat.ID != categoryID {
+			continue
+		}
+		if err := s.repo.UpdateTransactionCategory(ctx, transactionID, userID, categoryID); err != nil {
+			return nil, fmt.Errorf("failed to update transaction: %w", err)
+		}
+		s.invalidateReports(ctx, userID)
+		return &categories[i], nil
+	}
+	return nil, fmt.Errorf("category %s not found: %w", categoryID, model.ErrNotFound)
+}
+
+// guessCategory подбирает категорию по описанию (см. matchCategory).
+// Если ничего не подошло, используется категория "Прочее"
+func (s *ExpenseTracker) guessCategory(ctx context.Context, userID int64, amount float64, description string) (*model.Category, error) {
+	categoryType := "expense"
+	if amount > 0 {
+		categoryType = "income"
+	}
+
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	var history []model.Transaction
+	if description != "" {
+		history, err = s.repo.GetTransactions(ctx, userID, model.TransactionFilter{Limit: quickAddHistoryLimit})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get transactions: %w", err)
+		}
+	}
+
+	if category := matchCategory(model.ActiveCategories(categories), history, categoryType, description); category != nil {
+		return category, nil
+	}
+
+	categoryID, err := s.ensureCategory(ctx, userID, quickAddFallbackCategory, categoryType)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Category{
+		ID:     categoryID,
+		UserID: userID,
+		Name:   quickAddFallbackCategory,
+		Type:   categoryType,
+	}, nil
+}
+
+// matchCategory ищет категорию нужного типа по описанию: сначала по названию категории,
+// затем по последней транзакции с тем же описанием в history и по ключевым словам
+func matchCategory(categories []model.Category, history []model.Transaction, categoryType, description string) *model.Category {
+	byID := make(map[string]*model.Category, len(categories))
+	var candidates []model.Category
+	for i, cat := range categories {
+		byID[cat.ID] = &categories[i]
+		if cat.Type == categoryType {
+			candidates = append(candidates, cat)
+		}
+	}
+
+	words := strings.Fields(strings.ToLower(description))
+
+	// Описание совпадает с названием категории: "/add 500 продукты"
+	for _, word := range words {
+		for i, cat := range candidates {
+			name := strings.ToLower(cat.Name)
+			if name == word || (utf8.RuneCountInString(word) >= 3 && strings.HasPrefix(name, word)) {
+				return &candidates[i]
+			}
+		}
+	}
+
+	// Такое же описание уже встречалось - берем категорию последней такой транзакции
+	if description != "" {
+		for _, t := range history {
+			cat, ok := byID[t.CategoryID]
+			if ok && cat.Type == categoryType && strings.EqualFold(strings.TrimSpace(t.Description), strings.TrimSpace(description)) {
+				return cat
+			}
+		}
+	}
+
+	// Ключевые слова для распространенных категорий
+	for i, cat := range candidates {
+		for _, keyword := range categoryKeywords[cat.Name] {
+			for _, word := range words {
+				if strings.HasPrefix(word, keyword) {
+					return &candidates[i]
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// ErrReceiptProviderNotConfigured возвращается, если импорт чеков не настроен
+var ErrReceiptProviderNotConfigured = errors.New("receipt provider is not configured")
+
+// maxReceiptNames - сколько названий позиций попадает в описание транзакции категории
+const maxReceiptNames = 3
+
+// ReceiptProvider получает содержимое чека по строке QR-кода ФНС
+type ReceiptProvider interface {
+	GetReceipt(ctx context.Context, qrRaw string) (*model.Receipt, error)
+}
+
+// SetReceiptProvider подключает источник данных о чеках
+func (s *ExpenseTracker) SetReceiptProvider(provider ReceiptProvider) {
+	s.receipts = provider
+}
+
+// FetchReceipt получает содержимое чека по QR-коду
+func (s *ExpenseTracker) FetchReceipt(ctx context.Context, qrRaw string) (*model.Receipt, error) {
+	if s.receipts == nil {
+		return nil, ErrReceiptProviderNotConfigured
+	}
+	return s.receipts.GetReceipt(ctx, qrRaw)
+}
+
+// ImportReceiptTotal записывает итог чека одной транзакцией в выбранную категорию.
+// Чек возврата записывается доходом
+func (s *ExpenseTracker) ImportReceiptTotal(ctx context.Context, userID int64, receipt *model.Receipt, categoryID string) error {
+	transactionType, err := receipt.TransactionType()
+	if err != nil {
+		return err
+	}
+	transaction := s.receiptTransaction(userID, categoryID, transactionType, receipt.Total, receipt.Seller, receipt.DateTime)
+	if err := s.createTransaction(ctx, transaction); err != nil {
+		return fmt.Errorf("failed to import receipt: %w", err)
+	}
+	return nil
+}
+
+// ImportReceiptItems раскладывает позиции чека по категориям и записывает по одной транзакции
+// на категорию. Категория позиции подбирается по названию, иначе используется fallbackCategoryID.
+// Транзакции сохраняются одним запросом: при ошибке чек не записывается частично.
+// Возвращает количество созданных транзакций
+func (s *ExpenseTracker) ImportReceiptItems(ctx context.Context, userID int64, receipt *model.Receipt, fallbackCategoryID string) (int, error) {
+	transactionType, err := receipt.TransactionType()
+	if err != nil {
+		return 0, err
+	}
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	var order []string
+	sums := make(map[string]float64)
+	names := make(map[string][]string)
+	for _, item := range receipt.Items {
+		categoryID := guessItemCategory(item.Name, categories, transactionType)
+		if categoryID == "" {
+			categoryID = fallbackCategoryID
+		}
+		if _, ok := sums[categoryID]; !ok {
+			order = append(order, categoryID)
+		}
+		sums[categoryID] += item.Sum
+		names[categoryID] = append(names[categoryID], item.Name)
+	}
+
+	transactions := make([]*model.Transaction, 0, len(order))
+	for _, categoryID := range order {
+		sum := math.Round(sums[categoryID]*100) / 100
+		transactions = append(transactions, s.receiptTransaction(userID, categoryID, transactionType,
+			sum, receiptItemsDescription(names[categoryID]), receipt.DateTime))
+	}
+	if err := s.createTransactions(ctx, transactions); err != nil {
+		return 0, fmt.Errorf("failed to import receipt: %w", err)
+	}
+	return len(transactions), nil
+}
+
+func (s *ExpenseTracker) receiptTransaction(userID int64, categoryID, transactionType string, sum float64, description string, date time.Time) *model.Transaction {
+	transaction := &model.Transaction{
+		UserID:      userID,
+		CategoryID:  categoryID,
+		Amount:      sum,
+		Description: description,
+		Date:        date, // время покупки из чека
+		CreatedAt:   s.now(),
+	}
+	transaction.SetType(transactionType)
+	transaction.GenerateID()
+	return transaction
+}
+
+// receiptItemsDescription перечисляет позиции, записанные одной транзакцией
+func receiptItemsDescription(names []string) string {
+	if len(names) <= maxReceiptNames {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s и еще %d поз.", strings.Join(names[:maxReceiptNames], ", "), len(names)-maxReceiptNames)
+}
+
+// guessItemCategory подбирает категорию нужного типа, название которой встречается в названии позиции
+func guessItemCategory(itemName string, categories []model.Category, categoryType string) string {
+	name := strings.ToLower(itemName)
+	for _, cat := range categories {
+		if cat.Type != categoryType {
+			continue
+		}
+		// Сравниваем по основе слова, чтобы "Молоко" совпало с категорией "Молочные продукты"
+		stem := []rune(strings.ToLower(cat.Name))
+		if len(stem) > 5 {
+			stem = stem[:5]
+		}
+		if len(stem) >= 3 && strings.Contains(name, string(stem)) {
+			return cat.ID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+	"github.com/ivanoskov/financial_bot/internal/service/mocks"
+)
+
+func receiptRepo() *mocks.Repository {
+	return &mocks.Repository{
+		GetCategoriesFunc: func(ctx context.Context, userID int64) ([]model.Category, error) {
+			return []model.Category{
+				{ID: "milk", Name: "Молоко и сыр", Type: model.TransactionExpense},
+				{ID: "bread", Name: "Хлеб", Type: model.TransactionExpense},
+				{ID: "other", Name: "Прочее", Type: model.TransactionExpense},
+				{ID: "refund", Name: "Возвраты", Type: model.TransactionIncome},
+			}, nil
+		},
+	}
+}
+
+func testReceipt(operation model.ReceiptOperation) *model.Receipt {
+	return &model.Receipt{
+		Seller:    "Магазин",
+		DateTime:  time.Date(2026, 3, 20, 18, 30, 0, 0, msk),
+		Total:     300.3,
+		Operation: operation,
+		Items: []model.ReceiptItem{
+			{Name: "Молоко 3,2%", Sum: 89.9},
+			{Name: "Хлеб бородинский", Sum: 55},
+			{Name: "Молоко топленое", Sum: 95.4},
+			{Name: "Батарейки", Sum: 60},
+		},
+	}
+}
+
+func TestImportReceiptItemsGroupsByCategory(t *testing.T) {
+	repo := receiptRepo()
+	tracker := service.NewExpenseTracker(repo)
+
+	created, err := tracker.ImportReceiptItems(context.Background(), 1, testReceipt(model.ReceiptSale), "other")
+	if err != nil {
+		t.Fatalf("ImportReceiptItems: %v", err)
+	}
+	if created != 3 {
+		t.Errorf("создано транзакций: %d, ожидалось 3", created)
+	}
+	if calls := repo.CallsOf("CreateTransaction"); len(calls) != 0 {
+		t.Errorf("позиции записаны по одной: %d вызовов", len(calls))
+	}
+
+	calls := repo.CallsOf("CreateTransactions")
+	if len(calls) != 1 {
+		t.Fatalf("пакетных записей: %d, ожидалась 1", len(calls))
+	}
+	want := map[string]float64{"milk": -185.3, "bread": -55, "other": -60}
+	for _, tr := range calls[0].Args[0].([]*model.Transaction) {
+		if tr.Type != model.TransactionExpense || tr.Amount != want[tr.CategoryID] {
+			t.Errorf("категория %s: %s %.2f, ожидался расход %.2f", tr.CategoryID, tr.Type, tr.Amount, want[tr.CategoryID])
+		}
+		delete(want, tr.CategoryID)
+	}
+	if len(want) != 0 {
+		t.Errorf("не записаны категории: %v", want)
+	}
+}
+
+func TestImportReceiptRefundIsIncome(t *testing.T) {
+	repo := receiptRepo()
+	tracker := service.NewExpenseTracker(repo)
+
+	if err := tracker.ImportReceiptTotal(context.Background(), 1, testReceipt(model.ReceiptSaleRefund), "refund"); err != nil {
+		t.Fatalf("ImportReceiptTotal: %v", err)
+	}
+	calls := repo.CallsOf("CreateTransaction")
+	if len(calls) != 1 {
+		t.Fatalf("записано транзакций: %d, ожидалась 1", len(calls))
+	}
+	if tr := calls[0].Args[0].(*model.Transaction); tr.Type != model.TransactionIncome || tr.Amount != 300.3 {
+		t.Errorf("возврат записан как %s %.2f, ожидался доход 300.30", tr.Type, tr.Amount)
+	}
+
+	unknown := testReceipt(7)
+	if err := tracker.ImportReceiptTotal(context.Background(), 1, unknown, "refund"); !errors.Is(err, model.ErrValidation) {
+		t.Errorf("чек с неизвестной операцией: %v, ожидалась ошибка проверки", err)
+	}
+}
+
+func TestImportReceiptItemsFailsWhole(t *testing.T) {
+	repo := receiptRepo()
+	repo.CreateTransactionsFunc = func(ctx context.Context, transactions []*model.Transaction) error {
+		return errors.New("connection reset")
+	}
+	tracker := service.NewExpenseTracker(repo)
+
+	created, err := tracker.ImportReceiptItems(context.Background(), 1, testReceipt(model.ReceiptSale), "other")
+	if err == nil || created != 0 {
+		t.Errorf("ImportReceiptItems = %d, %v, ожидалась ошибка без записанных транзакций", created, err)
+	}
+}
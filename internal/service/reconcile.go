@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+const (
+	// reconcileDateWindow - допустимое расхождение дат между выпиской и записью в боте
+	reconcileDateWindow = 2 * 24 * time.Hour
+	// reconcileAmountEpsilon - допустимое расхождение сумм (копейки при округлении)
+	reconcileAmountEpsilon = 0.01
+	// statementCategoryName - категория для операций, созданных из выписки
+	statementCategoryName = "Прочее"
+	// maxStatementAmount - наибольшая сумма операции в выписке. Суммы больше - ошибка
+	// разбора или испорченный файл, а не операция
+	maxStatementAmount = 1e12
+)
+
+// statementDateLayouts - поддерживаемые форматы дат в выписках
+var statementDateLayouts = []string{
+	"02.01.2006",
+	"02.01.2006 15:04",
+	"02.01.2006 15:04:05",
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+}
+
+// StatementLine представляет одну операцию из банковской выписки
+type StatementLine struct {
+	Date        time.Time `json:"date"`
+	Amount      float64   `json:"amount"` // отрицательная для расходов
+	Description string    `json:"description"`
+}
+
+// ReconciliationResult содержит результат сверки выписки с записями бота
+type ReconciliationResult struct {
+	StartDate     time.Time
+	EndDate       time.Time
+	Matched       int
+	MissingInBot  []StatementLine     // есть в выписке, но нет в боте
+	MissingInBank []model.Transaction // есть в боте, но нет в выписке
+}
+
+// ParseStatementCSV разбирает выписку в формате CSV: дата, сумма, описание.
+// Поддерживаются разделители ";" и ",", строка заголовка пропускается
+func ParseStatementCSV(r io.Reader) ([]StatementLine, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read statement: %w", err)
+	}
+	content := strings.TrimPrefix(string(data), "\ufeff")
+
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.Comma = ';'
+	if firstLine, _, _ := strings.Cut(content, "\n"); !strings.Contains(firstLine, ";") {
+		reader.Comma = ','
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse statement: %w", err)
+	}
+
+	lines := make([]StatementLine, 0, len(records))
+	for i, record := range records {
+		if len(record) < 2 {
+			continue
+		}
+		date, err := parseStatementDate(record[0])
+		if err != nil {
+			if i == 0 {
+				continue // Строка заголовка
+			}
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		amount, err := parseStatementAmount(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		line := StatementLine{Date: date, Amount: amount}
+		if len(record) > 2 {
+			line.Description = strings.TrimSpace(record[2])
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("statement has no operations")
+	}
+	return lines, nil
+}
+
+func parseStatementDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range statementDateLayouts {
+		if date, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %q", value)
+}
+
+// parseStatementAmount разбирает сумму операции со знаком. ParseFloat принимает и "NaN",
+// "Inf" или "1e60", поэтому сумма дополнительно проверяется: конечная, ненулевая и правдоподобная
+func parseStatementAmount(value string) (float64, error) {
+	value = strings.NewReplacer(" ", "", "\u00a0", "", "₽", "", ",", ".").Replace(strings.TrimSpace(value))
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) ||
+		amount == 0 || math.Abs(amount) > maxStatementAmount {
+		return 0, fmt.Errorf("invalid amount: %q", value)
+	}
+	return amount, nil
+}
+
+// ReconcileStatement сопоставляет операции выписки с транзакциями бота за тот же период
+func (s *ExpenseTracker) ReconcileStatement(ctx context.Context, userID int64, lines []StatementLine) (*ReconciliationResult, error) {
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("statement is empty")
+	}
+
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].Date.Before(lines[j].Date)
+	})
+
+	first, last := lines[0].Date, lines[len(lines)-1].Date
+	startDate := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, first.Location())
+	endDate := time.Date(last.Year(), last.Month(), last.Day(), 23, 59, 59, 999999999, last.Location())
+
+	// Банк может провести операцию на пару дней позже записи в боте, поэтому пары ищутся
+	// и среди транзакций за reconcileDateWindow до и после периода выписки.
+	// В выписке разделенный платеж - одна операция, поэтому сверяем покупки целиком
+	fetchStart, fetchEnd := startDate.Add(-reconcileDateWindow), endDate.Add(reconcileDateWindow)
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{
+		StartDate: &fetchStart,
+		EndDate:   &fetchEnd,
+		Logical:   true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	result := &ReconciliationResult{
+		StartDate: startDate,
+		EndDate:   endDate,
+	}
+
+	// Жадно сопоставляем каждую строку выписки с ближайшей по дате транзакцией с той же суммой
+	used := make([]bool, len(transactions))
+	for _, line := range lines {
+		best := -1
+		var bestDiff time.Duration
+		for i, t := range transactions {
+			if used[i] || math.Abs(t.Amount-line.Amount) > reconcileAmountEpsilon {
+				continue
+			}
+			diff := t.Date.Sub(line.Date)
+			if diff < 0 {
+				diff = -diff
+			}
+			if diff > reconcileDateWindow {
+				continue
+			}
+			if best == -1 || diff < bestDiff {
+				best, bestDiff = i, diff
+			}
+		}
+
+		if best == -1 {
+			result.MissingInBot = append(result.MissingInBot, line)
+			continue
+		}
+		used[best] = true
+		result.Matched++
+	}
+
+	// Транзакции за пределами периода выписки нужны только для пар: их отсутствие в выписке
+	// ничего не значит
+	for i, t := range transactions {
+		if !used[i] && !t.Date.Before(startDate) && !t.Date.After(endDate) {
+			result.MissingInBank = append(result.MissingInBank, t)
+		}
+	}
+
+	return result, nil
+}
+
+// AddStatementTransaction создает транзакцию по строке выписки в категории "Прочее"
+func (s *ExpenseTracker) AddStatementTransaction(ctx context.Context, userID int64, date time.Time, amount float64, description string) error {
+	categoryType := "expense"
+	if amount > 0 {
+		categoryType = "income"
+	}
+
+	categoryID, err := s.ensureCategory(ctx, userID, statementCategoryName, categoryType)
+	if err != nil {
+		return err
+	}
+
+	return s.createStatementTransaction(ctx, userID, categoryID, date, amount, description)
+}
+
+// createStatementTransaction создает транзакцию по строке выписки в указанной категории
+func (s *ExpenseTracker) createStatementTransaction(ctx context.Context, userID int64, categoryID string, date time.Time, amount float64, description string) error {
+	if description == "" {
+		description = "Из выписки"
+	}
+
+	transaction := &model.Transaction{
+		UserID:      userID,
+		CategoryID:  categoryID,
+		Amount:      amount,
+		Description: description,
+		Date:        time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location()),
+		CreatedAt:   s.now(),
+	}
+	transaction.GenerateID()
+	return s.createTransaction(ctx, transaction)
+}
+
+// ensureCategory возвращает ID категории с указанным именем и типом, создавая ее при необходимости
+func (s *ExpenseTracker) ensureCategory(ctx context.Context, userID int64, name, categoryType string) (string, error) {
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get categories: %w", err)
+	}
+	for _, cat := range categories {
+		if cat.Type == categoryType && strings.EqualFold(cat.Name, name) {
+			return cat.ID, nil
+		}
+	}
+
+	category := &model.Category{
+		UserID:    userID,
+		Name:      name,
+		Type:      categoryType,
+		CreatedAt: s.now(),
+	}
+	if err := s.repo.CreateCategory(ctx, category); err != nil {
+		return "", fmt.Errorf("failed to create category %s: %w", name, err)
+	}
+	return category.ID, nil
+}
@@ -0,0 +1,85 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+	"github.com/ivanoskov/financial_bot/internal/service/mocks"
+)
+
+func TestReconcileStatementDateWindow(t *testing.T) {
+	lines := []service.StatementLine{
+		{Date: midnight(2026, 3, 10), Amount: -500, Description: "Кофейня"},
+		{Date: midnight(2026, 3, 15), Amount: -1200, Description: "Аптека"},
+	}
+	transactions := []model.Transaction{
+		// Записана в боте за день до начала выписки, банк провел покупку позже
+		{ID: "early", Amount: -500, Date: time.Date(2026, 3, 9, 19, 0, 0, 0, msk)},
+		{ID: "missing", Amount: -300, Date: time.Date(2026, 3, 12, 13, 0, 0, 0, msk)},
+		// Вне периода выписки и без пары: выписка о ней ничего не говорит
+		{ID: "after", Amount: -700, Date: time.Date(2026, 3, 16, 10, 0, 0, 0, msk)},
+	}
+	repo := &mocks.Repository{
+		GetTransactionsFunc: func(ctx context.Context, userID int64, filter model.TransactionFilter) ([]model.Transaction, error) {
+			var result []model.Transaction
+			for _, t := range transactions {
+				if !t.Date.Before(*filter.StartDate) && !t.Date.After(*filter.EndDate) {
+					result = append(result, t)
+				}
+			}
+			return result, nil
+		},
+	}
+	tracker := service.NewExpenseTracker(repo)
+
+	result, err := tracker.ReconcileStatement(context.Background(), 1, lines)
+	if err != nil {
+		t.Fatalf("ReconcileStatement: %v", err)
+	}
+	if result.Matched != 1 {
+		t.Errorf("совпало %d, ожидалась 1", result.Matched)
+	}
+	if len(result.MissingInBot) != 1 || result.MissingInBot[0].Description != "Аптека" {
+		t.Errorf("нет в боте: %+v, ожидалась аптека", result.MissingInBot)
+	}
+	if len(result.MissingInBank) != 1 || result.MissingInBank[0].ID != "missing" {
+		t.Errorf("нет в выписке: %+v, ожидалась только missing", result.MissingInBank)
+	}
+}
+
+func TestParseStatementCSVAmounts(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount string
+		want   float64 // 0 - строка отклоняется
+	}{
+		{"расход", "-1 234,50", -1234.5},
+		{"доход со знаком рубля", "5000₽", 5000},
+		{"NaN", "NaN", 0},
+		{"бесконечность", "-Inf", 0},
+		{"ноль", "0,00", 0},
+		{"нереальная сумма", "1e60", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := service.ParseStatementCSV(strings.NewReader("10.03.2026;" + tt.amount + ";Магазин\n"))
+			if tt.want == 0 {
+				if err == nil {
+					t.Errorf("сумма %q принята: %+v", tt.amount, lines)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseStatementCSV: %v", err)
+			}
+			if lines[0].Amount != tt.want {
+				t.Errorf("сумма %.2f, ожидалось %.2f", lines[0].Amount, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// RecycleBinDays - сколько дней удаленные транзакции и категории хранятся в корзине
+const RecycleBinDays = 30
+
+// ErrCategoryDeleted - транзакцию нельзя восстановить, пока ее категория в корзине
+var ErrCategoryDeleted = fmt.Errorf("%w: category of the transaction is deleted", model.ErrValidation)
+
+// DeletedCategory - категория в корзине и число транзакций, удаленных вместе с ней
+type DeletedCategory struct {
+	model.Category
+	Transactions int
+}
+
+// RecycleBin - содержимое корзины. Транзакции, удаленные вместе с категорией,
+// отдельно не показываются: они восстанавливаются вместе с ней
+type RecycleBin struct {
+	Categories   []DeletedCategory
+	Transactions []model.Transaction
+}
+
+// Empty сообщает, что корзина пуста
+func (b *RecycleBin) Empty() bool {
+	return len(b.Categories) == 0 && len(b.Transactions) == 0
+}
+
+// GetRecycleBin возвращает транзакции и категории, удаленные за последние RecycleBinDays дней
+func (s *ExpenseTracker) GetRecycleBin(ctx context.Context, userID int64) (*RecycleBin, error) {
+	since := s.now().AddDate(0, 0, -RecycleBinDays)
+	categories, err := s.repo.GetDeletedCategories(ctx, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted categories: %w", err)
+	}
+	transactions, err := s.repo.GetDeletedTransactions(ctx, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted transactions: %w", err)
+	}
+
+	bin := &RecycleBin{}
+	byID := make(map[string]int, len(categories))
+	for i, cat := range categories {
+		byID[cat.ID] = i
+		bin.Categories = append(bin.Categories, DeletedCategory{Category: cat})
+	}
+	for _, t := range transactions {
+		if i, ok := byID[t.CategoryID]; ok && deletedTogether(&t, &categories[i]) {
+			bin.Categories[i].Transactions++
+			continue
+		}
+		bin.Transactions = append(bin.Transactions, t)
+	}
+	return bin, nil
+}
+
+// deletedTogether сообщает, что транзакция удалена вместе со своей категорией
+func deletedTogether(t *model.Transaction, category *model.Category) bool {
+	return t.DeletedAt != nil && category.DeletedAt != nil && t.DeletedAt.Equal(*category.DeletedAt)
+}
+
+// RestoreTransaction возвращает транзакцию из корзины. Транзакцию из удаленной
+// категории можно вернуть только после самой категории
+func (s *ExpenseTracker) RestoreTransaction(ctx context.Context, userID int64, transactionID string) (*model.Transaction, error) {
+	bin, err := s.GetRecycleBin(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var transaction *model.Transaction
+	for i := range bin.Transactions {
+		if bin.Transactions[i].ID == transactionID {
+			transaction = &bin.Transactions[i]
+			break
+		}
+	}
+	if transaction == nil {
+		return nil, ErrTransactionNotFound
+	}
+	for _, cat := range bin.Categories {
+		if cat.ID == transaction.CategoryID {
+			return nil, fmt.Errorf("%w: %s", ErrCategoryDeleted, cat.Name)
+		}
+	}
+
+	if err := s.repo.RestoreTransaction(ctx, transactionID, userID); err != nil {
+		return nil, fmt.Errorf("failed to restore transaction: %w", err)
+	}
+	s.invalidateReports(ctx, userID)
+	transaction.DeletedAt = nil
+	return transaction, nil
+}
+
+// RestoreCategory возвращает категорию из корзины вместе с транзакциями, удаленными
+// с ней. Название проверяется заново: за это время могла появиться такая же категория
+func (s *ExpenseTracker) RestoreCategory(ctx context.Context, userID int64, categoryID string) (*DeletedCategory, error) {
+	bin, err := s.GetRecycleBin(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var category *DeletedCategory
+	for i := range bin.Categories {
+		if bin.Categories[i].ID == categoryID {
+			category = &bin.Categories[i]
+			break
+		}
+	}
+	if category == nil {
+		return nil, fmt.Errorf("category %s not found: %w", categoryID, model.ErrNotFound)
+	}
+
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	if len(categories) >= MaxCategories {
+		return nil, ErrTooManyCategories
+	}
+	if err := validateCategory(categories, &category.Category); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RestoreCategory(ctx, &category.Category); err != nil {
+		return nil, fmt.Errorf("failed to restore category: %w", err)
+	}
+	s.invalidateReports(ctx, userID)
+	return category, nil
+}
+
+// PurgeRecycleBin безвозвратно удаляет записи, пролежавшие в корзине дольше
+// RecycleBinDays дней, вместе с фото чеков. Вызывается по расписанию
+func (s *ExpenseTracker) PurgeRecycleBin(ctx context.Context, now time.Time) error {
+	attachments, err := s.repo.PurgeDeleted(ctx, now.AddDate(0, 0, -RecycleBinDays))
+	if err != nil {
+		return fmt.Errorf("failed to purge recycle bin: %w", err)
+	}
+	s.deleteAttachments(ctx, attachments...)
+	return nil
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// ReminderDue сообщает, пора ли напомнить пользователю о записи расходов
+func ReminderDue(settings *model.UserSettings, now time.Time) bool {
+	return settings.Reminder &&
+		now.Hour() == settings.ReminderHour &&
+		!settings.IsQuietDay(now.Weekday())
+}
+
+// HasLoggedToday сообщает, записал ли пользователь сегодня хотя бы одну транзакцию.
+// В общем бюджете учитываются только транзакции самого пользователя
+func (s *ExpenseTracker) HasLoggedToday(ctx context.Context, userID int64, now time.Time) (bool, error) {
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	end := start.AddDate(0, 0, 1).Add(-time.Nanosecond)
+
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{
+		StartDate: &start,
+		EndDate:   &end,
+		Logical:   true,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	for _, t := range transactions {
+		author := t.AuthorID
+		if author == 0 {
+			author = t.UserID
+		}
+		if author == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// maxReminders - сколько напоминаний может завести один пользователь
+const maxReminders = 20
+
+// defaultReminderTime - время напоминания, если в тексте оно не указано
+const defaultReminderTime = 10
+
+var (
+	// ErrInvalidReminder - в тексте напоминания не удалось найти расписание или текст
+	ErrInvalidReminder = fmt.Errorf("%w: invalid reminder", model.ErrValidation)
+	// ErrTooManyReminders - у пользователя уже maxReminders напоминаний
+	ErrTooManyReminders = fmt.Errorf("%w: too many reminders", model.ErrValidation)
+)
+
+var (
+	reminderDaily   = regexp.MustCompile(`(?i)^(каждый\s+день|ежедневно)`)
+	reminderMonthly = regexp.MustCompile(`(?i)^(?:каждое\s+)?(\d{1,2})(?:-?го)?\s+числа`)
+	reminderWeekly  = regexp.MustCompile(`(?i)^(?:кажд(?:ый|ую|ое)|по)\s+(понедельник|вторник|сред|четверг|пятниц|суббот|воскресень)\S*`)
+	reminderTime    = regexp.MustCompile(`(?i)^в\s+(\d{1,2})(?:[:.](\d{2}))?(?:\s|$)`)
+	reminderVerb    = regexp.MustCompile(`(?i)^(?:напоминай|напоминать|напомнить|напомни)`)
+)
+
+// reminderWeekdays - начала названий дней недели в расписании "по понедельникам"
+var reminderWeekdays = map[string]time.Weekday{
+	"понедельник": time.Monday,
+	"вторник":     time.Tuesday,
+	"сред":        time.Wednesday,
+	"четверг":     time.Thursday,
+	"пятниц":      time.Friday,
+	"суббот":      time.Saturday,
+	"воскресень":  time.Sunday,
+}
+
+// ParseReminder разбирает напоминание: расписание, необязательное время и текст.
+// "каждый день в 21:00 записать траты", "15 числа — оплатить интернет",
+// "по пятницам в 18:30 перевести на накопления". Без времени напоминание приходит в 10:00
+func ParseReminder(text string) (*model.Reminder, error) {
+	rest := strings.TrimSpace(text)
+	reminder := &model.Reminder{Hour: defaultReminderTime}
+
+	switch {
+	case reminderDaily.MatchString(rest):
+		reminder.Schedule = model.ReminderDaily
+		rest = rest[len(reminderDaily.FindString(rest)):]
+	case reminderMonthly.MatchString(rest):
+		m := reminderMonthly.FindStringSubmatch(rest)
+		day, _ := strconv.Atoi(m[1])
+		if day < 1 || day > 31 {
+			return nil, fmt.Errorf("%w: day %d", ErrInvalidReminder, day)
+		}
+		reminder.Schedule, reminder.Day = model.ReminderMonthly, day
+		rest = rest[len(m[0]):]
+	case reminderWeekly.MatchString(rest):
+		m := reminderWeekly.FindStringSubmatch(rest)
+		reminder.Schedule, reminder.Day = model.ReminderWeekly, int(reminderWeekdays[strings.ToLower(m[1])])
+		rest = rest[len(m[0]):]
+	default:
+		return nil, fmt.Errorf("%w: no schedule in %q", ErrInvalidReminder, text)
+	}
+
+	rest = strings.TrimSpace(rest)
+	if m := reminderTime.FindStringSubmatch(rest); m != nil {
+		hour, _ := strconv.Atoi(m[1])
+		minute := 0
+		if m[2] != "" {
+			minute, _ = strconv.Atoi(m[2])
+		}
+		if hour > 23 || minute > 59 {
+			return nil, fmt.Errorf("%w: time %s", ErrInvalidReminder, strings.TrimSpace(m[0]))
+		}
+		reminder.Hour, reminder.Minute = hour, minute
+		rest = rest[len(m[0]):]
+	}
+
+	rest = strings.TrimLeft(strings.TrimSpace(rest), "—–-:, ")
+	rest = strings.TrimSpace(reminderVerb.ReplaceAllString(rest, ""))
+	if rest == "" {
+		return nil, fmt.Errorf("%w: empty text", ErrInvalidReminder)
+	}
+	reminder.Text = rest
+	return reminder, nil
+}
+
+// CreateReminder разбирает и сохраняет напоминание пользователя
+func (s *ExpenseTracker) CreateReminder(ctx context.Context, userID int64, text string) (*model.Reminder, error) {
+	reminder, err := ParseReminder(text)
+	if err != nil {
+		return nil, err
+	}
+	reminders, err := s.repo.GetReminders(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminders: %w", err)
+	}
+	if len(reminders) >= maxReminders {
+		return nil, ErrTooManyReminders
+	}
+
+	reminder.UserID = userID
+	reminder.CreatedAt = time.Now()
+	if err := s.repo.CreateReminder(ctx, reminder); err != nil {
+		return nil, fmt.Errorf("failed to create reminder: %w", err)
+	}
+	markWrote(ctx)
+	return reminder, nil
+}
+
+// GetReminders возвращает напоминания пользователя
+func (s *ExpenseTracker) GetReminders(ctx context.Context, userID int64) ([]model.Reminder, error) {
+	return s.repo.GetReminders(ctx, userID)
+}
+
+// DeleteReminder удаляет напоминание пользователя
+func (s *ExpenseTracker) DeleteReminder(ctx context.Context, userID int64, id string) error {
+	return s.repo.DeleteReminder(ctx, id, userID)
+}
+
+// DueReminders возвращает напоминания всех пользователей, которые пора отправить
+func (s *ExpenseTracker) DueReminders(ctx context.Context, now time.Time) ([]model.Reminder, error) {
+	reminders, err := s.repo.GetAllReminders(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminders: %w", err)
+	}
+	var due []model.Reminder
+	for _, reminder := range reminders {
+		if reminder.Due(now) {
+			due = append(due, reminder)
+		}
+	}
+	return due, nil
+}
+
+// MarkReminderSent отмечает напоминание отправленным. Ошибка только пишется в лог:
+// в худшем случае напоминание придет еще раз при следующем вызове в пределах окна
+func (s *ExpenseTracker) MarkReminderSent(ctx context.Context, reminder *model.Reminder, sentAt time.Time) {
+	if err := s.repo.MarkReminderSent(ctx, reminder.ID, sentAt); err != nil {
+		log.Printf("Error marking reminder %s sent: %v", reminder.ID, err)
+	}
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/locale"
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// DefaultReportCacheTTL - время жизни готового отчета. Изменение транзакций сбрасывает кэш
+// раньше, поэтому срок ограничивает только устаревание из-за смены дня и состава бюджета
+const DefaultReportCacheTTL = 10 * time.Minute
+
+// ReportCache хранит готовые отчеты по владельцу бюджета и ключу периода.
+// Ошибки хранилища не должны мешать построению отчета, поэтому методы их не возвращают
+type ReportCache interface {
+	Get(ctx context.Context, ownerID int64, key string) (*BaseReport, bool)
+	Set(ctx context.Context, ownerID int64, key string, report *BaseReport)
+	Invalidate(ctx context.Context, ownerID int64)
+}
+
+// SetReportCache подключает кэш отчетов
+func (s *ExpenseTracker) SetReportCache(cache ReportCache) {
+	s.reports = cache
+}
+
+// reportCacheKey определяет отчет: тип, язык подписей, часовой пояс и начало периода
+func reportCacheKey(ctx context.Context, reportType ReportType, startDate time.Time, location *time.Location) string {
+	return fmt.Sprintf("%d:%s:%s:%s", reportType, locale.FromContext(ctx), location, startDate.Format("2006-01-02"))
+}
+
+// invalidateReports сбрасывает отчеты бюджета, в котором состоит пользователь
+func (s *ExpenseTracker) invalidateReports(ctx context.Context, userID int64) {
+	if s.reports == nil {
+		return
+	}
+	ownerID, err := s.ledger.owner(ctx, userID)
+	if err != nil {
+		log.Printf("Error resolving ledger owner: %v", err)
+		return
+	}
+	s.reports.Invalidate(ctx, ownerID)
+}
+
+// memoryReportCache - кэш отчетов в памяти процесса для режима long polling
+type memoryReportCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	reports map[int64]map[string]cachedReport
+}
+
+type cachedReport struct {
+	report    *BaseReport
+	expiresAt time.Time
+}
+
+// NewMemoryReportCache создает кэш отчетов в памяти
+func NewMemoryReportCache(ttl time.Duration) ReportCache {
+	return &memoryReportCache{
+		ttl:     ttl,
+		reports: make(map[int64]map[string]cachedReport),
+	}
+}
+
+func (c *memoryReportCache) Get(ctx context.Context, ownerID int64, key string) (*BaseReport, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.reports[ownerID][key]
+	if !ok || time.Now().After(cached.expiresAt) {
+		return nil, false
+	}
+	return cached.report, true
+}
+
+func (c *memoryReportCache) Set(ctx context.Context, ownerID int64, key string, report *BaseReport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	reports := c.reports[ownerID]
+	if reports == nil {
+		reports = make(map[string]cachedReport)
+		c.reports[ownerID] = reports
+	}
+	// Отчеты прошедших периодов больше не запрашиваются, убираем их при записи
+	for k, cached := range reports {
+		if now.After(cached.expiresAt) {
+			delete(reports, k)
+		}
+	}
+	reports[key] = cachedReport{report: report, expiresAt: now.Add(c.ttl)}
+}
+
+func (c *memoryReportCache) Invalidate(ctx context.Context, ownerID int64) {
+	c.mu.Lock()
+	delete(c.reports, ownerID)
+	c.mu.Unlock()
+}
+
+// ReportCacheStore - хранилище отчетов, общее для экземпляров функции
+type ReportCacheStore interface {
+	GetCachedReport(ctx context.Context, ownerID int64, key string) (*model.CachedReport, error)
+	SaveCachedReport(ctx context.Context, report *model.CachedReport) error
+	DeleteCachedReports(ctx context.Context, ownerID int64) error
+}
+
+// storeReportCache - кэш отчетов во внешнем хранилище для serverless-режима,
+// где память не переживает холодный старт и не разделяется между экземплярами
+type storeReportCache struct {
+	store ReportCacheStore
+	ttl   time.Duration
+}
+
+// NewStoreReportCache создает кэш отчетов поверх хранилища (таблица Supabase, Redis)
+func NewStoreReportCache(store ReportCacheStore, ttl time.Duration) ReportCache {
+	return &storeReportCache{store: store, ttl: ttl}
+}
+
+func (c *storeReportCache) Get(ctx context.Context, ownerID int64, key string) (*BaseReport, bool) {
+	cached, err := c.store.GetCachedReport(ctx, ownerID, key)
+	if err != nil {
+		log.Printf("Error getting cached report: %v", err)
+		return nil, false
+	}
+	if cached == nil || time.Now().After(cached.ExpiresAt) {
+		return nil, false
+	}
+
+	var report BaseReport
+	if err := json.Unmarshal(cached.Report, &report); err != nil {
+		log.Printf("Error decoding cached report: %v", err)
+		return nil, false
+	}
+	return &report, true
+}
+
+func (c *storeReportCache) Set(ctx context.Context, ownerID int64, key string, report *BaseReport) {
+	data, err := json.Marshal(report)
+	if err != nil {
+		log.Printf("Error encoding report: %v", err)
+		return
+	}
+
+	err = c.store.SaveCachedReport(ctx, &model.CachedReport{
+		OwnerID:   ownerID,
+		Key:       key,
+		Report:    data,
+		ExpiresAt: time.Now().Add(c.ttl),
+	})
+	if err != nil {
+		log.Printf("Error saving cached report: %v", err)
+	}
+}
+
+func (c *storeReportCache) Invalidate(ctx context.Context, ownerID int64) {
+	if err := c.store.DeleteCachedReports(ctx, ownerID); err != nil {
+		log.Printf("Error deleting cached reports: %v", err)
+	}
+}
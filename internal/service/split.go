@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// ErrSplitMismatch возвращается, если сумма частей не равна сумме платежа
+var ErrSplitMismatch = fmt.Errorf("%w: split parts do not add up to total", model.ErrValidation)
+
+// splitPattern разбирает ввод вида "5000 Ашан: 3000 продукты, 2000 хозтовары"
+var splitPattern = regexp.MustCompile(`^\s*(\d+(?:[.,]\d+)?)\s*([^:]*):(.+)$`)
+
+// SplitPart - часть платежа, относящаяся к одной категории
+type SplitPart struct {
+	CategoryID   string
+	CategoryName string
+	Amount       float64
+}
+
+// IsSplitInput сообщает, похож ли текст на разделенный платеж
+func IsSplitInput(text string) bool {
+	return splitPattern.MatchString(text)
+}
+
+// ParseSplit разбирает разделенный платеж. Категории ищутся среди категорий расходов по названию
+func ParseSplit(text string, categories []model.Category) (float64, string, []SplitPart, error) {
+	m := splitPattern.FindStringSubmatch(text)
+	if m == nil {
+		return 0, "", nil, fmt.Errorf("invalid split format")
+	}
+	total, err := parseSplitAmount(m[1])
+	if err != nil {
+		return 0, "", nil, err
+	}
+	description := strings.TrimSpace(m[2])
+
+	var parts []SplitPart
+	for _, item := range strings.Split(m[3], ",") {
+		fields := strings.Fields(item)
+		if len(fields) < 2 {
+			return 0, "", nil, fmt.Errorf("ожидается сумма и категория: %q", strings.TrimSpace(item))
+		}
+		amount, err := parseSplitAmount(fields[0])
+		if err != nil {
+			return 0, "", nil, err
+		}
+		name := strings.Join(fields[1:], " ")
+		category := findExpenseCategory(name, categories)
+		if category == nil {
+			return 0, "", nil, fmt.Errorf("категория %q не найдена", name)
+		}
+		parts = append(parts, SplitPart{
+			CategoryID:   category.ID,
+			CategoryName: category.Name,
+			Amount:       amount,
+		})
+	}
+	return total, description, parts, nil
+}
+
+func parseSplitAmount(value string) (float64, error) {
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64)
+	if err != nil || amount <= 0 {
+		return 0, fmt.Errorf("неверная сумма: %q", value)
+	}
+	return amount, nil
+}
+
+// findExpenseCategory ищет категорию расходов по точному названию, затем по началу слова
+func findExpenseCategory(name string, categories []model.Category) *model.Category {
+	query := strings.ToLower(strings.TrimSpace(name))
+	for i, cat := range categories {
+		if cat.Type == "expense" && strings.ToLower(cat.Name) == query {
+			return &categories[i]
+		}
+	}
+	for i, cat := range categories {
+		if cat.Type == "expense" && strings.HasPrefix(strings.ToLower(cat.Name), query) {
+			return &categories[i]
+		}
+	}
+	return nil
+}
+
+// AddSplitTransaction записывает платеж, разделенный по категориям.
+// Платеж сохраняется родительской записью для истории, части - дочерними записями для отчетов
+func (s *ExpenseTracker) AddSplitTransaction(ctx context.Context, userID int64, accountID string, total float64, description string, parts []SplitPart) error {
+	if len(parts) < 2 {
+		return fmt.Errorf("split requires at least two parts")
+	}
+	sum := 0.0
+	for _, part := range parts {
+		sum += part.Amount
+	}
+	if math.Abs(sum-total) > 0.009 {
+		return fmt.Errorf("%w: %.2f != %.2f", ErrSplitMismatch, sum, total)
+	}
+
+	now := s.now()
+
+	parent := &model.Transaction{
+		UserID:      userID,
+		AccountID:   accountID,
+		Type:        model.TransactionExpense,
+		Amount:      -total,
+		Description: description,
+		IsSplit:     true,
+		Date:        now,
+		CreatedAt:   now,
+	}
+	parent.GenerateID()
+	// Платеж целиком помечен IsSplit: хуки его пропускают и получают только части
+	if err := s.createTransaction(ctx, parent); err != nil {
+		return fmt.Errorf("failed to create split transaction: %w", err)
+	}
+
+	// Хуки плагинов получают части платежа, как обычные транзакции по категориям
+	for _, part := range parts {
+		child := &model.Transaction{
+			UserID:      userID,
+			CategoryID:  part.CategoryID,
+			AccountID:   accountID,
+			ParentID:    parent.ID,
+			Type:        model.TransactionExpense,
+			Amount:      -part.Amount,
+			Description: description,
+			Date:        now,
+			CreatedAt:   now,
+		}
+		child.GenerateID()
+		if err := s.createTransaction(ctx, child); err != nil {
+			// Удаляем платеж целиком (части удалятся каскадно), чтобы не оставить его записанным частично
+			if delErr := s.repo.DeleteTransaction(ctx, parent.ID, userID); delErr != nil {
+				log.Printf("Error rolling back split transaction %s: %v", parent.ID, delErr)
+			}
+			return fmt.Errorf("failed to create split part: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+	"github.com/ivanoskov/financial_bot/internal/service/mocks"
+)
+
+// transactionRecorder - плагин, запоминающий транзакции из хука
+type transactionRecorder struct {
+	created []*model.Transaction
+}
+
+func (r *transactionRecorder) Name() string { return "recorder" }
+
+func (r *transactionRecorder) OnTransactionCreated(ctx context.Context, transaction *model.Transaction) error {
+	r.created = append(r.created, transaction)
+	return nil
+}
+
+func TestAddSplitTransaction(t *testing.T) {
+	now := time.Date(2026, 3, 20, 12, 0, 0, 0, msk)
+	repo := &mocks.Repository{}
+	recorder := &transactionRecorder{}
+	tracker := service.NewExpenseTracker(repo)
+	tracker.SetClock(func() time.Time { return now })
+	tracker.AddPlugin(recorder)
+
+	ctx := service.WithUpdateWrites(context.Background())
+	err := tracker.AddSplitTransaction(ctx, 1, "", 5000, "Ашан", []service.SplitPart{
+		{CategoryID: "food", Amount: 3000},
+		{CategoryID: "home", Amount: 2000},
+	})
+	if err != nil {
+		t.Fatalf("AddSplitTransaction: %v", err)
+	}
+
+	calls := repo.CallsOf("CreateTransaction")
+	if len(calls) != 3 {
+		t.Fatalf("записано %d транзакций, ожидалось 3", len(calls))
+	}
+	parent := calls[0].Args[0].(*model.Transaction)
+	if !parent.IsSplit || parent.Type != model.TransactionExpense || !parent.Date.Equal(now) {
+		t.Errorf("платеж целиком: %+v", parent)
+	}
+	// Хуки получают только части, иначе платеж посчитался бы дважды
+	if len(recorder.created) != 2 || recorder.created[0].ParentID != parent.ID || recorder.created[1].ParentID != parent.ID {
+		t.Errorf("хуки получили %d транзакций, ожидались 2 части платежа", len(recorder.created))
+	}
+	if !service.UpdateWrote(ctx) {
+		t.Errorf("запись платежа не отмечена")
+	}
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// qifDateLayouts - форматы дат в QIF: у разных банков и программ они отличаются
+var qifDateLayouts = []string{
+	"01/02/2006",
+	"1/2/2006",
+	"01/02'2006",
+	"1/2'2006",
+	"01/02'06",
+	"1/2'06",
+	"01/02/06",
+	"1/2/06",
+	"02.01.2006",
+	"2006-01-02",
+}
+
+// ofxTransactionStart и ofxTransactionEnd выделяют блоки операций; закрывающий тег
+// в SGML-версии OFX необязателен, тогда блок заканчивается на следующей операции
+var (
+	ofxTransactionStart = regexp.MustCompile(`(?i)<STMTTRN>`)
+	ofxTransactionEnd   = regexp.MustCompile(`(?i)</STMTTRN>|</BANKTRANLIST>`)
+)
+
+// ofxFieldPattern выделяет значение поля до следующего тега или конца строки
+var ofxFieldPattern = regexp.MustCompile(`(?i)<(DTPOSTED|TRNAMT|NAME|MEMO)>([^<\r\n]*)`)
+
+// ImportLine - операция выписки, подготовленная к импорту
+type ImportLine struct {
+	Date        time.Time `json:"date"`
+	Amount      float64   `json:"amount"`
+	Description string    `json:"description"`
+	Group       int       `json:"group"` // индекс в StatementImport.Groups
+}
+
+// ImportGroup - операции с одинаковым описанием, которым назначается одна категория
+type ImportGroup struct {
+	Name       string  `json:"name"`
+	Type       string  `json:"type"`
+	Count      int     `json:"count"`
+	Total      float64 `json:"total"`
+	CategoryID string  `json:"category_id,omitempty"` // предложенная или выбранная категория
+	Skip       bool    `json:"skip,omitempty"`
+}
+
+// StatementImport - выписка, подготовленная к импорту: новые операции, сгруппированные по описанию
+type StatementImport struct {
+	Lines      []ImportLine  `json:"lines"`
+	Groups     []ImportGroup `json:"groups"`
+	Total      int           `json:"total"`      // операций в выписке
+	Duplicates int           `json:"duplicates"` // операций, уже записанных в боте
+}
+
+// ParseOFX разбирает выписку в формате OFX/QFX (SGML и XML версии)
+func ParseOFX(r io.Reader) ([]StatementLine, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read statement: %w", err)
+	}
+
+	var lines []StatementLine
+	for _, block := range ofxTransactionStart.Split(string(data), -1)[1:] {
+		if end := ofxTransactionEnd.FindStringIndex(block); end != nil {
+			block = block[:end[0]]
+		}
+
+		fields := make(map[string]string)
+		for _, field := range ofxFieldPattern.FindAllStringSubmatch(block, -1) {
+			fields[strings.ToUpper(field[1])] = strings.TrimSpace(field[2])
+		}
+
+		// Дата в формате YYYYMMDD[HHMMSS[.XXX]][[TZ]], используем только день
+		posted := fields["DTPOSTED"]
+		if len(posted) < 8 {
+			return nil, fmt.Errorf("invalid date: %q", posted)
+		}
+		date, err := time.ParseInLocation("20060102", posted[:8], time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date: %q", posted)
+		}
+		amount, err := parseStatementAmount(fields["TRNAMT"])
+		if err != nil {
+			return nil, err
+		}
+
+		description := fields["NAME"]
+		if description == "" {
+			description = fields["MEMO"]
+		}
+		lines = append(lines, StatementLine{Date: date, Amount: amount, Description: unescapeOFX(description)})
+	}
+
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("statement has no operations")
+	}
+	return lines, nil
+}
+
+func unescapeOFX(value string) string {
+	return strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">").Replace(value)
+}
+
+// ParseQIF разбирает выписку в формате QIF: записи из строк D (дата), T (сумма),
+// P (получатель), M (комментарий), разделенные строкой "^"
+func ParseQIF(r io.Reader) ([]StatementLine, error) {
+	var (
+		lines   []StatementLine
+		current StatementLine
+		memo    string
+		hasData bool
+	)
+
+	flush := func() {
+		if hasData {
+			if current.Description == "" {
+				current.Description = memo
+			}
+			lines = append(lines, current)
+		}
+		current, memo, hasData = StatementLine{}, "", false
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "!") {
+			continue
+		}
+
+		value := strings.TrimSpace(line[1:])
+		switch line[0] {
+		case 'D':
+			date, err := parseQIFDate(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			current.Date = date
+			hasData = true
+		case 'T', 'U':
+			amount, err := parseQIFAmount(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			current.Amount = amount
+		case 'P':
+			current.Description = value
+		case 'M':
+			memo = value
+		case '^':
+			flush()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read statement: %w", err)
+	}
+	flush()
+
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("statement has no operations")
+	}
+	return lines, nil
+}
+
+func parseQIFDate(value string) (time.Time, error) {
+	value = strings.ReplaceAll(value, " ", "")
+	for _, layout := range qifDateLayouts {
+		if date, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %q", value)
+}
+
+// parseQIFAmount разбирает сумму; запятая считается разделителем тысяч, если в сумме есть точка
+func parseQIFAmount(value string) (float64, error) {
+	if strings.Contains(value, ".") {
+		value = strings.ReplaceAll(value, ",", "")
+	}
+	return parseStatementAmount(value)
+}
+
+// PrepareStatementImport отбрасывает операции, которые уже есть в боте, и группирует остальные
+// по описанию с предложенной категорией. Дубликатом считается транзакция с той же суммой,
+// датой в пределах reconcileDateWindow и похожим описанием
+func (s *ExpenseTracker) PrepareStatementImport(ctx context.Context, userID int64, lines []StatementLine) (*StatementImport, error) {
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("statement is empty")
+	}
+
+	start, end := lines[0].Date, lines[0].Date
+	for _, line := range lines {
+		if line.Date.Before(start) {
+			start = line.Date
+		}
+		if line.Date.After(end) {
+			end = line.Date
+		}
+	}
+	start = start.Add(-reconcileDateWindow)
+	end = end.Add(reconcileDateWindow)
+
+	existing, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{
+		StartDate: &start,
+		EndDate:   &end,
+		Logical:   true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	history, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{Limit: quickAddHistoryLimit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	result := &StatementImport{Total: len(lines)}
+	used := make([]bool, len(existing))
+	groups := make(map[string]int)
+	for _, line := range lines {
+		if isDuplicateLine(line, existing, used) {
+			result.Duplicates++
+			continue
+		}
+
+		categoryType := "expense"
+		if line.Amount > 0 {
+			categoryType = "income"
+		}
+		key := categoryType + "|" + normalizeDescription(line.Description)
+		index, ok := groups[key]
+		if !ok {
+			group := ImportGroup{Name: line.Description, Type: categoryType}
+			if group.Name == "" {
+				group.Name = "Без описания"
+			}
+			if category := matchCategory(model.ActiveCategories(categories), history, categoryType, line.Description); category != nil {
+				group.CategoryID = category.ID
+			}
+			index = len(result.Groups)
+			groups[key] = index
+			result.Groups = append(result.Groups, group)
+		}
+		result.Groups[index].Count++
+		result.Groups[index].Total += math.Abs(line.Amount)
+
+		result.Lines = append(result.Lines, ImportLine{
+			Date:        line.Date,
+			Amount:      line.Amount,
+			Description: line.Description,
+			Group:       index,
+		})
+	}
+	return result, nil
+}
+
+// ApplyStatementImport записывает подготовленные операции с выбранными категориями.
+// Операции без категории попадают в "Прочее", пропущенные группы не записываются
+func (s *ExpenseTracker) ApplyStatementImport(ctx context.Context, userID int64, imp *StatementImport) (int, error) {
+	fallback := make(map[string]string)
+	imported := 0
+	for _, line := range imp.Lines {
+		group := imp.Groups[line.Group]
+		if group.Skip {
+			continue
+		}
+
+		categoryID := group.CategoryID
+		if categoryID == "" {
+			if fallback[group.Type] == "" {
+				id, err := s.ensureCategory(ctx, userID, statementCategoryName, group.Type)
+				if err != nil {
+					return imported, err
+				}
+				fallback[group.Type] = id
+			}
+			categoryID = fallback[group.Type]
+		}
+
+		if err := s.createStatementTransaction(ctx, userID, categoryID, line.Date, line.Amount, line.Description); err != nil {
+			return imported, fmt.Errorf("failed to create transaction: %w", err)
+		}
+		imported++
+	}
+	return imported, nil
+}
+
+// isDuplicateLine ищет среди еще не сопоставленных транзакций запись той же операции
+func isDuplicateLine(line StatementLine, existing []model.Transaction, used []bool) bool {
+	for i, t := range existing {
+		if used[i] || math.Abs(t.Amount-line.Amount) > reconcileAmountEpsilon {
+			continue
+		}
+		diff := t.Date.Sub(line.Date)
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > reconcileDateWindow || !similarDescriptions(t.Description, line.Description) {
+			continue
+		}
+		used[i] = true
+		return true
+	}
+	return false
+}
+
+// similarDescriptions сравнивает описания без учета регистра, цифр и знаков.
+// Пустое описание в боте совпадает с любым: пользователи часто не подписывают траты
+func similarDescriptions(a, b string) bool {
+	a, b = normalizeDescription(a), normalizeDescription(b)
+	if a == "" || b == "" {
+		return true
+	}
+	return strings.Contains(a, b) || strings.Contains(b, a)
+}
+
+// normalizeDescription убирает из описания номера карт, терминалов и прочие цифры и знаки
+func normalizeDescription(description string) string {
+	fields := strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	return strings.Join(fields, " ")
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// ErrStateTransition - переход в состояние не разрешен из текущего
+var ErrStateTransition = errors.New("state transition is not allowed")
+
+// GetUserState возвращает текущее состояние пользователя. Состояние, на которое
+// пользователь не ответил вовремя, удаляется: иначе оно перехватило бы сообщение,
+// отправленное через несколько дней совсем по другому поводу
+func (s *ExpenseTracker) GetUserState(ctx context.Context, userID int64) (*model.UserState, error) {
+	state, err := s.repo.GetUserState(ctx, userID)
+	if err != nil || state == nil {
+		return state, err
+	}
+	if state.Expired(time.Now()) {
+		if err := s.repo.DeleteUserState(ctx, userID); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return state, nil
+}
+
+// SaveUserState начинает сценарий: переводит пользователя в начальное состояние из любого
+func (s *ExpenseTracker) SaveUserState(ctx context.Context, state *model.UserState) error {
+	if !state.AwaitingAction.Entry() {
+		return fmt.Errorf("%w: %q does not start a scenario", ErrStateTransition, state.AwaitingAction)
+	}
+	return s.repo.SaveUserState(ctx, state)
+}
+
+// TransitionUserState продолжает сценарий: переводит пользователя из current в next
+func (s *ExpenseTracker) TransitionUserState(ctx context.Context, current, next *model.UserState) error {
+	var from model.StateAction
+	if current != nil {
+		from = current.AwaitingAction
+	}
+	if !model.CanTransition(from, next.AwaitingAction) {
+		return fmt.Errorf("%w: %q -> %q", ErrStateTransition, from, next.AwaitingAction)
+	}
+	return s.repo.SaveUserState(ctx, next)
+}
+
+// DeleteUserState удаляет состояние пользователя
+func (s *ExpenseTracker) DeleteUserState(ctx context.Context, userID int64) error {
+	return s.repo.DeleteUserState(ctx, userID)
+}
+
+// PruneUserStates удаляет состояния, брошенные пользователями. Истекшее состояние и так
+// не используется, очистка только не дает таблице расти
+func (s *ExpenseTracker) PruneUserStates(ctx context.Context, now time.Time) error {
+	return s.repo.DeleteUserStatesBefore(ctx, now.Add(-model.MaxStateTTL()))
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// maxStreakDays - глубина поиска последнего расхода для серии дней без трат
+const maxStreakDays = 90
+
+// NoSpendStreak возвращает количество дней подряд без расходов, включая текущий день.
+// Если расходов не было весь период поиска, возвращается maxStreakDays
+func (s *ExpenseTracker) NoSpendStreak(ctx context.Context, userID int64, now time.Time) (int, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	start := today.AddDate(0, 0, -maxStreakDays)
+	end := today.AddDate(0, 0, 1).Add(-time.Nanosecond)
+
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{
+		StartDate: &start,
+		EndDate:   &end,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	var lastExpense time.Time
+	for _, t := range transactions {
+		if !t.IsIncome() && t.Date.After(lastExpense) {
+			lastExpense = t.Date
+		}
+	}
+	if lastExpense.IsZero() {
+		return maxStreakDays, nil
+	}
+
+	lastDay := time.Date(lastExpense.Year(), lastExpense.Month(), lastExpense.Day(), 0, 0, 0, 0, now.Location())
+	return int(today.Sub(lastDay).Hours() / 24), nil
+}
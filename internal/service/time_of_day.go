@@ -0,0 +1,142 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// Ночные покупки - с nightStart до nightEnd часов
+const (
+	nightStart = 23
+	nightEnd   = 5
+)
+
+// HourStats - расходы за час суток или за часть суток
+type HourStats struct {
+	Expenses float64
+	Count    int
+}
+
+// TimeOfDay - расходы периода по часам суток. Расходы без времени в разбивку не входят:
+// записанные до того, как бот стал хранить время покупки, и импортированные из выписок
+type TimeOfDay struct {
+	Hours         [24]HourStats
+	Untimed       int       // расходов без времени
+	Night         HourStats // ночные покупки
+	PrevNight     HourStats // ночные покупки предыдущего периода
+	NightCategory string    // категория, где больше всего ночных трат
+}
+
+// IsNightHour сообщает, что покупка в этот час считается ночной
+func IsNightHour(hour int) bool {
+	return hour >= nightStart || hour < nightEnd
+}
+
+// NightHours возвращает границы ночи для подписей: "23:00-05:00"
+func NightHours() string {
+	return fmt.Sprintf("%02d:00-%02d:00", nightStart, nightEnd)
+}
+
+// Timed возвращает сумму расходов со временем
+func (t TimeOfDay) Timed() float64 {
+	total := 0.0
+	for _, hour := range t.Hours {
+		total += hour.Expenses
+	}
+	return total
+}
+
+// PeakHour возвращает час с наибольшими расходами, -1 - расходов со временем нет
+func (t TimeOfDay) PeakHour() int {
+	peak := -1
+	for hour, stats := range t.Hours {
+		if stats.Expenses > 0 && (peak < 0 || stats.Expenses > t.Hours[peak].Expenses) {
+			peak = hour
+		}
+	}
+	return peak
+}
+
+// NightShare возвращает долю ночных покупок в расходах со временем, в процентах
+func (t TimeOfDay) NightShare() float64 {
+	timed := t.Timed()
+	if timed == 0 {
+		return 0
+	}
+	return t.Night.Expenses / timed * 100
+}
+
+// NightChange возвращает изменение ночных трат к предыдущему периоду в процентах. Если
+// в прошлом периоде ночных покупок не было, изменение не считается: у записей, сделанных
+// до того, как бот стал хранить время, ночные покупки не видны, и рост был бы мнимым
+func (t TimeOfDay) NightChange() float64 {
+	if t.PrevNight.Count == 0 {
+		return 0
+	}
+	return calculateTrendPercent(t.Night.Expenses, t.PrevNight.Expenses)
+}
+
+// hasTime сообщает, что у транзакции записано время. Раньше дата транзакции обрезалась
+// до начала дня, и у таких записей, как и у импортированных из выписок, время ровно 00:00
+func hasTime(date time.Time) bool {
+	return date.Hour() != 0 || date.Minute() != 0 || date.Second() != 0 || date.Nanosecond() != 0
+}
+
+// fillTimeOfDay раскладывает расходы по часам суток и считает ночные покупки. Часы
+// считаются в часовом поясе пользователя: сервер обычно работает в UTC, и вечерняя
+// покупка в Москве иначе попала бы в другой час, а ночная - в обычные
+func (s *ExpenseTracker) fillTimeOfDay(report *BaseReport, location *time.Location, currentTransactions, prevTransactions []model.Transaction, categories []model.Category) {
+	names := make(map[string]string, len(categories))
+	parents := make(map[string]string, len(categories))
+	for _, cat := range categories {
+		names[cat.ID] = cat.Name
+		parents[cat.ID] = cat.ParentID
+	}
+
+	stats := &report.TimeOfDay
+	*stats = TimeOfDay{}
+	nightByCategory := make(map[string]float64)
+	for _, t := range currentTransactions {
+		if t.IsIncome() || t.Date.Before(report.StartDate) || t.Date.After(report.EndDate) {
+			continue
+		}
+		date := t.Date.In(location)
+		if !hasTime(date) {
+			stats.Untimed++
+			continue
+		}
+		hour := &stats.Hours[date.Hour()]
+		hour.Expenses += t.AbsAmount()
+		hour.Count++
+		if IsNightHour(date.Hour()) {
+			stats.Night.Expenses += t.AbsAmount()
+			stats.Night.Count++
+			// Подкатегории считаются вместе с родителем
+			categoryID := t.CategoryID
+			if parent := parents[categoryID]; parent != "" {
+				categoryID = parent
+			}
+			nightByCategory[categoryID] += t.AbsAmount()
+		}
+	}
+	top := 0.0
+	for categoryID, amount := range nightByCategory {
+		if amount > top && names[categoryID] != "" {
+			top, stats.NightCategory = amount, names[categoryID]
+		}
+	}
+
+	prev := Period{Start: report.StartDate, End: report.EndDate}.Previous()
+	for _, t := range prevTransactions {
+		if t.IsIncome() || t.Date.Before(prev.Start) || t.Date.After(prev.End) {
+			continue
+		}
+		date := t.Date.In(location)
+		if hasTime(date) && IsNightHour(date.Hour()) {
+			stats.PrevNight.Expenses += t.AbsAmount()
+			stats.PrevNight.Count++
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+const (
+	// processedUpdateTTL - сколько помнить обработанные обновления. Telegram повторяет
+	// доставку webhook'а в течение нескольких часов, дальше повторов не бывает
+	processedUpdateTTL = 24 * time.Hour
+	// processedUpdatePruneInterval - как часто удалять записи старше processedUpdateTTL
+	processedUpdatePruneInterval = time.Hour
+)
+
+// BeginUpdate отмечает обновление Telegram обработанным. Возвращает false, если оно
+// уже обрабатывалось: повторная доставка не должна, например, записать транзакцию дважды.
+// Отметка ставится до обработки, чтобы повтор, пришедший во время нее, был пропущен.
+// Если обработка не удалась, отметку снимает AbortUpdate
+func (s *ExpenseTracker) BeginUpdate(ctx context.Context, updateID int) (bool, error) {
+	now := s.now()
+	fresh, err := s.repo.MarkUpdateProcessed(ctx, &model.ProcessedUpdate{
+		UpdateID:    int64(updateID),
+		ProcessedAt: now,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// Очисткой занимается только первое обновление после истечения интервала
+	last := s.lastUpdatePrune.Load()
+	if now.Sub(time.Unix(0, last)) >= processedUpdatePruneInterval &&
+		s.lastUpdatePrune.CompareAndSwap(last, now.UnixNano()) {
+		if err := s.repo.DeleteProcessedUpdates(ctx, now.Add(-processedUpdateTTL)); err != nil {
+			log.Printf("Error pruning processed updates: %v", err)
+		}
+	}
+	return fresh, nil
+}
+
+// AbortUpdate снимает отметку BeginUpdate с обновления, которое не удалось обработать,
+// чтобы повторная доставка Telegram обработала его заново
+func (s *ExpenseTracker) AbortUpdate(ctx context.Context, updateID int) error {
+	return s.repo.DeleteProcessedUpdate(ctx, int64(updateID))
+}
+
+type updateWritesKey struct{}
+
+// WithUpdateWrites начинает учет записей за время обработки обновления: сервис отмечает
+// в контексте каждую запись, повтор которой создал бы дубль
+func WithUpdateWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, updateWritesKey{}, new(atomic.Bool))
+}
+
+// UpdateWrote сообщает, что обработка обновления уже сохранила новые данные. Такое
+// обновление нельзя обрабатывать повторно, даже если обработка потом оборвалась
+func UpdateWrote(ctx context.Context) bool {
+	wrote, _ := ctx.Value(updateWritesKey{}).(*atomic.Bool)
+	return wrote != nil && wrote.Load()
+}
+
+// markWrote отмечает, что обработка обновления сохранила новые данные
+func markWrote(ctx context.Context) {
+	if wrote, _ := ctx.Value(updateWritesKey{}).(*atomic.Bool); wrote != nil {
+		wrote.Store(true)
+	}
+}
@@ -0,0 +1,95 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+	"github.com/ivanoskov/financial_bot/internal/service/mocks"
+)
+
+func TestBeginUpdatePrunesByTime(t *testing.T) {
+	now := time.Date(2026, 3, 20, 12, 0, 0, 0, msk)
+	repo := &mocks.Repository{}
+	tracker := service.NewExpenseTracker(repo)
+	tracker.SetClock(func() time.Time { return now })
+
+	begin := func(updateID int) {
+		t.Helper()
+		fresh, err := tracker.BeginUpdate(context.Background(), updateID)
+		if err != nil || !fresh {
+			t.Fatalf("BeginUpdate(%d) = %v, %v, ожидалось новое обновление", updateID, fresh, err)
+		}
+	}
+
+	// Первое обновление после запуска чистит старые записи, следующие в течение часа - нет,
+	// какими бы ни были их номера
+	begin(7)
+	begin(500)
+	now = now.Add(59 * time.Minute)
+	begin(1000)
+	if calls := repo.CallsOf("DeleteProcessedUpdates"); len(calls) != 1 {
+		t.Fatalf("очисток за первый час: %d, ожидалась 1", len(calls))
+	}
+
+	now = now.Add(time.Minute)
+	begin(1001)
+	calls := repo.CallsOf("DeleteProcessedUpdates")
+	if len(calls) != 2 {
+		t.Fatalf("очисток через час: %d, ожидалось 2", len(calls))
+	}
+	if before := calls[1].Args[0].(time.Time); !before.Equal(now.Add(-24 * time.Hour)) {
+		t.Errorf("удаляются записи до %v, ожидалось до %v", before, now.Add(-24*time.Hour))
+	}
+}
+
+func TestAbortUpdate(t *testing.T) {
+	repo := &mocks.Repository{}
+	tracker := service.NewExpenseTracker(repo)
+
+	if err := tracker.AbortUpdate(context.Background(), 42); err != nil {
+		t.Fatalf("AbortUpdate: %v", err)
+	}
+	calls := repo.CallsOf("DeleteProcessedUpdate")
+	if len(calls) != 1 || calls[0].Args[0] != int64(42) {
+		t.Errorf("снята отметка %v, ожидалось обновление 42", calls)
+	}
+}
+
+func TestUpdateWrote(t *testing.T) {
+	failed := true
+	repo := &mocks.Repository{
+		GetCategoriesFunc: func(ctx context.Context, userID int64) ([]model.Category, error) {
+			return []model.Category{{ID: "food", Name: "Продукты", Type: model.TransactionExpense}}, nil
+		},
+		CreateTransactionFunc: func(ctx context.Context, transaction *model.Transaction) error {
+			if failed {
+				return model.ErrStorageUnavailable
+			}
+			return nil
+		},
+	}
+	tracker := service.NewExpenseTracker(repo)
+
+	// Неудачная запись не мешает обработать повторную доставку
+	ctx := service.WithUpdateWrites(context.Background())
+	if _, err := tracker.AddTransaction(ctx, 1, "food", "", 300, ""); err == nil {
+		t.Fatalf("AddTransaction без базы завершилась успешно")
+	}
+	if service.UpdateWrote(ctx) {
+		t.Errorf("неудачная запись отмечена в обновлении")
+	}
+
+	failed = false
+	if _, err := tracker.AddTransaction(ctx, 1, "food", "", 300, ""); err != nil {
+		t.Fatalf("AddTransaction: %v", err)
+	}
+	if !service.UpdateWrote(ctx) {
+		t.Errorf("сохраненная транзакция не отмечена в обновлении")
+	}
+	if service.UpdateWrote(context.Background()) {
+		t.Errorf("запись отмечена вне обработки обновления")
+	}
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// UserArchive - все данные пользователя, которые удаляет DeleteUserData
+type UserArchive struct {
+	ExportedAt   time.Time                  `json:"exported_at"`
+	User         *model.User                `json:"user,omitempty"`
+	Settings     *model.UserSettings        `json:"settings,omitempty"`
+	Ledger       *model.LedgerMember        `json:"ledger,omitempty"`
+	Categories   []model.Category           `json:"categories"`
+	Transactions []model.Transaction        `json:"transactions"`
+	Budgets      []model.Budget             `json:"budgets"`
+	Goals        []model.Goal               `json:"goals"`
+	Plans        []model.PlannedExpense     `json:"planned_expenses"`
+	Wishlist     []model.WishlistItem       `json:"wishlist"`
+	Allocations  []model.WishlistAllocation `json:"wishlist_allocations"`
+	Accounts     []model.Account            `json:"accounts"`
+	Assets       []model.Asset              `json:"assets"`
+	Holdings     []model.Holding            `json:"holdings"`
+	NetWorth     []model.NetWorthSnapshot   `json:"net_worth"`
+	Webhooks     []model.Webhook            `json:"webhooks"`
+	Events       []model.Event              `json:"events"`
+	Reminders    []model.Reminder           `json:"reminders"`
+	Ledgers      []LedgerArchive            `json:"ledgers"`
+}
+
+// LedgerArchive - данные дополнительной книги учета
+type LedgerArchive struct {
+	Ledger       model.Ledger        `json:"ledger"`
+	Categories   []model.Category    `json:"categories"`
+	Transactions []model.Transaction `json:"transactions"`
+	Budgets      []model.Budget      `json:"budgets"`
+	Accounts     []model.Account     `json:"accounts"`
+	Events       []model.Event       `json:"events"`
+}
+
+// ExportUserData собирает все личные данные пользователя. Данные общего бюджета,
+// в котором пользователь участник, принадлежат владельцу и в архив не входят
+func (s *ExpenseTracker) ExportUserData(ctx context.Context, userID int64) (*UserArchive, error) {
+	// Запросы идут мимо общего бюджета: в архив попадает то, что хранится под ID пользователя
+	repo := s.ledger.Repository
+	archive := &UserArchive{ExportedAt: s.now()}
+
+	var err error
+	if archive.User, err = repo.GetUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if archive.Settings, err = repo.GetUserSettings(ctx, userID); err != nil {
+		return nil, err
+	}
+	if archive.Settings != nil {
+		archive.Settings.PINHash = ""
+	}
+	if archive.Ledger, err = repo.GetLedgerMember(ctx, userID); err != nil {
+		return nil, err
+	}
+	if archive.Categories, err = repo.GetCategories(ctx, userID); err != nil {
+		return nil, err
+	}
+	if archive.Transactions, err = exportTransactions(ctx, repo, userID); err != nil {
+		return nil, err
+	}
+	if archive.Budgets, err = repo.GetBudgets(ctx, userID); err != nil {
+		return nil, err
+	}
+	if archive.Goals, err = repo.GetGoals(ctx, userID); err != nil {
+		return nil, err
+	}
+	if archive.Plans, err = repo.GetPlannedExpenses(ctx, userID, time.Time{}); err != nil {
+		return nil, err
+	}
+	if archive.Wishlist, err = repo.GetWishlist(ctx, userID); err != nil {
+		return nil, err
+	}
+	if archive.Allocations, err = repo.GetWishlistAllocations(ctx, userID); err != nil {
+		return nil, err
+	}
+	if archive.Accounts, err = repo.GetAccounts(ctx, userID); err != nil {
+		return nil, err
+	}
+	if archive.Assets, err = repo.GetAssets(ctx, userID); err != nil {
+		return nil, err
+	}
+	if archive.Holdings, err = repo.GetHoldings(ctx, userID); err != nil {
+		return nil, err
+	}
+	if archive.NetWorth, err = repo.GetNetWorthSnapshots(ctx, userID, 0); err != nil {
+		return nil, err
+	}
+	if archive.Webhooks, err = repo.GetWebhooks(ctx, userID); err != nil {
+		return nil, err
+	}
+	// Ключ подписи - секрет, а не данные пользователя
+	for i := range archive.Webhooks {
+		archive.Webhooks[i].Secret = ""
+	}
+	if archive.Events, err = repo.GetEvents(ctx, userID); err != nil {
+		return nil, err
+	}
+	if archive.Reminders, err = repo.GetReminders(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	ledgers, err := repo.GetLedgers(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, ledger := range ledgers {
+		data := LedgerArchive{Ledger: ledger}
+		if data.Categories, err = repo.GetCategories(ctx, ledger.DataID); err != nil {
+			return nil, err
+		}
+		if data.Transactions, err = exportTransactions(ctx, repo, ledger.DataID); err != nil {
+			return nil, err
+		}
+		if data.Budgets, err = repo.GetBudgets(ctx, ledger.DataID); err != nil {
+			return nil, err
+		}
+		if data.Accounts, err = repo.GetAccounts(ctx, ledger.DataID); err != nil {
+			return nil, err
+		}
+		if data.Events, err = repo.GetEvents(ctx, ledger.DataID); err != nil {
+			return nil, err
+		}
+		archive.Ledgers = append(archive.Ledgers, data)
+	}
+	return archive, nil
+}
+
+// exportTransactions возвращает все записи: части разделенных платежей и сами платежи
+func exportTransactions(ctx context.Context, repo Repository, userID int64) ([]model.Transaction, error) {
+	transactions, err := repo.GetTransactions(ctx, userID, model.TransactionFilter{})
+	if err != nil {
+		return nil, err
+	}
+	purchases, err := repo.GetTransactions(ctx, userID, model.TransactionFilter{Logical: true})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range purchases {
+		if t.IsSplit {
+			transactions = append(transactions, t)
+		}
+	}
+	return transactions, nil
+}
+
+// DeleteUserData безвозвратно удаляет все данные пользователя
+func (s *ExpenseTracker) DeleteUserData(ctx context.Context, userID int64) error {
+	// Участники бюджета пользователя возвращаются к личному учету
+	members, err := s.repo.GetLedgerMembers(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get ledger members: %w", err)
+	}
+
+	// Книги учета удаляются вместе с пользователем
+	ledgers, err := s.repo.GetLedgers(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get ledgers: %w", err)
+	}
+	dataIDs := []int64{userID}
+	for _, ledger := range ledgers {
+		dataIDs = append(dataIDs, ledger.DataID)
+	}
+	attachments, err := s.storedAttachments(ctx, dataIDs...)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteUserData(ctx, userID); err != nil {
+		return err
+	}
+	s.deleteAttachments(ctx, attachments...)
+
+	s.ledger.forget(ctx, userID)
+	for _, member := range members {
+		s.ledger.forget(ctx, member.MemberID)
+	}
+	s.seenAnnouncements.Delete(userID)
+	s.seenUsers.Delete(userID)
+	s.menuSessions.Delete(userID)
+	if s.reports != nil {
+		for _, dataID := range dataIDs {
+			s.reports.Invalidate(ctx, dataID)
+		}
+	}
+	return nil
+}
+
+// storedAttachments возвращает пути фото чеков, сохраненных под ключами данных dataIDs.
+// Фото лежат в хранилище файлов, пути к ним известны только из транзакций
+func (s *ExpenseTracker) storedAttachments(ctx context.Context, dataIDs ...int64) ([]string, error) {
+	if s.attachments == nil {
+		return nil, nil
+	}
+	var attachments []string
+	for _, dataID := range dataIDs {
+		transactions, err := exportTransactions(ctx, s.ledger.Repository, dataID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get transactions: %w", err)
+		}
+		deleted, err := s.ledger.Repository.GetDeletedTransactions(ctx, dataID, time.Time{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get deleted transactions: %w", err)
+		}
+		for _, t := range append(transactions, deleted...) {
+			attachments = append(attachments, t.AttachmentPath)
+		}
+	}
+	return attachments, nil
+}
@@ -0,0 +1,76 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+	"github.com/ivanoskov/financial_bot/internal/service"
+	"github.com/ivanoskov/financial_bot/internal/service/mocks"
+)
+
+func TestExportUserData(t *testing.T) {
+	now := time.Date(2026, 3, 20, 12, 0, 0, 0, msk)
+	reminders := []model.Reminder{
+		{ID: "r1", UserID: 1, Text: "записать траты", Schedule: model.ReminderDaily, Hour: 21},
+		{ID: "r2", UserID: 1, Text: "оплатить интернет", Schedule: model.ReminderMonthly, Day: 15, Hour: 10},
+	}
+	repo := &mocks.Repository{
+		GetRemindersFunc: func(ctx context.Context, userID int64) ([]model.Reminder, error) {
+			return reminders, nil
+		},
+		GetUserSettingsFunc: func(ctx context.Context, userID int64) (*model.UserSettings, error) {
+			settings := &model.UserSettings{UserID: userID}
+			settings.PINHash = "hash"
+			return settings, nil
+		},
+		GetWebhooksFunc: func(ctx context.Context, userID int64) ([]model.Webhook, error) {
+			return []model.Webhook{{ID: "w1", UserID: userID, URL: "https://example.com/hook", Secret: "secret"}}, nil
+		},
+	}
+	tracker := service.NewExpenseTracker(repo)
+	tracker.SetClock(func() time.Time { return now })
+
+	archive, err := tracker.ExportUserData(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("ExportUserData: %v", err)
+	}
+	if !archive.ExportedAt.Equal(now) {
+		t.Errorf("время выгрузки %v, ожидалось %v", archive.ExportedAt, now)
+	}
+	if calls := repo.CallsOf("GetReminders"); len(calls) != 1 || calls[0].Args[0] != int64(1) {
+		t.Errorf("напоминания запрошены %v, ожидался запрос пользователя 1", calls)
+	}
+	if len(archive.Reminders) != len(reminders) {
+		t.Fatalf("в архиве %d напоминаний, ожидалось %d", len(archive.Reminders), len(reminders))
+	}
+	for i, reminder := range archive.Reminders {
+		if reminder.ID != reminders[i].ID || reminder.Text != reminders[i].Text {
+			t.Errorf("напоминание %d: %+v, ожидалось %+v", i, reminder, reminders[i])
+		}
+	}
+
+	// Секреты в архив не попадают
+	if archive.Settings.PINHash != "" {
+		t.Errorf("в архиве хэш PIN-кода")
+	}
+	if archive.Webhooks[0].Secret != "" {
+		t.Errorf("в архиве ключ подписи webhook'а")
+	}
+
+	data, err := json.Marshal(archive)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	var decoded struct {
+		Reminders []model.Reminder `json:"reminders"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if len(decoded.Reminders) != len(reminders) {
+		t.Errorf("в JSON %d напоминаний, ожидалось %d", len(decoded.Reminders), len(reminders))
+	}
+}
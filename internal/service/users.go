@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// userSeenInterval - как часто обновлять время активности одного пользователя
+const userSeenInterval = time.Hour
+
+// RegisterUser записывает пользователя в реестр при /start
+func (s *ExpenseTracker) RegisterUser(ctx context.Context, user *model.User) error {
+	user.LastSeen = time.Now()
+	if err := s.repo.SaveUser(ctx, user); err != nil {
+		return err
+	}
+	s.seenUsers.Store(user.ID, user.LastSeen)
+	return nil
+}
+
+// TouchUser обновляет время активности и профиль пользователя не чаще userSeenInterval.
+// Пользователи, начавшие работу до появления реестра, попадают в него при первой активности
+func (s *ExpenseTracker) TouchUser(ctx context.Context, user *model.User) error {
+	now := time.Now()
+	if seen, ok := s.seenUsers.Load(user.ID); ok && now.Sub(seen.(time.Time)) < userSeenInterval {
+		return nil
+	}
+
+	user.LastSeen = now
+	if err := s.repo.SaveUser(ctx, user); err != nil {
+		return err
+	}
+	s.seenUsers.Store(user.ID, now)
+	return nil
+}
+
+// MenuSession возвращает номер текущей сессии меню пользователя: кнопки, отправленные
+// в прошлых сессиях, устарели. Номер кэшируется до следующего /start
+func (s *ExpenseTracker) MenuSession(ctx context.Context, userID int64) (int, error) {
+	if session, ok := s.menuSessions.Load(userID); ok {
+		return session.(int), nil
+	}
+	user, err := s.repo.GetUser(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user: %w", err)
+	}
+	session := 0
+	if user != nil {
+		session = user.MenuSession
+	}
+	s.menuSessions.Store(userID, session)
+	return session, nil
+}
+
+// CachedMenuSession возвращает номер сессии меню, если он уже загружен MenuSession
+func (s *ExpenseTracker) CachedMenuSession(userID int64) (int, bool) {
+	session, ok := s.menuSessions.Load(userID)
+	if !ok {
+		return 0, false
+	}
+	return session.(int), true
+}
+
+// StartMenuSession начинает новую сессию меню при /start. Номер читается из базы, а не
+// из кэша: в serverless-режиме прошлый /start мог обработать другой экземпляр функции
+func (s *ExpenseTracker) StartMenuSession(ctx context.Context, userID int64) (int, error) {
+	user, err := s.repo.GetUser(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user: %w", err)
+	}
+	session := 1
+	if user != nil {
+		session = user.MenuSession + 1
+	}
+	if err := s.repo.SetMenuSession(ctx, userID, session); err != nil {
+		return 0, err
+	}
+	s.menuSessions.Store(userID, session)
+	return session, nil
+}
+
+// CurrentMenuSession сообщает, что кнопка из сессии session не устарела. Кнопка из более
+// новой сессии значит, что /start обработал другой экземпляр функции, и кэш обновляется
+func (s *ExpenseTracker) CurrentMenuSession(ctx context.Context, userID int64, session int) (bool, error) {
+	current, err := s.MenuSession(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if session > current {
+		s.menuSessions.Store(userID, session)
+	}
+	return session >= current, nil
+}
+
+// UserStats - сводка по пользователям бота
+type UserStats struct {
+	Total       int
+	NewWeek     int // зарегистрировались за 7 дней
+	ActiveDay   int // были активны за сутки
+	ActiveWeek  int // были активны за 7 дней
+	ActiveMonth int // были активны за 30 дней
+	Languages   []LanguageCount
+}
+
+// LanguageCount - число пользователей с языком интерфейса Telegram
+type LanguageCount struct {
+	Language string
+	Users    int
+}
+
+// GetUserStats считает пользователей по дате регистрации, активности и языку
+func (s *ExpenseTracker) GetUserStats(ctx context.Context, now time.Time) (*UserStats, error) {
+	users, err := s.repo.GetUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	stats := &UserStats{Total: len(users)}
+	languages := make(map[string]int)
+	for _, user := range users {
+		if now.Sub(user.CreatedAt) <= 7*24*time.Hour {
+			stats.NewWeek++
+		}
+		switch since := now.Sub(user.LastSeen); {
+		case since <= 24*time.Hour:
+			stats.ActiveDay++
+			fallthrough
+		case since <= 7*24*time.Hour:
+			stats.ActiveWeek++
+			fallthrough
+		case since <= 30*24*time.Hour:
+			stats.ActiveMonth++
+		}
+
+		language := user.LanguageCode
+		if language == "" {
+			language = "?"
+		}
+		languages[language]++
+	}
+
+	for language, count := range languages {
+		stats.Languages = append(stats.Languages, LanguageCount{Language: language, Users: count})
+	}
+	sort.Slice(stats.Languages, func(i, j int) bool {
+		if stats.Languages[i].Users != stats.Languages[j].Users {
+			return stats.Languages[i].Users > stats.Languages[j].Users
+		}
+		return stats.Languages[i].Language < stats.Languages[j].Language
+	})
+	return stats, nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+const (
+	// maxWebhooks - максимальное количество webhook'ов у пользователя
+	maxWebhooks = 3
+	// webhookDeliveryTimeout - общее время на доставку события с повторами
+	webhookDeliveryTimeout = 30 * time.Second
+)
+
+var (
+	// ErrInvalidWebhookURL возвращается для URL, на который нельзя отправлять события
+	ErrInvalidWebhookURL = fmt.Errorf("%w: invalid webhook url", model.ErrValidation)
+	// ErrTooManyWebhooks возвращается при превышении maxWebhooks
+	ErrTooManyWebhooks = fmt.Errorf("%w: too many webhooks", model.ErrValidation)
+)
+
+// WebhookSender доставляет подписанные события на URL пользователя
+type WebhookSender interface {
+	Send(ctx context.Context, url, secret, event string, body []byte) error
+}
+
+// SetWebhookSender подключает доставку исходящих webhook'ов
+func (s *ExpenseTracker) SetWebhookSender(sender WebhookSender) {
+	s.webhooks = sender
+}
+
+// GetWebhooks возвращает webhook'и пользователя
+func (s *ExpenseTracker) GetWebhooks(ctx context.Context, userID int64) ([]model.Webhook, error) {
+	return s.repo.GetWebhooks(ctx, userID)
+}
+
+// RegisterWebhook сохраняет URL для событий о транзакциях и возвращает webhook с ключом подписи
+func (s *ExpenseTracker) RegisterWebhook(ctx context.Context, userID int64, rawURL string) (*model.Webhook, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+
+	existing, err := s.repo.GetWebhooks(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	if len(existing) >= maxWebhooks {
+		return nil, ErrTooManyWebhooks
+	}
+
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	webhook := &model.Webhook{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		URL:       parsed.String(),
+		Secret:    hex.EncodeToString(secret),
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.CreateWebhook(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	markWrote(ctx)
+	return webhook, nil
+}
+
+// DeleteWebhook удаляет webhook пользователя
+func (s *ExpenseTracker) DeleteWebhook(ctx context.Context, webhookID string, userID int64) error {
+	return s.repo.DeleteWebhook(ctx, webhookID, userID)
+}
+
+// PingWebhook синхронно отправляет тестовое событие, чтобы пользователь проверил интеграцию
+func (s *ExpenseTracker) PingWebhook(ctx context.Context, webhookID string, userID int64) error {
+	if s.webhooks == nil {
+		return fmt.Errorf("webhook sender is not configured")
+	}
+	webhooks, err := s.repo.GetWebhooks(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	for _, w := range webhooks {
+		if w.ID != webhookID {
+			continue
+		}
+		body, err := json.Marshal(model.WebhookPayload{Event: model.WebhookEventPing, SentAt: time.Now()})
+		if err != nil {
+			return fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		return s.webhooks.Send(ctx, w.URL, w.Secret, model.WebhookEventPing, body)
+	}
+	return fmt.Errorf("webhook %s not found: %w", webhookID, model.ErrNotFound)
+}
+
+// WaitWebhooks дожидается доставки отправленных событий.
+// В serverless-режиме вызывается перед завершением обработчика, иначе доставка может не успеть
+func (s *ExpenseTracker) WaitWebhooks() {
+	s.deliveries.Wait()
+}
+
+// notifyWebhooks отправляет событие о транзакции на webhook'и бюджета в фоне
+func (s *ExpenseTracker) notifyWebhooks(ctx context.Context, event string, transaction *model.Transaction) {
+	if s.webhooks == nil {
+		return
+	}
+
+	webhooks, err := s.repo.GetWebhooks(ctx, transaction.UserID)
+	if err != nil {
+		log.Printf("Error getting webhooks: %v", err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(model.WebhookPayload{
+		Event:       event,
+		Transaction: transaction,
+		SentAt:      time.Now(),
+	})
+	if err != nil {
+		log.Printf("Error marshaling webhook payload: %v", err)
+		return
+	}
+
+	// Доставка не должна задерживать ответ пользователю и не зависит от отмены запроса
+	ctx = context.WithoutCancel(ctx)
+	for _, w := range webhooks {
+		s.deliveries.Add(1)
+		go func(w model.Webhook) {
+			defer s.deliveries.Done()
+			ctx, cancel := context.WithTimeout(ctx, webhookDeliveryTimeout)
+			defer cancel()
+			if err := s.webhooks.Send(ctx, w.URL, w.Secret, event, body); err != nil {
+				log.Printf("Error delivering webhook %s: %v", w.ID, err)
+			}
+		}(w)
+	}
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+const (
+	// MaxWishlistItems - сколько покупок может быть в списке желаний
+	MaxWishlistItems = 20
+	// wishlistPaceMonths - за сколько последних месяцев считается темп накоплений
+	wishlistPaceMonths = 3
+)
+
+// WishlistProgress - покупка из списка желаний с накопленной суммой и прогнозом
+type WishlistProgress struct {
+	Item  model.WishlistItem
+	Saved float64
+	// MonthlyPace - сколько в среднем откладывается на покупку в месяц за последние
+	// wishlistPaceMonths месяцев или с добавления покупки, если она моложе
+	MonthlyPace float64
+	// AffordableAt - когда при текущем темпе наберется вся сумма. Нулевое время -
+	// на покупку еще ничего не откладывали и срок неизвестен
+	AffordableAt time.Time
+}
+
+// Remaining возвращает сумму, которую осталось отложить
+func (p WishlistProgress) Remaining() float64 {
+	return math.Max(p.Item.TargetAmount-p.Saved, 0)
+}
+
+// Affordable сообщает, что на покупку уже отложена вся сумма
+func (p WishlistProgress) Affordable() bool {
+	return p.Remaining() == 0
+}
+
+// GetWishlist возвращает список желаний с накопленными суммами и сроками покупки
+func (s *ExpenseTracker) GetWishlist(ctx context.Context, userID int64) ([]WishlistProgress, error) {
+	items, err := s.repo.GetWishlist(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wishlist: %w", err)
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	allocations, err := s.repo.GetWishlistAllocations(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wishlist allocations: %w", err)
+	}
+
+	now := s.now()
+	progress := make([]WishlistProgress, 0, len(items))
+	for _, item := range items {
+		progress = append(progress, wishlistProgress(item, allocations, now))
+	}
+	return progress, nil
+}
+
+// wishlistProgress считает накопленное на покупку и срок при текущем темпе
+func wishlistProgress(item model.WishlistItem, allocations []model.WishlistAllocation, now time.Time) WishlistProgress {
+	progress := WishlistProgress{Item: item}
+	paceStart := now.AddDate(0, -wishlistPaceMonths, 0)
+	if item.CreatedAt.After(paceStart) {
+		paceStart = item.CreatedAt
+	}
+	var recent float64
+	for _, allocation := range allocations {
+		if allocation.ItemID != item.ID {
+			continue
+		}
+		progress.Saved += allocation.Amount
+		if !allocation.CreatedAt.Before(paceStart) {
+			recent += allocation.Amount
+		}
+	}
+
+	// Покупка моложе месяца считается месячной: иначе первая отложенная сумма
+	// давала бы завышенный темп
+	months := math.Max(now.Sub(paceStart).Hours()/24/30.44, 1)
+	progress.MonthlyPace = recent / months
+	switch {
+	case progress.Affordable():
+		progress.AffordableAt = now
+	case progress.MonthlyPace > 0:
+		progress.AffordableAt = now.AddDate(0, int(math.Ceil(progress.Remaining()/progress.MonthlyPace)), 0)
+	}
+	return progress
+}
+
+// AddWishlistItem добавляет покупку в список желаний
+func (s *ExpenseTracker) AddWishlistItem(ctx context.Context, userID int64, name string, target float64) (*model.WishlistItem, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: wishlist item name is empty", model.ErrValidation)
+	}
+	if target <= 0 {
+		return nil, fmt.Errorf("%w: wishlist target must be positive", model.ErrValidation)
+	}
+	items, err := s.repo.GetWishlist(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wishlist: %w", err)
+	}
+	if len(items) >= MaxWishlistItems {
+		return nil, fmt.Errorf("%w: at most %d wishlist items", model.ErrValidation, MaxWishlistItems)
+	}
+
+	item := &model.WishlistItem{
+		UserID:       userID,
+		Name:         name,
+		TargetAmount: target,
+		CreatedAt:    time.Now(),
+	}
+	if err := s.repo.CreateWishlistItem(ctx, item); err != nil {
+		return nil, fmt.Errorf("failed to create wishlist item: %w", err)
+	}
+	markWrote(ctx)
+	return item, nil
+}
+
+// DeleteWishlistItem удаляет покупку из списка желаний, например когда она куплена
+func (s *ExpenseTracker) DeleteWishlistItem(ctx context.Context, userID int64, id string) error {
+	return s.repo.DeleteWishlistItem(ctx, id, userID)
+}
+
+// AllocateToWishlist откладывает сумму на покупку и возвращает обновленный прогноз
+func (s *ExpenseTracker) AllocateToWishlist(ctx context.Context, userID int64, itemID string, amount float64) (*WishlistProgress, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: allocation must be positive", model.ErrValidation)
+	}
+	items, err := s.repo.GetWishlist(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wishlist: %w", err)
+	}
+	var item *model.WishlistItem
+	for i := range items {
+		if items[i].ID == itemID {
+			item = &items[i]
+			break
+		}
+	}
+	if item == nil {
+		return nil, fmt.Errorf("wishlist item %s not found: %w", itemID, model.ErrNotFound)
+	}
+
+	allocation := &model.WishlistAllocation{
+		ItemID:    itemID,
+		UserID:    userID,
+		Amount:    amount,
+		CreatedAt: s.now(),
+	}
+	if err := s.repo.CreateWishlistAllocation(ctx, allocation); err != nil {
+		return nil, fmt.Errorf("failed to create wishlist allocation: %w", err)
+	}
+	markWrote(ctx)
+	allocations, err := s.repo.GetWishlistAllocations(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wishlist allocations: %w", err)
+	}
+	updated := wishlistProgress(*item, allocations, s.now())
+	return &updated, nil
+}
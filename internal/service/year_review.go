@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ivanoskov/financial_bot/internal/model"
+)
+
+// yearReviewCategories - сколько крупнейших категорий расходов итоги года показывают отдельно,
+// остальные собираются в «Другое»
+const yearReviewCategories = 5
+
+// yearOtherColor - серый цвет «Другого» на графиках, чтобы оно не спорило с категориями
+const yearOtherColor = "#bdbdbd"
+
+// YearCategory - расходы категории за год по месяцам
+type YearCategory struct {
+	Name    string
+	Emoji   string
+	Color   string
+	Monthly []float64 // суммы по месяцам YearReview.Months
+	Total   float64
+	Share   float64 // доля в расходах года, 0..1
+}
+
+// PeakMonth возвращает индекс месяца с наибольшими тратами в категории
+func (c YearCategory) PeakMonth() int {
+	peak := 0
+	for i, amount := range c.Monthly {
+		if amount > c.Monthly[peak] {
+			peak = i
+		}
+	}
+	return peak
+}
+
+// YearReview - итоги года: суммы по месяцам и категориям и траты по дням
+type YearReview struct {
+	Year int
+	// Months - первые дни месяцев года. Незаконченный год - до текущего месяца включительно
+	Months   []time.Time
+	Income   []float64 // доходы по месяцам
+	Expenses []float64 // расходы по месяцам
+	// Categories - крупнейшие категории расходов по убыванию, последней - «Другое»
+	Categories []YearCategory
+	// Daily - расходы по дням с 1 января, до сегодняшнего дня в незаконченном году
+	Daily        []float64
+	Transactions int
+}
+
+// TotalIncome возвращает доходы за год
+func (r *YearReview) TotalIncome() float64 {
+	return sum(r.Income)
+}
+
+// TotalExpenses возвращает расходы за год
+func (r *YearReview) TotalExpenses() float64 {
+	return sum(r.Expenses)
+}
+
+// SavingsRate возвращает долю сохраненного дохода за год в процентах, 0 - без дохода
+func (r *YearReview) SavingsRate() float64 {
+	return PeriodStats{
+		TotalIncome: r.TotalIncome(),
+		Balance:     r.TotalIncome() - r.TotalExpenses(),
+	}.SavingsRate()
+}
+
+// MonthSavingsRate возвращает долю сохраненного дохода месяца в процентах
+func (r *YearReview) MonthSavingsRate(month int) float64 {
+	return PeriodStats{
+		TotalIncome: r.Income[month],
+		Balance:     r.Income[month] - r.Expenses[month],
+	}.SavingsRate()
+}
+
+// BestMonth возвращает индекс месяца с наибольшим балансом, WorstMonth - с наименьшим
+func (r *YearReview) BestMonth() int {
+	best := 0
+	for i := range r.Months {
+		if r.Income[i]-r.Expenses[i] > r.Income[best]-r.Expenses[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+// WorstMonth возвращает индекс месяца с наименьшим балансом
+func (r *YearReview) WorstMonth() int {
+	worst := 0
+	for i := range r.Months {
+		if r.Income[i]-r.Expenses[i] < r.Income[worst]-r.Expenses[worst] {
+			worst = i
+		}
+	}
+	return worst
+}
+
+func sum(values []float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// GetYearReview подводит итоги года: доходы и расходы по месяцам, лучший и худший месяц,
+// крупнейшие категории и траты по дням. Незаконченный год считается до сегодняшнего дня.
+// Подкатегории расходов считаются в родительской категории
+func (s *ExpenseTracker) GetYearReview(ctx context.Context, userID int64, year int) (*YearReview, error) {
+	now := s.now()
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, now.Location())
+	end := start.AddDate(1, 0, 0).Add(-time.Nanosecond)
+	if now.Before(end) {
+		end = now
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("%w: year %d has not started", model.ErrValidation, year)
+	}
+
+	categories, err := s.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	transactions, err := s.repo.GetTransactions(ctx, userID, model.TransactionFilter{
+		StartDate: &start,
+		EndDate:   &end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	months := int(end.Month())
+	review := &YearReview{
+		Year:     year,
+		Income:   make([]float64, months),
+		Expenses: make([]float64, months),
+		Daily:    make([]float64, end.YearDay()),
+	}
+	for i := 0; i < months; i++ {
+		review.Months = append(review.Months, start.AddDate(0, i, 0))
+	}
+
+	groupOf := make(map[string]string, len(categories))
+	for _, cat := range categories {
+		groupOf[cat.ID] = cat.ID
+		if cat.ParentID != "" {
+			groupOf[cat.ID] = cat.ParentID
+		}
+	}
+	groups := make(map[string]*YearCategory)
+	for _, t := range transactions {
+		date := t.Date.In(now.Location())
+		if date.Year() != year {
+			continue
+		}
+		month := int(date.Month()) - 1
+		if month >= months {
+			continue
+		}
+		review.Transactions++
+		if t.IsIncome() {
+			review.Income[month] += t.AbsAmount()
+			continue
+		}
+		review.Expenses[month] += t.AbsAmount()
+		if day := date.YearDay() - 1; day < len(review.Daily) {
+			review.Daily[day] += t.AbsAmount()
+		}
+
+		id := groupOf[t.CategoryID]
+		group, ok := groups[id]
+		if !ok {
+			group = &YearCategory{Name: "Без категории", Monthly: make([]float64, months)}
+			if cat := findCategory(categories, id); cat != nil {
+				group.Name, group.Emoji, group.Color = cat.Name, cat.Icon(), cat.Color
+			}
+			groups[id] = group
+		}
+		group.Monthly[month] += t.AbsAmount()
+		group.Total += t.AbsAmount()
+	}
+
+	for _, group := range groups {
+		review.Categories = append(review.Categories, *group)
+	}
+	sort.Slice(review.Categories, func(i, j int) bool {
+		return review.Categories[i].Total > review.Categories[j].Total
+	})
+	if len(review.Categories) > yearReviewCategories {
+		other := YearCategory{Name: "Другое", Color: yearOtherColor, Monthly: make([]float64, months)}
+		for _, group := range review.Categories[yearReviewCategories:] {
+			for i, amount := range group.Monthly {
+				other.Monthly[i] += amount
+			}
+			other.Total += group.Total
+		}
+		review.Categories = append(review.Categories[:yearReviewCategories], other)
+	}
+	if total := review.TotalExpenses(); total > 0 {
+		for i := range review.Categories {
+			review.Categories[i].Share = review.Categories[i].Total / total
+		}
+	}
+	return review, nil
+}
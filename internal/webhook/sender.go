@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// SignatureHeader - заголовок с HMAC-SHA256 подписью тела запроса
+	SignatureHeader = "X-Signature-256"
+	// EventHeader - заголовок с типом события
+	EventHeader = "X-Event"
+)
+
+// Sender доставляет события на URL пользователей с повторами при ошибках
+type Sender struct {
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+}
+
+// NewSender создает отправителя: до 4 попыток с экспоненциальной задержкой от 1 секунды
+func NewSender() *Sender {
+	return &Sender{
+		client:      &http.Client{Timeout: 10 * time.Second},
+		maxAttempts: 4,
+		backoff:     time.Second,
+	}
+}
+
+// Sign возвращает подпись тела запроса в формате "sha256=<hex>"
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send отправляет подписанное событие. Повторяет попытку при сетевых ошибках,
+// ответах 5xx и 429; остальные ответы 4xx считаются окончательными
+func (s *Sender) Send(ctx context.Context, url, secret, event string, body []byte) error {
+	delay := s.backoff
+	var lastErr error
+	for attempt := 1; attempt <= s.maxAttempts; attempt++ {
+		retry, err := s.send(ctx, url, secret, event, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry || attempt == s.maxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	return fmt.Errorf("failed to deliver webhook: %w", lastErr)
+}
+
+// send выполняет одну попытку доставки и сообщает, имеет ли смысл повторить
+func (s *Sender) send(ctx context.Context, url, secret, event string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(SignatureHeader, Sign(secret, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+}
@@ -25,8 +25,10 @@ CREATE TABLE transactions (
 CREATE TABLE IF NOT EXISTS user_states (
     user_id BIGINT PRIMARY KEY,
     selected_category_id TEXT,
+    selected_account_id TEXT,
     transaction_type TEXT,
     awaiting_action TEXT,
+    payload TEXT,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -39,9 +41,297 @@ CREATE INDEX idx_transactions_user_id ON transactions(user_id);
 CREATE INDEX idx_transactions_category_id ON transactions(category_id);
 CREATE INDEX idx_transactions_date ON transactions(date);
 
+-- Месячные бюджеты по категориям
+CREATE TABLE IF NOT EXISTS budgets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id BIGINT NOT NULL,
+    category_id UUID REFERENCES categories(id) ON DELETE CASCADE,
+    amount DECIMAL NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (user_id, category_id)
+);
+
+-- Финансовые цели (накопления)
+CREATE TABLE IF NOT EXISTS goals (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    target_amount DECIMAL NOT NULL CHECK (target_amount > 0),
+    saved_amount DECIMAL NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_budgets_user_id ON budgets(user_id);
+CREATE INDEX IF NOT EXISTS idx_goals_user_id ON goals(user_id);
+
+-- Предрасчитанная статистика пользователей (пересчитывается еженедельно)
+CREATE TABLE IF NOT EXISTS user_baselines (
+    user_id BIGINT PRIMARY KEY,
+    daily_median DECIMAL NOT NULL DEFAULT 0,
+    daily_p90 DECIMAL NOT NULL DEFAULT 0,
+    category_medians JSONB NOT NULL DEFAULT '{}',
+    computed_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Счета (наличные, карты, вклады)
+CREATE TABLE IF NOT EXISTS accounts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('cash', 'card', 'deposit')),
+    initial_balance DECIMAL NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS account_id UUID REFERENCES accounts(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_accounts_user_id ON accounts(user_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_account_id ON transactions(account_id);
+
+-- Общие бюджеты: участники и одноразовые приглашения.
+-- Данные общего бюджета хранятся под user_id владельца, author_id - кто добавил транзакцию
+CREATE TABLE IF NOT EXISTS ledger_members (
+    member_id BIGINT PRIMARY KEY,
+    owner_id BIGINT NOT NULL,
+    name TEXT,
+    joined_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS ledger_invites (
+    code TEXT PRIMARY KEY,
+    owner_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS author_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_ledger_members_owner_id ON ledger_members(owner_id);
+
+-- Персональные настройки пользователей
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id BIGINT PRIMARY KEY,
+    last_seen_version INT NOT NULL DEFAULT 0,
+    daily_report BOOLEAN NOT NULL DEFAULT true,
+    weekly_digest BOOLEAN NOT NULL DEFAULT true,
+    monthly_digest BOOLEAN NOT NULL DEFAULT true,
+    delivery_hour INT NOT NULL DEFAULT 21 CHECK (delivery_hour BETWEEN 0 AND 23),
+    quiet_days INT[] NOT NULL DEFAULT '{}',
+    skip_empty_days BOOLEAN NOT NULL DEFAULT false,
+    reminder BOOLEAN NOT NULL DEFAULT false,
+    reminder_hour INT NOT NULL DEFAULT 20 CHECK (reminder_hour BETWEEN 0 AND 23),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Разделение платежа по категориям: родительская запись хранит покупку целиком,
+-- дочерние - суммы по категориям. В отчетах учитываются только дочерние записи
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES transactions(id) ON DELETE CASCADE;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS is_split BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_parent_id ON transactions(parent_id);
+
+-- Активы и обязательства, которые не являются счетами (недвижимость, кредиты)
+CREATE TABLE IF NOT EXISTS assets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('asset', 'liability')),
+    amount DECIMAL NOT NULL CHECK (amount >= 0),
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Ежемесячные снимки капитала
+CREATE TABLE IF NOT EXISTS net_worth_snapshots (
+    user_id BIGINT NOT NULL,
+    month DATE NOT NULL,
+    accounts DECIMAL NOT NULL DEFAULT 0,
+    assets DECIMAL NOT NULL DEFAULT 0,
+    liabilities DECIMAL NOT NULL DEFAULT 0,
+    net_worth DECIMAL NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (user_id, month)
+);
+
+CREATE INDEX IF NOT EXISTS idx_assets_user_id ON assets(user_id);
+
+-- Исходящие webhook'и: события о транзакциях на URL пользователя
+CREATE TABLE IF NOT EXISTS webhooks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id BIGINT NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_user ON webhooks(user_id);
+
+-- Кэш готовых отчетов для serverless-режима, сбрасывается при изменении транзакций
+CREATE TABLE IF NOT EXISTS report_cache (
+    owner_id BIGINT NOT NULL,
+    key TEXT NOT NULL,
+    report JSONB NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (owner_id, key)
+);
+
+-- Обработанные обновления Telegram: повторно доставленные обновления пропускаются
+CREATE TABLE IF NOT EXISTS processed_updates (
+    update_id BIGINT PRIMARY KEY,
+    processed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_processed_updates_time ON processed_updates(processed_at);
+
+-- Пользователи бота: регистрируются при /start, last_seen обновляется при активности
+CREATE TABLE IF NOT EXISTS users (
+    id BIGINT PRIMARY KEY,
+    username TEXT,
+    first_name TEXT,
+    language_code TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Переносим пользователей, которые уже вели учет до появления таблицы
+INSERT INTO users (id, created_at, last_seen)
+SELECT user_id, MIN(created_at), MAX(created_at)
+FROM (
+    SELECT user_id, created_at FROM transactions
+    UNION ALL
+    SELECT author_id, created_at FROM transactions WHERE author_id IS NOT NULL
+) AS authors
+GROUP BY user_id
+ON CONFLICT (id) DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS idx_users_last_seen ON users(last_seen);
+
+-- Удаление всех данных пользователя одной транзакцией (/delete_me). Если пользователь -
+-- владелец общего бюджета, бюджет удаляется, а участники возвращаются к личному учету.
+-- В чужом общем бюджете записи остаются у владельца, но теряют связь с автором
+CREATE OR REPLACE FUNCTION delete_user_data(p_user_id BIGINT) RETURNS VOID
+LANGUAGE plpgsql AS $$
+BEGIN
+    DELETE FROM transactions WHERE user_id = p_user_id;
+    UPDATE transactions SET author_id = NULL WHERE author_id = p_user_id;
+    DELETE FROM budgets WHERE user_id = p_user_id;
+    DELETE FROM categories WHERE user_id = p_user_id;
+    DELETE FROM goals WHERE user_id = p_user_id;
+    DELETE FROM accounts WHERE user_id = p_user_id;
+    DELETE FROM assets WHERE user_id = p_user_id;
+    DELETE FROM net_worth_snapshots WHERE user_id = p_user_id;
+    DELETE FROM webhooks WHERE user_id = p_user_id;
+    DELETE FROM reminders WHERE user_id = p_user_id;
+    DELETE FROM announcement_deliveries WHERE user_id = p_user_id;
+    DELETE FROM user_baselines WHERE user_id = p_user_id;
+    DELETE FROM user_states WHERE user_id = p_user_id;
+    DELETE FROM user_settings WHERE user_id = p_user_id;
+    DELETE FROM ledger_members WHERE member_id = p_user_id OR owner_id = p_user_id;
+    DELETE FROM ledger_invites WHERE owner_id = p_user_id;
+    DELETE FROM report_cache WHERE owner_id = p_user_id;
+    DELETE FROM users WHERE id = p_user_id;
+END;
+$$;
+
+-- Блокировка финансовых данных PIN-кодом
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS pin_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS pin_idle_minutes INT NOT NULL DEFAULT 15 CHECK (pin_idle_minutes > 0);
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS pin_failures INT NOT NULL DEFAULT 0;
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS pin_unlocked_until TIMESTAMPTZ;
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS pin_blocked_until TIMESTAMPTZ;
+
+-- Явные состояния диалога: ввод суммы раньше хранился пустым awaiting_action
+UPDATE user_states SET awaiting_action = 'amount'
+    WHERE COALESCE(awaiting_action, '') = '' AND COALESCE(selected_category_id, '') <> '';
+CREATE INDEX IF NOT EXISTS idx_user_states_updated_at ON user_states(updated_at);
+
+-- Вид текста отчетов: подробный или краткий
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS report_layout TEXT NOT NULL DEFAULT 'detailed'
+    CHECK (report_layout IN ('detailed', 'compact'));
+
+-- Значок и цвет категории
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS emoji TEXT NOT NULL DEFAULT '';
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS color TEXT NOT NULL DEFAULT ''
+    CHECK (color = '' OR color ~ '^#[0-9a-fA-F]{6}$');
+
+-- Подкатегории: один уровень вложенности, при удалении родителя подкатегории
+-- становятся категориями верхнего уровня
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES categories(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);
+
+-- Архив категорий: категория скрыта из выбора, но история транзакций сохраняется
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS archived BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Порядок категорий: место в списке и сортировка по частоте использования
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS sort_order INT NOT NULL DEFAULT 0;
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS category_sort TEXT NOT NULL DEFAULT 'manual'
+    CHECK (category_sort IN ('manual', 'usage'));
+
+-- Лимит трат категории за месяц, 0 - без лимита
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS spending_limit DECIMAL NOT NULL DEFAULT 0
+    CHECK (spending_limit >= 0);
+
+-- Пересчет годового отчета по инфляции в цены текущего месяца
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS inflation_adjusted BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Напоминания пользователей по расписанию (/remind)
+CREATE TABLE IF NOT EXISTS reminders (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id BIGINT NOT NULL,
+    text TEXT NOT NULL,
+    schedule TEXT NOT NULL CHECK (schedule IN ('daily', 'weekly', 'monthly')),
+    day INT NOT NULL DEFAULT 0,
+    hour INT NOT NULL,
+    minute INT NOT NULL DEFAULT 0,
+    last_sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reminders_user_id ON reminders(user_id);
+
+-- Рассылки администраторов (/broadcast) и их доставка пользователям
+CREATE TABLE IF NOT EXISTS announcements (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    text TEXT NOT NULL,
+    segment TEXT NOT NULL DEFAULT 'all',
+    status TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'queued', 'done', 'canceled')),
+    created_by BIGINT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    queued_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS announcement_deliveries (
+    announcement_id UUID NOT NULL REFERENCES announcements(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    error TEXT,
+    delivered_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (announcement_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_announcements_status ON announcements(status);
+
+-- Направление транзакции хранится явно, а не только знаком суммы. Тип существующих
+-- транзакций берется из категории, у транзакций без категории - из знака суммы
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS type TEXT CHECK (type IN ('expense', 'income'));
+UPDATE transactions t SET type = COALESCE(
+        (SELECT c.type FROM categories c WHERE c.id = t.category_id),
+        CASE WHEN t.amount > 0 THEN 'income' ELSE 'expense' END)
+    WHERE t.type IS NULL;
+-- Знак суммы приводится к типу: расход отрицательный, доход положительный
+UPDATE transactions SET amount = CASE WHEN type = 'income' THEN ABS(amount) ELSE -ABS(amount) END
+    WHERE amount <> 0 AND (type = 'income') <> (amount > 0);
+ALTER TABLE transactions ALTER COLUMN type SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_transactions_user_type ON transactions(user_id, type);
+
+-- Название категории не пустое и не длиннее service.MaxCategoryNameLength символов.
+-- Уже существующие категории не проверяются, их можно переименовать в боте
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_name_length;
+ALTER TABLE categories ADD CONSTRAINT categories_name_length
+    CHECK (char_length(btrim(name)) BETWEEN 1 AND 32) NOT VALID;
+
 -- Добавление базовых категорий для тестирования
 INSERT INTO categories (user_id, name, type) VALUES
     (12345, 'Продукты', 'expense'),
     (12345, 'Транспорт', 'expense'),
     (12345, 'Развлечения', 'expense'),
-    (12345, 'Зарплата', 'income'); 
\ No newline at end of file
+    (12345, 'Зарплата', 'income');